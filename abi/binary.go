@@ -0,0 +1,510 @@
+package abi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// The binary representation of a contract is a compact, versioned encoding
+// of a parsed Contract. It stores the parsed types together with the
+// precomputed signatures, selectors and topics, so decoding it does not
+// require JSON parsing nor hashing. It is intended to be generated at build
+// time and embedded in binaries that load a large number of contracts.
+//
+// The format is not a stable interchange format. It may change between
+// versions of this package, in which case decoding fails with an error
+// and the representation must be generated again.
+
+// binaryMagic is the prefix of the binary representation of a contract,
+// followed by the format version.
+var binaryMagic = []byte("goeth-abi\x01")
+
+// Type tags used in the binary representation.
+const (
+	binTypeTuple byte = iota + 1
+	binTypeEventTuple
+	binTypeArray
+	binTypeFixedArray
+	binTypeBytes
+	binTypeString
+	binTypeFixedBytes
+	binTypeUint
+	binTypeInt
+	binTypeBool
+	binTypeAddress
+	binTypeAlias
+)
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+//
+// Only the built-in types are supported. Contracts that use custom types
+// cannot be encoded.
+func (c *Contract) MarshalBinary() ([]byte, error) {
+	e := &binEncoder{}
+	e.buf.Write(binaryMagic)
+	if c.Constructor != nil {
+		e.bool(true)
+		if err := e.typ(c.Constructor.inputs); err != nil {
+			return nil, err
+		}
+	} else {
+		e.bool(false)
+	}
+
+	// Methods are stored once and referenced by index from both maps.
+	var methods []*Method
+	methodIdx := map[*Method]int{}
+	for _, mm := range []map[string]*Method{c.MethodsBySignature, c.Methods} {
+		for _, k := range sortedKeys(mm) {
+			if _, ok := methodIdx[mm[k]]; !ok {
+				methodIdx[mm[k]] = len(methods)
+				methods = append(methods, mm[k])
+			}
+		}
+	}
+	e.uint(uint64(len(methods)))
+	for _, m := range methods {
+		e.string(m.name)
+		e.uint(uint64(m.stateMutability))
+		e.buf.Write(m.fourBytes[:])
+		e.string(m.signature)
+		if err := e.typ(m.inputs); err != nil {
+			return nil, err
+		}
+		if err := e.typ(m.outputs); err != nil {
+			return nil, err
+		}
+	}
+	for _, mm := range []map[string]*Method{c.Methods, c.MethodsBySignature} {
+		keys := sortedKeys(mm)
+		e.uint(uint64(len(keys)))
+		for _, k := range keys {
+			e.string(k)
+			e.uint(uint64(methodIdx[mm[k]]))
+		}
+	}
+
+	keys := sortedKeys(c.Events)
+	e.uint(uint64(len(keys)))
+	for _, k := range keys {
+		ev := c.Events[k]
+		e.string(k)
+		e.string(ev.name)
+		e.bool(ev.anonymous)
+		e.buf.Write(ev.topic0[:])
+		e.string(ev.signature)
+		if err := e.typ(ev.inputs); err != nil {
+			return nil, err
+		}
+	}
+
+	keys = sortedKeys(c.Errors)
+	e.uint(uint64(len(keys)))
+	for _, k := range keys {
+		er := c.Errors[k]
+		e.string(k)
+		e.string(er.name)
+		e.buf.Write(er.fourBytes[:])
+		e.string(er.signature)
+		if err := e.typ(er.inputs); err != nil {
+			return nil, err
+		}
+	}
+
+	keys = sortedKeys(c.Types)
+	e.uint(uint64(len(keys)))
+	for _, k := range keys {
+		e.string(k)
+		if err := e.typ(c.Types[k]); err != nil {
+			return nil, err
+		}
+	}
+	return e.buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler interface.
+//
+// The contract uses the Default ABI instance. Use ABI.ParseBinary to use
+// a different instance.
+func (c *Contract) UnmarshalBinary(data []byte) error {
+	p, err := Default.ParseBinary(data)
+	if err != nil {
+		return err
+	}
+	*c = *p
+	return nil
+}
+
+// LoadBinary loads the contract from the given file containing the binary
+// representation created by Contract.MarshalBinary.
+func LoadBinary(path string) (*Contract, error) {
+	return Default.LoadBinary(path)
+}
+
+// MustLoadBinary is like LoadBinary but panics on error.
+func MustLoadBinary(path string) *Contract {
+	return Default.MustLoadBinary(path)
+}
+
+// ParseBinary parses the binary representation of a contract created by
+// Contract.MarshalBinary.
+func ParseBinary(data []byte) (*Contract, error) {
+	return Default.ParseBinary(data)
+}
+
+// MustParseBinary is like ParseBinary but panics on error.
+func MustParseBinary(data []byte) *Contract {
+	return Default.MustParseBinary(data)
+}
+
+// LoadBinary loads the contract from the given file containing the binary
+// representation created by Contract.MarshalBinary.
+func (a *ABI) LoadBinary(path string) (*Contract, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return a.ParseBinary(data)
+}
+
+// MustLoadBinary is like LoadBinary but panics on error.
+func (a *ABI) MustLoadBinary(path string) *Contract {
+	c, err := a.LoadBinary(path)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// ParseBinary parses the binary representation of a contract created by
+// Contract.MarshalBinary.
+func (a *ABI) ParseBinary(data []byte) (*Contract, error) {
+	if !bytes.HasPrefix(data, binaryMagic) {
+		return nil, errors.New("abi: invalid binary contract representation or unsupported version")
+	}
+	d := &binDecoder{data: data[len(binaryMagic):]}
+	c := &Contract{
+		Methods:            make(map[string]*Method),
+		MethodsBySignature: make(map[string]*Method),
+		Events:             make(map[string]*Event),
+		Errors:             make(map[string]*Error),
+		Types:              make(map[string]Type),
+	}
+	if d.bool() {
+		c.Constructor = a.NewConstructor(d.tuple())
+	}
+
+	methods := make([]*Method, d.len())
+	for i := range methods {
+		if d.err != nil {
+			break
+		}
+		m := &Method{abi: a}
+		m.name = d.string()
+		m.stateMutability = StateMutability(d.uint())
+		copy(m.fourBytes[:], d.bytes(4))
+		m.signature = d.string()
+		m.inputs = d.tuple()
+		m.outputs = d.tuple()
+		methods[i] = m
+	}
+	for _, mm := range []map[string]*Method{c.Methods, c.MethodsBySignature} {
+		n := d.len()
+		for i := 0; i < n && d.err == nil; i++ {
+			k := d.string()
+			idx := d.uint()
+			if idx >= uint64(len(methods)) {
+				d.fail("invalid method index")
+				break
+			}
+			mm[k] = methods[idx]
+		}
+	}
+
+	n := d.len()
+	for i := 0; i < n && d.err == nil; i++ {
+		k := d.string()
+		ev := &Event{abi: a}
+		ev.name = d.string()
+		ev.anonymous = d.bool()
+		ev.topic0 = types.MustHashFromBytes(d.bytes(types.HashLength), types.PadNone)
+		ev.signature = d.string()
+		ev.inputs, _ = d.typ().(*EventTupleType)
+		if ev.inputs == nil {
+			d.fail("expected event tuple type")
+		}
+		c.Events[k] = ev
+	}
+
+	n = d.len()
+	for i := 0; i < n && d.err == nil; i++ {
+		k := d.string()
+		er := &Error{abi: a}
+		er.name = d.string()
+		copy(er.fourBytes[:], d.bytes(4))
+		er.signature = d.string()
+		er.inputs = d.tuple()
+		c.Errors[k] = er
+	}
+
+	n = d.len()
+	for i := 0; i < n && d.err == nil; i++ {
+		k := d.string()
+		c.Types[k] = d.typ()
+	}
+
+	if d.err == nil && len(d.data) > 0 {
+		d.fail("unexpected trailing data")
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("abi: cannot parse binary contract: %w", d.err)
+	}
+	return c, nil
+}
+
+// MustParseBinary is like ParseBinary but panics on error.
+func (a *ABI) MustParseBinary(data []byte) *Contract {
+	c, err := a.ParseBinary(data)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+type binEncoder struct {
+	buf bytes.Buffer
+	tmp [binary.MaxVarintLen64]byte
+}
+
+func (e *binEncoder) uint(v uint64) {
+	n := binary.PutUvarint(e.tmp[:], v)
+	e.buf.Write(e.tmp[:n])
+}
+
+func (e *binEncoder) bool(v bool) {
+	if v {
+		e.buf.WriteByte(1)
+	} else {
+		e.buf.WriteByte(0)
+	}
+}
+
+func (e *binEncoder) string(s string) {
+	e.uint(uint64(len(s)))
+	e.buf.WriteString(s)
+}
+
+func (e *binEncoder) typ(t Type) error {
+	switch t := t.(type) {
+	case *TupleType:
+		e.buf.WriteByte(binTypeTuple)
+		e.uint(uint64(len(t.elems)))
+		for _, elem := range t.elems {
+			e.string(elem.Name)
+			if err := e.typ(elem.Type); err != nil {
+				return err
+			}
+		}
+	case *EventTupleType:
+		e.buf.WriteByte(binTypeEventTuple)
+		e.uint(uint64(len(t.elems)))
+		for _, elem := range t.elems {
+			e.string(elem.Name)
+			e.bool(elem.Indexed)
+			if err := e.typ(elem.Type); err != nil {
+				return err
+			}
+		}
+	case *ArrayType:
+		e.buf.WriteByte(binTypeArray)
+		return e.typ(t.typ)
+	case *FixedArrayType:
+		e.buf.WriteByte(binTypeFixedArray)
+		e.uint(uint64(t.size))
+		return e.typ(t.typ)
+	case *BytesType:
+		e.buf.WriteByte(binTypeBytes)
+	case *StringType:
+		e.buf.WriteByte(binTypeString)
+	case *FixedBytesType:
+		e.buf.WriteByte(binTypeFixedBytes)
+		e.uint(uint64(t.size))
+	case *UintType:
+		e.buf.WriteByte(binTypeUint)
+		e.uint(uint64(t.size))
+	case *IntType:
+		e.buf.WriteByte(binTypeInt)
+		e.uint(uint64(t.size))
+	case *BoolType:
+		e.buf.WriteByte(binTypeBool)
+	case *AddressType:
+		e.buf.WriteByte(binTypeAddress)
+	case *AliasType:
+		e.buf.WriteByte(binTypeAlias)
+		e.string(t.alias)
+		return e.typ(t.typ)
+	default:
+		return fmt.Errorf("abi: cannot encode type %s to binary, only built-in types are supported", t)
+	}
+	return nil
+}
+
+// binDecoder decodes the binary representation. After the first error, all
+// methods return zero values and the error is stored in the err field.
+type binDecoder struct {
+	data  []byte
+	err   error
+	depth int
+}
+
+func (d *binDecoder) fail(msg string) {
+	if d.err == nil {
+		d.err = errors.New(msg)
+	}
+	d.data = nil
+}
+
+func (d *binDecoder) uint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.fail("invalid varint")
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+// len reads a length and verifies that it does not exceed the remaining
+// data, so corrupted input cannot cause large allocations.
+func (d *binDecoder) len() int {
+	v := d.uint()
+	if v > uint64(len(d.data)) {
+		d.fail("invalid length")
+		return 0
+	}
+	return int(v)
+}
+
+func (d *binDecoder) bool() bool {
+	b := d.bytes(1)
+	return len(b) == 1 && b[0] == 1
+}
+
+func (d *binDecoder) bytes(n int) []byte {
+	if d.err != nil {
+		return make([]byte, n)
+	}
+	if len(d.data) < n {
+		d.fail("unexpected end of data")
+		return make([]byte, n)
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *binDecoder) string() string {
+	return string(d.bytes(d.len()))
+}
+
+func (d *binDecoder) tuple() *TupleType {
+	t, ok := d.typ().(*TupleType)
+	if !ok {
+		d.fail("expected tuple type")
+		return NewTupleType()
+	}
+	return t
+}
+
+// maxBinTypeDepth limits the nesting of types to protect against stack
+// exhaustion on corrupted input.
+const maxBinTypeDepth = 64
+
+func (d *binDecoder) typ() Type {
+	if d.err != nil {
+		return nil
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+	if d.depth > maxBinTypeDepth {
+		d.fail("types nested too deeply")
+		return nil
+	}
+	tag := d.bytes(1)[0]
+	switch tag {
+	case binTypeTuple:
+		elems := make([]TupleTypeElem, d.len())
+		for i := range elems {
+			elems[i].Name = d.string()
+			elems[i].Type = d.typ()
+		}
+		return NewTupleType(elems...)
+	case binTypeEventTuple:
+		elems := make([]EventTupleElem, d.len())
+		for i := range elems {
+			elems[i].Name = d.string()
+			elems[i].Indexed = d.bool()
+			elems[i].Type = d.typ()
+		}
+		return NewEventTupleType(elems...)
+	case binTypeArray:
+		return NewArrayType(d.typ())
+	case binTypeFixedArray:
+		size := d.uint()
+		if size == 0 || size > math.MaxInt32 {
+			d.fail("invalid fixed array size")
+			return nil
+		}
+		return NewFixedArrayType(d.typ(), int(size))
+	case binTypeBytes:
+		return NewBytesType()
+	case binTypeString:
+		return NewStringType()
+	case binTypeFixedBytes:
+		size := d.uint()
+		if size == 0 || size > 32 {
+			d.fail("invalid fixed bytes size")
+			return nil
+		}
+		return NewFixedBytesType(int(size))
+	case binTypeUint, binTypeInt:
+		size := d.uint()
+		if size == 0 || size > 256 || size%8 != 0 {
+			d.fail("invalid integer size")
+			return nil
+		}
+		if tag == binTypeUint {
+			return NewUintType(int(size))
+		}
+		return NewIntType(int(size))
+	case binTypeBool:
+		return NewBoolType()
+	case binTypeAddress:
+		return NewAddressType()
+	case binTypeAlias:
+		alias := d.string()
+		return NewAliasType(alias, d.typ())
+	default:
+		d.fail(fmt.Sprintf("unknown type tag %d", tag))
+		return nil
+	}
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
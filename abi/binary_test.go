@@ -0,0 +1,104 @@
+package abi
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContract_MarshalBinary(t *testing.T) {
+	c, err := LoadJSON("testdata/abi.json")
+	require.NoError(t, err)
+
+	data, err := c.MarshalBinary()
+	require.NoError(t, err)
+
+	// The representation is deterministic.
+	data2, err := c.MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, data, data2)
+
+	b, err := ParseBinary(data)
+	require.NoError(t, err)
+
+	assert.Equal(t, c.Constructor.String(), b.Constructor.String())
+	require.Len(t, b.Methods, len(c.Methods))
+	require.Len(t, b.MethodsBySignature, len(c.MethodsBySignature))
+	for k, m := range c.Methods {
+		require.Contains(t, b.Methods, k)
+		assert.Equal(t, m.String(), b.Methods[k].String())
+		assert.Equal(t, m.Signature(), b.Methods[k].Signature())
+		assert.Equal(t, m.FourBytes(), b.Methods[k].FourBytes())
+		assert.Same(t, b.Methods[k], b.MethodsBySignature[m.Signature()])
+	}
+	require.Len(t, b.Events, len(c.Events))
+	for k, e := range c.Events {
+		require.Contains(t, b.Events, k)
+		assert.Equal(t, e.String(), b.Events[k].String())
+		assert.Equal(t, e.Topic0(), b.Events[k].Topic0())
+	}
+	require.Len(t, b.Errors, len(c.Errors))
+	for k, e := range c.Errors {
+		require.Contains(t, b.Errors, k)
+		assert.Equal(t, e.String(), b.Errors[k].String())
+		assert.Equal(t, e.FourBytes(), b.Errors[k].FourBytes())
+	}
+	require.Len(t, b.Types, len(c.Types))
+	for k, typ := range c.Types {
+		require.Contains(t, b.Types, k)
+		assert.Equal(t, typ.String(), b.Types[k].String())
+		assert.Equal(t, typ.CanonicalType(), b.Types[k].CanonicalType())
+	}
+
+	// Decoded contract can be used to encode and decode data.
+	assert.Equal(t, c.Methods["Foo"].MustEncodeArgs(42), b.Methods["Foo"].MustEncodeArgs(42))
+	var res uint64
+	b.Methods["Foo"].MustDecodeValues(c.Methods["Foo"].MustEncodeArgs(42)[4:], &res)
+	assert.Equal(t, uint64(42), res)
+
+	// UnmarshalBinary.
+	var u Contract
+	require.NoError(t, u.UnmarshalBinary(data))
+	assert.Equal(t, c.Methods["Bar"].String(), u.Methods["Bar"].String())
+}
+
+func TestParseBinary_Invalid(t *testing.T) {
+	c, err := LoadJSON("testdata/abi.json")
+	require.NoError(t, err)
+	data, err := c.MarshalBinary()
+	require.NoError(t, err)
+
+	// Every truncated representation must be rejected without panicking.
+	for i := 0; i < len(data); i++ {
+		_, err := ParseBinary(data[:i])
+		require.Error(t, err, "length %d", i)
+	}
+	_, err = ParseBinary(append(data, 0))
+	require.Error(t, err)
+}
+
+func BenchmarkParseJSON(b *testing.B) {
+	data, err := os.ReadFile("testdata/abi.json")
+	require.NoError(b, err)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseJSON(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseBinary(b *testing.B) {
+	c, err := LoadJSON("testdata/abi.json")
+	require.NoError(b, err)
+	data, err := c.MarshalBinary()
+	require.NoError(b, err)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseBinary(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/defiweb/go-eth/hexutil"
+	"github.com/defiweb/go-eth/types"
 )
 
 func TestABI_LoadJSON(t *testing.T) {
@@ -188,6 +189,34 @@ func TestContract_HandleError(t *testing.T) {
 	})
 }
 
+func TestContract_SelectorIndex(t *testing.T) {
+	c, err := ParseSignatures(
+		"function foo(uint256)",
+		"function bar(address) returns (uint256)",
+		"event Transfer(address indexed from, address indexed to, uint256 value)",
+		"event Anon(uint256) anonymous",
+		"error foo(uint256)",
+	)
+	require.NoError(t, err)
+
+	idx := c.SelectorIndex()
+	assert.Len(t, idx.Methods, 2)
+	assert.Len(t, idx.Events, 1)
+	assert.Len(t, idx.Errors, 1)
+
+	assert.Equal(t, c.Methods["foo"], idx.Method(c.Methods["foo"].MustEncodeArgs(1)))
+	assert.Equal(t, c.Methods["bar"], idx.Method(c.Methods["bar"].FourBytes().Bytes()))
+	assert.Nil(t, idx.Method([]byte{1, 2, 3, 4}))
+	assert.Nil(t, idx.Method([]byte{1}))
+
+	topic0 := types.MustHashFromHex("0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef", types.PadNone)
+	assert.Equal(t, c.Events["Transfer"], idx.Event([]types.Hash{topic0}))
+	assert.Nil(t, idx.Event(nil))
+
+	assert.Equal(t, c.Errors["foo"], idx.Error(hexutil.MustHexToBytes("0x2fbebd38000000000000000000000000000000000000000000000000000000000000012c")))
+	assert.Nil(t, idx.Error(hexutil.MustHexToBytes("0x08c379a0")))
+}
+
 func TestContract_RegisterTypes(t *testing.T) {
 	abi := NewABI()
 
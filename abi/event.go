@@ -92,6 +92,12 @@ func (e *Event) Inputs() *EventTupleType {
 	return e.inputs
 }
 
+// IsAnonymous returns true if the event is anonymous. Anonymous events do not
+// have the topic0 in their logs.
+func (e *Event) IsAnonymous() bool {
+	return e.anonymous
+}
+
 // Topic0 returns the first topic of the event, that is, the Keccak256 hash of
 // the event signature.
 func (e *Event) Topic0() types.Hash {
@@ -0,0 +1,302 @@
+package abi
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/hexutil"
+	"github.com/defiweb/go-eth/types"
+)
+
+// FormatOptions are the options for FormatValue and ParseValue.
+type FormatOptions struct {
+	// Decimals is the number of decimals of integer values. If greater than
+	// zero, integers are formatted as decimal fractions, e.g. 1500000000000000000
+	// with 18 decimals is formatted as 1.5.
+	Decimals int
+
+	// Symbol is an optional unit appended to integer values, e.g. "ETH".
+	Symbol string
+
+	// ChecksumAddresses enables EIP-55 checksummed addresses.
+	ChecksumAddresses bool
+
+	// Labels is an optional source of human-readable address labels, e.g.
+	// a labels.Registry. Labeled addresses are formatted as
+	// "Uniswap V3 Router (0xe592...)".
+	Labels AddressLabeler
+}
+
+// AddressLabeler provides human-readable labels for addresses.
+type AddressLabeler interface {
+	// Label returns the label of the address. The second return value is
+	// false if the address has no label.
+	Label(addr types.Address) (string, bool)
+}
+
+// FormatValue returns a human-readable representation of the value of the
+// given type, intended for logs and explorers. The value may be an abi.Value
+// or any value that can be mapped to the type.
+//
+// Values are formatted as follows:
+//
+//	uint, int      1500000000000000000, 1.5 or 1.5 ETH, depending on options
+//	address        0xabc... or 0xAbC... if ChecksumAddresses is set, or
+//	               Label (0xabc...) if Labels has a label for the address
+//	bool           true or false
+//	string         "quoted string"
+//	bytes, bytesN  0x-prefixed hex
+//	arrays         [3]uint8{1, 2, 3} or []uint8{1, 2, 3}
+//	tuples         (a: 1, b: true)
+//
+// Values of simple types can be parsed back using ParseValue with the same
+// options.
+func FormatValue(t Type, val any, opts FormatOptions) (string, error) {
+	return Default.FormatValue(t, val, opts)
+}
+
+// MustFormatValue is like FormatValue but panics on error.
+func MustFormatValue(t Type, val any, opts FormatOptions) string {
+	return Default.MustFormatValue(t, val, opts)
+}
+
+// ParseValue parses a string created by FormatValue. Only simple types are
+// supported: integers, addresses, booleans, strings and byte arrays.
+func ParseValue(t Type, s string, opts FormatOptions) (Value, error) {
+	return Default.ParseValue(t, s, opts)
+}
+
+// FormatValue returns a human-readable representation of the value of the
+// given type.
+//
+// See FormatValue for more information.
+func (a *ABI) FormatValue(t Type, val any, opts FormatOptions) (string, error) {
+	v, ok := val.(Value)
+	if !ok {
+		v = t.Value()
+		if err := a.Mapper.Map(val, v); err != nil {
+			return "", err
+		}
+	}
+	var sb strings.Builder
+	if err := formatValue(&sb, t, v, opts); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// MustFormatValue is like FormatValue but panics on error.
+func (a *ABI) MustFormatValue(t Type, val any, opts FormatOptions) string {
+	s, err := a.FormatValue(t, val, opts)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// ParseValue parses a string created by FormatValue.
+//
+// See ParseValue for more information.
+func (a *ABI) ParseValue(t Type, s string, opts FormatOptions) (Value, error) {
+	for {
+		alias, ok := t.(*AliasType)
+		if !ok {
+			break
+		}
+		t = alias.Type()
+	}
+	var src any
+	switch t.(type) {
+	case *UintType, *IntType:
+		bn, err := parseDecimal(s, opts)
+		if err != nil {
+			return nil, fmt.Errorf("abi: cannot parse %s value %q: %v", t, s, err)
+		}
+		src = bn
+	case *AddressType:
+		// Strip the label added by FormatValue, if any.
+		if i := strings.LastIndex(s, " ("); i >= 0 && strings.HasSuffix(s, ")") {
+			s = s[i+2 : len(s)-1]
+		}
+		addr, err := types.AddressFromHex(s)
+		if err != nil {
+			return nil, fmt.Errorf("abi: cannot parse %s value %q: %v", t, s, err)
+		}
+		if opts.ChecksumAddresses && s != addr.Checksum(crypto.Keccak256) {
+			return nil, fmt.Errorf("abi: cannot parse %s value %q: invalid checksum", t, s)
+		}
+		src = addr
+	case *BoolType:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("abi: cannot parse %s value %q: %v", t, s, err)
+		}
+		src = b
+	case *StringType:
+		str, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("abi: cannot parse %s value %q: %v", t, s, err)
+		}
+		src = str
+	case *BytesType, *FixedBytesType:
+		b, err := hexutil.HexToBytes(s)
+		if err != nil {
+			return nil, fmt.Errorf("abi: cannot parse %s value %q: %v", t, s, err)
+		}
+		src = b
+	default:
+		return nil, fmt.Errorf("abi: cannot parse %s value, only simple types are supported", t)
+	}
+	v := t.Value()
+	if err := a.Mapper.Map(src, v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func formatValue(sb *strings.Builder, t Type, v Value, opts FormatOptions) error {
+	if alias, ok := t.(*AliasType); ok {
+		return formatValue(sb, alias.Type(), v, opts)
+	}
+	switch v := v.(type) {
+	case *UintValue:
+		sb.WriteString(formatDecimal(&v.Int, opts))
+	case *IntValue:
+		sb.WriteString(formatDecimal(&v.Int, opts))
+	case *AddressValue:
+		addr := v.Address().String()
+		if opts.ChecksumAddresses {
+			addr = v.Address().Checksum(crypto.Keccak256)
+		}
+		if opts.Labels != nil {
+			if label, ok := opts.Labels.Label(v.Address()); ok {
+				addr = label + " (" + addr + ")"
+			}
+		}
+		sb.WriteString(addr)
+	case *BoolValue:
+		sb.WriteString(strconv.FormatBool(bool(*v)))
+	case *StringValue:
+		sb.WriteString(strconv.Quote(v.String()))
+	case *BytesValue:
+		sb.WriteString(hexutil.BytesToHex(v.Bytes()))
+	case *FixedBytesValue:
+		sb.WriteString(hexutil.BytesToHex(v.Bytes()))
+	case *ArrayValue:
+		at, ok := t.(*ArrayType)
+		if !ok {
+			return fmt.Errorf("abi: cannot format %T as %s", v, t)
+		}
+		sb.WriteString(goTypeString(t))
+		return formatElems(sb, at.ElementType(), v.Elems, opts)
+	case *FixedArrayValue:
+		at, ok := t.(*FixedArrayType)
+		if !ok {
+			return fmt.Errorf("abi: cannot format %T as %s", v, t)
+		}
+		sb.WriteString(goTypeString(t))
+		return formatElems(sb, at.ElementType(), *v, opts)
+	case *TupleValue:
+		tt, ok := t.(*TupleType)
+		if !ok || tt.Size() != len(*v) {
+			return fmt.Errorf("abi: cannot format %T as %s", v, t)
+		}
+		sb.WriteString("(")
+		for i, elem := range *v {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(elem.Name)
+			sb.WriteString(": ")
+			if err := formatValue(sb, tt.elems[i].Type, elem.Value, opts); err != nil {
+				return err
+			}
+		}
+		sb.WriteString(")")
+	default:
+		fmt.Fprint(sb, v)
+	}
+	return nil
+}
+
+func formatElems(sb *strings.Builder, t Type, elems []Value, opts FormatOptions) error {
+	sb.WriteString("{")
+	for i, elem := range elems {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		if err := formatValue(sb, t, elem, opts); err != nil {
+			return err
+		}
+	}
+	sb.WriteString("}")
+	return nil
+}
+
+// goTypeString returns the type name using Go array notation, e.g. uint8[2][3]
+// is returned as [3][2]uint8.
+func goTypeString(t Type) string {
+	switch t := t.(type) {
+	case *ArrayType:
+		return "[]" + goTypeString(t.ElementType())
+	case *FixedArrayType:
+		return "[" + strconv.Itoa(t.Size()) + "]" + goTypeString(t.ElementType())
+	default:
+		return t.String()
+	}
+}
+
+// formatDecimal formats the integer as a decimal fraction with the number of
+// decimals and the symbol given in options.
+func formatDecimal(x *big.Int, opts FormatOptions) string {
+	s := x.String()
+	if opts.Decimals > 0 {
+		neg := x.Sign() < 0
+		digits := strings.TrimPrefix(s, "-")
+		if len(digits) <= opts.Decimals {
+			digits = strings.Repeat("0", opts.Decimals-len(digits)+1) + digits
+		}
+		intPart := digits[:len(digits)-opts.Decimals]
+		fracPart := strings.TrimRight(digits[len(digits)-opts.Decimals:], "0")
+		s = intPart
+		if len(fracPart) > 0 {
+			s += "." + fracPart
+		}
+		if neg {
+			s = "-" + s
+		}
+	}
+	if opts.Symbol != "" {
+		s += " " + opts.Symbol
+	}
+	return s
+}
+
+// parseDecimal parses a string created by formatDecimal.
+func parseDecimal(s string, opts FormatOptions) (*big.Int, error) {
+	if opts.Symbol != "" {
+		s = strings.TrimSuffix(s, " "+opts.Symbol)
+	}
+	if opts.Decimals == 0 && (strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "-0x")) {
+		return hexutil.HexToBigInt(s)
+	}
+	intPart, fracPart, hasFrac := strings.Cut(s, ".")
+	if hasFrac && len(fracPart) == 0 {
+		return nil, fmt.Errorf("invalid number")
+	}
+	if len(fracPart) > opts.Decimals {
+		return nil, fmt.Errorf("too many decimal places")
+	}
+	digits := intPart + fracPart + strings.Repeat("0", opts.Decimals-len(fracPart))
+	if strings.ContainsAny(fracPart, "+-") {
+		return nil, fmt.Errorf("invalid number")
+	}
+	bn, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid number")
+	}
+	return bn, nil
+}
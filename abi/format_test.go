@@ -0,0 +1,132 @@
+package abi
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+func TestFormatValue(t *testing.T) {
+	addr := types.MustAddressFromHex("0xabcdef0123456789abcdef0123456789abcdef01")
+	eth := FormatOptions{Decimals: 18, Symbol: "ETH"}
+	tests := []struct {
+		typ  string
+		val  any
+		opts FormatOptions
+		want string
+	}{
+		{typ: "uint256", val: big.NewInt(1500000000000000000), want: "1500000000000000000"},
+		{typ: "uint256", val: big.NewInt(1500000000000000000), opts: eth, want: "1.5 ETH"},
+		{typ: "uint256", val: big.NewInt(1000000000000000000), opts: eth, want: "1 ETH"},
+		{typ: "uint256", val: big.NewInt(1), opts: eth, want: "0.000000000000000001 ETH"},
+		{typ: "uint256", val: big.NewInt(0), opts: eth, want: "0 ETH"},
+		{typ: "int256", val: big.NewInt(-250), opts: FormatOptions{Decimals: 2}, want: "-2.5"},
+		{typ: "address", val: addr, want: "0xabcdef0123456789abcdef0123456789abcdef01"},
+		{typ: "address", val: addr, opts: FormatOptions{ChecksumAddresses: true}, want: "0xabCDeF0123456789AbcdEf0123456789aBCDEF01"},
+		{typ: "bool", val: true, want: "true"},
+		{typ: "string", val: "a \"b\"", want: `"a \"b\""`},
+		{typ: "bytes", val: []byte{0xca, 0xfe}, want: "0xcafe"},
+		{typ: "bytes4", val: []byte{1, 2, 3, 4}, want: "0x01020304"},
+		{typ: "uint8[3]", val: []uint8{1, 2, 3}, want: "[3]uint8{1, 2, 3}"},
+		{typ: "uint8[]", val: []uint8{1, 2}, want: "[]uint8{1, 2}"},
+		{typ: "(uint256 a, bool b)", val: map[string]any{"a": 1, "b": true}, want: "(a: 1, b: true)"},
+		{typ: "(uint256 a, address[] b)", val: map[string]any{"a": 1, "b": []types.Address{addr}}, want: "(a: 1, b: []address{0xabcdef0123456789abcdef0123456789abcdef01})"},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			typ := MustParseType(tt.typ)
+			got, err := FormatValue(typ, tt.val, tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			// Decoded values can be formatted directly.
+			v := typ.Value()
+			require.NoError(t, Default.Mapper.Map(tt.val, v))
+			got, err = FormatValue(typ, v, tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormatValue_NestedArray(t *testing.T) {
+	typ := NewArrayType(NewFixedArrayType(NewUintType(8), 2))
+	got, err := FormatValue(typ, [][2]uint8{{1, 2}, {3, 4}}, FormatOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "[][2]uint8{[2]uint8{1, 2}, [2]uint8{3, 4}}", got)
+}
+
+type mapLabeler map[types.Address]string
+
+func (m mapLabeler) Label(addr types.Address) (string, bool) {
+	label, ok := m[addr]
+	return label, ok
+}
+
+func TestFormatValue_Labels(t *testing.T) {
+	router := types.MustAddressFromHex("0xe592427a0aece92de3edee1f18e0157c05861564")
+	other := types.MustAddressFromHex("0xabcdef0123456789abcdef0123456789abcdef01")
+	opts := FormatOptions{ChecksumAddresses: true, Labels: mapLabeler{router: "Uniswap V3 Router"}}
+	typ := MustParseType("address[]")
+	got, err := FormatValue(typ, []types.Address{router, other}, opts)
+	require.NoError(t, err)
+	assert.Equal(t, "[]address{Uniswap V3 Router (0xE592427A0AEce92De3Edee1F18E0157C05861564), 0xabCDeF0123456789AbcdEf0123456789aBCDEF01}", got)
+
+	// Labeled addresses can be parsed back.
+	v, err := ParseValue(MustParseType("address"), "Uniswap V3 Router (0xE592427A0AEce92De3Edee1F18E0157C05861564)", opts)
+	require.NoError(t, err)
+	assert.Equal(t, router, v.(*AddressValue).Address())
+}
+
+func TestParseValue(t *testing.T) {
+	eth := FormatOptions{Decimals: 18, Symbol: "ETH"}
+	tests := []struct {
+		typ     string
+		s       string
+		opts    FormatOptions
+		wantErr bool
+	}{
+		{typ: "uint256", s: "1500000000000000000"},
+		{typ: "uint256", s: "1.5 ETH", opts: eth},
+		{typ: "uint256", s: "0.000000000000000001 ETH", opts: eth},
+		{typ: "int256", s: "-2.5", opts: FormatOptions{Decimals: 2}},
+		{typ: "uint8", s: "0xff"},
+		{typ: "address", s: "0xabcdef0123456789abcdef0123456789abcdef01"},
+		{typ: "address", s: "0xabCDeF0123456789AbcdEf0123456789aBCDEF01", opts: FormatOptions{ChecksumAddresses: true}},
+		{typ: "bool", s: "false"},
+		{typ: "string", s: `"a \"b\""`},
+		{typ: "bytes", s: "0xcafe"},
+		{typ: "bytes4", s: "0x01020304"},
+		{typ: "uint8", s: "256", wantErr: true},
+		{typ: "uint256", s: "-1", wantErr: true},
+		{typ: "uint256", s: "1.0000000000000000001 ETH", opts: eth, wantErr: true},
+		{typ: "uint256", s: "1.", opts: eth, wantErr: true},
+		{typ: "uint256", s: "abc", wantErr: true},
+		{typ: "address", s: "0xabcdef0123456789abcdef0123456789abcdef01", opts: FormatOptions{ChecksumAddresses: true}, wantErr: true},
+		{typ: "string", s: "unquoted", wantErr: true},
+		{typ: "uint8[]", s: "[]uint8{1}", wantErr: true},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			typ := MustParseType(tt.typ)
+			v, err := ParseValue(typ, tt.s, tt.opts)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			got, err := FormatValue(typ, v, tt.opts)
+			require.NoError(t, err)
+			if tt.s == "0xff" {
+				assert.Equal(t, "255", got)
+				return
+			}
+			assert.Equal(t, tt.s, got)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package abi
+
+import "github.com/defiweb/go-eth/types"
+
+// SelectorIndex maps selectors to the methods, events and errors of a
+// contract. It can be used to find the method, event or error matching
+// calldata, logs or revert data without iterating over the contract
+// definitions.
+//
+// Method selectors and event topics are computed once, when methods and
+// events are created, so building the index does not require hashing.
+type SelectorIndex struct {
+	Methods map[FourBytes]*Method // Methods by their four-byte selectors.
+	Events  map[types.Hash]*Event // Events by their first topic, anonymous events are not included.
+	Errors  map[FourBytes]*Error  // Errors by their four-byte selectors.
+}
+
+// SelectorIndex returns an index of the contract methods, events and errors
+// by their selectors.
+//
+// The index is built on every call and is not updated when the contract is
+// modified, so it should be stored by the caller if used repeatedly.
+func (c *Contract) SelectorIndex() *SelectorIndex {
+	idx := &SelectorIndex{
+		Methods: make(map[FourBytes]*Method, len(c.MethodsBySignature)),
+		Events:  make(map[types.Hash]*Event, len(c.Events)),
+		Errors:  make(map[FourBytes]*Error, len(c.Errors)),
+	}
+	for _, m := range c.MethodsBySignature {
+		idx.Methods[m.FourBytes()] = m
+	}
+	for _, m := range c.Methods {
+		if _, ok := idx.Methods[m.FourBytes()]; !ok {
+			idx.Methods[m.FourBytes()] = m
+		}
+	}
+	for _, e := range c.Events {
+		if e.anonymous {
+			continue
+		}
+		idx.Events[e.Topic0()] = e
+	}
+	for _, e := range c.Errors {
+		idx.Errors[e.FourBytes()] = e
+	}
+	return idx
+}
+
+// Method returns the method matching the selector of the given calldata, or
+// nil if there is no such method.
+func (s *SelectorIndex) Method(calldata []byte) *Method {
+	if len(calldata) < 4 {
+		return nil
+	}
+	var fb FourBytes
+	copy(fb[:], calldata[:4])
+	return s.Methods[fb]
+}
+
+// Event returns the event matching the first of the given log topics, or nil
+// if there is no such event.
+func (s *SelectorIndex) Event(topics []types.Hash) *Event {
+	if len(topics) == 0 {
+		return nil
+	}
+	return s.Events[topics[0]]
+}
+
+// Error returns the custom error matching the selector of the given revert
+// data, or nil if there is no such error.
+func (s *SelectorIndex) Error(data []byte) *Error {
+	if len(data) < 4 {
+		return nil
+	}
+	var fb FourBytes
+	copy(fb[:], data[:4])
+	return s.Errors[fb]
+}
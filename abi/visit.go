@@ -0,0 +1,161 @@
+package abi
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ErrStopVisit may be returned by a VisitFunc to stop visiting the remaining
+// values. The visit functions return nil in that case.
+var ErrStopVisit = errors.New("abi: stop visit")
+
+// VisitFunc is called by VisitValues for every top-level element of a tuple.
+//
+// The name is the element name, or argN if the element is unnamed, where N
+// is the index of the element. The raw argument contains the ABI encoding of
+// the element and can be decoded using DecodeValue with the typ type. For
+// static types, raw contains exactly the encoded value. For dynamic types,
+// raw starts at the value's offset and extends to the end of the data.
+//
+// The raw slice is a part of the data passed to VisitValues, so it must not
+// be modified.
+type VisitFunc func(name string, typ Type, raw []byte) error
+
+// VisitValues calls fn for every top-level element of the t tuple encoded in
+// the given ABI data, without decoding the values.
+//
+// It is useful when only a few values are needed from large tuples, because
+// only the values that are passed to DecodeValue in the callback are
+// decoded. If fn returns an error, visiting stops and the error is returned,
+// unless the error is ErrStopVisit.
+func VisitValues(t Type, abi []byte, fn VisitFunc) error {
+	for {
+		alias, ok := t.(*AliasType)
+		if !ok {
+			break
+		}
+		t = alias.Type()
+	}
+	tuple, ok := t.(*TupleType)
+	if !ok {
+		return fmt.Errorf("abi: cannot visit values, expected tuple type")
+	}
+	var head int
+	for i, elem := range tuple.elems {
+		name := elem.Name
+		if len(name) == 0 {
+			name = fmt.Sprintf("arg%d", i)
+		}
+		var raw []byte
+		if elem.Type.IsDynamic() {
+			if head+WordLength > len(abi) {
+				return fmt.Errorf("abi: cannot visit tuple, unexpected end of data")
+			}
+			offset, err := readOffset(abi[head : head+WordLength])
+			if err != nil {
+				return fmt.Errorf("abi: cannot visit tuple, invalid offset: %v", err)
+			}
+			if offset%WordLength != 0 {
+				return fmt.Errorf("abi: cannot visit tuple, offset not a multiple of word length")
+			}
+			if offset >= len(abi) {
+				return fmt.Errorf("abi: cannot visit tuple, offset exceeds data length")
+			}
+			raw = abi[offset:]
+			head += WordLength
+		} else {
+			size, err := staticSize(elem.Type, tail(abi, head))
+			if err != nil {
+				return err
+			}
+			if head+size > len(abi) {
+				return fmt.Errorf("abi: cannot visit tuple, unexpected end of data")
+			}
+			raw = abi[head : head+size]
+			head += size
+		}
+		if err := fn(name, elem.Type, raw); err != nil {
+			if errors.Is(err, ErrStopVisit) {
+				return nil
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// VisitValues calls fn for every return value of the method encoded in the
+// given ABI data. See the VisitValues function for details.
+func (m *Method) VisitValues(data []byte, fn VisitFunc) error {
+	return VisitValues(m.outputs, data, fn)
+}
+
+// VisitArgs calls fn for every argument of the method encoded in the given
+// calldata. See the VisitValues function for details.
+//
+// Provided data must be prefixed with the method selector.
+func (m *Method) VisitArgs(data []byte, fn VisitFunc) error {
+	if len(data) < 4 || !m.fourBytes.Match(data[:4]) {
+		return fmt.Errorf("abi: calldata signature do not match method signature %s", m.fourBytes)
+	}
+	return VisitValues(m.inputs, data[4:], fn)
+}
+
+// staticSize returns the size in bytes of the encoding of the static type t.
+// For types other than the built-in ones, the size is determined by decoding
+// the value from the given data.
+func staticSize(t Type, abi []byte) (int, error) {
+	switch t := t.(type) {
+	case *AliasType:
+		return staticSize(t.Type(), abi)
+	case *UintType, *IntType, *BoolType, *AddressType, *FixedBytesType:
+		return WordLength, nil
+	case *FixedArrayType:
+		n, err := staticSize(t.ElementType(), abi)
+		if err != nil {
+			return 0, err
+		}
+		return n * t.Size(), nil
+	case *TupleType:
+		var size int
+		for _, elem := range t.elems {
+			n, err := staticSize(elem.Type, tail(abi, size))
+			if err != nil {
+				return 0, err
+			}
+			size += n
+		}
+		return size, nil
+	default:
+		n, err := t.Value().DecodeABI(BytesToWords(abi))
+		if err != nil {
+			return 0, err
+		}
+		return n * WordLength, nil
+	}
+}
+
+// tail returns the data starting at the given offset, or nil if the offset
+// exceeds the data length.
+func tail(abi []byte, offset int) []byte {
+	if offset >= len(abi) {
+		return nil
+	}
+	return abi[offset:]
+}
+
+// readOffset reads an offset from a 32-byte word.
+func readOffset(b []byte) (int, error) {
+	for _, c := range b[:WordLength-8] {
+		if c != 0 {
+			return 0, fmt.Errorf("offset too large")
+		}
+	}
+	v := binary.BigEndian.Uint64(b[WordLength-8:])
+	if v > math.MaxInt32 {
+		return 0, fmt.Errorf("offset too large")
+	}
+	return int(v), nil
+}
@@ -0,0 +1,109 @@
+package abi
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+func TestVisitValues(t *testing.T) {
+	m := MustParseMethod("foo(uint256 a, (address p, uint8 q)[2] b, bytes c, string, (uint256 x, bytes y) e, bool f)")
+	addr := types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+	data := m.MustEncodeArgs(
+		big.NewInt(1),
+		[2]map[string]any{{"p": addr, "q": uint8(2)}, {"p": addr, "q": uint8(3)}},
+		[]byte{0xca, 0xfe},
+		"hello",
+		map[string]any{"x": big.NewInt(4), "y": []byte{0xbe, 0xef}},
+		true,
+	)
+
+	var (
+		names []string
+		a     *big.Int
+		b     [2]struct {
+			P types.Address
+			Q uint8
+		}
+		c []byte
+		s string
+		e struct {
+			X *big.Int
+			Y []byte
+		}
+		f bool
+	)
+	err := m.VisitArgs(data, func(name string, typ Type, raw []byte) error {
+		names = append(names, name)
+		switch name {
+		case "a":
+			assert.Len(t, raw, 32)
+			return DecodeValue(typ, raw, &a)
+		case "b":
+			assert.Len(t, raw, 4*32)
+			return DecodeValue(typ, raw, &b)
+		case "c":
+			return DecodeValue(typ, raw, &c)
+		case "arg3":
+			return DecodeValue(typ, raw, &s)
+		case "e":
+			return DecodeValue(typ, raw, &e)
+		case "f":
+			assert.Len(t, raw, 32)
+			return DecodeValue(typ, raw, &f)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "arg3", "e", "f"}, names)
+	assert.Equal(t, big.NewInt(1), a)
+	assert.Equal(t, addr, b[1].P)
+	assert.Equal(t, uint8(3), b[1].Q)
+	assert.Equal(t, []byte{0xca, 0xfe}, c)
+	assert.Equal(t, "hello", s)
+	assert.Equal(t, big.NewInt(4), e.X)
+	assert.Equal(t, []byte{0xbe, 0xef}, e.Y)
+	assert.True(t, f)
+
+	// Stop visiting.
+	names = nil
+	err = m.VisitArgs(data, func(name string, typ Type, raw []byte) error {
+		names = append(names, name)
+		return ErrStopVisit
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, names)
+
+	// Callback error.
+	cbErr := errors.New("callback error")
+	err = m.VisitArgs(data, func(string, Type, []byte) error { return cbErr })
+	require.ErrorIs(t, err, cbErr)
+
+	// Truncated data.
+	err = m.VisitArgs(data[:4+5*32], func(string, Type, []byte) error { return nil })
+	require.Error(t, err)
+
+	// Invalid selector.
+	err = m.VisitArgs([]byte{1, 2, 3, 4}, func(string, Type, []byte) error { return nil })
+	require.Error(t, err)
+}
+
+func TestMethod_VisitValues(t *testing.T) {
+	m := MustParseMethod("foo() returns (uint256 a, bytes b)")
+	data := MustEncodeValues(m.Outputs(), big.NewInt(42), []byte{1, 2, 3})
+
+	var b []byte
+	err := m.VisitValues(data, func(name string, typ Type, raw []byte) error {
+		if name == "b" {
+			return DecodeValue(typ, raw, &b)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, b)
+}
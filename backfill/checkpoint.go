@@ -0,0 +1,152 @@
+package backfill
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/defiweb/go-eth/store"
+)
+
+// Checkpoint persists the progress of a backfill job.
+type Checkpoint interface {
+	// Load returns the last fully processed block. If the job has not been
+	// started yet, ok is false.
+	Load(ctx context.Context) (block uint64, ok bool, err error)
+
+	// Save stores the last fully processed block.
+	Save(ctx context.Context, block uint64) error
+}
+
+// MemoryCheckpoint is a Checkpoint that keeps progress in memory. It does not
+// survive restarts and is mostly useful for tests and one-off jobs.
+type MemoryCheckpoint struct {
+	mu    sync.Mutex
+	block uint64
+	ok    bool
+}
+
+// NewMemoryCheckpoint returns a new MemoryCheckpoint.
+func NewMemoryCheckpoint() *MemoryCheckpoint {
+	return &MemoryCheckpoint{}
+}
+
+// Load implements the Checkpoint interface.
+func (c *MemoryCheckpoint) Load(_ context.Context) (uint64, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.block, c.ok, nil
+}
+
+// Save implements the Checkpoint interface.
+func (c *MemoryCheckpoint) Save(_ context.Context, block uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.block = block
+	c.ok = true
+	return nil
+}
+
+// FileCheckpoint is a Checkpoint that stores progress in a JSON file.
+//
+// The file is replaced atomically, so an interrupted write never leaves
+// a corrupted checkpoint behind.
+type FileCheckpoint struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileCheckpoint returns a new FileCheckpoint that uses the given path.
+func NewFileCheckpoint(path string) *FileCheckpoint {
+	return &FileCheckpoint{path: path}
+}
+
+type jsonCheckpoint struct {
+	Block uint64 `json:"block"`
+}
+
+// Load implements the Checkpoint interface.
+func (c *FileCheckpoint) Load(_ context.Context) (uint64, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	content, err := os.ReadFile(c.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	var cp jsonCheckpoint
+	if err := json.Unmarshal(content, &cp); err != nil {
+		return 0, false, err
+	}
+	return cp.Block, true, nil
+}
+
+// Save implements the Checkpoint interface.
+func (c *FileCheckpoint) Save(_ context.Context, block uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	content, err := json.Marshal(jsonCheckpoint{Block: block})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.path)
+}
+
+// KVCheckpoint is a Checkpoint that stores progress under a key of a
+// store.KV, so that a single storage can be shared by multiple jobs and
+// other components.
+type KVCheckpoint struct {
+	kv  store.KV
+	key []byte
+}
+
+// NewKVCheckpoint returns a new KVCheckpoint that uses the given key.
+func NewKVCheckpoint(kv store.KV, key []byte) *KVCheckpoint {
+	return &KVCheckpoint{kv: kv, key: key}
+}
+
+// Load implements the Checkpoint interface.
+func (c *KVCheckpoint) Load(ctx context.Context) (uint64, bool, error) {
+	content, err := c.kv.Get(ctx, c.key)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+	var cp jsonCheckpoint
+	if err := json.Unmarshal(content, &cp); err != nil {
+		return 0, false, err
+	}
+	return cp.Block, true, nil
+}
+
+// Save implements the Checkpoint interface.
+func (c *KVCheckpoint) Save(ctx context.Context, block uint64) error {
+	content, err := json.Marshal(jsonCheckpoint{Block: block})
+	if err != nil {
+		return err
+	}
+	return c.kv.Put(ctx, c.key, content)
+}
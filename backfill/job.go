@@ -0,0 +1,236 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/types"
+)
+
+// DefaultChunkSize is the default number of blocks requested in a single
+// eth_getLogs call.
+const DefaultChunkSize = 2000
+
+// DefaultMaxRetries is the default number of consecutive failed eth_getLogs
+// calls after which the job is stopped.
+const DefaultMaxRetries = 5
+
+// HandlerFunc processes logs from the block range [from, to]. Logs are
+// ordered as returned by the node.
+//
+// The range is marked as processed only after the handler returns without
+// an error. If the process is stopped between the handler returning and the
+// checkpoint being saved, the range is processed again after restart, so
+// handlers should be idempotent.
+type HandlerFunc func(ctx context.Context, from, to uint64, logs []types.Log) error
+
+// Job fetches logs over a long block range in chunks, persisting progress
+// after every chunk so that the job can be resumed after a restart.
+type Job struct {
+	opts JobOptions
+}
+
+// JobOptions is the options for NewJob.
+type JobOptions struct {
+	// Client is the RPC client used to fetch logs.
+	Client rpc.RPC
+
+	// Query is the log filter. The FromBlock, ToBlock and BlockHash fields
+	// are ignored.
+	Query *types.FilterLogsQuery
+
+	// FromBlock is the first block of the backfill range.
+	FromBlock uint64
+
+	// ToBlock is the last block of the backfill range.
+	ToBlock uint64
+
+	// ChunkSize is the maximum number of blocks requested in a single
+	// eth_getLogs call. If zero, DefaultChunkSize is used.
+	//
+	// If a request fails, the chunk is halved before the request is retried,
+	// because the most common cause of eth_getLogs errors are responses that
+	// exceed the node limits. The chunk size grows back after successful
+	// requests.
+	ChunkSize uint64
+
+	// Checkpoint stores the job progress. If nil, the progress is kept in
+	// memory.
+	Checkpoint Checkpoint
+
+	// Handler is called for every processed chunk.
+	Handler HandlerFunc
+
+	// OnComplete is an optional callback called when the job stops. The error
+	// is nil if the whole range was processed.
+	OnComplete func(err error)
+
+	// MinInterval is the minimum interval between consecutive eth_getLogs
+	// calls. It can be used to stay within provider rate limits.
+	MinInterval time.Duration
+
+	// MaxRetries is the maximum number of consecutive failed eth_getLogs calls.
+	// If zero, DefaultMaxRetries is used. If negative, there is no limit.
+	MaxRetries int
+
+	// BackoffFunc returns the delay before the next retry. It takes the
+	// current retry count as an argument. The transport.LinearBackoff and
+	// transport.ExponentialBackoff functions can be used. If nil, there is
+	// no delay other than MinInterval.
+	BackoffFunc func(int) time.Duration
+}
+
+// NewJob returns a new Job.
+func NewJob(opts JobOptions) (*Job, error) {
+	if opts.Client == nil {
+		return nil, errors.New("backfill job: client is required")
+	}
+	if opts.Handler == nil {
+		return nil, errors.New("backfill job: handler is required")
+	}
+	if opts.FromBlock > opts.ToBlock {
+		return nil, fmt.Errorf("backfill job: invalid block range [%d, %d]", opts.FromBlock, opts.ToBlock)
+	}
+	if opts.Query == nil {
+		opts.Query = types.NewFilterLogsQuery()
+	}
+	if opts.ChunkSize == 0 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+	if opts.Checkpoint == nil {
+		opts.Checkpoint = NewMemoryCheckpoint()
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = DefaultMaxRetries
+	}
+	return &Job{opts: opts}, nil
+}
+
+// Run processes the remaining part of the block range. It blocks until the
+// whole range is processed, an unrecoverable error occurs or the context is
+// canceled.
+func (j *Job) Run(ctx context.Context) (err error) {
+	defer func() {
+		if j.opts.OnComplete != nil {
+			j.opts.OnComplete(err)
+		}
+	}()
+	next, err := j.next(ctx)
+	if err != nil {
+		return err
+	}
+	var (
+		chunk   = j.opts.ChunkSize
+		retries int
+		lastReq time.Time
+	)
+	for next <= j.opts.ToBlock {
+		to := next + chunk - 1
+		if to > j.opts.ToBlock || to < next {
+			to = j.opts.ToBlock
+		}
+		if err := j.wait(ctx, lastReq, retries); err != nil {
+			return err
+		}
+		lastReq = time.Now()
+		logs, err := j.opts.Client.GetLogs(ctx, j.query(next, to))
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			retries++
+			if j.opts.MaxRetries >= 0 && retries > j.opts.MaxRetries {
+				return fmt.Errorf("backfill job: failed to fetch logs for range [%d, %d]: %w", next, to, err)
+			}
+			if chunk > 1 {
+				chunk /= 2
+			}
+			continue
+		}
+		retries = 0
+		if err := j.opts.Handler(ctx, next, to, logs); err != nil {
+			return fmt.Errorf("backfill job: handler failed for range [%d, %d]: %w", next, to, err)
+		}
+		if err := j.opts.Checkpoint.Save(ctx, to); err != nil {
+			return fmt.Errorf("backfill job: failed to save checkpoint: %w", err)
+		}
+		if chunk < j.opts.ChunkSize {
+			chunk *= 2
+			if chunk > j.opts.ChunkSize {
+				chunk = j.opts.ChunkSize
+			}
+		}
+		if to == j.opts.ToBlock {
+			break
+		}
+		next = to + 1
+	}
+	return nil
+}
+
+// Progress returns the last processed block and the fraction of the range
+// that has been processed.
+func (j *Job) Progress(ctx context.Context) (block uint64, done float64, err error) {
+	next, err := j.next(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	total := j.opts.ToBlock - j.opts.FromBlock + 1
+	if next > j.opts.ToBlock {
+		return j.opts.ToBlock, 1, nil
+	}
+	if next == j.opts.FromBlock {
+		return 0, 0, nil
+	}
+	return next - 1, float64(next-j.opts.FromBlock) / float64(total), nil
+}
+
+// next returns the first block that has not been processed yet.
+func (j *Job) next(ctx context.Context) (uint64, error) {
+	block, ok, err := j.opts.Checkpoint.Load(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("backfill job: failed to load checkpoint: %w", err)
+	}
+	if !ok || block < j.opts.FromBlock {
+		return j.opts.FromBlock, nil
+	}
+	return block + 1, nil
+}
+
+// wait blocks until the next request is allowed by the MinInterval and
+// BackoffFunc options.
+func (j *Job) wait(ctx context.Context, lastReq time.Time, retries int) error {
+	var delay time.Duration
+	if !lastReq.IsZero() && j.opts.MinInterval > 0 {
+		delay = j.opts.MinInterval - time.Since(lastReq)
+	}
+	if retries > 0 && j.opts.BackoffFunc != nil {
+		if d := j.opts.BackoffFunc(retries - 1); d > delay {
+			delay = d
+		}
+	}
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// query returns a copy of the job query for the given block range.
+func (j *Job) query(from, to uint64) *types.FilterLogsQuery {
+	return &types.FilterLogsQuery{
+		Address:   j.opts.Query.Address,
+		Topics:    j.opts.Query.Topics,
+		FromBlock: types.BlockNumberFromUint64Ptr(from),
+		ToBlock:   types.BlockNumberFromUint64Ptr(to),
+	}
+}
@@ -0,0 +1,172 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/store"
+	"github.com/defiweb/go-eth/types"
+)
+
+// fakeRPC returns one log per block and fails for ranges wider than maxRange.
+type fakeRPC struct {
+	rpc.Client
+
+	maxRange uint64
+	ranges   [][2]uint64
+}
+
+func (f *fakeRPC) GetLogs(_ context.Context, query *types.FilterLogsQuery) ([]types.Log, error) {
+	from, to := query.FromBlock.Big().Uint64(), query.ToBlock.Big().Uint64()
+	f.ranges = append(f.ranges, [2]uint64{from, to})
+	if f.maxRange > 0 && to-from+1 > f.maxRange {
+		return nil, errors.New("query returned more than 10000 results")
+	}
+	var logs []types.Log
+	for n := from; n <= to; n++ {
+		logs = append(logs, types.Log{BlockNumber: new(big.Int).SetUint64(n)})
+	}
+	return logs, nil
+}
+
+func TestJob_Run(t *testing.T) {
+	client := &fakeRPC{}
+	var blocks []uint64
+	var completed bool
+	job, err := NewJob(JobOptions{
+		Client:    client,
+		FromBlock: 10,
+		ToBlock:   34,
+		ChunkSize: 10,
+		Handler: func(_ context.Context, from, to uint64, logs []types.Log) error {
+			for _, l := range logs {
+				blocks = append(blocks, l.BlockNumber.Uint64())
+			}
+			return nil
+		},
+		OnComplete: func(err error) {
+			completed = err == nil
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, job.Run(context.Background()))
+
+	assert.True(t, completed)
+	assert.Equal(t, [][2]uint64{{10, 19}, {20, 29}, {30, 34}}, client.ranges)
+	require.Len(t, blocks, 25)
+	for i, b := range blocks {
+		assert.Equal(t, uint64(10+i), b)
+	}
+
+	block, done, err := job.Progress(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(34), block)
+	assert.Equal(t, float64(1), done)
+}
+
+func TestJob_Resume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	client := &fakeRPC{}
+	handlerErr := errors.New("handler error")
+	calls := 0
+	handler := func(_ context.Context, from, to uint64, logs []types.Log) error {
+		calls++
+		if calls == 2 {
+			return handlerErr
+		}
+		return nil
+	}
+	opts := JobOptions{
+		Client:     client,
+		FromBlock:  0,
+		ToBlock:    29,
+		ChunkSize:  10,
+		Checkpoint: NewFileCheckpoint(path),
+		Handler:    handler,
+	}
+
+	// First run fails on the second chunk.
+	job, err := NewJob(opts)
+	require.NoError(t, err)
+	require.ErrorIs(t, job.Run(context.Background()), handlerErr)
+
+	block, done, err := job.Progress(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(9), block)
+	assert.InDelta(t, 1.0/3, done, 1e-9)
+
+	// Second run, using a new job instance, resumes from the failed chunk.
+	job, err = NewJob(opts)
+	require.NoError(t, err)
+	require.NoError(t, job.Run(context.Background()))
+	assert.Equal(t, [][2]uint64{{0, 9}, {10, 19}, {10, 19}, {20, 29}}, client.ranges)
+}
+
+func TestJob_SplitsChunkOnError(t *testing.T) {
+	client := &fakeRPC{maxRange: 3}
+	job, err := NewJob(JobOptions{
+		Client:    client,
+		FromBlock: 0,
+		ToBlock:   5,
+		ChunkSize: 8,
+		Handler: func(context.Context, uint64, uint64, []types.Log) error {
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, job.Run(context.Background()))
+	assert.Equal(t, [][2]uint64{{0, 5}, {0, 3}, {0, 1}, {2, 5}, {2, 3}, {4, 5}}, client.ranges)
+}
+
+func TestJob_MaxRetries(t *testing.T) {
+	client := &fakeRPC{maxRange: 1}
+	job, err := NewJob(JobOptions{
+		Client:     client,
+		FromBlock:  0,
+		ToBlock:    1,
+		ChunkSize:  2,
+		MaxRetries: 2,
+		Handler: func(context.Context, uint64, uint64, []types.Log) error {
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, job.Run(context.Background()))
+
+	client = &fakeRPC{maxRange: 1}
+	job, err = NewJob(JobOptions{
+		Client:     client,
+		FromBlock:  0,
+		ToBlock:    3,
+		ChunkSize:  4,
+		MaxRetries: 1,
+		Handler: func(context.Context, uint64, uint64, []types.Log) error {
+			return nil
+		},
+	})
+	require.NoError(t, err)
+	require.Error(t, job.Run(context.Background()))
+}
+
+func TestKVCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	kv := store.NewMemory()
+	cp := NewKVCheckpoint(kv, []byte("backfill/logs"))
+
+	_, ok, err := cp.Load(ctx)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, cp.Save(ctx, 42))
+	block, ok, err := NewKVCheckpoint(kv, []byte("backfill/logs")).Load(ctx)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(42), block)
+}
@@ -0,0 +1,131 @@
+// Package chainlist provides chain metadata and public RPC endpoints in the
+// format used by the chainid.network chain list, and helpers to probe the
+// endpoints and build a failover transport from the healthy ones.
+package chainlist
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// DefaultURL is the URL of the chain list published by chainid.network.
+const DefaultURL = "https://chainid.network/chains.json"
+
+//go:embed chains.json
+var embeddedChains []byte
+
+var (
+	embeddedOnce sync.Once
+	embedded     []Chain
+)
+
+// Chain describes a chain in the chainid.network format.
+type Chain struct {
+	Name           string         `json:"name"`
+	Chain          string         `json:"chain"`
+	ShortName      string         `json:"shortName"`
+	ChainID        uint64         `json:"chainId"`
+	NetworkID      uint64         `json:"networkId"`
+	RPC            []string       `json:"rpc"`
+	NativeCurrency NativeCurrency `json:"nativeCurrency"`
+	InfoURL        string         `json:"infoURL"`
+	Explorers      []Explorer     `json:"explorers,omitempty"`
+}
+
+// NativeCurrency describes the native currency of a chain.
+type NativeCurrency struct {
+	Name     string `json:"name"`
+	Symbol   string `json:"symbol"`
+	Decimals uint8  `json:"decimals"`
+}
+
+// Explorer describes a block explorer of a chain.
+type Explorer struct {
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Standard string `json:"standard"`
+}
+
+// Endpoints returns the HTTP RPC endpoints of the chain. Endpoints that
+// require an API key, e.g. "https://mainnet.infura.io/v3/${INFURA_API_KEY}",
+// and websocket endpoints are omitted.
+func (c *Chain) Endpoints() []string {
+	var urls []string
+	for _, u := range c.RPC {
+		if strings.Contains(u, "${") {
+			continue
+		}
+		if !strings.HasPrefix(u, "https://") && !strings.HasPrefix(u, "http://") {
+			continue
+		}
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+// Parse parses a chain list in the chainid.network format.
+func Parse(data []byte) ([]Chain, error) {
+	var chains []Chain
+	if err := json.Unmarshal(data, &chains); err != nil {
+		return nil, fmt.Errorf("chainlist: failed to parse chain list: %w", err)
+	}
+	return chains, nil
+}
+
+// Embedded returns a small chain list embedded in the package. It contains
+// only a few popular chains and endpoints known at the time of the release,
+// use Fetch to get an up-to-date list.
+func Embedded() []Chain {
+	embeddedOnce.Do(func() {
+		chains, err := Parse(embeddedChains)
+		if err != nil {
+			panic(err)
+		}
+		embedded = chains
+	})
+	return append([]Chain(nil), embedded...)
+}
+
+// Fetch downloads the chain list from the given URL. If url is empty,
+// DefaultURL is used. If client is nil, http.DefaultClient is used.
+func Fetch(ctx context.Context, client *http.Client, url string) ([]Chain, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if url == "" {
+		url = DefaultURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("chainlist: failed to create request: %w", err)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("chainlist: failed to fetch chain list: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("chainlist: failed to fetch chain list: unexpected status %d", res.StatusCode)
+	}
+	var chains []Chain
+	if err := json.NewDecoder(res.Body).Decode(&chains); err != nil {
+		return nil, fmt.Errorf("chainlist: failed to parse chain list: %w", err)
+	}
+	return chains, nil
+}
+
+// Find returns the chain with the given chain ID.
+func Find(chains []Chain, chainID uint64) (*Chain, error) {
+	for i := range chains {
+		if chains[i].ChainID == chainID {
+			return &chains[i], nil
+		}
+	}
+	return nil, errors.New("chainlist: chain not found")
+}
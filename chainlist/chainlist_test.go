@@ -0,0 +1,39 @@
+package chainlist
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbedded(t *testing.T) {
+	chain, err := Find(Embedded(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Ethereum Mainnet", chain.Name)
+	assert.Equal(t, "ETH", chain.NativeCurrency.Symbol)
+	assert.Equal(t, uint8(18), chain.NativeCurrency.Decimals)
+	for _, u := range chain.Endpoints() {
+		assert.NotContains(t, u, "${")
+		assert.Regexp(t, "^https?://", u)
+	}
+
+	_, err = Find(Embedded(), 0)
+	require.Error(t, err)
+}
+
+func TestFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"name":"Test","chainId":1337,"rpc":["http://localhost:8545","wss://localhost:8546"]}]`))
+	}))
+	defer srv.Close()
+
+	chains, err := Fetch(context.Background(), nil, srv.URL)
+	require.NoError(t, err)
+	chain, err := Find(chains, 1337)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"http://localhost:8545"}, chain.Endpoints())
+}
@@ -0,0 +1,208 @@
+package chainlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/rpc/transport"
+)
+
+const (
+	// DefaultProbeTimeout is the default timeout for probing a single
+	// endpoint.
+	DefaultProbeTimeout = 5 * time.Second
+
+	// DefaultProbeConcurrency is the default number of endpoints probed
+	// concurrently.
+	DefaultProbeConcurrency = 8
+
+	// DefaultMaxBlockLag is the default maximum number of blocks an endpoint
+	// may be behind the highest probed endpoint.
+	DefaultMaxBlockLag = 5
+
+	// DefaultMaxEndpoints is the default maximum number of endpoints used by
+	// the transport returned by NewTransport.
+	DefaultMaxEndpoints = 3
+)
+
+// ProbeResult is the result of probing an RPC endpoint.
+type ProbeResult struct {
+	URL         string        // URL is the endpoint URL.
+	Latency     time.Duration // Latency is the time it took to fetch the block number.
+	ChainID     uint64        // ChainID is the chain ID reported by the endpoint.
+	BlockNumber uint64        // BlockNumber is the latest block number reported by the endpoint.
+	Err         error         // Err is the error returned by the endpoint, if any.
+}
+
+// ProbeOptions contains options for Probe.
+type ProbeOptions struct {
+	// HTTPClient is the HTTP client to use. If nil, http.DefaultClient is
+	// used.
+	HTTPClient *http.Client
+
+	// Timeout is the timeout for probing a single endpoint. If zero,
+	// DefaultProbeTimeout is used.
+	Timeout time.Duration
+
+	// Concurrency is the number of endpoints probed concurrently. If zero,
+	// DefaultProbeConcurrency is used.
+	Concurrency int
+}
+
+// Probe queries the chain ID and the latest block number of the given HTTP
+// endpoints. The results are returned in the same order as the URLs.
+func Probe(ctx context.Context, urls []string, opts ProbeOptions) []ProbeResult {
+	if opts.Timeout == 0 {
+		opts.Timeout = DefaultProbeTimeout
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultProbeConcurrency
+	}
+	var (
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, opts.Concurrency)
+		results = make([]ProbeResult, len(urls))
+	)
+	for i, url := range urls {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = probe(ctx, url, opts)
+		}(i, url)
+	}
+	wg.Wait()
+	return results
+}
+
+func probe(ctx context.Context, url string, opts ProbeOptions) ProbeResult {
+	res := ProbeResult{URL: url}
+	t, err := transport.NewHTTP(transport.HTTPOptions{URL: url, HTTPClient: opts.HTTPClient})
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	client, err := rpc.NewClient(rpc.WithTransport(t))
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	start := time.Now()
+	block, err := client.BlockNumber(ctx)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Latency = time.Since(start)
+	res.BlockNumber = block.Uint64()
+	res.ChainID, res.Err = client.ChainID(ctx)
+	return res
+}
+
+// Healthy returns the results of endpoints that responded without an error,
+// report the expected chain ID and are at most maxBlockLag blocks behind the
+// highest reported block. The results are sorted by latency.
+func Healthy(results []ProbeResult, chainID uint64, maxBlockLag uint64) []ProbeResult {
+	var (
+		healthy []ProbeResult
+		highest uint64
+	)
+	for _, r := range results {
+		if r.Err != nil || r.ChainID != chainID {
+			continue
+		}
+		if r.BlockNumber > highest {
+			highest = r.BlockNumber
+		}
+		healthy = append(healthy, r)
+	}
+	n := 0
+	for _, r := range healthy {
+		if r.BlockNumber+maxBlockLag >= highest {
+			healthy[n] = r
+			n++
+		}
+	}
+	healthy = healthy[:n]
+	sort.SliceStable(healthy, func(i, j int) bool {
+		return healthy[i].Latency < healthy[j].Latency
+	})
+	return healthy
+}
+
+// TransportOptions contains options for NewTransport.
+type TransportOptions struct {
+	// ChainID is the chain ID of the chain to connect to.
+	ChainID uint64
+
+	// Chains is the chain list to look up the endpoints in. If nil, the
+	// embedded chain list is used.
+	Chains []Chain
+
+	// Probe contains the options used to probe the endpoints.
+	Probe ProbeOptions
+
+	// MaxBlockLag is the maximum number of blocks an endpoint may be behind
+	// the highest probed endpoint. If zero, DefaultMaxBlockLag is used.
+	MaxBlockLag uint64
+
+	// MaxEndpoints is the maximum number of endpoints used by the transport.
+	// If zero, DefaultMaxEndpoints is used.
+	MaxEndpoints int
+}
+
+// NewTransport probes the public endpoints of the chain and returns a
+// failover transport that uses the healthy endpoints, ordered by latency.
+//
+// The probe results for all endpoints are returned as well, so that the
+// caller may report them.
+func NewTransport(ctx context.Context, opts TransportOptions) (*transport.Failover, []ProbeResult, error) {
+	chains := opts.Chains
+	if chains == nil {
+		chains = Embedded()
+	}
+	if opts.MaxBlockLag == 0 {
+		opts.MaxBlockLag = DefaultMaxBlockLag
+	}
+	if opts.MaxEndpoints == 0 {
+		opts.MaxEndpoints = DefaultMaxEndpoints
+	}
+	chain, err := Find(chains, opts.ChainID)
+	if err != nil {
+		return nil, nil, err
+	}
+	urls := chain.Endpoints()
+	if len(urls) == 0 {
+		return nil, nil, fmt.Errorf("chainlist: no public endpoints for chain %d", opts.ChainID)
+	}
+	results := Probe(ctx, urls, opts.Probe)
+	healthy := Healthy(results, opts.ChainID, opts.MaxBlockLag)
+	if len(healthy) == 0 {
+		return nil, results, errors.New("chainlist: no healthy endpoints")
+	}
+	if len(healthy) > opts.MaxEndpoints {
+		healthy = healthy[:opts.MaxEndpoints]
+	}
+	var transports []transport.Transport
+	for _, r := range healthy {
+		t, err := transport.NewHTTP(transport.HTTPOptions{URL: r.URL, HTTPClient: opts.Probe.HTTPClient})
+		if err != nil {
+			return nil, results, err
+		}
+		transports = append(transports, t)
+	}
+	t, err := transport.NewFailover(transport.FailoverOptions{Transports: transports})
+	if err != nil {
+		return nil, results, err
+	}
+	return t, results, nil
+}
@@ -0,0 +1,75 @@
+package chainlist
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeNode(t *testing.T, chainID, block uint64, delay time.Duration) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int    `json:"id"`
+			Method string `json:"method"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		time.Sleep(delay)
+		var res uint64
+		switch req.Method {
+		case "eth_chainId":
+			res = chainID
+		case "eth_blockNumber":
+			res = block
+		}
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x%x"}`, req.ID, res)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestNewTransport(t *testing.T) {
+	slow := fakeNode(t, 1337, 100, 20*time.Millisecond)
+	fast := fakeNode(t, 1337, 99, 0)
+	lagging := fakeNode(t, 1337, 10, 0)
+	otherChain := fakeNode(t, 1, 100, 0)
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	chains := []Chain{{
+		Name:    "Test",
+		ChainID: 1337,
+		RPC:     []string{slow.URL, failing.URL, fast.URL, lagging.URL, otherChain.URL, "https://example.com/${API_KEY}"},
+	}}
+	tr, results, err := NewTransport(context.Background(), TransportOptions{ChainID: 1337, Chains: chains})
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+	assert.Error(t, results[1].Err)
+	assert.Equal(t, uint64(10), results[3].BlockNumber)
+	assert.Equal(t, uint64(1), results[4].ChainID)
+
+	healthy := Healthy(results, 1337, DefaultMaxBlockLag)
+	require.Len(t, healthy, 2)
+	assert.Equal(t, fast.URL, healthy[0].URL)
+	assert.Equal(t, slow.URL, healthy[1].URL)
+
+	var block string
+	require.NoError(t, tr.Call(context.Background(), &block, "eth_blockNumber"))
+	assert.Equal(t, "0x63", block)
+}
+
+func TestNewTransport_NoHealthyEndpoints(t *testing.T) {
+	otherChain := fakeNode(t, 1, 100, 0)
+	chains := []Chain{{Name: "Test", ChainID: 1337, RPC: []string{otherChain.URL}}}
+	_, results, err := NewTransport(context.Background(), TransportOptions{ChainID: 1337, Chains: chains})
+	require.Error(t, err)
+	require.Len(t, results, 1)
+}
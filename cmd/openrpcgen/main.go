@@ -0,0 +1,70 @@
+// Command openrpcgen generates RPC method stubs from an OpenRPC document.
+//
+// Usage:
+//
+//	openrpcgen -spec openrpc.json [-out file.go] [-package rpc]
+//		[-receiver baseClient] [-methods eth_config,eth_simulateV1]
+//
+// The execution-apis document can be built from the
+// https://github.com/ethereum/execution-apis repository using "npm run build".
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/defiweb/go-eth/rpc/openrpcgen"
+)
+
+func main() {
+	var (
+		spec     = flag.String("spec", "", "path to the OpenRPC document, - for stdin")
+		out      = flag.String("out", "", "output file, stdout if empty")
+		pkg      = flag.String("package", "rpc", "package name of the generated file")
+		receiver = flag.String("receiver", "baseClient", "receiver type of the generated methods")
+		methods  = flag.String("methods", "", "comma separated list of methods, all if empty")
+	)
+	flag.Parse()
+	if err := run(*spec, *out, *pkg, *receiver, *methods); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(spec, out, pkg, receiver, methods string) error {
+	if spec == "" {
+		return fmt.Errorf("openrpcgen: -spec is required")
+	}
+	var (
+		data []byte
+		err  error
+	)
+	if spec == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(spec)
+	}
+	if err != nil {
+		return fmt.Errorf("openrpcgen: failed to read specification: %w", err)
+	}
+	opts := openrpcgen.Options{Package: pkg, Receiver: receiver}
+	if methods != "" {
+		for _, m := range strings.Split(methods, ",") {
+			if m = strings.TrimSpace(m); m != "" {
+				opts.Methods = append(opts.Methods, m)
+			}
+		}
+	}
+	src, err := openrpcgen.Generate(data, opts)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0o644) //nolint:gosec
+}
@@ -0,0 +1,33 @@
+package crypto
+
+import (
+	"github.com/defiweb/go-rlp"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// CreateAddress returns the address of a contract created by the CREATE
+// opcode or a contract creation transaction:
+//
+//	keccak256(rlp([sender, nonce]))[12:]
+func CreateAddress(sender types.Address, nonce uint64) types.Address {
+	bin, err := rlp.NewList(&sender, rlp.NewUint(nonce)).EncodeRLP()
+	if err != nil {
+		// Encoding an address and an integer cannot fail.
+		panic(err)
+	}
+	return types.MustAddressFromBytes(Keccak256(bin).Bytes()[12:])
+}
+
+// Create2Address returns the address of a contract created by the CREATE2
+// opcode as defined in EIP-1014:
+//
+//	keccak256(0xff || deployer || salt || keccak256(initCode))[12:]
+func Create2Address(deployer types.Address, salt types.Hash, initCode []byte) types.Address {
+	return types.MustAddressFromBytes(Keccak256(
+		[]byte{0xff},
+		deployer.Bytes(),
+		salt.Bytes(),
+		Keccak256(initCode).Bytes(),
+	).Bytes()[12:])
+}
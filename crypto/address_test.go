@@ -0,0 +1,60 @@
+package crypto
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/defiweb/go-eth/hexutil"
+	"github.com/defiweb/go-eth/types"
+)
+
+func TestCreateAddress(t *testing.T) {
+	sender := types.MustAddressFromHex("0x6ac7ea33f8831ea9dcc53393aaa88b25a785dbf0")
+	assert.Equal(t, types.MustAddressFromHex("0xcd234a471b72ba2f1ccf0a70fcaba648a5eecd8d"), CreateAddress(sender, 0))
+	assert.Equal(t, types.MustAddressFromHex("0x343c43a37d37dff08ae8c4a11544c718abb4fcf8"), CreateAddress(sender, 1))
+	assert.Equal(t, types.MustAddressFromHex("0xf778b86fa74e846c4f0a1fbd1335fe81c00a0c91"), CreateAddress(sender, 2))
+}
+
+func TestCreate2Address(t *testing.T) {
+	// Test vectors from EIP-1014.
+	tests := []struct {
+		deployer string
+		salt     string
+		initCode string
+		want     string
+	}{
+		{
+			deployer: "0x0000000000000000000000000000000000000000",
+			salt:     "0x0000000000000000000000000000000000000000000000000000000000000000",
+			initCode: "0x00",
+			want:     "0x4D1A2e2bB4F88F0250f26Ffff098B0b30B26BF38",
+		},
+		{
+			deployer: "0xdeadbeef00000000000000000000000000000000",
+			salt:     "0x000000000000000000000000feed000000000000000000000000000000000000",
+			initCode: "0x00",
+			want:     "0xD04116cDd17beBE565EB2422F2497E06cC1C9833",
+		},
+		{
+			deployer: "0x00000000000000000000000000000000deadbeef",
+			salt:     "0x00000000000000000000000000000000000000000000000000000000cafebabe",
+			initCode: "0xdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef",
+			want:     "0x1d8bfDC5D46DC4f61D6b6115972536eBE6A8854C",
+		},
+		{
+			deployer: "0x0000000000000000000000000000000000000000",
+			salt:     "0x0000000000000000000000000000000000000000000000000000000000000000",
+			initCode: "0x",
+			want:     "0xE33C0C7F7df4809055C3ebA6c09CFe4BaF1BD9e0",
+		},
+	}
+	for _, tt := range tests {
+		got := Create2Address(
+			types.MustAddressFromHex(tt.deployer),
+			types.MustHashFromHex(tt.salt, types.PadNone),
+			hexutil.MustHexToBytes(tt.initCode),
+		)
+		assert.Equal(t, types.MustAddressFromHex(tt.want), got)
+	}
+}
@@ -0,0 +1,44 @@
+package crypto
+
+import (
+	"fmt"
+
+	"github.com/defiweb/go-rlp"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// authorizationMagic is the EIP-7702 authorization signing hash prefix.
+const authorizationMagic = 0x05
+
+// AuthorizationHash returns the EIP-7702 authorization hash that must be
+// signed by the authority:
+//
+//	keccak256(0x05 || rlp([chain_id, address, nonce]))
+func AuthorizationHash(auth *types.SetCodeAuthorization) (types.Hash, error) {
+	bin, err := rlp.NewList(
+		rlp.NewUint(auth.ChainID),
+		&auth.Address,
+		rlp.NewUint(auth.Nonce),
+	).EncodeRLP()
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return Keccak256(append([]byte{authorizationMagic}, bin...)), nil
+}
+
+// RecoverAuthority recovers the address of the authority that signed the
+// EIP-7702 authorization.
+func RecoverAuthority(auth *types.SetCodeAuthorization) (*types.Address, error) {
+	if auth.Signature == nil {
+		return nil, fmt.Errorf("signature is missing")
+	}
+	if auth.Signature.V.BitLen() > 1 {
+		return nil, fmt.Errorf("invalid authorization signature: y-parity must be 0 or 1")
+	}
+	hash, err := AuthorizationHash(auth)
+	if err != nil {
+		return nil, err
+	}
+	return ECRecoverer.RecoverHash(hash, *auth.Signature)
+}
@@ -0,0 +1,48 @@
+package crypto
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+func TestAuthorizationHash(t *testing.T) {
+	auth := &types.SetCodeAuthorization{
+		ChainID: 1,
+		Address: types.MustAddressFromHex("0x3535353535353535353535353535353535353535"),
+		Nonce:   9,
+	}
+	hash, err := AuthorizationHash(auth)
+	require.NoError(t, err)
+
+	// 0x05 || rlp([1, 0x3535353535353535353535353535353535353535, 9])
+	want := Keccak256(append([]byte{0x05, 0xd7, 0x01, 0x94}, append(bytes.Repeat([]byte{0x35}, 20), 0x09)...))
+	assert.Equal(t, want, hash)
+}
+
+func TestRecoverAuthority(t *testing.T) {
+	key, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	auth := &types.SetCodeAuthorization{
+		ChainID: 1,
+		Address: types.MustAddressFromHex("0x3535353535353535353535353535353535353535"),
+		Nonce:   9,
+	}
+	hash, err := AuthorizationHash(auth)
+	require.NoError(t, err)
+	auth.Signature, err = ecSignHash(key.ToECDSA(), hash)
+	require.NoError(t, err)
+
+	addr, err := RecoverAuthority(auth)
+	require.NoError(t, err)
+	assert.Equal(t, ECPublicKeyToAddress(key.PubKey().ToECDSA()), *addr)
+
+	auth.Signature.V = big.NewInt(27)
+	_, err = RecoverAuthority(auth)
+	assert.Error(t, err)
+}
@@ -0,0 +1,98 @@
+package crypto
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// VerifyResult is the result of verifying a single signature by
+// VerifyBatch.
+type VerifyResult struct {
+	// Valid is true if the signature was created by the expected signer.
+	Valid bool
+
+	// Signer is the recovered signer, nil if the signature could not be
+	// recovered or was skipped.
+	Signer *types.Address
+
+	// Skipped is true if the signature was not verified because another
+	// signature in the batch was invalid and FailFast was set.
+	Skipped bool
+
+	// Err is the recovery error, if any.
+	Err error
+}
+
+// VerifyBatchOptions is the options for VerifyBatchWithOptions.
+type VerifyBatchOptions struct {
+	// Workers is the number of goroutines used to verify signatures. If
+	// zero, runtime.GOMAXPROCS(0) is used.
+	Workers int
+
+	// FailFast stops the verification after the first invalid signature.
+	// Signatures that were not verified yet are marked as skipped. It is
+	// useful when the whole batch is rejected if any signature is invalid.
+	FailFast bool
+}
+
+// VerifyBatch verifies signatures of the given hashes in parallel. The
+// signature at index i must be a signature of hashes[i] created by
+// signers[i]. It returns the result for every signature, in the same order.
+//
+// The signatures are verified using ECRecoverer.RecoverHash, so they must
+// be signatures of the hashes themselves. To verify EIP-191 messages, the
+// hashes must be calculated as Keccak256(AddMessagePrefix(data)).
+func VerifyBatch(hashes []types.Hash, sigs []types.Signature, signers []types.Address) ([]VerifyResult, error) {
+	return VerifyBatchWithOptions(hashes, sigs, signers, VerifyBatchOptions{})
+}
+
+// VerifyBatchWithOptions is like VerifyBatch but allows to specify options.
+func VerifyBatchWithOptions(hashes []types.Hash, sigs []types.Signature, signers []types.Address, opts VerifyBatchOptions) ([]VerifyResult, error) {
+	if len(hashes) != len(sigs) || len(hashes) != len(signers) {
+		return nil, errors.New("hashes, signatures and signers must have the same length")
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(hashes) {
+		workers = len(hashes)
+	}
+	results := make([]VerifyResult, len(hashes))
+	var (
+		next   = int64(-1) // Index of the last claimed item.
+		failed int32       // Set to 1 after the first invalid signature.
+		wg     sync.WaitGroup
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(atomic.AddInt64(&next, 1))
+				if i >= len(hashes) {
+					return
+				}
+				if opts.FailFast && atomic.LoadInt32(&failed) != 0 {
+					results[i].Skipped = true
+					continue
+				}
+				addr, err := ECRecoverer.RecoverHash(hashes[i], sigs[i])
+				results[i] = VerifyResult{
+					Valid:  err == nil && *addr == signers[i],
+					Signer: addr,
+					Err:    err,
+				}
+				if !results[i].Valid {
+					atomic.StoreInt32(&failed, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
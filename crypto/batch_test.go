@@ -0,0 +1,66 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+func batchFixture(t *testing.T, n int) ([]types.Hash, []types.Signature, []types.Address) {
+	key, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	signer := ECPublicKeyToAddress(key.ToECDSA().Public().(*ecdsa.PublicKey))
+	hashes := make([]types.Hash, n)
+	sigs := make([]types.Signature, n)
+	signers := make([]types.Address, n)
+	for i := range hashes {
+		hashes[i] = Keccak256([]byte{byte(i)})
+		sig, err := ecSignHash(key.ToECDSA(), hashes[i])
+		require.NoError(t, err)
+		sigs[i] = *sig
+		signers[i] = signer
+	}
+	return hashes, sigs, signers
+}
+
+func TestVerifyBatch(t *testing.T) {
+	hashes, sigs, signers := batchFixture(t, 100)
+	signers[10] = types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+	hashes[20] = Keccak256([]byte("other"))
+
+	res, err := VerifyBatch(hashes, sigs, signers)
+	require.NoError(t, err)
+	require.Len(t, res, 100)
+	for i, r := range res {
+		assert.False(t, r.Skipped)
+		require.NoError(t, r.Err)
+		if i == 10 || i == 20 {
+			assert.False(t, r.Valid, i)
+			continue
+		}
+		assert.True(t, r.Valid, i)
+		assert.Equal(t, signers[i], *r.Signer)
+	}
+
+	_, err = VerifyBatch(hashes, sigs[:1], signers)
+	assert.Error(t, err)
+}
+
+func TestVerifyBatch_FailFast(t *testing.T) {
+	hashes, sigs, signers := batchFixture(t, 100)
+	signers[0] = types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+
+	res, err := VerifyBatchWithOptions(hashes, sigs, signers, VerifyBatchOptions{Workers: 1, FailFast: true})
+	require.NoError(t, err)
+	assert.False(t, res[0].Valid)
+	assert.False(t, res[0].Skipped)
+	for _, r := range res[1:] {
+		assert.True(t, r.Skipped)
+		assert.False(t, r.Valid)
+	}
+}
@@ -8,6 +8,9 @@ import (
 )
 
 // Signer is an interface for signing data.
+//
+// Implementations must never log private key material or include it in
+// error messages, and should zero temporary copies of the key after use.
 type Signer interface {
 	// SignHash signs a hash.
 	SignHash(hash types.Hash) (*types.Signature, error)
@@ -48,6 +51,18 @@ type (
 	ecRecoverer struct{}
 )
 
+// String implements the fmt.Stringer interface. It prevents the private key
+// from being printed.
+func (s *ecSigner) String() string {
+	return "ECSigner(" + ECPublicKeyToAddress(&s.key.PublicKey).String() + ")"
+}
+
+// GoString implements the fmt.GoStringer interface. It prevents the private
+// key from being printed.
+func (s *ecSigner) GoString() string {
+	return s.String()
+}
+
 func (s *ecSigner) SignHash(hash types.Hash) (*types.Signature, error) {
 	return ecSignHash(s.key, hash)
 }
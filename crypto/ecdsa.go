@@ -23,11 +23,22 @@ func ECPublicKeyToAddress(pub *ecdsa.PublicKey) (addr types.Address) {
 }
 
 // ecSignHash signs the given hash with the given private key.
+//
+// The private key is copied into a temporary buffer that is zeroed after
+// signing. Neither the key nor the buffer may ever be logged or included in
+// error messages.
 func ecSignHash(key *ecdsa.PrivateKey, hash types.Hash) (*types.Signature, error) {
 	if key == nil {
 		return nil, fmt.Errorf("missing private key")
 	}
-	privKey, _ := btcec.PrivKeyFromBytes(key.D.Bytes())
+	if key.D == nil || key.D.Sign() == 0 {
+		return nil, fmt.Errorf("private key is destroyed")
+	}
+	var d [32]byte
+	key.D.FillBytes(d[:])
+	privKey, _ := btcec.PrivKeyFromBytes(d[:])
+	ZeroBytes(d[:])
+	defer privKey.Zero()
 	sig, err := btcececdsa.SignCompact(privKey, hash.Bytes(), false)
 	if err != nil {
 		return nil, err
@@ -42,6 +53,26 @@ func ecSignHash(key *ecdsa.PrivateKey, hash types.Hash) (*types.Signature, error
 	return types.SignatureFromBytesPtr(sig), nil
 }
 
+// ZeroECPrivateKey overwrites the private scalar of the given key with zeros.
+// The key cannot be used for signing afterwards.
+func ZeroECPrivateKey(key *ecdsa.PrivateKey) {
+	if key == nil || key.D == nil {
+		return
+	}
+	words := key.D.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	key.D.SetInt64(0)
+}
+
+// ZeroBytes overwrites the given slice with zeros.
+func ZeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
 // ecSignMessage signs the given message with the given private key.
 func ecSignMessage(key *ecdsa.PrivateKey, data []byte) (*types.Signature, error) {
 	if key == nil {
@@ -72,22 +103,12 @@ func ecSignTransaction(key *ecdsa.PrivateKey, tx *types.Transaction) error {
 	if err != nil {
 		return err
 	}
-	sv, sr, ss := sig.V, sig.R, sig.S
-	switch tx.Type {
-	case types.LegacyTxType:
-		if tx.ChainID != nil {
-			sv = new(big.Int).Add(sv, new(big.Int).SetUint64(*tx.ChainID*2))
-			sv = new(big.Int).Add(sv, big.NewInt(35))
-		} else {
-			sv = new(big.Int).Add(sv, big.NewInt(27))
-		}
-	case types.AccessListTxType:
-	case types.DynamicFeeTxType:
-	default:
-		return fmt.Errorf("unsupported transaction type: %d", tx.Type)
+	txSig, err := TransactionSignature(tx, *sig)
+	if err != nil {
+		return err
 	}
 	tx.From = &from
-	tx.Signature = types.SignatureFromVRSPtr(sv, sr, ss)
+	tx.Signature = txSig
 	return nil
 }
 
@@ -135,7 +156,13 @@ func ecRecoverTransaction(tx *types.Transaction) (*types.Address, error) {
 	sig := *tx.Signature
 	switch tx.Type {
 	case types.LegacyTxType:
+		// The signing hash is selected by the V value. An explicitly
+		// selected signing mode must match it.
+		cpy := *tx
 		if tx.Signature.V.Cmp(big.NewInt(35)) >= 0 {
+			if tx.LegacySigningMode == types.HomesteadLegacySigning {
+				return nil, errors.New("signature V value does not match the pre-EIP-155 signing mode")
+			}
 			x := new(big.Int).Sub(sig.V, big.NewInt(35))
 
 			// Derive the chain ID from the signature.
@@ -143,16 +170,27 @@ func ecRecoverTransaction(tx *types.Transaction) (*types.Address, error) {
 			if tx.ChainID != nil && *tx.ChainID != chainID.Uint64() {
 				return nil, fmt.Errorf("invalid chain ID: %d", chainID)
 			}
+			id := chainID.Uint64()
+			cpy.ChainID = &id
+			cpy.LegacySigningMode = types.EIP155LegacySigning
 
 			// Derive the recovery byte from the signature.
 			sig.V = new(big.Int).Add(new(big.Int).Mod(x, big.NewInt(2)), big.NewInt(27))
 		} else {
+			if tx.LegacySigningMode == types.EIP155LegacySigning {
+				return nil, errors.New("signature V value does not match the EIP-155 signing mode")
+			}
+			cpy.LegacySigningMode = types.HomesteadLegacySigning
 			sig.V = new(big.Int).Sub(sig.V, big.NewInt(27))
 		}
+		tx = &cpy
 	case types.AccessListTxType:
 	case types.DynamicFeeTxType:
+	case types.SetCodeTxType:
 	default:
-		return nil, fmt.Errorf("unsupported transaction type: %d", tx.Type)
+		if _, ok := types.LookupTransactionType(tx.Type); !ok {
+			return nil, fmt.Errorf("unsupported transaction type: %d", tx.Type)
+		}
 	}
 	hash, err := signingHash(tx)
 	if err != nil {
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/defiweb/go-rlp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -103,6 +104,28 @@ func Test_ecSignTransaction(t *testing.T) {
 		assert.Equal(t, "62072d055f9ceb871a47f2d81aeb5aa34df50c625da16c6d0d57d232fa3cd152", tx.Signature.R.Text(16))
 		assert.Equal(t, "57fd88df7c85076f5729493be7e87f51b618a78bc89441ed741bdfdb9d1d5572", tx.Signature.S.Text(16))
 	})
+	t.Run("set-code", func(t *testing.T) {
+		key, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+		tx := (&types.Transaction{}).
+			SetType(types.SetCodeTxType).
+			SetTo(types.MustAddressFromHex("0x3535353535353535353535353535353535353535")).
+			SetGasLimit(21000).
+			SetMaxFeePerGas(big.NewInt(20000000000)).
+			SetMaxPriorityFeePerGas(big.NewInt(20000000000)).
+			SetNonce(9).
+			SetAuthorizationList(types.AuthorizationList{{
+				ChainID: 1,
+				Address: types.MustAddressFromHex("0x3535353535353535353535353535353535353535"),
+				Nonce:   10,
+			}})
+		err := ecSignTransaction(key.ToECDSA(), tx)
+		require.NoError(t, err)
+		assert.True(t, tx.Signature.V.Cmp(big.NewInt(1)) <= 0)
+
+		addr, err := ecRecoverTransaction(tx)
+		require.NoError(t, err)
+		assert.Equal(t, *tx.From, *addr)
+	})
 }
 
 func Test_ecRecoverHash(t *testing.T) {
@@ -257,3 +280,106 @@ func Test_fuzzECSignMessage(t *testing.T) {
 		require.Equal(t, ECPublicKeyToAddress(key.PubKey().ToECDSA()), *addr)
 	}
 }
+
+// testExtension is a transaction extension with a single uint field.
+type testExtension struct {
+	Field uint64 `json:"field"`
+}
+
+func (e *testExtension) EncodeRLPFields() ([]rlp.Item, error) {
+	return []rlp.Item{rlp.NewUint(e.Field)}, nil
+}
+
+func (e *testExtension) DecodeRLPFields(fields []*rlp.RLP) error {
+	var err error
+	e.Field, err = fields[0].GetUint()
+	return err
+}
+
+func (e *testExtension) CopyExtension() types.TransactionExtension {
+	c := *e
+	return &c
+}
+
+func Test_ecSignTransaction_CustomType(t *testing.T) {
+	const customTxType types.TransactionType = 0x7e
+	require.NoError(t, types.RegisterTransactionType(customTxType, types.CustomTransactionType{
+		Base:         types.DynamicFeeTxType,
+		NewExtension: func() types.TransactionExtension { return &testExtension{} },
+	}))
+	t.Cleanup(func() { types.UnregisterTransactionType(customTxType) })
+
+	key, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	tx := (&types.Transaction{}).
+		SetType(customTxType).
+		SetTo(types.MustAddressFromHex("0x3535353535353535353535353535353535353535")).
+		SetGasLimit(21000).
+		SetMaxPriorityFeePerGas(big.NewInt(1000000000)).
+		SetMaxFeePerGas(big.NewInt(20000000000)).
+		SetNonce(9).
+		SetChainID(1)
+	tx.Extension = &testExtension{Field: 1}
+	require.NoError(t, ecSignTransaction(key.ToECDSA(), tx))
+
+	addr, err := ecRecoverTransaction(tx)
+	require.NoError(t, err)
+	assert.Equal(t, *tx.From, *addr)
+
+	// The extension fields must be covered by the signature.
+	tx.Extension = &testExtension{Field: 2}
+	addr, err = ecRecoverTransaction(tx)
+	require.NoError(t, err)
+	assert.NotEqual(t, *tx.From, *addr)
+}
+
+func Test_ecSignTransaction_LegacySigningMode(t *testing.T) {
+	key, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	newTx := func() *types.Transaction {
+		return (&types.Transaction{}).
+			SetType(types.LegacyTxType).
+			SetTo(types.MustAddressFromHex("0x3535353535353535353535353535353535353535")).
+			SetGasLimit(21000).
+			SetGasPrice(big.NewInt(20000000000)).
+			SetNonce(9).
+			SetValue(big.NewInt(1000000000000000000)).
+			SetChainID(1)
+	}
+	t.Run("homestead", func(t *testing.T) {
+		tx := newTx().SetLegacySigningMode(types.HomesteadLegacySigning)
+		require.NoError(t, ecSignTransaction(key.ToECDSA(), tx))
+
+		// The chain ID is ignored, so the signature is the same as for a
+		// transaction without the chain ID.
+		assert.Equal(t, "1b", tx.Signature.V.Text(16))
+		assert.Equal(t, "2bfad43ba1b40e7f3ffb6342b1a6eecc700dd344fb0aba543aed5c10fd1a9470", tx.Signature.R.Text(16))
+
+		addr, err := ecRecoverTransaction(tx)
+		require.NoError(t, err)
+		assert.Equal(t, *tx.From, *addr)
+
+		// The V value selects the signing hash during recovery, even in the
+		// auto mode with the chain ID set.
+		tx.LegacySigningMode = types.AutoLegacySigning
+		addr, err = ecRecoverTransaction(tx)
+		require.NoError(t, err)
+		assert.Equal(t, *tx.From, *addr)
+
+		tx.LegacySigningMode = types.EIP155LegacySigning
+		_, err = ecRecoverTransaction(tx)
+		assert.Error(t, err)
+	})
+	t.Run("eip155", func(t *testing.T) {
+		tx := newTx().SetLegacySigningMode(types.EIP155LegacySigning)
+		require.NoError(t, ecSignTransaction(key.ToECDSA(), tx))
+		assert.Equal(t, "26", tx.Signature.V.Text(16))
+
+		tx.LegacySigningMode = types.HomesteadLegacySigning
+		_, err := ecRecoverTransaction(tx)
+		assert.Error(t, err)
+	})
+	t.Run("eip155-without-chain-id", func(t *testing.T) {
+		tx := newTx().SetLegacySigningMode(types.EIP155LegacySigning)
+		tx.ChainID = nil
+		assert.Error(t, ecSignTransaction(key.ToECDSA(), tx))
+	})
+}
@@ -9,6 +9,47 @@ import (
 	"github.com/defiweb/go-eth/types"
 )
 
+// TransactionSigningHash returns the hash of the transaction that is signed
+// to produce the transaction signature.
+//
+// Together with TransactionSignature, it allows to sign transactions using
+// signers that can only sign raw hashes, such as remote key management
+// services.
+func TransactionSigningHash(tx *types.Transaction) (types.Hash, error) {
+	return signingHash(tx)
+}
+
+// TransactionSignature converts a signature of the transaction signing hash,
+// whose V value is the recovery ID (0 or 1), to the transaction signature,
+// adjusting V for the transaction type and chain ID.
+func TransactionSignature(tx *types.Transaction, sig types.Signature) (*types.Signature, error) {
+	sv := sig.V
+	if sv == nil {
+		sv = new(big.Int)
+	}
+	switch tx.Type {
+	case types.LegacyTxType:
+		eip155, err := tx.UsesEIP155Signing()
+		if err != nil {
+			return nil, err
+		}
+		if eip155 {
+			sv = new(big.Int).Add(sv, new(big.Int).SetUint64(*tx.ChainID*2))
+			sv = new(big.Int).Add(sv, big.NewInt(35))
+		} else {
+			sv = new(big.Int).Add(sv, big.NewInt(27))
+		}
+	case types.AccessListTxType:
+	case types.DynamicFeeTxType:
+	case types.SetCodeTxType:
+	default:
+		if _, ok := types.LookupTransactionType(tx.Type); !ok {
+			return nil, fmt.Errorf("unsupported transaction type: %d", tx.Type)
+		}
+	}
+	return types.SignatureFromVRSPtr(sv, sig.R, sig.S), nil
+}
+
 func signingHash(t *types.Transaction) (types.Hash, error) {
 	var (
 		chainID              = uint64(1)
@@ -20,6 +61,7 @@ func signingHash(t *types.Transaction) (types.Hash, error) {
 		to                   = ([]byte)(nil)
 		value                = big.NewInt(0)
 		accessList           = (types.AccessList)(nil)
+		authorizationList    = (types.AuthorizationList)(nil)
 	)
 	if t.ChainID != nil {
 		chainID = *t.ChainID
@@ -48,6 +90,9 @@ func signingHash(t *types.Transaction) (types.Hash, error) {
 	if t.AccessList != nil {
 		accessList = t.AccessList
 	}
+	if t.AuthorizationList != nil {
+		authorizationList = t.AuthorizationList
+	}
 	switch t.Type {
 	case types.LegacyTxType:
 		list := rlp.NewList(
@@ -58,7 +103,11 @@ func signingHash(t *types.Transaction) (types.Hash, error) {
 			rlp.NewBigInt(value),
 			rlp.NewBytes(t.Input),
 		)
-		if t.ChainID != nil && *t.ChainID != 0 {
+		eip155, err := t.UsesEIP155Signing()
+		if err != nil {
+			return types.Hash{}, err
+		}
+		if eip155 {
 			list.Append(
 				rlp.NewUint(chainID),
 				rlp.NewUint(0),
@@ -103,7 +152,32 @@ func signingHash(t *types.Transaction) (types.Hash, error) {
 		}
 		bin = append([]byte{byte(t.Type)}, bin...)
 		return Keccak256(bin), nil
+	case types.SetCodeTxType:
+		bin, err := rlp.NewList(
+			rlp.NewUint(chainID),
+			rlp.NewUint(nonce),
+			rlp.NewBigInt(maxPriorityFeePerGas),
+			rlp.NewBigInt(maxFeePerGas),
+			rlp.NewUint(gasLimit),
+			rlp.NewBytes(to),
+			rlp.NewBigInt(value),
+			rlp.NewBytes(t.Input),
+			&accessList,
+			&authorizationList,
+		).EncodeRLP()
+		if err != nil {
+			return types.Hash{}, err
+		}
+		bin = append([]byte{byte(t.Type)}, bin...)
+		return Keccak256(bin), nil
 	default:
+		if _, ok := types.LookupTransactionType(t.Type); ok {
+			bin, err := t.EncodeUnsignedRLP()
+			if err != nil {
+				return types.Hash{}, err
+			}
+			return Keccak256(bin), nil
+		}
 		return types.Hash{}, fmt.Errorf("invalid transaction type: %d", t.Type)
 	}
 }
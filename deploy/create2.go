@@ -0,0 +1,148 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/types"
+)
+
+// ErrDeployerNotDeployed is returned when the CREATE2 deployer contract does
+// not exist on the chain.
+var ErrDeployerNotDeployed = errors.New("deploy: CREATE2 deployer is not deployed")
+
+// Deployer deploys contracts using a CREATE2 deployer contract that takes
+// the salt followed by the init code as the call data, such as
+// DeterministicDeployer.
+type Deployer struct {
+	opts DeployerOptions
+}
+
+// DeployerOptions is the options for NewDeployer.
+type DeployerOptions struct {
+	// Client is the RPC client used to interact with the chain. To send
+	// transactions, the client must be able to sign them, e.g. using the
+	// rpc.WithKeys option.
+	Client rpc.RPC
+
+	// Factory is the address of the CREATE2 deployer. If nil,
+	// DeterministicDeployer is used.
+	Factory *types.Address
+}
+
+// NewDeployer returns a new Deployer.
+func NewDeployer(opts DeployerOptions) (*Deployer, error) {
+	if opts.Client == nil {
+		return nil, errors.New("deploy: client is required")
+	}
+	if opts.Factory == nil {
+		opts.Factory = &DeterministicDeployer
+	}
+	return &Deployer{opts: opts}, nil
+}
+
+// Factory returns the address of the CREATE2 deployer.
+func (d *Deployer) Factory() types.Address {
+	return *d.opts.Factory
+}
+
+// Address returns the address of the contract deployed with the given salt
+// and init code.
+func (d *Deployer) Address(salt types.Hash, initCode []byte) types.Address {
+	return crypto.Create2Address(*d.opts.Factory, salt, initCode)
+}
+
+// IsDeployed returns true if there is code at the given address.
+func (d *Deployer) IsDeployed(ctx context.Context, addr types.Address) (bool, error) {
+	code, err := d.opts.Client.GetCode(ctx, addr, types.LatestBlockNumber)
+	if err != nil {
+		return false, err
+	}
+	return len(code) > 0, nil
+}
+
+// DeployTransaction returns a transaction that deploys the contract with
+// the given salt and init code.
+func (d *Deployer) DeployTransaction(salt types.Hash, initCode []byte) *types.Transaction {
+	return types.NewTransaction().
+		SetTo(*d.opts.Factory).
+		SetInput(deployInput(salt, initCode))
+}
+
+// Deploy deploys the contract with the given salt and init code, sending the
+// transaction from the given address, unless the contract is already
+// deployed. It returns the contract address and the transaction hash. The
+// hash is nil if the contract was already deployed.
+//
+// Before sending the transaction, the deployment is simulated and the
+// returned address is compared with the predicted one. The function does not
+// wait for the transaction to be mined.
+func (d *Deployer) Deploy(ctx context.Context, from types.Address, salt types.Hash, initCode []byte) (*types.Address, *types.Hash, error) {
+	addr := d.Address(salt, initCode)
+	deployed, err := d.IsDeployed(ctx, addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if deployed {
+		return &addr, nil, nil
+	}
+	factoryDeployed, err := d.IsDeployed(ctx, *d.opts.Factory)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !factoryDeployed {
+		return nil, nil, ErrDeployerNotDeployed
+	}
+	tx := d.DeployTransaction(salt, initCode).SetFrom(from)
+	res, _, err := d.opts.Client.Call(ctx, &tx.Call, types.LatestBlockNumber)
+	if err != nil {
+		return nil, nil, fmt.Errorf("deploy: deployment simulation failed: %w", err)
+	}
+	if !bytes.Equal(res, addr.Bytes()) {
+		return nil, nil, fmt.Errorf("deploy: deployer returned unexpected address 0x%x, expected %s", res, addr)
+	}
+	hash, _, err := d.opts.Client.SendTransaction(ctx, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &addr, hash, nil
+}
+
+// DeployKeyless sends the keyless deployment transaction, unless the contract
+// is already deployed. It returns the transaction hash, or nil if the
+// contract was already deployed.
+//
+// The signer of the deployment must already be funded. It is typically used
+// with DeterministicDeployerDeployment to deploy the CREATE2 deployer on a
+// new chain.
+func DeployKeyless(ctx context.Context, client rpc.RPC, d *KeylessDeployment) (*types.Hash, error) {
+	code, err := client.GetCode(ctx, d.Address, types.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) > 0 {
+		return nil, nil
+	}
+	balance, err := client.GetBalance(ctx, d.Signer, types.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if balance.Cmp(d.Cost) < 0 {
+		return nil, fmt.Errorf("deploy: keyless signer %s has insufficient funds: have %s, need %s", d.Signer, balance, d.Cost)
+	}
+	raw, err := d.RawTransaction()
+	if err != nil {
+		return nil, err
+	}
+	return client.SendRawTransaction(ctx, raw)
+}
+
+func deployInput(salt types.Hash, initCode []byte) []byte {
+	input := make([]byte, 0, types.HashLength+len(initCode))
+	input = append(input, salt.Bytes()...)
+	return append(input, initCode...)
+}
@@ -0,0 +1,113 @@
+package deploy
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/types"
+)
+
+type fakeRPC struct {
+	rpc.Client
+
+	code    map[types.Address][]byte
+	balance *big.Int
+	callRes []byte
+	sent    []*types.Transaction
+	sentRaw [][]byte
+}
+
+func (f *fakeRPC) GetCode(_ context.Context, addr types.Address, _ types.BlockNumber) ([]byte, error) {
+	return f.code[addr], nil
+}
+
+func (f *fakeRPC) GetBalance(context.Context, types.Address, types.BlockNumber) (*big.Int, error) {
+	return f.balance, nil
+}
+
+func (f *fakeRPC) Call(_ context.Context, call *types.Call, _ types.BlockNumber) ([]byte, *types.Call, error) {
+	return f.callRes, call, nil
+}
+
+func (f *fakeRPC) SendTransaction(_ context.Context, tx *types.Transaction) (*types.Hash, *types.Transaction, error) {
+	f.sent = append(f.sent, tx)
+	return &types.Hash{1}, tx, nil
+}
+
+func (f *fakeRPC) SendRawTransaction(_ context.Context, data []byte) (*types.Hash, error) {
+	f.sentRaw = append(f.sentRaw, data)
+	return &types.Hash{2}, nil
+}
+
+func TestDeployer_Deploy(t *testing.T) {
+	var (
+		from     = types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+		salt     = types.MustHashFromHex("0x01", types.PadLeft)
+		initCode = []byte{0x60, 0x00}
+	)
+	client := &fakeRPC{code: map[types.Address][]byte{DeterministicDeployer: {0x01}}}
+	d, err := NewDeployer(DeployerOptions{Client: client})
+	require.NoError(t, err)
+	addr := d.Address(salt, initCode)
+
+	// Deployer returns an unexpected address.
+	client.callRes = make([]byte, types.AddressLength)
+	_, _, err = d.Deploy(context.Background(), from, salt, initCode)
+	require.Error(t, err)
+	require.Empty(t, client.sent)
+
+	// Contract is deployed.
+	client.callRes = addr.Bytes()
+	got, hash, err := d.Deploy(context.Background(), from, salt, initCode)
+	require.NoError(t, err)
+	assert.Equal(t, addr, *got)
+	require.NotNil(t, hash)
+	require.Len(t, client.sent, 1)
+	assert.Equal(t, DeterministicDeployer, *client.sent[0].To)
+	assert.Equal(t, from, *client.sent[0].From)
+	assert.Equal(t, append(salt.Bytes(), initCode...), client.sent[0].Input)
+
+	// Contract is already deployed.
+	client.code[addr] = []byte{0x01}
+	got, hash, err = d.Deploy(context.Background(), from, salt, initCode)
+	require.NoError(t, err)
+	assert.Equal(t, addr, *got)
+	assert.Nil(t, hash)
+	assert.Len(t, client.sent, 1)
+}
+
+func TestDeployer_DeployerNotDeployed(t *testing.T) {
+	client := &fakeRPC{}
+	d, err := NewDeployer(DeployerOptions{Client: client})
+	require.NoError(t, err)
+	_, _, err = d.Deploy(context.Background(), types.ZeroAddress, types.Hash{}, []byte{0x00})
+	require.ErrorIs(t, err, ErrDeployerNotDeployed)
+}
+
+func TestDeployKeyless(t *testing.T) {
+	deployment := DeterministicDeployerDeployment()
+	client := &fakeRPC{code: map[types.Address][]byte{}, balance: big.NewInt(1)}
+
+	// Signer is not funded.
+	_, err := DeployKeyless(context.Background(), client, deployment)
+	require.Error(t, err)
+
+	// Deployment is sent.
+	client.balance = deployment.Cost
+	hash, err := DeployKeyless(context.Background(), client, deployment)
+	require.NoError(t, err)
+	require.NotNil(t, hash)
+	require.Len(t, client.sentRaw, 1)
+
+	// Contract is already deployed.
+	client.code[DeterministicDeployer] = []byte{0x01}
+	hash, err = DeployKeyless(context.Background(), client, deployment)
+	require.NoError(t, err)
+	assert.Nil(t, hash)
+	assert.Len(t, client.sentRaw, 1)
+}
@@ -0,0 +1,99 @@
+// Package deploy provides helpers for deterministic contract deployments:
+// keyless deployment transactions (Nick's method) and deployments through
+// the canonical CREATE2 deployer.
+package deploy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/hexutil"
+	"github.com/defiweb/go-eth/types"
+)
+
+// keylessSignatureValue is the value used for the R and S components of
+// keyless deployment signatures. Since nobody knows the private key of the
+// recovered signer, the signer can only ever send this one transaction.
+var keylessSignatureValue = new(big.Int).SetBytes(bytes.Repeat([]byte{0x22}, 32))
+
+// KeylessDeployment is a contract creation transaction with a made-up
+// signature, known as Nick's method.
+//
+// Because the transaction does not include a chain ID, it can be sent to any
+// EVM chain. Once the signer is funded with Cost, anybody can broadcast the
+// transaction and the contract is deployed at the same address on every
+// chain.
+type KeylessDeployment struct {
+	Transaction *types.Transaction // Transaction is the signed contract creation transaction.
+	Signer      types.Address      // Signer is the address recovered from the signature.
+	Address     types.Address      // Address is the address of the deployed contract.
+	Cost        *big.Int           // Cost is the amount of ether the signer must hold.
+}
+
+// NewKeylessDeployment creates a keyless deployment transaction for the given
+// init code.
+//
+// Because the gas price and gas limit are part of the signed data, they
+// cannot be changed later. The gas price must be high enough to be accepted
+// by all target chains, and the gas limit must be sufficient for the
+// deployment on all of them.
+func NewKeylessDeployment(initCode []byte, gasPrice *big.Int, gasLimit uint64) (*KeylessDeployment, error) {
+	if len(initCode) == 0 {
+		return nil, errors.New("deploy: init code is empty")
+	}
+	if gasPrice == nil || gasPrice.Sign() <= 0 {
+		return nil, errors.New("deploy: gas price must be positive")
+	}
+	tx := types.NewTransaction().
+		SetType(types.LegacyTxType).
+		SetNonce(0).
+		SetGasPrice(gasPrice).
+		SetGasLimit(gasLimit).
+		SetValue(big.NewInt(0)).
+		SetInput(initCode).
+		SetSignature(types.SignatureFromVRS(
+			big.NewInt(27),
+			new(big.Int).Set(keylessSignatureValue),
+			new(big.Int).Set(keylessSignatureValue),
+		))
+	signer, err := crypto.ECRecoverer.RecoverTransaction(tx)
+	if err != nil {
+		return nil, fmt.Errorf("deploy: failed to recover keyless signer: %w", err)
+	}
+	tx.SetFrom(*signer)
+	return &KeylessDeployment{
+		Transaction: tx,
+		Signer:      *signer,
+		Address:     crypto.CreateAddress(*signer, 0),
+		Cost:        new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(gasLimit)),
+	}, nil
+}
+
+// RawTransaction returns the RLP encoded transaction that can be sent using
+// eth_sendRawTransaction.
+func (d *KeylessDeployment) RawTransaction() ([]byte, error) {
+	return d.Transaction.EncodeRLP()
+}
+
+// DeterministicDeployer is the address of the CREATE2 deployer deployed by
+// DeterministicDeployerDeployment on most EVM chains. The deployer takes
+// the salt followed by the init code as the call data and returns the
+// address of the deployed contract.
+var DeterministicDeployer = types.MustAddressFromHex("0x4e59b44847b379578588920cA78FbF26c0B4956C")
+
+// DeterministicDeployerCode is the init code of the CREATE2 deployer from
+// https://github.com/Arachnid/deterministic-deployment-proxy.
+var DeterministicDeployerCode = hexutil.MustHexToBytes("0x604580600e600039806000f350fe7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe03601600081602082378035828234f58015156039578182fd5b8082525050506014600cf3")
+
+// DeterministicDeployerDeployment returns the keyless deployment of the
+// CREATE2 deployer at the DeterministicDeployer address.
+func DeterministicDeployerDeployment() *KeylessDeployment {
+	d, err := NewKeylessDeployment(DeterministicDeployerCode, big.NewInt(100_000_000_000), 100_000)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
@@ -0,0 +1,38 @@
+package deploy
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/hexutil"
+	"github.com/defiweb/go-eth/types"
+)
+
+func TestDeterministicDeployerDeployment(t *testing.T) {
+	d := DeterministicDeployerDeployment()
+	assert.Equal(t, types.MustAddressFromHex("0x3fab184622dc19b6109349b94811493bf2a45362"), d.Signer)
+	assert.Equal(t, DeterministicDeployer, d.Address)
+	assert.Equal(t, big.NewInt(10_000_000_000_000_000), d.Cost)
+
+	raw, err := d.RawTransaction()
+	require.NoError(t, err)
+	assert.Equal(t, "0xf8a58085174876e800830186a08080b853604580600e600039806000f350fe7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffe03601600081602082378035828234f58015156039578182fd5b8082525050506014600cf31ba02222222222222222222222222222222222222222222222222222222222222222a02222222222222222222222222222222222222222222222222222222222222222", hexutil.BytesToHex(raw))
+}
+
+func TestNewKeylessDeployment(t *testing.T) {
+	_, err := NewKeylessDeployment(nil, big.NewInt(1), 100_000)
+	require.Error(t, err)
+	_, err = NewKeylessDeployment([]byte{0x00}, nil, 100_000)
+	require.Error(t, err)
+
+	// Different parameters result in a different signer.
+	d1, err := NewKeylessDeployment([]byte{0x00}, big.NewInt(1), 100_000)
+	require.NoError(t, err)
+	d2, err := NewKeylessDeployment([]byte{0x00}, big.NewInt(2), 100_000)
+	require.NoError(t, err)
+	assert.NotEqual(t, d1.Signer, d2.Signer)
+	assert.NotEqual(t, d1.Address, d2.Address)
+}
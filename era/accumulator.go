@@ -0,0 +1,66 @@
+package era
+
+import (
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// HeaderRecord is an element of the accumulator of an era1 file.
+type HeaderRecord struct {
+	BlockHash       types.Hash // BlockHash is the hash of the block.
+	TotalDifficulty *big.Int   // TotalDifficulty is the total difficulty of the chain at the block.
+}
+
+// AccumulatorRoot calculates the accumulator root of the given header
+// records, which is the SSZ hash tree root of a list of records limited to
+// MaxBlocks elements.
+func AccumulatorRoot(records []HeaderRecord) (types.Hash, error) {
+	if len(records) > MaxBlocks {
+		return types.Hash{}, errors.New("era: too many header records")
+	}
+	leaves := make([][32]byte, len(records))
+	for i, r := range records {
+		var td [32]byte
+		if r.TotalDifficulty != nil {
+			if r.TotalDifficulty.Sign() < 0 || r.TotalDifficulty.BitLen() > 256 {
+				return types.Hash{}, errors.New("era: invalid total difficulty")
+			}
+			// SSZ integers are little-endian.
+			copy(td[:], reverse(r.TotalDifficulty.FillBytes(make([]byte, 32))))
+		}
+		leaves[i] = sha256.Sum256(append(r.BlockHash.Bytes(), td[:]...))
+	}
+	root := merkleize(leaves, MaxBlocks)
+	var length [32]byte
+	length[0] = byte(len(records))
+	length[1] = byte(len(records) >> 8)
+	return types.Hash(sha256.Sum256(append(root[:], length[:]...))), nil
+}
+
+// merkleize returns the root of a binary merkle tree of the leaves, padded
+// with zero leaves up to the limit, which must be a power of two.
+func merkleize(leaves [][32]byte, limit int) [32]byte {
+	var zero [32]byte
+	layer := leaves
+	for width := limit; width > 1; width /= 2 {
+		next := make([][32]byte, (len(layer)+1)/2)
+		for i := range next {
+			left, right := layer[2*i], zero
+			if 2*i+1 < len(layer) {
+				right = layer[2*i+1]
+			}
+			next[i] = sha256.Sum256(append(left[:], right[:]...))
+		}
+		// Zero subtrees of the current depth are hashed from the
+		// previous zero subtrees.
+		zero = sha256.Sum256(append(zero[:], zero[:]...))
+		layer = next
+	}
+	if len(layer) == 0 {
+		return zero
+	}
+	return layer[0]
+}
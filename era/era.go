@@ -0,0 +1,532 @@
+// Package era reads era1 archive files.
+//
+// Era1 files store pre-merge history in groups of up to 8192 blocks: the
+// header, body, receipts and total difficulty of every block, followed by
+// the accumulator root of the group and an index of the blocks. They allow
+// processing historical blocks from static files instead of an RPC node.
+//
+// The format is specified in https://github.com/eth-clients/e2store-format-specs.
+package era
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+
+	"github.com/defiweb/go-rlp"
+	"github.com/golang/snappy"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/types"
+)
+
+// Entry types of the e2store format used by era1 files.
+const (
+	VersionType            uint16 = 0x3265
+	CompressedHeaderType   uint16 = 0x03
+	CompressedBodyType     uint16 = 0x04
+	CompressedReceiptsType uint16 = 0x05
+	TotalDifficultyType    uint16 = 0x06
+	AccumulatorType        uint16 = 0x07
+	BlockIndexType         uint16 = 0x3266
+)
+
+// MaxBlocks is the maximum number of blocks in an era1 file.
+const MaxBlocks = 8192
+
+// entryHeaderLength is the length of the header of an e2store entry: the
+// type, the data length and two reserved bytes.
+const entryHeaderLength = 8
+
+// ErrNotFound is returned when a block is not stored in the file.
+var ErrNotFound = errors.New("era: block not found")
+
+// Reader reads blocks from an era1 file.
+//
+// Reader is safe for concurrent use if the underlying io.ReaderAt is.
+type Reader struct {
+	r       io.ReaderAt
+	closer  io.Closer
+	start   uint64
+	offsets []int64
+}
+
+// Open opens the era1 file at the given path. The file must be closed with
+// Close.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("era: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("era: %w", err)
+	}
+	r, err := NewReader(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r.closer = f
+	return r, nil
+}
+
+// NewReader returns a Reader for an era1 file of the given size.
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	typ, data, err := readEntry(r, 0)
+	if err != nil {
+		return nil, err
+	}
+	if typ != VersionType || len(data) != 0 {
+		return nil, errors.New("era: invalid version entry")
+	}
+	// The block index is the last entry: the starting block number, the
+	// offset of every block and the number of blocks, all 8 bytes long.
+	var buf [8]byte
+	if size < 8 {
+		return nil, errors.New("era: file too short")
+	}
+	if _, err := r.ReadAt(buf[:], size-8); err != nil {
+		return nil, fmt.Errorf("era: failed to read block count: %w", err)
+	}
+	count := binary.LittleEndian.Uint64(buf[:])
+	if count == 0 || count > MaxBlocks {
+		return nil, fmt.Errorf("era: invalid block count %d", count)
+	}
+	indexOffset := size - entryHeaderLength - 16 - int64(count)*8
+	if indexOffset < 0 {
+		return nil, errors.New("era: file too short")
+	}
+	typ, index, err := readEntry(r, indexOffset)
+	if err != nil {
+		return nil, err
+	}
+	if typ != BlockIndexType || len(index) != 16+int(count)*8 {
+		return nil, errors.New("era: invalid block index")
+	}
+	e := &Reader{
+		r:       r,
+		start:   binary.LittleEndian.Uint64(index),
+		offsets: make([]int64, count),
+	}
+	for i := range e.offsets {
+		// Offsets are relative to the beginning of the block index.
+		e.offsets[i] = indexOffset + int64(binary.LittleEndian.Uint64(index[8+i*8:]))
+		if e.offsets[i] < 0 || e.offsets[i] >= indexOffset {
+			return nil, fmt.Errorf("era: invalid offset of block %d", e.start+uint64(i))
+		}
+	}
+	return e, nil
+}
+
+// Close closes the file opened with Open. It is a no-op for readers created
+// with NewReader.
+func (e *Reader) Close() error {
+	if e.closer == nil {
+		return nil
+	}
+	return e.closer.Close()
+}
+
+// Start returns the number of the first block in the file.
+func (e *Reader) Start() uint64 {
+	return e.start
+}
+
+// Count returns the number of blocks in the file.
+func (e *Reader) Count() uint64 {
+	return uint64(len(e.offsets))
+}
+
+// Block returns the block with the given number, including its
+// transactions, uncle headers and total difficulty. The hashes of the block
+// and of its transactions are calculated from the stored data.
+func (e *Reader) Block(number uint64) (*types.Block, error) {
+	t, err := e.tuple(number)
+	if err != nil {
+		return nil, err
+	}
+	return t.block()
+}
+
+// Receipts returns the receipts of the transactions of the block with the
+// given number. Fields that are not stored in the file, such as the sender
+// and the contract address, are not set.
+func (e *Reader) Receipts(number uint64) ([]types.TransactionReceipt, error) {
+	t, err := e.tuple(number)
+	if err != nil {
+		return nil, err
+	}
+	block, err := t.block()
+	if err != nil {
+		return nil, err
+	}
+	return t.receipts(block)
+}
+
+// TotalDifficulty returns the total difficulty of the chain at the block with
+// the given number.
+func (e *Reader) TotalDifficulty(number uint64) (*big.Int, error) {
+	t, err := e.tuple(number)
+	if err != nil {
+		return nil, err
+	}
+	return t.td, nil
+}
+
+// Accumulator returns the accumulator root stored in the file.
+func (e *Reader) Accumulator() (types.Hash, error) {
+	offset, err := e.end()
+	if err != nil {
+		return types.Hash{}, err
+	}
+	for {
+		typ, data, err := readEntry(e.r, offset)
+		if err != nil {
+			return types.Hash{}, err
+		}
+		switch typ {
+		case AccumulatorType:
+			if len(data) != types.HashLength {
+				return types.Hash{}, errors.New("era: invalid accumulator entry")
+			}
+			return types.MustHashFromBytes(data, types.PadNone), nil
+		case BlockIndexType:
+			return types.Hash{}, errors.New("era: accumulator entry not found")
+		}
+		offset += entryHeaderLength + int64(len(data))
+	}
+}
+
+// Verify checks that every header matches its body and receipts, and that
+// the accumulator root calculated from the header hashes and total
+// difficulties matches the root stored in the file.
+//
+// Verify only proves that the file is consistent. To prove that it contains
+// canonical history, the accumulator root must be compared with a trusted
+// value, such as the historical roots of the beacon chain.
+func (e *Reader) Verify() error {
+	records := make([]HeaderRecord, 0, e.Count())
+	for n := e.start; n < e.start+e.Count(); n++ {
+		t, err := e.tuple(n)
+		if err != nil {
+			return err
+		}
+		block, err := t.block()
+		if err != nil {
+			return err
+		}
+		if block.Number == nil || block.Number.Uint64() != n {
+			return fmt.Errorf("era: unexpected number of block %d", n)
+		}
+		if uncles := crypto.Keccak256(t.unclesRaw); uncles != block.Sha3Uncles {
+			return fmt.Errorf("era: uncles of block %d do not match the header", n)
+		}
+		receipts, err := t.receipts(block)
+		if err != nil {
+			return err
+		}
+		if len(receipts) != len(block.Transactions) {
+			return fmt.Errorf("era: number of receipts of block %d does not match the number of transactions", n)
+		}
+		records = append(records, HeaderRecord{BlockHash: block.Hash, TotalDifficulty: t.td})
+	}
+	stored, err := e.Accumulator()
+	if err != nil {
+		return err
+	}
+	root, err := AccumulatorRoot(records)
+	if err != nil {
+		return err
+	}
+	if root != stored {
+		return fmt.Errorf("era: accumulator root mismatch: stored %s, calculated %s", stored, root)
+	}
+	return nil
+}
+
+// tuple is the raw data of a single block.
+type tuple struct {
+	number      uint64
+	header      []byte
+	body        []byte
+	unclesRaw   []byte
+	receiptsRaw []byte
+	td          *big.Int
+}
+
+// tuple reads the entries of the block with the given number.
+func (e *Reader) tuple(number uint64) (*tuple, error) {
+	if number < e.start || number >= e.start+e.Count() {
+		return nil, ErrNotFound
+	}
+	t := &tuple{number: number}
+	offset := e.offsets[number-e.start]
+	for _, typ := range []uint16{CompressedHeaderType, CompressedBodyType, CompressedReceiptsType, TotalDifficultyType} {
+		entryType, data, err := readEntry(e.r, offset)
+		if err != nil {
+			return nil, err
+		}
+		if entryType != typ {
+			return nil, fmt.Errorf("era: unexpected entry type %#04x of block %d, expected %#04x", entryType, number, typ)
+		}
+		offset += entryHeaderLength + int64(len(data))
+		switch typ {
+		case CompressedHeaderType:
+			t.header, err = decompress(data)
+		case CompressedBodyType:
+			t.body, err = decompress(data)
+		case CompressedReceiptsType:
+			t.receiptsRaw, err = decompress(data)
+		case TotalDifficultyType:
+			if len(data) != 32 {
+				return nil, fmt.Errorf("era: invalid total difficulty of block %d", number)
+			}
+			t.td = new(big.Int).SetBytes(reverse(data))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("era: failed to decompress entry of block %d: %w", number, err)
+		}
+	}
+	return t, nil
+}
+
+// end returns the offset of the first entry after the last block.
+func (e *Reader) end() (int64, error) {
+	offset := e.offsets[len(e.offsets)-1]
+	for i := 0; i < 4; i++ {
+		_, data, err := readEntry(e.r, offset)
+		if err != nil {
+			return 0, err
+		}
+		offset += entryHeaderLength + int64(len(data))
+	}
+	return offset, nil
+}
+
+// block decodes the block from the header and the body, which is a list of
+// the transactions and the uncle headers.
+func (t *tuple) block() (*types.Block, error) {
+	d, _, err := rlp.Decode(t.body)
+	if err != nil {
+		return nil, fmt.Errorf("era: invalid body of block %d: %w", t.number, err)
+	}
+	body, err := d.GetList()
+	if err != nil || len(body) < 2 {
+		return nil, fmt.Errorf("era: invalid body of block %d", t.number)
+	}
+	t.unclesRaw = body[1].Bytes()
+	header := rlp.RLP(t.header)
+	list := rlp.NewList(&header)
+	for _, item := range body {
+		list.Append(item)
+	}
+	raw, err := list.EncodeRLP()
+	if err != nil {
+		return nil, fmt.Errorf("era: %w", err)
+	}
+	block := &types.Block{}
+	if _, err := block.DecodeRLP(raw); err != nil {
+		return nil, fmt.Errorf("era: invalid block %d: %w", t.number, err)
+	}
+	block.Hash = crypto.Keccak256(t.header)
+	block.TotalDifficulty = t.td
+	for i := range block.Transactions {
+		tx := &block.Transactions[i]
+		raw, err := tx.Transaction.Raw()
+		if err != nil {
+			return nil, fmt.Errorf("era: invalid transaction %d of block %d: %w", i, t.number, err)
+		}
+		hash := crypto.Keccak256(raw)
+		tx.Hash = &hash
+		tx.BlockHash = &block.Hash
+	}
+	for _, uncle := range block.UncleHeaders {
+		hash, err := uncle.HeaderHash(crypto.Keccak256)
+		if err != nil {
+			return nil, fmt.Errorf("era: invalid uncle of block %d: %w", t.number, err)
+		}
+		block.Uncles = append(block.Uncles, hash)
+	}
+	return block, nil
+}
+
+// receipts decodes the receipts of the block. Receipts are stored in their
+// consensus encoding: legacy receipts as lists and typed receipts as
+// strings containing the type followed by the list.
+func (t *tuple) receipts(block *types.Block) ([]types.TransactionReceipt, error) {
+	d, _, err := rlp.Decode(t.receiptsRaw)
+	if err != nil {
+		return nil, fmt.Errorf("era: invalid receipts of block %d: %w", t.number, err)
+	}
+	items, err := d.GetList()
+	if err != nil {
+		return nil, fmt.Errorf("era: invalid receipts of block %d: %w", t.number, err)
+	}
+	var (
+		receipts = make([]types.TransactionReceipt, len(items))
+		prevGas  uint64
+		logIndex uint64
+	)
+	for i, item := range items {
+		r := &receipts[i]
+		if err := decodeReceipt(item, r); err != nil {
+			return nil, fmt.Errorf("era: invalid receipt %d of block %d: %w", i, t.number, err)
+		}
+		r.TransactionIndex = uint64(i)
+		r.BlockHash = block.Hash
+		r.BlockNumber = block.Number
+		r.GasUsed = r.CumulativeGasUsed - prevGas
+		prevGas = r.CumulativeGasUsed
+		if i < len(block.Transactions) {
+			tx := block.Transactions[i]
+			r.TransactionHash = *tx.Hash
+			if tx.To != nil {
+				r.To = *tx.To
+			}
+		}
+		for j := range r.Logs {
+			l := &r.Logs[j]
+			index, txIndex := logIndex, r.TransactionIndex
+			txHash := r.TransactionHash
+			l.BlockHash = &block.Hash
+			l.BlockNumber = block.Number
+			l.TransactionHash = &txHash
+			l.TransactionIndex = &txIndex
+			l.LogIndex = &index
+			logIndex++
+		}
+	}
+	return receipts, nil
+}
+
+// decodeReceipt decodes a receipt in its consensus encoding: a list of the
+// status or the post-transaction state root, the cumulative gas used, the
+// logs bloom and the logs.
+func decodeReceipt(item *rlp.RLP, r *types.TransactionReceipt) error {
+	data := item.Bytes()
+	if item.IsString() {
+		typed, err := item.GetBytes()
+		if err != nil {
+			return err
+		}
+		if len(typed) == 0 {
+			return errors.New("empty receipt")
+		}
+		data = typed[1:]
+	}
+	d, _, err := rlp.Decode(data)
+	if err != nil {
+		return err
+	}
+	fields, err := d.GetList()
+	if err != nil {
+		return err
+	}
+	if len(fields) != 4 {
+		return fmt.Errorf("invalid receipt list length %d", len(fields))
+	}
+	state, err := fields[0].GetBytes()
+	if err != nil {
+		return err
+	}
+	switch len(state) {
+	case types.HashLength:
+		root := types.MustHashFromBytes(state, types.PadNone)
+		r.Root = &root
+	case 0, 1:
+		status := new(big.Int).SetBytes(state).Uint64()
+		r.Status = &status
+	default:
+		return fmt.Errorf("invalid receipt status")
+	}
+	if r.CumulativeGasUsed, err = fields[1].GetUint(); err != nil {
+		return err
+	}
+	if r.LogsBloom, err = fields[2].GetBytes(); err != nil {
+		return err
+	}
+	logs, err := fields[3].GetList()
+	if err != nil {
+		return err
+	}
+	for _, item := range logs {
+		var log types.Log
+		if err := decodeLog(item, &log); err != nil {
+			return err
+		}
+		r.Logs = append(r.Logs, log)
+	}
+	return nil
+}
+
+// decodeLog decodes a log encoded as a list of the address, the topics and
+// the data.
+func decodeLog(item *rlp.RLP, l *types.Log) error {
+	fields, err := item.GetList()
+	if err != nil {
+		return err
+	}
+	if len(fields) != 3 {
+		return fmt.Errorf("invalid log list length %d", len(fields))
+	}
+	if err := fields[0].DecodeTo(&l.Address); err != nil {
+		return err
+	}
+	topics, err := fields[1].GetList()
+	if err != nil {
+		return err
+	}
+	for _, t := range topics {
+		var topic types.Hash
+		if err := t.DecodeTo(&topic); err != nil {
+			return err
+		}
+		l.Topics = append(l.Topics, topic)
+	}
+	if l.Data, err = fields[2].GetBytes(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// readEntry reads the e2store entry at the given offset.
+func readEntry(r io.ReaderAt, offset int64) (uint16, []byte, error) {
+	var header [entryHeaderLength]byte
+	if _, err := r.ReadAt(header[:], offset); err != nil {
+		return 0, nil, fmt.Errorf("era: failed to read entry header at %d: %w", offset, err)
+	}
+	var (
+		typ      = binary.LittleEndian.Uint16(header[0:2])
+		length   = binary.LittleEndian.Uint32(header[2:6])
+		reserved = binary.LittleEndian.Uint16(header[6:8])
+	)
+	if reserved != 0 {
+		return 0, nil, fmt.Errorf("era: invalid entry header at %d", offset)
+	}
+	data := make([]byte, length)
+	if _, err := r.ReadAt(data, offset+entryHeaderLength); err != nil {
+		return 0, nil, fmt.Errorf("era: failed to read entry at %d: %w", offset, err)
+	}
+	return typ, data, nil
+}
+
+// decompress decompresses data compressed using the snappy framing format.
+func decompress(data []byte) ([]byte, error) {
+	return io.ReadAll(snappy.NewReader(bytes.NewReader(data)))
+}
+
+// reverse returns a reversed copy of b, to convert between little-endian
+// and big-endian integers.
+func reverse(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
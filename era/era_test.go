@@ -0,0 +1,222 @@
+package era
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/defiweb/go-rlp"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/types"
+)
+
+var (
+	emptyUncles = types.MustHashFromHex("0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347", types.PadNone)
+	recipient   = types.MustAddressFromHex("0x2222222222222222222222222222222222222222")
+)
+
+// testBlock is a block with its receipts and total difficulty, used to build
+// an era1 file.
+type testBlock struct {
+	block    types.Block
+	receipts [][]byte
+	td       *big.Int
+}
+
+func newTestBlocks(t *testing.T) []testBlock {
+	var (
+		nonce    = uint64(0)
+		gasLimit = uint64(21000)
+		chainID  = uint64(1)
+	)
+	tx := types.OnChainTransaction{Transaction: types.Transaction{
+		Call:      types.Call{To: &recipient, GasLimit: &gasLimit, GasPrice: big.NewInt(1e9), Value: big.NewInt(1)},
+		Type:      types.LegacyTxType,
+		Nonce:     &nonce,
+		ChainID:   &chainID,
+		Signature: types.SignatureFromVRSPtr(big.NewInt(37), big.NewInt(1), big.NewInt(2)),
+	}}
+	log := rlp.NewList(&recipient, rlp.NewList(&types.Hash{1}), rlp.NewBytes([]byte{1, 2, 3}))
+	receipt, err := rlp.NewList(rlp.NewUint(1), rlp.NewUint(21000), rlp.NewBytes(make([]byte, 256)), rlp.NewList(log)).EncodeRLP()
+	require.NoError(t, err)
+
+	var blocks []testBlock
+	for i := 0; i < 2; i++ {
+		b := types.Block{
+			Number:     big.NewInt(int64(1000 + i)),
+			Sha3Uncles: emptyUncles,
+			Difficulty: big.NewInt(100),
+			Nonce:      big.NewInt(int64(i)),
+			GasLimit:   5000000,
+			Timestamp:  time.Unix(int64(1500000000+i*15), 0),
+		}
+		var receipts [][]byte
+		if i == 0 {
+			b.GasUsed = 21000
+			b.Transactions = []types.OnChainTransaction{tx}
+			receipts = [][]byte{receipt}
+		}
+		if i > 0 {
+			b.ParentHash, err = blocks[i-1].block.HeaderHash(crypto.Keccak256)
+			require.NoError(t, err)
+		}
+		blocks = append(blocks, testBlock{block: b, receipts: receipts, td: big.NewInt(int64(100 * (i + 1)))})
+	}
+	return blocks
+}
+
+// writeEra1 builds an era1 file from the given blocks.
+func writeEra1(t *testing.T, blocks []testBlock) []byte {
+	var (
+		buf     bytes.Buffer
+		offsets []int64
+		records []HeaderRecord
+	)
+	writeEntry := func(typ uint16, data []byte) {
+		var header [entryHeaderLength]byte
+		binary.LittleEndian.PutUint16(header[0:2], typ)
+		binary.LittleEndian.PutUint32(header[2:6], uint32(len(data)))
+		buf.Write(header[:])
+		buf.Write(data)
+	}
+	compress := func(data []byte) []byte {
+		var c bytes.Buffer
+		w := snappy.NewBufferedWriter(&c)
+		_, err := w.Write(data)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		return c.Bytes()
+	}
+	writeEntry(VersionType, nil)
+	for _, b := range blocks {
+		offsets = append(offsets, int64(buf.Len()))
+		raw, err := b.block.EncodeRLP()
+		require.NoError(t, err)
+		d, _, err := rlp.Decode(raw)
+		require.NoError(t, err)
+		items, err := d.GetList()
+		require.NoError(t, err)
+		body, err := rlp.NewList(items[1], items[2]).EncodeRLP()
+		require.NoError(t, err)
+		receipts := rlp.NewList()
+		for _, r := range b.receipts {
+			item := rlp.RLP(r)
+			receipts.Append(&item)
+		}
+		rawReceipts, err := receipts.EncodeRLP()
+		require.NoError(t, err)
+		td := reverse(b.td.FillBytes(make([]byte, 32)))
+
+		writeEntry(CompressedHeaderType, compress(items[0].Bytes()))
+		writeEntry(CompressedBodyType, compress(body))
+		writeEntry(CompressedReceiptsType, compress(rawReceipts))
+		writeEntry(TotalDifficultyType, td)
+
+		hash, err := b.block.HeaderHash(crypto.Keccak256)
+		require.NoError(t, err)
+		records = append(records, HeaderRecord{BlockHash: hash, TotalDifficulty: b.td})
+	}
+	root, err := AccumulatorRoot(records)
+	require.NoError(t, err)
+	writeEntry(AccumulatorType, root.Bytes())
+
+	indexOffset := int64(buf.Len())
+	index := make([]byte, 16+len(offsets)*8)
+	binary.LittleEndian.PutUint64(index, blocks[0].block.Number.Uint64())
+	for i, off := range offsets {
+		binary.LittleEndian.PutUint64(index[8+i*8:], uint64(off-indexOffset))
+	}
+	binary.LittleEndian.PutUint64(index[len(index)-8:], uint64(len(offsets)))
+	writeEntry(BlockIndexType, index)
+	return buf.Bytes()
+}
+
+func TestReader(t *testing.T) {
+	blocks := newTestBlocks(t)
+	data := writeEra1(t, blocks)
+	path := filepath.Join(t.TempDir(), "mainnet-00000-00000000.era1")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	r, err := Open(path)
+	require.NoError(t, err)
+	defer r.Close()
+
+	assert.Equal(t, uint64(1000), r.Start())
+	assert.Equal(t, uint64(2), r.Count())
+	require.NoError(t, r.Verify())
+
+	block, err := r.Block(1000)
+	require.NoError(t, err)
+	hash, err := blocks[0].block.HeaderHash(crypto.Keccak256)
+	require.NoError(t, err)
+	assert.Equal(t, hash, block.Hash)
+	assert.Equal(t, big.NewInt(100), block.TotalDifficulty)
+	require.Len(t, block.Transactions, 1)
+	raw, err := blocks[0].block.Transactions[0].Raw()
+	require.NoError(t, err)
+	assert.Equal(t, crypto.Keccak256(raw), *block.Transactions[0].Hash)
+
+	receipts, err := r.Receipts(1000)
+	require.NoError(t, err)
+	require.Len(t, receipts, 1)
+	assert.Equal(t, uint64(1), *receipts[0].Status)
+	assert.Equal(t, uint64(21000), receipts[0].GasUsed)
+	assert.Equal(t, *block.Transactions[0].Hash, receipts[0].TransactionHash)
+	assert.Equal(t, recipient, receipts[0].To)
+	require.Len(t, receipts[0].Logs, 1)
+	assert.Equal(t, []byte{1, 2, 3}, receipts[0].Logs[0].Data)
+	assert.Equal(t, uint64(0), *receipts[0].Logs[0].LogIndex)
+
+	next, err := r.Block(1001)
+	require.NoError(t, err)
+	assert.Equal(t, block.Hash, next.ParentHash)
+	td, err := r.TotalDifficulty(1001)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(200), td)
+
+	_, err = r.Block(1002)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestReader_Verify_Tampered(t *testing.T) {
+	blocks := newTestBlocks(t)
+	data := writeEra1(t, blocks)
+	// Replace the total difficulty of the first block, which is stored
+	// uncompressed.
+	tdOffset := bytes.Index(data, reverse(big.NewInt(100).FillBytes(make([]byte, 32))))
+	require.Greater(t, tdOffset, 0)
+	tampered := append([]byte{}, data...)
+	tampered[tdOffset]++
+	r, err := NewReader(bytes.NewReader(tampered), int64(len(tampered)))
+	require.NoError(t, err)
+	assert.ErrorContains(t, r.Verify(), "accumulator root mismatch")
+}
+
+func TestNewReader_Invalid(t *testing.T) {
+	_, err := NewReader(bytes.NewReader([]byte{1, 2, 3}), 3)
+	assert.Error(t, err)
+
+	data := writeEra1(t, newTestBlocks(t))
+	data[0] = 0
+	_, err = NewReader(bytes.NewReader(data), int64(len(data)))
+	assert.Error(t, err)
+}
+
+func TestAccumulatorRoot(t *testing.T) {
+	empty, err := AccumulatorRoot(nil)
+	require.NoError(t, err)
+	one, err := AccumulatorRoot([]HeaderRecord{{BlockHash: types.Hash{1}, TotalDifficulty: big.NewInt(1)}})
+	require.NoError(t, err)
+	assert.NotEqual(t, empty, one)
+
+	_, err = AccumulatorRoot(make([]HeaderRecord, MaxBlocks+1))
+	assert.Error(t, err)
+}
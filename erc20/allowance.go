@@ -0,0 +1,242 @@
+// Package erc20 provides helpers to audit and revoke ERC-20 token
+// allowances.
+package erc20
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/rpc/transport"
+	"github.com/defiweb/go-eth/types"
+)
+
+var (
+	approvalEvent   = abi.MustParseEvent("Approval(address indexed owner, address indexed spender, uint256 value)")
+	allowanceMethod = abi.MustParseMethod("allowance(address owner, address spender) view returns (uint256)")
+	approveMethod   = abi.MustParseMethod("approve(address spender, uint256 value) returns (bool)")
+)
+
+// unlimitedThreshold is the allowance above which it is considered
+// unlimited. Wallets usually approve 2^256-1, but some tokens decrease the
+// allowance on every transfer, so anything above 2^255 is treated as
+// unlimited.
+var unlimitedThreshold = new(big.Int).Lsh(big.NewInt(1), 255)
+
+// Allowance is an allowance granted by a token holder to a spender.
+type Allowance struct {
+	Token   types.Address // Token is the address of the token contract.
+	Spender types.Address // Spender is the address allowed to spend tokens.
+
+	// Logged is the value from the last Approval event. It may differ from
+	// Current, because most tokens decrease the allowance in transferFrom
+	// without emitting an Approval event.
+	Logged *big.Int
+
+	// Current is the value returned by the allowance method of the token.
+	Current *big.Int
+
+	// Block is the number of the block of the last Approval event.
+	Block uint64
+}
+
+// Unlimited returns true if the current allowance is practically
+// unlimited.
+func (a Allowance) Unlimited() bool {
+	return a.Current != nil && a.Current.Cmp(unlimitedThreshold) >= 0
+}
+
+// Stale returns true if the current allowance differs from the value of the
+// last Approval event.
+func (a Allowance) Stale() bool {
+	return a.Logged == nil || a.Current == nil || a.Logged.Cmp(a.Current) != 0
+}
+
+// FindOptions is the options for FindAllowances.
+type FindOptions struct {
+	// Tokens limits the search to the given token contracts. If empty,
+	// Approval events of all contracts are inspected.
+	Tokens []types.Address
+
+	// FromBlock and ToBlock are the block range in which Approval events
+	// are searched. Current allowances are fetched at ToBlock. The default
+	// range is from the genesis block to the latest block.
+	FromBlock *types.BlockNumber
+	ToBlock   *types.BlockNumber
+
+	// ChunkSize is the maximum number of blocks per eth_getLogs request,
+	// for nodes that limit the block range of a single request. If 0, the
+	// whole range is requested at once. It requires both FromBlock and
+	// ToBlock to be block numbers, not tags.
+	ChunkSize uint64
+
+	// IncludeRevoked includes allowances that are currently zero.
+	IncludeRevoked bool
+}
+
+// FindAllowances enumerates the allowances granted by the owner using
+// Approval events and cross-checks them against the allowance method of the
+// token contracts.
+//
+// Allowances are sorted by token and spender. Contracts that emit a
+// matching Approval event but do not implement the allowance method, such
+// as ERC-721 tokens, are skipped.
+func FindAllowances(ctx context.Context, client rpc.RPC, owner types.Address, opts FindOptions) ([]Allowance, error) {
+	if client == nil {
+		return nil, errors.New("erc20: client is required")
+	}
+	logs, err := approvalLogs(ctx, client, owner, opts)
+	if err != nil {
+		return nil, err
+	}
+	type key struct{ token, spender types.Address }
+	latest := make(map[key]*Allowance)
+	for _, l := range logs {
+		if l.Removed {
+			continue
+		}
+		var (
+			holder  types.Address
+			spender types.Address
+			value   *big.Int
+		)
+		// ERC-721 Approval events have the same signature, but the token ID
+		// is indexed, so they have four topics and fail to decode.
+		if err := approvalEvent.DecodeValues(l.Topics, l.Data, &holder, &spender, &value); err != nil {
+			continue
+		}
+		a := &Allowance{Token: l.Address, Spender: spender, Logged: value}
+		if l.BlockNumber != nil {
+			a.Block = l.BlockNumber.Uint64()
+		}
+		latest[key{l.Address, spender}] = a
+	}
+	block := types.LatestBlockNumber
+	if opts.ToBlock != nil {
+		block = *opts.ToBlock
+	}
+	var res []Allowance
+	for _, a := range latest {
+		current, err := allowanceOf(ctx, client, a.Token, owner, a.Spender, block)
+		if err != nil {
+			if errors.Is(err, errNotERC20) {
+				continue
+			}
+			return nil, err
+		}
+		a.Current = current
+		if current.Sign() == 0 && !opts.IncludeRevoked {
+			continue
+		}
+		res = append(res, *a)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Token != res[j].Token {
+			return lessAddress(res[i].Token, res[j].Token)
+		}
+		return lessAddress(res[i].Spender, res[j].Spender)
+	})
+	return res, nil
+}
+
+// RevokeTransactions returns transactions that set the given allowances to
+// zero. Allowances that are already zero are skipped.
+//
+// The transactions only have the sender, recipient and input set. The
+// remaining fields, such as the nonce and fees, are expected to be set by
+// the client, e.g. using transaction modifiers.
+func RevokeTransactions(owner types.Address, allowances []Allowance) []*types.Transaction {
+	var txs []*types.Transaction
+	for _, a := range allowances {
+		if a.Current != nil && a.Current.Sign() == 0 {
+			continue
+		}
+		txs = append(txs, types.NewTransaction().
+			SetFrom(owner).
+			SetTo(a.Token).
+			SetInput(approveMethod.MustEncodeArgs(a.Spender, big.NewInt(0))))
+	}
+	return txs
+}
+
+// errNotERC20 is returned by allowanceOf if the contract does not implement
+// the allowance method.
+var errNotERC20 = errors.New("erc20: contract does not implement allowance")
+
+// allowanceOf returns the allowance of the spender at the given block.
+func allowanceOf(ctx context.Context, client rpc.RPC, token, owner, spender types.Address, block types.BlockNumber) (*big.Int, error) {
+	call := types.NewCall().
+		SetTo(token).
+		SetInput(allowanceMethod.MustEncodeArgs(owner, spender))
+	data, _, err := client.Call(ctx, call, block)
+	if err != nil {
+		var rpcErr transport.RPCErrorCode
+		if errors.As(err, &rpcErr) {
+			// The call reverted or the node rejected it.
+			return nil, errNotERC20
+		}
+		return nil, fmt.Errorf("erc20: failed to fetch allowance of %s on %s: %w", spender, token, err)
+	}
+	var value *big.Int
+	if err := allowanceMethod.DecodeValues(data, &value); err != nil {
+		return nil, errNotERC20
+	}
+	return value, nil
+}
+
+// approvalLogs returns Approval events emitted for the owner.
+func approvalLogs(ctx context.Context, client rpc.RPC, owner types.Address, opts FindOptions) ([]types.Log, error) {
+	query := func(from, to *types.BlockNumber) ([]types.Log, error) {
+		q := types.NewFilterLogsQuery().
+			SetAddresses(opts.Tokens...).
+			SetFromBlock(from).
+			SetToBlock(to).
+			SetTopics(
+				[]types.Hash{approvalEvent.Topic0()},
+				[]types.Hash{types.MustHashFromBytes(owner.Bytes(), types.PadLeft)},
+			)
+		logs, err := client.GetLogs(ctx, q)
+		if err != nil {
+			return nil, fmt.Errorf("erc20: failed to fetch Approval events: %w", err)
+		}
+		return logs, nil
+	}
+	from, to := opts.FromBlock, opts.ToBlock
+	if from == nil {
+		from = types.BlockNumberFromUint64Ptr(0)
+	}
+	if opts.ChunkSize == 0 {
+		return query(from, to)
+	}
+	if to == nil || from.IsTag() || to.IsTag() {
+		return nil, errors.New("erc20: chunked queries require numeric FromBlock and ToBlock")
+	}
+	var logs []types.Log
+	end := to.Big().Uint64()
+	for lo := from.Big().Uint64(); lo <= end; lo += opts.ChunkSize {
+		hi := lo + opts.ChunkSize - 1
+		if hi > end {
+			hi = end
+		}
+		chunk, err := query(types.BlockNumberFromUint64Ptr(lo), types.BlockNumberFromUint64Ptr(hi))
+		if err != nil {
+			return nil, err
+		}
+		logs = append(logs, chunk...)
+	}
+	return logs, nil
+}
+
+// lessAddress returns true if a is lexicographically less than b.
+func lessAddress(a, b types.Address) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package erc20
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/rpc/transport"
+	"github.com/defiweb/go-eth/types"
+)
+
+var (
+	owner    = types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+	spender1 = types.MustAddressFromHex("0x2222222222222222222222222222222222222222")
+	spender2 = types.MustAddressFromHex("0x3333333333333333333333333333333333333333")
+	tokenA   = types.MustAddressFromHex("0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+	tokenB   = types.MustAddressFromHex("0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb")
+	nft      = types.MustAddressFromHex("0xcccccccccccccccccccccccccccccccccccccccc")
+)
+
+type fakeRPC struct {
+	rpc.Client
+
+	logs       []types.Log
+	allowances map[types.Address]map[types.Address]*big.Int
+	queries    []*types.FilterLogsQuery
+}
+
+func (f *fakeRPC) GetLogs(_ context.Context, q *types.FilterLogsQuery) ([]types.Log, error) {
+	f.queries = append(f.queries, q)
+	return f.logs, nil
+}
+
+func (f *fakeRPC) Call(_ context.Context, call *types.Call, _ types.BlockNumber) ([]byte, *types.Call, error) {
+	var o, s types.Address
+	if err := allowanceMethod.DecodeArgs(call.Input, &o, &s); err != nil {
+		return nil, nil, err
+	}
+	v, ok := f.allowances[*call.To][s]
+	if !ok {
+		return nil, nil, &transport.RPCError{Code: 3, Message: "execution reverted"}
+	}
+	return abi.MustEncodeValue(abi.MustParseType("uint256"), v), call, nil
+}
+
+func approvalLog(token, spender types.Address, value *big.Int, block int64) types.Log {
+	return types.Log{
+		Address: token,
+		Topics: []types.Hash{
+			approvalEvent.Topic0(),
+			types.MustHashFromBytes(owner.Bytes(), types.PadLeft),
+			types.MustHashFromBytes(spender.Bytes(), types.PadLeft),
+		},
+		Data:        abi.MustEncodeValue(abi.MustParseType("uint256"), value),
+		BlockNumber: big.NewInt(block),
+	}
+}
+
+func TestFindAllowances(t *testing.T) {
+	unlimited := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	nftLog := approvalLog(nft, spender1, big.NewInt(0), 5)
+	nftLog.Topics = append(nftLog.Topics, types.MustHashFromHex("0x01", types.PadLeft))
+	nftLog.Data = nil
+	client := &fakeRPC{
+		logs: []types.Log{
+			approvalLog(tokenA, spender1, big.NewInt(100), 1),
+			approvalLog(tokenA, spender2, unlimited, 2),
+			approvalLog(tokenB, spender1, unlimited, 3),
+			approvalLog(tokenA, spender1, big.NewInt(50), 4),
+			approvalLog(tokenA, spender2, big.NewInt(0), 6),
+			nftLog,
+		},
+		allowances: map[types.Address]map[types.Address]*big.Int{
+			tokenA: {spender1: big.NewInt(30), spender2: big.NewInt(0)},
+			tokenB: {spender1: unlimited},
+		},
+	}
+
+	res, err := FindAllowances(context.Background(), client, owner, FindOptions{})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+
+	// Allowance decreased by transferFrom without an Approval event.
+	assert.Equal(t, tokenA, res[0].Token)
+	assert.Equal(t, spender1, res[0].Spender)
+	assert.Equal(t, big.NewInt(50), res[0].Logged)
+	assert.Equal(t, big.NewInt(30), res[0].Current)
+	assert.Equal(t, uint64(4), res[0].Block)
+	assert.True(t, res[0].Stale())
+	assert.False(t, res[0].Unlimited())
+
+	assert.Equal(t, tokenB, res[1].Token)
+	assert.False(t, res[1].Stale())
+	assert.True(t, res[1].Unlimited())
+
+	res, err = FindAllowances(context.Background(), client, owner, FindOptions{IncludeRevoked: true})
+	require.NoError(t, err)
+	require.Len(t, res, 3)
+	assert.Equal(t, spender2, res[1].Spender)
+
+	txs := RevokeTransactions(owner, res)
+	require.Len(t, txs, 2)
+	assert.Equal(t, owner, *txs[0].From)
+	assert.Equal(t, tokenA, *txs[0].To)
+	var (
+		s types.Address
+		v *big.Int
+	)
+	require.NoError(t, approveMethod.DecodeArgs(txs[0].Input, &s, &v))
+	assert.Equal(t, spender1, s)
+	assert.Equal(t, 0, v.Sign())
+	assert.Equal(t, tokenB, *txs[1].To)
+}
+
+func TestFindAllowances_Chunks(t *testing.T) {
+	client := &fakeRPC{}
+	_, err := FindAllowances(context.Background(), client, owner, FindOptions{
+		FromBlock: types.BlockNumberFromUint64Ptr(100),
+		ToBlock:   types.BlockNumberFromUint64Ptr(350),
+		ChunkSize: 100,
+	})
+	require.NoError(t, err)
+	require.Len(t, client.queries, 3)
+	assert.Equal(t, uint64(300), client.queries[2].FromBlock.Big().Uint64())
+	assert.Equal(t, uint64(350), client.queries[2].ToBlock.Big().Uint64())
+
+	_, err = FindAllowances(context.Background(), client, owner, FindOptions{ChunkSize: 100})
+	assert.Error(t, err)
+}
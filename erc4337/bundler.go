@@ -0,0 +1,85 @@
+package erc4337
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/defiweb/go-eth/rpc/transport"
+	"github.com/defiweb/go-eth/types"
+)
+
+// Bundler is a client for the ERC-4337 bundler JSON-RPC API.
+//
+// Errors returned by the bundler are converted to EntryPointError when they
+// contain an AAxx code.
+type Bundler struct {
+	transport transport.Transport
+}
+
+// BundlerOptions is the options for NewBundler.
+type BundlerOptions struct {
+	// Transport is the transport connected to the bundler endpoint.
+	Transport transport.Transport
+}
+
+// GasEstimate is the result of the eth_estimateUserOperationGas call.
+type GasEstimate struct {
+	PreVerificationGas   *big.Int
+	VerificationGasLimit *big.Int
+	CallGasLimit         *big.Int
+}
+
+// NewBundler returns a new Bundler.
+func NewBundler(opts BundlerOptions) (*Bundler, error) {
+	if opts.Transport == nil {
+		return nil, errors.New("bundler: transport is required")
+	}
+	return &Bundler{transport: opts.Transport}, nil
+}
+
+// SupportedEntryPoints performs eth_supportedEntryPoints RPC call.
+//
+// It returns the entry points supported by the bundler.
+func (b *Bundler) SupportedEntryPoints(ctx context.Context) ([]types.Address, error) {
+	var res []types.Address
+	if err := b.transport.Call(ctx, &res, "eth_supportedEntryPoints"); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// EstimateUserOperationGas performs eth_estimateUserOperationGas RPC call.
+//
+// It estimates the gas values for the user operation. The operation must
+// have a signature that passes the account validation, or at least a dummy
+// signature of the correct length.
+func (b *Bundler) EstimateUserOperationGas(ctx context.Context, op *UserOperation, entryPoint types.Address) (*GasEstimate, error) {
+	var res jsonGasEstimate
+	if err := b.transport.Call(ctx, &res, "eth_estimateUserOperationGas", op, entryPoint); err != nil {
+		return nil, ToEntryPointError(err)
+	}
+	return &GasEstimate{
+		PreVerificationGas:   res.PreVerificationGas.Big(),
+		VerificationGasLimit: res.VerificationGasLimit.Big(),
+		CallGasLimit:         res.CallGasLimit.Big(),
+	}, nil
+}
+
+// SendUserOperation performs eth_sendUserOperation RPC call.
+//
+// It submits the user operation to the bundler mempool and returns the user
+// operation hash.
+func (b *Bundler) SendUserOperation(ctx context.Context, op *UserOperation, entryPoint types.Address) (*types.Hash, error) {
+	var res types.Hash
+	if err := b.transport.Call(ctx, &res, "eth_sendUserOperation", op, entryPoint); err != nil {
+		return nil, ToEntryPointError(err)
+	}
+	return &res, nil
+}
+
+type jsonGasEstimate struct {
+	PreVerificationGas   types.Number `json:"preVerificationGas"`
+	VerificationGasLimit types.Number `json:"verificationGasLimit"`
+	CallGasLimit         types.Number `json:"callGasLimit"`
+}
@@ -0,0 +1,131 @@
+package erc4337
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/rpc/transport"
+)
+
+// EntryPointError is a user operation validation error reported by the entry
+// point. Entry point errors are identified by "AAxx" codes, where the first
+// digit identifies the failing entity: 1 - factory, 2 - account, 3 -
+// paymaster, 4 - verification gas, 5 - post operation, 9 - bundler.
+//
+// Use errors.Is with one of the Err* variables to check for a specific code.
+type EntryPointError struct {
+	Code   int    // Code is the numeric part of the AAxx code.
+	Reason string // Reason is the full reason reported by the entry point, e.g. "AA21 didn't pay prefund".
+	Err    error  // Err is the underlying error.
+}
+
+// Error implements the error interface.
+func (e *EntryPointError) Error() string {
+	if e.Reason == "" {
+		return fmt.Sprintf("entry point error: AA%02d", e.Code)
+	}
+	return fmt.Sprintf("entry point error: %s", e.Reason)
+}
+
+// Unwrap returns the underlying error.
+func (e *EntryPointError) Unwrap() error {
+	return e.Err
+}
+
+// Is returns true if the target is an EntryPointError with the same code.
+func (e *EntryPointError) Is(target error) bool {
+	var t *EntryPointError
+	if !errors.As(target, &t) {
+		return false
+	}
+	return e.Code == t.Code
+}
+
+// IsFactoryError returns true for AA1x errors.
+func (e *EntryPointError) IsFactoryError() bool { return e.Code/10 == 1 }
+
+// IsAccountError returns true for AA2x errors.
+func (e *EntryPointError) IsAccountError() bool { return e.Code/10 == 2 }
+
+// IsPaymasterError returns true for AA3x errors.
+func (e *EntryPointError) IsPaymasterError() bool { return e.Code/10 == 3 }
+
+// IsGasError returns true for AA4x errors.
+func (e *EntryPointError) IsGasError() bool { return e.Code/10 == 4 }
+
+// Entry point errors defined by EntryPoint v0.6.
+var (
+	ErrSenderAlreadyConstructed  = &EntryPointError{Code: 10, Reason: "AA10 sender already constructed"}
+	ErrInitCodeFailed            = &EntryPointError{Code: 13, Reason: "AA13 initCode failed or OOG"}
+	ErrInitCodeMustReturnSender  = &EntryPointError{Code: 14, Reason: "AA14 initCode must return sender"}
+	ErrInitCodeMustCreateSender  = &EntryPointError{Code: 15, Reason: "AA15 initCode must create sender"}
+	ErrAccountNotDeployed        = &EntryPointError{Code: 20, Reason: "AA20 account not deployed"}
+	ErrDidNotPayPrefund          = &EntryPointError{Code: 21, Reason: "AA21 didn't pay prefund"}
+	ErrAccountExpired            = &EntryPointError{Code: 22, Reason: "AA22 expired or not due"}
+	ErrAccountReverted           = &EntryPointError{Code: 23, Reason: "AA23 reverted (or OOG)"}
+	ErrAccountSignature          = &EntryPointError{Code: 24, Reason: "AA24 signature error"}
+	ErrInvalidAccountNonce       = &EntryPointError{Code: 25, Reason: "AA25 invalid account nonce"}
+	ErrPaymasterNotDeployed      = &EntryPointError{Code: 30, Reason: "AA30 paymaster not deployed"}
+	ErrPaymasterDepositTooLow    = &EntryPointError{Code: 31, Reason: "AA31 paymaster deposit too low"}
+	ErrPaymasterExpired          = &EntryPointError{Code: 32, Reason: "AA32 paymaster expired or not due"}
+	ErrPaymasterReverted         = &EntryPointError{Code: 33, Reason: "AA33 reverted (or OOG)"}
+	ErrPaymasterSignature        = &EntryPointError{Code: 34, Reason: "AA34 signature error"}
+	ErrOverVerificationGasLimit  = &EntryPointError{Code: 40, Reason: "AA40 over verificationGasLimit"}
+	ErrTooLittleVerificationGas  = &EntryPointError{Code: 41, Reason: "AA41 too little verificationGas"}
+	ErrPostOpReverted            = &EntryPointError{Code: 50, Reason: "AA50 postOp reverted"}
+	ErrPrefundBelowActualGasCost = &EntryPointError{Code: 51, Reason: "AA51 prefund below actualGasCost"}
+	ErrInvalidPaymasterAndData   = &EntryPointError{Code: 93, Reason: "AA93 invalid paymasterAndData"}
+	ErrGasValuesOverflow         = &EntryPointError{Code: 94, Reason: "AA94 gas values overflow"}
+	ErrOutOfGas                  = &EntryPointError{Code: 95, Reason: "AA95 out of gas"}
+)
+
+// failedOpError is the error used by the entry point to report validation
+// failures during simulation.
+var failedOpError = abi.MustParseError("FailedOp(uint256 opIndex, string reason)")
+
+// aaCodeRegexp matches the AAxx code in error messages.
+var aaCodeRegexp = regexp.MustCompile(`\bAA(\d{2})\b`)
+
+// ToEntryPointError converts an error returned by a bundler or by a
+// simulation call into an EntryPointError. The AAxx code is looked up in the
+// FailedOp revert data, if present, and then in the error message. If no
+// code is found, the error is returned unchanged.
+func ToEntryPointError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var epErr *EntryPointError
+	if errors.As(err, &epErr) {
+		return err
+	}
+	var dataErr transport.RPCErrorData
+	if errors.As(err, &dataErr) {
+		if data, ok := dataErr.RPCErrorData().([]byte); ok && failedOpError.Is(data) {
+			var (
+				opIndex uint64
+				reason  string
+			)
+			if abi.DecodeValues(failedOpError.Inputs(), data[4:], &opIndex, &reason) == nil {
+				if e := parseEntryPointError(reason, err); e != nil {
+					return e
+				}
+			}
+		}
+	}
+	if e := parseEntryPointError(err.Error(), err); e != nil {
+		return e
+	}
+	return err
+}
+
+func parseEntryPointError(msg string, err error) *EntryPointError {
+	loc := aaCodeRegexp.FindStringSubmatchIndex(msg)
+	if loc == nil {
+		return nil
+	}
+	code, _ := strconv.Atoi(msg[loc[2]:loc[3]])
+	return &EntryPointError{Code: code, Reason: msg[loc[0]:], Err: err}
+}
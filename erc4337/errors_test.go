@@ -0,0 +1,40 @@
+package erc4337
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/rpc/transport"
+)
+
+func TestToEntryPointError(t *testing.T) {
+	t.Run("message", func(t *testing.T) {
+		rpcErr := transport.NewRPCError(-32500, "UserOperation reverted during simulation with reason: AA21 didn't pay prefund", nil)
+		err := ToEntryPointError(rpcErr)
+
+		var epErr *EntryPointError
+		require.ErrorAs(t, err, &epErr)
+		assert.Equal(t, 21, epErr.Code)
+		assert.Equal(t, "AA21 didn't pay prefund", epErr.Reason)
+		assert.True(t, epErr.IsAccountError())
+		assert.ErrorIs(t, err, ErrDidNotPayPrefund)
+		assert.NotErrorIs(t, err, ErrAccountSignature)
+		assert.ErrorIs(t, err, rpcErr)
+	})
+	t.Run("failed op data", func(t *testing.T) {
+		data, err := abi.EncodeValues(failedOpError.Inputs(), 0, "AA33 reverted (or OOG)")
+		require.NoError(t, err)
+		data = append(failedOpError.FourBytes().Bytes(), data...)
+		err = ToEntryPointError(transport.NewRPCError(-32500, "execution reverted", data))
+
+		assert.ErrorIs(t, err, ErrPaymasterReverted)
+	})
+	t.Run("other error", func(t *testing.T) {
+		err := errors.New("connection refused")
+		assert.Equal(t, err, ToEntryPointError(err))
+	})
+}
@@ -0,0 +1,147 @@
+package erc4337
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/defiweb/go-eth/types"
+	"github.com/defiweb/go-eth/wallet"
+)
+
+// DefaultMaxEstimateIterations is the default maximum number of
+// eth_estimateUserOperationGas calls made by GasEstimator.Estimate.
+const DefaultMaxEstimateIterations = 3
+
+// GasEstimator estimates gas values for user operations.
+//
+// Gas values are covered by the account and the paymaster signatures, and
+// the size of the signed fields affects the pre-verification gas. Because of
+// that, a single estimation is often not enough: after the gas values are
+// updated, the operation must be signed again and the new operation may
+// require more gas than estimated. GasEstimator repeats the estimation until
+// the signed operation is covered by its own gas values.
+type GasEstimator struct {
+	bundler       *Bundler
+	entryPoint    types.Address
+	chainID       uint64
+	key           wallet.Key
+	paymaster     PaymasterDataBuilder
+	multiplier    float64
+	maxIterations int
+}
+
+// GasEstimatorOptions is the options for NewGasEstimator.
+type GasEstimatorOptions struct {
+	// Bundler is the bundler used to estimate gas.
+	Bundler *Bundler
+
+	// EntryPoint is the entry point address.
+	EntryPoint types.Address
+
+	// ChainID is the chain ID used to compute the user operation hash.
+	ChainID uint64
+
+	// Key is used to sign the operation before every estimation. If nil,
+	// the operation signature is left unchanged, in which case it must be
+	// a dummy signature accepted by the account during simulation.
+	Key wallet.Key
+
+	// Paymaster is used to build the PaymasterAndData field before every
+	// estimation. If nil, the field is left unchanged.
+	Paymaster PaymasterDataBuilder
+
+	// GasMultiplier is applied to the estimated gas values. If zero, 1 is
+	// used.
+	GasMultiplier float64
+
+	// MaxIterations is the maximum number of estimations. If zero,
+	// DefaultMaxEstimateIterations is used.
+	MaxIterations int
+}
+
+// NewGasEstimator returns a new GasEstimator.
+func NewGasEstimator(opts GasEstimatorOptions) (*GasEstimator, error) {
+	if opts.Bundler == nil {
+		return nil, errors.New("user operation gas estimator: bundler is required")
+	}
+	if opts.GasMultiplier == 0 {
+		opts.GasMultiplier = 1
+	}
+	if opts.GasMultiplier < 1 {
+		return nil, errors.New("user operation gas estimator: gas multiplier must be at least 1")
+	}
+	if opts.MaxIterations == 0 {
+		opts.MaxIterations = DefaultMaxEstimateIterations
+	}
+	return &GasEstimator{
+		bundler:       opts.Bundler,
+		entryPoint:    opts.EntryPoint,
+		chainID:       opts.ChainID,
+		key:           opts.Key,
+		paymaster:     opts.Paymaster,
+		multiplier:    opts.GasMultiplier,
+		maxIterations: opts.MaxIterations,
+	}, nil
+}
+
+// Estimate returns a copy of the user operation with the gas values set and,
+// if a key or paymaster is configured, with the final signature and
+// paymaster data. The given operation is not modified.
+//
+// If the entry point rejects the operation during simulation, the returned
+// error is an EntryPointError.
+func (e *GasEstimator) Estimate(ctx context.Context, op *UserOperation) (*UserOperation, error) {
+	op = op.Copy()
+	for i := 0; ; i++ {
+		if err := e.prepare(ctx, op); err != nil {
+			return nil, err
+		}
+		est, err := e.bundler.EstimateUserOperationGas(ctx, op, e.entryPoint)
+		if err != nil {
+			return nil, fmt.Errorf("user operation gas estimator: %w", err)
+		}
+		if covers(op.PreVerificationGas, est.PreVerificationGas) &&
+			covers(op.VerificationGasLimit, est.VerificationGasLimit) &&
+			covers(op.CallGasLimit, est.CallGasLimit) {
+			return op, nil
+		}
+		if i+1 >= e.maxIterations {
+			return nil, fmt.Errorf("user operation gas estimator: estimation did not converge after %d iterations", e.maxIterations)
+		}
+		op.PreVerificationGas = e.buffer(op.PreVerificationGas, est.PreVerificationGas)
+		op.VerificationGasLimit = e.buffer(op.VerificationGasLimit, est.VerificationGasLimit)
+		op.CallGasLimit = e.buffer(op.CallGasLimit, est.CallGasLimit)
+	}
+}
+
+// prepare sets the paymaster data and the signature.
+func (e *GasEstimator) prepare(ctx context.Context, op *UserOperation) error {
+	if e.paymaster != nil {
+		if err := op.ApplyPaymaster(ctx, e.paymaster, e.chainID); err != nil {
+			return fmt.Errorf("user operation gas estimator: failed to build paymaster data: %w", err)
+		}
+	}
+	if e.key != nil {
+		if err := op.Sign(ctx, e.key, e.entryPoint, e.chainID); err != nil {
+			return fmt.Errorf("user operation gas estimator: failed to sign user operation: %w", err)
+		}
+	}
+	return nil
+}
+
+// buffer applies the gas multiplier to the estimated value. The current
+// value is never decreased, so that the estimation converges.
+func (e *GasEstimator) buffer(current, estimated *big.Int) *big.Int {
+	v, _ := new(big.Float).Mul(new(big.Float).SetInt(estimated), big.NewFloat(e.multiplier)).Int(nil)
+	if current != nil && current.Cmp(v) > 0 {
+		return current
+	}
+	return v
+}
+
+// covers returns true if the current value is at least the estimated one.
+func covers(current, estimated *big.Int) bool {
+	return current != nil && estimated != nil && current.Cmp(estimated) >= 0
+}
@@ -0,0 +1,85 @@
+package erc4337
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/rpc/transport"
+	"github.com/defiweb/go-eth/types"
+	"github.com/defiweb/go-eth/wallet"
+)
+
+// fakeBundler estimates the pre-verification gas based on the size of the
+// signature and paymaster data, which change after every estimation.
+type fakeBundler struct {
+	calls int
+	err   error
+}
+
+func (f *fakeBundler) Call(_ context.Context, result any, method string, args ...any) error {
+	if method != "eth_estimateUserOperationGas" {
+		return transport.NewRPCError(transport.ErrCodeMethodNotFound, "method not found", nil)
+	}
+	f.calls++
+	if f.err != nil {
+		return f.err
+	}
+	op := args[0].(*UserOperation)
+	pvg := 21000 + 16*int64(len(op.Signature)+len(op.PaymasterAndData))
+	if op.CallGasLimit != nil {
+		pvg += int64(op.CallGasLimit.BitLen())
+	}
+	res, err := json.Marshal(jsonGasEstimate{
+		PreVerificationGas:   types.NumberFromUint64(uint64(pvg)),
+		VerificationGasLimit: types.NumberFromUint64(100000),
+		CallGasLimit:         types.NumberFromUint64(50000),
+	})
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(res, result)
+}
+
+func TestGasEstimator_Estimate(t *testing.T) {
+	key := wallet.NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	fb := &fakeBundler{}
+	bundler, err := NewBundler(BundlerOptions{Transport: fb})
+	require.NoError(t, err)
+	est, err := NewGasEstimator(GasEstimatorOptions{
+		Bundler:       bundler,
+		EntryPoint:    EntryPointV06,
+		ChainID:       1,
+		Key:           key,
+		Paymaster:     NewERC20Paymaster(ERC20PaymasterOptions{}),
+		GasMultiplier: 1.1,
+	})
+	require.NoError(t, err)
+
+	op := NewUserOperation().SetSender(key.Address()).SetNonce(big.NewInt(0))
+	got, err := est.Estimate(context.Background(), op)
+	require.NoError(t, err)
+
+	assert.Nil(t, op.CallGasLimit)
+	assert.Equal(t, big.NewInt(110000), got.VerificationGasLimit)
+	assert.Equal(t, big.NewInt(55000), got.CallGasLimit)
+	assert.Len(t, got.PaymasterAndData, 40)
+	assert.Len(t, got.Signature, 65)
+	assert.Equal(t, 2, fb.calls)
+}
+
+func TestGasEstimator_EntryPointError(t *testing.T) {
+	fb := &fakeBundler{err: transport.NewRPCError(-32500, "AA24 signature error", nil)}
+	bundler, err := NewBundler(BundlerOptions{Transport: fb})
+	require.NoError(t, err)
+	est, err := NewGasEstimator(GasEstimatorOptions{Bundler: bundler, EntryPoint: EntryPointV06})
+	require.NoError(t, err)
+
+	_, err = est.Estimate(context.Background(), NewUserOperation())
+	assert.ErrorIs(t, err, ErrAccountSignature)
+}
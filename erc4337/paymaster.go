@@ -0,0 +1,164 @@
+package erc4337
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/types"
+	"github.com/defiweb/go-eth/wallet"
+)
+
+// PaymasterDataBuilder builds the PaymasterAndData field of a user operation.
+type PaymasterDataBuilder interface {
+	// PaymasterAndData returns the PaymasterAndData field for the given
+	// user operation. Implementations must not modify the operation.
+	PaymasterAndData(ctx context.Context, op *UserOperation, chainID uint64) ([]byte, error)
+}
+
+// VerifyingPaymaster builds the PaymasterAndData field for paymasters
+// compatible with the EntryPoint v0.6 VerifyingPaymaster reference
+// implementation. The paymaster sponsors operations signed by an off-chain
+// signer.
+//
+// The layout of the field is:
+//
+//	paymaster (20 bytes) || abi.encode(uint48 validUntil, uint48 validAfter) || signature (65 bytes)
+//
+// The signature covers all user operation fields except PaymasterAndData and
+// Signature, so the gas fields must be final before the builder is used.
+type VerifyingPaymaster struct {
+	paymaster  types.Address
+	signer     wallet.Key
+	validUntil time.Time
+	validAfter time.Time
+}
+
+// VerifyingPaymasterOptions is the options for NewVerifyingPaymaster.
+type VerifyingPaymasterOptions struct {
+	Paymaster  types.Address // Paymaster is the address of the paymaster contract.
+	Signer     wallet.Key    // Signer is the key of the paymaster's verifying signer.
+	ValidUntil time.Time     // ValidUntil is the expiration time, zero means no expiration.
+	ValidAfter time.Time     // ValidAfter is the time after which the sponsorship is valid, zero means immediately.
+}
+
+// NewVerifyingPaymaster returns a new VerifyingPaymaster.
+func NewVerifyingPaymaster(opts VerifyingPaymasterOptions) (*VerifyingPaymaster, error) {
+	if opts.Signer == nil {
+		return nil, errors.New("verifying paymaster: signer is required")
+	}
+	return &VerifyingPaymaster{
+		paymaster:  opts.Paymaster,
+		signer:     opts.Signer,
+		validUntil: opts.ValidUntil,
+		validAfter: opts.ValidAfter,
+	}, nil
+}
+
+// PaymasterAndData implements the PaymasterDataBuilder interface.
+func (p *VerifyingPaymaster) PaymasterAndData(ctx context.Context, op *UserOperation, chainID uint64) ([]byte, error) {
+	validUntil, validAfter := unixOrZero(p.validUntil), unixOrZero(p.validAfter)
+	hash, err := VerifyingPaymasterHash(op, chainID, p.paymaster, validUntil, validAfter)
+	if err != nil {
+		return nil, fmt.Errorf("verifying paymaster: %w", err)
+	}
+	sig, err := p.signer.SignMessage(ctx, hash.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("verifying paymaster: failed to sign: %w", err)
+	}
+	validity, err := abi.EncodeValues(verifyingPaymasterValidityType, validUntil, validAfter)
+	if err != nil {
+		return nil, fmt.Errorf("verifying paymaster: %w", err)
+	}
+	data := make([]byte, 0, types.AddressLength+len(validity)+65)
+	data = append(data, p.paymaster.Bytes()...)
+	data = append(data, validity...)
+	data = append(data, sig.Bytes()...)
+	return data, nil
+}
+
+// VerifyingPaymasterHash returns the hash signed by the verifying paymaster
+// signer, as computed by the VerifyingPaymaster.getHash method.
+func VerifyingPaymasterHash(op *UserOperation, chainID uint64, paymaster types.Address, validUntil, validAfter uint64) (types.Hash, error) {
+	enc, err := abi.EncodeValues(
+		verifyingPaymasterHashType,
+		op.Sender,
+		bigIntOrZero(op.Nonce),
+		crypto.Keccak256(op.InitCode),
+		crypto.Keccak256(op.CallData),
+		bigIntOrZero(op.CallGasLimit),
+		bigIntOrZero(op.VerificationGasLimit),
+		bigIntOrZero(op.PreVerificationGas),
+		bigIntOrZero(op.MaxFeePerGas),
+		bigIntOrZero(op.MaxPriorityFeePerGas),
+		new(big.Int).SetUint64(chainID),
+		paymaster,
+		validUntil,
+		validAfter,
+	)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return crypto.Keccak256(enc), nil
+}
+
+// ERC20Paymaster builds the PaymasterAndData field for paymasters that
+// charge the sender in an ERC-20 token. The sender must approve the paymaster
+// to spend the token before the operation is executed.
+//
+// The layout of the field is:
+//
+//	paymaster (20 bytes) || token (20 bytes) [|| uint256 maxTokenCost (32 bytes)]
+//
+// The maximum token cost is appended only if MaxTokenCost is set.
+type ERC20Paymaster struct {
+	paymaster    types.Address
+	token        types.Address
+	maxTokenCost *big.Int
+}
+
+// ERC20PaymasterOptions is the options for NewERC20Paymaster.
+type ERC20PaymasterOptions struct {
+	Paymaster    types.Address // Paymaster is the address of the paymaster contract.
+	Token        types.Address // Token is the address of the ERC-20 token used to pay fees.
+	MaxTokenCost *big.Int      // MaxTokenCost is the maximum amount of tokens the sender agrees to pay, or nil.
+}
+
+// NewERC20Paymaster returns a new ERC20Paymaster.
+func NewERC20Paymaster(opts ERC20PaymasterOptions) *ERC20Paymaster {
+	return &ERC20Paymaster{
+		paymaster:    opts.Paymaster,
+		token:        opts.Token,
+		maxTokenCost: opts.MaxTokenCost,
+	}
+}
+
+// PaymasterAndData implements the PaymasterDataBuilder interface.
+func (p *ERC20Paymaster) PaymasterAndData(_ context.Context, _ *UserOperation, _ uint64) ([]byte, error) {
+	data := make([]byte, 0, 2*types.AddressLength+32)
+	data = append(data, p.paymaster.Bytes()...)
+	data = append(data, p.token.Bytes()...)
+	if p.maxTokenCost != nil {
+		if p.maxTokenCost.Sign() < 0 || p.maxTokenCost.BitLen() > 256 {
+			return nil, fmt.Errorf("erc20 paymaster: invalid max token cost: %s", p.maxTokenCost)
+		}
+		data = append(data, types.MustHashFromBigInt(p.maxTokenCost).Bytes()...)
+	}
+	return data, nil
+}
+
+var (
+	verifyingPaymasterValidityType = abi.MustParseType("(uint48,uint48)")
+	verifyingPaymasterHashType     = abi.MustParseType("(address,uint256,bytes32,bytes32,uint256,uint256,uint256,uint256,uint256,uint256,address,uint48,uint48)")
+)
+
+func unixOrZero(t time.Time) uint64 {
+	if t.IsZero() {
+		return 0
+	}
+	return uint64(t.Unix())
+}
@@ -0,0 +1,65 @@
+package erc4337
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/types"
+	"github.com/defiweb/go-eth/wallet"
+)
+
+func TestVerifyingPaymaster(t *testing.T) {
+	key := wallet.NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	paymaster := types.MustAddressFromHex("0x2222222222222222222222222222222222222222")
+	pm, err := NewVerifyingPaymaster(VerifyingPaymasterOptions{
+		Paymaster:  paymaster,
+		Signer:     key,
+		ValidUntil: time.Unix(2000, 0),
+		ValidAfter: time.Unix(1000, 0),
+	})
+	require.NoError(t, err)
+
+	op := testUserOperation()
+	require.NoError(t, op.ApplyPaymaster(context.Background(), pm, 1))
+	require.Len(t, op.PaymasterAndData, 20+64+65)
+	assert.Equal(t, paymaster.Bytes(), op.PaymasterAndData[:20])
+
+	var validUntil, validAfter uint64
+	require.NoError(t, abi.DecodeValues(verifyingPaymasterValidityType, op.PaymasterAndData[20:84], &validUntil, &validAfter))
+	assert.Equal(t, uint64(2000), validUntil)
+	assert.Equal(t, uint64(1000), validAfter)
+
+	hash, err := VerifyingPaymasterHash(op, 1, paymaster, 2000, 1000)
+	require.NoError(t, err)
+	addr, err := crypto.ECRecoverer.RecoverMessage(hash.Bytes(), types.MustSignatureFromBytes(op.PaymasterAndData[84:]))
+	require.NoError(t, err)
+	assert.Equal(t, key.Address(), *addr)
+}
+
+func TestERC20Paymaster(t *testing.T) {
+	paymaster := types.MustAddressFromHex("0x2222222222222222222222222222222222222222")
+	token := types.MustAddressFromHex("0x3333333333333333333333333333333333333333")
+
+	data, err := NewERC20Paymaster(ERC20PaymasterOptions{Paymaster: paymaster, Token: token}).
+		PaymasterAndData(context.Background(), testUserOperation(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, append(paymaster.Bytes(), token.Bytes()...), data)
+
+	data, err = NewERC20Paymaster(ERC20PaymasterOptions{Paymaster: paymaster, Token: token, MaxTokenCost: big.NewInt(256)}).
+		PaymasterAndData(context.Background(), testUserOperation(), 1)
+	require.NoError(t, err)
+	require.Len(t, data, 72)
+	assert.Equal(t, types.MustHashFromBigInt(big.NewInt(256)).Bytes(), data[40:])
+
+	_, err = NewERC20Paymaster(ERC20PaymasterOptions{Paymaster: paymaster, Token: token, MaxTokenCost: big.NewInt(-1)}).
+		PaymasterAndData(context.Background(), testUserOperation(), 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,248 @@
+// Package erc4337 provides types and helpers for ERC-4337 account
+// abstraction.
+//
+// The package implements the EntryPoint v0.6 user operation format, which is
+// the format used by the eth_sendUserOperation and eth_estimateUserOperationGas
+// bundler methods.
+package erc4337
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/types"
+	"github.com/defiweb/go-eth/wallet"
+)
+
+// EntryPointV06 is the address of the canonical EntryPoint v0.6 contract.
+var EntryPointV06 = types.MustAddressFromHex("0x5FF137D4b0FDCD49DcA30c7CF57E578a026d2789")
+
+// UserOperation is an ERC-4337 user operation.
+type UserOperation struct {
+	Sender               types.Address // Sender is the account making the operation.
+	Nonce                *big.Int      // Nonce is the anti-replay parameter managed by the entry point.
+	InitCode             []byte        // InitCode is the account factory address followed by the factory call data, if the account is not deployed yet.
+	CallData             []byte        // CallData is the data passed to the sender during the execution.
+	CallGasLimit         *big.Int      // CallGasLimit is the gas limit for the execution of CallData.
+	VerificationGasLimit *big.Int      // VerificationGasLimit is the gas limit for the verification step.
+	PreVerificationGas   *big.Int      // PreVerificationGas is the gas paid to the bundler for the calldata and overhead.
+	MaxFeePerGas         *big.Int      // MaxFeePerGas is the maximum fee per gas, as in EIP-1559.
+	MaxPriorityFeePerGas *big.Int      // MaxPriorityFeePerGas is the maximum priority fee per gas, as in EIP-1559.
+	PaymasterAndData     []byte        // PaymasterAndData is the paymaster address followed by paymaster specific data, empty if the sender pays.
+	Signature            []byte        // Signature is the data passed to the account to verify the operation.
+}
+
+// NewUserOperation creates a new user operation.
+func NewUserOperation() *UserOperation {
+	return &UserOperation{}
+}
+
+func (u *UserOperation) SetSender(sender types.Address) *UserOperation {
+	u.Sender = sender
+	return u
+}
+
+func (u *UserOperation) SetNonce(nonce *big.Int) *UserOperation {
+	u.Nonce = nonce
+	return u
+}
+
+func (u *UserOperation) SetInitCode(initCode []byte) *UserOperation {
+	u.InitCode = initCode
+	return u
+}
+
+func (u *UserOperation) SetCallData(callData []byte) *UserOperation {
+	u.CallData = callData
+	return u
+}
+
+func (u *UserOperation) SetCallGasLimit(callGasLimit *big.Int) *UserOperation {
+	u.CallGasLimit = callGasLimit
+	return u
+}
+
+func (u *UserOperation) SetVerificationGasLimit(verificationGasLimit *big.Int) *UserOperation {
+	u.VerificationGasLimit = verificationGasLimit
+	return u
+}
+
+func (u *UserOperation) SetPreVerificationGas(preVerificationGas *big.Int) *UserOperation {
+	u.PreVerificationGas = preVerificationGas
+	return u
+}
+
+func (u *UserOperation) SetMaxFeePerGas(maxFeePerGas *big.Int) *UserOperation {
+	u.MaxFeePerGas = maxFeePerGas
+	return u
+}
+
+func (u *UserOperation) SetMaxPriorityFeePerGas(maxPriorityFeePerGas *big.Int) *UserOperation {
+	u.MaxPriorityFeePerGas = maxPriorityFeePerGas
+	return u
+}
+
+func (u *UserOperation) SetPaymasterAndData(paymasterAndData []byte) *UserOperation {
+	u.PaymasterAndData = paymasterAndData
+	return u
+}
+
+func (u *UserOperation) SetSignature(signature []byte) *UserOperation {
+	u.Signature = signature
+	return u
+}
+
+// Copy returns a deep copy of the user operation.
+func (u *UserOperation) Copy() *UserOperation {
+	if u == nil {
+		return nil
+	}
+	return &UserOperation{
+		Sender:               u.Sender,
+		Nonce:                copyBigInt(u.Nonce),
+		InitCode:             copyBytes(u.InitCode),
+		CallData:             copyBytes(u.CallData),
+		CallGasLimit:         copyBigInt(u.CallGasLimit),
+		VerificationGasLimit: copyBigInt(u.VerificationGasLimit),
+		PreVerificationGas:   copyBigInt(u.PreVerificationGas),
+		MaxFeePerGas:         copyBigInt(u.MaxFeePerGas),
+		MaxPriorityFeePerGas: copyBigInt(u.MaxPriorityFeePerGas),
+		PaymasterAndData:     copyBytes(u.PaymasterAndData),
+		Signature:            copyBytes(u.Signature),
+	}
+}
+
+// Hash returns the user operation hash as computed by the
+// EntryPoint.getUserOpHash method. The hash does not cover the signature.
+func (u *UserOperation) Hash(entryPoint types.Address, chainID uint64) (types.Hash, error) {
+	packed, err := abi.EncodeValues(
+		userOpPackType,
+		u.Sender,
+		bigIntOrZero(u.Nonce),
+		crypto.Keccak256(u.InitCode),
+		crypto.Keccak256(u.CallData),
+		bigIntOrZero(u.CallGasLimit),
+		bigIntOrZero(u.VerificationGasLimit),
+		bigIntOrZero(u.PreVerificationGas),
+		bigIntOrZero(u.MaxFeePerGas),
+		bigIntOrZero(u.MaxPriorityFeePerGas),
+		crypto.Keccak256(u.PaymasterAndData),
+	)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	enc, err := abi.EncodeValues(
+		userOpHashType,
+		crypto.Keccak256(packed),
+		entryPoint,
+		new(big.Int).SetUint64(chainID),
+	)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return crypto.Keccak256(enc), nil
+}
+
+// Sign signs the user operation hash using the EIP-191 message prefix, which
+// is the scheme used by most ECDSA based smart accounts, and sets the
+// signature.
+func (u *UserOperation) Sign(ctx context.Context, key wallet.Key, entryPoint types.Address, chainID uint64) error {
+	hash, err := u.Hash(entryPoint, chainID)
+	if err != nil {
+		return err
+	}
+	sig, err := key.SignMessage(ctx, hash.Bytes())
+	if err != nil {
+		return err
+	}
+	u.Signature = sig.Bytes()
+	return nil
+}
+
+// ApplyPaymaster sets the PaymasterAndData field using the given builder.
+func (u *UserOperation) ApplyPaymaster(ctx context.Context, builder PaymasterDataBuilder, chainID uint64) error {
+	data, err := builder.PaymasterAndData(ctx, u, chainID)
+	if err != nil {
+		return err
+	}
+	u.PaymasterAndData = data
+	return nil
+}
+
+func (u UserOperation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(&jsonUserOperation{
+		Sender:               u.Sender,
+		Nonce:                types.NumberFromBigInt(bigIntOrZero(u.Nonce)),
+		InitCode:             types.Bytes(u.InitCode),
+		CallData:             types.Bytes(u.CallData),
+		CallGasLimit:         types.NumberFromBigInt(bigIntOrZero(u.CallGasLimit)),
+		VerificationGasLimit: types.NumberFromBigInt(bigIntOrZero(u.VerificationGasLimit)),
+		PreVerificationGas:   types.NumberFromBigInt(bigIntOrZero(u.PreVerificationGas)),
+		MaxFeePerGas:         types.NumberFromBigInt(bigIntOrZero(u.MaxFeePerGas)),
+		MaxPriorityFeePerGas: types.NumberFromBigInt(bigIntOrZero(u.MaxPriorityFeePerGas)),
+		PaymasterAndData:     types.Bytes(u.PaymasterAndData),
+		Signature:            types.Bytes(u.Signature),
+	})
+}
+
+func (u *UserOperation) UnmarshalJSON(data []byte) error {
+	op := &jsonUserOperation{}
+	if err := json.Unmarshal(data, op); err != nil {
+		return err
+	}
+	u.Sender = op.Sender
+	u.Nonce = op.Nonce.Big()
+	u.InitCode = op.InitCode
+	u.CallData = op.CallData
+	u.CallGasLimit = op.CallGasLimit.Big()
+	u.VerificationGasLimit = op.VerificationGasLimit.Big()
+	u.PreVerificationGas = op.PreVerificationGas.Big()
+	u.MaxFeePerGas = op.MaxFeePerGas.Big()
+	u.MaxPriorityFeePerGas = op.MaxPriorityFeePerGas.Big()
+	u.PaymasterAndData = op.PaymasterAndData
+	u.Signature = op.Signature
+	return nil
+}
+
+type jsonUserOperation struct {
+	Sender               types.Address `json:"sender"`
+	Nonce                types.Number  `json:"nonce"`
+	InitCode             types.Bytes   `json:"initCode"`
+	CallData             types.Bytes   `json:"callData"`
+	CallGasLimit         types.Number  `json:"callGasLimit"`
+	VerificationGasLimit types.Number  `json:"verificationGasLimit"`
+	PreVerificationGas   types.Number  `json:"preVerificationGas"`
+	MaxFeePerGas         types.Number  `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas types.Number  `json:"maxPriorityFeePerGas"`
+	PaymasterAndData     types.Bytes   `json:"paymasterAndData"`
+	Signature            types.Bytes   `json:"signature"`
+}
+
+var (
+	userOpPackType = abi.MustParseType("(address,uint256,bytes32,bytes32,uint256,uint256,uint256,uint256,uint256,bytes32)")
+	userOpHashType = abi.MustParseType("(bytes32,address,uint256)")
+)
+
+func bigIntOrZero(x *big.Int) *big.Int {
+	if x == nil {
+		return new(big.Int)
+	}
+	return x
+}
+
+func copyBigInt(x *big.Int) *big.Int {
+	if x == nil {
+		return nil
+	}
+	return new(big.Int).Set(x)
+}
+
+func copyBytes(b []byte) []byte {
+	if b == nil {
+		return nil
+	}
+	return append([]byte(nil), b...)
+}
@@ -0,0 +1,86 @@
+package erc4337
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/types"
+	"github.com/defiweb/go-eth/wallet"
+)
+
+func testUserOperation() *UserOperation {
+	return NewUserOperation().
+		SetSender(types.MustAddressFromHex("0x1111111111111111111111111111111111111111")).
+		SetNonce(big.NewInt(1)).
+		SetCallData([]byte{1, 2, 3, 4}).
+		SetCallGasLimit(big.NewInt(100000)).
+		SetVerificationGasLimit(big.NewInt(200000)).
+		SetPreVerificationGas(big.NewInt(50000)).
+		SetMaxFeePerGas(big.NewInt(2000000000)).
+		SetMaxPriorityFeePerGas(big.NewInt(1000000000))
+}
+
+func TestUserOperation_JSON(t *testing.T) {
+	op := testUserOperation().SetSignature([]byte{5, 6})
+	j, err := json.Marshal(op)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"sender": "0x1111111111111111111111111111111111111111",
+		"nonce": "0x1",
+		"initCode": "0x",
+		"callData": "0x01020304",
+		"callGasLimit": "0x186a0",
+		"verificationGasLimit": "0x30d40",
+		"preVerificationGas": "0xc350",
+		"maxFeePerGas": "0x77359400",
+		"maxPriorityFeePerGas": "0x3b9aca00",
+		"paymasterAndData": "0x",
+		"signature": "0x0506"
+	}`, string(j))
+
+	got := &UserOperation{}
+	require.NoError(t, json.Unmarshal(j, got))
+	assert.Equal(t, op.Nonce, got.Nonce)
+	assert.Equal(t, op.CallGasLimit, got.CallGasLimit)
+	assert.Equal(t, op.CallData, got.CallData)
+	assert.Equal(t, op.Signature, got.Signature)
+}
+
+func TestUserOperation_Hash(t *testing.T) {
+	op := testUserOperation()
+	h1, err := op.Hash(EntryPointV06, 1)
+	require.NoError(t, err)
+
+	// Signature is not covered by the hash.
+	h2, err := op.Copy().SetSignature([]byte{1}).Hash(EntryPointV06, 1)
+	require.NoError(t, err)
+	assert.Equal(t, h1, h2)
+
+	// Chain ID and paymaster data are covered by the hash.
+	h3, err := op.Hash(EntryPointV06, 2)
+	require.NoError(t, err)
+	assert.NotEqual(t, h1, h3)
+	h4, err := op.Copy().SetPaymasterAndData([]byte{1}).Hash(EntryPointV06, 1)
+	require.NoError(t, err)
+	assert.NotEqual(t, h1, h4)
+}
+
+func TestUserOperation_Sign(t *testing.T) {
+	key := wallet.NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	op := testUserOperation()
+	require.NoError(t, op.Sign(context.Background(), key, EntryPointV06, 1))
+	require.Len(t, op.Signature, 65)
+
+	hash, err := op.Hash(EntryPointV06, 1)
+	require.NoError(t, err)
+	addr, err := crypto.ECRecoverer.RecoverMessage(hash.Bytes(), types.MustSignatureFromBytes(op.Signature))
+	require.NoError(t, err)
+	assert.Equal(t, key.Address(), *addr)
+}
@@ -0,0 +1,185 @@
+package events
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"github.com/defiweb/go-eth/jsoncodec"
+	"github.com/defiweb/go-eth/types"
+)
+
+// ConflictStrategy determines which version of a log or receipt is used
+// when sources return different data for the same position.
+type ConflictStrategy uint8
+
+const (
+	// MajorityStrategy uses the version returned by most sources. Ties are
+	// resolved in favor of the version returned by the earlier source.
+	MajorityStrategy ConflictStrategy = iota
+
+	// PreferredStrategy uses the version returned by the earliest source
+	// that returned the position at all.
+	PreferredStrategy
+)
+
+// Position identifies a log or a receipt in the chain.
+//
+// For logs, Index is the log index in the block. For receipts, it is the
+// transaction index in the block.
+type Position struct {
+	BlockNumber uint64
+	Index       uint64
+}
+
+// Conflict describes a position for which sources returned different data.
+type Conflict struct {
+	Position Position
+	Versions int   // Versions is the number of distinct versions.
+	Sources  []int // Sources lists indices of sources that returned the chosen version.
+}
+
+// MergeOptions is the options for MergeLogs and MergeReceipts.
+type MergeOptions struct {
+	// Strategy is the conflict resolution strategy. The default is
+	// MajorityStrategy.
+	Strategy ConflictStrategy
+
+	// OnConflict is an optional callback called for every position for
+	// which sources returned different data.
+	OnConflict func(Conflict)
+}
+
+// SortLogs sorts logs by block number and log index. The sort is stable,
+// so logs at the same position keep their relative order.
+//
+// Logs without a block number or log index, such as pending logs, are
+// placed at the end.
+func SortLogs(logs []types.Log) {
+	sort.SliceStable(logs, func(i, j int) bool {
+		pi, oki := logPosition(logs[i])
+		pj, okj := logPosition(logs[j])
+		if oki != okj {
+			return oki
+		}
+		return pi.less(pj)
+	})
+}
+
+// SortReceipts sorts receipts by block number and transaction index. The
+// sort is stable, so receipts at the same position keep their relative
+// order.
+func SortReceipts(receipts []types.TransactionReceipt) {
+	sort.SliceStable(receipts, func(i, j int) bool {
+		pi, _ := receiptPosition(receipts[i])
+		pj, _ := receiptPosition(receipts[j])
+		return pi.less(pj)
+	})
+}
+
+// MergeLogs merges logs returned by multiple sources, for example by
+// different providers queried for the same filter, into a single sequence
+// ordered by block number and log index, with one log per position.
+//
+// Sources should be ordered by preference; the order is used to resolve
+// ties and by the PreferredStrategy. Passing a single source removes
+// duplicates from it.
+//
+// All logs must have the block number and log index set.
+func MergeLogs(opts MergeOptions, sources ...[]types.Log) ([]types.Log, error) {
+	return merge(opts, sources, func(l types.Log) (Position, bool) {
+		return logPosition(l)
+	})
+}
+
+// MergeReceipts merges receipts returned by multiple sources into a single
+// sequence ordered by block number and transaction index, with one receipt
+// per position.
+//
+// See MergeLogs for details.
+func MergeReceipts(opts MergeOptions, sources ...[]types.TransactionReceipt) ([]types.TransactionReceipt, error) {
+	return merge(opts, sources, receiptPosition)
+}
+
+func (p Position) less(o Position) bool {
+	if p.BlockNumber != o.BlockNumber {
+		return p.BlockNumber < o.BlockNumber
+	}
+	return p.Index < o.Index
+}
+
+func logPosition(l types.Log) (Position, bool) {
+	if l.BlockNumber == nil || l.LogIndex == nil {
+		return Position{}, false
+	}
+	return Position{BlockNumber: l.BlockNumber.Uint64(), Index: *l.LogIndex}, true
+}
+
+func receiptPosition(r types.TransactionReceipt) (Position, bool) {
+	if r.BlockNumber == nil {
+		return Position{}, false
+	}
+	return Position{BlockNumber: r.BlockNumber.Uint64(), Index: r.TransactionIndex}, true
+}
+
+// version is a distinct version of an item at a position.
+type version[T any] struct {
+	item    T
+	content []byte // JSON encoding, used to compare versions.
+	sources []int  // Indices of sources that returned this version.
+}
+
+func merge[T any](opts MergeOptions, sources [][]T, position func(T) (Position, bool)) ([]T, error) {
+	versions := make(map[Position][]*version[T])
+	for src, items := range sources {
+		for _, item := range items {
+			pos, ok := position(item)
+			if !ok {
+				return nil, errors.New("events: cannot merge items without a block number or index")
+			}
+			content, err := jsoncodec.Marshal(item)
+			if err != nil {
+				return nil, err
+			}
+			addVersion(versions, pos, item, content, src)
+		}
+	}
+	positions := make([]Position, 0, len(versions))
+	for pos := range versions {
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].less(positions[j])
+	})
+	res := make([]T, 0, len(positions))
+	for _, pos := range positions {
+		vs := versions[pos]
+		chosen := vs[0] // Versions are ordered by the first source that returned them.
+		if opts.Strategy == MajorityStrategy {
+			for _, v := range vs[1:] {
+				if len(v.sources) > len(chosen.sources) {
+					chosen = v
+				}
+			}
+		}
+		if len(vs) > 1 && opts.OnConflict != nil {
+			opts.OnConflict(Conflict{Position: pos, Versions: len(vs), Sources: chosen.sources})
+		}
+		res = append(res, chosen.item)
+	}
+	return res, nil
+}
+
+func addVersion[T any](versions map[Position][]*version[T], pos Position, item T, content []byte, src int) {
+	for _, v := range versions[pos] {
+		if !bytes.Equal(v.content, content) {
+			continue
+		}
+		// Duplicates within the same source count only once.
+		if v.sources[len(v.sources)-1] != src {
+			v.sources = append(v.sources, src)
+		}
+		return
+	}
+	versions[pos] = append(versions[pos], &version[T]{item: item, content: content, sources: []int{src}})
+}
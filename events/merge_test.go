@@ -0,0 +1,80 @@
+package events
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+func mergeLog(block, index uint64, data byte) types.Log {
+	return types.Log{
+		Address:     tokenA,
+		Data:        []byte{data},
+		BlockNumber: new(big.Int).SetUint64(block),
+		LogIndex:    &index,
+	}
+}
+
+func TestSortLogs(t *testing.T) {
+	logs := []types.Log{
+		mergeLog(2, 0, 1),
+		{Address: tokenB},
+		mergeLog(1, 1, 2),
+		mergeLog(1, 0, 3),
+		mergeLog(1, 1, 4),
+	}
+	SortLogs(logs)
+	assert.Equal(t, []byte{3}, logs[0].Data)
+	assert.Equal(t, []byte{2}, logs[1].Data)
+	assert.Equal(t, []byte{4}, logs[2].Data)
+	assert.Equal(t, []byte{1}, logs[3].Data)
+	assert.Equal(t, tokenB, logs[4].Address)
+}
+
+func TestMergeLogs(t *testing.T) {
+	a := []types.Log{mergeLog(1, 0, 1), mergeLog(1, 1, 1), mergeLog(2, 0, 9)}
+	b := []types.Log{mergeLog(2, 0, 2), mergeLog(1, 1, 1), mergeLog(1, 1, 1)}
+	c := []types.Log{mergeLog(2, 0, 2), mergeLog(3, 0, 1)}
+
+	var conflicts []Conflict
+	logs, err := MergeLogs(MergeOptions{OnConflict: func(c Conflict) { conflicts = append(conflicts, c) }}, a, b, c)
+	require.NoError(t, err)
+	require.Len(t, logs, 4)
+	assert.Equal(t, mergeLog(1, 0, 1), logs[0])
+	assert.Equal(t, mergeLog(1, 1, 1), logs[1])
+	assert.Equal(t, mergeLog(2, 0, 2), logs[2]) // Majority.
+	assert.Equal(t, mergeLog(3, 0, 1), logs[3])
+	assert.Equal(t, []Conflict{{Position: Position{BlockNumber: 2}, Versions: 2, Sources: []int{1, 2}}}, conflicts)
+
+	logs, err = MergeLogs(MergeOptions{Strategy: PreferredStrategy}, a, b, c)
+	require.NoError(t, err)
+	assert.Equal(t, mergeLog(2, 0, 9), logs[2])
+
+	// Ties are resolved in favor of the earlier source.
+	logs, err = MergeLogs(MergeOptions{}, b, a)
+	require.NoError(t, err)
+	assert.Equal(t, mergeLog(2, 0, 2), logs[2])
+
+	_, err = MergeLogs(MergeOptions{}, []types.Log{{Address: tokenA}})
+	assert.Error(t, err)
+}
+
+func TestMergeReceipts(t *testing.T) {
+	receipt := func(block, index uint64, gas uint64) types.TransactionReceipt {
+		return types.TransactionReceipt{BlockNumber: new(big.Int).SetUint64(block), TransactionIndex: index, GasUsed: gas}
+	}
+	receipts, err := MergeReceipts(
+		MergeOptions{},
+		[]types.TransactionReceipt{receipt(1, 1, 10), receipt(1, 0, 10)},
+		[]types.TransactionReceipt{receipt(1, 0, 10), receipt(0, 5, 10)},
+	)
+	require.NoError(t, err)
+	require.Len(t, receipts, 3)
+	assert.Equal(t, uint64(0), receipts[0].BlockNumber.Uint64())
+	assert.Equal(t, uint64(0), receipts[1].TransactionIndex)
+	assert.Equal(t, uint64(1), receipts[2].TransactionIndex)
+}
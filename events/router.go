@@ -0,0 +1,226 @@
+// Package events provides utilities for processing logs: a router that
+// decodes logs and dispatches them to typed event handlers, and helpers for
+// merging logs and receipts returned by multiple providers.
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/types"
+)
+
+// HandlerFunc handles a decoded event. The log is the raw log the event was
+// decoded from.
+type HandlerFunc[T any] func(ctx context.Context, log types.Log, event T) error
+
+// Router dispatches logs to handlers registered for (address, event) pairs.
+//
+// Every handler matching a log is called, in the order in which the handlers
+// were registered. A handler that returns an error or panics does not
+// prevent other handlers from being called.
+//
+// Router is safe for concurrent use.
+type Router struct {
+	mu     sync.RWMutex
+	routes map[types.Hash][]*route // Routes by topic0.
+	opts   RouterOptions
+}
+
+// RouterOptions is the options for NewRouter.
+type RouterOptions struct {
+	// OnError is called by Run for every failed handler. If nil, errors
+	// are ignored.
+	OnError func(err *HandlerError)
+
+	// OnUnmatched is called for logs that do not match any handler. If nil,
+	// such logs are ignored.
+	OnUnmatched func(ctx context.Context, log types.Log)
+}
+
+// route is a single registered handler.
+type route struct {
+	address types.Address
+	event   *abi.Event
+	handle  func(ctx context.Context, log types.Log) error
+}
+
+// HandlerError is returned when a log cannot be decoded, the handler returns
+// an error, or the handler panics.
+type HandlerError struct {
+	Log   types.Log  // Log is the log that was dispatched.
+	Event *abi.Event // Event is the event the handler was registered for.
+	Err   error      // Err is the decoding or handler error.
+	Panic any        // Panic is the value passed to panic, if the handler panicked.
+}
+
+// Error implements the error interface.
+func (e *HandlerError) Error() string {
+	if e.Panic != nil {
+		return fmt.Sprintf("events: handler for %s panicked: %v", e.Event.Name(), e.Panic)
+	}
+	return fmt.Sprintf("events: handler for %s failed: %v", e.Event.Name(), e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *HandlerError) Unwrap() error {
+	return e.Err
+}
+
+// DispatchError is returned by Router.Dispatch if one or more handlers
+// failed.
+type DispatchError struct {
+	Errors []*HandlerError
+}
+
+// Error implements the error interface.
+func (e *DispatchError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("events: %d handlers failed: %s", len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// NewRouter returns a new Router.
+func NewRouter(opts RouterOptions) *Router {
+	return &Router{
+		routes: make(map[types.Hash][]*route),
+		opts:   opts,
+	}
+}
+
+// Handle registers a handler for the event emitted by the given address.
+// If the address is types.ZeroAddress, the handler is called for the event
+// emitted by any address.
+//
+// The event is decoded into a value of type T using abi.Event.DecodeValue,
+// so T may be a struct with fields named after the event arguments or
+// a map[string]any.
+//
+// Anonymous events cannot be routed, because their logs do not contain
+// the event topic.
+func Handle[T any](r *Router, address types.Address, event *abi.Event, fn HandlerFunc[T]) error {
+	if event == nil {
+		return errors.New("events: event is required")
+	}
+	if fn == nil {
+		return errors.New("events: handler is required")
+	}
+	if event.IsAnonymous() {
+		return fmt.Errorf("events: anonymous event %s cannot be routed", event.Name())
+	}
+	rt := &route{
+		address: address,
+		event:   event,
+		handle: func(ctx context.Context, log types.Log) error {
+			var v T
+			if err := event.DecodeValue(log.Topics, log.Data, &v); err != nil {
+				return err
+			}
+			return fn(ctx, log, v)
+		},
+	}
+	r.mu.Lock()
+	r.routes[event.Topic0()] = append(r.routes[event.Topic0()], rt)
+	r.mu.Unlock()
+	return nil
+}
+
+// MustHandle is like Handle but panics on error.
+func MustHandle[T any](r *Router, address types.Address, event *abi.Event, fn HandlerFunc[T]) {
+	if err := Handle(r, address, event, fn); err != nil {
+		panic(err)
+	}
+}
+
+// Dispatch dispatches logs to the matching handlers. It returns
+// a *DispatchError if any of the handlers failed.
+func (r *Router) Dispatch(ctx context.Context, logs ...types.Log) error {
+	var errs []*HandlerError
+	for _, log := range logs {
+		errs = append(errs, r.dispatch(ctx, log)...)
+	}
+	if len(errs) > 0 {
+		return &DispatchError{Errors: errs}
+	}
+	return nil
+}
+
+// Run dispatches logs received from the channel, for example one returned
+// by rpc.Client.SubscribeLogs, until the channel is closed or the context
+// is canceled. Handler errors are reported to the OnError callback.
+func (r *Router) Run(ctx context.Context, logs <-chan types.Log) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case log, ok := <-logs:
+			if !ok {
+				return nil
+			}
+			for _, err := range r.dispatch(ctx, log) {
+				if r.opts.OnError != nil {
+					r.opts.OnError(err)
+				}
+			}
+		}
+	}
+}
+
+// dispatch calls handlers matching the log.
+func (r *Router) dispatch(ctx context.Context, log types.Log) []*HandlerError {
+	if len(log.Topics) == 0 {
+		r.unmatched(ctx, log)
+		return nil
+	}
+	r.mu.RLock()
+	routes := r.routes[log.Topics[0]]
+	r.mu.RUnlock()
+	var (
+		errs    []*HandlerError
+		matched bool
+	)
+	for _, rt := range routes {
+		if rt.address != types.ZeroAddress && rt.address != log.Address {
+			continue
+		}
+		matched = true
+		if err := rt.call(ctx, log); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if !matched {
+		r.unmatched(ctx, log)
+	}
+	return errs
+}
+
+func (r *Router) unmatched(ctx context.Context, log types.Log) {
+	if r.opts.OnUnmatched != nil {
+		r.opts.OnUnmatched(ctx, log)
+	}
+}
+
+// call calls the handler, converting panics to errors.
+func (rt *route) call(ctx context.Context, log types.Log) (herr *HandlerError) {
+	defer func() {
+		if p := recover(); p != nil {
+			herr = &HandlerError{Log: log, Event: rt.event, Panic: p}
+			if err, ok := p.(error); ok {
+				herr.Err = err
+			}
+		}
+	}()
+	if err := rt.handle(ctx, log); err != nil {
+		return &HandlerError{Log: log, Event: rt.event, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/types"
+)
+
+var (
+	transferEvent = abi.MustParseEvent("Transfer(address indexed src, address indexed dst, uint256 wad)")
+	approvalEvent = abi.MustParseEvent("Approval(address indexed src, address indexed guy, uint256 wad)")
+
+	tokenA = types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+	tokenB = types.MustAddressFromHex("0x2222222222222222222222222222222222222222")
+	alice  = types.MustAddressFromHex("0x3333333333333333333333333333333333333333")
+	bob    = types.MustAddressFromHex("0x4444444444444444444444444444444444444444")
+)
+
+type transfer struct {
+	Src types.Address
+	Dst types.Address
+	Wad *big.Int
+}
+
+func transferLog(token types.Address, wad int64) types.Log {
+	return types.Log{
+		Address: token,
+		Topics: []types.Hash{
+			transferEvent.Topic0(),
+			types.MustHashFromBytes(alice.Bytes(), types.PadLeft),
+			types.MustHashFromBytes(bob.Bytes(), types.PadLeft),
+		},
+		Data: types.MustHashFromBigInt(big.NewInt(wad)).Bytes(),
+	}
+}
+
+func TestRouter_Dispatch(t *testing.T) {
+	r := NewRouter(RouterOptions{})
+
+	var tokenATransfers, allTransfers []transfer
+	MustHandle(r, tokenA, transferEvent, func(_ context.Context, _ types.Log, e transfer) error {
+		tokenATransfers = append(tokenATransfers, e)
+		return nil
+	})
+	MustHandle(r, types.ZeroAddress, transferEvent, func(_ context.Context, _ types.Log, e transfer) error {
+		allTransfers = append(allTransfers, e)
+		return nil
+	})
+	MustHandle(r, tokenA, approvalEvent, func(_ context.Context, _ types.Log, e map[string]any) error {
+		t.Fatal("unexpected approval")
+		return nil
+	})
+
+	require.NoError(t, r.Dispatch(context.Background(), transferLog(tokenA, 1), transferLog(tokenB, 2)))
+	require.Len(t, tokenATransfers, 1)
+	assert.Equal(t, transfer{Src: alice, Dst: bob, Wad: big.NewInt(1)}, tokenATransfers[0])
+	require.Len(t, allTransfers, 2)
+	assert.Equal(t, big.NewInt(2), allTransfers[1].Wad)
+}
+
+func TestRouter_HandlerIsolation(t *testing.T) {
+	r := NewRouter(RouterOptions{})
+	handlerErr := errors.New("handler error")
+
+	var calls int
+	MustHandle(r, tokenA, transferEvent, func(context.Context, types.Log, transfer) error {
+		panic("boom")
+	})
+	MustHandle(r, tokenA, transferEvent, func(context.Context, types.Log, transfer) error {
+		return handlerErr
+	})
+	MustHandle(r, tokenA, transferEvent, func(context.Context, types.Log, transfer) error {
+		calls++
+		return nil
+	})
+
+	err := r.Dispatch(context.Background(), transferLog(tokenA, 1))
+	var dispatchErr *DispatchError
+	require.ErrorAs(t, err, &dispatchErr)
+	require.Len(t, dispatchErr.Errors, 2)
+	assert.Equal(t, "boom", dispatchErr.Errors[0].Panic)
+	assert.ErrorIs(t, dispatchErr.Errors[1], handlerErr)
+	assert.Equal(t, 1, calls)
+}
+
+func TestRouter_Run(t *testing.T) {
+	var (
+		unmatched int
+		errs      []*HandlerError
+	)
+	r := NewRouter(RouterOptions{
+		OnError:     func(err *HandlerError) { errs = append(errs, err) },
+		OnUnmatched: func(context.Context, types.Log) { unmatched++ },
+	})
+	MustHandle(r, tokenA, transferEvent, func(context.Context, types.Log, transfer) error {
+		return nil
+	})
+
+	// A log with an invalid number of topics cannot be decoded.
+	invalid := transferLog(tokenA, 1)
+	invalid.Topics = invalid.Topics[:2]
+
+	ch := make(chan types.Log, 4)
+	ch <- transferLog(tokenA, 1)
+	ch <- transferLog(tokenB, 1)
+	ch <- types.Log{Address: tokenA}
+	ch <- invalid
+	close(ch)
+	require.NoError(t, r.Run(context.Background(), ch))
+	assert.Equal(t, 2, unmatched)
+	require.Len(t, errs, 1)
+	assert.Nil(t, errs[0].Panic)
+}
+
+func TestHandle_Anonymous(t *testing.T) {
+	r := NewRouter(RouterOptions{})
+	event := abi.MustParseEvent("Foo(uint256 a) anonymous")
+	require.Error(t, Handle(r, tokenA, event, func(context.Context, types.Log, map[string]any) error { return nil }))
+}
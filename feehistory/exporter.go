@@ -0,0 +1,306 @@
+package feehistory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/rpc/transport"
+	"github.com/defiweb/go-eth/types"
+)
+
+// DefaultMaxBlocksPerRequest is the default number of blocks requested in a
+// single eth_feeHistory call. Most nodes limit the range to 1024 blocks.
+const DefaultMaxBlocksPerRequest = 1024
+
+// Sample contains fee data for a single block.
+type Sample struct {
+	Number        uint64     // Number is the block number.
+	Time          time.Time  // Time is the block timestamp, see Exporter.Samples for details.
+	BaseFeePerGas *big.Int   // BaseFeePerGas is the base fee per gas of the block.
+	GasUsedRatio  float64    // GasUsedRatio is the ratio of gas used to the gas limit.
+	Reward        []*big.Int // Reward contains the priority fees at the requested reward percentiles.
+}
+
+// Stats contains aggregated values of a single series in a bucket.
+type Stats struct {
+	Min    *big.Int
+	Median *big.Int
+	Mean   *big.Int
+	Max    *big.Int
+}
+
+// Bucket contains fee data aggregated over a time interval.
+type Bucket struct {
+	Start         time.Time // Start is the beginning of the interval.
+	FromBlock     uint64    // FromBlock is the first block in the bucket.
+	ToBlock       uint64    // ToBlock is the last block in the bucket.
+	Blocks        int       // Blocks is the number of blocks in the bucket.
+	BaseFeePerGas Stats     // BaseFeePerGas contains base fee statistics.
+	GasUsedRatio  float64   // GasUsedRatio is the mean gas used ratio.
+	Reward        []Stats   // Reward contains statistics for every requested reward percentile.
+}
+
+// Exporter walks eth_feeHistory over long block ranges and aggregates the
+// results into time buckets.
+type Exporter struct {
+	client      rpc.RPC
+	maxBlocks   uint64
+	percentiles []float64
+}
+
+// ExporterOptions is the options for NewExporter.
+type ExporterOptions struct {
+	// Client is the RPC client used to fetch the fee history.
+	Client rpc.RPC
+
+	// MaxBlocksPerRequest is the maximum number of blocks requested in a
+	// single eth_feeHistory call. If zero, DefaultMaxBlocksPerRequest is used.
+	//
+	// If the node rejects the request with an RPC error, the window is halved
+	// until the request succeeds. If the node silently returns fewer blocks,
+	// the missing blocks are fetched in the next request.
+	MaxBlocksPerRequest uint64
+
+	// RewardPercentiles is the list of reward percentiles to fetch.
+	RewardPercentiles []float64
+}
+
+// NewExporter returns a new Exporter.
+func NewExporter(opts ExporterOptions) (*Exporter, error) {
+	if opts.Client == nil {
+		return nil, errors.New("fee history exporter: client is required")
+	}
+	if opts.MaxBlocksPerRequest == 0 {
+		opts.MaxBlocksPerRequest = DefaultMaxBlocksPerRequest
+	}
+	for i, p := range opts.RewardPercentiles {
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("fee history exporter: invalid reward percentile %f", p)
+		}
+		if i > 0 && p < opts.RewardPercentiles[i-1] {
+			return nil, errors.New("fee history exporter: reward percentiles must be in ascending order")
+		}
+	}
+	return &Exporter{
+		client:      opts.Client,
+		maxBlocks:   opts.MaxBlocksPerRequest,
+		percentiles: opts.RewardPercentiles,
+	}, nil
+}
+
+// Samples returns the fee data for every block in the range [from, to],
+// ordered by block number.
+//
+// The eth_feeHistory method does not return block timestamps. To avoid
+// fetching every block header, timestamps of the first and last block of
+// every requested window are fetched and the timestamps of blocks in between
+// are linearly interpolated.
+func (e *Exporter) Samples(ctx context.Context, from, to uint64) ([]Sample, error) {
+	if from > to {
+		return nil, fmt.Errorf("fee history exporter: invalid block range [%d, %d]", from, to)
+	}
+	var (
+		samples = make([]Sample, 0, to-from+1)
+		window  = e.maxBlocks
+		newest  = to
+	)
+	// The range is walked backwards, because eth_feeHistory returns blocks
+	// up to the newest block. If the node returns fewer blocks than requested,
+	// the next request simply starts below the oldest returned block.
+	for {
+		count := window
+		if newest-from+1 < count {
+			count = newest - from + 1
+		}
+		fh, err := e.client.FeeHistory(ctx, count, types.BlockNumberFromUint64(newest), e.percentiles)
+		if err != nil {
+			if window > 1 && isRPCError(err) {
+				window /= 2
+				continue
+			}
+			return nil, fmt.Errorf("fee history exporter: %w", err)
+		}
+		chunk, err := e.chunkSamples(ctx, fh, from, newest)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, chunk...)
+		oldest := chunk[0].Number
+		if oldest <= from {
+			break
+		}
+		newest = oldest - 1
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].Number < samples[j].Number
+	})
+	return samples, nil
+}
+
+// Export returns the fee data for the range [from, to] aggregated into
+// buckets of the given interval, e.g. time.Hour or 24 * time.Hour.
+func (e *Exporter) Export(ctx context.Context, from, to uint64, interval time.Duration) ([]Bucket, error) {
+	samples, err := e.Samples(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return Aggregate(samples, interval), nil
+}
+
+// chunkSamples converts a single eth_feeHistory response to samples.
+func (e *Exporter) chunkSamples(ctx context.Context, fh *types.FeeHistory, from, newest uint64) ([]Sample, error) {
+	// The BaseFeePerGas contains one extra element for the block after the
+	// newest block, hence the number of blocks is taken from GasUsedRatio.
+	n := uint64(len(fh.GasUsedRatio))
+	if n == 0 || uint64(len(fh.BaseFeePerGas)) < n {
+		return nil, fmt.Errorf("fee history exporter: empty or malformed response for block %d", newest)
+	}
+	if fh.OldestBlock+n-1 != newest {
+		return nil, fmt.Errorf("fee history exporter: unexpected block range [%d, %d], expected newest block %d", fh.OldestBlock, fh.OldestBlock+n-1, newest)
+	}
+	first, last := fh.OldestBlock, newest
+	if first < from {
+		first = from
+	}
+	firstTime, err := e.blockTime(ctx, first)
+	if err != nil {
+		return nil, err
+	}
+	lastTime := firstTime
+	if last != first {
+		if lastTime, err = e.blockTime(ctx, last); err != nil {
+			return nil, err
+		}
+	}
+	samples := make([]Sample, 0, last-first+1)
+	for num := first; num <= last; num++ {
+		i := num - fh.OldestBlock
+		s := Sample{
+			Number:        num,
+			Time:          interpolateTime(first, last, firstTime, lastTime, num),
+			BaseFeePerGas: fh.BaseFeePerGas[i],
+			GasUsedRatio:  fh.GasUsedRatio[i],
+		}
+		if i < uint64(len(fh.Reward)) {
+			s.Reward = fh.Reward[i]
+		}
+		samples = append(samples, s)
+	}
+	return samples, nil
+}
+
+// blockTime returns the timestamp of the given block.
+func (e *Exporter) blockTime(ctx context.Context, number uint64) (time.Time, error) {
+	block, err := e.client.BlockByNumber(ctx, types.BlockNumberFromUint64(number), false)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("fee history exporter: failed to fetch block %d: %w", number, err)
+	}
+	return block.Timestamp, nil
+}
+
+// Aggregate groups samples into buckets of the given interval. Bucket
+// boundaries are aligned to the interval using time.Time.Truncate, so for
+// a 24h interval, buckets start at midnight UTC.
+//
+// Samples must be ordered by block number.
+func Aggregate(samples []Sample, interval time.Duration) []Bucket {
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	var (
+		buckets []Bucket
+		group   []Sample
+	)
+	for _, s := range samples {
+		start := s.Time.UTC().Truncate(interval)
+		if len(group) > 0 && !start.Equal(group[0].Time.UTC().Truncate(interval)) {
+			buckets = append(buckets, newBucket(group, interval))
+			group = group[:0]
+		}
+		group = append(group, s)
+	}
+	if len(group) > 0 {
+		buckets = append(buckets, newBucket(group, interval))
+	}
+	return buckets
+}
+
+// newBucket aggregates samples that belong to the same interval.
+func newBucket(samples []Sample, interval time.Duration) Bucket {
+	b := Bucket{
+		Start:     samples[0].Time.UTC().Truncate(interval),
+		FromBlock: samples[0].Number,
+		ToBlock:   samples[len(samples)-1].Number,
+		Blocks:    len(samples),
+	}
+	var (
+		baseFees  = make([]*big.Int, 0, len(samples))
+		rewards   [][]*big.Int
+		gasUsedRt float64
+	)
+	for _, s := range samples {
+		baseFees = append(baseFees, s.BaseFeePerGas)
+		gasUsedRt += s.GasUsedRatio
+		for i, r := range s.Reward {
+			if i >= len(rewards) {
+				rewards = append(rewards, make([]*big.Int, 0, len(samples)))
+			}
+			rewards[i] = append(rewards[i], r)
+		}
+	}
+	b.BaseFeePerGas = newStats(baseFees)
+	b.GasUsedRatio = gasUsedRt / float64(len(samples))
+	for _, r := range rewards {
+		b.Reward = append(b.Reward, newStats(r))
+	}
+	return b
+}
+
+// newStats calculates statistics for the given values.
+func newStats(values []*big.Int) Stats {
+	sorted := make([]*big.Int, 0, len(values))
+	for _, v := range values {
+		if v != nil {
+			sorted = append(sorted, v)
+		}
+	}
+	if len(sorted) == 0 {
+		return Stats{}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Cmp(sorted[j]) < 0
+	})
+	sum := new(big.Int)
+	for _, v := range sorted {
+		sum.Add(sum, v)
+	}
+	return Stats{
+		Min:    new(big.Int).Set(sorted[0]),
+		Median: new(big.Int).Set(sorted[len(sorted)/2]),
+		Mean:   sum.Div(sum, big.NewInt(int64(len(sorted)))),
+		Max:    new(big.Int).Set(sorted[len(sorted)-1]),
+	}
+}
+
+// interpolateTime returns the estimated timestamp of block num, assuming
+// blocks between first and last were produced at a constant rate.
+func interpolateTime(first, last uint64, firstTime, lastTime time.Time, num uint64) time.Time {
+	if last == first {
+		return firstTime
+	}
+	span := lastTime.Sub(firstTime)
+	offset := time.Duration(float64(span) * float64(num-first) / float64(last-first))
+	return firstTime.Add(offset).Truncate(time.Second)
+}
+
+// isRPCError returns true if the error was returned by the node, as opposed
+// to transport or context errors.
+func isRPCError(err error) bool {
+	var rpcErr transport.RPCErrorCode
+	return errors.As(err, &rpcErr)
+}
@@ -0,0 +1,121 @@
+package feehistory
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/rpc/transport"
+	"github.com/defiweb/go-eth/types"
+)
+
+// fakeRPC simulates a node with 12s block time, base fee equal to the block
+// number and rewards equal to the block number multiplied by the percentile.
+type fakeRPC struct {
+	rpc.Client
+
+	limit  uint64 // Maximum number of blocks returned, excess is silently truncated.
+	reject uint64 // Requests for more blocks than this are rejected.
+	calls  int
+}
+
+func (f *fakeRPC) FeeHistory(_ context.Context, blockCount uint64, newestBlock types.BlockNumber, rewardPercentiles []float64) (*types.FeeHistory, error) {
+	f.calls++
+	if f.reject > 0 && blockCount > f.reject {
+		return nil, transport.NewRPCError(transport.ErrCodeInvalidParams, "too many blocks", nil)
+	}
+	if f.limit > 0 && blockCount > f.limit {
+		blockCount = f.limit
+	}
+	newest := newestBlock.Big().Uint64()
+	fh := &types.FeeHistory{OldestBlock: newest - blockCount + 1}
+	for n := fh.OldestBlock; n <= newest+1; n++ {
+		fh.BaseFeePerGas = append(fh.BaseFeePerGas, new(big.Int).SetUint64(n))
+		if n > newest {
+			continue
+		}
+		fh.GasUsedRatio = append(fh.GasUsedRatio, 0.5)
+		var reward []*big.Int
+		for _, p := range rewardPercentiles {
+			reward = append(reward, new(big.Int).SetUint64(n*uint64(p)))
+		}
+		fh.Reward = append(fh.Reward, reward)
+	}
+	return fh, nil
+}
+
+func (f *fakeRPC) BlockByNumber(_ context.Context, number types.BlockNumber, _ bool) (*types.Block, error) {
+	n := number.Big().Int64()
+	return &types.Block{Number: big.NewInt(n), Timestamp: time.Unix(n*12, 0)}, nil
+}
+
+func TestExporter_Samples(t *testing.T) {
+	tests := []struct {
+		name   string
+		rpc    *fakeRPC
+		window uint64
+		calls  int
+	}{
+		{name: "single window", rpc: &fakeRPC{}, window: 1024, calls: 1},
+		{name: "multiple windows", rpc: &fakeRPC{}, window: 100, calls: 11},
+		{name: "silently truncated", rpc: &fakeRPC{limit: 300}, window: 1024, calls: 4},
+		{name: "rejected window", rpc: &fakeRPC{reject: 500}, window: 1024, calls: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := NewExporter(ExporterOptions{
+				Client:              tt.rpc,
+				MaxBlocksPerRequest: tt.window,
+				RewardPercentiles:   []float64{10, 50},
+			})
+			require.NoError(t, err)
+
+			samples, err := e.Samples(context.Background(), 1000, 2000)
+			require.NoError(t, err)
+			require.Len(t, samples, 1001)
+			for i, s := range samples {
+				n := uint64(1000 + i)
+				assert.Equal(t, n, s.Number)
+				assert.Equal(t, new(big.Int).SetUint64(n), s.BaseFeePerGas)
+				assert.Equal(t, []*big.Int{new(big.Int).SetUint64(n * 10), new(big.Int).SetUint64(n * 50)}, s.Reward)
+				assert.Equal(t, time.Unix(int64(n*12), 0), s.Time)
+			}
+			assert.Equal(t, tt.calls, tt.rpc.calls)
+		})
+	}
+}
+
+func TestAggregate(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var samples []Sample
+	for i := 0; i < 6; i++ {
+		samples = append(samples, Sample{
+			Number:        uint64(i),
+			Time:          start.Add(time.Duration(i) * 30 * time.Minute),
+			BaseFeePerGas: big.NewInt(int64(i + 1)),
+			GasUsedRatio:  float64(i) / 10,
+			Reward:        []*big.Int{big.NewInt(int64(i * 2))},
+		})
+	}
+
+	buckets := Aggregate(samples, time.Hour)
+	require.Len(t, buckets, 3)
+
+	assert.Equal(t, start.Add(time.Hour), buckets[1].Start)
+	assert.Equal(t, uint64(2), buckets[1].FromBlock)
+	assert.Equal(t, uint64(3), buckets[1].ToBlock)
+	assert.Equal(t, 2, buckets[1].Blocks)
+	assert.Equal(t, big.NewInt(3), buckets[1].BaseFeePerGas.Min)
+	assert.Equal(t, big.NewInt(4), buckets[1].BaseFeePerGas.Max)
+	assert.Equal(t, big.NewInt(3), buckets[1].BaseFeePerGas.Mean)
+	assert.Equal(t, big.NewInt(4), buckets[1].BaseFeePerGas.Median)
+	assert.InDelta(t, 0.25, buckets[1].GasUsedRatio, 1e-9)
+	require.Len(t, buckets[1].Reward, 1)
+	assert.Equal(t, big.NewInt(4), buckets[1].Reward[0].Min)
+	assert.Equal(t, big.NewInt(6), buckets[1].Reward[0].Max)
+}
@@ -11,6 +11,9 @@ require (
 	github.com/defiweb/go-anymapper v0.3.0
 	github.com/defiweb/go-rlp v0.3.0
 	github.com/defiweb/go-sigparser v0.6.0
+	github.com/goccy/go-json v0.10.2
+	github.com/golang/snappy v0.0.4
+	github.com/mattn/go-sqlite3 v1.14.17
 	github.com/stretchr/testify v1.8.4
 	github.com/tyler-smith/go-bip39 v1.1.0
 	golang.org/x/crypto v0.18.0
@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math/big"
+	"strconv"
 )
 
 // BigIntToHex returns the hex representation of the given big integer.
@@ -52,6 +53,104 @@ func MustHexToBigInt(h string) *big.Int {
 	return x
 }
 
+// Uint64ToHex returns the hex representation of the given integer, encoded
+// as a quantity as defined in the Ethereum JSON-RPC specification, e.g.
+// "0x0" or "0x1a".
+func Uint64ToHex(x uint64) string {
+	return "0x" + strconv.FormatUint(x, 16)
+}
+
+// HexToUint64 returns the integer representation of the given hex string.
+//
+// The parsing is lenient: the hex string may be prefixed with "0x" and may
+// contain leading zeros. Use HexToUint64Strict to accept only quantities
+// encoded as required by the specification.
+func HexToUint64(h string) (uint64, error) {
+	if Has0xPrefix(h) {
+		h = h[2:]
+	}
+	if len(h) == 0 {
+		return 0, fmt.Errorf("invalid hex string")
+	}
+	x, err := strconv.ParseUint(h, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hex string")
+	}
+	return x, nil
+}
+
+// MustHexToUint64 is like HexToUint64 but panics on error.
+func MustHexToUint64(h string) uint64 {
+	x, err := HexToUint64(h)
+	if err != nil {
+		panic(err)
+	}
+	return x
+}
+
+// HexToUint64Strict is like HexToUint64 but accepts only valid quantities,
+// see ValidateQuantity.
+func HexToUint64Strict(h string) (uint64, error) {
+	if err := ValidateQuantity(h); err != nil {
+		return 0, err
+	}
+	return HexToUint64(h)
+}
+
+// HexToBigIntStrict is like HexToBigInt but accepts only valid quantities,
+// see ValidateQuantity. Negative numbers are not allowed.
+func HexToBigIntStrict(h string) (*big.Int, error) {
+	if err := ValidateQuantity(h); err != nil {
+		return nil, err
+	}
+	return HexToBigInt(h)
+}
+
+// ValidateQuantity verifies that the hex string is a quantity encoded as
+// defined in the Ethereum JSON-RPC specification: it must be prefixed with
+// "0x", must contain at least one digit and must not have leading zeros,
+// with the exception of "0x0".
+func ValidateQuantity(h string) error {
+	if len(h) < 2 || h[0] != '0' || h[1] != 'x' {
+		return fmt.Errorf("invalid quantity, missing 0x prefix")
+	}
+	h = h[2:]
+	if len(h) == 0 {
+		return fmt.Errorf("invalid quantity, no digits")
+	}
+	if len(h) > 1 && h[0] == '0' {
+		return fmt.Errorf("invalid quantity, leading zeros are not allowed")
+	}
+	for _, c := range h {
+		if !isHexDigit(c) {
+			return fmt.Errorf("invalid hex string")
+		}
+	}
+	return nil
+}
+
+// BytesToHashPadded returns the given bytes left-padded with zeros to 32
+// bytes, e.g. to convert an address to a log topic. The result can be
+// assigned to types.Hash. It returns an error if the input is longer than
+// 32 bytes.
+func BytesToHashPadded(b []byte) ([32]byte, error) {
+	var h [32]byte
+	if len(b) > len(h) {
+		return h, fmt.Errorf("invalid hash length, got %d bytes, want at most 32", len(b))
+	}
+	copy(h[len(h)-len(b):], b)
+	return h, nil
+}
+
+// MustBytesToHashPadded is like BytesToHashPadded but panics on error.
+func MustBytesToHashPadded(b []byte) [32]byte {
+	h, err := BytesToHashPadded(b)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
 // BytesToHex returns the hex representation of the given bytes. The hex string
 // is always even-length and prefixed with "0x".
 func BytesToHex(b []byte) string {
@@ -91,6 +190,11 @@ func MustHexToBytes(h string) []byte {
 	return b
 }
 
+// isHexDigit returns true if the given rune is a hex digit.
+func isHexDigit(c rune) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f') || ('A' <= c && c <= 'F')
+}
+
 // Has0xPrefix returns true if the given byte slice starts with "0x".
 func Has0xPrefix(h string) bool {
 	return len(h) >= 2 && h[0] == '0' && (h[1] == 'x' || h[1] == 'X')
@@ -2,6 +2,7 @@ package hexutil
 
 import (
 	"fmt"
+	"math"
 	"math/big"
 	"testing"
 
@@ -96,3 +97,70 @@ func TestHexToBytes(t *testing.T) {
 		})
 	}
 }
+
+func TestUint64ToHex(t *testing.T) {
+	assert.Equal(t, "0x0", Uint64ToHex(0))
+	assert.Equal(t, "0x1a", Uint64ToHex(26))
+	assert.Equal(t, "0xffffffffffffffff", Uint64ToHex(math.MaxUint64))
+}
+
+func TestHexToUint64(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		expected  uint64
+		wantErr   bool
+		strictErr bool
+	}{
+		{name: "zero", input: "0x0", expected: 0},
+		{name: "valid", input: "0x1a", expected: 26},
+		{name: "uppercase", input: "0x1A", expected: 26},
+		{name: "max", input: "0xffffffffffffffff", expected: math.MaxUint64},
+		{name: "leading zeros", input: "0x001a", expected: 26, strictErr: true},
+		{name: "without prefix", input: "1a", expected: 26, strictErr: true},
+		{name: "uppercase prefix", input: "0X1a", expected: 26, strictErr: true},
+		{name: "empty", input: "0x", wantErr: true, strictErr: true},
+		{name: "overflow", input: "0x10000000000000000", wantErr: true, strictErr: true},
+		{name: "invalid", input: "0x1g", wantErr: true, strictErr: true},
+		{name: "negative", input: "-0x1", wantErr: true, strictErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, err := HexToUint64(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, x)
+			}
+			x, err = HexToUint64Strict(tt.input)
+			if tt.strictErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected, x)
+			}
+		})
+	}
+}
+
+func TestHexToBigIntStrict(t *testing.T) {
+	x, err := HexToBigIntStrict("0x10000000000000000")
+	assert.NoError(t, err)
+	assert.Equal(t, "18446744073709551616", x.String())
+
+	_, err = HexToBigIntStrict("0x01")
+	assert.Error(t, err)
+
+	_, err = HexToBigIntStrict("-0x1")
+	assert.Error(t, err)
+}
+
+func TestBytesToHashPadded(t *testing.T) {
+	h := MustBytesToHashPadded([]byte{0xaa, 0xbb})
+	assert.Equal(t, "0x000000000000000000000000000000000000000000000000000000000000aabb", BytesToHex(h[:]))
+
+	_, err := BytesToHashPadded(make([]byte, 33))
+	assert.Error(t, err)
+	assert.Panics(t, func() { MustBytesToHashPadded(make([]byte, 33)) })
+}
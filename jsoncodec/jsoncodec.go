@@ -0,0 +1,120 @@
+// Package jsoncodec allows to replace the JSON codec used by the transport
+// and types packages.
+//
+// By default, the encoding/json package is used. High-throughput
+// applications, such as indexers decoding full blocks, may use a faster
+// codec instead. The codec must be compatible with encoding/json, i.e. it
+// must respect struct tags and the json.Marshaler and json.Unmarshaler
+// interfaces.
+//
+// For example, to use github.com/json-iterator/go:
+//
+//	type jsoniterCodec struct{ api jsoniter.API }
+//
+//	func (c jsoniterCodec) Marshal(v any) ([]byte, error)      { return c.api.Marshal(v) }
+//	func (c jsoniterCodec) Unmarshal(data []byte, v any) error { return c.api.Unmarshal(data, v) }
+//	func (c jsoniterCodec) NewEncoder(w io.Writer) jsoncodec.Encoder { return c.api.NewEncoder(w) }
+//	func (c jsoniterCodec) NewDecoder(r io.Reader) jsoncodec.Decoder { return c.api.NewDecoder(r) }
+//
+//	jsoncodec.Set(jsoniterCodec{api: jsoniter.ConfigCompatibleWithStandardLibrary})
+//
+// The codec should be set once, before any transport is created.
+package jsoncodec
+
+import (
+	"encoding/json"
+	"io"
+	"sync/atomic"
+)
+
+// Codec is a JSON codec.
+type Codec interface {
+	// Marshal returns the JSON encoding of v.
+	Marshal(v any) ([]byte, error)
+
+	// Unmarshal parses the JSON-encoded data and stores the result in the
+	// value pointed to by v.
+	Unmarshal(data []byte, v any) error
+
+	// NewEncoder returns a new encoder that writes to w.
+	NewEncoder(w io.Writer) Encoder
+
+	// NewDecoder returns a new decoder that reads from r.
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Encoder writes JSON values to an output stream.
+type Encoder interface {
+	Encode(v any) error
+}
+
+// Decoder reads JSON values from an input stream.
+type Decoder interface {
+	Decode(v any) error
+}
+
+// Std is the codec that uses the encoding/json package.
+var Std Codec = stdCodec{}
+
+// codecHolder wraps a Codec, so that different implementations can be stored
+// in the same atomic.Value.
+type codecHolder struct{ Codec }
+
+var current atomic.Value
+
+func init() {
+	current.Store(codecHolder{Std})
+}
+
+// Set sets the codec used by the package-level functions. If c is nil, the
+// Std codec is restored.
+func Set(c Codec) {
+	if c == nil {
+		c = Std
+	}
+	current.Store(codecHolder{c})
+}
+
+// Get returns the current codec.
+func Get() Codec {
+	return current.Load().(codecHolder).Codec
+}
+
+// Marshal returns the JSON encoding of v using the current codec.
+func Marshal(v any) ([]byte, error) {
+	return Get().Marshal(v)
+}
+
+// Unmarshal parses the JSON-encoded data and stores the result in the value
+// pointed to by v using the current codec.
+func Unmarshal(data []byte, v any) error {
+	return Get().Unmarshal(data, v)
+}
+
+// NewEncoder returns a new encoder that writes to w using the current codec.
+func NewEncoder(w io.Writer) Encoder {
+	return Get().NewEncoder(w)
+}
+
+// NewDecoder returns a new decoder that reads from r using the current codec.
+func NewDecoder(r io.Reader) Decoder {
+	return Get().NewDecoder(r)
+}
+
+type stdCodec struct{}
+
+func (stdCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+func (stdCodec) NewEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+func (stdCodec) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
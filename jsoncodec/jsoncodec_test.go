@@ -0,0 +1,153 @@
+package jsoncodec_test
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	gojson "github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/jsoncodec"
+	"github.com/defiweb/go-eth/types"
+)
+
+// countingCodec wraps the Std codec and counts the calls.
+type countingCodec struct {
+	marshal, unmarshal int
+}
+
+func (c *countingCodec) Marshal(v any) ([]byte, error) {
+	c.marshal++
+	return jsoncodec.Std.Marshal(v)
+}
+
+func (c *countingCodec) Unmarshal(data []byte, v any) error {
+	c.unmarshal++
+	return jsoncodec.Std.Unmarshal(data, v)
+}
+
+func (c *countingCodec) NewEncoder(w io.Writer) jsoncodec.Encoder {
+	return jsoncodec.Std.NewEncoder(w)
+}
+
+func (c *countingCodec) NewDecoder(r io.Reader) jsoncodec.Decoder {
+	return jsoncodec.Std.NewDecoder(r)
+}
+
+func TestSet(t *testing.T) {
+	c := &countingCodec{}
+	jsoncodec.Set(c)
+	defer jsoncodec.Set(nil)
+
+	var block types.Block
+	require.NoError(t, block.UnmarshalJSON([]byte(fullBlockJSON(2))))
+	assert.Len(t, block.Transactions, 2)
+	assert.Greater(t, c.unmarshal, 0)
+
+	_, err := block.MarshalJSON()
+	require.NoError(t, err)
+	assert.Greater(t, c.marshal, 0)
+
+	jsoncodec.Set(nil)
+	assert.Equal(t, jsoncodec.Std, jsoncodec.Get())
+}
+
+// goJSONCodec is a codec that uses the github.com/goccy/go-json package.
+type goJSONCodec struct{}
+
+func (goJSONCodec) Marshal(v any) ([]byte, error) {
+	return gojson.Marshal(v)
+}
+
+func (goJSONCodec) Unmarshal(data []byte, v any) error {
+	return gojson.Unmarshal(data, v)
+}
+
+func (goJSONCodec) NewEncoder(w io.Writer) jsoncodec.Encoder {
+	return gojson.NewEncoder(w)
+}
+
+func (goJSONCodec) NewDecoder(r io.Reader) jsoncodec.Decoder {
+	return gojson.NewDecoder(r)
+}
+
+// BenchmarkUnmarshalBlock measures decoding of a full block with 200
+// transactions using the Std codec and a faster third-party codec.
+func BenchmarkUnmarshalBlock(b *testing.B) {
+	data := []byte(fullBlockJSON(200))
+	codecs := []struct {
+		name  string
+		codec jsoncodec.Codec
+	}{
+		{name: "std", codec: jsoncodec.Std},
+		{name: "fast", codec: goJSONCodec{}},
+	}
+	for _, c := range codecs {
+		b.Run(c.name, func(b *testing.B) {
+			jsoncodec.Set(c.codec)
+			defer jsoncodec.Set(nil)
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var block types.Block
+				if err := jsoncodec.Unmarshal(data, &block); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func fullBlockJSON(txs int) string {
+	var sb strings.Builder
+	sb.WriteString(`{
+		"number": "0x11",
+		"hash": "0x2222222222222222222222222222222222222222222222222222222222222222",
+		"parentHash": "0x3333333333333333333333333333333333333333333333333333333333333333",
+		"nonce": "0x4444444444444444",
+		"sha3Uncles": "0x5555555555555555555555555555555555555555555555555555555555555555",
+		"logsBloom": "0x` + strings.Repeat("66", 256) + `",
+		"transactionsRoot": "0x7777777777777777777777777777777777777777777777777777777777777777",
+		"stateRoot": "0x8888888888888888888888888888888888888888888888888888888888888888",
+		"receiptsRoot": "0x9999999999999999999999999999999999999999999999999999999999999999",
+		"miner": "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		"difficulty": "0x0",
+		"extraData": "0x",
+		"size": "0xdddddd",
+		"gasLimit": "0x1c9c380",
+		"gasUsed": "0xffffff",
+		"timestamp": "0x54e34e8e",
+		"baseFeePerGas": "0x3b9aca00",
+		"uncles": [],
+		"transactions": [`)
+	for i := 0; i < txs; i++ {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		fmt.Fprintf(&sb, `{
+			"type": "0x2",
+			"hash": "0x%064x",
+			"nonce": "0x%x",
+			"blockHash": "0x2222222222222222222222222222222222222222222222222222222222222222",
+			"blockNumber": "0x11",
+			"transactionIndex": "0x%x",
+			"from": "0x5555555555555555555555555555555555555555",
+			"to": "0x6666666666666666666666666666666666666666",
+			"value": "0x2540be400",
+			"gas": "0x76c0",
+			"maxFeePerGas": "0x9184e72a000",
+			"maxPriorityFeePerGas": "0x3b9aca00",
+			"input": "0xa9059cbb000000000000000000000000777777777777777777777777777777777777777700000000000000000000000000000000000000000000000000000000000003e8",
+			"accessList": [],
+			"chainId": "0x1",
+			"v": "0x1",
+			"r": "0x8888888888888888888888888888888888888888888888888888888888888888",
+			"s": "0x1999999999999999999999999999999999999999999999999999999999999999"
+		}`, i+1, i, i)
+	}
+	sb.WriteString(`]}`)
+	return sb.String()
+}
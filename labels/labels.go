@@ -0,0 +1,190 @@
+// Package labels provides a registry of human-readable names for addresses,
+// such as "Uniswap V3 Router", used by tools to make their output easier to
+// read.
+//
+// The registry can be filled manually or loaded from JSON or CSV datasets.
+// A Registry can be passed to abi.FormatOptions to show labels next to
+// formatted addresses.
+package labels
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// Default is the default registry.
+var Default = NewRegistry()
+
+// Registry maps addresses to human-readable names.
+//
+// Registry is safe for concurrent use.
+type Registry struct {
+	mu     sync.RWMutex
+	labels map[types.Address]string
+}
+
+// NewRegistry creates a new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{labels: make(map[types.Address]string)}
+}
+
+// Set sets the label of the address. An empty label removes the address
+// from the registry.
+func (r *Registry) Set(addr types.Address, label string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if label == "" {
+		delete(r.labels, addr)
+		return
+	}
+	r.labels[addr] = label
+}
+
+// Label returns the label of the address. The second return value is false
+// if the address has no label.
+func (r *Registry) Label(addr types.Address) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	label, ok := r.labels[addr]
+	return label, ok
+}
+
+// Name returns the label of the address, or the address as a hex string if
+// the address has no label.
+func (r *Registry) Name(addr types.Address) string {
+	if label, ok := r.Label(addr); ok {
+		return label
+	}
+	return addr.String()
+}
+
+// Len returns the number of labeled addresses.
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.labels)
+}
+
+// LoadJSON loads labels from a JSON document. Two formats are supported,
+// an object that maps addresses to labels:
+//
+//	{"0xe592427a0aece92de3edee1f18e0157c05861564": "Uniswap V3 Router"}
+//
+// and an array of objects with "address" and "name" fields:
+//
+//	[{"address": "0xe592427a0aece92de3edee1f18e0157c05861564", "name": "Uniswap V3 Router"}]
+//
+// Existing labels are overwritten.
+func (r *Registry) LoadJSON(rd io.Reader) error {
+	var raw json.RawMessage
+	if err := json.NewDecoder(rd).Decode(&raw); err != nil {
+		return fmt.Errorf("labels: failed to parse JSON: %w", err)
+	}
+	labels := make(map[types.Address]string)
+	switch {
+	case strings.HasPrefix(strings.TrimSpace(string(raw)), "["):
+		var entries []struct {
+			Address types.Address `json:"address"`
+			Name    string        `json:"name"`
+		}
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			return fmt.Errorf("labels: failed to parse JSON: %w", err)
+		}
+		for _, e := range entries {
+			labels[e.Address] = e.Name
+		}
+	default:
+		var entries map[string]string
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			return fmt.Errorf("labels: failed to parse JSON: %w", err)
+		}
+		for k, v := range entries {
+			addr, err := types.AddressFromHex(k)
+			if err != nil {
+				return fmt.Errorf("labels: invalid address %q: %w", k, err)
+			}
+			labels[addr] = v
+		}
+	}
+	r.setAll(labels)
+	return nil
+}
+
+// LoadCSV loads labels from a CSV document. The first column must contain
+// the address and the second column the label, other columns are ignored.
+// The first row is skipped if it does not contain a valid address, so the
+// document may have a header.
+//
+// Existing labels are overwritten.
+func (r *Registry) LoadCSV(rd io.Reader) error {
+	cr := csv.NewReader(rd)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	labels := make(map[types.Address]string)
+	for line := 1; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("labels: failed to parse CSV: %w", err)
+		}
+		if len(record) < 2 {
+			return fmt.Errorf("labels: line %d: expected at least 2 columns", line)
+		}
+		addr, err := types.AddressFromHex(strings.TrimSpace(record[0]))
+		if err != nil {
+			if line == 1 {
+				continue // Header.
+			}
+			return fmt.Errorf("labels: line %d: invalid address %q: %w", line, record[0], err)
+		}
+		labels[addr] = strings.TrimSpace(record[1])
+	}
+	r.setAll(labels)
+	return nil
+}
+
+// LoadFile loads labels from a JSON or CSV file. The format is determined
+// by the file extension.
+func (r *Registry) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("labels: %w", err)
+	}
+	defer f.Close()
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return r.LoadJSON(f)
+	case ".csv":
+		return r.LoadCSV(f)
+	default:
+		return fmt.Errorf("labels: unsupported file extension %q", filepath.Ext(path))
+	}
+}
+
+// setAll sets all labels at once, so that a failed load does not leave the
+// registry partially updated.
+func (r *Registry) setAll(labels map[types.Address]string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for addr, label := range labels {
+		if label == "" {
+			delete(r.labels, addr)
+			continue
+		}
+		r.labels[addr] = label
+	}
+}
@@ -0,0 +1,99 @@
+package labels
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+var (
+	router = types.MustAddressFromHex("0xe592427a0aece92de3edee1f18e0157c05861564")
+	weth   = types.MustAddressFromHex("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2")
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	r.Set(router, "Uniswap V3 Router")
+
+	label, ok := r.Label(router)
+	assert.True(t, ok)
+	assert.Equal(t, "Uniswap V3 Router", label)
+	assert.Equal(t, "Uniswap V3 Router", r.Name(router))
+
+	_, ok = r.Label(weth)
+	assert.False(t, ok)
+	assert.Equal(t, weth.String(), r.Name(weth))
+
+	r.Set(router, "")
+	assert.Equal(t, 0, r.Len())
+}
+
+func TestRegistry_LoadJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name: "object",
+			data: `{"0xe592427a0aece92de3edee1f18e0157c05861564": "Uniswap V3 Router", "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2": "WETH"}`,
+		},
+		{
+			name: "array",
+			data: `[{"address": "0xe592427a0aece92de3edee1f18e0157c05861564", "name": "Uniswap V3 Router"}, {"address": "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2", "name": "WETH"}]`,
+		},
+		{
+			name:    "invalid address",
+			data:    `{"0x1234": "Invalid"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewRegistry()
+			err := r.LoadJSON(strings.NewReader(tt.data))
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Equal(t, 0, r.Len())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "Uniswap V3 Router", r.Name(router))
+			assert.Equal(t, "WETH", r.Name(weth))
+		})
+	}
+}
+
+func TestRegistry_LoadCSV(t *testing.T) {
+	r := NewRegistry()
+	err := r.LoadCSV(strings.NewReader("address,name,category\n" +
+		"0xe592427a0aece92de3edee1f18e0157c05861564, Uniswap V3 Router, dex\n" +
+		"0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2,\"WETH, Wrapped Ether\"\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, r.Len())
+	assert.Equal(t, "Uniswap V3 Router", r.Name(router))
+	assert.Equal(t, "WETH, Wrapped Ether", r.Name(weth))
+
+	err = r.LoadCSV(strings.NewReader("0xe592427a0aece92de3edee1f18e0157c05861564,Router\ninvalid,Invalid\n"))
+	require.Error(t, err)
+	assert.Equal(t, "Uniswap V3 Router", r.Name(router))
+}
+
+func TestRegistry_LoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "labels.csv")
+	require.NoError(t, os.WriteFile(path, []byte("0xe592427a0aece92de3edee1f18e0157c05861564,Uniswap V3 Router\n"), 0o600))
+
+	r := NewRegistry()
+	require.NoError(t, r.LoadFile(path))
+	assert.Equal(t, "Uniswap V3 Router", r.Name(router))
+
+	path = filepath.Join(t.TempDir(), "labels.txt")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+	assert.Error(t, r.LoadFile(path))
+}
@@ -0,0 +1,95 @@
+package ledger
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// csvHeader is the header of the CSV export.
+var csvHeader = []string{"block", "time", "transaction", "account", "kind", "direction", "token", "from", "to", "amount"}
+
+// WriteCSV writes the entries to w as CSV with a header row. Amounts are
+// decimal integers in the smallest unit of the currency or token, times are
+// in RFC 3339 format in UTC, and the token column is empty for the native
+// currency.
+func WriteCSV(w io.Writer, entries []Entry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		j := newJSONEntry(e)
+		if err := cw.Write([]string{
+			strconv.FormatUint(j.Block, 10),
+			j.Time,
+			j.Transaction.String(),
+			j.Account.String(),
+			j.Kind,
+			j.Direction,
+			j.Token,
+			j.From.String(),
+			j.To.String(),
+			j.Amount,
+		}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the entries to w as a JSON array. The fields are the
+// same as the columns of WriteCSV, with amounts encoded as decimal strings
+// to avoid loss of precision.
+func WriteJSON(w io.Writer, entries []Entry) error {
+	res := make([]jsonEntry, len(entries))
+	for i, e := range entries {
+		res[i] = newJSONEntry(e)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(res)
+}
+
+// jsonEntry is the exported representation of an entry.
+type jsonEntry struct {
+	Block       uint64        `json:"block"`
+	Time        string        `json:"time"`
+	Transaction types.Hash    `json:"transaction"`
+	Account     types.Address `json:"account"`
+	Kind        string        `json:"kind"`
+	Direction   string        `json:"direction"`
+	Token       string        `json:"token,omitempty"`
+	From        types.Address `json:"from"`
+	To          types.Address `json:"to"`
+	Amount      string        `json:"amount"`
+}
+
+func newJSONEntry(e Entry) jsonEntry {
+	j := jsonEntry{
+		Block:       e.Block,
+		Time:        e.Time.UTC().Format(time.RFC3339),
+		Transaction: e.Transaction,
+		Account:     e.Account,
+		Kind:        e.Kind.String(),
+		Direction:   "out",
+		From:        e.From,
+		To:          e.To,
+		Amount:      "0",
+	}
+	if e.Incoming {
+		j.Direction = "in"
+	}
+	if e.Token != nil {
+		j.Token = e.Token.String()
+	}
+	if e.Amount != nil {
+		j.Amount = e.Amount.String()
+	}
+	return j
+}
@@ -0,0 +1,348 @@
+// Package ledger builds a normalized history of value movements of a set of
+// owned accounts, for accounting and tax reporting.
+//
+// The history is built in stages that can be used separately or replaced:
+// Scan finds transactions involving the accounts in a block range, Collect
+// turns transactions into ledger entries using receipts, token transfer
+// logs and, if available, call traces, and WriteCSV and WriteJSON export the
+// entries.
+package ledger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/rpc/transport"
+	"github.com/defiweb/go-eth/types"
+)
+
+// transferEvent is the ERC-20 Transfer event. ERC-721 transfers have the
+// same signature but an indexed token ID, so they have four topics and are
+// not matched.
+var transferEvent = abi.MustParseEvent("Transfer(address indexed from, address indexed to, uint256 value)")
+
+// Kind is the kind of a ledger entry.
+type Kind uint8
+
+const (
+	// NativeTransferKind is a transfer of the native currency by the
+	// transaction itself.
+	NativeTransferKind Kind = iota
+
+	// InternalTransferKind is a transfer of the native currency by a call
+	// made during the execution of the transaction.
+	InternalTransferKind
+
+	// TokenTransferKind is an ERC-20 token transfer.
+	TokenTransferKind
+
+	// FeeKind is the transaction fee paid by the sender.
+	FeeKind
+)
+
+// String implements the fmt.Stringer interface.
+func (k Kind) String() string {
+	switch k {
+	case NativeTransferKind:
+		return "native"
+	case InternalTransferKind:
+		return "internal"
+	case TokenTransferKind:
+		return "token"
+	case FeeKind:
+		return "fee"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(k))
+	}
+}
+
+// Entry is a single value movement of an owned account.
+//
+// A transfer between two owned accounts produces two entries, one for each
+// account.
+type Entry struct {
+	Account     types.Address  // Account is the owned account.
+	Kind        Kind           // Kind is the kind of the entry.
+	Block       uint64         // Block is the block number.
+	Time        time.Time      // Time is the block timestamp.
+	Transaction types.Hash     // Transaction is the hash of the transaction.
+	Token       *types.Address // Token is the token contract, nil for the native currency.
+	From        types.Address  // From is the sender of the value.
+	To          types.Address  // To is the recipient of the value.
+	Amount      *big.Int       // Amount is the transferred amount or the fee, always positive.
+	Incoming    bool           // Incoming is true if the account received the value.
+}
+
+// Delta returns the amount by which the entry changes the balance of the
+// account: positive for incoming entries and negative otherwise.
+func (e Entry) Delta() *big.Int {
+	if e.Incoming {
+		return new(big.Int).Set(e.Amount)
+	}
+	return new(big.Int).Neg(e.Amount)
+}
+
+// Tracer traces the calls of a transaction. It is implemented by
+// rpc.Client.
+type Tracer interface {
+	TraceCalls(ctx context.Context, hash types.Hash) (*rpc.CallFrame, error)
+}
+
+// Options is the options for New.
+type Options struct {
+	// Client is the RPC client used to fetch transactions, receipts, logs
+	// and blocks.
+	Client rpc.RPC
+
+	// Accounts is the list of owned accounts.
+	Accounts []types.Address
+
+	// Tracer is used to find internal transfers. If nil, or if the node
+	// does not support tracing, internal transfers are not included.
+	Tracer Tracer
+}
+
+// Ledger builds the history of the owned accounts.
+type Ledger struct {
+	opts     Options
+	accounts map[types.Address]struct{}
+}
+
+// New returns a new Ledger.
+func New(opts Options) (*Ledger, error) {
+	if opts.Client == nil {
+		return nil, errors.New("ledger: client is required")
+	}
+	if len(opts.Accounts) == 0 {
+		return nil, errors.New("ledger: at least one account is required")
+	}
+	l := &Ledger{opts: opts, accounts: make(map[types.Address]struct{}, len(opts.Accounts))}
+	for _, addr := range opts.Accounts {
+		l.accounts[addr] = struct{}{}
+	}
+	return l, nil
+}
+
+// Scan returns the hashes of transactions in the block range [from, to]
+// that were sent to or by an owned account, or that transferred ERC-20
+// tokens to or from an owned account. Hashes are ordered as in the chain.
+//
+// Every block in the range is fetched, so Scan is suitable for short
+// ranges only. For longer histories, use an indexer to find the
+// transactions and pass them to Collect.
+//
+// Transactions that only transfer the native currency to an owned account
+// using an internal call cannot be found without tracing every transaction
+// and are not returned.
+func (l *Ledger) Scan(ctx context.Context, from, to uint64) ([]types.Hash, error) {
+	type position struct {
+		block uint64
+		index uint64
+	}
+	found := make(map[types.Hash]position)
+	for n := from; n <= to; n++ {
+		block, err := l.opts.Client.BlockByNumber(ctx, types.BlockNumberFromUint64(n), true)
+		if err != nil {
+			return nil, fmt.Errorf("ledger: failed to fetch block %d: %w", n, err)
+		}
+		for i, tx := range block.Transactions {
+			if tx.Hash == nil || !(l.owned(tx.From) || l.owned(tx.To)) {
+				continue
+			}
+			found[*tx.Hash] = position{block: n, index: uint64(i)}
+		}
+		if n == to {
+			break
+		}
+	}
+	var topics []types.Hash
+	for _, addr := range l.opts.Accounts {
+		topics = append(topics, types.MustHashFromBytes(addr.Bytes(), types.PadLeft))
+	}
+	for _, query := range []*types.FilterLogsQuery{
+		{Topics: [][]types.Hash{{transferEvent.Topic0()}, topics}},
+		{Topics: [][]types.Hash{{transferEvent.Topic0()}, nil, topics}},
+	} {
+		query.FromBlock = types.BlockNumberFromUint64Ptr(from)
+		query.ToBlock = types.BlockNumberFromUint64Ptr(to)
+		logs, err := l.opts.Client.GetLogs(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("ledger: failed to fetch transfer logs: %w", err)
+		}
+		for _, log := range logs {
+			if log.TransactionHash == nil || log.BlockNumber == nil || log.TransactionIndex == nil {
+				continue
+			}
+			found[*log.TransactionHash] = position{block: log.BlockNumber.Uint64(), index: *log.TransactionIndex}
+		}
+	}
+	hashes := make([]types.Hash, 0, len(found))
+	for hash := range found {
+		hashes = append(hashes, hash)
+	}
+	sort.Slice(hashes, func(i, j int) bool {
+		a, b := found[hashes[i]], found[hashes[j]]
+		if a.block != b.block {
+			return a.block < b.block
+		}
+		return a.index < b.index
+	})
+	return hashes, nil
+}
+
+// Collect returns the ledger entries of the owned accounts for the given
+// transactions, in the order of the transactions. Within a transaction,
+// the fee comes first, followed by native, internal and token transfers in
+// the order of execution.
+//
+// Value transfers of failed transactions are not included, but the fee is.
+func (l *Ledger) Collect(ctx context.Context, hashes []types.Hash) ([]Entry, error) {
+	var (
+		entries []Entry
+		times   = make(map[uint64]time.Time)
+	)
+	for _, hash := range hashes {
+		tx, err := l.opts.Client.GetTransactionByHash(ctx, hash)
+		if err != nil {
+			return nil, fmt.Errorf("ledger: failed to fetch transaction %s: %w", hash, err)
+		}
+		receipt, err := l.opts.Client.GetTransactionReceipt(ctx, hash)
+		if err != nil {
+			return nil, fmt.Errorf("ledger: failed to fetch receipt of %s: %w", hash, err)
+		}
+		if receipt.BlockNumber == nil {
+			return nil, fmt.Errorf("ledger: transaction %s is not included in a block", hash)
+		}
+		number := receipt.BlockNumber.Uint64()
+		ts, ok := times[number]
+		if !ok {
+			block, err := l.opts.Client.BlockByNumber(ctx, types.BlockNumberFromUint64(number), false)
+			if err != nil {
+				return nil, fmt.Errorf("ledger: failed to fetch block %d: %w", number, err)
+			}
+			ts = block.Timestamp
+			times[number] = ts
+		}
+		base := Entry{Block: number, Time: ts, Transaction: hash}
+		txEntries, err := l.transactionEntries(ctx, base, tx, receipt)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, txEntries...)
+	}
+	return entries, nil
+}
+
+// transactionEntries returns the entries of a single transaction.
+func (l *Ledger) transactionEntries(ctx context.Context, base Entry, tx *types.OnChainTransaction, receipt *types.TransactionReceipt) ([]Entry, error) {
+	var entries []Entry
+	if l.owned(&receipt.From) && receipt.EffectiveGasPrice != nil {
+		fee := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+		if fee.Sign() > 0 {
+			e := base
+			e.Account = receipt.From
+			e.Kind = FeeKind
+			e.From = receipt.From
+			e.Amount = fee
+			entries = append(entries, e)
+		}
+	}
+	if receipt.Status != nil && *receipt.Status == 0 {
+		return entries, nil
+	}
+	if tx.Value != nil && tx.Value.Sign() > 0 {
+		to := receipt.To
+		if receipt.ContractAddress != nil {
+			to = *receipt.ContractAddress
+		}
+		entries = l.appendTransfer(entries, base, NativeTransferKind, nil, receipt.From, to, tx.Value)
+	}
+	if l.opts.Tracer != nil {
+		frame, err := l.opts.Tracer.TraceCalls(ctx, base.Transaction)
+		switch {
+		case err == nil:
+			frame.Walk(func(f *rpc.CallFrame, depth int) bool {
+				// Reverted calls do not transfer value, including their
+				// subcalls.
+				if f.Error != "" {
+					return false
+				}
+				// The top-level call is the transaction itself.
+				if depth > 0 && f.To != nil && f.Value != nil && f.Value.Sign() > 0 {
+					entries = l.appendTransfer(entries, base, InternalTransferKind, nil, f.From, *f.To, f.Value)
+				}
+				return true
+			})
+		case !tracingUnsupported(err):
+			return nil, fmt.Errorf("ledger: %w", err)
+		}
+	}
+	for _, log := range receipt.Logs {
+		if len(log.Topics) != 3 || log.Topics[0] != transferEvent.Topic0() {
+			continue
+		}
+		var (
+			from, to types.Address
+			amount   *big.Int
+		)
+		if err := transferEvent.DecodeValues(log.Topics, log.Data, &from, &to, &amount); err != nil {
+			continue
+		}
+		token := log.Address
+		entries = l.appendTransfer(entries, base, TokenTransferKind, &token, from, to, amount)
+	}
+	return entries, nil
+}
+
+// appendTransfer appends the entries of a transfer for each owned party.
+func (l *Ledger) appendTransfer(entries []Entry, base Entry, kind Kind, token *types.Address, from, to types.Address, amount *big.Int) []Entry {
+	if amount == nil || amount.Sign() <= 0 {
+		return entries
+	}
+	base.Kind = kind
+	base.Token = token
+	base.From = from
+	base.To = to
+	if l.owned(&from) {
+		e := base
+		e.Account = from
+		e.Amount = new(big.Int).Set(amount)
+		entries = append(entries, e)
+	}
+	if l.owned(&to) {
+		e := base
+		e.Account = to
+		e.Amount = new(big.Int).Set(amount)
+		e.Incoming = true
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func (l *Ledger) owned(addr *types.Address) bool {
+	if addr == nil {
+		return false
+	}
+	_, ok := l.accounts[*addr]
+	return ok
+}
+
+// tracingUnsupported returns true if the error indicates that the node
+// does not support the debug namespace.
+func tracingUnsupported(err error) bool {
+	var rpcErr transport.RPCErrorCode
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	switch rpcErr.RPCErrorCode() {
+	case transport.ErrCodeMethodNotFound, transport.NethermindErrCodeMethodNotSupported:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,230 @@
+package ledger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/rpc/transport"
+	"github.com/defiweb/go-eth/types"
+)
+
+var (
+	accountA = types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+	accountB = types.MustAddressFromHex("0x2222222222222222222222222222222222222222")
+	contract = types.MustAddressFromHex("0x3333333333333333333333333333333333333333")
+	token    = types.MustAddressFromHex("0x4444444444444444444444444444444444444444")
+
+	hash1 = types.Hash{1}
+	hash2 = types.Hash{2}
+	hash3 = types.Hash{3}
+)
+
+type fakeRPC struct {
+	rpc.Client
+
+	blocks   map[uint64]*types.Block
+	txs      map[types.Hash]*types.OnChainTransaction
+	receipts map[types.Hash]*types.TransactionReceipt
+	logs     []types.Log
+}
+
+func (f *fakeRPC) BlockByNumber(_ context.Context, number types.BlockNumber, _ bool) (*types.Block, error) {
+	b, ok := f.blocks[number.Big().Uint64()]
+	if !ok {
+		return nil, fmt.Errorf("block not found")
+	}
+	return b, nil
+}
+
+func (f *fakeRPC) GetTransactionByHash(_ context.Context, hash types.Hash) (*types.OnChainTransaction, error) {
+	return f.txs[hash], nil
+}
+
+func (f *fakeRPC) GetTransactionReceipt(_ context.Context, hash types.Hash) (*types.TransactionReceipt, error) {
+	return f.receipts[hash], nil
+}
+
+func (f *fakeRPC) GetLogs(_ context.Context, query *types.FilterLogsQuery) ([]types.Log, error) {
+	var res []types.Log
+	for _, log := range f.logs {
+		if match(log.Topics, query.Topics) {
+			res = append(res, log)
+		}
+	}
+	return res, nil
+}
+
+func match(topics []types.Hash, filter [][]types.Hash) bool {
+	for i, f := range filter {
+		if len(f) == 0 {
+			continue
+		}
+		if i >= len(topics) {
+			return false
+		}
+		found := false
+		for _, h := range f {
+			if h == topics[i] {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+type fakeTracer struct {
+	frames map[types.Hash]*rpc.CallFrame
+	err    error
+}
+
+func (f *fakeTracer) TraceCalls(_ context.Context, hash types.Hash) (*rpc.CallFrame, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	if frame, ok := f.frames[hash]; ok {
+		return frame, nil
+	}
+	return &rpc.CallFrame{}, nil
+}
+
+func transferLog(from, to types.Address, amount int64, block, index uint64, hash types.Hash) types.Log {
+	return types.Log{
+		Address: token,
+		Topics: []types.Hash{
+			transferEvent.Topic0(),
+			types.MustHashFromBytes(from.Bytes(), types.PadLeft),
+			types.MustHashFromBytes(to.Bytes(), types.PadLeft),
+		},
+		Data:             abi.MustEncodeValue(abi.MustParseType("uint256"), big.NewInt(amount)),
+		BlockNumber:      new(big.Int).SetUint64(block),
+		TransactionHash:  &hash,
+		TransactionIndex: &index,
+	}
+}
+
+func status(s uint64) *uint64 {
+	return &s
+}
+
+func testChain() *fakeRPC {
+	tokenLog := transferLog(accountB, accountA, 100, 2, 0, hash2)
+	return &fakeRPC{
+		blocks: map[uint64]*types.Block{
+			1: {
+				Number:    big.NewInt(1),
+				Timestamp: time.Unix(1700000000, 0),
+				Transactions: []types.OnChainTransaction{
+					{Transaction: types.Transaction{Call: types.Call{From: &accountB, To: &contract}}, Hash: &types.Hash{9}},
+					{Transaction: types.Transaction{Call: types.Call{From: &accountA, To: &accountB}}, Hash: &hash1},
+				},
+			},
+			2: {
+				Number:    big.NewInt(2),
+				Timestamp: time.Unix(1700000012, 0),
+				Transactions: []types.OnChainTransaction{
+					{Transaction: types.Transaction{Call: types.Call{From: &accountB, To: &contract}}, Hash: &hash2},
+					{Transaction: types.Transaction{Call: types.Call{From: &accountA, To: &contract}}, Hash: &hash3},
+				},
+			},
+		},
+		txs: map[types.Hash]*types.OnChainTransaction{
+			hash1: {Transaction: types.Transaction{Call: types.Call{From: &accountA, To: &accountB, Value: big.NewInt(1000)}}},
+			hash2: {Transaction: types.Transaction{Call: types.Call{From: &accountB, To: &contract, Value: big.NewInt(0)}}},
+			hash3: {Transaction: types.Transaction{Call: types.Call{From: &accountA, To: &contract, Value: big.NewInt(7)}}},
+		},
+		receipts: map[types.Hash]*types.TransactionReceipt{
+			hash1: {BlockNumber: big.NewInt(1), From: accountA, To: accountB, GasUsed: 21000, EffectiveGasPrice: big.NewInt(10), Status: status(1)},
+			hash2: {BlockNumber: big.NewInt(2), From: accountB, To: contract, GasUsed: 50000, EffectiveGasPrice: big.NewInt(10), Status: status(1), Logs: []types.Log{tokenLog}},
+			hash3: {BlockNumber: big.NewInt(2), From: accountA, To: contract, GasUsed: 30000, EffectiveGasPrice: big.NewInt(10), Status: status(0)},
+		},
+		logs: []types.Log{tokenLog},
+	}
+}
+
+func TestLedger_Scan(t *testing.T) {
+	l, err := New(Options{Client: testChain(), Accounts: []types.Address{accountA}})
+	require.NoError(t, err)
+
+	hashes, err := l.Scan(context.Background(), 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []types.Hash{hash1, hash2, hash3}, hashes)
+}
+
+func TestLedger_Collect(t *testing.T) {
+	tracer := &fakeTracer{frames: map[types.Hash]*rpc.CallFrame{
+		hash2: {
+			Type: "CALL", From: accountB, To: &contract, Value: big.NewInt(0),
+			Calls: []rpc.CallFrame{
+				{Type: "CALL", From: contract, To: &accountA, Value: big.NewInt(5)},
+				// Reverted calls do not transfer value.
+				{Type: "CALL", From: contract, To: &accountA, Value: big.NewInt(6), Error: "execution reverted"},
+			},
+		},
+	}}
+	l, err := New(Options{Client: testChain(), Accounts: []types.Address{accountA}, Tracer: tracer})
+	require.NoError(t, err)
+
+	entries, err := l.Collect(context.Background(), []types.Hash{hash1, hash2, hash3})
+	require.NoError(t, err)
+	t1, t2 := time.Unix(1700000000, 0), time.Unix(1700000012, 0)
+	assert.Equal(t, []Entry{
+		{Account: accountA, Kind: FeeKind, Block: 1, Time: t1, Transaction: hash1, From: accountA, Amount: big.NewInt(210000)},
+		{Account: accountA, Kind: NativeTransferKind, Block: 1, Time: t1, Transaction: hash1, From: accountA, To: accountB, Amount: big.NewInt(1000)},
+		{Account: accountA, Kind: InternalTransferKind, Block: 2, Time: t2, Transaction: hash2, From: contract, To: accountA, Amount: big.NewInt(5), Incoming: true},
+		{Account: accountA, Kind: TokenTransferKind, Block: 2, Time: t2, Transaction: hash2, Token: &token, From: accountB, To: accountA, Amount: big.NewInt(100), Incoming: true},
+		// The failed transaction only pays the fee.
+		{Account: accountA, Kind: FeeKind, Block: 2, Time: t2, Transaction: hash3, From: accountA, Amount: big.NewInt(300000)},
+	}, entries)
+	assert.Equal(t, big.NewInt(-1000), entries[1].Delta())
+	assert.Equal(t, big.NewInt(5), entries[2].Delta())
+
+	// Nodes without tracing support.
+	tracer.err = transport.NewRPCError(transport.ErrCodeMethodNotFound, "method not found", nil)
+	entries, err = l.Collect(context.Background(), []types.Hash{hash2})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, TokenTransferKind, entries[0].Kind)
+
+	tracer.err = fmt.Errorf("connection refused")
+	_, err = l.Collect(context.Background(), []types.Hash{hash2})
+	assert.Error(t, err)
+}
+
+func TestWriteCSV(t *testing.T) {
+	entries := []Entry{
+		{Account: accountA, Kind: FeeKind, Block: 1, Time: time.Unix(1700000000, 0), Transaction: hash1, From: accountA, Amount: big.NewInt(210000)},
+		{Account: accountA, Kind: TokenTransferKind, Block: 2, Time: time.Unix(1700000012, 0), Transaction: hash2, Token: &token, From: accountB, To: accountA, Amount: big.NewInt(100), Incoming: true},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, WriteCSV(&buf, entries))
+	assert.Equal(t, ""+
+		"block,time,transaction,account,kind,direction,token,from,to,amount\n"+
+		"1,2023-11-14T22:13:20Z,"+hash1.String()+","+accountA.String()+",fee,out,,"+accountA.String()+","+types.ZeroAddress.String()+",210000\n"+
+		"2,2023-11-14T22:13:32Z,"+hash2.String()+","+accountA.String()+",token,in,"+token.String()+","+accountB.String()+","+accountA.String()+",100\n",
+		buf.String(),
+	)
+
+	buf.Reset()
+	require.NoError(t, WriteJSON(&buf, entries))
+	var decoded []map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Len(t, decoded, 2)
+	assert.Equal(t, "fee", decoded[0]["kind"])
+	assert.NotContains(t, decoded[0], "token")
+	assert.Equal(t, "100", decoded[1]["amount"])
+	assert.Equal(t, "in", decoded[1]["direction"])
+	assert.Equal(t, token.String(), decoded[1]["token"])
+}
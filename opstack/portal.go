@@ -0,0 +1,267 @@
+package opstack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/types"
+)
+
+// ErrNotReadyToProve is returned by Portal.ProveWithdrawalTransaction when
+// the withdrawal is not yet included in the latest dispute game.
+var ErrNotReadyToProve = errors.New("opstack: withdrawal is not included in the latest dispute game yet")
+
+var (
+	proveWithdrawalMethod      = abi.MustParseMethod("proveWithdrawalTransaction((uint256 nonce, address sender, address target, uint256 value, uint256 gasLimit, bytes data) tx, uint256 disputeGameIndex, (bytes32 version, bytes32 stateRoot, bytes32 messagePasserStorageRoot, bytes32 latestBlockhash) outputRootProof, bytes[] withdrawalProof)")
+	finalizeWithdrawalMethod   = abi.MustParseMethod("finalizeWithdrawalTransaction((uint256 nonce, address sender, address target, uint256 value, uint256 gasLimit, bytes data) tx)")
+	finalizedWithdrawalsMethod = abi.MustParseMethod("finalizedWithdrawals(bytes32 withdrawalHash) view returns (bool)")
+	provenWithdrawalsMethod    = abi.MustParseMethod("provenWithdrawals(bytes32 withdrawalHash, address proofSubmitter) view returns (address disputeGameProxy, uint64 timestamp)")
+	proofMaturityDelayMethod   = abi.MustParseMethod("proofMaturityDelaySeconds() view returns (uint256)")
+	disputeGameFactoryMethod   = abi.MustParseMethod("disputeGameFactory() view returns (address)")
+	respectedGameTypeMethod    = abi.MustParseMethod("respectedGameType() view returns (uint32)")
+	gameCountMethod            = abi.MustParseMethod("gameCount() view returns (uint256)")
+	findLatestGamesMethod      = abi.MustParseMethod("findLatestGames(uint32 gameType, uint256 start, uint256 n) view returns ((uint256 index, bytes32 metadata, uint64 timestamp, bytes32 rootClaim, bytes extraData)[] games)")
+)
+
+// Status is the status of a withdrawal on L1.
+type Status uint8
+
+const (
+	NotProvenStatus Status = iota // NotProvenStatus means the withdrawal was not proven yet.
+	ProvenStatus                  // ProvenStatus means the withdrawal was proven and waits for finalization.
+	FinalizedStatus               // FinalizedStatus means the withdrawal was finalized.
+)
+
+// String implements the fmt.Stringer interface.
+func (s Status) String() string {
+	switch s {
+	case NotProvenStatus:
+		return "not-proven"
+	case ProvenStatus:
+		return "proven"
+	case FinalizedStatus:
+		return "finalized"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(s))
+	}
+}
+
+// WithdrawalStatus is the result of Portal.WithdrawalStatus.
+type WithdrawalStatus struct {
+	Status Status // Status is the status of the withdrawal.
+
+	// The fields below are set only for proven withdrawals.
+	DisputeGame types.Address // DisputeGame is the dispute game against which the withdrawal was proven.
+	ProvenAt    time.Time     // ProvenAt is the time at which the withdrawal was proven.
+
+	// FinalizableAt is the earliest time at which the withdrawal can be
+	// finalized. In addition, the dispute game must be resolved in favor
+	// of the proposed output root.
+	FinalizableAt time.Time
+}
+
+// DisputeGame is a dispute game that proposes an L2 output root.
+type DisputeGame struct {
+	Index     uint64        // Index is the index of the game in the DisputeGameFactory.
+	Proxy     types.Address // Proxy is the address of the game contract.
+	L2Block   uint64        // L2Block is the number of the L2 block of the proposed output root.
+	RootClaim types.Hash    // RootClaim is the proposed output root.
+	CreatedAt time.Time     // CreatedAt is the time at which the game was created.
+}
+
+// Portal is a client for the OptimismPortal contract on L1.
+type Portal struct {
+	opts PortalOptions
+}
+
+// PortalOptions is the options for NewPortal.
+type PortalOptions struct {
+	// Client is the RPC client connected to L1.
+	Client rpc.RPC
+
+	// Address is the address of the OptimismPortal proxy.
+	Address types.Address
+}
+
+// NewPortal returns a new Portal.
+func NewPortal(opts PortalOptions) (*Portal, error) {
+	if opts.Client == nil {
+		return nil, errors.New("opstack: client is required")
+	}
+	if opts.Address == types.ZeroAddress {
+		return nil, errors.New("opstack: portal address is required")
+	}
+	return &Portal{opts: opts}, nil
+}
+
+// Address returns the address of the OptimismPortal.
+func (p *Portal) Address() types.Address {
+	return p.opts.Address
+}
+
+// LatestGame returns the latest dispute game of the type respected by the
+// portal.
+func (p *Portal) LatestGame(ctx context.Context) (*DisputeGame, error) {
+	var factory types.Address
+	if err := p.call(ctx, p.opts.Address, disputeGameFactoryMethod, nil, &factory); err != nil {
+		return nil, err
+	}
+	var gameType uint32
+	if err := p.call(ctx, p.opts.Address, respectedGameTypeMethod, nil, &gameType); err != nil {
+		return nil, err
+	}
+	var count *big.Int
+	if err := p.call(ctx, factory, gameCountMethod, nil, &count); err != nil {
+		return nil, err
+	}
+	if count.Sign() == 0 {
+		return nil, errors.New("opstack: no dispute games found")
+	}
+	var games []struct {
+		Index     *big.Int   `abi:"index"`
+		Metadata  types.Hash `abi:"metadata"`
+		Timestamp uint64     `abi:"timestamp"`
+		RootClaim types.Hash `abi:"rootClaim"`
+		ExtraData []byte     `abi:"extraData"`
+	}
+	args := []any{gameType, new(big.Int).Sub(count, big.NewInt(1)), big.NewInt(1)}
+	if err := p.call(ctx, factory, findLatestGamesMethod, args, &games); err != nil {
+		return nil, err
+	}
+	if len(games) == 0 {
+		return nil, fmt.Errorf("opstack: no dispute games of type %d found", gameType)
+	}
+	g := games[0]
+	// For output root games, the extra data is the L2 block number.
+	if len(g.ExtraData) < 32 {
+		return nil, fmt.Errorf("opstack: unexpected extra data of dispute game %s", g.Index)
+	}
+	l2Block := new(big.Int).SetBytes(g.ExtraData[:32])
+	if !l2Block.IsUint64() {
+		return nil, fmt.Errorf("opstack: invalid L2 block number of dispute game %s", g.Index)
+	}
+	// The metadata packs the game type, the creation time and the address
+	// of the game, the address is stored in the lowest 20 bytes.
+	return &DisputeGame{
+		Index:     g.Index.Uint64(),
+		Proxy:     types.MustAddressFromBytes(g.Metadata[types.HashLength-types.AddressLength:]),
+		L2Block:   l2Block.Uint64(),
+		RootClaim: g.RootClaim,
+		CreatedAt: time.Unix(int64(g.Timestamp), 0),
+	}, nil
+}
+
+// ProveWithdrawalTransaction returns the transaction that proves the
+// withdrawal against the latest dispute game. The l2 client must be
+// connected to a node of the L2 chain that supports eth_getProof.
+//
+// If the withdrawal is not yet included in the L2 block of the latest
+// dispute game, ErrNotReadyToProve is returned.
+func (p *Portal) ProveWithdrawalTransaction(ctx context.Context, l2 rpc.RPC, w *Withdrawal) (*types.Transaction, error) {
+	hash, err := w.Hash()
+	if err != nil {
+		return nil, err
+	}
+	game, err := p.LatestGame(ctx)
+	if err != nil {
+		return nil, err
+	}
+	block := types.BlockNumberFromUint64(game.L2Block)
+	header, err := l2.BlockByNumber(ctx, block, false)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := l2.GetProof(ctx, L2ToL1MessagePasserAddress, []types.Hash{StorageSlot(hash)}, block)
+	if err != nil {
+		return nil, err
+	}
+	if len(proof.StorageProof) != 1 {
+		return nil, fmt.Errorf("opstack: expected one storage proof, got %d", len(proof.StorageProof))
+	}
+	if proof.StorageProof[0].Value == nil || proof.StorageProof[0].Value.Sign() == 0 {
+		return nil, ErrNotReadyToProve
+	}
+	outputRootProof := OutputRootProof{
+		StateRoot:                header.StateRoot,
+		MessagePasserStorageRoot: proof.StorageHash,
+		LatestBlockhash:          header.Hash,
+	}
+	if root := outputRootProof.Hash(); root != game.RootClaim {
+		return nil, fmt.Errorf("opstack: output root %s of L2 block %d does not match root claim %s of dispute game %d", root, game.L2Block, game.RootClaim, game.Index)
+	}
+	input, err := proveWithdrawalMethod.EncodeArgs(w, new(big.Int).SetUint64(game.Index), outputRootProof, proof.StorageProof[0].Proof)
+	if err != nil {
+		return nil, fmt.Errorf("opstack: failed to encode proveWithdrawalTransaction call: %w", err)
+	}
+	return types.NewTransaction().
+		SetTo(p.opts.Address).
+		SetInput(input), nil
+}
+
+// FinalizeWithdrawalTransaction returns the transaction that finalizes the
+// proven withdrawal.
+func (p *Portal) FinalizeWithdrawalTransaction(w *Withdrawal) (*types.Transaction, error) {
+	input, err := finalizeWithdrawalMethod.EncodeArgs(w)
+	if err != nil {
+		return nil, fmt.Errorf("opstack: failed to encode finalizeWithdrawalTransaction call: %w", err)
+	}
+	return types.NewTransaction().
+		SetTo(p.opts.Address).
+		SetInput(input), nil
+}
+
+// WithdrawalStatus returns the status of the withdrawal with the given hash.
+// The portal stores proofs per submitter, so the address that submitted the
+// proof must be provided.
+func (p *Portal) WithdrawalStatus(ctx context.Context, withdrawalHash types.Hash, prover types.Address) (*WithdrawalStatus, error) {
+	var finalized bool
+	if err := p.call(ctx, p.opts.Address, finalizedWithdrawalsMethod, []any{withdrawalHash}, &finalized); err != nil {
+		return nil, err
+	}
+	if finalized {
+		return &WithdrawalStatus{Status: FinalizedStatus}, nil
+	}
+	var (
+		game      types.Address
+		timestamp uint64
+	)
+	if err := p.call(ctx, p.opts.Address, provenWithdrawalsMethod, []any{withdrawalHash, prover}, &game, &timestamp); err != nil {
+		return nil, err
+	}
+	if timestamp == 0 {
+		return &WithdrawalStatus{Status: NotProvenStatus}, nil
+	}
+	var delay *big.Int
+	if err := p.call(ctx, p.opts.Address, proofMaturityDelayMethod, nil, &delay); err != nil {
+		return nil, err
+	}
+	provenAt := time.Unix(int64(timestamp), 0)
+	return &WithdrawalStatus{
+		Status:        ProvenStatus,
+		DisputeGame:   game,
+		ProvenAt:      provenAt,
+		FinalizableAt: provenAt.Add(time.Duration(delay.Int64()) * time.Second),
+	}, nil
+}
+
+// call calls the view method of the contract at the latest block and
+// decodes the result into vals.
+func (p *Portal) call(ctx context.Context, to types.Address, method *abi.Method, args []any, vals ...any) error {
+	input, err := method.EncodeArgs(args...)
+	if err != nil {
+		return fmt.Errorf("opstack: failed to encode %s call: %w", method.Name(), err)
+	}
+	data, _, err := p.opts.Client.Call(ctx, types.NewCall().SetTo(to).SetInput(input), types.LatestBlockNumber)
+	if err != nil {
+		return fmt.Errorf("opstack: %s call failed: %w", method.Name(), err)
+	}
+	if err := method.DecodeValues(data, vals...); err != nil {
+		return fmt.Errorf("opstack: failed to decode %s result: %w", method.Name(), err)
+	}
+	return nil
+}
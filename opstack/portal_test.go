@@ -0,0 +1,164 @@
+package opstack
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/types"
+)
+
+var (
+	testPortal  = types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+	testFactory = types.MustAddressFromHex("0x2222222222222222222222222222222222222222")
+	testGame    = types.MustAddressFromHex("0x3333333333333333333333333333333333333333")
+)
+
+// fakeRPC returns results of contract calls from the results map, keyed by
+// the method selector.
+type fakeRPC struct {
+	rpc.Client
+
+	results map[abi.FourBytes][]byte
+	header  *types.Block
+	proof   *types.AccountProof
+}
+
+func (f *fakeRPC) Call(_ context.Context, call *types.Call, _ types.BlockNumber) ([]byte, *types.Call, error) {
+	var sel abi.FourBytes
+	copy(sel[:], call.Input)
+	res, ok := f.results[sel]
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected call 0x%x", call.Input)
+	}
+	return res, call, nil
+}
+
+func (f *fakeRPC) BlockByNumber(context.Context, types.BlockNumber, bool) (*types.Block, error) {
+	return f.header, nil
+}
+
+func (f *fakeRPC) GetProof(context.Context, types.Address, []types.Hash, types.BlockNumber) (*types.AccountProof, error) {
+	return f.proof, nil
+}
+
+func (f *fakeRPC) setResult(m *abi.Method, vals ...any) {
+	if f.results == nil {
+		f.results = make(map[abi.FourBytes][]byte)
+	}
+	f.results[m.FourBytes()] = abi.MustEncodeValues(m.Outputs(), vals...)
+}
+
+func TestPortal_ProveWithdrawalTransaction(t *testing.T) {
+	w := testWithdrawal()
+	l2 := &fakeRPC{
+		header: &types.Block{Hash: types.Hash{1}, StateRoot: types.Hash{2}},
+		proof: &types.AccountProof{
+			StorageHash:  types.Hash{3},
+			StorageProof: []types.StorageProof{{Value: big.NewInt(1), Proof: [][]byte{{0xaa}, {0xbb}}}},
+		},
+	}
+	outputRootProof := OutputRootProof{StateRoot: types.Hash{2}, MessagePasserStorageRoot: types.Hash{3}, LatestBlockhash: types.Hash{1}}
+
+	type game struct {
+		Index     *big.Int   `abi:"index"`
+		Metadata  types.Hash `abi:"metadata"`
+		Timestamp uint64     `abi:"timestamp"`
+		RootClaim types.Hash `abi:"rootClaim"`
+		ExtraData []byte     `abi:"extraData"`
+	}
+	l1 := &fakeRPC{}
+	l1.setResult(disputeGameFactoryMethod, testFactory)
+	l1.setResult(respectedGameTypeMethod, uint32(0))
+	l1.setResult(gameCountMethod, big.NewInt(8))
+	l1.setResult(findLatestGamesMethod, []game{{
+		Index:     big.NewInt(7),
+		Metadata:  types.MustHashFromBytes(testGame.Bytes(), types.PadLeft),
+		Timestamp: 1700000000,
+		RootClaim: outputRootProof.Hash(),
+		ExtraData: types.MustHashFromBigInt(big.NewInt(100)).Bytes(),
+	}})
+	p, err := NewPortal(PortalOptions{Client: l1, Address: testPortal})
+	require.NoError(t, err)
+
+	g, err := p.LatestGame(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), g.Index)
+	assert.Equal(t, testGame, g.Proxy)
+	assert.Equal(t, uint64(100), g.L2Block)
+
+	tx, err := p.ProveWithdrawalTransaction(context.Background(), l2, w)
+	require.NoError(t, err)
+	assert.Equal(t, testPortal, *tx.To)
+	var (
+		decoded   Withdrawal
+		gameIndex *big.Int
+		proof     OutputRootProof
+		storage   [][]byte
+	)
+	require.NoError(t, proveWithdrawalMethod.DecodeArgs(tx.Input, &decoded, &gameIndex, &proof, &storage))
+	assert.Equal(t, *w, decoded)
+	assert.Equal(t, big.NewInt(7), gameIndex)
+	assert.Equal(t, outputRootProof, proof)
+	assert.Equal(t, [][]byte{{0xaa}, {0xbb}}, storage)
+
+	// Output root does not match the root claim.
+	l2.header.StateRoot = types.Hash{4}
+	_, err = p.ProveWithdrawalTransaction(context.Background(), l2, w)
+	assert.Error(t, err)
+
+	// Withdrawal is not in the message passer at the block of the game.
+	l2.proof.StorageProof[0].Value = big.NewInt(0)
+	_, err = p.ProveWithdrawalTransaction(context.Background(), l2, w)
+	assert.ErrorIs(t, err, ErrNotReadyToProve)
+}
+
+func TestPortal_FinalizeWithdrawalTransaction(t *testing.T) {
+	w := testWithdrawal()
+	p, err := NewPortal(PortalOptions{Client: &fakeRPC{}, Address: testPortal})
+	require.NoError(t, err)
+
+	tx, err := p.FinalizeWithdrawalTransaction(w)
+	require.NoError(t, err)
+	assert.Equal(t, testPortal, *tx.To)
+	var decoded Withdrawal
+	require.NoError(t, finalizeWithdrawalMethod.DecodeArgs(tx.Input, &decoded))
+	assert.Equal(t, *w, decoded)
+}
+
+func TestPortal_WithdrawalStatus(t *testing.T) {
+	var (
+		hash   = types.Hash{1}
+		prover = types.MustAddressFromHex("0x4444444444444444444444444444444444444444")
+	)
+	l1 := &fakeRPC{}
+	p, err := NewPortal(PortalOptions{Client: l1, Address: testPortal})
+	require.NoError(t, err)
+
+	l1.setResult(finalizedWithdrawalsMethod, false)
+	l1.setResult(provenWithdrawalsMethod, types.ZeroAddress, uint64(0))
+	s, err := p.WithdrawalStatus(context.Background(), hash, prover)
+	require.NoError(t, err)
+	assert.Equal(t, NotProvenStatus, s.Status)
+
+	l1.setResult(provenWithdrawalsMethod, testGame, uint64(1700000000))
+	l1.setResult(proofMaturityDelayMethod, big.NewInt(3600))
+	s, err = p.WithdrawalStatus(context.Background(), hash, prover)
+	require.NoError(t, err)
+	assert.Equal(t, ProvenStatus, s.Status)
+	assert.Equal(t, testGame, s.DisputeGame)
+	assert.Equal(t, time.Unix(1700000000, 0), s.ProvenAt)
+	assert.Equal(t, time.Unix(1700003600, 0), s.FinalizableAt)
+
+	l1.setResult(finalizedWithdrawalsMethod, true)
+	s, err = p.WithdrawalStatus(context.Background(), hash, prover)
+	require.NoError(t, err)
+	assert.Equal(t, FinalizedStatus, s.Status)
+}
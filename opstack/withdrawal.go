@@ -0,0 +1,102 @@
+// Package opstack provides helpers for withdrawals from OP Stack L2 chains
+// to L1: computing withdrawal hashes, building the transactions that prove
+// and finalize withdrawals on the OptimismPortal contract, and querying the
+// status of withdrawals.
+//
+// The package supports the OptimismPortal with fault proofs, i.e. portals
+// that use dispute games instead of the L2OutputOracle.
+package opstack
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/types"
+)
+
+// L2ToL1MessagePasserAddress is the address of the L2ToL1MessagePasser
+// predeploy, which stores the hashes of initiated withdrawals.
+var L2ToL1MessagePasserAddress = types.MustAddressFromHex("0x4200000000000000000000000000000000000016")
+
+var (
+	messagePassedEvent = abi.MustParseEvent("MessagePassed(uint256 indexed nonce, address indexed sender, address indexed target, uint256 value, uint256 gasLimit, bytes data, bytes32 withdrawalHash)")
+	withdrawalType     = abi.MustParseType("(uint256,address,address,uint256,uint256,bytes)")
+	storageSlotType    = abi.MustParseType("(bytes32,uint256)")
+)
+
+// Withdrawal is a withdrawal transaction initiated on L2, as defined by the
+// Types.WithdrawalTransaction struct of the OptimismPortal.
+type Withdrawal struct {
+	Nonce    *big.Int      `abi:"nonce"`    // Nonce is the nonce assigned by the L2ToL1MessagePasser.
+	Sender   types.Address `abi:"sender"`   // Sender is the address that initiated the withdrawal on L2.
+	Target   types.Address `abi:"target"`   // Target is the address called on L1.
+	Value    *big.Int      `abi:"value"`    // Value is the amount of ether sent to the target.
+	GasLimit *big.Int      `abi:"gasLimit"` // GasLimit is the minimum gas provided for the call on L1.
+	Data     []byte        `abi:"data"`     // Data is the call data sent to the target.
+}
+
+// Hash returns the withdrawal hash, which identifies the withdrawal on both
+// L1 and L2.
+func (w *Withdrawal) Hash() (types.Hash, error) {
+	enc, err := abi.EncodeValues(withdrawalType, w.Nonce, w.Sender, w.Target, w.Value, w.GasLimit, w.Data)
+	if err != nil {
+		return types.Hash{}, fmt.Errorf("opstack: failed to encode withdrawal: %w", err)
+	}
+	return crypto.Keccak256(enc), nil
+}
+
+// StorageSlot returns the slot of the sentMessages mapping of the
+// L2ToL1MessagePasser in which the withdrawal with the given hash is stored.
+// The proof of this slot is required to prove the withdrawal on L1.
+func StorageSlot(withdrawalHash types.Hash) types.Hash {
+	// The sentMessages mapping is stored in the slot 0.
+	return crypto.Keccak256(abi.MustEncodeValues(storageSlotType, withdrawalHash, new(big.Int)))
+}
+
+// WithdrawalsFromReceipt returns withdrawals initiated in the L2 transaction
+// with the given receipt.
+func WithdrawalsFromReceipt(receipt *types.TransactionReceipt) ([]Withdrawal, error) {
+	var ws []Withdrawal
+	for _, l := range receipt.Logs {
+		if l.Address != L2ToL1MessagePasserAddress || len(l.Topics) == 0 || l.Topics[0] != messagePassedEvent.Topic0() {
+			continue
+		}
+		var (
+			w    Withdrawal
+			hash types.Hash
+		)
+		if err := messagePassedEvent.DecodeValues(l.Topics, l.Data, &w.Nonce, &w.Sender, &w.Target, &w.Value, &w.GasLimit, &w.Data, &hash); err != nil {
+			return nil, fmt.Errorf("opstack: failed to decode MessagePassed event: %w", err)
+		}
+		computed, err := w.Hash()
+		if err != nil {
+			return nil, err
+		}
+		if computed != hash {
+			return nil, fmt.Errorf("opstack: withdrawal hash mismatch, event has %s, computed %s", hash, computed)
+		}
+		ws = append(ws, w)
+	}
+	return ws, nil
+}
+
+// OutputRootProof is the preimage of an L2 output root, as defined by the
+// Types.OutputRootProof struct of the OptimismPortal.
+type OutputRootProof struct {
+	Version                  types.Hash `abi:"version"`                  // Version is the output root version, currently zero.
+	StateRoot                types.Hash `abi:"stateRoot"`                // StateRoot is the state root of the L2 block.
+	MessagePasserStorageRoot types.Hash `abi:"messagePasserStorageRoot"` // MessagePasserStorageRoot is the storage root of the L2ToL1MessagePasser.
+	LatestBlockhash          types.Hash `abi:"latestBlockhash"`          // LatestBlockhash is the hash of the L2 block.
+}
+
+// Hash returns the output root.
+func (p *OutputRootProof) Hash() types.Hash {
+	return crypto.Keccak256(
+		p.Version.Bytes(),
+		p.StateRoot.Bytes(),
+		p.MessagePasserStorageRoot.Bytes(),
+		p.LatestBlockhash.Bytes(),
+	)
+}
@@ -0,0 +1,99 @@
+package opstack
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/types"
+)
+
+func testWithdrawal() *Withdrawal {
+	return &Withdrawal{
+		Nonce:    new(big.Int).Lsh(big.NewInt(1), 240), // Version 1 nonce.
+		Sender:   types.MustAddressFromHex("0x4200000000000000000000000000000000000007"),
+		Target:   types.MustAddressFromHex("0x25ace71c97b33cc4729cf772ae268934f7ab5fa1"),
+		Value:    big.NewInt(1000),
+		GasLimit: big.NewInt(200000),
+		Data:     []byte{1, 2, 3},
+	}
+}
+
+func messagePassedLog(w *Withdrawal, hash types.Hash) types.Log {
+	return types.Log{
+		Address: L2ToL1MessagePasserAddress,
+		Topics: []types.Hash{
+			messagePassedEvent.Topic0(),
+			types.MustHashFromBigInt(w.Nonce),
+			types.MustHashFromBytes(w.Sender.Bytes(), types.PadLeft),
+			types.MustHashFromBytes(w.Target.Bytes(), types.PadLeft),
+		},
+		Data: abi.MustEncodeValues(abi.MustParseType("(uint256,uint256,bytes,bytes32)"), w.Value, w.GasLimit, w.Data, hash),
+	}
+}
+
+func TestWithdrawal_Hash(t *testing.T) {
+	w := testWithdrawal()
+	hash, err := w.Hash()
+	require.NoError(t, err)
+
+	// abi.encode of the fields, with the data at the offset of 6 words.
+	enc := make([]byte, 0, 9*32)
+	for _, word := range [][]byte{
+		types.MustHashFromBigInt(w.Nonce).Bytes(),
+		types.MustHashFromBytes(w.Sender.Bytes(), types.PadLeft).Bytes(),
+		types.MustHashFromBytes(w.Target.Bytes(), types.PadLeft).Bytes(),
+		types.MustHashFromBigInt(w.Value).Bytes(),
+		types.MustHashFromBigInt(w.GasLimit).Bytes(),
+		types.MustHashFromBigInt(big.NewInt(6 * 32)).Bytes(),
+		types.MustHashFromBigInt(big.NewInt(3)).Bytes(),
+		types.MustHashFromBytes([]byte{1, 2, 3}, types.PadRight).Bytes(),
+	} {
+		enc = append(enc, word...)
+	}
+	assert.Equal(t, crypto.Keccak256(enc), hash)
+}
+
+func TestStorageSlot(t *testing.T) {
+	hash := types.MustHashFromHex("0x01", types.PadLeft)
+	assert.Equal(t, crypto.Keccak256(hash.Bytes(), make([]byte, 32)), StorageSlot(hash))
+}
+
+func TestWithdrawalsFromReceipt(t *testing.T) {
+	w := testWithdrawal()
+	hash, err := w.Hash()
+	require.NoError(t, err)
+
+	receipt := &types.TransactionReceipt{
+		Logs: []types.Log{
+			// Log from another contract with the same signature is ignored.
+			{Address: types.MustAddressFromHex("0x1111111111111111111111111111111111111111"), Topics: messagePassedLog(w, hash).Topics},
+			messagePassedLog(w, hash),
+		},
+	}
+	ws, err := WithdrawalsFromReceipt(receipt)
+	require.NoError(t, err)
+	require.Len(t, ws, 1)
+	assert.Equal(t, *w, ws[0])
+
+	// Event hash does not match the withdrawal.
+	receipt.Logs[1] = messagePassedLog(w, types.Hash{1})
+	_, err = WithdrawalsFromReceipt(receipt)
+	assert.Error(t, err)
+}
+
+func TestOutputRootProof_Hash(t *testing.T) {
+	p := OutputRootProof{
+		StateRoot:                types.Hash{1},
+		MessagePasserStorageRoot: types.Hash{2},
+		LatestBlockhash:          types.Hash{3},
+	}
+	enc := append(make([]byte, 32), p.StateRoot.Bytes()...)
+	enc = append(enc, p.MessagePasserStorageRoot.Bytes()...)
+	enc = append(enc, p.LatestBlockhash.Bytes()...)
+	assert.Equal(t, crypto.Keccak256(enc), p.Hash())
+}
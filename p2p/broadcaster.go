@@ -0,0 +1,134 @@
+package p2p
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// Broadcaster keeps connections to a set of peers and broadcasts signed
+// transactions to all of them.
+type Broadcaster struct {
+	mu       sync.Mutex
+	peers    []*Peer
+	announce bool
+}
+
+// BroadcasterOptions is the options for NewBroadcaster.
+type BroadcasterOptions struct {
+	// Config is the configuration of the local node.
+	Config Config
+
+	// Nodes is the list of nodes to connect to.
+	Nodes []*Node
+
+	// Announce, if true, announces transaction hashes instead of sending
+	// full transactions. Peers request the transactions they do not know.
+	// Blob transactions must always be announced.
+	Announce bool
+}
+
+// NewBroadcaster connects to the given nodes in parallel. Nodes that cannot
+// be connected to are skipped. An error is returned only if no connection
+// could be established.
+func NewBroadcaster(ctx context.Context, opts BroadcasterOptions) (*Broadcaster, error) {
+	if len(opts.Nodes) == 0 {
+		return nil, errors.New("p2p broadcaster: at least one node is required")
+	}
+	var (
+		wg    sync.WaitGroup
+		peers = make([]*Peer, len(opts.Nodes))
+		errs  = make([]error, len(opts.Nodes))
+	)
+	for i, node := range opts.Nodes {
+		wg.Add(1)
+		go func(i int, node *Node) {
+			defer wg.Done()
+			peers[i], errs[i] = Dial(ctx, node, opts.Config)
+		}(i, node)
+	}
+	wg.Wait()
+	b := &Broadcaster{announce: opts.Announce}
+	for _, p := range peers {
+		if p != nil {
+			b.peers = append(b.peers, p)
+		}
+	}
+	if len(b.peers) == 0 {
+		return nil, fmt.Errorf("p2p broadcaster: failed to connect to any node: %w", errs[0])
+	}
+	return b, nil
+}
+
+// Peers returns the currently connected peers. Disconnected peers are
+// removed from the list.
+func (b *Broadcaster) Peers() []*Peer {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var peers []*Peer
+	for _, p := range b.peers {
+		if p.Err() == nil {
+			peers = append(peers, p)
+		}
+	}
+	b.peers = peers
+	return append([]*Peer(nil), peers...)
+}
+
+// Broadcast sends the signed transactions to all connected peers. It returns
+// the number of peers the transactions were sent to. An error is returned
+// only if the transactions could not be sent to any peer.
+func (b *Broadcaster) Broadcast(ctx context.Context, txs ...*types.Transaction) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	peers := b.Peers()
+	if len(peers) == 0 {
+		return 0, errors.New("p2p broadcaster: no connected peers")
+	}
+	var (
+		wg   sync.WaitGroup
+		errs = make([]error, len(peers))
+	)
+	for i, p := range peers {
+		wg.Add(1)
+		go func(i int, p *Peer) {
+			defer wg.Done()
+			if b.announce {
+				errs[i] = p.AnnounceTransactions(txs...)
+			} else {
+				errs[i] = p.SendTransactions(txs...)
+			}
+		}(i, p)
+	}
+	wg.Wait()
+	var (
+		sent    int
+		lastErr error
+	)
+	for _, err := range errs {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		sent++
+	}
+	if sent == 0 {
+		return 0, fmt.Errorf("p2p broadcaster: failed to broadcast transactions: %w", lastErr)
+	}
+	return sent, nil
+}
+
+// Close disconnects from all peers.
+func (b *Broadcaster) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, p := range b.peers {
+		p.Close()
+	}
+	b.peers = nil
+	return nil
+}
@@ -0,0 +1,93 @@
+package p2p
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// eciesOverhead is the size added to a message by eciesEncrypt: the
+// ephemeral public key, the IV and the MAC.
+const eciesOverhead = 65 + aes.BlockSize + sha256.Size
+
+// eciesEncrypt encrypts the message for the given public key using ECIES
+// with AES-128-CTR and HMAC-SHA256, as used by the RLPx handshake.
+//
+// The shared data is included in the MAC, but not in the ciphertext.
+func eciesEncrypt(pub *btcec.PublicKey, msg, shared []byte) ([]byte, error) {
+	eph, err := btcec.NewPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	ke, km := eciesKeys(btcec.GenerateSharedSecret(eph, pub))
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(ke)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 0, len(msg)+eciesOverhead)
+	out = append(out, eph.PubKey().SerializeUncompressed()...)
+	out = append(out, iv...)
+	ct := make([]byte, len(msg))
+	cipher.NewCTR(block, iv).XORKeyStream(ct, msg)
+	out = append(out, ct...)
+	out = append(out, eciesMAC(km, iv, ct, shared)...)
+	return out, nil
+}
+
+// eciesDecrypt decrypts a message encrypted with eciesEncrypt.
+func eciesDecrypt(prv *btcec.PrivateKey, data, shared []byte) ([]byte, error) {
+	if len(data) < eciesOverhead || data[0] != 0x04 {
+		return nil, errors.New("invalid ECIES message")
+	}
+	pub, err := btcec.ParsePubKey(data[:65])
+	if err != nil {
+		return nil, err
+	}
+	var (
+		iv  = data[65 : 65+aes.BlockSize]
+		ct  = data[65+aes.BlockSize : len(data)-sha256.Size]
+		mac = data[len(data)-sha256.Size:]
+	)
+	ke, km := eciesKeys(btcec.GenerateSharedSecret(prv, pub))
+	if !hmac.Equal(mac, eciesMAC(km, iv, ct, shared)) {
+		return nil, errors.New("invalid ECIES message MAC")
+	}
+	block, err := aes.NewCipher(ke)
+	if err != nil {
+		return nil, err
+	}
+	msg := make([]byte, len(ct))
+	cipher.NewCTR(block, iv).XORKeyStream(msg, ct)
+	return msg, nil
+}
+
+// eciesKeys derives the encryption and MAC keys from the shared secret
+// using the NIST SP 800-56 concatenation KDF.
+func eciesKeys(z []byte) (ke, km []byte) {
+	var ctr [4]byte
+	binary.BigEndian.PutUint32(ctr[:], 1)
+	h := sha256.New()
+	h.Write(ctr[:])
+	h.Write(z)
+	k := h.Sum(nil)
+	kmHash := sha256.Sum256(k[16:32])
+	return k[:16], kmHash[:]
+}
+
+func eciesMAC(km, iv, ct, shared []byte) []byte {
+	mac := hmac.New(sha256.New, km)
+	mac.Write(iv)
+	mac.Write(ct)
+	mac.Write(shared)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,95 @@
+package p2p
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// Node is a devp2p node address.
+type Node struct {
+	PublicKey *btcec.PublicKey // PublicKey is the node public key.
+	IP        net.IP           // IP is the node IP address.
+	TCP       uint16           // TCP is the RLPx port.
+}
+
+// ParseNode parses an enode URL in the form:
+//
+//	enode://<hex node id>@<ip>:<port>
+//
+// The node ID is the 64-byte uncompressed public key without the 0x04 prefix.
+// Host names are not resolved, the host must be an IP address.
+func ParseNode(enode string) (*Node, error) {
+	u, err := url.Parse(enode)
+	if err != nil {
+		return nil, fmt.Errorf("p2p: invalid enode URL: %w", err)
+	}
+	if u.Scheme != "enode" {
+		return nil, fmt.Errorf("p2p: invalid enode URL scheme: %s", u.Scheme)
+	}
+	if u.User == nil {
+		return nil, fmt.Errorf("p2p: missing node ID in enode URL")
+	}
+	id, err := hex.DecodeString(u.User.String())
+	if err != nil {
+		return nil, fmt.Errorf("p2p: invalid node ID: %w", err)
+	}
+	pub, err := parsePubKey(id)
+	if err != nil {
+		return nil, err
+	}
+	ip := net.ParseIP(u.Hostname())
+	if ip == nil {
+		return nil, fmt.Errorf("p2p: invalid IP address: %s", u.Hostname())
+	}
+	port, err := strconv.ParseUint(u.Port(), 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("p2p: invalid port: %s", u.Port())
+	}
+	return &Node{PublicKey: pub, IP: ip, TCP: uint16(port)}, nil
+}
+
+// MustParseNode is like ParseNode but panics on error.
+func MustParseNode(enode string) *Node {
+	n, err := ParseNode(enode)
+	if err != nil {
+		panic(err)
+	}
+	return n
+}
+
+// ID returns the 64-byte node ID.
+func (n *Node) ID() []byte {
+	return encodePubKey(n.PublicKey)
+}
+
+// Addr returns the TCP address of the node.
+func (n *Node) Addr() string {
+	return net.JoinHostPort(n.IP.String(), strconv.Itoa(int(n.TCP)))
+}
+
+// String returns the enode URL of the node.
+func (n *Node) String() string {
+	return fmt.Sprintf("enode://%x@%s", n.ID(), n.Addr())
+}
+
+// encodePubKey returns the 64-byte encoding of the public key used by devp2p.
+func encodePubKey(pub *btcec.PublicKey) []byte {
+	return pub.SerializeUncompressed()[1:]
+}
+
+// parsePubKey parses the 64-byte encoding of the public key used by devp2p.
+func parsePubKey(b []byte) (*btcec.PublicKey, error) {
+	if len(b) != 64 {
+		return nil, fmt.Errorf("p2p: invalid public key length: %d", len(b))
+	}
+	pub, err := btcec.ParsePubKey(append([]byte{0x04}, b...))
+	if err != nil {
+		return nil, fmt.Errorf("p2p: invalid public key: %w", err)
+	}
+	return pub, nil
+}
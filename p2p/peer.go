@@ -0,0 +1,423 @@
+package p2p
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/defiweb/go-rlp"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/types"
+)
+
+// DefaultHandshakeTimeout is the default timeout for the RLPx, hello and
+// status handshakes.
+const DefaultHandshakeTimeout = 10 * time.Second
+
+// maxPooledTransactions is the maximum number of announced transactions
+// kept by a peer to serve GetPooledTransactions requests.
+const maxPooledTransactions = 4096
+
+// Config is the configuration of the local node.
+type Config struct {
+	// PrivateKey is the node key. If nil, a random key is generated.
+	PrivateKey *ecdsa.PrivateKey
+
+	// Name is the client name sent in the hello message. If empty,
+	// "go-eth" is used.
+	Name string
+
+	// NetworkID is the network ID, 1 for mainnet.
+	NetworkID uint64
+
+	// Genesis is the genesis block hash.
+	Genesis types.Hash
+
+	// Head is the hash of a recent block. Peers use it to decide whether
+	// they can sync from us, so it does not have to be the latest block.
+	Head types.Hash
+
+	// TD is the total difficulty of the head block. After the merge, the
+	// final total difficulty of the chain can be used.
+	TD *big.Int
+
+	// ForkID is the EIP-2124 fork identifier, see NewForkID. Peers
+	// disconnect if the fork ID is not compatible with their chain.
+	ForkID ForkID
+
+	// HandshakeTimeout is the timeout for the handshakes. If zero,
+	// DefaultHandshakeTimeout is used.
+	HandshakeTimeout time.Duration
+}
+
+// Peer is a connection to a remote node speaking the eth/68 protocol.
+//
+// The peer only supports broadcasting transactions. Other messages sent by
+// the remote node are ignored, except for pings and GetPooledTransactions
+// requests for transactions announced by AnnounceTransactions.
+type Peer struct {
+	node   *Node
+	conn   *rlpxConn
+	hello  hello
+	status Status
+
+	writeMu sync.Mutex
+
+	poolMu    sync.Mutex
+	pool      map[types.Hash]rawTx
+	poolOrder []types.Hash
+
+	closeOnce sync.Once
+	done      chan struct{}
+	err       error
+}
+
+// Dial connects to the node and performs the RLPx, hello and eth status
+// handshakes.
+func Dial(ctx context.Context, node *Node, cfg Config) (*Peer, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", node.Addr())
+	if err != nil {
+		return nil, fmt.Errorf("p2p: failed to dial %s: %w", node.Addr(), err)
+	}
+	p, err := handshake(ctx, conn, node, cfg)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// handshake performs the initiator handshakes on an established connection.
+func handshake(ctx context.Context, conn net.Conn, node *Node, cfg Config) (*Peer, error) {
+	prv, err := nodeKey(cfg.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	timeout := cfg.HandshakeTimeout
+	if timeout == 0 {
+		timeout = DefaultHandshakeTimeout
+	}
+	if dl, ok := ctx.Deadline(); ok && time.Until(dl) < timeout {
+		timeout = time.Until(dl)
+	}
+	rc, err := rlpxInitiate(conn, prv, node.PublicKey, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("p2p: RLPx handshake failed: %w", err)
+	}
+	p := &Peer{
+		node: node,
+		conn: rc,
+		pool: make(map[types.Hash]rawTx),
+		done: make(chan struct{}),
+	}
+	if err := conn.SetDeadline(deadline(timeout)); err != nil {
+		return nil, err
+	}
+	if err := p.handshakeHello(prv, cfg.Name); err != nil {
+		return nil, err
+	}
+	if err := p.handshakeStatus(cfg); err != nil {
+		return nil, err
+	}
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		return nil, err
+	}
+	go p.readLoop()
+	return p, nil
+}
+
+func (p *Peer) handshakeHello(prv *btcec.PrivateKey, name string) error {
+	if name == "" {
+		name = "go-eth"
+	}
+	msg, err := (&hello{
+		Version: baseProtocolVersion,
+		Name:    name,
+		Caps:    []capability{{Name: ethProtocolName, Version: ethProtocolVersion}},
+		ID:      encodePubKey(prv.PubKey()),
+	}).encode()
+	if err != nil {
+		return err
+	}
+	if err := p.conn.writeMsg(helloMsg, msg); err != nil {
+		return fmt.Errorf("p2p: failed to send hello: %w", err)
+	}
+	code, payload, err := p.conn.readMsg()
+	if err != nil {
+		return fmt.Errorf("p2p: failed to read hello: %w", err)
+	}
+	switch code {
+	case helloMsg:
+	case disconnectMsg:
+		return decodeDisconnect(payload)
+	default:
+		return fmt.Errorf("p2p: expected hello message, got %d", code)
+	}
+	if err := p.hello.decode(payload); err != nil {
+		return err
+	}
+	if !p.hello.hasCap(ethProtocolName, ethProtocolVersion) {
+		p.disconnect(DisconnectUselessPeer)
+		return fmt.Errorf("p2p: peer does not support %s/%d", ethProtocolName, ethProtocolVersion)
+	}
+	p.conn.snappy = p.hello.Version >= baseProtocolVersion
+	return nil
+}
+
+func (p *Peer) handshakeStatus(cfg Config) error {
+	msg, err := (&Status{
+		ProtocolVersion: ethProtocolVersion,
+		NetworkID:       cfg.NetworkID,
+		TD:              cfg.TD,
+		Head:            cfg.Head,
+		Genesis:         cfg.Genesis,
+		ForkID:          cfg.ForkID,
+	}).encode()
+	if err != nil {
+		return err
+	}
+	if err := p.conn.writeMsg(ethOffset+statusMsg, msg); err != nil {
+		return fmt.Errorf("p2p: failed to send status: %w", err)
+	}
+	for {
+		code, payload, err := p.conn.readMsg()
+		if err != nil {
+			return fmt.Errorf("p2p: failed to read status: %w", err)
+		}
+		switch code {
+		case ethOffset + statusMsg:
+			if err := p.status.decode(payload); err != nil {
+				return err
+			}
+			if p.status.NetworkID != cfg.NetworkID || p.status.Genesis != cfg.Genesis {
+				p.disconnect(DisconnectUselessPeer)
+				return errors.New("p2p: peer is on a different network")
+			}
+			return nil
+		case pingMsg:
+			if err := p.writeMsg(pongMsg, []byte{0xc0}); err != nil {
+				return err
+			}
+		case disconnectMsg:
+			return decodeDisconnect(payload)
+		default:
+			return fmt.Errorf("p2p: expected status message, got %d", code)
+		}
+	}
+}
+
+// Node returns the remote node.
+func (p *Peer) Node() *Node {
+	return p.node
+}
+
+// Name returns the client name of the remote node.
+func (p *Peer) Name() string {
+	return p.hello.Name
+}
+
+// Status returns the status message received from the remote node.
+func (p *Peer) Status() Status {
+	return p.status
+}
+
+// SendTransactions sends the signed transactions to the peer using the
+// Transactions message.
+func (p *Peer) SendTransactions(txs ...*types.Transaction) error {
+	raw, err := encodeRawTxs(txs)
+	if err != nil {
+		return err
+	}
+	msg, err := encodeTransactions(raw)
+	if err != nil {
+		return err
+	}
+	return p.writeMsg(ethOffset+transactionsMsg, msg)
+}
+
+// AnnounceTransactions announces the hashes of the signed transactions to
+// the peer. The peer may then request the transactions, which are served
+// from memory.
+func (p *Peer) AnnounceTransactions(txs ...*types.Transaction) error {
+	raw, err := encodeRawTxs(txs)
+	if err != nil {
+		return err
+	}
+	var (
+		txTypes []byte
+		sizes   = rlp.NewList()
+		hashes  = rlp.NewList()
+	)
+	p.poolMu.Lock()
+	for _, tx := range raw {
+		hash := crypto.Keccak256(tx)
+		txTypes = append(txTypes, tx.txType())
+		sizes.Append(rlp.NewUint(uint64(len(tx))))
+		hashes.Append(rlp.NewBytes(hash.Bytes()))
+		if _, ok := p.pool[hash]; !ok {
+			p.pool[hash] = tx
+			p.poolOrder = append(p.poolOrder, hash)
+		}
+	}
+	for len(p.poolOrder) > maxPooledTransactions {
+		delete(p.pool, p.poolOrder[0])
+		p.poolOrder = p.poolOrder[1:]
+	}
+	p.poolMu.Unlock()
+	msg, err := rlp.NewList(rlp.NewBytes(txTypes), sizes, hashes).EncodeRLP()
+	if err != nil {
+		return err
+	}
+	return p.writeMsg(ethOffset+newPooledTransactionHashesMsg, msg)
+}
+
+// Done returns a channel that is closed when the connection is closed.
+func (p *Peer) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err returns the reason the connection was closed. It returns nil while
+// the connection is open.
+func (p *Peer) Err() error {
+	select {
+	case <-p.done:
+		return p.err
+	default:
+		return nil
+	}
+}
+
+// Close sends a disconnect message and closes the connection.
+func (p *Peer) Close() error {
+	p.disconnect(DisconnectRequested)
+	p.close(errors.New("p2p: connection closed"))
+	return nil
+}
+
+func (p *Peer) readLoop() {
+	for {
+		code, payload, err := p.conn.readMsg()
+		if err != nil {
+			p.close(err)
+			return
+		}
+		switch code {
+		case pingMsg:
+			err = p.writeMsg(pongMsg, []byte{0xc0})
+		case disconnectMsg:
+			err = decodeDisconnect(payload)
+		case ethOffset + getPooledTransactionsMsg:
+			err = p.handleGetPooledTransactions(payload)
+		}
+		if err != nil {
+			p.close(err)
+			return
+		}
+	}
+}
+
+func (p *Peer) handleGetPooledTransactions(payload []byte) error {
+	var (
+		requestID = &rlp.UintItem{}
+		hashes    = &rlp.ListItem{}
+	)
+	if err := decodeLoose(payload, requestID, hashes); err != nil {
+		return fmt.Errorf("p2p: invalid GetPooledTransactions message: %w", err)
+	}
+	var txs []rawTx
+	p.poolMu.Lock()
+	for _, item := range hashes.Items() {
+		r, ok := item.(*rlp.RLP)
+		if !ok {
+			continue
+		}
+		b, err := r.GetBytes()
+		if err != nil || len(b) != types.HashLength {
+			continue
+		}
+		if tx, ok := p.pool[types.MustHashFromBytes(b, types.PadNone)]; ok {
+			txs = append(txs, tx)
+		}
+	}
+	p.poolMu.Unlock()
+	list, err := encodeTransactions(txs)
+	if err != nil {
+		return err
+	}
+	msg, err := rlp.NewList(rlp.NewUint(requestID.X), rawItem(list)).EncodeRLP()
+	if err != nil {
+		return err
+	}
+	return p.writeMsg(ethOffset+pooledTransactionsMsg, msg)
+}
+
+func (p *Peer) writeMsg(code uint64, payload []byte) error {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	return p.conn.writeMsg(code, payload)
+}
+
+func (p *Peer) disconnect(reason DisconnectReason) {
+	msg, err := rlp.NewList(rlp.NewUint(uint64(reason))).EncodeRLP()
+	if err != nil {
+		return
+	}
+	p.conn.conn.SetWriteDeadline(time.Now().Add(time.Second))
+	_ = p.writeMsg(disconnectMsg, msg)
+}
+
+func (p *Peer) close(err error) {
+	p.closeOnce.Do(func() {
+		p.err = err
+		p.conn.close()
+		close(p.done)
+	})
+}
+
+func decodeDisconnect(payload []byte) error {
+	// The reason may be sent as a list with a single element or, by some
+	// implementations, as a bare integer.
+	reason := &rlp.UintItem{}
+	if err := decodeLoose(payload, reason); err != nil {
+		if _, err := rlp.DecodeTo(payload, reason); err != nil {
+			return &DisconnectError{Reason: DisconnectRequested}
+		}
+	}
+	return &DisconnectError{Reason: DisconnectReason(reason.X)}
+}
+
+func encodeRawTxs(txs []*types.Transaction) ([]rawTx, error) {
+	raw := make([]rawTx, len(txs))
+	for i, tx := range txs {
+		if tx.Signature == nil {
+			return nil, errors.New("p2p: transaction is not signed")
+		}
+		b, err := tx.EncodeRLP()
+		if err != nil {
+			return nil, fmt.Errorf("p2p: failed to encode transaction: %w", err)
+		}
+		raw[i] = b
+	}
+	return raw, nil
+}
+
+func nodeKey(key *ecdsa.PrivateKey) (*btcec.PrivateKey, error) {
+	if key == nil {
+		return btcec.NewPrivateKey()
+	}
+	prv, _ := btcec.PrivKeyFromBytes(key.D.Bytes())
+	return prv, nil
+}
+
+func rawItem(b []byte) rlp.Item {
+	r := rlp.RLP(b)
+	return &r
+}
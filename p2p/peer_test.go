@@ -0,0 +1,188 @@
+package p2p
+
+import (
+	"context"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/defiweb/go-rlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/types"
+)
+
+var testGenesis = types.MustHashFromHex("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3", types.PadNone)
+
+// fakeRemote performs the recipient side of the handshakes and returns the
+// connection for further message exchange.
+func fakeRemote(t *testing.T, conn net.Conn, prv *btcec.PrivateKey, networkID uint64) *rlpxConn {
+	rc, err := rlpxRespond(conn, prv, time.Second)
+	require.NoError(t, err)
+
+	code, payload, err := rc.readMsg()
+	require.NoError(t, err)
+	require.Equal(t, uint64(helloMsg), code)
+	var h hello
+	require.NoError(t, h.decode(payload))
+	assert.True(t, h.hasCap(ethProtocolName, ethProtocolVersion))
+
+	msg, err := (&hello{
+		Version: baseProtocolVersion,
+		Name:    "fake",
+		Caps:    []capability{{Name: ethProtocolName, Version: ethProtocolVersion}},
+		ID:      encodePubKey(prv.PubKey()),
+	}).encode()
+	require.NoError(t, err)
+	require.NoError(t, rc.writeMsg(helloMsg, msg))
+	rc.snappy = true
+
+	code, payload, err = rc.readMsg()
+	require.NoError(t, err)
+	require.Equal(t, uint64(ethOffset+statusMsg), code)
+	var s Status
+	require.NoError(t, s.decode(payload))
+
+	s.NetworkID = networkID
+	msg, err = s.encode()
+	require.NoError(t, err)
+	require.NoError(t, rc.writeMsg(ethOffset+statusMsg, msg))
+	return rc
+}
+
+func testPeer(t *testing.T, networkID uint64) (*Peer, *rlpxConn, error) {
+	prv, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	node := &Node{PublicKey: prv.PubKey(), IP: net.IPv4(127, 0, 0, 1), TCP: 30303}
+
+	c1, c2 := net.Pipe()
+	remoteCh := make(chan *rlpxConn, 1)
+	go func() {
+		remoteCh <- fakeRemote(t, c2, prv, networkID)
+	}()
+	p, err := handshake(context.Background(), c1, node, Config{
+		NetworkID:        1,
+		Genesis:          testGenesis,
+		TD:               big.NewInt(1),
+		HandshakeTimeout: time.Second,
+	})
+	remote := <-remoteCh
+	t.Cleanup(func() {
+		remote.close()
+		if p != nil {
+			p.Close()
+		}
+	})
+	return p, remote, err
+}
+
+func testTransaction() *types.Transaction {
+	return types.NewTransaction().
+		SetType(types.DynamicFeeTxType).
+		SetChainID(1).
+		SetNonce(1).
+		SetTo(types.MustAddressFromHex("0x3333333333333333333333333333333333333333")).
+		SetGasLimit(21000).
+		SetMaxFeePerGas(big.NewInt(2)).
+		SetMaxPriorityFeePerGas(big.NewInt(1)).
+		SetValue(big.NewInt(1)).
+		SetSignature(types.SignatureFromVRS(big.NewInt(1), big.NewInt(2), big.NewInt(3)))
+}
+
+func TestPeer_SendTransactions(t *testing.T) {
+	p, remote, err := testPeer(t, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "fake", p.Name())
+	assert.Equal(t, testGenesis, p.Status().Genesis)
+
+	tx := testTransaction()
+	raw, err := tx.EncodeRLP()
+	require.NoError(t, err)
+
+	go func() {
+		assert.NoError(t, p.SendTransactions(tx))
+	}()
+	code, payload, err := remote.readMsg()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(ethOffset+transactionsMsg), code)
+	list := &rlp.ListItem{}
+	_, err = rlp.DecodeTo(payload, list)
+	require.NoError(t, err)
+	require.Len(t, list.Items(), 1)
+	b, err := list.Items()[0].(*rlp.RLP).GetBytes()
+	require.NoError(t, err)
+	assert.Equal(t, raw, b)
+}
+
+func TestPeer_AnnounceTransactions(t *testing.T) {
+	p, remote, err := testPeer(t, 1)
+	require.NoError(t, err)
+
+	tx := testTransaction()
+	raw, err := tx.EncodeRLP()
+	require.NoError(t, err)
+	hash := crypto.Keccak256(raw)
+
+	go func() {
+		assert.NoError(t, p.AnnounceTransactions(tx))
+	}()
+	code, payload, err := remote.readMsg()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(ethOffset+newPooledTransactionHashesMsg), code)
+	var (
+		txTypes = &rlp.StringItem{}
+		sizes   = &rlp.ListItem{}
+		hashes  = &rlp.ListItem{}
+	)
+	require.NoError(t, decodeLoose(payload, txTypes, sizes, hashes))
+	assert.Equal(t, []byte{byte(types.DynamicFeeTxType)}, txTypes.Bytes())
+	require.Len(t, hashes.Items(), 1)
+	b, err := hashes.Items()[0].(*rlp.RLP).GetBytes()
+	require.NoError(t, err)
+	assert.Equal(t, hash.Bytes(), b)
+
+	// Request the announced transaction.
+	msg, err := rlp.NewList(rlp.NewUint(7), rlp.NewList(rlp.NewBytes(hash.Bytes()))).EncodeRLP()
+	require.NoError(t, err)
+	require.NoError(t, remote.writeMsg(ethOffset+getPooledTransactionsMsg, msg))
+	code, payload, err = remote.readMsg()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(ethOffset+pooledTransactionsMsg), code)
+	var (
+		requestID = &rlp.UintItem{}
+		txs       = &rlp.ListItem{}
+	)
+	require.NoError(t, decodeLoose(payload, requestID, txs))
+	assert.Equal(t, uint64(7), requestID.X)
+	require.Len(t, txs.Items(), 1)
+	b, err = txs.Items()[0].(*rlp.RLP).GetBytes()
+	require.NoError(t, err)
+	assert.Equal(t, raw, b)
+}
+
+func TestPeer_Disconnect(t *testing.T) {
+	p, remote, err := testPeer(t, 1)
+	require.NoError(t, err)
+
+	msg, err := rlp.NewList(rlp.NewUint(uint64(DisconnectTooManyPeers))).EncodeRLP()
+	require.NoError(t, err)
+	require.NoError(t, remote.writeMsg(disconnectMsg, msg))
+
+	select {
+	case <-p.Done():
+	case <-time.After(time.Second):
+		t.Fatal("peer not closed")
+	}
+	var de *DisconnectError
+	require.ErrorAs(t, p.Err(), &de)
+	assert.Equal(t, DisconnectTooManyPeers, de.Reason)
+}
+
+func TestPeer_NetworkMismatch(t *testing.T) {
+	_, _, err := testPeer(t, 5)
+	require.Error(t, err)
+}
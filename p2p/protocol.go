@@ -0,0 +1,281 @@
+package p2p
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"math/big"
+	"sort"
+
+	"github.com/defiweb/go-rlp"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+const (
+	// baseProtocolVersion is the devp2p base protocol version. Version 5
+	// enables snappy compression.
+	baseProtocolVersion = 5
+
+	// ethProtocolName and ethProtocolVersion identify the supported eth
+	// capability.
+	ethProtocolName    = "eth"
+	ethProtocolVersion = 68
+
+	// ethOffset is the message code offset of the eth protocol, which is the
+	// only capability negotiated by the peer.
+	ethOffset = 0x10
+)
+
+// Base protocol messages.
+const (
+	helloMsg      = 0x00
+	disconnectMsg = 0x01
+	pingMsg       = 0x02
+	pongMsg       = 0x03
+)
+
+// eth/68 protocol messages, relative to ethOffset.
+const (
+	statusMsg                     = 0x00
+	transactionsMsg               = 0x02
+	newPooledTransactionHashesMsg = 0x08
+	getPooledTransactionsMsg      = 0x09
+	pooledTransactionsMsg         = 0x0a
+)
+
+// DisconnectReason is the reason sent in a disconnect message.
+type DisconnectReason uint64
+
+// Disconnect reasons.
+const (
+	DisconnectRequested          DisconnectReason = 0x00
+	DisconnectNetworkError       DisconnectReason = 0x01
+	DisconnectProtocolError      DisconnectReason = 0x02
+	DisconnectUselessPeer        DisconnectReason = 0x03
+	DisconnectTooManyPeers       DisconnectReason = 0x04
+	DisconnectAlreadyConnected   DisconnectReason = 0x05
+	DisconnectIncompatible       DisconnectReason = 0x06
+	DisconnectInvalidIdentity    DisconnectReason = 0x07
+	DisconnectQuitting           DisconnectReason = 0x08
+	DisconnectUnexpectedIdentity DisconnectReason = 0x09
+	DisconnectSelf               DisconnectReason = 0x0a
+	DisconnectReadTimeout        DisconnectReason = 0x0b
+	DisconnectSubprotocolError   DisconnectReason = 0x10
+)
+
+// DisconnectError is returned when the remote peer closes the connection
+// with a disconnect message.
+type DisconnectError struct {
+	Reason DisconnectReason
+}
+
+// Error implements the error interface.
+func (e *DisconnectError) Error() string {
+	return fmt.Sprintf("p2p: peer disconnected: reason %d", e.Reason)
+}
+
+// ForkID is the EIP-2124 fork identifier.
+type ForkID struct {
+	Hash [4]byte // Hash is the CRC32 checksum of the genesis hash and passed fork blocks and timestamps.
+	Next uint64  // Next is the block number or timestamp of the next fork, or zero.
+}
+
+// NewForkID calculates the EIP-2124 fork identifier for a node at the given
+// head block number and timestamp.
+//
+// The blockForks and timeForks are the activation block numbers and
+// timestamps of the chain forks. Forks activated at genesis are ignored.
+func NewForkID(genesis types.Hash, head, time uint64, blockForks, timeForks []uint64) ForkID {
+	hash := crc32.ChecksumIEEE(genesis.Bytes())
+	var buf [8]byte
+	for _, forks := range []struct {
+		list []uint64
+		cur  uint64
+	}{{blockForks, head}, {timeForks, time}} {
+		for _, fork := range uniqueForks(forks.list) {
+			if fork > forks.cur {
+				var id ForkID
+				binary.BigEndian.PutUint32(id.Hash[:], hash)
+				id.Next = fork
+				return id
+			}
+			binary.BigEndian.PutUint64(buf[:], fork)
+			hash = crc32.Update(hash, crc32.IEEETable, buf[:])
+		}
+	}
+	var id ForkID
+	binary.BigEndian.PutUint32(id.Hash[:], hash)
+	return id
+}
+
+// uniqueForks returns sorted forks without duplicates and genesis forks.
+func uniqueForks(forks []uint64) []uint64 {
+	s := append([]uint64(nil), forks...)
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	var out []uint64
+	for _, f := range s {
+		if f == 0 || (len(out) > 0 && out[len(out)-1] == f) {
+			continue
+		}
+		out = append(out, f)
+	}
+	return out
+}
+
+// Status is the eth protocol status message exchanged during the handshake.
+type Status struct {
+	ProtocolVersion uint64     // ProtocolVersion is the eth protocol version.
+	NetworkID       uint64     // NetworkID is the network ID.
+	TD              *big.Int   // TD is the total difficulty of the head block.
+	Head            types.Hash // Head is the hash of the head block.
+	Genesis         types.Hash // Genesis is the genesis block hash.
+	ForkID          ForkID     // ForkID is the EIP-2124 fork identifier.
+}
+
+func (s *Status) encode() ([]byte, error) {
+	td := s.TD
+	if td == nil {
+		td = new(big.Int)
+	}
+	return rlp.NewList(
+		rlp.NewUint(s.ProtocolVersion),
+		rlp.NewUint(s.NetworkID),
+		rlp.NewBigInt(td),
+		rlp.NewBytes(s.Head.Bytes()),
+		rlp.NewBytes(s.Genesis.Bytes()),
+		rlp.NewList(rlp.NewBytes(s.ForkID.Hash[:]), rlp.NewUint(s.ForkID.Next)),
+	).EncodeRLP()
+}
+
+func (s *Status) decode(data []byte) error {
+	var (
+		version   = &rlp.UintItem{}
+		networkID = &rlp.UintItem{}
+		td        = &rlp.BigIntItem{}
+		head      = &rlp.StringItem{}
+		genesis   = &rlp.StringItem{}
+		forkHash  = &rlp.StringItem{}
+		forkNext  = &rlp.UintItem{}
+	)
+	if _, err := rlp.DecodeTo(data, rlp.NewList(version, networkID, td, head, genesis, rlp.NewList(forkHash, forkNext))); err != nil {
+		return fmt.Errorf("p2p: invalid status message: %w", err)
+	}
+	if len(head.Bytes()) != types.HashLength || len(genesis.Bytes()) != types.HashLength || len(forkHash.Bytes()) != 4 {
+		return errors.New("p2p: invalid status message")
+	}
+	s.ProtocolVersion = version.X
+	s.NetworkID = networkID.X
+	s.TD = td.X
+	s.Head = types.MustHashFromBytes(head.Bytes(), types.PadNone)
+	s.Genesis = types.MustHashFromBytes(genesis.Bytes(), types.PadNone)
+	copy(s.ForkID.Hash[:], forkHash.Bytes())
+	s.ForkID.Next = forkNext.X
+	return nil
+}
+
+// hello is the base protocol handshake message.
+type hello struct {
+	Version uint64
+	Name    string
+	Caps    []capability
+	Port    uint64
+	ID      []byte
+}
+
+type capability struct {
+	Name    string
+	Version uint64
+}
+
+func (h *hello) encode() ([]byte, error) {
+	caps := rlp.NewList()
+	for _, c := range h.Caps {
+		caps.Append(rlp.NewList(rlp.NewString(c.Name), rlp.NewUint(c.Version)))
+	}
+	return rlp.NewList(
+		rlp.NewUint(h.Version),
+		rlp.NewString(h.Name),
+		caps,
+		rlp.NewUint(h.Port),
+		rlp.NewBytes(h.ID),
+	).EncodeRLP()
+}
+
+func (h *hello) decode(data []byte) error {
+	r, _, err := rlp.Decode(data)
+	if err != nil {
+		return fmt.Errorf("p2p: invalid hello message: %w", err)
+	}
+	l, err := r.GetList()
+	if err != nil || len(l) < 5 {
+		return errors.New("p2p: invalid hello message")
+	}
+	if h.Version, err = l[0].GetUint(); err != nil {
+		return fmt.Errorf("p2p: invalid hello message: %w", err)
+	}
+	if h.Name, err = l[1].GetString(); err != nil {
+		return fmt.Errorf("p2p: invalid hello message: %w", err)
+	}
+	caps, err := l[2].GetList()
+	if err != nil {
+		return fmt.Errorf("p2p: invalid hello message: %w", err)
+	}
+	for _, c := range caps {
+		var (
+			name    = &rlp.StringItem{}
+			version = &rlp.UintItem{}
+		)
+		if err := decodeLoose(c.Bytes(), name, version); err != nil {
+			return fmt.Errorf("p2p: invalid hello message: %w", err)
+		}
+		h.Caps = append(h.Caps, capability{Name: name.String(), Version: version.X})
+	}
+	if h.Port, err = l[3].GetUint(); err != nil {
+		return fmt.Errorf("p2p: invalid hello message: %w", err)
+	}
+	if h.ID, err = l[4].GetBytes(); err != nil {
+		return fmt.Errorf("p2p: invalid hello message: %w", err)
+	}
+	return nil
+}
+
+func (h *hello) hasCap(name string, version uint64) bool {
+	for _, c := range h.Caps {
+		if c.Name == name && c.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// rawTx is a signed transaction in its consensus encoding.
+type rawTx []byte
+
+// item returns the RLP item used to embed the transaction in eth protocol
+// messages. Legacy transactions are embedded as lists and typed
+// transactions as byte strings.
+func (tx rawTx) item() rlp.Item {
+	if len(tx) > 0 && tx[0] >= 0xc0 {
+		r := rlp.RLP(tx)
+		return &r
+	}
+	return rlp.NewBytes(tx)
+}
+
+// txType returns the EIP-2718 transaction type.
+func (tx rawTx) txType() byte {
+	if len(tx) == 0 || tx[0] >= 0xc0 {
+		return byte(types.LegacyTxType)
+	}
+	return tx[0]
+}
+
+func encodeTransactions(txs []rawTx) ([]byte, error) {
+	l := rlp.NewList()
+	for _, tx := range txs {
+		l.Append(tx.item())
+	}
+	return l.EncodeRLP()
+}
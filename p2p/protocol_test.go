@@ -0,0 +1,63 @@
+package p2p
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+func TestNewForkID(t *testing.T) {
+	genesis := types.MustHashFromHex("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3", types.PadNone)
+	forks := []uint64{1150000, 1920000, 2463000, 2675000, 4370000, 7280000, 7280000, 9069000}
+	tests := []struct {
+		head uint64
+		want ForkID
+	}{
+		{head: 0, want: ForkID{Hash: [4]byte{0xfc, 0x64, 0xec, 0x04}, Next: 1150000}},
+		{head: 1149999, want: ForkID{Hash: [4]byte{0xfc, 0x64, 0xec, 0x04}, Next: 1150000}},
+		{head: 1150000, want: ForkID{Hash: [4]byte{0x97, 0xc2, 0xc3, 0x4c}, Next: 1920000}},
+		{head: 1920000, want: ForkID{Hash: [4]byte{0x91, 0xd1, 0xf9, 0x48}, Next: 2463000}},
+		{head: 7280000, want: ForkID{Hash: [4]byte{0x66, 0x8d, 0xb0, 0xaf}, Next: 9069000}},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, NewForkID(genesis, tt.head, 0, forks, nil), "head %d", tt.head)
+	}
+}
+
+func TestStatus_RLP(t *testing.T) {
+	s := Status{
+		ProtocolVersion: ethProtocolVersion,
+		NetworkID:       1,
+		TD:              big.NewInt(1000),
+		Head:            types.MustHashFromHex("0x1111111111111111111111111111111111111111111111111111111111111111", types.PadNone),
+		Genesis:         types.MustHashFromHex("0x2222222222222222222222222222222222222222222222222222222222222222", types.PadNone),
+		ForkID:          ForkID{Hash: [4]byte{1, 2, 3, 4}, Next: 5},
+	}
+	enc, err := s.encode()
+	require.NoError(t, err)
+
+	var dec Status
+	require.NoError(t, dec.decode(enc))
+	assert.Equal(t, s, dec)
+}
+
+func TestHello_RLP(t *testing.T) {
+	h := hello{
+		Version: baseProtocolVersion,
+		Name:    "go-eth",
+		Caps:    []capability{{Name: "eth", Version: 67}, {Name: "eth", Version: 68}, {Name: "snap", Version: 1}},
+		ID:      make([]byte, 64),
+	}
+	enc, err := h.encode()
+	require.NoError(t, err)
+
+	var dec hello
+	require.NoError(t, dec.decode(enc))
+	assert.Equal(t, h, dec)
+	assert.True(t, dec.hasCap("eth", 68))
+	assert.False(t, dec.hasCap("eth", 69))
+}
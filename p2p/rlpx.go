@@ -0,0 +1,466 @@
+package p2p
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	mrand "math/rand"
+	"net"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	btcececdsa "github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/defiweb/go-rlp"
+	"github.com/golang/snappy"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// handshakeVersion is the version field of the EIP-8 auth and ack
+	// messages.
+	handshakeVersion = 4
+
+	// maxFrameSize is the maximum size of a single RLPx frame.
+	maxFrameSize = 0xffffff
+
+	// maxHandshakeSize is the maximum size of the auth and ack messages.
+	maxHandshakeSize = 2048
+)
+
+// zeroHeader is the RLP encoded header data of a frame, that is,
+// [capability-id, context-id] set to zero.
+var zeroHeader = []byte{0xc2, 0x80, 0x80}
+
+// rlpxConn is an RLPx connection after a successful handshake. It encrypts
+// and authenticates messages as defined by the RLPx transport protocol.
+//
+// The connection is not safe for concurrent reads or concurrent writes, but
+// a single reader and a single writer may be used concurrently.
+type rlpxConn struct {
+	conn       net.Conn
+	remote     *btcec.PublicKey
+	enc        cipher.Stream
+	dec        cipher.Stream
+	egressMAC  *frameMAC
+	ingressMAC *frameMAC
+	snappy     bool
+}
+
+// secrets are the session secrets derived during the handshake.
+type secrets struct {
+	aes        []byte
+	mac        []byte
+	egressMAC  hash.Hash
+	ingressMAC hash.Hash
+}
+
+// rlpxInitiate performs the initiator side of the RLPx handshake.
+func rlpxInitiate(conn net.Conn, prv *btcec.PrivateKey, remote *btcec.PublicKey, timeout time.Duration) (*rlpxConn, error) {
+	if err := conn.SetDeadline(deadline(timeout)); err != nil {
+		return nil, err
+	}
+	defer conn.SetDeadline(time.Time{})
+	eph, initNonce, err := newEphemeral()
+	if err != nil {
+		return nil, err
+	}
+	return initiateHandshake(conn, prv, remote, eph, initNonce)
+}
+
+// initiateHandshake performs the initiator side of the RLPx handshake using
+// the given ephemeral key and nonce.
+func initiateHandshake(conn net.Conn, prv *btcec.PrivateKey, remote *btcec.PublicKey, eph *btcec.PrivateKey, initNonce []byte) (*rlpxConn, error) {
+	// Sign the static shared secret XOR nonce with the ephemeral key, so the
+	// recipient can recover our ephemeral public key.
+	token := btcec.GenerateSharedSecret(prv, remote)
+	sig, err := signCompact(eph, xor(token, initNonce))
+	if err != nil {
+		return nil, err
+	}
+	auth, err := rlp.NewList(
+		rlp.NewBytes(sig),
+		rlp.NewBytes(encodePubKey(prv.PubKey())),
+		rlp.NewBytes(initNonce),
+		rlp.NewUint(handshakeVersion),
+	).EncodeRLP()
+	if err != nil {
+		return nil, err
+	}
+	authPacket, err := sealHandshake(auth, remote)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(authPacket); err != nil {
+		return nil, err
+	}
+
+	ack, ackPacket, err := readHandshake(conn, prv)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		remoteEphBytes = &rlp.StringItem{}
+		respNonce      = &rlp.StringItem{}
+	)
+	if err := decodeLoose(ack, remoteEphBytes, respNonce); err != nil {
+		return nil, fmt.Errorf("invalid ack message: %w", err)
+	}
+	remoteEph, err := parsePubKey(remoteEphBytes.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if len(respNonce.Bytes()) != 32 {
+		return nil, errors.New("invalid ack message: invalid nonce")
+	}
+	s := deriveSecrets(eph, remoteEph, initNonce, respNonce.Bytes())
+	s.egressMAC = newMACHash(s.mac, respNonce.Bytes(), authPacket)
+	s.ingressMAC = newMACHash(s.mac, initNonce, ackPacket)
+	return newRLPxConn(conn, remote, s)
+}
+
+// rlpxRespond performs the recipient side of the RLPx handshake.
+func rlpxRespond(conn net.Conn, prv *btcec.PrivateKey, timeout time.Duration) (*rlpxConn, error) {
+	if err := conn.SetDeadline(deadline(timeout)); err != nil {
+		return nil, err
+	}
+	defer conn.SetDeadline(time.Time{})
+	eph, respNonce, err := newEphemeral()
+	if err != nil {
+		return nil, err
+	}
+	return respondHandshake(conn, prv, eph, respNonce)
+}
+
+// respondHandshake performs the recipient side of the RLPx handshake using
+// the given ephemeral key and nonce.
+func respondHandshake(conn net.Conn, prv *btcec.PrivateKey, eph *btcec.PrivateKey, respNonce []byte) (*rlpxConn, error) {
+	auth, authPacket, err := readHandshake(conn, prv)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		sig           = &rlp.StringItem{}
+		remoteIDBytes = &rlp.StringItem{}
+		initNonce     = &rlp.StringItem{}
+	)
+	if err := decodeLoose(auth, sig, remoteIDBytes, initNonce); err != nil {
+		return nil, fmt.Errorf("invalid auth message: %w", err)
+	}
+	remote, err := parsePubKey(remoteIDBytes.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	if len(initNonce.Bytes()) != 32 || len(sig.Bytes()) != 65 {
+		return nil, errors.New("invalid auth message")
+	}
+	token := btcec.GenerateSharedSecret(prv, remote)
+	remoteEph, err := recoverCompact(sig.Bytes(), xor(token, initNonce.Bytes()))
+	if err != nil {
+		return nil, err
+	}
+
+	ack, err := rlp.NewList(
+		rlp.NewBytes(encodePubKey(eph.PubKey())),
+		rlp.NewBytes(respNonce),
+		rlp.NewUint(handshakeVersion),
+	).EncodeRLP()
+	if err != nil {
+		return nil, err
+	}
+	ackPacket, err := sealHandshake(ack, remote)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(ackPacket); err != nil {
+		return nil, err
+	}
+	s := deriveSecrets(eph, remoteEph, initNonce.Bytes(), respNonce)
+	s.egressMAC = newMACHash(s.mac, initNonce.Bytes(), ackPacket)
+	s.ingressMAC = newMACHash(s.mac, respNonce, authPacket)
+	return newRLPxConn(conn, remote, s)
+}
+
+// newEphemeral returns a random ephemeral key and nonce for the handshake.
+func newEphemeral() (*btcec.PrivateKey, []byte, error) {
+	eph, err := btcec.NewPrivateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce, err := randomBytes(32)
+	if err != nil {
+		return nil, nil, err
+	}
+	return eph, nonce, nil
+}
+
+func newRLPxConn(conn net.Conn, remote *btcec.PublicKey, s *secrets) (*rlpxConn, error) {
+	encBlock, err := aes.NewCipher(s.aes)
+	if err != nil {
+		return nil, err
+	}
+	macBlock, err := aes.NewCipher(s.mac)
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, aes.BlockSize)
+	return &rlpxConn{
+		conn:       conn,
+		remote:     remote,
+		enc:        cipher.NewCTR(encBlock, iv),
+		dec:        cipher.NewCTR(encBlock, iv),
+		egressMAC:  &frameMAC{cipher: macBlock, hash: s.egressMAC},
+		ingressMAC: &frameMAC{cipher: macBlock, hash: s.ingressMAC},
+	}, nil
+}
+
+// writeMsg writes a single message in one frame.
+func (c *rlpxConn) writeMsg(code uint64, payload []byte) error {
+	ptype, err := rlp.NewUint(code).EncodeRLP()
+	if err != nil {
+		return err
+	}
+	if c.snappy {
+		payload = snappy.Encode(nil, payload)
+	}
+	size := len(ptype) + len(payload)
+	if size > maxFrameSize {
+		return errors.New("message too large")
+	}
+
+	header := make([]byte, 16)
+	header[0], header[1], header[2] = byte(size>>16), byte(size>>8), byte(size)
+	copy(header[3:], zeroHeader)
+	c.enc.XORKeyStream(header, header)
+	headerMAC := c.egressMAC.computeHeader(header)
+
+	frame := make([]byte, roundUp16(size))
+	copy(frame, ptype)
+	copy(frame[len(ptype):], payload)
+	c.enc.XORKeyStream(frame, frame)
+	frameMAC := c.egressMAC.computeFrame(frame)
+
+	buf := make([]byte, 0, len(header)+len(headerMAC)+len(frame)+len(frameMAC))
+	buf = append(buf, header...)
+	buf = append(buf, headerMAC...)
+	buf = append(buf, frame...)
+	buf = append(buf, frameMAC...)
+	_, err = c.conn.Write(buf)
+	return err
+}
+
+// readMsg reads a single message.
+func (c *rlpxConn) readMsg() (code uint64, payload []byte, err error) {
+	header := make([]byte, 32)
+	if _, err := io.ReadFull(c.conn, header); err != nil {
+		return 0, nil, err
+	}
+	if !hmac.Equal(c.ingressMAC.computeHeader(header[:16]), header[16:]) {
+		return 0, nil, errors.New("invalid frame header MAC")
+	}
+	c.dec.XORKeyStream(header[:16], header[:16])
+	size := int(header[0])<<16 | int(header[1])<<8 | int(header[2])
+
+	frame := make([]byte, roundUp16(size)+16)
+	if _, err := io.ReadFull(c.conn, frame); err != nil {
+		return 0, nil, err
+	}
+	frameData, frameMAC := frame[:len(frame)-16], frame[len(frame)-16:]
+	if !hmac.Equal(c.ingressMAC.computeFrame(frameData), frameMAC) {
+		return 0, nil, errors.New("invalid frame MAC")
+	}
+	c.dec.XORKeyStream(frameData, frameData)
+	frameData = frameData[:size]
+
+	ptype := &rlp.UintItem{}
+	n, err := rlp.DecodeTo(frameData, ptype)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid message code: %w", err)
+	}
+	payload = frameData[n:]
+	if c.snappy {
+		l, err := snappy.DecodedLen(payload)
+		if err != nil {
+			return 0, nil, err
+		}
+		if l > maxFrameSize {
+			return 0, nil, errors.New("message too large")
+		}
+		if payload, err = snappy.Decode(nil, payload); err != nil {
+			return 0, nil, err
+		}
+	}
+	return ptype.X, payload, nil
+}
+
+func (c *rlpxConn) close() error {
+	return c.conn.Close()
+}
+
+// frameMAC is the egress or ingress MAC state of the connection.
+type frameMAC struct {
+	cipher cipher.Block
+	hash   hash.Hash
+}
+
+func (m *frameMAC) computeHeader(header []byte) []byte {
+	return m.compute(m.hash.Sum(nil), header)
+}
+
+func (m *frameMAC) computeFrame(frame []byte) []byte {
+	m.hash.Write(frame)
+	seed := m.hash.Sum(nil)
+	return m.compute(seed, seed[:16])
+}
+
+func (m *frameMAC) compute(sum, seed []byte) []byte {
+	buf := make([]byte, aes.BlockSize)
+	m.cipher.Encrypt(buf, sum[:aes.BlockSize])
+	for i := range buf {
+		buf[i] ^= seed[i]
+	}
+	m.hash.Write(buf)
+	return m.hash.Sum(nil)[:16]
+}
+
+// deriveSecrets derives the session secrets from the ephemeral keys and
+// nonces.
+func deriveSecrets(eph *btcec.PrivateKey, remoteEph *btcec.PublicKey, initNonce, respNonce []byte) *secrets {
+	ecdhe := btcec.GenerateSharedSecret(eph, remoteEph)
+	shared := keccak256(ecdhe, keccak256(respNonce, initNonce))
+	aesSecret := keccak256(ecdhe, shared)
+	return &secrets{
+		aes: aesSecret,
+		mac: keccak256(ecdhe, aesSecret),
+	}
+}
+
+func newMACHash(macSecret, nonce, packet []byte) hash.Hash {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(xor(macSecret, nonce))
+	h.Write(packet)
+	return h
+}
+
+// sealHandshake pads and encrypts an EIP-8 handshake message and prepends
+// the size prefix.
+func sealHandshake(msg []byte, remote *btcec.PublicKey) ([]byte, error) {
+	pad, err := randomBytes(mrand.Intn(100) + 100)
+	if err != nil {
+		return nil, err
+	}
+	msg = append(msg, pad...)
+	prefix := make([]byte, 2)
+	binary.BigEndian.PutUint16(prefix, uint16(len(msg)+eciesOverhead))
+	enc, err := eciesEncrypt(remote, msg, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return append(prefix, enc...), nil
+}
+
+// readHandshake reads and decrypts an EIP-8 handshake message. It returns
+// the decrypted message and the whole packet, as read from the connection.
+func readHandshake(r io.Reader, prv *btcec.PrivateKey) (msg, packet []byte, err error) {
+	prefix := make([]byte, 2)
+	if _, err := io.ReadFull(r, prefix); err != nil {
+		return nil, nil, err
+	}
+	size := binary.BigEndian.Uint16(prefix)
+	if size > maxHandshakeSize {
+		return nil, nil, errors.New("handshake message too large")
+	}
+	packet = make([]byte, 2+int(size))
+	copy(packet, prefix)
+	if _, err := io.ReadFull(r, packet[2:]); err != nil {
+		return nil, nil, err
+	}
+	msg, err = eciesDecrypt(prv, packet[2:], prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	return msg, packet, nil
+}
+
+// decodeLoose decodes the leading elements of an RLP list, ignoring any
+// additional elements and the trailing padding.
+func decodeLoose(data []byte, items ...rlp.Item) error {
+	r, _, err := rlp.Decode(data)
+	if err != nil {
+		return err
+	}
+	l, err := r.GetList()
+	if err != nil {
+		return err
+	}
+	if len(l) < len(items) {
+		return errors.New("too few list elements")
+	}
+	for i, item := range items {
+		if err := l[i].DecodeTo(item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signCompact signs the hash and returns the signature in the [R || S || V]
+// format, where V is 0 or 1.
+func signCompact(prv *btcec.PrivateKey, hash []byte) ([]byte, error) {
+	sig, err := btcececdsa.SignCompact(prv, hash, false)
+	if err != nil {
+		return nil, err
+	}
+	return append(sig[1:], sig[0]-27), nil
+}
+
+// recoverCompact recovers the public key from a signature created by
+// signCompact.
+func recoverCompact(sig, hash []byte) (*btcec.PublicKey, error) {
+	compact := append([]byte{sig[64] + 27}, sig[:64]...)
+	pub, _, err := btcececdsa.RecoverCompact(compact, hash)
+	return pub, err
+}
+
+func keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+func xor(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func roundUp16(n int) int {
+	if r := n % 16; r != 0 {
+		return n + 16 - r
+	}
+	return n
+}
+
+func deadline(timeout time.Duration) time.Time {
+	if timeout <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(timeout)
+}
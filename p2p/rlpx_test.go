@@ -0,0 +1,272 @@
+package p2p
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/defiweb/go-rlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test vectors of the RLPx handshake from EIP-8. The auth and ack packets
+// are the EIP-8 encodings of version 4 without additional list elements.
+var (
+	eip8KeyA   = unhex("49a7b37aa6f6645917e7b807e9d1c00d4fa71f18343b0d4122a4d2df64dd6fee")
+	eip8KeyB   = unhex("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	eip8EphA   = unhex("869d6ecf5211f1cc60418a13b9d870b22959d0c16f02bec714c960dd2298a32d")
+	eip8EphB   = unhex("e238eb8e04fee6511ab04c6dd3c89ce097b11f25d584863ac2b6d5b35b1847e4")
+	eip8NonceA = unhex("7e968bba13b6c50e2c4cd7f241cc0d64d1ac25c7f5952df231ac6a2bda8ee5d6")
+	eip8NonceB = unhex("559aead08264d5795d3909718cdd05abd49572e84fe55590eef31a88a08fdffd")
+	eip8Auth   = unhex(`
+		01b304ab7578555167be8154d5cc456f567d5ba302662433674222360f08d5f1534499d3678b513b
+		0fca474f3a514b18e75683032eb63fccb16c156dc6eb2c0b1593f0d84ac74f6e475f1b8d56116b84
+		9634a8c458705bf83a626ea0384d4d7341aae591fae42ce6bd5c850bfe0b999a694a49bbbaf3ef6c
+		da61110601d3b4c02ab6c30437257a6e0117792631a4b47c1d52fc0f8f89caadeb7d02770bf999cc
+		147d2df3b62e1ffb2c9d8c125a3984865356266bca11ce7d3a688663a51d82defaa8aad69da39ab6
+		d5470e81ec5f2a7a47fb865ff7cca21516f9299a07b1bc63ba56c7a1a892112841ca44b6e0034dee
+		70c9adabc15d76a54f443593fafdc3b27af8059703f88928e199cb122362a4b35f62386da7caad09
+		c001edaeb5f8a06d2b26fb6cb93c52a9fca51853b68193916982358fe1e5369e249875bb8d0d0ec3
+		6f917bc5e1eafd5896d46bd61ff23f1a863a8a8dcd54c7b109b771c8e61ec9c8908c733c0263440e
+		2aa067241aaa433f0bb053c7b31a838504b148f570c0ad62837129e547678c5190341e4f1693956c
+		3bf7678318e2d5b5340c9e488eefea198576344afbdf66db5f51204a6961a63ce072c8926c`)
+	eip8Ack = unhex(`
+		01ea0451958701280a56482929d3b0757da8f7fbe5286784beead59d95089c217c9b917788989470
+		b0e330cc6e4fb383c0340ed85fab836ec9fb8a49672712aeabbdfd1e837c1ff4cace34311cd7f4de
+		05d59279e3524ab26ef753a0095637ac88f2b499b9914b5f64e143eae548a1066e14cd2f4bd7f814
+		c4652f11b254f8a2d0191e2f5546fae6055694aed14d906df79ad3b407d94692694e259191cde171
+		ad542fc588fa2b7333313d82a9f887332f1dfc36cea03f831cb9a23fea05b33deb999e85489e645f
+		6aab1872475d488d7bd6c7c120caf28dbfc5d6833888155ed69d34dbdc39c1f299be1057810f34fb
+		e754d021bfca14dc989753d61c413d261934e1a9c67ee060a25eefb54e81a4d14baff922180c395d
+		3f998d70f46f6b58306f969627ae364497e73fc27f6d17ae45a413d322cb8814276be6ddd13b885b
+		201b943213656cde498fa0e9ddc8e0b8f8a53824fbd82254f3e2c17e8eaea009c38b4aa0a3f306e8
+		797db43c25d68e86f262e564086f59a2fc60511c42abfb3057c247a8a8fe4fb3ccbadde17514b7ac
+		8000cdb6a912778426260c47f38919a91f25f4b5ffb455d6aaaf150f7e5529c100ce62d6d92826a7
+		1778d809bdf60232ae21ce8a437eca8223f45ac37f6487452ce626f549b3b5fdee26afd2072e4bc7
+		5833c2464c805246155289f4`)
+
+	// Session secrets derived from the ephemeral keys and nonces above,
+	// and the ingress MAC of the recipient after writing "foo" to it.
+	eip8AES        = unhex("80e8632c05fed6fc2a13b0f8d31a3cf645366239170ea067065aba8e28bac487")
+	eip8MAC        = unhex("2ea74ec5dae199227dff1af715362700e989d889d7a493cb0639691efb8e5f98")
+	eip8FooIngress = unhex("0c7ec6340062cc46f5e9f1e3cf86f8c8c403c5a0964f5df0ebd34a75ddc86db5")
+)
+
+func unhex(s string) []byte {
+	b, err := hex.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func privKey(b []byte) *btcec.PrivateKey {
+	prv, _ := btcec.PrivKeyFromBytes(b)
+	return prv
+}
+
+// assertKeyStream checks that the stream is AES-CTR with the given key and
+// a zero IV.
+func assertKeyStream(t *testing.T, key []byte, stream cipher.Stream) {
+	block, err := aes.NewCipher(key)
+	require.NoError(t, err)
+	want := make([]byte, 32)
+	cipher.NewCTR(block, make([]byte, aes.BlockSize)).XORKeyStream(want, want)
+	got := make([]byte, 32)
+	stream.XORKeyStream(got, got)
+	assert.Equal(t, want, got)
+}
+
+// fakeHash is a hash that ignores written data and always returns the
+// same sum.
+type fakeHash []byte
+
+func (h fakeHash) Write(p []byte) (int, error) { return len(p), nil }
+func (h fakeHash) Sum(in []byte) []byte        { return append(in, h...) }
+func (h fakeHash) Reset()                      {}
+func (h fakeHash) Size() int                   { return len(h) }
+func (h fakeHash) BlockSize() int              { return len(h) }
+
+// bufferConn is a connection that writes to a buffer.
+type bufferConn struct {
+	net.Conn
+	buf bytes.Buffer
+}
+
+func (c *bufferConn) Write(p []byte) (int, error) { return c.buf.Write(p) }
+
+func rlpxPipe(t *testing.T) (initiator, recipient *rlpxConn) {
+	prv1, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	prv2, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+
+	c1, c2 := net.Pipe()
+	errCh := make(chan error, 1)
+	go func() {
+		var err error
+		recipient, err = rlpxRespond(c2, prv2, time.Second)
+		errCh <- err
+	}()
+	initiator, err = rlpxInitiate(c1, prv1, prv2.PubKey(), time.Second)
+	require.NoError(t, err)
+	require.NoError(t, <-errCh)
+	assert.True(t, recipient.remote.IsEqual(prv1.PubKey()))
+	t.Cleanup(func() {
+		initiator.close()
+		recipient.close()
+	})
+	return initiator, recipient
+}
+
+func TestRLPx(t *testing.T) {
+	initiator, recipient := rlpxPipe(t)
+
+	for _, s := range []bool{false, true} {
+		initiator.snappy, recipient.snappy = s, s
+		for _, msg := range [][]byte{{}, {0xc0}, make([]byte, 1000)} {
+			go func(msg []byte) {
+				assert.NoError(t, initiator.writeMsg(0x10, msg))
+			}(msg)
+			code, payload, err := recipient.readMsg()
+			require.NoError(t, err)
+			assert.Equal(t, uint64(0x10), code)
+			assert.True(t, bytes.Equal(msg, payload))
+
+			go func(msg []byte) {
+				assert.NoError(t, recipient.writeMsg(0x02, msg))
+			}(msg)
+			code, payload, err = initiator.readMsg()
+			require.NoError(t, err)
+			assert.Equal(t, uint64(0x02), code)
+			assert.True(t, bytes.Equal(msg, payload))
+		}
+	}
+}
+
+func TestRLPx_EIP8Recipient(t *testing.T) {
+	keyA, keyB := privKey(eip8KeyA), privKey(eip8KeyB)
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	ackCh := make(chan []byte, 1)
+	go func() {
+		_, err := c1.Write(eip8Auth)
+		assert.NoError(t, err)
+		ack, _, err := readHandshake(c1, keyA)
+		assert.NoError(t, err)
+		ackCh <- ack
+	}()
+	conn, err := respondHandshake(c2, keyB, privKey(eip8EphB), eip8NonceB)
+	require.NoError(t, err)
+	assert.True(t, conn.remote.IsEqual(keyA.PubKey()))
+
+	// The ack message contains the ephemeral key and the nonce.
+	var (
+		ephPub  = &rlp.StringItem{}
+		nonce   = &rlp.StringItem{}
+		version = &rlp.UintItem{}
+	)
+	require.NoError(t, decodeLoose(<-ackCh, ephPub, nonce, version))
+	assert.Equal(t, encodePubKey(privKey(eip8EphB).PubKey()), ephPub.Bytes())
+	assert.Equal(t, eip8NonceB, nonce.Bytes())
+	assert.Equal(t, uint64(handshakeVersion), version.X)
+
+	// The ingress MAC is initialized with the auth packet.
+	assertKeyStream(t, eip8AES, conn.enc)
+	conn.ingressMAC.hash.Write([]byte("foo"))
+	assert.Equal(t, eip8FooIngress, conn.ingressMAC.hash.Sum(nil))
+}
+
+func TestRLPx_EIP8Initiator(t *testing.T) {
+	keyA, keyB := privKey(eip8KeyA), privKey(eip8KeyB)
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	authCh := make(chan []byte, 1)
+	go func() {
+		auth, _, err := readHandshake(c2, keyB)
+		assert.NoError(t, err)
+		authCh <- auth
+		_, err = c2.Write(eip8Ack)
+		assert.NoError(t, err)
+	}()
+	conn, err := initiateHandshake(c1, keyA, keyB.PubKey(), privKey(eip8EphA), eip8NonceA)
+	require.NoError(t, err)
+
+	// The auth message contains the static key and the nonce, and its
+	// signature recovers the ephemeral key.
+	var (
+		sig     = &rlp.StringItem{}
+		pub     = &rlp.StringItem{}
+		nonce   = &rlp.StringItem{}
+		version = &rlp.UintItem{}
+	)
+	require.NoError(t, decodeLoose(<-authCh, sig, pub, nonce, version))
+	assert.Equal(t, encodePubKey(keyA.PubKey()), pub.Bytes())
+	assert.Equal(t, eip8NonceA, nonce.Bytes())
+	assert.Equal(t, uint64(handshakeVersion), version.X)
+	token := btcec.GenerateSharedSecret(keyB, keyA.PubKey())
+	ephPub, err := recoverCompact(sig.Bytes(), xor(token, eip8NonceA))
+	require.NoError(t, err)
+	assert.True(t, ephPub.IsEqual(privKey(eip8EphA).PubKey()))
+
+	// The ingress MAC is initialized with the ack packet.
+	assertKeyStream(t, eip8AES, conn.dec)
+	conn.ingressMAC.hash.Write([]byte("foo"))
+	assert.Equal(t, keccak256(xor(eip8MAC, eip8NonceA), eip8Ack, []byte("foo")), conn.ingressMAC.hash.Sum(nil))
+}
+
+func TestDeriveSecrets(t *testing.T) {
+	s := deriveSecrets(privKey(eip8EphB), privKey(eip8EphA).PubKey(), eip8NonceA, eip8NonceB)
+	assert.Equal(t, eip8AES, s.aes)
+	assert.Equal(t, eip8MAC, s.mac)
+
+	// Both sides derive the same secrets.
+	s = deriveSecrets(privKey(eip8EphA), privKey(eip8EphB).PubKey(), eip8NonceA, eip8NonceB)
+	assert.Equal(t, eip8AES, s.aes)
+	assert.Equal(t, eip8MAC, s.mac)
+}
+
+func TestRLPx_Frame(t *testing.T) {
+	// Frame of the message 0x08 with the payload [1, 2, 3, 4], encrypted
+	// with AES and MAC secrets set to keccak256(""). The MAC hash returns
+	// a fixed sum, so the MACs are computed from it.
+	sum := fakeHash(bytes.Repeat([]byte{0x01}, 32))
+	buf := &bufferConn{}
+	conn, err := newRLPxConn(buf, nil, &secrets{
+		aes:        keccak256(),
+		mac:        keccak256(),
+		egressMAC:  sum,
+		ingressMAC: sum,
+	})
+	require.NoError(t, err)
+	require.NoError(t, conn.writeMsg(0x08, []byte{0xc4, 0x01, 0x02, 0x03, 0x04}))
+	assert.Equal(t, unhex(`
+		00828ddae471818bb0bfa6b551d1cb42
+		01010101010101010101010101010101
+		ba628a4ba590cb43f7848f41c4382885
+		01010101010101010101010101010101`), buf.buf.Bytes())
+}
+
+func TestECIES(t *testing.T) {
+	prv, err := btcec.NewPrivateKey()
+	require.NoError(t, err)
+	enc, err := eciesEncrypt(prv.PubKey(), []byte("hello"), []byte{1, 2})
+	require.NoError(t, err)
+	assert.Len(t, enc, 5+eciesOverhead)
+
+	dec, err := eciesDecrypt(prv, enc, []byte{1, 2})
+	require.NoError(t, err)
+	assert.Equal(t, []byte("hello"), dec)
+
+	_, err = eciesDecrypt(prv, enc, []byte{1, 3})
+	assert.Error(t, err)
+}
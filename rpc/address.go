@@ -0,0 +1,211 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// AddressKind is the kind of account an address belongs to.
+type AddressKind uint8
+
+const (
+	EOAAddressKind          AddressKind = iota // EOAAddressKind is an externally owned account without code.
+	ContractAddressKind                        // ContractAddressKind is a contract account.
+	DelegatedEOAAddressKind                    // DelegatedEOAAddressKind is an EOA with an EIP-7702 delegation.
+)
+
+// String implements the fmt.Stringer interface.
+func (k AddressKind) String() string {
+	switch k {
+	case EOAAddressKind:
+		return "eoa"
+	case ContractAddressKind:
+		return "contract"
+	case DelegatedEOAAddressKind:
+		return "delegated-eoa"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(k))
+	}
+}
+
+// ProxyType is the proxy pattern detected for a contract.
+type ProxyType uint8
+
+const (
+	NoProxyType                 ProxyType = iota // NoProxyType means no known proxy pattern was detected.
+	EIP1167ProxyType                             // EIP1167ProxyType is a minimal proxy clone.
+	EIP1967ProxyType                             // EIP1967ProxyType is a proxy using the EIP-1967 implementation slot.
+	EIP1967BeaconProxyType                       // EIP1967BeaconProxyType is a proxy using the EIP-1967 beacon slot.
+	EIP1822ProxyType                             // EIP1822ProxyType is a UUPS proxy using the EIP-1822 PROXIABLE slot.
+	OpenZeppelinLegacyProxyType                  // OpenZeppelinLegacyProxyType is a proxy using the pre-EIP-1967 ZeppelinOS slot.
+)
+
+// String implements the fmt.Stringer interface.
+func (p ProxyType) String() string {
+	switch p {
+	case NoProxyType:
+		return "none"
+	case EIP1167ProxyType:
+		return "eip-1167"
+	case EIP1967ProxyType:
+		return "eip-1967"
+	case EIP1967BeaconProxyType:
+		return "eip-1967-beacon"
+	case EIP1822ProxyType:
+		return "eip-1822"
+	case OpenZeppelinLegacyProxyType:
+		return "openzeppelin-legacy"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(p))
+	}
+}
+
+// AddressInfo describes an address classified by Client.AddressKind.
+type AddressInfo struct {
+	Kind AddressKind // Kind is the kind of the account.
+
+	// Delegate is the EIP-7702 delegation target, only set for
+	// DelegatedEOAAddressKind.
+	Delegate *types.Address
+
+	// Proxy fields, only set for ContractAddressKind:
+	Proxy          ProxyType      // Proxy is the detected proxy pattern.
+	Implementation *types.Address // Implementation is the implementation address, if it could be determined.
+	Beacon         *types.Address // Beacon is the beacon address for EIP1967BeaconProxyType.
+}
+
+var (
+	// eip1167Prefix and eip1167Suffix surround the implementation address in
+	// the EIP-1167 minimal proxy bytecode.
+	eip1167Prefix = []byte{0x36, 0x3d, 0x3d, 0x37, 0x3d, 0x3d, 0x3d, 0x36, 0x3d, 0x73}
+	eip1167Suffix = []byte{0x5a, 0xf4, 0x3d, 0x82, 0x80, 0x3e, 0x90, 0x3d, 0x91, 0x60, 0x2b, 0x57, 0xfd, 0x5b, 0xf3}
+
+	// bytes32(uint256(keccak256("eip1967.proxy.implementation")) - 1)
+	eip1967ImplementationSlot = types.MustHashFromHex("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc", types.PadNone)
+
+	// bytes32(uint256(keccak256("eip1967.proxy.beacon")) - 1)
+	eip1967BeaconSlot = types.MustHashFromHex("0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50", types.PadNone)
+
+	// keccak256("PROXIABLE")
+	eip1822ProxiableSlot = types.MustHashFromHex("0xc5f16f0fcc639fa48a6947836d9850f504798523bf8c9a3a87d5876cf622bcf7", types.PadNone)
+
+	// keccak256("org.zeppelinos.proxy.implementation")
+	openZeppelinLegacySlot = types.MustHashFromHex("0x7050c9e0f4ca769c69bd3a8ef740bc37934f8e2c036e5a723fd8ee048ed3f8c3", types.PadNone)
+
+	// implementation() selector used by EIP-1967 beacons.
+	beaconImplementationSelector = []byte{0x5c, 0x60, 0xda, 0x1b}
+)
+
+// AddressKind classifies the given address as an EOA, a contract or an EOA
+// with an EIP-7702 delegation, using the code at the latest block.
+//
+// For contracts, it additionally tries to detect common proxy patterns
+// (EIP-1167, EIP-1967, EIP-1967 beacon, EIP-1822 and the legacy ZeppelinOS
+// slot) and resolve the implementation address. Proxy detection is
+// heuristic: a contract that is not a proxy but happens to use one of the
+// well-known storage slots is reported as a proxy.
+func (c *Client) AddressKind(ctx context.Context, addr types.Address) (*AddressInfo, error) {
+	code, err := c.GetCode(ctx, addr, types.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if len(code) == 0 {
+		return &AddressInfo{Kind: EOAAddressKind}, nil
+	}
+	if delegate, ok := types.DelegationFromCode(code); ok {
+		return &AddressInfo{Kind: DelegatedEOAAddressKind, Delegate: &delegate}, nil
+	}
+	info := &AddressInfo{Kind: ContractAddressKind}
+	if impl, ok := eip1167Implementation(code); ok {
+		info.Proxy = EIP1167ProxyType
+		info.Implementation = &impl
+		return info, nil
+	}
+	for _, s := range []struct {
+		slot  types.Hash
+		proxy ProxyType
+	}{
+		{slot: eip1967ImplementationSlot, proxy: EIP1967ProxyType},
+		{slot: eip1967BeaconSlot, proxy: EIP1967BeaconProxyType},
+		{slot: eip1822ProxiableSlot, proxy: EIP1822ProxyType},
+		{slot: openZeppelinLegacySlot, proxy: OpenZeppelinLegacyProxyType},
+	} {
+		slotAddr, err := c.storageAddress(ctx, addr, s.slot)
+		if err != nil {
+			return nil, err
+		}
+		if slotAddr == nil {
+			continue
+		}
+		info.Proxy = s.proxy
+		if s.proxy != EIP1967BeaconProxyType {
+			info.Implementation = slotAddr
+			return info, nil
+		}
+		info.Beacon = slotAddr
+		res, _, err := c.Call(ctx, &types.Call{To: slotAddr, Input: beaconImplementationSelector}, types.LatestBlockNumber)
+		if err == nil && len(res) == types.HashLength {
+			if impl := wordToAddress(res); impl != nil {
+				info.Implementation = impl
+			}
+		}
+		return info, nil
+	}
+	return info, nil
+}
+
+// Delegation returns the current EIP-7702 delegation target of the given
+// address. It returns nil if the address has no delegation.
+func (c *Client) Delegation(ctx context.Context, addr types.Address, block types.BlockNumber) (*types.Address, error) {
+	code, err := c.GetCode(ctx, addr, block)
+	if err != nil {
+		return nil, err
+	}
+	if delegate, ok := types.DelegationFromCode(code); ok {
+		return &delegate, nil
+	}
+	return nil, nil
+}
+
+// storageAddress reads an address stored in the given storage slot. It returns
+// nil if the slot is empty.
+func (c *Client) storageAddress(ctx context.Context, addr types.Address, slot types.Hash) (*types.Address, error) {
+	val, err := c.GetStorageAt(ctx, addr, slot, types.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return wordToAddress(val.Bytes()), nil
+}
+
+// eip1167Implementation returns the implementation address if the code is an
+// EIP-1167 minimal proxy.
+func eip1167Implementation(code []byte) (types.Address, bool) {
+	if len(code) != len(eip1167Prefix)+types.AddressLength+len(eip1167Suffix) {
+		return types.Address{}, false
+	}
+	if !bytes.HasPrefix(code, eip1167Prefix) || !bytes.HasSuffix(code, eip1167Suffix) {
+		return types.Address{}, false
+	}
+	return types.MustAddressFromBytes(code[len(eip1167Prefix) : len(eip1167Prefix)+types.AddressLength]), true
+}
+
+// wordToAddress converts a 32-byte word to an address. It returns nil if the
+// word is zero or the upper 12 bytes are not zero.
+func wordToAddress(word []byte) *types.Address {
+	if len(word) != types.HashLength {
+		return nil
+	}
+	for _, b := range word[:types.HashLength-types.AddressLength] {
+		if b != 0 {
+			return nil
+		}
+	}
+	addr := types.MustAddressFromBytes(word[types.HashLength-types.AddressLength:])
+	if addr.IsZero() {
+		return nil
+	}
+	return &addr
+}
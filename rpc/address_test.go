@@ -0,0 +1,88 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+func TestClient_AddressKind(t *testing.T) {
+	addr := types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+	impl := types.MustAddressFromHex("0x2222222222222222222222222222222222222222")
+	beacon := types.MustAddressFromHex("0x3333333333333333333333333333333333333333")
+	implWord := types.MustHashFromBytes(impl.Bytes(), types.PadLeft)
+
+	tests := []struct {
+		name    string
+		code    string
+		storage map[types.Hash]types.Hash
+		call    map[types.Address]types.Bytes
+		want    AddressInfo
+	}{
+		{
+			name: "eoa",
+			code: "0x",
+			want: AddressInfo{Kind: EOAAddressKind},
+		},
+		{
+			name: "delegated eoa",
+			code: "0xef01002222222222222222222222222222222222222222",
+			want: AddressInfo{Kind: DelegatedEOAAddressKind, Delegate: &impl},
+		},
+		{
+			name: "contract",
+			code: "0x6080604052",
+			want: AddressInfo{Kind: ContractAddressKind},
+		},
+		{
+			name: "eip-1167",
+			code: "0x363d3d373d3d3d363d732222222222222222222222222222222222222222" + "5af43d82803e903d91602b57fd5bf3",
+			want: AddressInfo{Kind: ContractAddressKind, Proxy: EIP1167ProxyType, Implementation: &impl},
+		},
+		{
+			name:    "eip-1967",
+			code:    "0x6080604052",
+			storage: map[types.Hash]types.Hash{eip1967ImplementationSlot: implWord},
+			want:    AddressInfo{Kind: ContractAddressKind, Proxy: EIP1967ProxyType, Implementation: &impl},
+		},
+		{
+			name:    "eip-1967 beacon",
+			code:    "0x6080604052",
+			storage: map[types.Hash]types.Hash{eip1967BeaconSlot: types.MustHashFromBytes(beacon.Bytes(), types.PadLeft)},
+			call:    map[types.Address]types.Bytes{beacon: implWord.Bytes()},
+			want:    AddressInfo{Kind: ContractAddressKind, Proxy: EIP1967BeaconProxyType, Implementation: &impl, Beacon: &beacon},
+		},
+		{
+			name:    "eip-1822",
+			code:    "0x6080604052",
+			storage: map[types.Hash]types.Hash{eip1822ProxiableSlot: implWord},
+			want:    AddressInfo{Kind: ContractAddressKind, Proxy: EIP1822ProxyType, Implementation: &impl},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+				switch method {
+				case "eth_getCode":
+					return tt.code, nil
+				case "eth_getStorageAt":
+					return tt.storage[args[1].(types.Hash)], nil
+				case "eth_call":
+					return tt.call[*args[0].(*types.Call).To], nil
+				}
+				t.Fatalf("unexpected method %s", method)
+				return nil, nil
+			}}
+			client, err := NewClient(WithTransport(mock))
+			require.NoError(t, err)
+
+			info, err := client.AddressKind(context.Background(), addr)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *info)
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// defaultBalanceHistoryStep is the default sampling interval of
+// Client.BalanceHistory.
+const defaultBalanceHistoryStep = 1000
+
+// BalanceHistoryOptions is the options for Client.BalanceHistory.
+type BalanceHistoryOptions struct {
+	// Step is the number of blocks between balance samples. Intervals in
+	// which the balance changed are bisected to find the exact blocks of
+	// the changes. The default is 1000 blocks.
+	//
+	// Changes that cancel each other out within a single interval, e.g.
+	// receiving and sending the same amount, cannot be detected from the
+	// balances alone. A smaller step reduces the risk of missing them, at
+	// the cost of more requests.
+	Step uint64
+
+	// CheckNonce enables sampling of the account nonce in addition to its
+	// balance. Intervals in which the nonce changed are bisected even if the
+	// balance did not change, which detects offsetting changes caused by
+	// transactions sent from the account.
+	CheckNonce bool
+
+	// Timestamps enables fetching of block timestamps for every point of
+	// the history.
+	Timestamps bool
+}
+
+// BalancePoint is a point of the balance history returned by
+// Client.BalanceHistory.
+type BalancePoint struct {
+	Block   uint64    // Block is the block number at which the balance changed.
+	Time    time.Time // Time is the block timestamp, if requested.
+	Balance *big.Int  // Balance is the balance after the block.
+	Change  *big.Int  // Change is the difference from the previous point, may be negative.
+}
+
+// balanceSample is the state of an account at a given block.
+type balanceSample struct {
+	balance *big.Int
+	nonce   uint64
+}
+
+// BalanceHistory reconstructs the ETH balance history of the address in the
+// given block range, inclusive. The first point is the balance at the
+// first block of the range, with a zero change. Every other point is a
+// block in which the balance changed.
+//
+// The history is reconstructed from balance queries, so it requires a node
+// that keeps the historical state for the whole range, e.g. an archive node.
+func (c *Client) BalanceHistory(ctx context.Context, addr types.Address, from, to uint64, opts BalanceHistoryOptions) ([]BalancePoint, error) {
+	if from > to {
+		return nil, fmt.Errorf("rpc client: invalid block range %d-%d", from, to)
+	}
+	if opts.Step == 0 {
+		opts.Step = defaultBalanceHistoryStep
+	}
+	first, err := c.balanceSample(ctx, addr, from, opts.CheckNonce)
+	if err != nil {
+		return nil, err
+	}
+	points := []BalancePoint{{Block: from, Balance: first.balance, Change: new(big.Int)}}
+	prev := first
+	for lo := from; lo < to; {
+		hi := to
+		if to-lo > opts.Step {
+			hi = lo + opts.Step
+		}
+		next, err := c.balanceSample(ctx, addr, hi, opts.CheckNonce)
+		if err != nil {
+			return nil, err
+		}
+		if points, err = c.bisectBalance(ctx, addr, lo, prev, hi, next, opts.CheckNonce, points); err != nil {
+			return nil, err
+		}
+		lo, prev = hi, next
+	}
+	if opts.Timestamps {
+		for i := range points {
+			block, err := c.BlockByNumber(ctx, types.BlockNumberFromUint64(points[i].Block), false)
+			if err != nil {
+				return nil, err
+			}
+			points[i].Time = block.Timestamp
+		}
+	}
+	return points, nil
+}
+
+// bisectBalance appends to points every block in the range (lo, hi] in
+// which the balance changed.
+func (c *Client) bisectBalance(ctx context.Context, addr types.Address, lo uint64, loSample balanceSample, hi uint64, hiSample balanceSample, checkNonce bool, points []BalancePoint) ([]BalancePoint, error) {
+	if loSample.balance.Cmp(hiSample.balance) == 0 && loSample.nonce == hiSample.nonce {
+		return points, nil
+	}
+	if hi-lo == 1 {
+		if loSample.balance.Cmp(hiSample.balance) == 0 {
+			return points, nil
+		}
+		return append(points, BalancePoint{
+			Block:   hi,
+			Balance: hiSample.balance,
+			Change:  new(big.Int).Sub(hiSample.balance, loSample.balance),
+		}), nil
+	}
+	mid := lo + (hi-lo)/2
+	midSample, err := c.balanceSample(ctx, addr, mid, checkNonce)
+	if err != nil {
+		return nil, err
+	}
+	if points, err = c.bisectBalance(ctx, addr, lo, loSample, mid, midSample, checkNonce, points); err != nil {
+		return nil, err
+	}
+	return c.bisectBalance(ctx, addr, mid, midSample, hi, hiSample, checkNonce, points)
+}
+
+// balanceSample returns the balance and, optionally, the nonce of the
+// address at the given block.
+func (c *Client) balanceSample(ctx context.Context, addr types.Address, block uint64, checkNonce bool) (balanceSample, error) {
+	var (
+		s   balanceSample
+		err error
+	)
+	s.balance, err = c.GetBalance(ctx, addr, types.BlockNumberFromUint64(block))
+	if err != nil {
+		return s, err
+	}
+	if checkNonce {
+		s.nonce, err = c.GetTransactionCount(ctx, addr, types.BlockNumberFromUint64(block))
+		if err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
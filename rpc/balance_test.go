@@ -0,0 +1,89 @@
+package rpc
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+func TestClient_BalanceHistory(t *testing.T) {
+	// Balance changes at blocks 3 and 7, and offsetting changes caused by
+	// a transaction sent at block 13.
+	changes := map[uint64]int64{3: 100, 7: -40, 12: 5, 13: -5}
+	state := func(block uint64) (balance int64, nonce uint64) {
+		balance = 1000
+		for b, c := range changes {
+			if b <= block {
+				balance += c
+			}
+		}
+		if block >= 13 {
+			nonce = 1
+		}
+		return
+	}
+	var calls int
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		calls++
+		switch method {
+		case "eth_getBalance":
+			balance, _ := state(blockArg(args[1]))
+			return types.NumberFromBigInt(big.NewInt(balance)), nil
+		case "eth_getTransactionCount":
+			_, nonce := state(blockArg(args[1]))
+			return types.NumberFromUint64(nonce), nil
+		case "eth_getBlockByNumber":
+			number := blockArg(args[0])
+			return map[string]any{
+				"number":    types.NumberFromUint64(number),
+				"timestamp": types.NumberFromUint64(1000 + number*12),
+			}, nil
+		}
+		t.Fatalf("unexpected call %s", method)
+		return nil, nil
+	}}
+	client, err := NewClient(WithTransport(mock))
+	require.NoError(t, err)
+	addr := types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+
+	t.Run("balance only", func(t *testing.T) {
+		calls = 0
+		points, err := client.BalanceHistory(context.Background(), addr, 0, 15, BalanceHistoryOptions{Step: 5})
+		require.NoError(t, err)
+		require.Len(t, points, 3)
+		assert.Equal(t, BalancePoint{Block: 0, Balance: big.NewInt(1000), Change: big.NewInt(0)}, points[0])
+		assert.Equal(t, BalancePoint{Block: 3, Balance: big.NewInt(1100), Change: big.NewInt(100)}, points[1])
+		assert.Equal(t, BalancePoint{Block: 7, Balance: big.NewInt(1060), Change: big.NewInt(-40)}, points[2])
+		assert.Less(t, calls, 16)
+	})
+	t.Run("check nonce", func(t *testing.T) {
+		points, err := client.BalanceHistory(context.Background(), addr, 0, 15, BalanceHistoryOptions{
+			Step:       5,
+			CheckNonce: true,
+			Timestamps: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, points, 5)
+		assert.Equal(t, uint64(12), points[3].Block)
+		assert.Equal(t, big.NewInt(5), points[3].Change)
+		assert.Equal(t, uint64(13), points[4].Block)
+		assert.Equal(t, big.NewInt(-5), points[4].Change)
+		assert.Equal(t, big.NewInt(1060), points[4].Balance)
+		assert.Equal(t, time.Unix(1000+13*12, 0), points[4].Time)
+	})
+	t.Run("invalid range", func(t *testing.T) {
+		_, err := client.BalanceHistory(context.Background(), addr, 2, 1, BalanceHistoryOptions{})
+		assert.Error(t, err)
+	})
+}
+
+func blockArg(arg any) uint64 {
+	number := arg.(types.BlockNumber)
+	return number.Big().Uint64()
+}
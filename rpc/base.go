@@ -1,12 +1,14 @@
 package rpc
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
 
+	"github.com/defiweb/go-eth/jsoncodec"
 	"github.com/defiweb/go-eth/rpc/transport"
 	"github.com/defiweb/go-eth/types"
 )
@@ -55,8 +57,15 @@ func (c *baseClient) ProtocolVersion(ctx context.Context) (uint64, error) {
 
 // Syncing implements the RPC interface.
 func (c *baseClient) Syncing(ctx context.Context) (*types.SyncStatus, error) {
+	var raw json.RawMessage
+	if err := c.transport.Call(ctx, &raw, "eth_syncing"); err != nil {
+		return nil, err
+	}
+	if string(bytes.TrimSpace(raw)) == "false" {
+		return nil, nil
+	}
 	var res types.SyncStatus
-	if err := c.transport.Call(ctx, &res, "eth_syncing"); err != nil {
+	if err := jsoncodec.Unmarshal(raw, &res); err != nil {
 		return nil, err
 	}
 	return &res, nil
@@ -83,6 +92,15 @@ func (c *baseClient) ChainID(ctx context.Context) (uint64, error) {
 	return res.Big().Uint64(), nil
 }
 
+// Config implements the RPC interface.
+func (c *baseClient) Config(ctx context.Context) (*types.ChainConfig, error) {
+	var res types.ChainConfig
+	if err := c.transport.Call(ctx, &res, "eth_config"); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
 // GasPrice implements the RPC interface.
 func (c *baseClient) GasPrice(ctx context.Context) (*big.Int, error) {
 	var res types.Number
@@ -128,6 +146,18 @@ func (c *baseClient) GetStorageAt(ctx context.Context, account types.Address, ke
 	return &res, nil
 }
 
+// GetProof implements the RPC interface.
+func (c *baseClient) GetProof(ctx context.Context, account types.Address, keys []types.Hash, block types.BlockNumber) (*types.AccountProof, error) {
+	if keys == nil {
+		keys = []types.Hash{}
+	}
+	var res types.AccountProof
+	if err := c.transport.Call(ctx, &res, "eth_getProof", account, keys, block); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
 // GetTransactionCount implements the RPC interface.
 func (c *baseClient) GetTransactionCount(ctx context.Context, account types.Address, block types.BlockNumber) (uint64, error) {
 	var res types.Number
@@ -266,58 +296,89 @@ func (c *baseClient) EstimateGas(ctx context.Context, call *types.Call, block ty
 	return res.Big().Uint64(), call, nil
 }
 
+// estimateGasWithOverride performs eth_estimateGas RPC call with the state
+// override as the third parameter.
+func (c *baseClient) estimateGasWithOverride(ctx context.Context, call *types.Call, block types.BlockNumber, override types.StateOverride) (uint64, error) {
+	var res types.Number
+	if err := c.transport.Call(ctx, &res, "eth_estimateGas", call, block, override); err != nil {
+		return 0, err
+	}
+	if !res.Big().IsUint64() {
+		return 0, errors.New("gas estimate is too big")
+	}
+	return res.Big().Uint64(), nil
+}
+
 // BlockByHash implements the RPC interface.
 func (c *baseClient) BlockByHash(ctx context.Context, hash types.Hash, full bool) (*types.Block, error) {
-	var res types.Block
+	var res *types.Block
 	if err := c.transport.Call(ctx, &res, "eth_getBlockByHash", hash, full); err != nil {
 		return nil, err
 	}
-	return &res, nil
+	if res == nil {
+		return nil, fmt.Errorf("%w: block %s", ErrNotFound, hash)
+	}
+	return res, nil
 }
 
 // BlockByNumber implements the RPC interface.
 func (c *baseClient) BlockByNumber(ctx context.Context, number types.BlockNumber, full bool) (*types.Block, error) {
-	var res types.Block
+	var res *types.Block
 	if err := c.transport.Call(ctx, &res, "eth_getBlockByNumber", number, full); err != nil {
 		return nil, err
 	}
-	return &res, nil
+	if res == nil {
+		return nil, fmt.Errorf("%w: block %s", ErrNotFound, number.String())
+	}
+	return res, nil
 }
 
 // GetTransactionByHash implements the RPC interface.
 func (c *baseClient) GetTransactionByHash(ctx context.Context, hash types.Hash) (*types.OnChainTransaction, error) {
-	var res types.OnChainTransaction
+	var res *types.OnChainTransaction
 	if err := c.transport.Call(ctx, &res, "eth_getTransactionByHash", hash); err != nil {
 		return nil, err
 	}
-	return &res, nil
+	if res == nil {
+		return nil, fmt.Errorf("%w: transaction %s", ErrNotFound, hash)
+	}
+	return res, nil
 }
 
 // GetTransactionByBlockHashAndIndex implements the RPC interface.
 func (c *baseClient) GetTransactionByBlockHashAndIndex(ctx context.Context, hash types.Hash, index uint64) (*types.OnChainTransaction, error) {
-	var res types.OnChainTransaction
+	var res *types.OnChainTransaction
 	if err := c.transport.Call(ctx, &res, "eth_getTransactionByBlockHashAndIndex", hash, types.NumberFromUint64(index)); err != nil {
 		return nil, err
 	}
-	return &res, nil
+	if res == nil {
+		return nil, fmt.Errorf("%w: transaction %d in block %s", ErrNotFound, index, hash)
+	}
+	return res, nil
 }
 
 // GetTransactionByBlockNumberAndIndex implements the RPC interface.
 func (c *baseClient) GetTransactionByBlockNumberAndIndex(ctx context.Context, number types.BlockNumber, index uint64) (*types.OnChainTransaction, error) {
-	var res types.OnChainTransaction
+	var res *types.OnChainTransaction
 	if err := c.transport.Call(ctx, &res, "eth_getTransactionByBlockNumberAndIndex", number, types.NumberFromUint64(index)); err != nil {
 		return nil, err
 	}
-	return &res, nil
+	if res == nil {
+		return nil, fmt.Errorf("%w: transaction %d in block %s", ErrNotFound, index, number.String())
+	}
+	return res, nil
 }
 
 // GetTransactionReceipt implements the RPC interface.
 func (c *baseClient) GetTransactionReceipt(ctx context.Context, hash types.Hash) (*types.TransactionReceipt, error) {
-	var res types.TransactionReceipt
+	var res *types.TransactionReceipt
 	if err := c.transport.Call(ctx, &res, "eth_getTransactionReceipt", hash); err != nil {
 		return nil, err
 	}
-	return &res, nil
+	if res == nil {
+		return nil, fmt.Errorf("%w: receipt of transaction %s", ErrNotFound, hash)
+	}
+	return res, nil
 }
 
 // GetBlockReceipts implements the RPC interface.
@@ -326,29 +387,41 @@ func (c *baseClient) GetBlockReceipts(ctx context.Context, block types.BlockNumb
 	if err := c.transport.Call(ctx, &res, "eth_getBlockReceipts", block); err != nil {
 		return nil, err
 	}
+	if res == nil {
+		return nil, fmt.Errorf("%w: receipts of block %s", ErrNotFound, block.String())
+	}
 	return res, nil
 }
 
 // GetUncleByBlockHashAndIndex implements the RPC interface.
 func (c *baseClient) GetUncleByBlockHashAndIndex(ctx context.Context, hash types.Hash, index uint64) (*types.Block, error) {
-	var res types.Block
+	var res *types.Block
 	if err := c.transport.Call(ctx, &res, "eth_getUncleByBlockHashAndIndex", hash, types.NumberFromUint64(index)); err != nil {
 		return nil, err
 	}
-	return &res, nil
+	if res == nil {
+		return nil, fmt.Errorf("%w: uncle %d of block %s", ErrNotFound, index, hash)
+	}
+	return res, nil
 }
 
 // GetUncleByBlockNumberAndIndex implements the RPC interface.
 func (c *baseClient) GetUncleByBlockNumberAndIndex(ctx context.Context, number types.BlockNumber, index uint64) (*types.Block, error) {
-	var res types.Block
+	var res *types.Block
 	if err := c.transport.Call(ctx, &res, "eth_getUncleByBlockNumberAndIndex", number, types.NumberFromUint64(index)); err != nil {
 		return nil, err
 	}
-	return &res, nil
+	if res == nil {
+		return nil, fmt.Errorf("%w: uncle %d of block %s", ErrNotFound, index, number.String())
+	}
+	return res, nil
 }
 
 // NewFilter implements the RPC interface.
 func (c *baseClient) NewFilter(ctx context.Context, query *types.FilterLogsQuery) (*big.Int, error) {
+	if err := query.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid filter query: %w", err)
+	}
 	var res *types.Number
 	if err := c.transport.Call(ctx, &res, "eth_newFilter", query); err != nil {
 		return nil, err
@@ -413,6 +486,9 @@ func (c *baseClient) GetBlockFilterChanges(ctx context.Context, id *big.Int) ([]
 
 // GetLogs implements the RPC interface.
 func (c *baseClient) GetLogs(ctx context.Context, query *types.FilterLogsQuery) ([]types.Log, error) {
+	if err := query.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid filter query: %w", err)
+	}
 	var res []types.Log
 	if err := c.transport.Call(ctx, &res, "eth_getLogs", query); err != nil {
 		return nil, err
@@ -420,6 +496,14 @@ func (c *baseClient) GetLogs(ctx context.Context, query *types.FilterLogsQuery)
 	return res, nil
 }
 
+// StreamLogs implements the RPC interface.
+func (c *baseClient) StreamLogs(ctx context.Context, query *types.FilterLogsQuery, fn func(types.Log) error) error {
+	if err := query.Validate(); err != nil {
+		return fmt.Errorf("invalid filter query: %w", err)
+	}
+	return c.transport.Call(ctx, &logStream{fn: fn}, "eth_getLogs", query)
+}
+
 // MaxPriorityFeePerGas implements the RPC interface.
 func (c *baseClient) MaxPriorityFeePerGas(ctx context.Context) (*big.Int, error) {
 	var res types.Number
@@ -429,56 +513,56 @@ func (c *baseClient) MaxPriorityFeePerGas(ctx context.Context) (*big.Int, error)
 	return res.Big(), nil
 }
 
+// FeeHistory implements the RPC interface.
+func (c *baseClient) FeeHistory(ctx context.Context, blockCount uint64, newestBlock types.BlockNumber, rewardPercentiles []float64) (*types.FeeHistory, error) {
+	if rewardPercentiles == nil {
+		rewardPercentiles = []float64{}
+	}
+	var res types.FeeHistory
+	if err := c.transport.Call(ctx, &res, "eth_feeHistory", types.NumberFromUint64(blockCount), newestBlock, rewardPercentiles); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
 // SubscribeLogs implements the RPC interface.
 func (c *baseClient) SubscribeLogs(ctx context.Context, query *types.FilterLogsQuery) (<-chan types.Log, error) {
-	return subscribe[types.Log](ctx, c.transport, "logs", query)
+	sub, err := c.LogsSubscription(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return sub.Chan(), nil
 }
 
 // SubscribeNewHeads implements the RPC interface.
 func (c *baseClient) SubscribeNewHeads(ctx context.Context) (<-chan types.Block, error) {
-	return subscribe[types.Block](ctx, c.transport, "newHeads")
+	sub, err := c.NewHeadsSubscription(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return sub.Chan(), nil
 }
 
 // SubscribeNewPendingTransactions implements the RPC interface.
 func (c *baseClient) SubscribeNewPendingTransactions(ctx context.Context) (<-chan types.Hash, error) {
-	return subscribe[types.Hash](ctx, c.transport, "newPendingTransactions")
-}
-
-// subscribe creates a subscription to the given method and returns a channel
-// that will receive the subscription messages. The messages are unmarshalled
-// to the T type. The subscription is unsubscribed and channel closed when the
-// context is cancelled.
-func subscribe[T any](ctx context.Context, t transport.Transport, method string, params ...any) (chan T, error) {
-	st, ok := t.(transport.SubscriptionTransport)
-	if !ok {
-		return nil, errors.New("transport does not support subscriptions")
-	}
-	rawCh, subID, err := st.Subscribe(ctx, method, params...)
+	sub, err := c.NewPendingTransactionsSubscription(ctx)
 	if err != nil {
 		return nil, err
 	}
-	msgCh := make(chan T)
-	go subscriptionRoutine(ctx, st, subID, rawCh, msgCh)
-	return msgCh, nil
-}
-
-//nolint:errcheck
-func subscriptionRoutine[T any](ctx context.Context, t transport.SubscriptionTransport, subID string, rawCh chan json.RawMessage, msgCh chan T) {
-	defer close(msgCh)
-	defer t.Unsubscribe(ctx, subID)
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case raw, ok := <-rawCh:
-			if !ok {
-				return
-			}
-			var msg T
-			if err := json.Unmarshal(raw, &msg); err != nil {
-				continue
-			}
-			msgCh <- msg
-		}
-	}
+	return sub.Chan(), nil
+}
+
+// LogsSubscription implements the RPC interface.
+func (c *baseClient) LogsSubscription(ctx context.Context, query *types.FilterLogsQuery) (*Subscription[types.Log], error) {
+	return subscribe[types.Log](ctx, c.transport, "logs", query)
+}
+
+// NewHeadsSubscription implements the RPC interface.
+func (c *baseClient) NewHeadsSubscription(ctx context.Context) (*Subscription[types.Block], error) {
+	return subscribe[types.Block](ctx, c.transport, "newHeads")
+}
+
+// NewPendingTransactionsSubscription implements the RPC interface.
+func (c *baseClient) NewPendingTransactionsSubscription(ctx context.Context) (*Subscription[types.Hash], error) {
+	return subscribe[types.Hash](ctx, c.transport, "newPendingTransactions")
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"math/big"
 	"net/http"
@@ -14,6 +15,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/defiweb/go-eth/hexutil"
+	"github.com/defiweb/go-eth/rpc/transport"
 	"github.com/defiweb/go-eth/types"
 )
 
@@ -185,6 +187,20 @@ func TestBaseClient_Syncing(t *testing.T) {
 	}, syncing)
 }
 
+func TestBaseClient_Syncing_False(t *testing.T) {
+	httpMock := newHTTPMock()
+	client := &baseClient{transport: httpMock}
+
+	httpMock.ResponseMock = &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewBufferString(`{"jsonrpc": "2.0", "id": 1, "result": false}`)),
+	}
+
+	syncing, err := client.Syncing(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, syncing)
+}
+
 const mockNetworkIDRequest = `
 	{
 	  "jsonrpc": "2.0",
@@ -249,6 +265,86 @@ func TestBaseClient_ChainID(t *testing.T) {
 	assert.Equal(t, uint64(1), chainID)
 }
 
+const mockConfigRequest = `
+	{
+	  "jsonrpc": "2.0",
+	  "id": 1,
+	  "method": "eth_config",
+	  "params": []
+	}
+`
+
+const mockConfigResponse = `
+	{
+	  "jsonrpc": "2.0",
+	  "id": 1,
+	  "result": {
+	    "current": {
+	      "activationTime": 1710338135,
+	      "blobSchedule": {
+	        "baseFeeUpdateFraction": 3338477,
+	        "max": 6,
+	        "target": 3
+	      },
+	      "chainId": "0x1",
+	      "forkId": "0x9f3d2254",
+	      "precompiles": {
+	        "ECREC": "0x0000000000000000000000000000000000000001"
+	      },
+	      "systemContracts": {
+	        "BEACON_ROOTS_ADDRESS": "0x000f3df6d732807ef1319fb7b8bb8522d0beac02"
+	      }
+	    },
+	    "next": {
+	      "activationTime": 1746612311,
+	      "blobSchedule": {
+	        "baseFeeUpdateFraction": 5007716,
+	        "max": 9,
+	        "target": 6
+	      },
+	      "chainId": "0x1",
+	      "forkId": "0xc376cf8b",
+	      "precompiles": {
+	        "ECREC": "0x0000000000000000000000000000000000000001",
+	        "BLS12_G1ADD": "0x000000000000000000000000000000000000000b"
+	      },
+	      "systemContracts": {
+	        "BEACON_ROOTS_ADDRESS": "0x000f3df6d732807ef1319fb7b8bb8522d0beac02"
+	      }
+	    },
+	    "last": null
+	  }
+	}
+`
+
+func TestBaseClient_Config(t *testing.T) {
+	httpMock := newHTTPMock()
+	client := &baseClient{transport: httpMock}
+
+	httpMock.ResponseMock = &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewBufferString(mockConfigResponse)),
+	}
+
+	config, err := client.Config(context.Background())
+	require.NoError(t, err)
+	assert.JSONEq(t, mockConfigRequest, readBody(httpMock.Request))
+	require.NotNil(t, config.Current)
+	require.NotNil(t, config.Next)
+	assert.Nil(t, config.Last)
+	assert.Equal(t, uint64(1710338135), config.Current.ActivationTime)
+	assert.Equal(t, &types.BlobSchedule{Target: 3, Max: 6, BaseFeeUpdateFraction: 3338477}, config.Current.BlobSchedule)
+	assert.Equal(t, uint64(1), config.Current.ChainID)
+	assert.Equal(t, [4]byte{0x9f, 0x3d, 0x22, 0x54}, config.Current.ForkID)
+	assert.Equal(t, types.MustAddressFromHex("0x000f3df6d732807ef1319fb7b8bb8522d0beac02"), config.Current.SystemContracts["BEACON_ROOTS_ADDRESS"])
+
+	bls := types.MustAddressFromHex("0x000000000000000000000000000000000000000b")
+	assert.False(t, config.Current.IsPrecompile(bls))
+	assert.True(t, config.Next.IsPrecompile(bls))
+	assert.Equal(t, config.Current, config.At(1746612310))
+	assert.Equal(t, config.Next, config.At(1746612311))
+}
+
 const mockGasPriceRequest = `
 	{
 	  "jsonrpc": "2.0",
@@ -396,6 +492,69 @@ func TestBaseClient_GetStorageAt(t *testing.T) {
 	assert.Equal(t, types.MustHashFromHex("0x3333333333333333333333333333333333333333333333333333333333333333", types.PadNone), *storage)
 }
 
+const mockGetProofRequest = `
+	{
+	  "jsonrpc": "2.0",
+	  "id": 1,
+	  "method": "eth_getProof",
+	  "params": [
+		"0x1111111111111111111111111111111111111111",
+		["0x0000000000000000000000000000000000000000000000000000000000000001"],
+		"latest"
+	  ]
+	}
+`
+
+const mockGetProofResponse = `
+	{
+	  "jsonrpc": "2.0",
+	  "id": 1,
+	  "result": {
+		"address": "0x1111111111111111111111111111111111111111",
+		"accountProof": ["0xaa", "0xbb"],
+		"balance": "0x64",
+		"codeHash": "0x2222222222222222222222222222222222222222222222222222222222222222",
+		"nonce": "0x2",
+		"storageHash": "0x3333333333333333333333333333333333333333333333333333333333333333",
+		"storageProof": [
+		  {
+			"key": "0x1",
+			"value": "0x5",
+			"proof": ["0xcc"]
+		  }
+		]
+	  }
+	}
+`
+
+func TestBaseClient_GetProof(t *testing.T) {
+	httpMock := newHTTPMock()
+	client := &baseClient{transport: httpMock}
+
+	httpMock.ResponseMock = &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewBufferString(mockGetProofResponse)),
+	}
+
+	proof, err := client.GetProof(
+		context.Background(),
+		types.MustAddressFromHex("0x1111111111111111111111111111111111111111"),
+		[]types.Hash{types.MustHashFromHex("0x01", types.PadLeft)},
+		types.LatestBlockNumber,
+	)
+
+	require.NoError(t, err)
+	assert.JSONEq(t, mockGetProofRequest, readBody(httpMock.Request))
+	assert.Equal(t, [][]byte{{0xaa}, {0xbb}}, proof.AccountProof)
+	assert.Equal(t, big.NewInt(100), proof.Balance)
+	assert.Equal(t, uint64(2), proof.Nonce)
+	assert.Equal(t, types.MustHashFromHex("0x3333333333333333333333333333333333333333333333333333333333333333", types.PadNone), proof.StorageHash)
+	require.Len(t, proof.StorageProof, 1)
+	assert.Equal(t, types.MustHashFromHex("0x01", types.PadLeft), proof.StorageProof[0].Key)
+	assert.Equal(t, big.NewInt(5), proof.StorageProof[0].Value)
+	assert.Equal(t, [][]byte{{0xcc}}, proof.StorageProof[0].Proof)
+}
+
 const mockGetTransactionCountRequest = `
 	{
 	  "jsonrpc": "2.0",
@@ -1318,6 +1477,39 @@ func TestBaseClient_GetTransactionReceipt(t *testing.T) {
 	assert.Equal(t, false, receipt.Logs[0].Removed)
 }
 
+func TestBaseClient_NotFound(t *testing.T) {
+	hash := types.MustHashFromHex("0x1111111111111111111111111111111111111111111111111111111111111111", types.PadNone)
+	client := &baseClient{transport: &callMock{Handler: func(method string, args ...any) (any, error) {
+		return nil, nil
+	}}}
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		call func() (any, error)
+	}{
+		{name: "BlockByHash", call: func() (any, error) { return client.BlockByHash(ctx, hash, false) }},
+		{name: "BlockByNumber", call: func() (any, error) { return client.BlockByNumber(ctx, types.BlockNumberFromUint64(1), false) }},
+		{name: "GetTransactionByHash", call: func() (any, error) { return client.GetTransactionByHash(ctx, hash) }},
+		{name: "GetTransactionByBlockHashAndIndex", call: func() (any, error) { return client.GetTransactionByBlockHashAndIndex(ctx, hash, 0) }},
+		{name: "GetTransactionByBlockNumberAndIndex", call: func() (any, error) {
+			return client.GetTransactionByBlockNumberAndIndex(ctx, types.LatestBlockNumber, 0)
+		}},
+		{name: "GetTransactionReceipt", call: func() (any, error) { return client.GetTransactionReceipt(ctx, hash) }},
+		{name: "GetBlockReceipts", call: func() (any, error) { return client.GetBlockReceipts(ctx, types.LatestBlockNumber) }},
+		{name: "GetUncleByBlockHashAndIndex", call: func() (any, error) { return client.GetUncleByBlockHashAndIndex(ctx, hash, 0) }},
+		{name: "GetUncleByBlockNumberAndIndex", call: func() (any, error) {
+			return client.GetUncleByBlockNumberAndIndex(ctx, types.LatestBlockNumber, 0)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := tt.call()
+			assert.ErrorIs(t, err, ErrNotFound)
+			assert.Nil(t, res)
+		})
+	}
+}
+
 const mockGetBlockReceiptsRequest = `
 	{
 	  "jsonrpc": "2.0",
@@ -1819,6 +2011,72 @@ func TestBaseClient_GetLogs(t *testing.T) {
 	assert.Equal(t, false, logs[0].Removed)
 }
 
+func TestBaseClient_StreamLogs(t *testing.T) {
+	httpMock := newHTTPMock()
+	client := &baseClient{transport: httpMock}
+
+	httpMock.ResponseMock = &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewBufferString(mockGetLogsResponse)),
+	}
+
+	from := types.MustBlockNumberFromHex("0x1")
+	to := types.MustBlockNumberFromHex("0x2")
+	var logs []types.Log
+	err := client.StreamLogs(context.Background(), &types.FilterLogsQuery{
+		FromBlock: &from,
+		ToBlock:   &to,
+		Address:   []types.Address{types.MustAddressFromHex("0x3333333333333333333333333333333333333333")},
+		Topics: [][]types.Hash{
+			{types.MustHashFromHex("0x4444444444444444444444444444444444444444444444444444444444444444", types.PadNone)},
+		},
+	}, func(log types.Log) error {
+		logs = append(logs, log)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, mockGetLogsRequest, readBody(httpMock.Request))
+	require.Len(t, logs, 1)
+	assert.Equal(t, types.MustAddressFromHex("0x3333333333333333333333333333333333333333"), logs[0].Address)
+	assert.Equal(t, big.NewInt(1), logs[0].BlockNumber)
+
+	// Callback error stops decoding.
+	httpMock.ResponseMock = &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewBufferString(mockGetLogsResponse)),
+	}
+	cbErr := errors.New("callback error")
+	err = client.StreamLogs(context.Background(), types.NewFilterLogsQuery(), func(types.Log) error {
+		return cbErr
+	})
+	require.ErrorIs(t, err, cbErr)
+
+	// RPC error.
+	httpMock.ResponseMock = &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewBufferString(`{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"query returned more than 10000 results"}}`)),
+	}
+	err = client.StreamLogs(context.Background(), types.NewFilterLogsQuery(), func(types.Log) error {
+		return nil
+	})
+	var rpcErr *transport.RPCError
+	require.ErrorAs(t, err, &rpcErr)
+	assert.Equal(t, transport.ErrCodeLimitExceeded, rpcErr.Code)
+}
+
+func TestBaseClient_StreamLogs_NonStreamingTransport(t *testing.T) {
+	client := &baseClient{transport: &callMock{Handler: func(string, ...any) (any, error) {
+		return []types.Log{{LogIndex: new(uint64)}, {LogIndex: new(uint64)}}, nil
+	}}}
+	n := 0
+	err := client.StreamLogs(context.Background(), types.NewFilterLogsQuery(), func(types.Log) error {
+		n++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
 const mockMaxPriorityFeePerGasRequest = `
 	{
 	  "jsonrpc": "2.0",
@@ -1851,6 +2109,46 @@ func TestBaseClient_MaxPriorityFeePerGas(t *testing.T) {
 	assert.Equal(t, hexToBigInt("0x1"), gasPrice)
 }
 
+const mockFeeHistoryRequest = `
+	{
+	  "jsonrpc": "2.0",
+	  "id": 1,
+	  "method": "eth_feeHistory",
+	  "params": ["0x2", "latest", [25, 75]]
+	}
+`
+
+const mockFeeHistoryResponse = `
+	{
+	  "jsonrpc": "2.0",
+	  "id": 1,
+	  "result": {
+	    "oldestBlock": "0x10",
+	    "reward": [["0x1", "0x2"], ["0x3", "0x4"]],
+	    "baseFeePerGas": ["0x64", "0x65", "0x66"],
+	    "gasUsedRatio": [0.5, 0.25]
+	  }
+	}
+`
+
+func TestBaseClient_FeeHistory(t *testing.T) {
+	httpMock := newHTTPMock()
+	client := &baseClient{transport: httpMock}
+
+	httpMock.ResponseMock = &http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewBufferString(mockFeeHistoryResponse)),
+	}
+
+	feeHistory, err := client.FeeHistory(context.Background(), 2, types.LatestBlockNumber, []float64{25, 75})
+	require.NoError(t, err)
+	assert.JSONEq(t, mockFeeHistoryRequest, readBody(httpMock.Request))
+	assert.Equal(t, uint64(16), feeHistory.OldestBlock)
+	assert.Equal(t, [][]*big.Int{{big.NewInt(1), big.NewInt(2)}, {big.NewInt(3), big.NewInt(4)}}, feeHistory.Reward)
+	assert.Equal(t, []*big.Int{big.NewInt(100), big.NewInt(101), big.NewInt(102)}, feeHistory.BaseFeePerGas)
+	assert.Equal(t, []float64{0.5, 0.25}, feeHistory.GasUsedRatio)
+}
+
 const mockSubscribeLogsResponse = `
 	{
 	  "address": "0x3333333333333333333333333333333333333333",
@@ -1919,7 +2217,7 @@ func TestBaseClient_SubscribeLogs(t *testing.T) {
 
 	ctxCancel()
 	assert.Eventually(t, func() bool {
-		return len(streamMock.UnsubscribeMocks) == 0
+		return streamMock.pendingUnsubscribes() == 0
 	}, time.Second, 10*time.Millisecond)
 }
 
@@ -2026,7 +2324,7 @@ func TestBaseClient_SubscribeNewHeads(t *testing.T) {
 
 	ctxCancel()
 	assert.Eventually(t, func() bool {
-		return len(streamMock.UnsubscribeMocks) == 0
+		return streamMock.pendingUnsubscribes() == 0
 	}, time.Second, 10*time.Millisecond)
 }
 
@@ -2067,7 +2365,7 @@ func TestClient_SubscribeNewPendingTransactions(t *testing.T) {
 
 	ctxCancel()
 	assert.Eventually(t, func() bool {
-		return len(streamMock.UnsubscribeMocks) == 0
+		return streamMock.pendingUnsubscribes() == 0
 	}, time.Second, 10*time.Millisecond)
 }
 
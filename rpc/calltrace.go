@@ -0,0 +1,105 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/defiweb/go-eth/jsoncodec"
+	"github.com/defiweb/go-eth/types"
+)
+
+// CallFrame is a call made during the execution of a transaction, as
+// returned by the callTracer of debug_traceTransaction.
+type CallFrame struct {
+	Type    string         // Type is the call type, e.g. "CALL", "DELEGATECALL" or "CREATE".
+	From    types.Address  // From is the caller.
+	To      *types.Address // To is the called or created contract, nil if the creation failed.
+	Value   *big.Int       // Value is the transferred value, nil for calls that cannot transfer value.
+	Gas     uint64         // Gas is the gas available to the call.
+	GasUsed uint64         // GasUsed is the gas used by the call, including subcalls.
+	Input   []byte         // Input is the call data.
+	Output  []byte         // Output is the returned data.
+	Error   string         // Error is the error that occurred, empty if the call succeeded.
+	Calls   []CallFrame    // Calls are the subcalls made by the call.
+}
+
+func (f CallFrame) MarshalJSON() ([]byte, error) {
+	frame := &jsonCallFrame{
+		Type:    f.Type,
+		From:    f.From,
+		To:      f.To,
+		Gas:     types.NumberFromUint64(f.Gas),
+		GasUsed: types.NumberFromUint64(f.GasUsed),
+		Input:   f.Input,
+		Output:  f.Output,
+		Error:   f.Error,
+		Calls:   f.Calls,
+	}
+	if f.Value != nil {
+		value := types.NumberFromBigInt(f.Value)
+		frame.Value = &value
+	}
+	return jsoncodec.Marshal(frame)
+}
+
+func (f *CallFrame) UnmarshalJSON(input []byte) error {
+	frame := &jsonCallFrame{}
+	if err := jsoncodec.Unmarshal(input, frame); err != nil {
+		return err
+	}
+	f.Type = frame.Type
+	f.From = frame.From
+	f.To = frame.To
+	f.Value = nil
+	if frame.Value != nil {
+		f.Value = frame.Value.Big()
+	}
+	f.Gas = frame.Gas.Big().Uint64()
+	f.GasUsed = frame.GasUsed.Big().Uint64()
+	f.Input = frame.Input
+	f.Output = frame.Output
+	f.Error = frame.Error
+	f.Calls = frame.Calls
+	return nil
+}
+
+// Walk calls fn for the frame and all its subcalls, depth-first. If fn
+// returns false, the subcalls of the frame are skipped.
+func (f *CallFrame) Walk(fn func(frame *CallFrame, depth int) bool) {
+	f.walk(fn, 0)
+}
+
+func (f *CallFrame) walk(fn func(frame *CallFrame, depth int) bool, depth int) {
+	if !fn(f, depth) {
+		return
+	}
+	for i := range f.Calls {
+		f.Calls[i].walk(fn, depth+1)
+	}
+}
+
+type jsonCallFrame struct {
+	Type    string         `json:"type"`
+	From    types.Address  `json:"from"`
+	To      *types.Address `json:"to,omitempty"`
+	Value   *types.Number  `json:"value,omitempty"`
+	Gas     types.Number   `json:"gas"`
+	GasUsed types.Number   `json:"gasUsed"`
+	Input   types.Bytes    `json:"input"`
+	Output  types.Bytes    `json:"output,omitempty"`
+	Error   string         `json:"error,omitempty"`
+	Calls   []CallFrame    `json:"calls,omitempty"`
+}
+
+// TraceCalls traces the transaction using the callTracer of
+// debug_traceTransaction and returns the top-level call frame. The node
+// must support the debug namespace.
+func (c *Client) TraceCalls(ctx context.Context, hash types.Hash) (*CallFrame, error) {
+	var frame CallFrame
+	config := map[string]any{"tracer": "callTracer"}
+	if err := c.transport.Call(ctx, &frame, "debug_traceTransaction", hash, config); err != nil {
+		return nil, fmt.Errorf("rpc client: failed to trace transaction %s: %w", hash, err)
+	}
+	return &frame, nil
+}
@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+const mockCallTrace = `{
+	"type": "CALL",
+	"from": "0x1111111111111111111111111111111111111111",
+	"to": "0x2222222222222222222222222222222222222222",
+	"value": "0x0",
+	"gas": "0x7530",
+	"gasUsed": "0x5208",
+	"input": "0x01",
+	"calls": [
+		{
+			"type": "CALL",
+			"from": "0x2222222222222222222222222222222222222222",
+			"to": "0x3333333333333333333333333333333333333333",
+			"value": "0x5",
+			"gas": "0x100",
+			"gasUsed": "0x10",
+			"input": "0x",
+			"error": "execution reverted"
+		},
+		{
+			"type": "STATICCALL",
+			"from": "0x2222222222222222222222222222222222222222",
+			"to": "0x4444444444444444444444444444444444444444",
+			"gas": "0x100",
+			"gasUsed": "0x10",
+			"input": "0x"
+		}
+	]
+}`
+
+func TestClient_TraceCalls(t *testing.T) {
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		assert.Equal(t, "debug_traceTransaction", method)
+		assert.Equal(t, map[string]any{"tracer": "callTracer"}, args[1])
+		return json.RawMessage(mockCallTrace), nil
+	}}
+	client, err := NewClient(WithTransport(mock))
+	require.NoError(t, err)
+
+	frame, err := client.TraceCalls(context.Background(), types.Hash{1})
+	require.NoError(t, err)
+	assert.Equal(t, "CALL", frame.Type)
+	assert.Equal(t, uint64(30000), frame.Gas)
+	assert.Equal(t, uint64(21000), frame.GasUsed)
+	assert.Equal(t, []byte{1}, frame.Input)
+	require.Len(t, frame.Calls, 2)
+	assert.Equal(t, big.NewInt(5), frame.Calls[0].Value)
+	assert.Equal(t, "execution reverted", frame.Calls[0].Error)
+	assert.Nil(t, frame.Calls[1].Value)
+
+	// Walk skips subcalls of frames for which the callback returns false.
+	var visited []string
+	frame.Walk(func(f *CallFrame, depth int) bool {
+		visited = append(visited, f.Type)
+		return depth == 0
+	})
+	assert.Equal(t, []string{"CALL", "CALL", "STATICCALL"}, visited)
+
+	// Round trip.
+	raw, err := json.Marshal(frame)
+	require.NoError(t, err)
+	var decoded CallFrame
+	require.NoError(t, json.Unmarshal(raw, &decoded))
+	assert.Equal(t, *frame, decoded)
+}
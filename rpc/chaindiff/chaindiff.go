@@ -0,0 +1,300 @@
+// Package chaindiff compares chain data returned by two RPC endpoints.
+//
+// Providers occasionally return data that differs from the canonical chain,
+// for example because of a bug in a caching layer or a node that is stuck on
+// a fork. Compare fetches the same block range from two endpoints and
+// reports every field that differs in blocks, transactions, receipts and
+// logs:
+//
+//	report, err := chaindiff.Compare(ctx, chaindiff.Options{
+//		A:    primary,
+//		B:    secondary,
+//		From: 19000000,
+//		To:   19000100,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	for _, d := range report.Discrepancies {
+//		fmt.Println(d)
+//	}
+//
+// Values are compared after they are decoded by the client, so differences
+// in encoding, such as leading zeros in hex numbers, are not reported.
+package chaindiff
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/types"
+)
+
+// Kind is the kind of compared data.
+type Kind uint8
+
+const (
+	BlockKind   Kind = iota // BlockKind is a block with transactions.
+	ReceiptKind             // ReceiptKind is a transaction receipt.
+	LogsKind                // LogsKind is the list of logs of a block, as returned by eth_getLogs.
+)
+
+// String implements the fmt.Stringer interface.
+func (k Kind) String() string {
+	switch k {
+	case BlockKind:
+		return "block"
+	case ReceiptKind:
+		return "receipt"
+	case LogsKind:
+		return "logs"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(k))
+	}
+}
+
+// Options is the options for Compare.
+type Options struct {
+	// A and B are the compared endpoints.
+	A rpc.RPC
+	B rpc.RPC
+
+	// From and To are the block range to compare, inclusive.
+	From uint64
+	To   uint64
+
+	// SkipReceipts and SkipLogs disable comparing receipts and logs, which
+	// require one request per transaction and per block respectively.
+	SkipReceipts bool
+	SkipLogs     bool
+
+	// Ignore is a list of fields that are not compared. An entry matches a
+	// field either by its full path, e.g. "transactions[0].v", or by its
+	// name, e.g. "totalDifficulty", which some providers omit after the
+	// merge.
+	Ignore []string
+}
+
+// Discrepancy is a field that differs between the endpoints.
+type Discrepancy struct {
+	Block       uint64      // Block is the block number.
+	Kind        Kind        // Kind is the kind of compared data.
+	Transaction *types.Hash // Transaction is the hash of the transaction, for receipts.
+	Path        string      // Path is the path of the field, e.g. "transactions[2].gasPrice", empty for the whole value.
+	A           any         // A is the JSON value returned by endpoint A, nil if missing.
+	B           any         // B is the JSON value returned by endpoint B, nil if missing.
+}
+
+// String implements the fmt.Stringer interface.
+func (d Discrepancy) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "block %d %s", d.Block, d.Kind)
+	if d.Transaction != nil {
+		fmt.Fprintf(&b, " %s", d.Transaction.String())
+	}
+	if d.Path != "" {
+		fmt.Fprintf(&b, " %s", d.Path)
+	}
+	fmt.Fprintf(&b, ": %s != %s", formatValue(d.A), formatValue(d.B))
+	return b.String()
+}
+
+// Report is the result of Compare.
+type Report struct {
+	Blocks        uint64        // Blocks is the number of compared blocks.
+	Receipts      uint64        // Receipts is the number of compared receipts.
+	Discrepancies []Discrepancy // Discrepancies is the list of differing fields.
+}
+
+// Equal returns true if no discrepancies were found.
+func (r *Report) Equal() bool {
+	return len(r.Discrepancies) == 0
+}
+
+// Compare fetches the blocks in the range [From, To] from both endpoints
+// and compares them field by field. Receipts are fetched for transactions
+// of the block returned by endpoint A.
+//
+// An error is returned only if a request fails. Data that differs, or is
+// missing on one of the endpoints, is reported as a discrepancy.
+func Compare(ctx context.Context, opts Options) (*Report, error) {
+	if opts.A == nil || opts.B == nil {
+		return nil, errors.New("chaindiff: both endpoints are required")
+	}
+	if opts.From > opts.To {
+		return nil, errors.New("chaindiff: invalid block range")
+	}
+	c := &comparer{opts: opts, report: &Report{}, ignore: make(map[string]struct{}, len(opts.Ignore))}
+	for _, f := range opts.Ignore {
+		c.ignore[f] = struct{}{}
+	}
+	for n := opts.From; ; n++ {
+		if err := c.compareBlock(ctx, n); err != nil {
+			return nil, err
+		}
+		if n == opts.To {
+			break
+		}
+	}
+	return c.report, nil
+}
+
+type comparer struct {
+	opts   Options
+	report *Report
+	ignore map[string]struct{}
+}
+
+func (c *comparer) compareBlock(ctx context.Context, n uint64) error {
+	number := types.BlockNumberFromUint64(n)
+	blockA, err := c.opts.A.BlockByNumber(ctx, number, true)
+	if err != nil {
+		return fmt.Errorf("chaindiff: failed to fetch block %d from A: %w", n, err)
+	}
+	blockB, err := c.opts.B.BlockByNumber(ctx, number, true)
+	if err != nil {
+		return fmt.Errorf("chaindiff: failed to fetch block %d from B: %w", n, err)
+	}
+	c.report.Blocks++
+	if err := c.diff(Discrepancy{Block: n, Kind: BlockKind}, blockA, blockB); err != nil {
+		return err
+	}
+	if !c.opts.SkipReceipts {
+		for _, tx := range blockA.Transactions {
+			if tx.Hash == nil {
+				continue
+			}
+			hash := *tx.Hash
+			receiptA, err := c.opts.A.GetTransactionReceipt(ctx, hash)
+			if err != nil {
+				return fmt.Errorf("chaindiff: failed to fetch receipt %s from A: %w", hash, err)
+			}
+			receiptB, err := c.opts.B.GetTransactionReceipt(ctx, hash)
+			if err != nil {
+				return fmt.Errorf("chaindiff: failed to fetch receipt %s from B: %w", hash, err)
+			}
+			c.report.Receipts++
+			if err := c.diff(Discrepancy{Block: n, Kind: ReceiptKind, Transaction: &hash}, receiptA, receiptB); err != nil {
+				return err
+			}
+		}
+	}
+	if !c.opts.SkipLogs {
+		query := &types.FilterLogsQuery{FromBlock: &number, ToBlock: &number}
+		logsA, err := c.opts.A.GetLogs(ctx, query)
+		if err != nil {
+			return fmt.Errorf("chaindiff: failed to fetch logs of block %d from A: %w", n, err)
+		}
+		logsB, err := c.opts.B.GetLogs(ctx, query)
+		if err != nil {
+			return fmt.Errorf("chaindiff: failed to fetch logs of block %d from B: %w", n, err)
+		}
+		if err := c.diff(Discrepancy{Block: n, Kind: LogsKind}, logsA, logsB); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diff compares the JSON representations of a and b and adds a
+// discrepancy, based on the given one, for every differing field.
+func (c *comparer) diff(base Discrepancy, a, b any) error {
+	va, err := toJSONValue(a)
+	if err != nil {
+		return fmt.Errorf("chaindiff: %w", err)
+	}
+	vb, err := toJSONValue(b)
+	if err != nil {
+		return fmt.Errorf("chaindiff: %w", err)
+	}
+	c.diffValues(base, "", "", va, vb)
+	return nil
+}
+
+func (c *comparer) diffValues(base Discrepancy, path, name string, a, b any) {
+	if _, ok := c.ignore[path]; ok {
+		return
+	}
+	if _, ok := c.ignore[name]; ok && name != "" {
+		return
+	}
+	switch ta := a.(type) {
+	case map[string]any:
+		if tb, ok := b.(map[string]any); ok {
+			for _, key := range unionKeys(ta, tb) {
+				c.diffValues(base, joinPath(path, key), key, ta[key], tb[key])
+			}
+			return
+		}
+	case []any:
+		if tb, ok := b.([]any); ok {
+			if len(ta) != len(tb) {
+				d := base
+				d.Path = joinPath(path, "length")
+				d.A, d.B = len(ta), len(tb)
+				c.report.Discrepancies = append(c.report.Discrepancies, d)
+			}
+			for i := 0; i < len(ta) && i < len(tb); i++ {
+				c.diffValues(base, fmt.Sprintf("%s[%d]", path, i), name, ta[i], tb[i])
+			}
+			return
+		}
+	}
+	if !reflect.DeepEqual(a, b) {
+		d := base
+		d.Path = path
+		d.A, d.B = a, b
+		c.report.Discrepancies = append(c.report.Discrepancies, d)
+	}
+}
+
+// toJSONValue converts v to its generic JSON representation.
+func toJSONValue(v any) (any, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var res any
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func unionKeys(a, b map[string]any) []string {
+	keys := make([]string, 0, len(a))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func formatValue(v any) string {
+	if v == nil {
+		return "<missing>"
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(raw)
+}
@@ -0,0 +1,143 @@
+package chaindiff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/types"
+)
+
+type fakeRPC struct {
+	rpc.Client
+
+	blocks   map[uint64]*types.Block
+	receipts map[types.Hash]*types.TransactionReceipt
+	logs     map[uint64][]types.Log
+	err      error
+}
+
+func (f *fakeRPC) BlockByNumber(_ context.Context, number types.BlockNumber, _ bool) (*types.Block, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	b, ok := f.blocks[number.Big().Uint64()]
+	if !ok {
+		return nil, fmt.Errorf("block not found")
+	}
+	return b, nil
+}
+
+func (f *fakeRPC) GetTransactionReceipt(_ context.Context, hash types.Hash) (*types.TransactionReceipt, error) {
+	return f.receipts[hash], nil
+}
+
+func (f *fakeRPC) GetLogs(_ context.Context, query *types.FilterLogsQuery) ([]types.Log, error) {
+	return f.logs[query.FromBlock.Big().Uint64()], nil
+}
+
+var (
+	hash1 = types.Hash{1}
+	hash2 = types.Hash{2}
+	addr1 = types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+)
+
+// newChain returns a fake endpoint with two blocks, each containing a
+// single transaction with one log.
+func newChain() *fakeRPC {
+	f := &fakeRPC{
+		blocks:   make(map[uint64]*types.Block),
+		receipts: make(map[types.Hash]*types.TransactionReceipt),
+		logs:     make(map[uint64][]types.Log),
+	}
+	for i, hash := range []types.Hash{hash1, hash2} {
+		n := uint64(100 + i)
+		hash := hash
+		tx := types.OnChainTransaction{Hash: &hash, BlockNumber: new(big.Int).SetUint64(n)}
+		tx.Value = big.NewInt(int64(i + 1))
+		tx.GasPrice = big.NewInt(10)
+		log := types.Log{Address: addr1, Data: []byte{byte(i)}, TransactionHash: &hash}
+		f.blocks[n] = &types.Block{
+			Number:       new(big.Int).SetUint64(n),
+			Hash:         types.Hash{byte(n)},
+			GasUsed:      21000,
+			Timestamp:    time.Unix(1700000000+int64(i)*12, 0),
+			Transactions: []types.OnChainTransaction{tx},
+		}
+		f.receipts[hash] = &types.TransactionReceipt{
+			TransactionHash: hash,
+			BlockNumber:     new(big.Int).SetUint64(n),
+			GasUsed:         21000,
+			Logs:            []types.Log{log},
+		}
+		f.logs[n] = []types.Log{log}
+	}
+	return f
+}
+
+func TestCompare(t *testing.T) {
+	ctx := context.Background()
+	t.Run("equal", func(t *testing.T) {
+		report, err := Compare(ctx, Options{A: newChain(), B: newChain(), From: 100, To: 101})
+		require.NoError(t, err)
+		assert.True(t, report.Equal())
+		assert.Equal(t, uint64(2), report.Blocks)
+		assert.Equal(t, uint64(2), report.Receipts)
+	})
+	t.Run("discrepancies", func(t *testing.T) {
+		a, b := newChain(), newChain()
+		b.blocks[100].GasUsed = 42000
+		b.blocks[101].Transactions[0].GasPrice = big.NewInt(11)
+		b.receipts[hash2].Logs = nil
+		b.logs[101] = nil
+		report, err := Compare(ctx, Options{A: a, B: b, From: 100, To: 101})
+		require.NoError(t, err)
+		require.Len(t, report.Discrepancies, 4)
+
+		d := report.Discrepancies
+		assert.Equal(t, Discrepancy{Block: 100, Kind: BlockKind, Path: "gasUsed", A: "0x5208", B: "0xa410"}, d[0])
+		assert.Equal(t, Discrepancy{Block: 101, Kind: BlockKind, Path: "transactions[0].gasPrice", A: "0xa", B: "0xb"}, d[1])
+		assert.Equal(t, ReceiptKind, d[2].Kind)
+		assert.Equal(t, &hash2, d[2].Transaction)
+		assert.Equal(t, "logs", d[2].Path)
+		assert.Equal(t, LogsKind, d[3].Kind)
+		assert.Nil(t, d[3].B)
+		assert.Equal(t, `block 100 block gasUsed: "0x5208" != "0xa410"`, d[0].String())
+		assert.Contains(t, d[3].String(), "!= <missing>")
+	})
+	t.Run("ignore", func(t *testing.T) {
+		a, b := newChain(), newChain()
+		b.blocks[100].GasUsed = 42000
+		b.blocks[101].Transactions[0].GasPrice = big.NewInt(11)
+		report, err := Compare(ctx, Options{A: a, B: b, From: 100, To: 101, Ignore: []string{"gasUsed", "transactions[0].gasPrice"}})
+		require.NoError(t, err)
+		assert.True(t, report.Equal())
+	})
+	t.Run("skip", func(t *testing.T) {
+		a, b := newChain(), newChain()
+		b.receipts[hash1].GasUsed = 1
+		b.logs[100] = nil
+		report, err := Compare(ctx, Options{A: a, B: b, From: 100, To: 100, SkipReceipts: true, SkipLogs: true})
+		require.NoError(t, err)
+		assert.True(t, report.Equal())
+		assert.Equal(t, uint64(0), report.Receipts)
+	})
+	t.Run("error", func(t *testing.T) {
+		b := newChain()
+		b.err = errors.New("unavailable")
+		_, err := Compare(ctx, Options{A: newChain(), B: b, From: 100, To: 100})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "from B")
+	})
+	t.Run("invalid range", func(t *testing.T) {
+		_, err := Compare(ctx, Options{A: newChain(), B: newChain(), From: 2, To: 1})
+		require.Error(t, err)
+	})
+}
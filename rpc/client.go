@@ -2,7 +2,10 @@ package rpc
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/big"
+	"time"
 
 	"github.com/defiweb/go-eth/rpc/transport"
 	"github.com/defiweb/go-eth/types"
@@ -10,18 +13,46 @@ import (
 )
 
 // Client allows to interact with the Ethereum node.
+//
+// Client is safe for concurrent use by multiple goroutines. Its options
+// cannot be changed after it is created. Methods never modify the
+// transactions and calls passed to them, modifiers and the approver work on
+// per-call copies. Note that the nonce of transactions sent concurrently
+// from the same address must be assigned by a modifier that reserves
+// nonces, such as txmodifier.NonceProvider with the Reserve option.
 type Client struct {
 	baseClient
 
-	keys        map[types.Address]wallet.Key
+	keys        []wallet.Key
+	signPolicy  SignPolicy
+	policies    map[types.Address]SignPolicy
 	defaultAddr *types.Address
 	txModifiers []TXModifier
+	txIsolate   bool
+	txApprover  TXApprover
+	txTimeout   time.Duration
+	maxTxCost   *big.Int
+	maxFee      *big.Int
+	gasGuard    bool
+	valueGuard  bool
+	strict155   bool
+	syncLag     uint64
+	estBalance  *big.Int
+	senders     *senderRecovery
+	filters     *filterRegistry
 }
 
+// ClientOptions is an option that configures the Client, see NewClient.
 type ClientOptions func(c *Client) error
 
 // TXModifier allows to modify the transaction before it is signed or sent to
 // the node.
+//
+// Modify may be called concurrently for different transactions, so
+// implementations must be safe for concurrent use. The transaction is a
+// copy owned by the current call, but values assigned to it must not be
+// shared with other transactions or with the modifier itself, because
+// later modifiers and the approver may modify them in place.
 type TXModifier interface {
 	Modify(ctx context.Context, client RPC, tx *types.Transaction) error
 }
@@ -32,6 +63,55 @@ func (f TXModifierFunc) Modify(ctx context.Context, client RPC, tx *types.Transa
 	return f(ctx, client, tx)
 }
 
+// TXApprover approves the transaction before it is signed. The transaction
+// is fully populated, all transaction modifiers have already been applied.
+//
+// The approver may modify the transaction, for example to lower the gas
+// price. To deny the transaction, an error must be returned. Approve may be
+// called concurrently for different transactions.
+type TXApprover interface {
+	Approve(ctx context.Context, tx *types.Transaction) error
+}
+
+type TXApproverFunc func(ctx context.Context, tx *types.Transaction) error
+
+func (f TXApproverFunc) Approve(ctx context.Context, tx *types.Transaction) error {
+	return f(ctx, tx)
+}
+
+// SignPolicy controls what the keys provided with WithKeys are allowed to
+// sign. Policies can be combined using the bitwise OR operator.
+type SignPolicy uint8
+
+const (
+	// MessageSignPolicy allows signing messages with the EIP-191 "Ethereum
+	// Signed Message" prefix (version 0x45) using Sign.
+	MessageSignPolicy SignPolicy = 1 << iota
+
+	// HashSignPolicy allows signing raw 32-byte hashes using SignHash.
+	//
+	// A signature of a raw hash can authorize anything the hash represents,
+	// including transactions and EIP-712 messages, so it should only be
+	// enabled for keys that need it.
+	HashSignPolicy
+)
+
+// String implements the fmt.Stringer interface.
+func (p SignPolicy) String() string {
+	switch p {
+	case 0:
+		return "none"
+	case MessageSignPolicy:
+		return "message"
+	case HashSignPolicy:
+		return "hash"
+	case MessageSignPolicy | HashSignPolicy:
+		return "message|hash"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(p))
+	}
+}
+
 // WithTransport sets the transport for the client.
 func WithTransport(transport transport.Transport) ClientOptions {
 	return func(c *Client) error {
@@ -46,13 +126,51 @@ func WithTransport(transport transport.Transport) ClientOptions {
 // The following methods are affected:
 //   - Accounts - returns the addresses of the provided keys
 //   - Sign - signs the data with the provided key
+//   - SignHash - signs the raw hash with the provided key, if allowed by
+//     the sign policy, see WithSignPolicy
 //   - SignTransaction - signs transaction with the provided key
 //   - SendTransaction - signs transaction with the provided key and sends it
 //     using SendRawTransaction
+//
+// Keys are looked up by their current address, so keys whose address
+// changes, such as wallet.RotatingKey, can be used. If a key implements
+// wallet.KeyWithAddresses, its previous addresses are resolved to the key as
+// well, and the key decides whether it can sign for them.
 func WithKeys(keys ...wallet.Key) ClientOptions {
 	return func(c *Client) error {
 		for _, k := range keys {
-			c.keys[k.Address()] = k
+			c.addKey(k)
+		}
+		return nil
+	}
+}
+
+// WithAsyncKeys works like WithKeys, but for keys where signing requires
+// multiple round-trips or an approval, such as threshold (MPC) signers. The
+// signing methods block until the signing request is completed or the
+// context is canceled.
+func WithAsyncKeys(keys ...wallet.AsyncKey) ClientOptions {
+	return func(c *Client) error {
+		for _, k := range keys {
+			c.addKey(wallet.NewAwaitingKey(k))
+		}
+		return nil
+	}
+}
+
+// WithSignPolicy sets the sign policy for the given addresses. If no
+// addresses are given, it sets the policy for keys without an explicit
+// policy. The default policy is MessageSignPolicy.
+//
+// The policy applies only to keys provided with WithKeys or WithAsyncKeys.
+func WithSignPolicy(policy SignPolicy, addrs ...types.Address) ClientOptions {
+	return func(c *Client) error {
+		if len(addrs) == 0 {
+			c.signPolicy = policy
+			return nil
+		}
+		for _, addr := range addrs {
+			c.policies[addr] = policy
 		}
 		return nil
 	}
@@ -64,6 +182,10 @@ func WithKeys(keys ...wallet.Key) ClientOptions {
 //   - SendTransaction
 //   - Call
 //   - EstimateGas
+//
+// If the address belongs to a key provided with WithKeys, the current
+// address of the key is used, so the default address follows the key when
+// it is rotated.
 func WithDefaultAddress(addr types.Address) ClientOptions {
 	return func(c *Client) error {
 		c.defaultAddr = &addr
@@ -82,10 +204,156 @@ func WithTXModifiers(modifiers ...TXModifier) ClientOptions {
 	}
 }
 
+// WithTXModifierIsolation makes the client detach the transaction from
+// every modifier after it is applied. The next modifier, and the signer,
+// work on a deep copy, so changes made by a modifier that keeps a reference
+// to the transaction, for example in a background goroutine, do not affect
+// the transaction that is signed and returned.
+//
+// Without this option, all modifiers share a single copy of the
+// transaction. The transaction passed by the caller is never modified,
+// regardless of this option.
+func WithTXModifierIsolation() ClientOptions {
+	return func(c *Client) error {
+		c.txIsolate = true
+		return nil
+	}
+}
+
+// WithTXApprover sets the transaction approver that is called before the
+// transaction is signed by SignTransaction and SendTransaction, after all
+// transaction modifiers are applied. It can be used to implement approval
+// workflows, such as a confirmation by a human or a policy service.
+//
+// If timeout is greater than zero, the transaction is denied if the approver
+// does not respond within the given time.
+func WithTXApprover(approver TXApprover, timeout time.Duration) ClientOptions {
+	return func(c *Client) error {
+		c.txApprover = approver
+		c.txTimeout = timeout
+		return nil
+	}
+}
+
+// WithMaxTxCost sets the maximum cost of a transaction, in wei, that
+// SignTransaction and SendTransaction are allowed to sign. The cost is
+// calculated as gasLimit * maxFeePerGas (gasPrice for legacy transactions)
+// plus the transferred value, after all modifiers and the approver are
+// applied.
+//
+// It protects against signing transactions during fee spikes or with fees
+// provided in wrong units. Transactions that exceed the limit, or whose
+// cost cannot be calculated because the gas limit or fee is not set, are
+// refused with a *TXCostExceededError.
+//
+// The blob fee is not included, because types.Transaction does not support
+// blob transactions.
+func WithMaxTxCost(wei *big.Int) ClientOptions {
+	return func(c *Client) error {
+		if wei == nil || wei.Sign() < 0 {
+			return fmt.Errorf("rpc client: invalid maximum transaction cost")
+		}
+		c.maxTxCost = new(big.Int).Set(wei)
+		return nil
+	}
+}
+
+// WithMaxFeePerGas sets the maximum fee per gas, in wei, that
+// SignTransaction and SendTransaction are allowed to sign. The limit
+// applies to maxFeePerGas, or gasPrice for legacy and access list
+// transactions, see types.Transaction.FeeCap.
+//
+// It is an absolute cap that protects against fee estimators returning
+// absurd values, e.g. during fee spikes or because of a misbehaving node.
+// Transactions that exceed the limit are refused with a *GuardError.
+func WithMaxFeePerGas(wei *big.Int) ClientOptions {
+	return func(c *Client) error {
+		if wei == nil || wei.Sign() <= 0 {
+			return fmt.Errorf("rpc client: invalid maximum fee per gas")
+		}
+		c.maxFee = new(big.Int).Set(wei)
+		return nil
+	}
+}
+
+// WithBlockGasLimitGuard makes SignTransaction and SendTransaction refuse
+// to sign transactions with a gas limit above the gas limit of the latest
+// block. Such transactions can never be included in a block. Transactions
+// that exceed the limit are refused with a *GuardError.
+func WithBlockGasLimitGuard() ClientOptions {
+	return func(c *Client) error {
+		c.gasGuard = true
+		return nil
+	}
+}
+
+// WithBalanceGuard makes SignTransaction and SendTransaction refuse to sign
+// transactions whose value exceeds the balance of the sender at the latest
+// block. Transactions that exceed the balance are refused with
+// a *GuardError.
+func WithBalanceGuard() ClientOptions {
+	return func(c *Client) error {
+		c.valueGuard = true
+		return nil
+	}
+}
+
+// WithStrictEIP155 makes SignTransaction and SendTransaction refuse to sign
+// legacy transactions without a chain ID. Such transactions are not replay
+// protected as defined in EIP-155 and can be replayed on other chains.
+func WithStrictEIP155() ClientOptions {
+	return func(c *Client) error {
+		c.strict155 = true
+		return nil
+	}
+}
+
+// WithSyncTolerance sets the maximum number of blocks the node may lag
+// behind the highest known block to be considered synced by IsSynced.
+// The default is 2 blocks.
+func WithSyncTolerance(blocks uint64) ClientOptions {
+	return func(c *Client) error {
+		c.syncLag = blocks
+		return nil
+	}
+}
+
+// defaultEstimateBalance is the balance of the sender used by
+// WithEstimateBalanceOverride if no balance is given.
+var defaultEstimateBalance = new(big.Int).Lsh(big.NewInt(1), 128)
+
+// WithEstimateBalanceOverride makes EstimateGas override the balance of the
+// sender with the given amount of wei, using the state override parameter
+// of eth_estimateGas. It allows to estimate gas for senders without funds,
+// e.g. on forked chains or in tests. If balance is nil, 2^128 wei is used.
+//
+// The override is used only for gas estimation. Calls and sent
+// transactions are executed with the actual balance, so a transaction
+// estimated this way still fails if the sender cannot pay for it. If the
+// node does not support state overrides in eth_estimateGas, the gas is
+// estimated without the override.
+func WithEstimateBalanceOverride(balance *big.Int) ClientOptions {
+	return func(c *Client) error {
+		if balance == nil {
+			balance = defaultEstimateBalance
+		}
+		if balance.Sign() < 0 {
+			return fmt.Errorf("rpc client: negative balance override")
+		}
+		c.estBalance = new(big.Int).Set(balance)
+		return nil
+	}
+}
+
 // NewClient creates a new RPC client.
 // The WithTransport option is required.
 func NewClient(opts ...ClientOptions) (*Client, error) {
-	c := &Client{keys: make(map[types.Address]wallet.Key)}
+	c := &Client{
+		signPolicy: MessageSignPolicy,
+		policies:   make(map[types.Address]SignPolicy),
+		syncLag:    defaultSyncLag,
+		filters:    newFilterRegistry(),
+	}
 	for _, opt := range opts {
 		if err := opt(c); err != nil {
 			return nil, err
@@ -110,14 +378,45 @@ func (c *Client) Accounts(ctx context.Context) ([]types.Address, error) {
 }
 
 // Sign implements the RPC interface.
+//
+// If keys are provided with WithKeys, the data is signed with the EIP-191
+// message prefix, as done by the eth_sign method of most nodes. The sign
+// policy of the key must include MessageSignPolicy.
 func (c *Client) Sign(ctx context.Context, account types.Address, data []byte) (*types.Signature, error) {
 	if len(c.keys) == 0 {
 		return c.baseClient.Sign(ctx, account, data)
 	}
-	if key := c.findKey(&account); key != nil {
-		return key.SignMessage(ctx, data)
+	key := c.findKey(&account)
+	if key == nil {
+		return nil, fmt.Errorf("rpc client: no key found for address %s", account)
 	}
-	return nil, fmt.Errorf("rpc client: no key found for address %s", account)
+	if !c.allowed(account, MessageSignPolicy) {
+		return nil, &SignPolicyError{Address: account, Policy: MessageSignPolicy}
+	}
+	return key.SignMessage(ctx, data)
+}
+
+// SignHash signs the raw 32-byte hash, without the EIP-191 message prefix,
+// with the key provided with WithKeys. It can be used to sign hashes
+// computed by the caller, e.g. EIP-191 version 0x00 data or EIP-712
+// messages.
+//
+// Nodes differ in whether eth_sign prefixes the data, so SignHash never
+// calls the node. The key must implement wallet.KeyWithHashSigner, and its
+// sign policy must include HashSignPolicy.
+func (c *Client) SignHash(ctx context.Context, account types.Address, hash types.Hash) (*types.Signature, error) {
+	key := c.findKey(&account)
+	if key == nil {
+		return nil, fmt.Errorf("rpc client: no key found for address %s", account)
+	}
+	if !c.allowed(account, HashSignPolicy) {
+		return nil, &SignPolicyError{Address: account, Policy: HashSignPolicy}
+	}
+	hashKey, ok := key.(wallet.KeyWithHashSigner)
+	if !ok {
+		return nil, fmt.Errorf("rpc client: key %s does not support signing hashes", account)
+	}
+	return hashKey.SignHash(ctx, hash)
 }
 
 // SignTransaction implements the RPC interface.
@@ -126,6 +425,12 @@ func (c *Client) SignTransaction(ctx context.Context, tx *types.Transaction) ([]
 	if err != nil {
 		return nil, nil, err
 	}
+	if err := c.approveTransaction(ctx, tx); err != nil {
+		return nil, nil, err
+	}
+	if err := c.checkTransaction(ctx, tx); err != nil {
+		return nil, nil, err
+	}
 	if len(c.keys) == 0 {
 		return c.baseClient.SignTransaction(ctx, tx)
 	}
@@ -143,13 +448,26 @@ func (c *Client) SignTransaction(ctx context.Context, tx *types.Transaction) ([]
 }
 
 // SendTransaction implements the RPC interface.
+//
+// If the node rejects the transaction because the sender cannot pay for it,
+// an *InsufficientFundsError is returned.
 func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) (*types.Hash, *types.Transaction, error) {
 	tx, err := c.PrepareTransaction(ctx, tx)
 	if err != nil {
 		return nil, nil, err
 	}
+	if err := c.approveTransaction(ctx, tx); err != nil {
+		return nil, nil, err
+	}
+	if err := c.checkTransaction(ctx, tx); err != nil {
+		return nil, nil, err
+	}
 	if len(c.keys) == 0 {
-		return c.baseClient.SendTransaction(ctx, tx)
+		txHash, txCpy, err := c.baseClient.SendTransaction(ctx, tx)
+		if err != nil {
+			return nil, nil, c.insufficientFundsError(ctx, tx, err)
+		}
+		return txHash, txCpy, nil
 	}
 	if key := c.findKey(tx.Call.From); key != nil {
 		if err := key.SignTransaction(ctx, tx); err != nil {
@@ -161,7 +479,7 @@ func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) (*t
 		}
 		txHash, err := c.SendRawTransaction(ctx, raw)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, c.insufficientFundsError(ctx, tx, err)
 		}
 		return txHash, tx, nil
 	}
@@ -171,20 +489,23 @@ func (c *Client) SendTransaction(ctx context.Context, tx *types.Transaction) (*t
 // PrepareTransaction prepares the transaction by applying transaction
 // modifiers and setting the default address if it is not set.
 //
-// A copy of the modified transaction is returned.
+// A copy of the modified transaction is returned, the given transaction is
+// not modified. See also WithTXModifierIsolation.
 func (c *Client) PrepareTransaction(ctx context.Context, tx *types.Transaction) (*types.Transaction, error) {
 	if tx == nil {
 		return nil, fmt.Errorf("rpc client: transaction is nil")
 	}
 	txCpy := tx.Copy()
-	if txCpy.Call.From == nil && c.defaultAddr != nil {
-		defaultAddr := *c.defaultAddr
-		txCpy.Call.From = &defaultAddr
+	if txCpy.Call.From == nil {
+		txCpy.Call.From = c.defaultFrom()
 	}
 	for _, modifier := range c.txModifiers {
 		if err := modifier.Modify(ctx, c, txCpy); err != nil {
 			return nil, err
 		}
+		if c.txIsolate {
+			txCpy = txCpy.Copy()
+		}
 	}
 	return txCpy, nil
 }
@@ -195,9 +516,8 @@ func (c *Client) Call(ctx context.Context, call *types.Call, block types.BlockNu
 		return nil, nil, fmt.Errorf("rpc client: call is nil")
 	}
 	callCpy := call.Copy()
-	if callCpy.From == nil && c.defaultAddr != nil {
-		defaultAddr := *c.defaultAddr
-		callCpy.From = &defaultAddr
+	if callCpy.From == nil {
+		callCpy.From = c.defaultFrom()
 	}
 	return c.baseClient.Call(ctx, callCpy, block)
 }
@@ -208,20 +528,170 @@ func (c *Client) EstimateGas(ctx context.Context, call *types.Call, block types.
 		return 0, nil, fmt.Errorf("rpc client: call is nil")
 	}
 	callCpy := call.Copy()
-	if callCpy.From == nil && c.defaultAddr != nil {
-		defaultAddr := *c.defaultAddr
-		callCpy.From = &defaultAddr
+	if callCpy.From == nil {
+		callCpy.From = c.defaultFrom()
+	}
+	if c.estBalance != nil && callCpy.From != nil {
+		override := types.StateOverride{*callCpy.From: {Balance: new(big.Int).Set(c.estBalance)}}
+		gas, err := c.baseClient.estimateGasWithOverride(ctx, callCpy, block, override)
+		if err == nil {
+			return gas, callCpy, nil
+		}
+		if !isOverrideNotSupported(err) {
+			return 0, nil, err
+		}
 	}
 	return c.baseClient.EstimateGas(ctx, callCpy, block)
 }
 
-// findKey finds a key by address.
+// approveTransaction calls the transaction approver, if any. The approver
+// works on a copy of the transaction, so that an approver that does not
+// respect the timeout cannot modify the transaction after it is signed.
+func (c *Client) approveTransaction(ctx context.Context, tx *types.Transaction) error {
+	if c.txApprover == nil {
+		return nil
+	}
+	if c.txTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.txTimeout)
+		defer cancel()
+	}
+	txCpy := tx.Copy()
+	errCh := make(chan error, 1)
+	go func() { errCh <- c.txApprover.Approve(ctx, txCpy) }()
+	select {
+	case err := <-errCh:
+		if err != nil {
+			return &TXNotApprovedError{Err: err}
+		}
+		*tx = *txCpy
+		return nil
+	case <-ctx.Done():
+		return &TXNotApprovedError{Err: ctx.Err()}
+	}
+}
+
+// checkTransaction verifies that the transaction satisfies the restrictions
+// set by WithMaxTxCost, WithMaxFeePerGas, WithBlockGasLimitGuard,
+// WithBalanceGuard and WithStrictEIP155.
+func (c *Client) checkTransaction(ctx context.Context, tx *types.Transaction) error {
+	if c.strict155 && !tx.IsReplayProtected() {
+		return ErrNotReplayProtected
+	}
+	if c.maxTxCost != nil {
+		cost := tx.MaxCost()
+		if cost == nil || cost.Cmp(c.maxTxCost) > 0 {
+			return &TXCostExceededError{Cost: cost, Limit: new(big.Int).Set(c.maxTxCost)}
+		}
+	}
+	if c.maxFee != nil {
+		if fee := tx.FeeCap(); fee != nil && fee.Cmp(c.maxFee) > 0 {
+			return &GuardError{Guard: MaxFeePerGasGuard, Value: fee, Limit: new(big.Int).Set(c.maxFee)}
+		}
+	}
+	if c.gasGuard && tx.GasLimit != nil {
+		block, err := c.BlockByNumber(ctx, types.LatestBlockNumber, false)
+		if err != nil {
+			return fmt.Errorf("rpc client: failed to fetch block gas limit: %w", err)
+		}
+		if *tx.GasLimit > block.GasLimit {
+			return &GuardError{
+				Guard: BlockGasLimitGuard,
+				Value: new(big.Int).SetUint64(*tx.GasLimit),
+				Limit: new(big.Int).SetUint64(block.GasLimit),
+			}
+		}
+	}
+	if c.valueGuard && tx.From != nil && tx.Value != nil && tx.Value.Sign() > 0 {
+		balance, err := c.GetBalance(ctx, *tx.From, types.LatestBlockNumber)
+		if err != nil {
+			return fmt.Errorf("rpc client: failed to fetch balance: %w", err)
+		}
+		if tx.Value.Cmp(balance) > 0 {
+			return &GuardError{Guard: BalanceGuard, Value: tx.Value, Limit: balance}
+		}
+	}
+	return nil
+}
+
+// isOverrideNotSupported returns true if the error indicates that the node
+// does not accept the state override parameter of eth_estimateGas.
+func isOverrideNotSupported(err error) bool {
+	var rpcErr *transport.RPCError
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	return rpcErr.Code == transport.ErrCodeInvalidParams || transport.IsMethodNotSupported(err)
+}
+
+// allowed returns true if the sign policy of the address includes the
+// given policy. A policy set for a previous address of a key applies to its
+// current address as well.
+func (c *Client) allowed(addr types.Address, policy SignPolicy) bool {
+	p, ok := c.policies[addr]
+	if !ok {
+		if key, isMulti := c.findKey(&addr).(wallet.KeyWithAddresses); isMulti {
+			for _, a := range key.Addresses() {
+				if p, ok = c.policies[a]; ok {
+					break
+				}
+			}
+		}
+	}
+	if !ok {
+		p = c.signPolicy
+	}
+	return p&policy == policy
+}
+
+// addKey adds the key, replacing a key with the same address.
+func (c *Client) addKey(key wallet.Key) {
+	addr := key.Address()
+	for i, k := range c.keys {
+		if k.Address() == addr {
+			c.keys[i] = key
+			return
+		}
+	}
+	c.keys = append(c.keys, key)
+}
+
+// findKey finds a key by address. Keys are matched by their current address
+// first, and then by the previous addresses of keys that implement
+// wallet.KeyWithAddresses.
 func (c *Client) findKey(addr *types.Address) wallet.Key {
 	if addr == nil {
 		return nil
 	}
-	if key, ok := c.keys[*addr]; ok {
-		return key
+	for _, key := range c.keys {
+		if key.Address() == *addr {
+			return key
+		}
+	}
+	for _, key := range c.keys {
+		multi, ok := key.(wallet.KeyWithAddresses)
+		if !ok {
+			continue
+		}
+		for _, a := range multi.Addresses() {
+			if a == *addr {
+				return key
+			}
+		}
 	}
 	return nil
 }
+
+// defaultFrom returns a copy of the default address, or nil if it is not
+// set. If the default address belongs to a key, the current address of the
+// key is returned.
+func (c *Client) defaultFrom() *types.Address {
+	if c.defaultAddr == nil {
+		return nil
+	}
+	addr := *c.defaultAddr
+	if key := c.findKey(&addr); key != nil {
+		addr = key.Address()
+	}
+	return &addr
+}
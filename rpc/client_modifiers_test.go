@@ -0,0 +1,139 @@
+package rpc_test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/jsoncodec"
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/txmodifier"
+	"github.com/defiweb/go-eth/types"
+	"github.com/defiweb/go-eth/wallet"
+)
+
+// nodeMock is a transport that returns fixed results for the methods used
+// by the transaction modifiers and records the sent transactions. Like a
+// node that has not seen them yet, it returns the same transaction count
+// while transactions are being sent.
+type nodeMock struct {
+	mu      sync.Mutex
+	results map[string]string
+	sent    []*types.Transaction
+}
+
+func newNodeMock() *nodeMock {
+	return &nodeMock{results: map[string]string{
+		"eth_chainId":              `"0x1"`,
+		"eth_getTransactionCount":  `"0x2a"`,
+		"eth_estimateGas":          `"0x5208"`,
+		"eth_gasPrice":             `"0x64"`,
+		"eth_maxPriorityFeePerGas": `"0xa"`,
+	}}
+}
+
+func (n *nodeMock) Call(_ context.Context, result any, method string, args ...any) error {
+	if method == "eth_sendRawTransaction" {
+		raw := args[0].(types.Bytes)
+		tx := new(types.Transaction)
+		if _, err := tx.DecodeRLP(raw); err != nil {
+			return err
+		}
+		n.mu.Lock()
+		n.sent = append(n.sent, tx)
+		n.mu.Unlock()
+		return jsoncodec.Unmarshal([]byte(fmt.Sprintf("%q", crypto.Keccak256(raw).String())), result)
+	}
+	res, ok := n.results[method]
+	if !ok {
+		return fmt.Errorf("unexpected method %s", method)
+	}
+	return jsoncodec.Unmarshal([]byte(res), result)
+}
+
+func TestClient_SendTransaction_Concurrent(t *testing.T) {
+	const n = 50
+	key := wallet.NewRandomKey()
+	from := key.Address()
+	to := types.MustAddressFromHex("0xd46e8dd67c5d32be8058bb8eb970870f07244567")
+	node := newNodeMock()
+	client, err := rpc.NewClient(
+		rpc.WithTransport(node),
+		rpc.WithKeys(key),
+		rpc.WithTXModifiers(
+			txmodifier.NewChainIDProvider(txmodifier.ChainIDProviderOptions{
+				Cache: true,
+			}),
+			txmodifier.NewNonceProvider(txmodifier.NonceProviderOptions{
+				Reserve: true,
+			}),
+			txmodifier.NewGasLimitEstimator(txmodifier.GasLimitEstimatorOptions{
+				Multiplier: 1.25,
+			}),
+			txmodifier.NewEIP1559GasFeeEstimator(txmodifier.EIP1559GasFeeEstimatorOptions{
+				GasPriceMultiplier:          2,
+				PriorityFeePerGasMultiplier: 1,
+			}),
+		),
+		rpc.WithTXApprover(rpc.TXApproverFunc(func(ctx context.Context, tx *types.Transaction) error {
+			// Modify the values in place, it must not affect other
+			// transactions or the transaction passed by the caller.
+			tx.MaxFeePerGas.Mul(tx.MaxFeePerGas, big.NewInt(2))
+			tx.Value.Add(tx.Value, big.NewInt(1))
+			return nil
+		}), time.Second),
+		rpc.WithMaxTxCost(big.NewInt(1e18)),
+	)
+	require.NoError(t, err)
+
+	// All goroutines send the same transaction instance.
+	tx := &types.Transaction{
+		Call: types.Call{From: &from, To: &to, Value: big.NewInt(1)},
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, sent, err := client.SendTransaction(context.Background(), tx)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, uint64(1), *sent.ChainID)
+			assert.Equal(t, uint64(26250), *sent.GasLimit)
+			assert.Equal(t, big.NewInt(400), sent.MaxFeePerGas)
+			assert.Equal(t, big.NewInt(10), sent.MaxPriorityFeePerGas)
+			assert.Equal(t, big.NewInt(2), sent.Value)
+		}()
+	}
+	wg.Wait()
+
+	// Every transaction got a unique nonce, starting from the node's
+	// transaction count, and was signed by the key.
+	require.Len(t, node.sent, n)
+	nonces := make(map[uint64]struct{})
+	for _, sent := range node.sent {
+		nonces[*sent.Nonce] = struct{}{}
+		sender, err := crypto.ECRecoverer.RecoverTransaction(sent)
+		require.NoError(t, err)
+		assert.Equal(t, from, *sender)
+	}
+	for i := uint64(0); i < n; i++ {
+		assert.Contains(t, nonces, 42+i)
+	}
+
+	// The caller's transaction was not modified.
+	assert.Nil(t, tx.ChainID)
+	assert.Nil(t, tx.Nonce)
+	assert.Nil(t, tx.GasLimit)
+	assert.Nil(t, tx.MaxFeePerGas)
+	assert.Nil(t, tx.Signature)
+	assert.Equal(t, big.NewInt(1), tx.Value)
+}
@@ -3,15 +3,20 @@ package rpc
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
 	"math/big"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/rpc/transport"
 	"github.com/defiweb/go-eth/types"
+	"github.com/defiweb/go-eth/wallet"
 )
 
 func TestClient_Sign(t *testing.T) {
@@ -35,6 +40,118 @@ func TestClient_Sign(t *testing.T) {
 	assert.Equal(t, types.MustSignatureFromHex("0xa3a7b12762dbc5df6cfbedbecdf8a821929c6112d2634abbb0d99dc63ad914908051b2c8c7d159db49ad19bd01026156eedab2f3d8c1dfdd07d21c07a4bbdd846f"), *signature)
 }
 
+func TestClient_SignPolicy(t *testing.T) {
+	var (
+		addrA = types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+		addrB = types.MustAddressFromHex("0x2222222222222222222222222222222222222222")
+		sig   = types.MustSignatureFromHexPtr("0xa3a7b12762dbc5df6cfbedbecdf8a821929c6112d2634abbb0d99dc63ad914908051b2c8c7d159db49ad19bd01026156eedab2f3d8c1dfdd07d21c07a4bbdd846f")
+	)
+	newKey := func(addr types.Address) *keyMock {
+		return &keyMock{
+			addressCallback:     func() types.Address { return addr },
+			signMessageCallback: func([]byte) (*types.Signature, error) { return sig, nil },
+			signHashCallback:    func(types.Hash) (*types.Signature, error) { return sig, nil },
+		}
+	}
+	client, err := NewClient(
+		WithTransport(newHTTPMock()),
+		WithKeys(newKey(addrA), newKey(addrB)),
+		WithSignPolicy(HashSignPolicy, addrB),
+	)
+	require.NoError(t, err)
+
+	// By default, only prefixed messages can be signed.
+	_, err = client.Sign(context.Background(), addrA, []byte("message"))
+	require.NoError(t, err)
+	_, err = client.SignHash(context.Background(), addrA, types.Hash{1})
+	assert.ErrorIs(t, err, ErrSignNotAllowed)
+
+	// Key with a policy that allows only hashes.
+	_, err = client.Sign(context.Background(), addrB, []byte("message"))
+	assert.ErrorIs(t, err, ErrSignNotAllowed)
+	res, err := client.SignHash(context.Background(), addrB, types.Hash{1})
+	require.NoError(t, err)
+	assert.Equal(t, sig, res)
+
+	// Unknown key.
+	_, err = client.SignHash(context.Background(), types.Address{}, types.Hash{1})
+	assert.Error(t, err)
+}
+
+func TestClient_RotatingKey(t *testing.T) {
+	var (
+		oldKey = wallet.NewRandomKey()
+		newKey = wallet.NewRandomKey()
+		oldAdr = oldKey.Address()
+		newAdr = newKey.Address()
+		to     = types.MustAddressFromHex("0xd46e8dd67c5d32be8058bb8eb970870f07244567")
+	)
+	key, err := wallet.NewRotatingKey(wallet.RotatingKeyOptions{Key: oldKey})
+	require.NoError(t, err)
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		if method != "eth_sendRawTransaction" {
+			return nil, errors.New("unexpected method")
+		}
+		return crypto.Keccak256(args[0].(types.Bytes)), nil
+	}}
+	client, err := NewClient(
+		WithTransport(mock),
+		WithKeys(key),
+		WithDefaultAddress(oldAdr),
+		WithSignPolicy(HashSignPolicy, oldAdr),
+	)
+	require.NoError(t, err)
+
+	send := func(from *types.Address) (*types.Transaction, error) {
+		chainID := uint64(1)
+		nonce := uint64(0)
+		gasLimit := uint64(21000)
+		_, tx, err := client.SendTransaction(context.Background(), &types.Transaction{
+			ChainID: &chainID,
+			Call: types.Call{
+				From:     from,
+				To:       &to,
+				GasLimit: &gasLimit,
+				GasPrice: big.NewInt(1),
+			},
+			Nonce: &nonce,
+		})
+		return tx, err
+	}
+
+	tx, err := send(nil)
+	require.NoError(t, err)
+	assert.Equal(t, oldAdr, *tx.From)
+
+	require.NoError(t, key.Rotate(newKey))
+
+	// The default address follows the current key.
+	tx, err = send(nil)
+	require.NoError(t, err)
+	assert.Equal(t, newAdr, *tx.From)
+	sender, err := crypto.ECRecoverer.RecoverTransaction(tx)
+	require.NoError(t, err)
+	assert.Equal(t, newAdr, *sender)
+
+	// The new address is known to the client.
+	tx, err = send(&newAdr)
+	require.NoError(t, err)
+	assert.Equal(t, newAdr, *tx.From)
+
+	// The old address is still resolved to the rotating key, which refuses
+	// to sign for it.
+	_, err = send(&oldAdr)
+	assert.ErrorContains(t, err, "rotated out")
+
+	// The sign policy set for the old address applies to the new one.
+	_, err = client.Sign(context.Background(), newAdr, []byte("message"))
+	assert.ErrorIs(t, err, ErrSignNotAllowed)
+
+	accounts, err := client.Accounts(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []types.Address{newAdr}, accounts)
+}
+
 func TestClient_SignTransaction(t *testing.T) {
 	httpMock := newHTTPMock()
 	keyMock := &keyMock{}
@@ -128,6 +245,287 @@ func TestClient_SendTransaction(t *testing.T) {
 	assert.Equal(t, input, tx.Input)
 }
 
+func TestClient_TXModifierIsolation(t *testing.T) {
+	for _, isolate := range []bool{false, true} {
+		var kept *types.Transaction
+		opts := []ClientOptions{
+			WithTransport(&callMock{}),
+			WithTXModifiers(
+				TXModifierFunc(func(ctx context.Context, client RPC, tx *types.Transaction) error {
+					kept = tx
+					tx.GasPrice = big.NewInt(1)
+					return nil
+				}),
+				TXModifierFunc(func(ctx context.Context, client RPC, tx *types.Transaction) error {
+					tx.SetNonce(2)
+					return nil
+				}),
+			),
+		}
+		if isolate {
+			opts = append(opts, WithTXModifierIsolation())
+		}
+		client, err := NewClient(opts...)
+		require.NoError(t, err)
+
+		tx := types.NewTransaction()
+		prepared, err := client.PrepareTransaction(context.Background(), tx)
+		require.NoError(t, err)
+		assert.Nil(t, tx.GasPrice)
+		assert.Equal(t, uint64(2), *prepared.Nonce)
+
+		// The first modifier changes the transaction after it returned.
+		kept.GasPrice.SetInt64(3)
+		if isolate {
+			assert.Equal(t, big.NewInt(1), prepared.GasPrice)
+			assert.Nil(t, kept.Nonce)
+		} else {
+			assert.Equal(t, big.NewInt(3), prepared.GasPrice)
+		}
+	}
+}
+
+func TestClient_TXApprover(t *testing.T) {
+	from := types.MustAddressFromHex("0xb60e8dd61c5d32be8058bb8eb970870f07233155")
+	to := types.MustAddressFromHex("0xd46e8dd67c5d32be8058bb8eb970870f07244567")
+	denied := errors.New("denied")
+
+	tests := []struct {
+		name     string
+		approver TXApproverFunc
+		wantErr  error
+		gasPrice *big.Int
+	}{
+		{
+			name: "approved",
+			approver: func(ctx context.Context, tx *types.Transaction) error {
+				return nil
+			},
+			gasPrice: big.NewInt(10),
+		},
+		{
+			name: "modified",
+			approver: func(ctx context.Context, tx *types.Transaction) error {
+				tx.GasPrice = big.NewInt(5)
+				return nil
+			},
+			gasPrice: big.NewInt(5),
+		},
+		{
+			name: "denied",
+			approver: func(ctx context.Context, tx *types.Transaction) error {
+				return denied
+			},
+			wantErr: denied,
+		},
+		{
+			name: "timeout",
+			approver: func(ctx context.Context, tx *types.Transaction) error {
+				time.Sleep(time.Second)
+				return nil
+			},
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyMock := &keyMock{}
+			keyMock.addressCallback = func() types.Address {
+				return from
+			}
+			keyMock.signTransactionCallback = func(tx *types.Transaction) error {
+				tx.Signature = types.MustSignatureFromHexPtr("0x2222222222222222222222222222222222222222222222222222222222222222333333333333333333333333333333333333333333333333333333333333333311")
+				return nil
+			}
+			client, _ := NewClient(
+				WithTransport(newHTTPMock()),
+				WithKeys(keyMock),
+				WithTXModifiers(TXModifierFunc(func(ctx context.Context, client RPC, tx *types.Transaction) error {
+					tx.GasPrice = big.NewInt(10)
+					return nil
+				})),
+				WithTXApprover(tt.approver, 10*time.Millisecond),
+			)
+			_, tx, err := client.SignTransaction(
+				context.Background(),
+				(&types.Transaction{}).SetFrom(from).SetTo(to).SetChainID(1).SetGasLimit(21000),
+			)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, ErrTXNotApproved)
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.gasPrice, tx.GasPrice)
+		})
+	}
+}
+
+func TestClient_MaxTxCost(t *testing.T) {
+	from := types.MustAddressFromHex("0xb60e8dd61c5d32be8058bb8eb970870f07233155")
+	to := types.MustAddressFromHex("0xd46e8dd67c5d32be8058bb8eb970870f07244567")
+
+	tests := []struct {
+		name    string
+		tx      *types.Transaction
+		limit   *big.Int
+		wantErr bool
+	}{
+		{
+			name:  "legacy within limit",
+			tx:    (&types.Transaction{}).SetGasLimit(21000).SetGasPrice(big.NewInt(10)).SetValue(big.NewInt(1000)),
+			limit: big.NewInt(211000),
+		},
+		{
+			name:    "legacy exceeds limit",
+			tx:      (&types.Transaction{}).SetGasLimit(21000).SetGasPrice(big.NewInt(10)).SetValue(big.NewInt(1001)),
+			limit:   big.NewInt(211000),
+			wantErr: true,
+		},
+		{
+			name: "dynamic fee within limit",
+			tx: (&types.Transaction{}).
+				SetType(types.DynamicFeeTxType).
+				SetGasLimit(21000).
+				SetMaxFeePerGas(big.NewInt(10)).
+				SetMaxPriorityFeePerGas(big.NewInt(1)),
+			limit: big.NewInt(210000),
+		},
+		{
+			name: "dynamic fee exceeds limit",
+			tx: (&types.Transaction{}).
+				SetType(types.DynamicFeeTxType).
+				SetGasLimit(21000).
+				SetMaxFeePerGas(big.NewInt(11)).
+				SetMaxPriorityFeePerGas(big.NewInt(1)),
+			limit:   big.NewInt(210000),
+			wantErr: true,
+		},
+		{
+			name:    "missing fee",
+			tx:      (&types.Transaction{}).SetGasLimit(21000),
+			limit:   big.NewInt(210000),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyMock := &keyMock{}
+			keyMock.addressCallback = func() types.Address {
+				return from
+			}
+			keyMock.signTransactionCallback = func(tx *types.Transaction) error {
+				tx.Signature = types.MustSignatureFromHexPtr("0x2222222222222222222222222222222222222222222222222222222222222222333333333333333333333333333333333333333333333333333333333333333311")
+				return nil
+			}
+			client, err := NewClient(
+				WithTransport(newHTTPMock()),
+				WithKeys(keyMock),
+				WithMaxTxCost(tt.limit),
+			)
+			require.NoError(t, err)
+			_, _, err = client.SignTransaction(context.Background(), tt.tx.SetFrom(from).SetTo(to).SetChainID(1))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrTXCostExceeded)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestClient_StrictEIP155(t *testing.T) {
+	from := types.MustAddressFromHex("0xb60e8dd61c5d32be8058bb8eb970870f07233155")
+	to := types.MustAddressFromHex("0xd46e8dd67c5d32be8058bb8eb970870f07244567")
+	keyMock := &keyMock{}
+	keyMock.addressCallback = func() types.Address {
+		return from
+	}
+	keyMock.signTransactionCallback = func(tx *types.Transaction) error {
+		tx.Signature = types.MustSignatureFromHexPtr("0x2222222222222222222222222222222222222222222222222222222222222222333333333333333333333333333333333333333333333333333333333333333325")
+		return nil
+	}
+	client, err := NewClient(WithTransport(newHTTPMock()), WithKeys(keyMock), WithStrictEIP155())
+	require.NoError(t, err)
+
+	_, _, err = client.SignTransaction(context.Background(), (&types.Transaction{}).SetFrom(from).SetTo(to))
+	assert.ErrorIs(t, err, ErrNotReplayProtected)
+
+	_, _, err = client.SignTransaction(context.Background(), (&types.Transaction{}).SetFrom(from).SetTo(to).SetChainID(1))
+	assert.NoError(t, err)
+
+	_, _, err = client.SignTransaction(context.Background(), (&types.Transaction{}).SetType(types.DynamicFeeTxType).SetFrom(from).SetTo(to))
+	assert.NoError(t, err)
+}
+
+func TestClient_Guards(t *testing.T) {
+	from := types.MustAddressFromHex("0xb60e8dd61c5d32be8058bb8eb970870f07233155")
+	to := types.MustAddressFromHex("0xd46e8dd67c5d32be8058bb8eb970870f07244567")
+	keyMock := &keyMock{}
+	keyMock.addressCallback = func() types.Address {
+		return from
+	}
+	keyMock.signTransactionCallback = func(tx *types.Transaction) error {
+		tx.Signature = types.MustSignatureFromHexPtr("0x2222222222222222222222222222222222222222222222222222222222222222333333333333333333333333333333333333333333333333333333333333333325")
+		return nil
+	}
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		switch method {
+		case "eth_getBlockByNumber":
+			return map[string]any{"gasLimit": types.NumberFromUint64(30_000_000)}, nil
+		case "eth_getBalance":
+			return types.NumberFromUint64(1000), nil
+		}
+		t.Fatalf("unexpected call %s", method)
+		return nil, nil
+	}}
+	client, err := NewClient(
+		WithTransport(mock),
+		WithKeys(keyMock),
+		WithMaxFeePerGas(big.NewInt(500e9)),
+		WithBlockGasLimitGuard(),
+		WithBalanceGuard(),
+	)
+	require.NoError(t, err)
+
+	tx := func() *types.Transaction {
+		return types.NewTransaction().
+			SetType(types.DynamicFeeTxType).
+			SetFrom(from).
+			SetTo(to).
+			SetGasLimit(21000).
+			SetMaxFeePerGas(big.NewInt(100e9)).
+			SetValue(big.NewInt(1000))
+	}
+	tests := []struct {
+		name    string
+		tx      *types.Transaction
+		guard   Guard
+		wantErr bool
+	}{
+		{name: "valid", tx: tx()},
+		{name: "fee", tx: tx().SetMaxFeePerGas(big.NewInt(501e9)), guard: MaxFeePerGasGuard, wantErr: true},
+		{name: "legacy fee", tx: tx().SetType(types.LegacyTxType).SetMaxFeePerGas(nil).SetGasPrice(big.NewInt(501e9)), guard: MaxFeePerGasGuard, wantErr: true},
+		{name: "legacy fee with max fee", tx: tx().SetType(types.LegacyTxType).SetGasPrice(big.NewInt(501e9)), guard: MaxFeePerGasGuard, wantErr: true},
+		{name: "access list fee", tx: tx().SetType(types.AccessListTxType).SetGasPrice(big.NewInt(501e9)), guard: MaxFeePerGasGuard, wantErr: true},
+		{name: "gas limit", tx: tx().SetGasLimit(30_000_001), guard: BlockGasLimitGuard, wantErr: true},
+		{name: "value", tx: tx().SetValue(big.NewInt(1001)), guard: BalanceGuard, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := client.SignTransaction(context.Background(), tt.tx)
+			if !tt.wantErr {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, ErrGuard)
+			var guardErr *GuardError
+			require.ErrorAs(t, err, &guardErr)
+			assert.Equal(t, tt.guard, guardErr.Guard)
+		})
+	}
+}
+
 func TestClient_Call(t *testing.T) {
 	httpMock := newHTTPMock()
 	client, _ := NewClient(
@@ -187,3 +585,50 @@ func TestClient_EstimateGas(t *testing.T) {
 	require.NoError(t, err)
 	assert.JSONEq(t, mockEstimateGasRequest, readBody(httpMock.Request))
 }
+
+func TestClient_EstimateBalanceOverride(t *testing.T) {
+	from := types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+	to := types.MustAddressFromHex("0x2222222222222222222222222222222222222222")
+	for _, supported := range []bool{true, false} {
+		var calls [][]any
+		mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+			require.Equal(t, "eth_estimateGas", method)
+			calls = append(calls, args)
+			if len(args) == 3 && !supported {
+				return nil, transport.NewRPCError(transport.ErrCodeInvalidParams, "too many arguments, want at most 2", nil)
+			}
+			return types.NumberFromUint64(21000), nil
+		}}
+		client, err := NewClient(
+			WithTransport(mock),
+			WithDefaultAddress(from),
+			WithEstimateBalanceOverride(nil),
+		)
+		require.NoError(t, err)
+
+		gas, _, err := client.EstimateGas(context.Background(), types.NewCall().SetTo(to).SetValue(big.NewInt(1e18)), types.LatestBlockNumber)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(21000), gas)
+		require.Len(t, calls[0], 3)
+		override := calls[0][2].(types.StateOverride)
+		assert.Equal(t, defaultEstimateBalance, override[from].Balance)
+		if supported {
+			assert.Len(t, calls, 1)
+		} else {
+			require.Len(t, calls, 2)
+			assert.Len(t, calls[1], 2)
+		}
+	}
+
+	// Other errors are returned.
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		return nil, transport.NewRPCError(transport.ErrCodeExecutionError, "execution reverted", nil)
+	}}
+	client, err := NewClient(WithTransport(mock), WithEstimateBalanceOverride(big.NewInt(1)))
+	require.NoError(t, err)
+	_, _, err = client.EstimateGas(context.Background(), types.NewCall().SetFrom(from).SetTo(to), types.LatestBlockNumber)
+	assert.ErrorContains(t, err, "execution reverted")
+
+	_, err = NewClient(WithTransport(mock), WithEstimateBalanceOverride(big.NewInt(-1)))
+	assert.Error(t, err)
+}
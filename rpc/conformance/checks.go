@@ -0,0 +1,343 @@
+package conformance
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/types"
+)
+
+// check is a single conformance check. A check calls one client method,
+// but it may call other methods to prepare the input data.
+type check struct {
+	method string
+	run    func(ctx context.Context, e *env) error
+}
+
+// env holds the state shared between checks.
+type env struct {
+	client           *rpc.Client
+	sendTransactions bool
+
+	accounts []types.Address
+	block    *types.Block
+	txHash   *types.Hash
+	receipt  *types.TransactionReceipt
+	filters  []*big.Int
+}
+
+// account returns the first account of the node or the zero address if the
+// node has no accounts.
+func (e *env) account(ctx context.Context) types.Address {
+	if e.accounts == nil {
+		e.accounts, _ = e.client.Accounts(ctx)
+	}
+	if len(e.accounts) == 0 {
+		return types.ZeroAddress
+	}
+	return e.accounts[0]
+}
+
+// latestBlock returns the latest block.
+func (e *env) latestBlock(ctx context.Context) (*types.Block, error) {
+	if e.block != nil {
+		return e.block, nil
+	}
+	block, err := e.client.BlockByNumber(ctx, types.LatestBlockNumber, false)
+	if err != nil {
+		return nil, err
+	}
+	e.block = block
+	return block, nil
+}
+
+// transaction returns the receipt of a mined transaction. If sending
+// transactions is enabled, a new transaction is sent, otherwise the most
+// recent blocks are searched for a transaction.
+func (e *env) transaction(ctx context.Context) (*types.TransactionReceipt, error) {
+	if e.receipt != nil {
+		return e.receipt, nil
+	}
+	if e.txHash == nil {
+		if e.sendTransactions {
+			if err := e.sendTransaction(ctx); err != nil {
+				return nil, err
+			}
+		} else if err := e.findTransaction(ctx); err != nil {
+			return nil, err
+		}
+	}
+	for i := 0; i < 100; i++ {
+		receipt, err := e.client.GetTransactionReceipt(ctx, *e.txHash)
+		if err == nil && receipt != nil {
+			e.receipt = receipt
+			return receipt, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	return nil, errSkip
+}
+
+func (e *env) sendTransaction(ctx context.Context) error {
+	from := e.account(ctx)
+	if from == types.ZeroAddress {
+		return errSkip
+	}
+	hash, _, err := e.client.SendTransaction(ctx, types.NewTransaction().
+		SetFrom(from).
+		SetTo(from).
+		SetValue(big.NewInt(1)))
+	if err != nil {
+		return err
+	}
+	e.txHash = hash
+	return nil
+}
+
+func (e *env) findTransaction(ctx context.Context) error {
+	block, err := e.latestBlock(ctx)
+	if err != nil {
+		return err
+	}
+	for n := block.Number.Int64(); n >= 0 && n > block.Number.Int64()-16; n-- {
+		b, err := e.client.BlockByNumber(ctx, types.BlockNumberFromUint64(uint64(n)), false)
+		if err != nil {
+			return err
+		}
+		if len(b.Transactions) > 0 {
+			hash := b.Transactions[0].Hash
+			e.txHash = hash
+			return nil
+		}
+	}
+	return errSkip
+}
+
+// checks is the list of checks run by Run, in order.
+var checks = []check{
+	{method: "web3_clientVersion", run: func(ctx context.Context, e *env) error {
+		_, err := e.client.ClientVersion(ctx)
+		return err
+	}},
+	{method: "net_version", run: func(ctx context.Context, e *env) error {
+		_, err := e.client.NetworkID(ctx)
+		return err
+	}},
+	{method: "net_listening", run: func(ctx context.Context, e *env) error {
+		_, err := e.client.Listening(ctx)
+		return err
+	}},
+	{method: "net_peerCount", run: func(ctx context.Context, e *env) error {
+		_, err := e.client.PeerCount(ctx)
+		return err
+	}},
+	{method: "eth_syncing", run: func(ctx context.Context, e *env) error {
+		_, err := e.client.Syncing(ctx)
+		return err
+	}},
+	{method: "eth_chainId", run: func(ctx context.Context, e *env) error {
+		_, err := e.client.ChainID(ctx)
+		return err
+	}},
+	{method: "eth_gasPrice", run: func(ctx context.Context, e *env) error {
+		_, err := e.client.GasPrice(ctx)
+		return err
+	}},
+	{method: "eth_maxPriorityFeePerGas", run: func(ctx context.Context, e *env) error {
+		_, err := e.client.MaxPriorityFeePerGas(ctx)
+		return err
+	}},
+	{method: "eth_accounts", run: func(ctx context.Context, e *env) error {
+		accounts, err := e.client.Accounts(ctx)
+		if err != nil {
+			return err
+		}
+		e.accounts = accounts
+		return nil
+	}},
+	{method: "eth_blockNumber", run: func(ctx context.Context, e *env) error {
+		_, err := e.client.BlockNumber(ctx)
+		return err
+	}},
+	{method: "eth_getBalance", run: func(ctx context.Context, e *env) error {
+		_, err := e.client.GetBalance(ctx, e.account(ctx), types.LatestBlockNumber)
+		return err
+	}},
+	{method: "eth_getStorageAt", run: func(ctx context.Context, e *env) error {
+		_, err := e.client.GetStorageAt(ctx, e.account(ctx), types.Hash{}, types.LatestBlockNumber)
+		return err
+	}},
+	{method: "eth_getTransactionCount", run: func(ctx context.Context, e *env) error {
+		_, err := e.client.GetTransactionCount(ctx, e.account(ctx), types.LatestBlockNumber)
+		return err
+	}},
+	{method: "eth_getCode", run: func(ctx context.Context, e *env) error {
+		_, err := e.client.GetCode(ctx, e.account(ctx), types.LatestBlockNumber)
+		return err
+	}},
+	{method: "eth_sign", run: func(ctx context.Context, e *env) error {
+		if !e.sendTransactions || e.account(ctx) == types.ZeroAddress {
+			return errSkip
+		}
+		_, err := e.client.Sign(ctx, e.account(ctx), []byte("conformance"))
+		return err
+	}},
+	{method: "eth_sendTransaction", run: func(ctx context.Context, e *env) error {
+		if !e.sendTransactions {
+			return errSkip
+		}
+		_, err := e.transaction(ctx)
+		return err
+	}},
+	{method: "eth_getBlockByNumber", run: func(ctx context.Context, e *env) error {
+		if _, err := e.client.BlockByNumber(ctx, types.LatestBlockNumber, false); err != nil {
+			return err
+		}
+		_, err := e.client.BlockByNumber(ctx, types.LatestBlockNumber, true)
+		return err
+	}},
+	{method: "eth_getBlockByHash", run: func(ctx context.Context, e *env) error {
+		block, err := e.latestBlock(ctx)
+		if err != nil {
+			return err
+		}
+		if _, err := e.client.BlockByHash(ctx, block.Hash, false); err != nil {
+			return err
+		}
+		_, err = e.client.BlockByHash(ctx, block.Hash, true)
+		return err
+	}},
+	{method: "eth_getBlockTransactionCountByHash", run: func(ctx context.Context, e *env) error {
+		block, err := e.latestBlock(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = e.client.GetBlockTransactionCountByHash(ctx, block.Hash)
+		return err
+	}},
+	{method: "eth_getBlockTransactionCountByNumber", run: func(ctx context.Context, e *env) error {
+		_, err := e.client.GetBlockTransactionCountByNumber(ctx, types.LatestBlockNumber)
+		return err
+	}},
+	{method: "eth_getUncleCountByBlockHash", run: func(ctx context.Context, e *env) error {
+		block, err := e.latestBlock(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = e.client.GetUncleCountByBlockHash(ctx, block.Hash)
+		return err
+	}},
+	{method: "eth_getUncleCountByBlockNumber", run: func(ctx context.Context, e *env) error {
+		_, err := e.client.GetUncleCountByBlockNumber(ctx, types.LatestBlockNumber)
+		return err
+	}},
+	{method: "eth_getTransactionReceipt", run: func(ctx context.Context, e *env) error {
+		_, err := e.transaction(ctx)
+		return err
+	}},
+	{method: "eth_getTransactionByHash", run: func(ctx context.Context, e *env) error {
+		receipt, err := e.transaction(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = e.client.GetTransactionByHash(ctx, receipt.TransactionHash)
+		return err
+	}},
+	{method: "eth_getTransactionByBlockHashAndIndex", run: func(ctx context.Context, e *env) error {
+		receipt, err := e.transaction(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = e.client.GetTransactionByBlockHashAndIndex(ctx, receipt.BlockHash, receipt.TransactionIndex)
+		return err
+	}},
+	{method: "eth_getTransactionByBlockNumberAndIndex", run: func(ctx context.Context, e *env) error {
+		receipt, err := e.transaction(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = e.client.GetTransactionByBlockNumberAndIndex(ctx, types.BlockNumberFromBigInt(receipt.BlockNumber), receipt.TransactionIndex)
+		return err
+	}},
+	{method: "eth_getBlockReceipts", run: func(ctx context.Context, e *env) error {
+		_, err := e.client.GetBlockReceipts(ctx, types.LatestBlockNumber)
+		return err
+	}},
+	{method: "eth_call", run: func(ctx context.Context, e *env) error {
+		addr := e.account(ctx)
+		_, _, err := e.client.Call(ctx, types.NewCall().SetFrom(addr).SetTo(addr), types.LatestBlockNumber)
+		return err
+	}},
+	{method: "eth_estimateGas", run: func(ctx context.Context, e *env) error {
+		addr := e.account(ctx)
+		_, _, err := e.client.EstimateGas(ctx, types.NewCall().SetFrom(addr).SetTo(addr), types.LatestBlockNumber)
+		return err
+	}},
+	{method: "eth_feeHistory", run: func(ctx context.Context, e *env) error {
+		_, err := e.client.FeeHistory(ctx, 4, types.LatestBlockNumber, []float64{25, 75})
+		return err
+	}},
+	{method: "eth_getLogs", run: func(ctx context.Context, e *env) error {
+		_, err := e.client.GetLogs(ctx, types.NewFilterLogsQuery().
+			SetFromBlock(&types.LatestBlockNumber).
+			SetToBlock(&types.LatestBlockNumber))
+		return err
+	}},
+	{method: "eth_newFilter", run: func(ctx context.Context, e *env) error {
+		id, err := e.client.NewFilter(ctx, types.NewFilterLogsQuery().SetFromBlock(&types.LatestBlockNumber))
+		if err != nil {
+			return err
+		}
+		e.filters = append(e.filters, id)
+		return nil
+	}},
+	{method: "eth_getFilterLogs", run: func(ctx context.Context, e *env) error {
+		if len(e.filters) == 0 {
+			return errSkip
+		}
+		_, err := e.client.GetFilterLogs(ctx, e.filters[0])
+		return err
+	}},
+	{method: "eth_getFilterChanges", run: func(ctx context.Context, e *env) error {
+		if len(e.filters) == 0 {
+			return errSkip
+		}
+		_, err := e.client.GetFilterChanges(ctx, e.filters[0])
+		return err
+	}},
+	{method: "eth_newBlockFilter", run: func(ctx context.Context, e *env) error {
+		id, err := e.client.NewBlockFilter(ctx)
+		if err != nil {
+			return err
+		}
+		e.filters = append(e.filters, id)
+		_, err = e.client.GetBlockFilterChanges(ctx, id)
+		return err
+	}},
+	{method: "eth_newPendingTransactionFilter", run: func(ctx context.Context, e *env) error {
+		id, err := e.client.NewPendingTransactionFilter(ctx)
+		if err != nil {
+			return err
+		}
+		e.filters = append(e.filters, id)
+		return nil
+	}},
+	{method: "eth_uninstallFilter", run: func(ctx context.Context, e *env) error {
+		if len(e.filters) == 0 {
+			return errSkip
+		}
+		for _, id := range e.filters {
+			if _, err := e.client.UninstallFilter(ctx, id); err != nil {
+				return err
+			}
+		}
+		e.filters = nil
+		return nil
+	}},
+}
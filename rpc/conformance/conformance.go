@@ -0,0 +1,276 @@
+// Package conformance provides a harness that runs the RPC client against
+// a live node and checks the requests sent by the client and the responses
+// returned by the node against the Ethereum execution-apis OpenRPC
+// specification.
+//
+// The harness is meant to be run against a development node, such as anvil,
+// to detect both provider drift and marshalling bugs in the client:
+//
+//	report, err := conformance.Run(ctx, conformance.Options{
+//		Transport:        t,
+//		SendTransactions: true,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	for _, r := range report.Results {
+//		fmt.Println(r)
+//	}
+//
+// Three kinds of checks are performed for every call:
+//
+//   - request: the parameters sent by the client must match the method
+//     params schema,
+//   - response: the result returned by the node must match the method
+//     result schema,
+//   - roundtrip: the result decoded by the client and encoded again must
+//     still match the method result schema.
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/defiweb/go-eth/jsoncodec"
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/rpc/transport"
+)
+
+// Stage is the stage of a call at which a violation was detected.
+type Stage string
+
+const (
+	RequestStage   Stage = "request"   // RequestStage is the request sent by the client.
+	ResponseStage  Stage = "response"  // ResponseStage is the response returned by the node.
+	RoundtripStage Stage = "roundtrip" // RoundtripStage is the response decoded and encoded again by the client.
+)
+
+// errSkip is returned by checks that cannot be run, for example because
+// the node has no transactions.
+var errSkip = errors.New("skipped")
+
+// Options is the options for Run.
+type Options struct {
+	// Transport is the transport used to connect to the node.
+	Transport transport.Transport
+
+	// Spec is the specification to check against. If nil, the embedded
+	// subset of the execution-apis specification is used.
+	Spec *Spec
+
+	// SendTransactions allows the harness to send a transaction from the
+	// first account returned by eth_accounts. It must be enabled only for
+	// development nodes with unlocked accounts, such as anvil.
+	SendTransactions bool
+
+	// Methods limits the checks to the given methods. If empty, all methods
+	// are checked.
+	Methods []string
+}
+
+// Violation is a single conformance violation.
+type Violation struct {
+	Method  string // Method is the JSON-RPC method name.
+	Stage   Stage  // Stage is the stage at which the violation was detected.
+	Message string // Message describes the violation.
+}
+
+// String implements the fmt.Stringer interface.
+func (v Violation) String() string {
+	return fmt.Sprintf("%s (%s): %s", v.Method, v.Stage, v.Message)
+}
+
+// Result is the result of a single check.
+type Result struct {
+	Method     string      // Method is the JSON-RPC method being checked.
+	Skipped    bool        // Skipped is true if the check could not be run.
+	Err        error       // Err is the error returned by the client, if any.
+	Violations []Violation // Violations is the list of violations.
+}
+
+// Passed returns true if the check was run and no errors or violations were
+// found.
+func (r Result) Passed() bool {
+	return !r.Skipped && r.Err == nil && len(r.Violations) == 0
+}
+
+// String implements the fmt.Stringer interface.
+func (r Result) String() string {
+	switch {
+	case r.Skipped:
+		return fmt.Sprintf("SKIP %s", r.Method)
+	case r.Passed():
+		return fmt.Sprintf("PASS %s", r.Method)
+	}
+	var sb strings.Builder
+	sb.WriteString("FAIL ")
+	sb.WriteString(r.Method)
+	if r.Err != nil {
+		sb.WriteString(": ")
+		sb.WriteString(r.Err.Error())
+	}
+	for _, v := range r.Violations {
+		sb.WriteString("\n\t")
+		sb.WriteString(v.String())
+	}
+	return sb.String()
+}
+
+// Report is the result of Run.
+type Report struct {
+	Results []Result
+}
+
+// Failed returns the results of checks that failed.
+func (r *Report) Failed() []Result {
+	var failed []Result
+	for _, res := range r.Results {
+		if !res.Skipped && !res.Passed() {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// Run runs the conformance checks. The returned error is not nil only if
+// the harness could not be started; failed checks are reported in the
+// returned report.
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	if opts.Transport == nil {
+		return nil, errors.New("conformance: transport is required")
+	}
+	if opts.Spec == nil {
+		opts.Spec = DefaultSpec()
+	}
+	rec := &recorder{transport: opts.Transport}
+	client, err := rpc.NewClient(rpc.WithTransport(rec))
+	if err != nil {
+		return nil, fmt.Errorf("conformance: %w", err)
+	}
+	env := &env{client: client, sendTransactions: opts.SendTransactions}
+	filter := make(map[string]bool, len(opts.Methods))
+	for _, m := range opts.Methods {
+		filter[m] = true
+	}
+	report := &Report{}
+	for _, c := range checks {
+		if len(filter) > 0 && !filter[c.method] {
+			continue
+		}
+		rec.reset()
+		err := c.run(ctx, env)
+		res := Result{Method: c.method}
+		switch {
+		case errors.Is(err, errSkip):
+			res.Skipped = true
+		case err != nil:
+			res.Err = err
+		}
+		for _, call := range rec.reset() {
+			res.Violations = append(res.Violations, validateCall(opts.Spec, call)...)
+		}
+		report.Results = append(report.Results, res)
+	}
+	return report, nil
+}
+
+// validateCall validates a single recorded call.
+func validateCall(spec *Spec, c call) []Violation {
+	if !spec.HasMethod(c.method) {
+		return nil
+	}
+	var vs []Violation
+	add := func(stage Stage, msgs []string) {
+		for _, m := range msgs {
+			vs = append(vs, Violation{Method: c.method, Stage: stage, Message: m})
+		}
+	}
+	add(RequestStage, spec.ValidateParams(c.method, c.params))
+	if c.err != nil {
+		return vs
+	}
+	add(ResponseStage, spec.ValidateResult(c.method, c.result))
+	if c.decodeErr != nil {
+		add(RoundtripStage, []string{fmt.Sprintf("failed to decode result: %v", c.decodeErr)})
+	} else if c.roundtrip != nil && !skipRoundtrip[c.method] {
+		add(RoundtripStage, spec.ValidateResult(c.method, c.roundtrip))
+	}
+	return vs
+}
+
+// skipRoundtrip lists methods whose results are decoded into types that are
+// not meant to be encoded back into the wire format, e.g. net_version is
+// decoded as a number but encoded by the specification as a decimal string.
+var skipRoundtrip = map[string]bool{
+	"net_version": true,
+}
+
+// call is a call recorded by the recorder.
+type call struct {
+	method    string
+	params    []json.RawMessage
+	result    json.RawMessage
+	roundtrip json.RawMessage
+	err       error
+	decodeErr error
+}
+
+// recorder is a transport that records requests and raw responses.
+type recorder struct {
+	transport transport.Transport
+
+	mu    sync.Mutex
+	calls []call
+}
+
+// Call implements the transport.Transport interface.
+func (r *recorder) Call(ctx context.Context, result any, method string, args ...any) error {
+	c := call{method: method}
+	for _, arg := range args {
+		p, err := jsoncodec.Marshal(arg)
+		if err != nil {
+			return err
+		}
+		c.params = append(c.params, p)
+	}
+	var raw json.RawMessage
+	c.err = r.transport.Call(ctx, &raw, method, args...)
+	if c.err == nil {
+		c.result = raw
+		if result != nil {
+			c.decodeErr = jsoncodec.Unmarshal(orNull(raw), result)
+			if c.decodeErr == nil {
+				if _, ok := result.(transport.StreamDecoder); !ok {
+					c.roundtrip, _ = jsoncodec.Marshal(result)
+				}
+			}
+		}
+	}
+	r.mu.Lock()
+	r.calls = append(r.calls, c)
+	r.mu.Unlock()
+	if c.err != nil {
+		return c.err
+	}
+	return c.decodeErr
+}
+
+// reset returns the recorded calls and clears the list.
+func (r *recorder) reset() []call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls := r.calls
+	r.calls = nil
+	return calls
+}
+
+func orNull(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 {
+		return json.RawMessage("null")
+	}
+	return raw
+}
@@ -0,0 +1,236 @@
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/rpc/transport"
+)
+
+const (
+	testHash    = `"0x1111111111111111111111111111111111111111111111111111111111111111"`
+	testAddress = `"0x2222222222222222222222222222222222222222"`
+)
+
+var testBloom = `"0x` + strings.Repeat("0", 512) + `"`
+
+var testBlock = `{
+	"hash": ` + testHash + `,
+	"parentHash": ` + testHash + `,
+	"sha3Uncles": ` + testHash + `,
+	"miner": ` + testAddress + `,
+	"stateRoot": ` + testHash + `,
+	"transactionsRoot": ` + testHash + `,
+	"receiptsRoot": ` + testHash + `,
+	"logsBloom": ` + testBloom + `,
+	"difficulty": "0x0",
+	"number": "0x10",
+	"gasLimit": "0x1c9c380",
+	"gasUsed": "0x5208",
+	"timestamp": "0x6553f100",
+	"extraData": "0x",
+	"mixHash": ` + testHash + `,
+	"nonce": "0x0000000000000000",
+	"baseFeePerGas": "0x7",
+	"size": "0x220",
+	"transactions": [` + testHash + `],
+	"uncles": []
+}`
+
+var testReceipt = `{
+	"transactionHash": ` + testHash + `,
+	"transactionIndex": "0x0",
+	"blockHash": ` + testHash + `,
+	"blockNumber": "0x10",
+	"from": ` + testAddress + `,
+	"to": ` + testAddress + `,
+	"cumulativeGasUsed": "0x5208",
+	"gasUsed": "0x5208",
+	"contractAddress": null,
+	"logs": [],
+	"logsBloom": ` + testBloom + `,
+	"status": "0x1",
+	"effectiveGasPrice": "0x7",
+	"type": "0x2"
+}`
+
+var testTransaction = `{
+	"blockHash": ` + testHash + `,
+	"blockNumber": "0x10",
+	"from": ` + testAddress + `,
+	"hash": ` + testHash + `,
+	"transactionIndex": "0x0",
+	"type": "0x2",
+	"nonce": "0x0",
+	"to": ` + testAddress + `,
+	"gas": "0x5208",
+	"value": "0x1",
+	"input": "0x",
+	"maxPriorityFeePerGas": "0x1",
+	"maxFeePerGas": "0x10",
+	"gasPrice": "0x7",
+	"accessList": [],
+	"chainId": "0x1",
+	"v": "0x0",
+	"yParity": "0x0",
+	"r": "0x1",
+	"s": "0x1"
+}`
+
+// fakeTransport returns canned responses.
+type fakeTransport struct {
+	responses map[string]string
+}
+
+func (f *fakeTransport) Call(_ context.Context, result any, method string, _ ...any) error {
+	res, ok := f.responses[method]
+	if !ok {
+		return transport.NewRPCError(transport.ErrCodeMethodNotFound, "method not found", nil)
+	}
+	return json.Unmarshal([]byte(res), result)
+}
+
+func newFakeTransport() *fakeTransport {
+	return &fakeTransport{responses: map[string]string{
+		"web3_clientVersion":                      `"anvil/v0.2.0"`,
+		"net_version":                             `"1"`,
+		"net_listening":                           `true`,
+		"net_peerCount":                           `"0x0"`,
+		"eth_syncing":                             `false`,
+		"eth_chainId":                             `"0x1"`,
+		"eth_gasPrice":                            `"0x7"`,
+		"eth_maxPriorityFeePerGas":                `"0x1"`,
+		"eth_accounts":                            `[` + testAddress + `]`,
+		"eth_blockNumber":                         `"0x10"`,
+		"eth_getBalance":                          `"0xde0b6b3a7640000"`,
+		"eth_getStorageAt":                        `"0x0000000000000000000000000000000000000000000000000000000000000000"`,
+		"eth_getTransactionCount":                 `"0x1"`,
+		"eth_getCode":                             `"0x"`,
+		"eth_sign":                                `"0x` + strings.Repeat("ab", 65) + `"`,
+		"eth_sendTransaction":                     testHash,
+		"eth_getBlockByNumber":                    testBlock,
+		"eth_getBlockByHash":                      testBlock,
+		"eth_getBlockTransactionCountByHash":      `"0x1"`,
+		"eth_getBlockTransactionCountByNumber":    `"0x1"`,
+		"eth_getUncleCountByBlockHash":            `"0x0"`,
+		"eth_getUncleCountByBlockNumber":          `"0x0"`,
+		"eth_getTransactionReceipt":               testReceipt,
+		"eth_getTransactionByHash":                testTransaction,
+		"eth_getTransactionByBlockHashAndIndex":   testTransaction,
+		"eth_getTransactionByBlockNumberAndIndex": testTransaction,
+		"eth_getBlockReceipts":                    `[` + testReceipt + `]`,
+		"eth_call":                                `"0x"`,
+		"eth_estimateGas":                         `"0x5208"`,
+		"eth_feeHistory":                          `{"oldestBlock": "0xd", "baseFeePerGas": ["0x7", "0x7", "0x7", "0x7", "0x7"], "gasUsedRatio": [0.5, 0.5, 0.5, 0.5], "reward": [["0x1", "0x2"], ["0x1", "0x2"], ["0x1", "0x2"], ["0x1", "0x2"]]}`,
+		"eth_getLogs":                             `[]`,
+		"eth_newFilter":                           `"0x1"`,
+		"eth_getFilterLogs":                       `[]`,
+		"eth_getFilterChanges":                    `[]`,
+		"eth_newBlockFilter":                      `"0x2"`,
+		"eth_newPendingTransactionFilter":         `"0x3"`,
+		"eth_uninstallFilter":                     `true`,
+	}}
+}
+
+func TestRun(t *testing.T) {
+	report, err := Run(context.Background(), Options{
+		Transport:        newFakeTransport(),
+		SendTransactions: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, report.Results, len(checks))
+	for _, r := range report.Results {
+		assert.True(t, r.Passed(), r.String())
+	}
+}
+
+func TestRun_Violations(t *testing.T) {
+	tr := newFakeTransport()
+	tr.responses["eth_chainId"] = `"0x01"`
+	tr.responses["eth_getBalance"] = `"1000"`
+	report, err := Run(context.Background(), Options{
+		Transport: tr,
+		Methods:   []string{"eth_chainId", "eth_getBalance", "eth_sendTransaction"},
+	})
+	require.NoError(t, err)
+	require.Len(t, report.Results, 3)
+
+	// Leading zeros are not allowed by the specification.
+	require.Len(t, report.Results[0].Violations, 1)
+	assert.Equal(t, ResponseStage, report.Results[0].Violations[0].Stage)
+
+	// Decimal numbers are accepted by the client, but not by the
+	// specification.
+	require.NoError(t, report.Results[1].Err)
+	require.Len(t, report.Results[1].Violations, 1)
+	assert.Equal(t, ResponseStage, report.Results[1].Violations[0].Stage)
+
+	// Sending transactions is disabled.
+	assert.True(t, report.Results[2].Skipped)
+	assert.Len(t, report.Failed(), 2)
+}
+
+func TestSpec_ValidateParams(t *testing.T) {
+	spec := DefaultSpec()
+	tests := []struct {
+		method string
+		params []string
+		valid  bool
+	}{
+		{method: "eth_getBalance", params: []string{testAddress, `"latest"`}, valid: true},
+		{method: "eth_getBalance", params: []string{testAddress, testHash}, valid: true},
+		{method: "eth_getBalance", params: []string{testAddress}, valid: true},
+		{method: "eth_getBalance", params: []string{}, valid: false},
+		{method: "eth_getBalance", params: []string{testAddress, `"newest"`}, valid: false},
+		{method: "eth_getBlockByNumber", params: []string{`"0x10"`, `false`}, valid: true},
+		{method: "eth_getBlockByNumber", params: []string{`"0x010"`, `false`}, valid: false},
+		{method: "eth_call", params: []string{`{"to": ` + testAddress + `, "input": "0xAB"}`, `"latest"`}, valid: false},
+		{method: "eth_getLogs", params: []string{`{"address": [` + testAddress + `], "topics": [null, [` + testHash + `]]}`}, valid: true},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			var params []json.RawMessage
+			for _, p := range tt.params {
+				params = append(params, json.RawMessage(p))
+			}
+			errs := spec.ValidateParams(tt.method, params)
+			if tt.valid {
+				assert.Empty(t, errs)
+			} else {
+				assert.NotEmpty(t, errs)
+			}
+		})
+	}
+}
+
+// TestConformance_Live runs the conformance checks against a live node.
+// The node URL is taken from the GOETH_CONFORMANCE_RPC_URL environment
+// variable; the test is skipped if it is not set. In CI, the checks are
+// run against anvil.
+func TestConformance_Live(t *testing.T) {
+	url := os.Getenv("GOETH_CONFORMANCE_RPC_URL")
+	if url == "" {
+		t.Skip("GOETH_CONFORMANCE_RPC_URL is not set")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	tr, err := transport.New(ctx, url)
+	require.NoError(t, err)
+	report, err := Run(ctx, Options{
+		Transport:        tr,
+		SendTransactions: os.Getenv("GOETH_CONFORMANCE_SEND_TX") != "",
+	})
+	require.NoError(t, err)
+	for _, r := range report.Results {
+		t.Log(r.String())
+	}
+	assert.Empty(t, report.Failed())
+}
@@ -0,0 +1,321 @@
+package conformance
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/defiweb/go-eth/rpc/internal/openrpc"
+)
+
+// openRPCSpec is a subset of the ethereum/execution-apis OpenRPC
+// specification that covers the methods implemented by the RPC client.
+//
+//go:embed openrpc.json
+var openRPCSpec []byte
+
+// Spec is a parsed OpenRPC specification.
+type Spec struct {
+	doc      *openrpc.Document
+	methods  map[string]*openrpc.Method
+	patterns map[string]*regexp.Regexp // Compiled schema patterns.
+}
+
+// DefaultSpec returns the embedded execution-apis specification.
+func DefaultSpec() *Spec {
+	s, err := ParseSpec(openRPCSpec)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// ParseSpec parses an OpenRPC specification in JSON format.
+//
+// Only the subset of JSON Schema used by the execution-apis specification
+// is supported: $ref, type, pattern, enum, required, properties, items,
+// oneOf and anyOf.
+func ParseSpec(data []byte) (*Spec, error) {
+	doc, err := openrpc.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("conformance: invalid specification: %w", err)
+	}
+	s := &Spec{
+		doc:      doc,
+		methods:  make(map[string]*openrpc.Method, len(doc.Methods)),
+		patterns: make(map[string]*regexp.Regexp),
+	}
+	for _, m := range doc.Methods {
+		s.methods[m.Name] = m
+		for _, p := range m.Params {
+			if err := s.compile(p.Schema); err != nil {
+				return nil, err
+			}
+		}
+		if m.Result != nil {
+			if err := s.compile(m.Result.Schema); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, sc := range doc.Components.Schemas {
+		if err := s.compile(sc); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Methods returns the names of the methods defined in the specification,
+// in alphabetical order.
+func (s *Spec) Methods() []string {
+	names := make([]string, 0, len(s.methods))
+	for name := range s.methods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasMethod returns true if the method is defined in the specification.
+func (s *Spec) HasMethod(method string) bool {
+	_, ok := s.methods[method]
+	return ok
+}
+
+// ValidateParams validates the request parameters of the given method.
+// It returns a list of violations; an empty list means that the
+// parameters conform to the specification.
+func (s *Spec) ValidateParams(method string, params []json.RawMessage) []string {
+	m, ok := s.methods[method]
+	if !ok {
+		return nil
+	}
+	var errs []string
+	if len(params) > len(m.Params) {
+		errs = append(errs, fmt.Sprintf("params: expected at most %d params, got %d", len(m.Params), len(params)))
+	}
+	for i, p := range m.Params {
+		path := fmt.Sprintf("params[%d] (%s)", i, p.Name)
+		if i >= len(params) {
+			if p.Required {
+				errs = append(errs, path+": required param is missing")
+			}
+			continue
+		}
+		var v any
+		if err := json.Unmarshal(params[i], &v); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: invalid JSON: %v", path, err))
+			continue
+		}
+		errs = s.validate(p.Schema, v, path, errs)
+	}
+	return errs
+}
+
+// ValidateResult validates the result of the given method. It returns a list
+// of violations; an empty list means that the result conforms to the
+// specification.
+func (s *Spec) ValidateResult(method string, result json.RawMessage) []string {
+	m, ok := s.methods[method]
+	if !ok {
+		return nil
+	}
+	var v any
+	if len(result) == 0 {
+		result = json.RawMessage("null")
+	}
+	if err := json.Unmarshal(result, &v); err != nil {
+		return []string{fmt.Sprintf("result: invalid JSON: %v", err)}
+	}
+	if m.Result == nil {
+		return nil
+	}
+	return s.validate(m.Result.Schema, v, "result", nil)
+}
+
+func (s *Spec) validate(sc *openrpc.Schema, v any, path string, errs []string) []string {
+	if sc == nil {
+		return errs
+	}
+	if sc.Ref != "" {
+		ref, err := s.doc.Resolve(sc.Ref)
+		if err != nil {
+			return append(errs, fmt.Sprintf("%s: %v", path, err))
+		}
+		return s.validate(ref, v, path, errs)
+	}
+	if len(sc.OneOf) > 0 {
+		var (
+			n    int
+			best []string
+		)
+		for _, alt := range sc.OneOf {
+			altErrs := s.validate(alt, v, path, nil)
+			if len(altErrs) == 0 {
+				n++
+				continue
+			}
+			if best == nil || (s.typeMatches(alt, v) && len(altErrs) < len(best)) {
+				best = altErrs
+			}
+		}
+		switch {
+		case n == 0:
+			// Report errors from the closest alternative, which is
+			// usually the one that was intended.
+			errs = append(errs, best...)
+		case n > 1:
+			errs = append(errs, fmt.Sprintf("%s: value %s must match exactly one schema, matched %d", path, short(v), n))
+		}
+	}
+	if len(sc.AnyOf) > 0 {
+		var (
+			ok   bool
+			best []string
+		)
+		for _, alt := range sc.AnyOf {
+			altErrs := s.validate(alt, v, path, nil)
+			if len(altErrs) == 0 {
+				ok = true
+				break
+			}
+			if best == nil || len(altErrs) < len(best) {
+				best = altErrs
+			}
+		}
+		if !ok {
+			errs = append(errs, best...)
+		}
+	}
+	if len(sc.Type) > 0 && !hasType(sc.Type, v) {
+		return append(errs, fmt.Sprintf("%s: expected %s, got %s", path, strings.Join(sc.Type, " or "), short(v)))
+	}
+	if len(sc.Enum) > 0 {
+		found := false
+		for _, e := range sc.Enum {
+			if e == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, fmt.Sprintf("%s: value %s is not one of %v", path, short(v), sc.Enum))
+		}
+	}
+	switch tv := v.(type) {
+	case string:
+		if sc.Pattern != "" {
+			if !s.patterns[sc.Pattern].MatchString(tv) {
+				errs = append(errs, fmt.Sprintf("%s: value %s does not match pattern %s", path, short(v), sc.Pattern))
+			}
+		}
+	case map[string]any:
+		for _, r := range sc.Required {
+			if _, ok := tv[r]; !ok {
+				errs = append(errs, fmt.Sprintf("%s.%s: required field is missing", path, r))
+			}
+		}
+		for _, k := range openrpc.SortedKeys(sc.Properties) {
+			if pv, ok := tv[k]; ok {
+				errs = s.validate(sc.Properties[k], pv, path+"."+k, errs)
+			}
+		}
+	case []any:
+		if sc.Items != nil {
+			for i, iv := range tv {
+				errs = s.validate(sc.Items, iv, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+	}
+	return errs
+}
+
+// typeMatches reports whether the value matches the top-level type of the
+// schema. Schemas without a type match any value.
+func (s *Spec) typeMatches(sc *openrpc.Schema, v any) bool {
+	for sc != nil && sc.Ref != "" {
+		ref, err := s.doc.Resolve(sc.Ref)
+		if err != nil {
+			return false
+		}
+		sc = ref
+	}
+	return sc == nil || len(sc.Type) == 0 || hasType(sc.Type, v)
+}
+
+// compile compiles the patterns of the schema and its subschemas.
+func (s *Spec) compile(sc *openrpc.Schema) error {
+	if sc == nil {
+		return nil
+	}
+	if _, ok := s.patterns[sc.Pattern]; sc.Pattern != "" && !ok {
+		re, err := regexp.Compile(sc.Pattern)
+		if err != nil {
+			return fmt.Errorf("conformance: invalid pattern %q: %w", sc.Pattern, err)
+		}
+		s.patterns[sc.Pattern] = re
+	}
+	subs := append(append([]*openrpc.Schema{sc.Items}, sc.OneOf...), sc.AnyOf...)
+	for _, p := range sc.Properties {
+		subs = append(subs, p)
+	}
+	for _, sub := range subs {
+		if err := s.compile(sub); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasType(types openrpc.SchemaType, v any) bool {
+	for _, t := range types {
+		switch t {
+		case "null":
+			if v == nil {
+				return true
+			}
+		case "boolean":
+			if _, ok := v.(bool); ok {
+				return true
+			}
+		case "string":
+			if _, ok := v.(string); ok {
+				return true
+			}
+		case "number":
+			if _, ok := v.(float64); ok {
+				return true
+			}
+		case "integer":
+			if f, ok := v.(float64); ok && f == float64(int64(f)) {
+				return true
+			}
+		case "object":
+			if _, ok := v.(map[string]any); ok {
+				return true
+			}
+		case "array":
+			if _, ok := v.([]any); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// short returns a shortened JSON representation of the value for use in
+// error messages.
+func short(v any) string {
+	if v == nil {
+		return "null"
+	}
+	b, _ := json.Marshal(v)
+	if len(b) > 80 {
+		return string(b[:77]) + "..."
+	}
+	return string(b)
+}
@@ -0,0 +1,220 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+var (
+	// insufficientFundsRegexp matches errors returned by nodes when the sender
+	// cannot pay for a transaction, e.g.:
+	//
+	//	insufficient funds for gas * price + value: address 0x... have 1 want 2
+	//	insufficient funds for transfer
+	insufficientFundsRegexp = regexp.MustCompile(`(?i)insufficient funds`)
+
+	// insufficientFundsHaveWantRegexp extracts the balance and the cost from
+	// the error message, if the node includes them.
+	insufficientFundsHaveWantRegexp = regexp.MustCompile(`(?i)have (\d+) want (\d+)`)
+)
+
+// ErrInsufficientFunds is returned by Client.SendTransaction when the node
+// rejects a transaction because the sender cannot pay for it. Errors of
+// type *InsufficientFundsError match it with errors.Is.
+var ErrInsufficientFunds = errors.New("rpc client: insufficient funds")
+
+// InsufficientFundsError is returned by Client.SendTransaction when the node
+// rejects a transaction because the sender cannot pay for it.
+type InsufficientFundsError struct {
+	Address   types.Address // Address is the sender address.
+	Balance   *big.Int      // Balance is the balance of the sender.
+	Cost      *big.Int      // Cost is the maximum cost of the transaction, see types.Transaction.MaxCost.
+	Shortfall *big.Int      // Shortfall is the amount of wei missing to pay for the transaction.
+	Err       error         // Err is the error returned by the node.
+}
+
+// Error implements the error interface.
+func (e *InsufficientFundsError) Error() string {
+	return fmt.Sprintf(
+		"%s: %s: balance %s wei, cost %s wei, shortfall %s wei",
+		ErrInsufficientFunds, e.Address, e.Balance, e.Cost, e.Shortfall,
+	)
+}
+
+// Unwrap returns the error returned by the node.
+func (e *InsufficientFundsError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrInsufficientFunds.
+func (e *InsufficientFundsError) Is(target error) bool {
+	return target == ErrInsufficientFunds
+}
+
+// insufficientFundsError converts an "insufficient funds" error returned by
+// the node into an InsufficientFundsError. The balance is fetched again,
+// because the balance reported by the node may be stale or missing. Other
+// errors are returned unchanged.
+func (c *Client) insufficientFundsError(ctx context.Context, tx *types.Transaction, err error) error {
+	if err == nil || tx.From == nil || !insufficientFundsRegexp.MatchString(err.Error()) {
+		return err
+	}
+	e := &InsufficientFundsError{
+		Address: *tx.From,
+		Cost:    tx.MaxCost(),
+		Err:     err,
+	}
+	if m := insufficientFundsHaveWantRegexp.FindStringSubmatch(err.Error()); m != nil {
+		e.Balance, _ = new(big.Int).SetString(m[1], 10)
+		if e.Cost == nil {
+			e.Cost, _ = new(big.Int).SetString(m[2], 10)
+		}
+	}
+	if balance, err := c.GetBalance(ctx, *tx.From, types.PendingBlockNumber); err == nil {
+		e.Balance = balance
+	}
+	if e.Balance == nil || e.Cost == nil {
+		return err
+	}
+	e.Shortfall = new(big.Int).Sub(e.Cost, e.Balance)
+	if e.Shortfall.Sign() < 0 {
+		e.Shortfall.SetUint64(0)
+	}
+	return e
+}
+
+// ErrTXNotApproved is returned by SignTransaction and SendTransaction when
+// the transaction approver denies the transaction or does not respond in
+// time. Errors of type *TXNotApprovedError match it with errors.Is.
+var ErrTXNotApproved = errors.New("rpc client: transaction not approved")
+
+// TXNotApprovedError is returned when the transaction approver denies the
+// transaction or does not respond in time.
+type TXNotApprovedError struct {
+	Err error // Err is the error returned by the approver or the context error.
+}
+
+// Error implements the error interface.
+func (e *TXNotApprovedError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrTXNotApproved, e.Err)
+}
+
+// Unwrap returns the error returned by the approver or the context error.
+func (e *TXNotApprovedError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrTXNotApproved.
+func (e *TXNotApprovedError) Is(target error) bool {
+	return target == ErrTXNotApproved
+}
+
+// ErrSignNotAllowed is returned by Client.Sign and Client.SignHash when the
+// sign policy of the key does not allow the requested kind of signing.
+// Errors of type *SignPolicyError match it with errors.Is.
+var ErrSignNotAllowed = errors.New("rpc client: signing not allowed by policy")
+
+// SignPolicyError is returned when the sign policy of a key does not allow
+// the requested kind of signing.
+type SignPolicyError struct {
+	Address types.Address // Address is the address of the key.
+	Policy  SignPolicy    // Policy is the policy required for the request.
+}
+
+// Error implements the error interface.
+func (e *SignPolicyError) Error() string {
+	return fmt.Sprintf("%s: sign policy of %s does not allow %s signing", ErrSignNotAllowed, e.Address, e.Policy)
+}
+
+// Is reports whether target is ErrSignNotAllowed.
+func (e *SignPolicyError) Is(target error) bool {
+	return target == ErrSignNotAllowed
+}
+
+// ErrNotFound is returned when the node responds with null to a request for
+// a block, transaction, receipt or uncle. It means that the object does not
+// exist, the transaction is still pending, or the node has not synced it
+// yet. The returned error wraps ErrNotFound and describes the requested
+// object, use errors.Is to check for it.
+var ErrNotFound = errors.New("rpc client: not found")
+
+// ErrNotReplayProtected is returned by SignTransaction and SendTransaction
+// when the WithStrictEIP155 option is used and the transaction is a legacy
+// transaction without a chain ID.
+var ErrNotReplayProtected = errors.New("rpc client: legacy transaction without chain ID is not replay protected")
+
+// ErrTXCostExceeded is returned by SignTransaction and SendTransaction when
+// the transaction cost exceeds the limit set by WithMaxTxCost. Errors of
+// type *TXCostExceededError match it with errors.Is.
+var ErrTXCostExceeded = errors.New("rpc client: transaction cost exceeds the limit")
+
+// TXCostExceededError is returned when the transaction cost exceeds the
+// limit set by WithMaxTxCost.
+type TXCostExceededError struct {
+	Cost  *big.Int // Cost is the maximum cost of the transaction, nil if it cannot be calculated.
+	Limit *big.Int // Limit is the maximum allowed cost.
+}
+
+// Error implements the error interface.
+func (e *TXCostExceededError) Error() string {
+	if e.Cost == nil {
+		return fmt.Sprintf("%s: unable to calculate cost, gas limit or fee is not set", ErrTXCostExceeded)
+	}
+	return fmt.Sprintf("%s: cost %s wei, limit %s wei", ErrTXCostExceeded, e.Cost, e.Limit)
+}
+
+// Is reports whether target is ErrTXCostExceeded.
+func (e *TXCostExceededError) Is(target error) bool {
+	return target == ErrTXCostExceeded
+}
+
+// ErrGuard is returned by SignTransaction and SendTransaction when the
+// transaction is refused by one of the guards enabled by WithMaxFeePerGas,
+// WithBlockGasLimitGuard or WithBalanceGuard. Errors of type *GuardError
+// match it with errors.Is.
+var ErrGuard = errors.New("rpc client: transaction refused by guard")
+
+// Guard is a sanity check applied to transactions before they are signed.
+type Guard uint8
+
+const (
+	MaxFeePerGasGuard  Guard = iota // MaxFeePerGasGuard limits the fee per gas, see WithMaxFeePerGas.
+	BlockGasLimitGuard              // BlockGasLimitGuard limits the gas limit to the block gas limit, see WithBlockGasLimitGuard.
+	BalanceGuard                    // BalanceGuard limits the value to the sender balance, see WithBalanceGuard.
+)
+
+// String implements the fmt.Stringer interface.
+func (g Guard) String() string {
+	switch g {
+	case MaxFeePerGasGuard:
+		return "max fee per gas"
+	case BlockGasLimitGuard:
+		return "block gas limit"
+	case BalanceGuard:
+		return "balance"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(g))
+	}
+}
+
+// GuardError is returned when a transaction is refused by a guard.
+type GuardError struct {
+	Guard Guard    // Guard is the violated guard.
+	Value *big.Int // Value is the checked value of the transaction.
+	Limit *big.Int // Limit is the maximum allowed value.
+}
+
+// Error implements the error interface.
+func (e *GuardError) Error() string {
+	return fmt.Sprintf("%s: %s guard: value %s exceeds limit %s", ErrGuard, e.Guard, e.Value, e.Limit)
+}
+
+// Is reports whether target is ErrGuard.
+func (e *GuardError) Is(target error) bool {
+	return target == ErrGuard
+}
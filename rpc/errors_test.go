@@ -0,0 +1,78 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/rpc/transport"
+	"github.com/defiweb/go-eth/types"
+)
+
+func TestClient_SendTransaction_InsufficientFunds(t *testing.T) {
+	from := types.MustAddressFromHex("0xb60e8dd61c5d32be8058bb8eb970870f07233155")
+	sendErr := transport.NewRPCError(
+		transport.ErrCodeGeneral,
+		"insufficient funds for gas * price + value: address 0xb60e8dd61c5d32be8058bb8eb970870f07233155 have 100 want 300",
+		nil,
+	)
+	tests := []struct {
+		name       string
+		tx         *types.Transaction
+		balance    any
+		balanceErr error
+		want       *InsufficientFundsError
+	}{
+		{
+			name:    "fresh balance and computed cost",
+			tx:      types.NewTransaction().SetFrom(from).SetGasLimit(21000).SetGasPrice(big.NewInt(1)).SetValue(big.NewInt(1000)),
+			balance: "0x3e8",
+			want:    &InsufficientFundsError{Address: from, Balance: big.NewInt(1000), Cost: big.NewInt(22000), Shortfall: big.NewInt(21000)},
+		},
+		{
+			name:       "values reported by node",
+			tx:         types.NewTransaction().SetFrom(from),
+			balanceErr: errors.New("unavailable"),
+			want:       &InsufficientFundsError{Address: from, Balance: big.NewInt(100), Cost: big.NewInt(300), Shortfall: big.NewInt(200)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(WithTransport(&callMock{Handler: func(method string, args ...any) (any, error) {
+				switch method {
+				case "eth_sendTransaction":
+					return nil, sendErr
+				case "eth_getBalance":
+					return tt.balance, tt.balanceErr
+				}
+				return nil, errors.New("unexpected method")
+			}}))
+			require.NoError(t, err)
+
+			_, _, err = client.SendTransaction(context.Background(), tt.tx)
+			var ifErr *InsufficientFundsError
+			require.ErrorAs(t, err, &ifErr)
+			assert.ErrorIs(t, err, ErrInsufficientFunds)
+			assert.Equal(t, tt.want.Address, ifErr.Address)
+			assert.Equal(t, tt.want.Balance, ifErr.Balance)
+			assert.Equal(t, tt.want.Cost, ifErr.Cost)
+			assert.Equal(t, tt.want.Shortfall, ifErr.Shortfall)
+			assert.ErrorIs(t, err, sendErr)
+		})
+	}
+}
+
+func TestClient_SendTransaction_OtherError(t *testing.T) {
+	sendErr := transport.NewRPCError(transport.ErrCodeGeneral, "nonce too low", nil)
+	client, err := NewClient(WithTransport(&callMock{Handler: func(string, ...any) (any, error) {
+		return nil, sendErr
+	}}))
+	require.NoError(t, err)
+
+	_, _, err = client.SendTransaction(context.Background(), types.NewTransaction().SetFrom(types.ZeroAddress))
+	assert.Equal(t, sendErr, err)
+}
@@ -0,0 +1,234 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// uninstallTimeout is the timeout for the eth_uninstallFilter call made when
+// a filter is closed or garbage collected.
+const uninstallTimeout = 10 * time.Second
+
+// ErrFilterClosed is returned by Filter methods after the filter was closed.
+var ErrFilterClosed = errors.New("rpc client: filter closed")
+
+// FilterKind is the kind of node-side filter.
+type FilterKind uint8
+
+const (
+	LogsFilterKind               FilterKind = iota // LogsFilterKind is a filter created with eth_newFilter.
+	BlockFilterKind                                // BlockFilterKind is a filter created with eth_newBlockFilter.
+	PendingTransactionFilterKind                   // PendingTransactionFilterKind is a filter created with eth_newPendingTransactionFilter.
+)
+
+// String implements the fmt.Stringer interface.
+func (k FilterKind) String() string {
+	switch k {
+	case LogsFilterKind:
+		return "logs"
+	case BlockFilterKind:
+		return "block"
+	case PendingTransactionFilterKind:
+		return "pending-transaction"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(k))
+	}
+}
+
+// FilterInfo describes an active filter.
+type FilterInfo struct {
+	ID      *big.Int   // ID is the filter ID assigned by the node.
+	Kind    FilterKind // Kind is the kind of the filter.
+	Created time.Time  // Created is the time the filter was created.
+}
+
+// Filter is a handle to a node-side filter.
+//
+// Nodes keep filters until they are uninstalled or until they time out
+// after not being polled for a while. To avoid leaking filters on providers
+// with long timeouts, the filter is uninstalled when Close is called or,
+// as a safety net, when the Filter is garbage collected. Relying on garbage
+// collection is discouraged, because it may happen much later or not at all.
+type Filter struct {
+	info   FilterInfo
+	client *Client
+	once   sync.Once
+	err    error
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// filterRegistry keeps track of filters that have not been uninstalled yet.
+// It does not hold references to Filter handles, so that they can be
+// garbage collected.
+type filterRegistry struct {
+	mu      sync.Mutex
+	filters map[string]FilterInfo
+}
+
+func newFilterRegistry() *filterRegistry {
+	return &filterRegistry{filters: make(map[string]FilterInfo)}
+}
+
+func (r *filterRegistry) add(info FilterInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.filters[info.ID.String()] = info
+}
+
+func (r *filterRegistry) remove(id *big.Int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.filters, id.String())
+}
+
+func (r *filterRegistry) list() []FilterInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]FilterInfo, 0, len(r.filters))
+	for _, f := range r.filters {
+		list = append(list, f)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Created.Before(list[j].Created)
+	})
+	return list
+}
+
+// LogsFilter creates a log filter using eth_newFilter. The filter must be
+// closed after use.
+func (c *Client) LogsFilter(ctx context.Context, query *types.FilterLogsQuery) (*Filter, error) {
+	id, err := c.NewFilter(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return c.newFilter(id, LogsFilterKind), nil
+}
+
+// BlockFilter creates a new block filter using eth_newBlockFilter. The
+// filter must be closed after use.
+func (c *Client) BlockFilter(ctx context.Context) (*Filter, error) {
+	id, err := c.NewBlockFilter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.newFilter(id, BlockFilterKind), nil
+}
+
+// PendingTransactionFilter creates a new pending transaction filter using
+// eth_newPendingTransactionFilter. The filter must be closed after use.
+func (c *Client) PendingTransactionFilter(ctx context.Context) (*Filter, error) {
+	id, err := c.NewPendingTransactionFilter(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return c.newFilter(id, PendingTransactionFilterKind), nil
+}
+
+// ActiveFilters returns filters created with LogsFilter, BlockFilter and
+// PendingTransactionFilter that have not been uninstalled yet, ordered by
+// creation time.
+func (c *Client) ActiveFilters() []FilterInfo {
+	return c.filters.list()
+}
+
+func (c *Client) newFilter(id *big.Int, kind FilterKind) *Filter {
+	f := &Filter{
+		info:   FilterInfo{ID: id, Kind: kind, Created: time.Now()},
+		client: c,
+	}
+	c.filters.add(f.info)
+	runtime.SetFinalizer(f, func(f *Filter) {
+		// Finalizers run on a single goroutine, so the call must not
+		// block it.
+		go f.uninstall() //nolint:errcheck
+	})
+	return f
+}
+
+// ID returns the filter ID assigned by the node.
+func (f *Filter) ID() *big.Int {
+	return f.info.ID
+}
+
+// Kind returns the kind of the filter.
+func (f *Filter) Kind() FilterKind {
+	return f.info.Kind
+}
+
+// Logs returns logs emitted since the last poll, using eth_getFilterChanges.
+// It can only be used with log filters.
+func (f *Filter) Logs(ctx context.Context) ([]types.Log, error) {
+	if err := f.check(LogsFilterKind); err != nil {
+		return nil, err
+	}
+	return f.client.GetFilterChanges(ctx, f.info.ID)
+}
+
+// AllLogs returns all logs matching the filter, using eth_getFilterLogs.
+// It can only be used with log filters.
+func (f *Filter) AllLogs(ctx context.Context) ([]types.Log, error) {
+	if err := f.check(LogsFilterKind); err != nil {
+		return nil, err
+	}
+	return f.client.GetFilterLogs(ctx, f.info.ID)
+}
+
+// Hashes returns hashes of new blocks or pending transactions since the
+// last poll, using eth_getFilterChanges. It can only be used with block and
+// pending transaction filters.
+func (f *Filter) Hashes(ctx context.Context) ([]types.Hash, error) {
+	if err := f.check(BlockFilterKind, PendingTransactionFilterKind); err != nil {
+		return nil, err
+	}
+	return f.client.GetBlockFilterChanges(ctx, f.info.ID)
+}
+
+// Close uninstalls the filter. It is safe to call Close multiple times.
+func (f *Filter) Close() error {
+	f.mu.Lock()
+	f.closed = true
+	f.mu.Unlock()
+	runtime.SetFinalizer(f, nil)
+	return f.uninstall()
+}
+
+// uninstall uninstalls the filter on the node and removes it from the
+// registry. Only the first call has an effect.
+func (f *Filter) uninstall() error {
+	f.once.Do(func() {
+		defer f.client.filters.remove(f.info.ID)
+		ctx, cancel := context.WithTimeout(context.Background(), uninstallTimeout)
+		defer cancel()
+		if _, err := f.client.UninstallFilter(ctx, f.info.ID); err != nil {
+			f.err = fmt.Errorf("rpc client: failed to uninstall filter %s: %w", f.info.ID, err)
+		}
+	})
+	return f.err
+}
+
+// check returns an error if the filter is closed or is not one of the
+// given kinds.
+func (f *Filter) check(kinds ...FilterKind) error {
+	f.mu.Lock()
+	closed := f.closed
+	f.mu.Unlock()
+	if closed {
+		return ErrFilterClosed
+	}
+	for _, k := range kinds {
+		if f.info.Kind == k {
+			return nil
+		}
+	}
+	return fmt.Errorf("rpc client: method not supported by %s filter", f.info.Kind)
+}
@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"context"
+	"math/big"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// filterMock simulates node-side filters.
+type filterMock struct {
+	mu        sync.Mutex
+	next      uint64
+	installed map[string]bool
+}
+
+func newFilterMock() *filterMock {
+	return &filterMock{installed: make(map[string]bool)}
+}
+
+func (m *filterMock) handler(method string, args ...any) (any, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch method {
+	case "eth_newFilter", "eth_newBlockFilter", "eth_newPendingTransactionFilter":
+		m.next++
+		id := types.NumberFromUint64(m.next)
+		m.installed[id.Big().String()] = true
+		return id, nil
+	case "eth_uninstallFilter":
+		n := args[0].(types.Number)
+		id := n.Big().String()
+		ok := m.installed[id]
+		delete(m.installed, id)
+		return ok, nil
+	case "eth_getFilterChanges":
+		return []types.Hash{}, nil
+	}
+	return nil, nil
+}
+
+func (m *filterMock) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.installed)
+}
+
+func TestClient_Filter(t *testing.T) {
+	node := newFilterMock()
+	client, err := NewClient(WithTransport(&callMock{Handler: node.handler}))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	logs, err := client.LogsFilter(ctx, types.NewFilterLogsQuery())
+	require.NoError(t, err)
+	blocks, err := client.BlockFilter(ctx)
+	require.NoError(t, err)
+
+	active := client.ActiveFilters()
+	require.Len(t, active, 2)
+	assert.Equal(t, big.NewInt(1), active[0].ID)
+	assert.Equal(t, LogsFilterKind, active[0].Kind)
+	assert.Equal(t, BlockFilterKind, active[1].Kind)
+	assert.Equal(t, 2, node.count())
+
+	_, err = blocks.Hashes(ctx)
+	require.NoError(t, err)
+	_, err = blocks.Logs(ctx)
+	require.Error(t, err)
+
+	require.NoError(t, logs.Close())
+	require.NoError(t, logs.Close())
+	_, err = logs.Logs(ctx)
+	require.ErrorIs(t, err, ErrFilterClosed)
+	assert.Len(t, client.ActiveFilters(), 1)
+	assert.Equal(t, 1, node.count())
+	require.NoError(t, blocks.Close())
+}
+
+func TestClient_Filter_Finalizer(t *testing.T) {
+	node := newFilterMock()
+	client, err := NewClient(WithTransport(&callMock{Handler: node.handler}))
+	require.NoError(t, err)
+
+	_, err = client.PendingTransactionFilter(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, node.count())
+
+	require.Eventually(t, func() bool {
+		runtime.GC()
+		return node.count() == 0 && len(client.ActiveFilters()) == 0
+	}, time.Second, 10*time.Millisecond)
+}
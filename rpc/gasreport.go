@@ -0,0 +1,214 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// refundQuotient is the maximum portion of the gas used that can be
+// refunded, as defined in EIP-3529.
+const refundQuotient = 5
+
+// StructLogTrace is the result of debug_traceTransaction with the default
+// struct logger.
+type StructLogTrace struct {
+	Gas         uint64      `json:"gas"`         // Gas is the gas used by the transaction, after refunds.
+	Failed      bool        `json:"failed"`      // Failed is true if the transaction reverted.
+	ReturnValue string      `json:"returnValue"` // ReturnValue is the hex-encoded return data.
+	StructLogs  []StructLog `json:"structLogs"`  // StructLogs is the list of executed opcodes.
+}
+
+// StructLog is a single step of a struct log trace.
+type StructLog struct {
+	PC      uint64   `json:"pc"`               // PC is the program counter.
+	Op      string   `json:"op"`               // Op is the opcode name.
+	Gas     uint64   `json:"gas"`              // Gas is the remaining gas before the opcode is executed.
+	GasCost uint64   `json:"gasCost"`          // GasCost is the cost of the opcode as reported by the node.
+	Depth   int      `json:"depth"`            // Depth is the call depth, starting from 1.
+	Refund  uint64   `json:"refund,omitempty"` // Refund is the refund counter before the opcode is executed.
+	Memory  []string `json:"memory,omitempty"` // Memory is the memory before the opcode is executed, in 32-byte words.
+	Error   string   `json:"error,omitempty"`  // Error is the error that occurred while executing the opcode.
+}
+
+// GasReport is a breakdown of the gas used by a transaction.
+type GasReport struct {
+	GasUsed      uint64 // GasUsed is the gas used by the transaction, after refunds.
+	IntrinsicGas uint64 // IntrinsicGas is the gas charged before the execution, including calldata and access list costs.
+	ExecutionGas uint64 // ExecutionGas is the gas used by the executed opcodes.
+
+	// Refund is the refund counter at the end of the execution, which is
+	// the sum of SSTORE refunds. AppliedRefund is the part of it that was
+	// refunded, which is capped at 1/5 of the gas used before refunds and
+	// is zero for failed transactions.
+	Refund        uint64
+	AppliedRefund uint64
+
+	// MemoryExpansion is the gas spent on memory expansion. It is included
+	// in the costs of the opcodes that expanded the memory. It is only
+	// available if the trace includes memory.
+	MemoryExpansion uint64
+
+	// Opcodes is the gas used per opcode, sorted by gas in descending
+	// order. The cost of call and create opcodes does not include the gas
+	// used by the called contract.
+	Opcodes []OpcodeGas
+
+	// Cost is the cost of the transaction in wei. It is only set by
+	// Client.GasReport.
+	Cost *big.Int
+}
+
+// OpcodeGas is the gas used by all executions of an opcode.
+type OpcodeGas struct {
+	Op    string // Op is the opcode name.
+	Count uint64 // Count is the number of executions.
+	Gas   uint64 // Gas is the total gas used.
+}
+
+// GasReport traces the transaction using debug_traceTransaction and returns
+// a breakdown of the gas it used. The node must support the debug namespace.
+//
+// Tracing with memory can produce large responses for complex
+// transactions.
+func (c *Client) GasReport(ctx context.Context, hash types.Hash) (*GasReport, error) {
+	receipt, err := c.GetTransactionReceipt(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	var trace StructLogTrace
+	config := map[string]any{
+		"enableMemory":     true,
+		"disableStack":     true,
+		"disableStorage":   true,
+		"enableReturnData": false,
+	}
+	if err := c.transport.Call(ctx, &trace, "debug_traceTransaction", hash, config); err != nil {
+		return nil, fmt.Errorf("rpc client: failed to trace transaction %s: %w", hash, err)
+	}
+	report := AnalyzeStructLogs(&trace)
+	if receipt.EffectiveGasPrice != nil {
+		report.Cost = new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), receipt.EffectiveGasPrice)
+	}
+	return report, nil
+}
+
+// AnalyzeStructLogs returns a breakdown of the gas used by a transaction
+// from its struct log trace.
+func AnalyzeStructLogs(trace *StructLogTrace) *GasReport {
+	report := &GasReport{GasUsed: trace.Gas}
+	logs := trace.StructLogs
+	costs := make([]uint64, len(logs))
+
+	// Frames of the calls in progress, indexed by depth - 1.
+	type frame struct {
+		last      int    // Index of the last step in the frame, -1 if none.
+		used      uint64 // Gas used by the frame, excluding the last step.
+		children  uint64 // Gas used by child frames after the last step.
+		memoryLen int    // Memory size in words before the last step.
+	}
+	frames := []frame{{last: -1}}
+	for i, l := range logs {
+		for l.Depth < len(frames) && len(frames) > 1 {
+			// Returned from a call, the gas used by the callee is added
+			// to the caller.
+			child := frames[len(frames)-1]
+			frames = frames[:len(frames)-1]
+			used := child.used + child.children
+			if child.last >= 0 {
+				used += costs[child.last]
+			}
+			frames[len(frames)-1].children += used
+		}
+		for l.Depth > len(frames) {
+			frames = append(frames, frame{last: -1})
+		}
+		f := &frames[len(frames)-1]
+		if f.last >= 0 {
+			prev := logs[f.last]
+			if isCallOp(prev.Op) && prev.Gas >= l.Gas+f.children {
+				// The cost reported for calls includes the gas passed to
+				// the callee, so the actual cost is calculated from the
+				// remaining gas after the call.
+				costs[f.last] = prev.Gas - l.Gas - f.children
+			}
+			f.used += costs[f.last] + f.children
+			if len(l.Memory) > f.memoryLen {
+				report.MemoryExpansion += memoryCost(len(l.Memory)) - memoryCost(f.memoryLen)
+			}
+		}
+		costs[i] = l.GasCost
+		f.last = i
+		f.children = 0
+		f.memoryLen = len(l.Memory)
+	}
+
+	// Aggregate the costs per opcode. Costs of steps in child frames are
+	// already excluded from the costs of call opcodes.
+	byOp := make(map[string]*OpcodeGas)
+	for i, l := range logs {
+		o := byOp[l.Op]
+		if o == nil {
+			o = &OpcodeGas{Op: l.Op}
+			byOp[l.Op] = o
+		}
+		o.Count++
+		o.Gas += costs[i]
+		report.ExecutionGas += costs[i]
+	}
+	for _, o := range byOp {
+		report.Opcodes = append(report.Opcodes, *o)
+	}
+	sort.Slice(report.Opcodes, func(i, j int) bool {
+		if report.Opcodes[i].Gas != report.Opcodes[j].Gas {
+			return report.Opcodes[i].Gas > report.Opcodes[j].Gas
+		}
+		return report.Opcodes[i].Op < report.Opcodes[j].Op
+	})
+
+	if len(logs) > 0 {
+		report.Refund = logs[len(logs)-1].Refund
+	}
+	if !trace.Failed {
+		report.AppliedRefund = appliedRefund(trace.Gas, report.Refund)
+	}
+	if used := trace.Gas + report.AppliedRefund; used > report.ExecutionGas {
+		report.IntrinsicGas = used - report.ExecutionGas
+	}
+	return report
+}
+
+// appliedRefund returns the refund applied to a transaction that used the
+// given amount of gas after refunds, for the given refund counter.
+func appliedRefund(gasUsed, refund uint64) uint64 {
+	if refund <= (gasUsed+refund)/refundQuotient {
+		return refund
+	}
+	// The refund is capped, find the gas used before refunds. It is at most
+	// gasUsed * 5/4 and at least one less.
+	hi := gasUsed * refundQuotient / (refundQuotient - 1)
+	for used := hi; used >= gasUsed && used+1 >= hi; used-- {
+		if used-used/refundQuotient == gasUsed {
+			return used / refundQuotient
+		}
+	}
+	return 0
+}
+
+// memoryCost returns the cost of memory of the given size in words.
+func memoryCost(words int) uint64 {
+	w := uint64(words)
+	return w*3 + w*w/512
+}
+
+// isCallOp returns true if the opcode creates a new call frame.
+func isCallOp(op string) bool {
+	switch op {
+	case "CALL", "CALLCODE", "DELEGATECALL", "STATICCALL", "CREATE", "CREATE2":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package rpc
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+func words(n int) []string {
+	return make([]string, n)
+}
+
+func TestAnalyzeStructLogs(t *testing.T) {
+	trace := &StructLogTrace{
+		Gas: 21162,
+		StructLogs: []StructLog{
+			{Op: "PUSH1", Gas: 1000, GasCost: 3, Depth: 1},
+			{Op: "MSTORE", Gas: 997, GasCost: 6, Depth: 1},
+			// The cost of the call includes the gas passed to the callee.
+			{Op: "CALL", Gas: 991, GasCost: 500, Depth: 1, Memory: words(1)},
+			{Op: "PUSH1", Gas: 480, GasCost: 3, Depth: 2},
+			{Op: "SSTORE", Gas: 477, GasCost: 100, Depth: 2},
+			{Op: "STOP", Gas: 377, GasCost: 0, Depth: 2, Refund: 50},
+			// The call cost 100 gas and the callee used 103 gas.
+			{Op: "STOP", Gas: 788, GasCost: 0, Depth: 1, Refund: 50, Memory: words(2)},
+		},
+	}
+	report := AnalyzeStructLogs(trace)
+	assert.Equal(t, uint64(21162), report.GasUsed)
+	assert.Equal(t, uint64(212), report.ExecutionGas)
+	assert.Equal(t, uint64(21000), report.IntrinsicGas)
+	assert.Equal(t, uint64(50), report.Refund)
+	assert.Equal(t, uint64(50), report.AppliedRefund)
+	assert.Equal(t, uint64(6), report.MemoryExpansion)
+	assert.Equal(t, []OpcodeGas{
+		{Op: "CALL", Count: 1, Gas: 100},
+		{Op: "SSTORE", Count: 1, Gas: 100},
+		{Op: "MSTORE", Count: 1, Gas: 6},
+		{Op: "PUSH1", Count: 2, Gas: 6},
+		{Op: "STOP", Count: 2, Gas: 0},
+	}, report.Opcodes)
+
+	// Refunds are not applied to failed transactions.
+	trace.Failed = true
+	assert.Equal(t, uint64(0), AnalyzeStructLogs(trace).AppliedRefund)
+}
+
+func TestAppliedRefund(t *testing.T) {
+	tests := []struct {
+		gasUsed uint64
+		refund  uint64
+		want    uint64
+	}{
+		{gasUsed: 9000, refund: 1000, want: 1000},
+		{gasUsed: 8000, refund: 5000, want: 2000},
+		{gasUsed: 8001, refund: 5000, want: 2000},
+		{gasUsed: 0, refund: 0, want: 0},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, appliedRefund(tt.gasUsed, tt.refund))
+	}
+}
+
+func TestClient_GasReport(t *testing.T) {
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		switch method {
+		case "eth_getTransactionReceipt":
+			return &types.TransactionReceipt{GasUsed: 21003, EffectiveGasPrice: big.NewInt(2)}, nil
+		case "debug_traceTransaction":
+			assert.Equal(t, true, args[1].(map[string]any)["enableMemory"])
+			return map[string]any{
+				"gas":         21003,
+				"failed":      false,
+				"returnValue": "",
+				"structLogs": []map[string]any{
+					{"pc": 0, "op": "PUSH1", "gas": 100, "gasCost": 3, "depth": 1},
+					{"pc": 2, "op": "STOP", "gas": 97, "gasCost": 0, "depth": 1},
+				},
+			}, nil
+		}
+		t.Fatalf("unexpected call %s", method)
+		return nil, nil
+	}}
+	client, err := NewClient(WithTransport(mock))
+	require.NoError(t, err)
+
+	report, err := client.GasReport(context.Background(), types.Hash{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), report.ExecutionGas)
+	assert.Equal(t, uint64(21000), report.IntrinsicGas)
+	assert.Equal(t, big.NewInt(42006), report.Cost)
+}
@@ -0,0 +1,149 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// Liveness is implemented by subscriptions whose liveness is checked by
+// Client.Health. It is implemented by Subscription.
+type Liveness interface {
+	// Err returns the reason the subscription ended, or nil if it is
+	// active.
+	Err() error
+}
+
+// HealthOptions is the options for Client.Health.
+type HealthOptions struct {
+	// MaxHeadAge is the maximum age of the latest block for the node to be
+	// considered healthy. A node that is connected to the network but does
+	// not receive new blocks still reports that it is synced. If zero, the
+	// age is not checked.
+	MaxHeadAge time.Duration
+
+	// Accounts is the list of accounts whose pending transactions are
+	// counted. If nil, the addresses of the keys provided with WithKeys
+	// are used.
+	Accounts []types.Address
+
+	// Subscriptions are the subscriptions to check, by name.
+	Subscriptions map[string]Liveness
+}
+
+// HealthStatus is the result of Client.Health.
+type HealthStatus struct {
+	// Reachable is true if the node responded to the request for the
+	// latest block. Latency is the duration of that request.
+	Reachable bool
+	Latency   time.Duration
+
+	// Head is the number of the latest block and HeadAge is the time since
+	// its timestamp. Stale is true if HeadAge exceeds MaxHeadAge.
+	Head    uint64
+	HeadAge time.Duration
+	Stale   bool
+
+	// Lag is the number of blocks the node is behind the highest known
+	// block, as reported by eth_syncing. Synced is true if the lag does not
+	// exceed the tolerance set by WithSyncTolerance.
+	Lag    uint64
+	Synced bool
+
+	// PendingTransactions is the number of transactions of every account
+	// that are sent but not yet included in a block.
+	PendingTransactions map[types.Address]uint64
+
+	// Subscriptions is the error of every checked subscription, nil for
+	// active subscriptions.
+	Subscriptions map[string]error
+
+	// Err is the first error that occurred during the check.
+	Err error
+}
+
+// Ready returns true if the node is reachable, synced and receives new
+// blocks, all checks succeeded and all checked subscriptions are active.
+// It is intended to be used in readiness probes.
+func (h *HealthStatus) Ready() bool {
+	if !h.Reachable || !h.Synced || h.Stale || h.Err != nil {
+		return false
+	}
+	for _, err := range h.Subscriptions {
+		if err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Health checks the state of the node and of the given subscriptions.
+//
+// Errors do not stop the check, the status contains the results of all
+// checks that succeeded and the first error in the Err field.
+func (c *Client) Health(ctx context.Context, opts HealthOptions) *HealthStatus {
+	status := &HealthStatus{
+		PendingTransactions: make(map[types.Address]uint64),
+		Subscriptions:       make(map[string]error, len(opts.Subscriptions)),
+	}
+	setErr := func(err error) {
+		if status.Err == nil {
+			status.Err = err
+		}
+	}
+	for name, sub := range opts.Subscriptions {
+		status.Subscriptions[name] = sub.Err()
+	}
+
+	start := time.Now()
+	block, err := c.BlockByNumber(ctx, types.LatestBlockNumber, false)
+	if err != nil {
+		setErr(fmt.Errorf("rpc client: failed to fetch latest block: %w", err))
+		return status
+	}
+	status.Reachable = true
+	status.Latency = time.Since(start)
+	if block.Number != nil {
+		status.Head = block.Number.Uint64()
+	}
+	if age := time.Since(block.Timestamp); age > 0 {
+		status.HeadAge = age
+	}
+	status.Stale = opts.MaxHeadAge > 0 && status.HeadAge > opts.MaxHeadAge
+
+	if sync, err := c.Syncing(ctx); err != nil {
+		setErr(fmt.Errorf("rpc client: failed to fetch sync status: %w", err))
+	} else {
+		if sync != nil {
+			status.Lag = sync.Lag()
+		}
+		status.Synced = status.Lag <= c.syncLag
+	}
+
+	accounts := opts.Accounts
+	if accounts == nil {
+		for _, key := range c.keys {
+			accounts = append(accounts, key.Address())
+		}
+	}
+	for _, addr := range accounts {
+		pending, err := c.GetTransactionCount(ctx, addr, types.PendingBlockNumber)
+		if err != nil {
+			setErr(fmt.Errorf("rpc client: failed to fetch pending nonce of %s: %w", addr, err))
+			continue
+		}
+		latest, err := c.GetTransactionCount(ctx, addr, types.LatestBlockNumber)
+		if err != nil {
+			setErr(fmt.Errorf("rpc client: failed to fetch nonce of %s: %w", addr, err))
+			continue
+		}
+		if pending > latest {
+			status.PendingTransactions[addr] = pending - latest
+		} else {
+			status.PendingTransactions[addr] = 0
+		}
+	}
+	return status
+}
@@ -0,0 +1,89 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+type livenessMock struct {
+	err error
+}
+
+func (l livenessMock) Err() error {
+	return l.err
+}
+
+func TestClient_Health(t *testing.T) {
+	var (
+		addr     = types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+		headTime = time.Now().Add(-30 * time.Second)
+		syncing  = `false`
+		down     = false
+	)
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		if down {
+			return nil, errors.New("connection refused")
+		}
+		switch method {
+		case "eth_getBlockByNumber":
+			return &types.Block{Number: big.NewInt(100), Timestamp: headTime}, nil
+		case "eth_syncing":
+			return json.RawMessage(syncing), nil
+		case "eth_getTransactionCount":
+			if block := args[1].(types.BlockNumber); block.IsPending() {
+				return types.NumberFromUint64(12), nil
+			}
+			return types.NumberFromUint64(10), nil
+		}
+		t.Fatalf("unexpected call %s", method)
+		return nil, nil
+	}}
+	client, err := NewClient(WithTransport(mock))
+	require.NoError(t, err)
+
+	opts := HealthOptions{
+		Accounts:      []types.Address{addr},
+		Subscriptions: map[string]Liveness{"heads": livenessMock{}},
+	}
+	h := client.Health(context.Background(), opts)
+	assert.True(t, h.Ready())
+	assert.True(t, h.Reachable)
+	assert.True(t, h.Synced)
+	assert.False(t, h.Stale)
+	assert.Equal(t, uint64(100), h.Head)
+	assert.GreaterOrEqual(t, h.HeadAge, 29*time.Second)
+	assert.Equal(t, map[types.Address]uint64{addr: 2}, h.PendingTransactions)
+	assert.Equal(t, map[string]error{"heads": nil}, h.Subscriptions)
+
+	// The head is older than allowed.
+	opts.MaxHeadAge = 10 * time.Second
+	h = client.Health(context.Background(), opts)
+	assert.True(t, h.Stale)
+	assert.False(t, h.Ready())
+
+	// The node is syncing and the subscription has ended.
+	opts.MaxHeadAge = 0
+	opts.Subscriptions["heads"] = livenessMock{err: ErrSubscriptionClosed}
+	syncing = `{"startingBlock": "0x0", "currentBlock": "0x64", "highestBlock": "0x80"}`
+	h = client.Health(context.Background(), opts)
+	assert.False(t, h.Synced)
+	assert.Equal(t, uint64(28), h.Lag)
+	assert.ErrorIs(t, h.Subscriptions["heads"], ErrSubscriptionClosed)
+	assert.False(t, h.Ready())
+
+	// The node is down.
+	down = true
+	h = client.Health(context.Background(), opts)
+	assert.False(t, h.Reachable)
+	assert.Error(t, h.Err)
+	assert.False(t, h.Ready())
+}
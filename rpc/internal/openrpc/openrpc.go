@@ -0,0 +1,127 @@
+// Package openrpc parses OpenRPC documents, such as the execution-apis
+// specification published at https://github.com/ethereum/execution-apis.
+// It is shared by the openrpcgen and conformance packages.
+//
+// Only the subset of JSON Schema used by the execution-apis specification
+// is supported: $ref, type, pattern, enum, required, properties, items,
+// oneOf, anyOf and allOf.
+package openrpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// schemaRefPrefix is the prefix of references to component schemas.
+const schemaRefPrefix = "#/components/schemas/"
+
+// Document is the subset of an OpenRPC document that describes methods
+// and component schemas.
+type Document struct {
+	Methods    []*Method `json:"methods"`
+	Components struct {
+		Schemas map[string]*Schema `json:"schemas"`
+	} `json:"components"`
+}
+
+// Method is an RPC method.
+type Method struct {
+	Name        string   `json:"name"`
+	Summary     string   `json:"summary"`
+	Description string   `json:"description"`
+	Params      []*Param `json:"params"`
+	Result      *Param   `json:"result"`
+	Deprecated  bool     `json:"deprecated"`
+}
+
+// Param is a method parameter or result.
+type Param struct {
+	Name     string  `json:"name"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// Schema is a JSON Schema.
+type Schema struct {
+	Ref         string             `json:"$ref"`
+	Title       string             `json:"title"`
+	Description string             `json:"description"`
+	Type        SchemaType         `json:"type"`
+	Pattern     string             `json:"pattern"`
+	Enum        []any              `json:"enum"`
+	Required    []string           `json:"required"`
+	Properties  map[string]*Schema `json:"properties"`
+	Items       *Schema            `json:"items"`
+	OneOf       []*Schema          `json:"oneOf"`
+	AnyOf       []*Schema          `json:"anyOf"`
+	AllOf       []*Schema          `json:"allOf"`
+}
+
+// SchemaType is a JSON Schema type, which may be either a single type or
+// a list of types.
+type SchemaType []string
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (t *SchemaType) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*t = SchemaType{s}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// Parse parses an OpenRPC document in JSON format.
+func Parse(data []byte) (*Document, error) {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// Method returns the method with the given name, or nil if the document
+// does not define it.
+func (d *Document) Method(name string) *Method {
+	for _, m := range d.Methods {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+// Resolve returns the component schema referenced by ref.
+func (d *Document) Resolve(ref string) (*Schema, error) {
+	name, ok := RefName(ref)
+	if !ok {
+		return nil, fmt.Errorf("unsupported reference %q", ref)
+	}
+	s, ok := d.Components.Schemas[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown reference %q", ref)
+	}
+	return s, nil
+}
+
+// RefName returns the name of the component schema referenced by ref. It
+// returns false if ref does not reference a component schema.
+func RefName(ref string) (string, bool) {
+	if !strings.HasPrefix(ref, schemaRefPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(ref, schemaRefPrefix), true
+}
+
+// SortedKeys returns the keys of the map in alphabetical order, e.g. the
+// names of schema properties.
+func SortedKeys(m map[string]*Schema) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
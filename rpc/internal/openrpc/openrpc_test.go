@@ -0,0 +1,41 @@
+package openrpc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	doc, err := Parse([]byte(`{
+		"methods": [{
+			"name": "eth_chainId",
+			"params": [],
+			"result": {"name": "Chain ID", "schema": {"$ref": "#/components/schemas/uint"}}
+		}],
+		"components": {"schemas": {
+			"uint": {"title": "hex encoded unsigned integer", "type": "string", "pattern": "^0x([1-9a-f]+[0-9a-f]*|0)$"},
+			"nullable": {"type": ["string", "null"]}
+		}}
+	}`))
+	require.NoError(t, err)
+
+	m := doc.Method("eth_chainId")
+	require.NotNil(t, m)
+	assert.Nil(t, doc.Method("eth_blockNumber"))
+
+	s, err := doc.Resolve(m.Result.Schema.Ref)
+	require.NoError(t, err)
+	assert.Equal(t, SchemaType{"string"}, s.Type)
+	assert.Equal(t, SchemaType{"string", "null"}, doc.Components.Schemas["nullable"].Type)
+	assert.Equal(t, []string{"nullable", "uint"}, SortedKeys(doc.Components.Schemas))
+
+	_, err = doc.Resolve("#/components/schemas/missing")
+	assert.Error(t, err)
+	_, err = doc.Resolve("#/definitions/uint")
+	assert.Error(t, err)
+
+	_, err = Parse([]byte(`{"methods": {}}`))
+	assert.Error(t, err)
+}
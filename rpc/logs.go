@@ -0,0 +1,35 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// GetLogsAtBlock returns logs emitted in the block with the given hash,
+// optionally filtered by addresses and topics.
+//
+// Unlike querying by block number, querying by block hash is not affected
+// by reorgs: either the logs of exactly that block are returned or the call
+// fails if the node does not know the block.
+//
+// Some nodes silently ignore the blockHash field and return logs from the
+// latest block instead. To detect this, the method verifies that every
+// returned log belongs to the requested block.
+func (c *Client) GetLogsAtBlock(ctx context.Context, blockHash types.Hash, addresses []types.Address, topics [][]types.Hash) ([]types.Log, error) {
+	query := types.NewFilterLogsQuery().
+		SetBlockHash(&blockHash).
+		SetAddresses(addresses...).
+		SetTopics(topics...)
+	logs, err := c.GetLogs(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	for _, l := range logs {
+		if l.BlockHash != nil && *l.BlockHash != blockHash {
+			return nil, fmt.Errorf("node returned a log from block %s instead of %s", l.BlockHash, blockHash)
+		}
+	}
+	return logs, nil
+}
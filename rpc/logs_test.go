@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+func TestClient_GetLogsAtBlock(t *testing.T) {
+	blockHash := types.MustHashFromHex("0x1111111111111111111111111111111111111111111111111111111111111111", types.PadNone)
+	otherHash := types.MustHashFromHex("0x2222222222222222222222222222222222222222222222222222222222222222", types.PadNone)
+	addr := types.MustAddressFromHex("0x3333333333333333333333333333333333333333")
+	topic := types.MustHashFromHex("0x4444444444444444444444444444444444444444444444444444444444444444", types.PadNone)
+
+	var (
+		query   json.RawMessage
+		logHash = blockHash
+	)
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		require.Equal(t, "eth_getLogs", method)
+		query, _ = json.Marshal(args[0])
+		return []types.Log{{Address: addr, BlockHash: &logHash}}, nil
+	}}
+	client, err := NewClient(WithTransport(mock))
+	require.NoError(t, err)
+
+	logs, err := client.GetLogsAtBlock(context.Background(), blockHash, []types.Address{addr}, [][]types.Hash{{topic}})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.JSONEq(t, `{
+		"address": "0x3333333333333333333333333333333333333333",
+		"topics": ["0x4444444444444444444444444444444444444444444444444444444444444444"],
+		"blockHash": "0x1111111111111111111111111111111111111111111111111111111111111111"
+	}`, string(query))
+
+	// Node ignored the block hash.
+	logHash = otherHash
+	_, err = client.GetLogsAtBlock(context.Background(), blockHash, nil, nil)
+	require.Error(t, err)
+}
+
+func TestClient_GetLogs_InvalidQuery(t *testing.T) {
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		t.Fatalf("unexpected call %s", method)
+		return nil, nil
+	}}
+	client, err := NewClient(WithTransport(mock))
+	require.NoError(t, err)
+
+	blockHash := types.MustHashFromHex("0x1111111111111111111111111111111111111111111111111111111111111111", types.PadNone)
+	query := types.NewFilterLogsQuery().
+		SetBlockHash(&blockHash).
+		SetFromBlock(types.BlockNumberFromUint64Ptr(1))
+	_, err = client.GetLogs(context.Background(), query)
+	require.Error(t, err)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -40,8 +41,27 @@ func newHTTPMock() *httpMock {
 	return h
 }
 
+// callMock is a transport that passes calls to the Handler function. The value
+// returned by the handler is marshaled to JSON and unmarshaled into the result.
+type callMock struct {
+	Handler func(method string, args ...any) (any, error)
+}
+
+func (c *callMock) Call(_ context.Context, result any, method string, args ...any) error {
+	res, err := c.Handler(method, args...)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, result)
+}
+
 type streamMock struct {
-	t *testing.T
+	t  *testing.T
+	mu sync.Mutex
 
 	SubscribeMocks   []subscribeMock
 	UnsubscribeMocks []unsubscribeMock
@@ -69,6 +89,8 @@ func (s *streamMock) Call(_ context.Context, _ any, _ string, _ ...any) error {
 }
 
 func (s *streamMock) Subscribe(_ context.Context, method string, args ...any) (ch chan json.RawMessage, id string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	require.NotEmpty(s.t, s.SubscribeMocks)
 	m := s.SubscribeMocks[0]
 	s.SubscribeMocks = s.SubscribeMocks[1:]
@@ -81,6 +103,8 @@ func (s *streamMock) Subscribe(_ context.Context, method string, args ...any) (c
 }
 
 func (s *streamMock) Unsubscribe(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	require.NotEmpty(s.t, s.UnsubscribeMocks)
 	m := s.UnsubscribeMocks[0]
 	s.UnsubscribeMocks = s.UnsubscribeMocks[1:]
@@ -88,6 +112,14 @@ func (s *streamMock) Unsubscribe(_ context.Context, id string) error {
 	return m.ResultErr
 }
 
+// pendingUnsubscribes returns the number of unsubscribe mocks that have not
+// been used yet.
+func (s *streamMock) pendingUnsubscribes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.UnsubscribeMocks)
+}
+
 type keyMock struct {
 	addressCallback         func() types.Address
 	signHashCallback        func(hash types.Hash) (*types.Signature, error)
@@ -118,3 +150,5 @@ func (k *keyMock) VerifyHash(ctx context.Context, hash types.Hash, sig types.Sig
 func (k keyMock) VerifyMessage(ctx context.Context, data []byte, sig types.Signature) bool {
 	return false
 }
+
+func (k *keyMock) Destroy() {}
@@ -0,0 +1,89 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+)
+
+// ClientVersion is a parsed web3_clientVersion string.
+//
+// Most clients report the version in the form
+// "name[/identity]/version/platform[/runtime]", e.g.
+// "Geth/v1.13.5-stable-916d6a44/linux-amd64/go1.21.4" or
+// "Nethermind/v1.25.4+20b10b35/linux-x64/dotnet8.0.2". Fields that are not
+// reported are empty.
+type ClientVersion struct {
+	Raw      string // Raw is the unparsed version string.
+	Name     string // Name is the client name, e.g. "Geth".
+	Identity string // Identity is the optional node name set by the operator.
+	Version  string // Version is the client version, e.g. "v1.13.5-stable-916d6a44".
+	Platform string // Platform is the OS and architecture, e.g. "linux-amd64".
+	Runtime  string // Runtime is the language runtime, e.g. "go1.21.4".
+}
+
+// NodeInfo identifies the node the client is connected to.
+type NodeInfo struct {
+	Client  ClientVersion // Client is the parsed client version.
+	ChainID uint64        // ChainID is the chain ID reported by the node.
+
+	// ProtocolVersion is the Ethereum protocol version, or 0 if the node
+	// does not support eth_protocolVersion, which was removed from most
+	// clients after the merge.
+	ProtocolVersion uint64
+}
+
+// ParseClientVersion parses the string returned by web3_clientVersion.
+func ParseClientVersion(s string) ClientVersion {
+	cv := ClientVersion{Raw: s}
+	parts := strings.Split(s, "/")
+	cv.Name = parts[0]
+	parts = parts[1:]
+	for i, p := range parts {
+		if !isVersion(p) {
+			continue
+		}
+		cv.Identity = strings.Join(parts[:i], "/")
+		cv.Version = p
+		parts = parts[i+1:]
+		if len(parts) > 0 {
+			cv.Platform = parts[0]
+		}
+		if len(parts) > 1 {
+			cv.Runtime = strings.Join(parts[1:], "/")
+		}
+		return cv
+	}
+	// No part looks like a version, assume the "name/version" form.
+	if len(parts) > 0 {
+		cv.Version = parts[0]
+	}
+	return cv
+}
+
+// NodeInfo returns information about the node, for example to be logged
+// when connecting to a node.
+func (c *Client) NodeInfo(ctx context.Context) (*NodeInfo, error) {
+	version, err := c.ClientVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	chainID, err := c.ChainID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	info := &NodeInfo{
+		Client:  ParseClientVersion(version),
+		ChainID: chainID,
+	}
+	if protocolVersion, err := c.ProtocolVersion(ctx); err == nil {
+		info.ProtocolVersion = protocolVersion
+	}
+	return info, nil
+}
+
+// isVersion returns true if s looks like a version number, optionally
+// prefixed with "v".
+func isVersion(s string) bool {
+	s = strings.TrimPrefix(s, "v")
+	return len(s) > 0 && s[0] >= '0' && s[0] <= '9' && strings.Contains(s, ".")
+}
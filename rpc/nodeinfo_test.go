@@ -0,0 +1,80 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClientVersion(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want ClientVersion
+	}{
+		{
+			arg:  "Geth/v1.13.5-stable-916d6a44/linux-amd64/go1.21.4",
+			want: ClientVersion{Name: "Geth", Version: "v1.13.5-stable-916d6a44", Platform: "linux-amd64", Runtime: "go1.21.4"},
+		},
+		{
+			arg:  "Geth/mynode/v1.13.5-stable/linux-amd64/go1.21.4",
+			want: ClientVersion{Name: "Geth", Identity: "mynode", Version: "v1.13.5-stable", Platform: "linux-amd64", Runtime: "go1.21.4"},
+		},
+		{
+			arg:  "Nethermind/v1.25.4+20b10b35/linux-x64/dotnet8.0.2",
+			want: ClientVersion{Name: "Nethermind", Version: "v1.25.4+20b10b35", Platform: "linux-x64", Runtime: "dotnet8.0.2"},
+		},
+		{
+			arg:  "erigon/2.55.1/linux-amd64/go1.21.5",
+			want: ClientVersion{Name: "erigon", Version: "2.55.1", Platform: "linux-amd64", Runtime: "go1.21.5"},
+		},
+		{
+			arg:  "reth/v0.1.0-alpha.13-7b7b8e8/x86_64-unknown-linux-gnu",
+			want: ClientVersion{Name: "reth", Version: "v0.1.0-alpha.13-7b7b8e8", Platform: "x86_64-unknown-linux-gnu"},
+		},
+		{
+			arg:  "HardhatNetwork/2.19.0/@ethereumjs/vm/5.9.3",
+			want: ClientVersion{Name: "HardhatNetwork", Version: "2.19.0", Platform: "@ethereumjs", Runtime: "vm/5.9.3"},
+		},
+		{
+			arg:  "anvil/dev",
+			want: ClientVersion{Name: "anvil", Version: "dev"},
+		},
+		{
+			arg:  "custom",
+			want: ClientVersion{Name: "custom"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			tt.want.Raw = tt.arg
+			assert.Equal(t, tt.want, ParseClientVersion(tt.arg))
+		})
+	}
+}
+
+func TestClient_NodeInfo(t *testing.T) {
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		switch method {
+		case "web3_clientVersion":
+			return "Geth/v1.13.5-stable-916d6a44/linux-amd64/go1.21.4", nil
+		case "eth_chainId":
+			return "0x1", nil
+		case "eth_protocolVersion":
+			return nil, errors.New("the method eth_protocolVersion does not exist/is not available")
+		}
+		t.Fatalf("unexpected call %s", method)
+		return nil, nil
+	}}
+	client, err := NewClient(WithTransport(mock))
+	require.NoError(t, err)
+
+	info, err := client.NodeInfo(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "Geth", info.Client.Name)
+	assert.Equal(t, "v1.13.5-stable-916d6a44", info.Client.Version)
+	assert.Equal(t, uint64(1), info.ChainID)
+	assert.Equal(t, uint64(0), info.ProtocolVersion)
+}
@@ -0,0 +1,260 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// replacementFeeBump is the minimum fee increase, in percent, required by
+// nodes to replace a pending transaction.
+const replacementFeeBump = 10
+
+// StuckReason is the reason why a transaction is considered stuck.
+type StuckReason uint8
+
+const (
+	UnderpricedStuckReason StuckReason = iota // UnderpricedStuckReason means the fee cap is below the base fee of the next block.
+	NonceGapStuckReason                       // NonceGapStuckReason means the transaction is queued behind a nonce gap.
+)
+
+// String implements the fmt.Stringer interface.
+func (r StuckReason) String() string {
+	switch r {
+	case UnderpricedStuckReason:
+		return "underpriced"
+	case NonceGapStuckReason:
+		return "nonce-gap"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(r))
+	}
+}
+
+// NonceReport is the result of Client.FindNonceGaps.
+type NonceReport struct {
+	Address      types.Address      // Address is the inspected account.
+	LatestNonce  uint64             // LatestNonce is the nonce of the next transaction to be mined.
+	PendingNonce uint64             // PendingNonce is the next nonce that includes pending transactions.
+	PoolNonce    uint64             // PoolNonce is the next nonce after the pool transactions that follow LatestNonce without a gap.
+	Gaps         []NonceGap         // Gaps lists ranges of nonces missing from the transaction pool.
+	Stuck        []StuckTransaction // Stuck lists transactions that will not be mined without intervention.
+
+	// Inconsistent is true if PendingNonce differs from PoolNonce, e.g. if
+	// the pool is empty but PendingNonce is ahead of LatestNonce, or if
+	// PendingNonce is below the nonce of a transaction waiting in the pool.
+	// It usually means that the calls were answered by different nodes,
+	// e.g. behind a load balancer, or that the pool changed between them,
+	// so the gaps may be inaccurate.
+	Inconsistent bool
+}
+
+// NonceGap is a range of nonces for which there are no transactions in the
+// transaction pool, while transactions with higher nonces are waiting.
+type NonceGap struct {
+	First uint64 // First is the first missing nonce.
+	Last  uint64 // Last is the last missing nonce, inclusive.
+}
+
+// StuckTransaction is a transaction from the transaction pool that will not
+// be mined without intervention.
+type StuckTransaction struct {
+	Transaction types.OnChainTransaction // Transaction is the transaction from the pool.
+	Reason      StuckReason              // Reason is the reason why the transaction is stuck.
+
+	// Suggested fees for a replacement transaction with the same nonce. The
+	// fees are at least 10% higher than the fees of the stuck transaction,
+	// as required by nodes to accept a replacement. For legacy transactions,
+	// only GasPrice is set, otherwise only MaxFeePerGas and
+	// MaxPriorityFeePerGas are set.
+	GasPrice             *big.Int
+	MaxFeePerGas         *big.Int
+	MaxPriorityFeePerGas *big.Int
+}
+
+// txPoolContentFrom is the result of the txpool_contentFrom call.
+type txPoolContentFrom struct {
+	Pending map[string]*types.OnChainTransaction `json:"pending"`
+	Queued  map[string]*types.OnChainTransaction `json:"queued"`
+}
+
+// txPoolContent is the result of the txpool_content call.
+type txPoolContent struct {
+	Pending map[types.Address]map[string]*types.OnChainTransaction `json:"pending"`
+	Queued  map[types.Address]map[string]*types.OnChainTransaction `json:"queued"`
+}
+
+// FindNonceGaps inspects the nonces of the given account and its
+// transactions in the transaction pool of the node.
+//
+// It reports gaps, i.e. nonces that are missing while transactions with
+// higher nonces are waiting in the pool, and stuck transactions, together
+// with suggested fees for their replacements. If the pending nonce of the
+// node does not match the transactions in the pool, the report is marked
+// as inconsistent.
+//
+// The node must support the txpool_contentFrom or txpool_content method.
+func (c *Client) FindNonceGaps(ctx context.Context, addr types.Address) (*NonceReport, error) {
+	latest, err := c.GetTransactionCount(ctx, addr, types.LatestBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	pending, err := c.GetTransactionCount(ctx, addr, types.PendingBlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	txs, err := c.txPoolTransactions(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	report := &NonceReport{
+		Address:      addr,
+		LatestNonce:  latest,
+		PendingNonce: pending,
+		PoolNonce:    latest,
+	}
+	if len(txs) == 0 {
+		report.Inconsistent = pending != latest
+		return report, nil
+	}
+	fees, err := c.nextBlockFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	next := latest
+	for _, tx := range txs {
+		nonce := *tx.Nonce
+		if nonce < next {
+			// Already mined or a duplicate.
+			continue
+		}
+		if nonce > next {
+			report.Gaps = append(report.Gaps, NonceGap{First: next, Last: nonce - 1})
+		}
+		next = nonce + 1
+		if len(report.Gaps) == 0 {
+			report.PoolNonce = next
+		}
+		switch {
+		case len(report.Gaps) > 0:
+			report.Stuck = append(report.Stuck, fees.stuck(tx, NonceGapStuckReason))
+		case fees.underpriced(tx):
+			report.Stuck = append(report.Stuck, fees.stuck(tx, UnderpricedStuckReason))
+		}
+	}
+	report.Inconsistent = pending != report.PoolNonce
+	return report, nil
+}
+
+// txPoolTransactions returns transactions sent by the given address from
+// the transaction pool, ordered by nonce.
+func (c *Client) txPoolTransactions(ctx context.Context, addr types.Address) ([]*types.OnChainTransaction, error) {
+	var (
+		pending map[string]*types.OnChainTransaction
+		queued  map[string]*types.OnChainTransaction
+	)
+	var from txPoolContentFrom
+	if err := c.transport.Call(ctx, &from, "txpool_contentFrom", addr); err == nil {
+		pending, queued = from.Pending, from.Queued
+	} else {
+		var all txPoolContent
+		if err := c.transport.Call(ctx, &all, "txpool_content"); err != nil {
+			return nil, fmt.Errorf("rpc client: failed to fetch transaction pool content: %w", err)
+		}
+		pending, queued = all.Pending[addr], all.Queued[addr]
+	}
+	txs := make([]*types.OnChainTransaction, 0, len(pending)+len(queued))
+	for _, m := range []map[string]*types.OnChainTransaction{pending, queued} {
+		for _, tx := range m {
+			if tx == nil || tx.Nonce == nil {
+				return nil, fmt.Errorf("rpc client: transaction pool returned a transaction without a nonce")
+			}
+			txs = append(txs, tx)
+		}
+	}
+	sort.Slice(txs, func(i, j int) bool {
+		return *txs[i].Nonce < *txs[j].Nonce
+	})
+	return txs, nil
+}
+
+// blockFees are the fees required to include a transaction in the next
+// block.
+type blockFees struct {
+	baseFee  *big.Int // Base fee of the next block, nil on chains without EIP-1559.
+	tip      *big.Int // Suggested priority fee, nil on chains without EIP-1559.
+	gasPrice *big.Int // Suggested gas price.
+}
+
+func (c *Client) nextBlockFees(ctx context.Context) (*blockFees, error) {
+	var (
+		fees = &blockFees{}
+		err  error
+	)
+	fees.gasPrice, err = c.GasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	history, err := c.FeeHistory(ctx, 1, types.LatestBlockNumber, nil)
+	if err != nil || len(history.BaseFeePerGas) == 0 || history.BaseFeePerGas[len(history.BaseFeePerGas)-1] == nil {
+		// The chain does not support EIP-1559.
+		return fees, nil //nolint:nilerr
+	}
+	fees.baseFee = history.BaseFeePerGas[len(history.BaseFeePerGas)-1]
+	fees.tip, err = c.MaxPriorityFeePerGas(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return fees, nil
+}
+
+// underpriced returns true if the fee cap of the transaction is below the
+// base fee of the next block.
+func (f *blockFees) underpriced(tx *types.OnChainTransaction) bool {
+	feeCap := tx.MaxFeePerGas
+	if feeCap == nil {
+		feeCap = tx.GasPrice
+	}
+	if feeCap == nil {
+		return false
+	}
+	if f.baseFee != nil {
+		return feeCap.Cmp(f.baseFee) < 0
+	}
+	return feeCap.Cmp(f.gasPrice) < 0
+}
+
+// stuck returns a StuckTransaction with suggested replacement fees.
+func (f *blockFees) stuck(tx *types.OnChainTransaction, reason StuckReason) StuckTransaction {
+	st := StuckTransaction{Transaction: *tx, Reason: reason}
+	if tx.MaxFeePerGas == nil || f.baseFee == nil {
+		st.GasPrice = maxBig(bumpFee(tx.GasPrice), f.gasPrice)
+		return st
+	}
+	st.MaxPriorityFeePerGas = maxBig(bumpFee(tx.MaxPriorityFeePerGas), f.tip)
+	st.MaxFeePerGas = maxBig(
+		bumpFee(tx.MaxFeePerGas),
+		new(big.Int).Add(new(big.Int).Mul(f.baseFee, big.NewInt(2)), st.MaxPriorityFeePerGas),
+	)
+	return st
+}
+
+// bumpFee returns the fee increased by replacementFeeBump percent, rounded
+// up.
+func bumpFee(fee *big.Int) *big.Int {
+	if fee == nil {
+		return new(big.Int)
+	}
+	x := new(big.Int).Mul(fee, big.NewInt(100+replacementFeeBump))
+	x.Add(x, big.NewInt(99))
+	return x.Div(x, big.NewInt(100))
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if b == nil || a.Cmp(b) >= 0 {
+		return a
+	}
+	return new(big.Int).Set(b)
+}
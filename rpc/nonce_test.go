@@ -0,0 +1,148 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+const (
+	mockPoolPending = `{"5": {
+		"from": "0x1111111111111111111111111111111111111111",
+		"to": "0x2222222222222222222222222222222222222222",
+		"nonce": "0x5",
+		"gas": "0x5208",
+		"maxFeePerGas": "0x8",
+		"maxPriorityFeePerGas": "0x1",
+		"input": "0x",
+		"type": "0x2"
+	}}`
+	mockPoolQueued = `{"8": {
+		"from": "0x1111111111111111111111111111111111111111",
+		"to": "0x2222222222222222222222222222222222222222",
+		"nonce": "0x8",
+		"gas": "0x5208",
+		"gasPrice": "0x64",
+		"input": "0x",
+		"type": "0x0"
+	}}`
+)
+
+func nonceGapsMock(t *testing.T, contentFrom bool) *callMock {
+	return &callMock{Handler: func(method string, args ...any) (any, error) {
+		switch method {
+		case "eth_getTransactionCount":
+			if block := args[1].(types.BlockNumber); block.IsPending() {
+				return "0x6", nil
+			}
+			return "0x5", nil
+		case "txpool_contentFrom":
+			if !contentFrom {
+				return nil, errors.New("method not found")
+			}
+			return json.RawMessage(`{"pending": ` + mockPoolPending + `, "queued": ` + mockPoolQueued + `}`), nil
+		case "txpool_content":
+			return json.RawMessage(`{
+				"pending": {"0x1111111111111111111111111111111111111111": ` + mockPoolPending + `},
+				"queued": {"0x1111111111111111111111111111111111111111": ` + mockPoolQueued + `}
+			}`), nil
+		case "eth_gasPrice":
+			return "0xc", nil
+		case "eth_feeHistory":
+			return json.RawMessage(`{"oldestBlock": "0x1", "baseFeePerGas": ["0x9", "0xa"], "gasUsedRatio": [0.5]}`), nil
+		case "eth_maxPriorityFeePerGas":
+			return "0x2", nil
+		}
+		t.Fatalf("unexpected call %s", method)
+		return nil, nil
+	}}
+}
+
+func TestClient_FindNonceGaps(t *testing.T) {
+	for _, contentFrom := range []bool{true, false} {
+		client, err := NewClient(WithTransport(nonceGapsMock(t, contentFrom)))
+		require.NoError(t, err)
+
+		report, err := client.FindNonceGaps(context.Background(), types.MustAddressFromHex("0x1111111111111111111111111111111111111111"))
+		require.NoError(t, err)
+		assert.Equal(t, uint64(5), report.LatestNonce)
+		assert.Equal(t, uint64(6), report.PendingNonce)
+		assert.Equal(t, uint64(6), report.PoolNonce)
+		assert.False(t, report.Inconsistent)
+		assert.Equal(t, []NonceGap{{First: 6, Last: 7}}, report.Gaps)
+		require.Len(t, report.Stuck, 2)
+
+		// Fee cap of 8 is below the next base fee of 10.
+		underpriced := report.Stuck[0]
+		assert.Equal(t, uint64(5), *underpriced.Transaction.Nonce)
+		assert.Equal(t, UnderpricedStuckReason, underpriced.Reason)
+		assert.Equal(t, big.NewInt(2), underpriced.MaxPriorityFeePerGas)
+		assert.Equal(t, big.NewInt(22), underpriced.MaxFeePerGas)
+		assert.Nil(t, underpriced.GasPrice)
+
+		// Legacy transaction waiting for nonces 6 and 7.
+		queued := report.Stuck[1]
+		assert.Equal(t, uint64(8), *queued.Transaction.Nonce)
+		assert.Equal(t, NonceGapStuckReason, queued.Reason)
+		assert.Equal(t, big.NewInt(110), queued.GasPrice)
+		assert.Nil(t, queued.MaxFeePerGas)
+	}
+}
+
+func TestClient_FindNonceGaps_Inconsistent(t *testing.T) {
+	tests := []struct {
+		name         string
+		pending      string
+		pool         string
+		inconsistent bool
+	}{
+		{name: "empty pool", pending: "0x5", pool: `{}`},
+		{name: "empty pool, pending ahead", pending: "0x6", pool: `{}`, inconsistent: true},
+		{name: "pending matches pool", pending: "0x6", pool: mockPoolPending},
+		{name: "pending behind pool", pending: "0x5", pool: mockPoolPending, inconsistent: true},
+		{name: "pending ahead of pool", pending: "0x7", pool: mockPoolPending, inconsistent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+				switch method {
+				case "eth_getTransactionCount":
+					if block := args[1].(types.BlockNumber); block.IsPending() {
+						return tt.pending, nil
+					}
+					return "0x5", nil
+				case "txpool_contentFrom":
+					return json.RawMessage(`{"pending": ` + tt.pool + `, "queued": {}}`), nil
+				case "eth_gasPrice":
+					return "0x1", nil
+				case "eth_feeHistory":
+					return json.RawMessage(`{"oldestBlock": "0x1", "baseFeePerGas": ["0x1", "0x1"], "gasUsedRatio": [0.5]}`), nil
+				case "eth_maxPriorityFeePerGas":
+					return "0x1", nil
+				}
+				t.Fatalf("unexpected call %s", method)
+				return nil, nil
+			}}
+			client, err := NewClient(WithTransport(mock))
+			require.NoError(t, err)
+
+			report, err := client.FindNonceGaps(context.Background(), types.MustAddressFromHex("0x1111111111111111111111111111111111111111"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.inconsistent, report.Inconsistent)
+			assert.Empty(t, report.Gaps)
+		})
+	}
+}
+
+func TestBumpFee(t *testing.T) {
+	assert.Equal(t, big.NewInt(110), bumpFee(big.NewInt(100)))
+	assert.Equal(t, big.NewInt(2), bumpFee(big.NewInt(1)))
+	assert.Equal(t, big.NewInt(0), bumpFee(nil))
+}
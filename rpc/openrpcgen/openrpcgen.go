@@ -0,0 +1,552 @@
+// Package openrpcgen generates RPC method stubs from an OpenRPC document,
+// such as the execution-apis specification published at
+// https://github.com/ethereum/execution-apis.
+//
+// For every selected method, the generator emits a method that performs the
+// RPC call using the transport of the receiver type, along with Go types for
+// object schemas that are not mapped to existing go-eth types. The generated
+// code is meant to be a starting point: methods that need conversion to more
+// convenient types (e.g. uint64 instead of types.Number) should be moved to
+// a regular source file and adjusted by hand.
+//
+// The generator is usually invoked using the openrpcgen command:
+//
+//	go run github.com/defiweb/go-eth/cmd/openrpcgen \
+//		-spec openrpc.json -methods eth_config -out eth_config_gen.go
+package openrpcgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/defiweb/go-eth/rpc/internal/openrpc"
+)
+
+// DefaultTypeMap maps execution-apis component schemas to go-eth types.
+//
+// Types prefixed with an asterisk are passed and returned as pointers.
+var DefaultTypeMap = map[string]string{
+	"address":                "types.Address",
+	"byte":                   "types.Bytes",
+	"bytes":                  "types.Bytes",
+	"bytesMax32":             "types.Bytes",
+	"bytes8":                 "types.Bytes",
+	"bytes32":                "types.Hash",
+	"bytes65":                "types.Bytes",
+	"bytes256":               "types.Bytes",
+	"hash32":                 "types.Hash",
+	"uint":                   "types.Number",
+	"uint64":                 "types.Number",
+	"uint256":                "types.Number",
+	"BlockTag":               "types.BlockNumber",
+	"BlockNumberOrTag":       "types.BlockNumber",
+	"BlockNumberOrTagOrHash": "types.BlockNumber",
+	"AccessList":             "types.AccessList",
+	"AuthorizationList":      "types.AuthorizationList",
+	"GenericTransaction":     "*types.Call",
+	"TransactionInfo":        "*types.OnChainTransaction",
+	"TransactionSigned":      "*types.Transaction",
+	"Log":                    "types.Log",
+	"ReceiptInfo":            "*types.TransactionReceipt",
+	"Block":                  "*types.Block",
+	"Filter":                 "*types.FilterLogsQuery",
+	"FeeHistoryResult":       "*types.FeeHistory",
+	"SyncingStatus":          "json.RawMessage",
+}
+
+// Options is the options for Generate.
+type Options struct {
+	// Package is the package name of the generated file. If empty, "rpc"
+	// is used.
+	Package string
+
+	// Receiver is the name of the type for which the methods are generated.
+	// The type must have a transport field that implements the
+	// transport.Transport interface. If empty, "baseClient" is used.
+	Receiver string
+
+	// Methods is the list of methods to generate. If empty, all methods from
+	// the document are generated.
+	Methods []string
+
+	// TypeMap maps component schema names to Go types. Entries override
+	// the ones from DefaultTypeMap.
+	TypeMap map[string]string
+
+	// TrimNamespaces is the list of method namespaces that are omitted
+	// from the Go method names, e.g. eth_getBalance becomes GetBalance.
+	// If nil, only the "eth" namespace is trimmed.
+	TrimNamespaces []string
+}
+
+// Generate generates Go source code for the methods in the given OpenRPC
+// document.
+func Generate(spec []byte, opts Options) ([]byte, error) {
+	doc, err := openrpc.Parse(spec)
+	if err != nil {
+		return nil, fmt.Errorf("openrpcgen: invalid document: %w", err)
+	}
+	if opts.Package == "" {
+		opts.Package = "rpc"
+	}
+	if opts.Receiver == "" {
+		opts.Receiver = "baseClient"
+	}
+	if opts.TrimNamespaces == nil {
+		opts.TrimNamespaces = []string{"eth"}
+	}
+	g := &generator{
+		opts:    opts,
+		doc:     doc,
+		typeMap: make(map[string]string),
+		structs: make(map[string]string),
+	}
+	for k, v := range DefaultTypeMap {
+		g.typeMap[k] = v
+	}
+	for k, v := range opts.TypeMap {
+		g.typeMap[k] = v
+	}
+	methods := doc.Methods
+	if len(opts.Methods) > 0 {
+		methods = nil
+		for _, name := range opts.Methods {
+			m := doc.Method(name)
+			if m == nil {
+				return nil, fmt.Errorf("openrpcgen: method %q not found", name)
+			}
+			methods = append(methods, m)
+		}
+	}
+	for _, m := range methods {
+		if err := g.method(m); err != nil {
+			return nil, fmt.Errorf("openrpcgen: %s: %w", m.Name, err)
+		}
+	}
+	return g.source()
+}
+
+// generator holds the state of a single Generate call.
+type generator struct {
+	opts    Options
+	doc     *openrpc.Document
+	typeMap map[string]string
+
+	methods bytes.Buffer
+	structs map[string]string // Generated struct declarations by type name.
+}
+
+// method generates a stub for a single method.
+func (g *generator) method(m *openrpc.Method) error {
+	var (
+		goName = g.methodName(m.Name)
+		names  = map[string]bool{"ctx": true, "res": true, "args": true, "err": true}
+		params []string
+		args   []string
+		opts   []string // Trailing optional params, passed only if not nil.
+	)
+	// Only trailing optional parameters can be omitted from the request.
+	firstOptional := len(m.Params)
+	for i := len(m.Params) - 1; i >= 0 && !m.Params[i].Required; i-- {
+		firstOptional = i
+	}
+	for i, p := range m.Params {
+		name := uniqueName(lowerIdent(p.Name), names)
+		typ, err := g.goType(p.Schema, goName+exportedIdent(p.Name))
+		if err != nil {
+			return err
+		}
+		if i >= firstOptional && !isNillable(typ) {
+			typ = "*" + typ
+		}
+		params = append(params, name+" "+typ)
+		if i >= firstOptional {
+			opts = append(opts, name)
+		} else {
+			args = append(args, name)
+		}
+	}
+	resType := "json.RawMessage"
+	if m.Result != nil {
+		var err error
+		if resType, err = g.goType(m.Result.Schema, goName+"Result"); err != nil {
+			return err
+		}
+	}
+
+	w := &g.methods
+	fmt.Fprintf(w, "// %s performs %s RPC call.\n", goName, m.Name)
+	if doc := firstNonEmpty(m.Summary, m.Description); doc != "" {
+		fmt.Fprintf(w, "//\n")
+		writeComment(w, doc)
+	}
+	if m.Deprecated {
+		fmt.Fprintf(w, "//\n// Deprecated: %s is deprecated by the specification.\n", m.Name)
+	}
+	fmt.Fprintf(w, "func (c *%s) %s(%s) (%s, error) {\n", g.opts.Receiver, goName, strings.Join(append([]string{"ctx context.Context"}, params...), ", "), resType)
+	fmt.Fprintf(w, "\tvar res %s\n", resType)
+	call := append([]string{"ctx", "&res", fmt.Sprintf("%q", m.Name)}, args...)
+	if len(opts) > 0 {
+		fmt.Fprintf(w, "\targs := []any{%s}\n", strings.Join(args, ", "))
+		// Optional params are appended in order, stopping at the first
+		// nil one, because params cannot be skipped by position.
+		for i, o := range opts {
+			fmt.Fprintf(w, "%sif %s != nil {\n", strings.Repeat("\t", i+1), o)
+			fmt.Fprintf(w, "%sargs = append(args, %s)\n", strings.Repeat("\t", i+2), o)
+		}
+		for i := len(opts) - 1; i >= 0; i-- {
+			fmt.Fprintf(w, "%s}\n", strings.Repeat("\t", i+1))
+		}
+		call = append(call[:3], "args...")
+	}
+	fmt.Fprintf(w, "\tif err := c.transport.Call(%s); err != nil {\n", strings.Join(call, ", "))
+	fmt.Fprintf(w, "\t\treturn %s, err\n", zeroValue(resType))
+	fmt.Fprintf(w, "\t}\n")
+	fmt.Fprintf(w, "\treturn res, nil\n")
+	fmt.Fprintf(w, "}\n\n")
+	return nil
+}
+
+// goType returns the Go type for the given schema. The name is used for
+// generated struct types if the schema is an inline object.
+func (g *generator) goType(s *openrpc.Schema, name string) (string, error) {
+	if s == nil {
+		return "json.RawMessage", nil
+	}
+	if s.Ref != "" {
+		ref, _ := openrpc.RefName(s.Ref)
+		if typ, ok := g.typeMap[ref]; ok {
+			return typ, nil
+		}
+		rs, err := g.doc.Resolve(s.Ref)
+		if err != nil {
+			return "", err
+		}
+		return g.goType(rs, exportedIdent(ref))
+	}
+	if len(s.AllOf) > 0 {
+		merged, err := g.mergeAllOf(s)
+		if err != nil {
+			return "", err
+		}
+		return g.goType(merged, name)
+	}
+	if alts := append(append([]*openrpc.Schema{}, s.OneOf...), s.AnyOf...); len(alts) > 0 {
+		// A nullable type is represented as a pointer, other unions
+		// are left for the caller to decode.
+		var nonNull []*openrpc.Schema
+		for _, alt := range alts {
+			if len(alt.Type) != 1 || alt.Type[0] != "null" {
+				nonNull = append(nonNull, alt)
+			}
+		}
+		if len(nonNull) != 1 {
+			return "json.RawMessage", nil
+		}
+		typ, err := g.goType(nonNull[0], name)
+		if err != nil {
+			return "", err
+		}
+		if len(nonNull) != len(alts) && !isNillable(typ) {
+			typ = "*" + typ
+		}
+		return typ, nil
+	}
+	switch nonNullType(s.Type) {
+	case "string":
+		return "string", nil
+	case "boolean":
+		return "bool", nil
+	case "integer":
+		return "int64", nil
+	case "number":
+		return "float64", nil
+	case "array":
+		typ, err := g.goType(s.Items, name+"Item")
+		if err != nil {
+			return "", err
+		}
+		return "[]" + strings.TrimPrefix(typ, "*"), nil
+	case "object":
+		if len(s.Properties) == 0 {
+			return "json.RawMessage", nil
+		}
+		return g.structType(s, name)
+	}
+	return "json.RawMessage", nil
+}
+
+// structType generates a struct type for an object schema and returns its
+// name as a pointer type.
+func (g *generator) structType(s *openrpc.Schema, name string) (string, error) {
+	if _, ok := g.structs[name]; ok {
+		return "*" + name, nil
+	}
+	g.structs[name] = "" // Placeholder for recursive schemas.
+	required := make(map[string]bool, len(s.Required))
+	for _, r := range s.Required {
+		required[r] = true
+	}
+	var (
+		w     strings.Builder
+		names = map[string]bool{}
+	)
+	fmt.Fprintf(&w, "// %s is generated from the OpenRPC specification.\n", name)
+	if doc := firstNonEmpty(s.Description, s.Title); doc != "" {
+		w.WriteString("//\n")
+		writeComment(&w, doc)
+	}
+	fmt.Fprintf(&w, "type %s struct {\n", name)
+	for _, prop := range openrpc.SortedKeys(s.Properties) {
+		field := uniqueName(exportedIdent(prop), names)
+		typ, err := g.goType(s.Properties[prop], name+field)
+		if err != nil {
+			return "", err
+		}
+		tag := prop
+		if !required[prop] {
+			tag += ",omitempty"
+			if !isNillable(typ) {
+				typ = "*" + typ
+			}
+		} else {
+			typ = strings.TrimPrefix(typ, "*")
+		}
+		fmt.Fprintf(&w, "\t%s %s `json:%q`\n", field, typ, tag)
+	}
+	fmt.Fprintf(&w, "}\n\n")
+	g.structs[name] = w.String()
+	return "*" + name, nil
+}
+
+// mergeAllOf merges the properties of allOf subschemas into a single object
+// schema.
+func (g *generator) mergeAllOf(s *openrpc.Schema) (*openrpc.Schema, error) {
+	merged := &openrpc.Schema{
+		Title:       s.Title,
+		Description: s.Description,
+		Type:        openrpc.SchemaType{"object"},
+		Properties:  make(map[string]*openrpc.Schema),
+	}
+	for _, sub := range s.AllOf {
+		for sub.Ref != "" {
+			rs, err := g.doc.Resolve(sub.Ref)
+			if err != nil {
+				return nil, err
+			}
+			sub = rs
+		}
+		if len(sub.AllOf) > 0 {
+			var err error
+			if sub, err = g.mergeAllOf(sub); err != nil {
+				return nil, err
+			}
+		}
+		for k, v := range sub.Properties {
+			merged.Properties[k] = v
+		}
+		merged.Required = append(merged.Required, sub.Required...)
+	}
+	return merged, nil
+}
+
+// methodName returns the Go name of the RPC method.
+func (g *generator) methodName(name string) string {
+	ns, fn, ok := strings.Cut(name, "_")
+	if !ok {
+		return exportedIdent(name)
+	}
+	for _, t := range g.opts.TrimNamespaces {
+		if ns == t {
+			return exportedIdent(fn)
+		}
+	}
+	return exportedIdent(ns) + exportedIdent(fn)
+}
+
+// source assembles and formats the generated file.
+func (g *generator) source() ([]byte, error) {
+	var body bytes.Buffer
+	body.Write(g.methods.Bytes())
+	names := make([]string, 0, len(g.structs))
+	for n := range g.structs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		body.WriteString(g.structs[n])
+	}
+
+	code := body.String()
+	imports := []string{`"context"`}
+	if strings.Contains(code, "json.") {
+		imports = append(imports, `"encoding/json"`)
+	}
+	if strings.Contains(code, "types.") {
+		imports = append(imports, "", `"github.com/defiweb/go-eth/types"`)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by openrpcgen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&out, "package %s\n\n", g.opts.Package)
+	fmt.Fprintf(&out, "import (\n")
+	for _, i := range imports {
+		fmt.Fprintf(&out, "\t%s\n", i)
+	}
+	fmt.Fprintf(&out, ")\n\n")
+	out.WriteString(code)
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("openrpcgen: failed to format generated code: %w", err)
+	}
+	return src, nil
+}
+
+// commonInitialisms are words that are written in upper case in Go names.
+var commonInitialisms = map[string]bool{
+	"ID": true, "RPC": true, "URL": true, "JSON": true, "API": true,
+	"EVM": true, "TX": true, "HTTP": true, "IP": true,
+}
+
+// exportedIdent converts a name such as "hydrated transactions",
+// "chain_id" or "getBalance" to an exported Go identifier.
+func exportedIdent(name string) string {
+	var b strings.Builder
+	for _, word := range splitWords(name) {
+		if up := strings.ToUpper(word); commonInitialisms[up] {
+			b.WriteString(up)
+			continue
+		}
+		r := []rune(word)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+	s := b.String()
+	if s == "" || !unicode.IsLetter([]rune(s)[0]) {
+		s = "X" + s
+	}
+	return s
+}
+
+// lowerIdent converts a name to an unexported Go identifier.
+func lowerIdent(name string) string {
+	words := splitWords(name)
+	if len(words) == 0 {
+		return "p"
+	}
+	var b strings.Builder
+	b.WriteString(strings.ToLower(words[0]))
+	if len(words) > 1 {
+		b.WriteString(exportedIdent(strings.Join(words[1:], " ")))
+	}
+	s := b.String()
+	if !unicode.IsLetter([]rune(s)[0]) {
+		s = "p" + s
+	}
+	if token.Lookup(s).IsKeyword() {
+		s += "_"
+	}
+	return s
+}
+
+// splitWords splits a name into words on non-alphanumeric characters and
+// lower-to-upper case transitions.
+func splitWords(name string) []string {
+	var (
+		words []string
+		cur   []rune
+	)
+	flush := func() {
+		if len(cur) > 0 {
+			words = append(words, string(cur))
+			cur = nil
+		}
+	}
+	for _, r := range name {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && len(cur) > 0 && !unicode.IsUpper(cur[len(cur)-1]):
+			flush()
+			cur = append(cur, r)
+		default:
+			cur = append(cur, r)
+		}
+	}
+	flush()
+	return words
+}
+
+// uniqueName returns name, or name with a numeric suffix if it is already
+// used, and marks the returned name as used.
+func uniqueName(name string, used map[string]bool) string {
+	n := name
+	for i := 2; used[n]; i++ {
+		n = fmt.Sprintf("%s%d", name, i)
+	}
+	used[n] = true
+	return n
+}
+
+// isNillable reports whether a zero value of the type can be represented
+// as nil.
+func isNillable(typ string) bool {
+	return strings.HasPrefix(typ, "*") ||
+		strings.HasPrefix(typ, "[]") ||
+		strings.HasPrefix(typ, "map[") ||
+		typ == "json.RawMessage" ||
+		typ == "types.Bytes" ||
+		typ == "types.AccessList" ||
+		typ == "types.AuthorizationList"
+}
+
+// zeroValue returns the zero value expression for a non-pointer type.
+func zeroValue(typ string) string {
+	switch {
+	case isNillable(typ):
+		return "nil"
+	case typ == "string":
+		return `""`
+	case typ == "bool":
+		return "false"
+	case typ == "int64" || typ == "float64":
+		return "0"
+	}
+	return typ + "{}"
+}
+
+func nonNullType(t openrpc.SchemaType) string {
+	for _, s := range t {
+		if s != "null" {
+			return s
+		}
+	}
+	return ""
+}
+
+func firstNonEmpty(s ...string) string {
+	for _, v := range s {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// writeComment writes text as a Go comment.
+func writeComment(w io.StringWriter, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			w.WriteString("//\n")
+			continue
+		}
+		w.WriteString("// " + line + "\n")
+	}
+}
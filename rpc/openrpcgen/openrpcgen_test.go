@@ -0,0 +1,120 @@
+package openrpcgen
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testSpec = `{
+	"openrpc": "1.2.4",
+	"methods": [
+		{
+			"name": "eth_config",
+			"summary": "Returns the client fork configuration.",
+			"params": [],
+			"result": {"name": "Config", "schema": {"$ref": "#/components/schemas/Config"}}
+		},
+		{
+			"name": "debug_getRawHeader",
+			"params": [
+				{"name": "Block", "required": true, "schema": {"$ref": "#/components/schemas/BlockNumberOrTag"}},
+				{"name": "Full", "required": false, "schema": {"type": "boolean"}}
+			],
+			"result": {"name": "Header RLP", "schema": {"$ref": "#/components/schemas/bytes"}}
+		}
+	],
+	"components": {
+		"schemas": {
+			"bytes": {"type": "string"},
+			"uint": {"type": "string"},
+			"BlockNumberOrTag": {"type": "string"},
+			"Fork": {
+				"title": "Fork configuration",
+				"type": "object",
+				"required": ["activationTime", "chainId"],
+				"properties": {
+					"activationTime": {"$ref": "#/components/schemas/uint"},
+					"chainId": {"$ref": "#/components/schemas/uint"},
+					"precompiles": {"type": "object", "properties": {"address": {"type": "string"}}}
+				}
+			},
+			"Config": {
+				"allOf": [
+					{"type": "object", "required": ["current"], "properties": {"current": {"$ref": "#/components/schemas/Fork"}}},
+					{"type": "object", "properties": {"next": {"oneOf": [{"$ref": "#/components/schemas/Fork"}, {"type": "null"}]}}}
+				]
+			}
+		}
+	}
+}`
+
+func TestGenerate(t *testing.T) {
+	src, err := Generate([]byte(testSpec), Options{})
+	require.NoError(t, err)
+	_, err = parser.ParseFile(token.NewFileSet(), "", src, parser.AllErrors)
+	require.NoError(t, err)
+
+	code := string(src)
+	assert.Contains(t, code, "// Code generated by openrpcgen. DO NOT EDIT.")
+	assert.Contains(t, code, "package rpc")
+	assert.Contains(t, code, "// Config performs eth_config RPC call.\n//\n// Returns the client fork configuration.\n")
+	assert.Contains(t, code, "func (c *baseClient) Config(ctx context.Context) (*Config, error) {")
+	assert.Contains(t, code, "func (c *baseClient) DebugGetRawHeader(ctx context.Context, block types.BlockNumber, full *bool) (types.Bytes, error) {")
+	assert.Contains(t, code, "args := []any{block}")
+	assert.Contains(t, code, "type Config struct {")
+	assert.Contains(t, code, "Current Fork  `json:\"current\"`")
+	assert.Contains(t, code, "Next    *Fork `json:\"next,omitempty\"`")
+	assert.Contains(t, code, "ActivationTime types.Number")
+	assert.Contains(t, code, "ChainID        types.Number")
+	assert.Contains(t, code, "Precompiles    *ForkPrecompiles")
+}
+
+func TestGenerate_Options(t *testing.T) {
+	src, err := Generate([]byte(testSpec), Options{
+		Package:        "debug",
+		Receiver:       "Client",
+		Methods:        []string{"debug_getRawHeader"},
+		TypeMap:        map[string]string{"bytes": "[]byte"},
+		TrimNamespaces: []string{"debug"},
+	})
+	require.NoError(t, err)
+	code := string(src)
+	assert.Contains(t, code, "package debug")
+	assert.Contains(t, code, "func (c *Client) GetRawHeader(ctx context.Context, block types.BlockNumber, full *bool) ([]byte, error) {")
+	assert.NotContains(t, code, "eth_config")
+
+	_, err = Generate([]byte(testSpec), Options{Methods: []string{"eth_unknown"}})
+	assert.Error(t, err)
+}
+
+func TestGenerate_ExecutionAPIs(t *testing.T) {
+	spec, err := os.ReadFile("../conformance/openrpc.json")
+	require.NoError(t, err)
+	src, err := Generate(spec, Options{})
+	require.NoError(t, err)
+	code := string(src)
+	assert.Contains(t, code, "func (c *baseClient) GetBlockByNumber(ctx context.Context, block types.BlockNumber, hydratedTransactions bool) (*types.Block, error) {")
+	assert.Contains(t, code, "func (c *baseClient) GetBalance(ctx context.Context, address types.Address, block *types.BlockNumber) (types.Number, error) {")
+	assert.Contains(t, code, "func (c *baseClient) FeeHistory(ctx context.Context, blockCount types.Number, newestBlock types.BlockNumber, rewardPercentiles []float64) (*types.FeeHistory, error) {")
+}
+
+func TestExportedIdent(t *testing.T) {
+	tests := map[string]string{
+		"getBalance":            "GetBalance",
+		"hydrated transactions": "HydratedTransactions",
+		"chainId":               "ChainID",
+		"block_hash":            "BlockHash",
+		"rpcUrl":                "RPCURL",
+		"1559":                  "X1559",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, exportedIdent(in), in)
+	}
+	assert.Equal(t, "type_", lowerIdent("Type"))
+	assert.Equal(t, "blockHash", lowerIdent("Block hash"))
+}
@@ -0,0 +1,207 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// defaultPoolCheckInterval is the default interval at which WatchPool
+// checks whether the tracked transactions are still in the pool.
+const defaultPoolCheckInterval = 5 * time.Second
+
+// PoolEventType is the type of PoolEvent.
+type PoolEventType uint8
+
+const (
+	DroppedPoolEvent  PoolEventType = iota // DroppedPoolEvent means the transaction was removed from the pool without being mined.
+	ReplacedPoolEvent                      // ReplacedPoolEvent means another transaction with the same nonce replaced the transaction.
+)
+
+// String implements the fmt.Stringer interface.
+func (t PoolEventType) String() string {
+	switch t {
+	case DroppedPoolEvent:
+		return "dropped"
+	case ReplacedPoolEvent:
+		return "replaced"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(t))
+	}
+}
+
+// PoolEvent is emitted by Client.WatchPool when a pending transaction
+// leaves the transaction pool without being mined.
+type PoolEvent struct {
+	Type        PoolEventType            // Type is the type of the event.
+	Transaction types.OnChainTransaction // Transaction is the pending transaction that was dropped or replaced.
+
+	// Replacement is the hash of the transaction that replaced the pending
+	// transaction. It is nil for dropped transactions, and for replaced
+	// transactions if the replacement was mined before it was seen in the
+	// pool.
+	Replacement *types.Hash
+}
+
+// WatchPoolOptions is the options for Client.WatchPool.
+type WatchPoolOptions struct {
+	// Interval is the interval at which the tracked transactions are
+	// checked. The default is five seconds.
+	Interval time.Duration
+}
+
+// WatchPool subscribes to pending transactions and emits events when a
+// pending transaction sent by one of the given addresses disappears from
+// the transaction pool without being mined, or is replaced by another
+// transaction with the same nonce.
+//
+// A replacement is reported as soon as the replacing transaction is seen
+// in the pool. Other transactions are checked periodically: a transaction
+// whose nonce was used by a mined transaction other than itself is
+// reported as replaced, and a transaction that is no longer known to the
+// node while its nonce is unused is reported as dropped. Mined transactions
+// are no longer tracked.
+//
+// The channel is closed when the context is canceled or the subscription
+// ends.
+func (c *Client) WatchPool(ctx context.Context, addrs []types.Address, opts WatchPoolOptions) (<-chan PoolEvent, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultPoolCheckInterval
+	}
+	sub, err := c.NewPendingTransactionsSubscription(ctx)
+	if err != nil {
+		return nil, err
+	}
+	w := &poolWatcher{
+		client:  c,
+		senders: make(map[types.Address]struct{}, len(addrs)),
+		pending: make(map[types.Address]map[uint64]*types.OnChainTransaction),
+		ch:      make(chan PoolEvent),
+	}
+	for _, addr := range addrs {
+		w.senders[addr] = struct{}{}
+	}
+	go w.run(ctx, sub, opts.Interval)
+	return w.ch, nil
+}
+
+// poolWatcher tracks pending transactions for Client.WatchPool.
+type poolWatcher struct {
+	client  *Client
+	senders map[types.Address]struct{}
+	pending map[types.Address]map[uint64]*types.OnChainTransaction // Tracked transactions by sender and nonce.
+	ch      chan PoolEvent
+}
+
+func (w *poolWatcher) run(ctx context.Context, sub *Subscription[types.Hash], interval time.Duration) {
+	defer close(w.ch)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case hash, ok := <-sub.Chan():
+			if !ok {
+				return
+			}
+			if !w.add(ctx, hash) {
+				return
+			}
+		case <-ticker.C:
+			if !w.check(ctx) {
+				return
+			}
+		}
+	}
+}
+
+// add starts tracking the pending transaction with the given hash if it
+// was sent by one of the watched addresses. It returns false if the
+// context was canceled.
+func (w *poolWatcher) add(ctx context.Context, hash types.Hash) bool {
+	tx, err := w.client.GetTransactionByHash(ctx, hash)
+	if err != nil || tx.From == nil || tx.Nonce == nil || tx.BlockNumber != nil {
+		return ctx.Err() == nil
+	}
+	if _, ok := w.senders[*tx.From]; !ok {
+		return true
+	}
+	if tx.Hash == nil {
+		tx.Hash = &hash
+	}
+	nonces := w.pending[*tx.From]
+	if nonces == nil {
+		nonces = make(map[uint64]*types.OnChainTransaction)
+		w.pending[*tx.From] = nonces
+	}
+	prev := nonces[*tx.Nonce]
+	nonces[*tx.Nonce] = tx
+	if prev != nil && *prev.Hash != hash {
+		return w.emit(ctx, PoolEvent{Type: ReplacedPoolEvent, Transaction: *prev, Replacement: &hash})
+	}
+	return true
+}
+
+// check verifies that the tracked transactions are still pending. It
+// returns false if the context was canceled.
+func (w *poolWatcher) check(ctx context.Context) bool {
+	for addr, nonces := range w.pending {
+		next, err := w.client.GetTransactionCount(ctx, addr, types.LatestBlockNumber)
+		if err != nil {
+			continue
+		}
+		for _, nonce := range sortedNonces(nonces) {
+			tx := nonces[nonce]
+			if nonce < next {
+				// The nonce was used, either by this transaction or by
+				// a replacement.
+				_, err := w.client.GetTransactionReceipt(ctx, *tx.Hash)
+				switch {
+				case err == nil:
+					delete(nonces, nonce)
+				case errors.Is(err, ErrNotFound):
+					delete(nonces, nonce)
+					if !w.emit(ctx, PoolEvent{Type: ReplacedPoolEvent, Transaction: *tx}) {
+						return false
+					}
+				}
+				continue
+			}
+			if _, err := w.client.GetTransactionByHash(ctx, *tx.Hash); errors.Is(err, ErrNotFound) {
+				delete(nonces, nonce)
+				if !w.emit(ctx, PoolEvent{Type: DroppedPoolEvent, Transaction: *tx}) {
+					return false
+				}
+			}
+		}
+		if len(nonces) == 0 {
+			delete(w.pending, addr)
+		}
+	}
+	return ctx.Err() == nil
+}
+
+// emit sends the event to the channel. It returns false if the context was
+// canceled.
+func (w *poolWatcher) emit(ctx context.Context, ev PoolEvent) bool {
+	select {
+	case w.ch <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func sortedNonces(m map[uint64]*types.OnChainTransaction) []uint64 {
+	nonces := make([]uint64, 0, len(m))
+	for nonce := range m {
+		nonces = append(nonces, nonce)
+	}
+	sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+	return nonces
+}
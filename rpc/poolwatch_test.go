@@ -0,0 +1,121 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// subscriptionCallMock is a callMock that also supports subscriptions.
+type subscriptionCallMock struct {
+	callMock
+	ch chan json.RawMessage
+}
+
+func (s *subscriptionCallMock) Subscribe(context.Context, string, ...any) (chan json.RawMessage, string, error) {
+	return s.ch, "1", nil
+}
+
+func (s *subscriptionCallMock) Unsubscribe(context.Context, string) error {
+	return nil
+}
+
+func TestClient_WatchPool(t *testing.T) {
+	var (
+		sender = types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+		other  = types.MustAddressFromHex("0x2222222222222222222222222222222222222222")
+		hash1  = types.MustHashFromHex("0x01", types.PadLeft)
+		hash2  = types.MustHashFromHex("0x02", types.PadLeft)
+		hash3  = types.MustHashFromHex("0x03", types.PadLeft)
+		hash4  = types.MustHashFromHex("0x04", types.PadLeft)
+	)
+	pendingTx := func(hash types.Hash, from types.Address, nonce uint64) *types.OnChainTransaction {
+		return &types.OnChainTransaction{
+			Transaction: *(&types.Transaction{}).SetFrom(from).SetNonce(nonce),
+			Hash:        &hash,
+		}
+	}
+
+	var mu sync.Mutex
+	pool := map[types.Hash]*types.OnChainTransaction{
+		hash1: pendingTx(hash1, sender, 5),
+		hash2: pendingTx(hash2, other, 5),
+		hash3: pendingTx(hash3, sender, 5),
+		hash4: pendingTx(hash4, sender, 6),
+	}
+	mined := map[types.Hash]bool{}
+	nonce := uint64(5)
+	mock := &subscriptionCallMock{
+		ch: make(chan json.RawMessage),
+		callMock: callMock{Handler: func(method string, args ...any) (any, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			switch method {
+			case "eth_getTransactionByHash":
+				if tx, ok := pool[args[0].(types.Hash)]; ok {
+					return tx, nil
+				}
+				return nil, nil
+			case "eth_getTransactionCount":
+				return types.NumberFromUint64(nonce), nil
+			case "eth_getTransactionReceipt":
+				if mined[args[0].(types.Hash)] {
+					return &types.TransactionReceipt{TransactionHash: args[0].(types.Hash)}, nil
+				}
+				return nil, nil
+			}
+			t.Fatalf("unexpected call %s", method)
+			return nil, nil
+		}},
+	}
+	client, err := NewClient(WithTransport(mock))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := client.WatchPool(ctx, []types.Address{sender}, WatchPoolOptions{Interval: time.Millisecond})
+	require.NoError(t, err)
+
+	send := func(h types.Hash) {
+		raw, err := json.Marshal(h)
+		require.NoError(t, err)
+		mock.ch <- raw
+	}
+
+	// Transaction with the same nonce from another sender is ignored.
+	send(hash1)
+	send(hash2)
+	send(hash3)
+	ev := <-events
+	assert.Equal(t, ReplacedPoolEvent, ev.Type)
+	assert.Equal(t, hash1, *ev.Transaction.Hash)
+	assert.Equal(t, &hash3, ev.Replacement)
+
+	// The replacement is mined and the next transaction is dropped.
+	send(hash4)
+	// Messages are delivered one by one, so after two more messages are
+	// received, the previous one must have been processed.
+	send(hash2)
+	send(hash2)
+	mu.Lock()
+	nonce = 6
+	mined[hash3] = true
+	delete(pool, hash4)
+	mu.Unlock()
+	ev = <-events
+	assert.Equal(t, DroppedPoolEvent, ev.Type)
+	assert.Equal(t, hash4, *ev.Transaction.Hash)
+	assert.Nil(t, ev.Replacement)
+
+	cancel()
+	for range events {
+		t.Fatal("unexpected event")
+	}
+}
@@ -0,0 +1,242 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// Transaction pool limits and gas costs used by PreflightTx.
+const (
+	txGas                     = 21000  // Base cost of a transaction.
+	txCreationGas             = 53000  // Base cost of a contract creation transaction.
+	txDataZeroGas             = 4      // Cost of a zero byte of calldata.
+	txDataNonZeroGas          = 16     // Cost of a non-zero byte of calldata.
+	txAccessListAddressGas    = 2400   // Cost of an address in the access list, see EIP-2930.
+	txAccessListStorageKeyGas = 1900   // Cost of a storage key in the access list, see EIP-2930.
+	txAuthorizationGas        = 25000  // Cost of an authorization, see EIP-7702.
+	txInitCodeWordGas         = 2      // Cost of a 32-byte word of init code, see EIP-3860.
+	txFloorPerTokenGas        = 10     // Minimum cost of a calldata token, see EIP-7623.
+	txMaxInitCodeSize         = 49152  // Maximum size of init code, see EIP-3860.
+	txMaxSize                 = 131072 // Maximum size of a transaction accepted by the transaction pool of geth.
+	txSignatureSize           = 64     // Size added to the encoding of unsigned transactions by the signature.
+)
+
+// PreflightCheck is a check performed by PreflightTx.
+type PreflightCheck uint8
+
+const (
+	GasLimitCheck PreflightCheck = iota // GasLimitCheck checks the gas limit against the intrinsic gas and the block gas limit.
+	BalanceCheck                        // BalanceCheck checks that the balance of the sender covers the maximum cost.
+	NonceCheck                          // NonceCheck checks the nonce against the nonce of the sender.
+	FeeCheck                            // FeeCheck checks the fees against each other and the current base fee.
+	SizeCheck                           // SizeCheck checks the size of the transaction and of its init code.
+)
+
+// String implements the fmt.Stringer interface.
+func (c PreflightCheck) String() string {
+	switch c {
+	case GasLimitCheck:
+		return "gas limit"
+	case BalanceCheck:
+		return "balance"
+	case NonceCheck:
+		return "nonce"
+	case FeeCheck:
+		return "fee"
+	case SizeCheck:
+		return "size"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(c))
+	}
+}
+
+// PreflightIssue is a problem found by PreflightTx.
+type PreflightIssue struct {
+	Check   PreflightCheck // Check is the check that found the issue.
+	Fatal   bool           // Fatal is true if the node will reject the transaction, otherwise the transaction may only be delayed.
+	Message string         // Message describes the issue.
+}
+
+// String implements the fmt.Stringer interface.
+func (i PreflightIssue) String() string {
+	return fmt.Sprintf("%s: %s", i.Check, i.Message)
+}
+
+// PreflightReport is the result of PreflightTx.
+type PreflightReport struct {
+	Sender       *types.Address // Sender is the checked sender, nil if unknown.
+	IntrinsicGas uint64         // IntrinsicGas is the minimum gas limit of the transaction.
+	Size         uint64         // Size is the size of the encoded transaction, including the signature.
+	MaxCost      *big.Int       // MaxCost is the maximum cost of the transaction, nil if the gas limit or the fee is not set.
+	Balance      *big.Int       // Balance is the balance of the sender at the latest block.
+	Nonce        *uint64        // Nonce is the pending nonce of the sender.
+	BaseFee      *big.Int       // BaseFee is the base fee of the latest block, nil before London.
+	BlockGas     uint64         // BlockGas is the gas limit of the latest block.
+
+	Issues  []PreflightIssue // Issues is the list of found problems.
+	Skipped []PreflightCheck // Skipped is the list of checks that were skipped because the required fields are not set.
+}
+
+// OK returns true if no fatal issues were found.
+func (r *PreflightReport) OK() bool {
+	for _, i := range r.Issues {
+		if i.Fatal {
+			return false
+		}
+	}
+	return true
+}
+
+// PreflightTx runs the validations that the transaction pool of the node
+// performs on new transactions, so that problems are found before the
+// transaction is signed or sent. Found problems are listed in the report;
+// an error is returned only if the required data cannot be fetched.
+//
+// The transaction is checked as given, transaction modifiers are not
+// applied. To check the transaction that SendTransaction would sign, pass
+// the result of PrepareTransaction. Checks that require fields that are
+// not set, such as the gas limit or the nonce, are skipped. If the sender
+// is not set, the address set by WithDefaultAddress is used.
+//
+// The following checks are performed:
+//   - the gas limit covers the intrinsic gas and does not exceed the block
+//     gas limit
+//   - the balance of the sender covers the maximum cost of the transaction
+//   - the nonce is not lower than the nonce of the sender, a nonce above
+//     the pending nonce is reported as a non-fatal gap
+//   - the priority fee does not exceed the max fee, a fee below the current
+//     base fee is reported as non-fatal because the transaction may be
+//     included once the base fee drops
+//   - the transaction and its init code do not exceed the size limits
+func (c *Client) PreflightTx(ctx context.Context, tx *types.Transaction) (*PreflightReport, error) {
+	if tx == nil {
+		return nil, fmt.Errorf("rpc client: transaction is nil")
+	}
+	r := &PreflightReport{
+		Sender:       tx.From,
+		IntrinsicGas: intrinsicGas(tx),
+	}
+	if r.Sender == nil {
+		r.Sender = c.defaultFrom()
+	}
+	block, err := c.BlockByNumber(ctx, types.LatestBlockNumber, false)
+	if err != nil {
+		return nil, fmt.Errorf("rpc client: failed to fetch latest block: %w", err)
+	}
+	r.BaseFee = block.BaseFeePerGas
+	r.BlockGas = block.GasLimit
+
+	// Gas limit.
+	if tx.GasLimit != nil {
+		if *tx.GasLimit < r.IntrinsicGas {
+			r.fatal(GasLimitCheck, "gas limit %d is below the intrinsic gas %d", *tx.GasLimit, r.IntrinsicGas)
+		}
+		if *tx.GasLimit > r.BlockGas {
+			r.fatal(GasLimitCheck, "gas limit %d exceeds the block gas limit %d", *tx.GasLimit, r.BlockGas)
+		}
+	} else {
+		r.Skipped = append(r.Skipped, GasLimitCheck)
+	}
+
+	// Balance.
+	r.MaxCost = tx.MaxCost()
+	if r.Sender != nil && r.MaxCost != nil {
+		if r.Balance, err = c.GetBalance(ctx, *r.Sender, types.LatestBlockNumber); err != nil {
+			return nil, fmt.Errorf("rpc client: failed to fetch balance: %w", err)
+		}
+		if r.MaxCost.Cmp(r.Balance) > 0 {
+			r.fatal(BalanceCheck, "maximum cost %s wei exceeds the balance %s wei", r.MaxCost, r.Balance)
+		}
+	} else {
+		r.Skipped = append(r.Skipped, BalanceCheck)
+	}
+
+	// Nonce.
+	if r.Sender != nil && tx.Nonce != nil {
+		latest, err := c.GetTransactionCount(ctx, *r.Sender, types.LatestBlockNumber)
+		if err != nil {
+			return nil, fmt.Errorf("rpc client: failed to fetch nonce: %w", err)
+		}
+		pending, err := c.GetTransactionCount(ctx, *r.Sender, types.PendingBlockNumber)
+		if err != nil {
+			return nil, fmt.Errorf("rpc client: failed to fetch pending nonce: %w", err)
+		}
+		r.Nonce = &pending
+		switch {
+		case *tx.Nonce < latest:
+			r.fatal(NonceCheck, "nonce %d is lower than the account nonce %d", *tx.Nonce, latest)
+		case *tx.Nonce > pending:
+			r.warn(NonceCheck, "nonce %d leaves a gap after the pending nonce %d", *tx.Nonce, pending)
+		}
+	} else {
+		r.Skipped = append(r.Skipped, NonceCheck)
+	}
+
+	// Fees.
+	if fee := tx.FeeCap(); fee != nil {
+		if tx.MaxPriorityFeePerGas != nil && tx.MaxFeePerGas != nil && tx.MaxPriorityFeePerGas.Cmp(tx.MaxFeePerGas) > 0 {
+			r.fatal(FeeCheck, "max priority fee %s wei exceeds the max fee %s wei", tx.MaxPriorityFeePerGas, tx.MaxFeePerGas)
+		}
+		if r.BaseFee != nil && fee.Cmp(r.BaseFee) < 0 {
+			r.warn(FeeCheck, "max fee %s wei is below the current base fee %s wei", fee, r.BaseFee)
+		}
+	} else {
+		r.Skipped = append(r.Skipped, FeeCheck)
+	}
+
+	// Size.
+	raw, err := tx.Raw()
+	if err != nil {
+		return nil, fmt.Errorf("rpc client: failed to encode transaction: %w", err)
+	}
+	r.Size = uint64(len(raw))
+	if tx.Signature == nil {
+		r.Size += txSignatureSize
+	}
+	if r.Size > txMaxSize {
+		r.fatal(SizeCheck, "transaction size %d exceeds the limit %d", r.Size, txMaxSize)
+	}
+	if tx.To == nil && len(tx.Input) > txMaxInitCodeSize {
+		r.fatal(SizeCheck, "init code size %d exceeds the limit %d", len(tx.Input), txMaxInitCodeSize)
+	}
+	return r, nil
+}
+
+func (r *PreflightReport) fatal(check PreflightCheck, format string, args ...any) {
+	r.Issues = append(r.Issues, PreflightIssue{Check: check, Fatal: true, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *PreflightReport) warn(check PreflightCheck, format string, args ...any) {
+	r.Issues = append(r.Issues, PreflightIssue{Check: check, Message: fmt.Sprintf(format, args...)})
+}
+
+// intrinsicGas returns the minimum gas limit of the transaction: the gas
+// charged before the execution, or the calldata floor defined in EIP-7623,
+// whichever is higher.
+func intrinsicGas(tx *types.Transaction) uint64 {
+	gas := uint64(txGas)
+	if tx.To == nil {
+		gas = txCreationGas
+		gas += txInitCodeWordGas * ((uint64(len(tx.Input)) + 31) / 32)
+	}
+	var zero, nonZero uint64
+	for _, b := range tx.Input {
+		if b == 0 {
+			zero++
+		} else {
+			nonZero++
+		}
+	}
+	gas += zero*txDataZeroGas + nonZero*txDataNonZeroGas
+	for _, t := range tx.AccessList {
+		gas += txAccessListAddressGas + uint64(len(t.StorageKeys))*txAccessListStorageKeyGas
+	}
+	gas += uint64(len(tx.AuthorizationList)) * txAuthorizationGas
+	if floor := txGas + (zero+nonZero*4)*txFloorPerTokenGas; floor > gas {
+		gas = floor
+	}
+	return gas
+}
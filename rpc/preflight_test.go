@@ -0,0 +1,140 @@
+package rpc
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+func preflightMock(t *testing.T) *callMock {
+	return &callMock{Handler: func(method string, args ...any) (any, error) {
+		switch method {
+		case "eth_getBlockByNumber":
+			return map[string]any{
+				"gasLimit":      types.NumberFromUint64(30_000_000),
+				"baseFeePerGas": types.NumberFromUint64(10),
+			}, nil
+		case "eth_getBalance":
+			return types.NumberFromUint64(1_000_000), nil
+		case "eth_getTransactionCount":
+			if block := args[1].(types.BlockNumber); block.IsPending() {
+				return types.NumberFromUint64(7), nil
+			}
+			return types.NumberFromUint64(5), nil
+		}
+		t.Fatalf("unexpected call %s", method)
+		return nil, nil
+	}}
+}
+
+func TestClient_PreflightTx(t *testing.T) {
+	from := types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+	to := types.MustAddressFromHex("0x2222222222222222222222222222222222222222")
+	tests := []struct {
+		name    string
+		tx      *types.Transaction
+		ok      bool
+		issues  []PreflightCheck
+		skipped []PreflightCheck
+	}{
+		{
+			name: "valid",
+			tx: types.NewTransaction().SetType(types.DynamicFeeTxType).SetFrom(from).SetTo(to).
+				SetNonce(6).SetGasLimit(21000).SetMaxFeePerGas(big.NewInt(20)).SetMaxPriorityFeePerGas(big.NewInt(1)),
+			ok: true,
+		},
+		{
+			name: "intrinsic gas",
+			tx:   types.NewTransaction().SetFrom(from).SetTo(to).SetInput([]byte{1, 2, 3}).SetGasLimit(21000).SetGasPrice(big.NewInt(10)),
+			ok:   false, issues: []PreflightCheck{GasLimitCheck}, skipped: []PreflightCheck{NonceCheck},
+		},
+		{
+			name: "block gas limit",
+			tx:   types.NewTransaction().SetTo(to).SetGasLimit(40_000_000),
+			ok:   false, issues: []PreflightCheck{GasLimitCheck}, skipped: []PreflightCheck{BalanceCheck, NonceCheck, FeeCheck},
+		},
+		{
+			name: "balance",
+			tx:   types.NewTransaction().SetFrom(from).SetTo(to).SetGasLimit(21000).SetGasPrice(big.NewInt(10)).SetValue(big.NewInt(1_000_000)),
+			ok:   false, issues: []PreflightCheck{BalanceCheck}, skipped: []PreflightCheck{NonceCheck},
+		},
+		{
+			name: "nonce too low",
+			tx:   types.NewTransaction().SetFrom(from).SetNonce(4),
+			ok:   false, issues: []PreflightCheck{NonceCheck}, skipped: []PreflightCheck{GasLimitCheck, BalanceCheck, FeeCheck},
+		},
+		{
+			name: "nonce gap",
+			tx:   types.NewTransaction().SetFrom(from).SetNonce(8),
+			ok:   true, issues: []PreflightCheck{NonceCheck}, skipped: []PreflightCheck{GasLimitCheck, BalanceCheck, FeeCheck},
+		},
+		{
+			name: "fee below base fee",
+			tx:   types.NewTransaction().SetGasPrice(big.NewInt(9)),
+			ok:   true, issues: []PreflightCheck{FeeCheck}, skipped: []PreflightCheck{GasLimitCheck, BalanceCheck, NonceCheck},
+		},
+		{
+			name: "priority fee above max fee",
+			tx:   types.NewTransaction().SetType(types.DynamicFeeTxType).SetMaxFeePerGas(big.NewInt(20)).SetMaxPriorityFeePerGas(big.NewInt(21)),
+			ok:   false, issues: []PreflightCheck{FeeCheck}, skipped: []PreflightCheck{GasLimitCheck, BalanceCheck, NonceCheck},
+		},
+		{
+			name: "init code size",
+			tx:   types.NewTransaction().SetInput(make([]byte, txMaxInitCodeSize+1)),
+			ok:   false, issues: []PreflightCheck{SizeCheck}, skipped: []PreflightCheck{GasLimitCheck, BalanceCheck, NonceCheck, FeeCheck},
+		},
+		{
+			name: "transaction size",
+			tx:   types.NewTransaction().SetTo(to).SetInput(make([]byte, txMaxSize)),
+			ok:   false, issues: []PreflightCheck{SizeCheck}, skipped: []PreflightCheck{GasLimitCheck, BalanceCheck, NonceCheck, FeeCheck},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(WithTransport(preflightMock(t)))
+			require.NoError(t, err)
+			report, err := client.PreflightTx(context.Background(), tt.tx)
+			require.NoError(t, err)
+			var issues []PreflightCheck
+			for _, i := range report.Issues {
+				issues = append(issues, i.Check)
+			}
+			assert.Equal(t, tt.ok, report.OK(), report.Issues)
+			assert.Equal(t, tt.issues, issues)
+			assert.Equal(t, tt.skipped, report.Skipped)
+		})
+	}
+}
+
+func TestClient_PreflightTx_Report(t *testing.T) {
+	from := types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+	client, err := NewClient(WithTransport(preflightMock(t)), WithDefaultAddress(from))
+	require.NoError(t, err)
+	tx := types.NewTransaction().SetNonce(7).SetGasLimit(100000).SetGasPrice(big.NewInt(10)).SetInput([]byte{0, 1})
+	report, err := client.PreflightTx(context.Background(), tx)
+	require.NoError(t, err)
+	assert.True(t, report.OK())
+	assert.Equal(t, &from, report.Sender)
+	// 53000 + 2 (one init code word) + 4 + 16.
+	assert.Equal(t, uint64(53022), report.IntrinsicGas)
+	assert.Equal(t, big.NewInt(1_000_000), report.MaxCost)
+	assert.Equal(t, big.NewInt(1_000_000), report.Balance)
+	assert.Equal(t, uint64(7), *report.Nonce)
+	assert.Equal(t, big.NewInt(10), report.BaseFee)
+	assert.Equal(t, uint64(30_000_000), report.BlockGas)
+	assert.Greater(t, report.Size, uint64(txSignatureSize))
+}
+
+func TestIntrinsicGas_Floor(t *testing.T) {
+	// 100 non-zero bytes: 21000 + 1600 standard cost, 21000 + 4000 floor.
+	tx := types.NewTransaction().SetTo(types.Address{}).SetInput(make([]byte, 100))
+	for i := range tx.Input {
+		tx.Input[i] = 1
+	}
+	assert.Equal(t, uint64(25000), intrinsicGas(tx))
+}
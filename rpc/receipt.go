@@ -0,0 +1,106 @@
+package rpc
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// defaultWaitInterval is the default polling interval of WaitForReceipt.
+const defaultWaitInterval = time.Second
+
+// WaitOptions is the options for Client.WaitForReceipt.
+type WaitOptions struct {
+	// Confirmations is the number of blocks, including the inclusion block,
+	// that must be mined before the receipt is returned. If 0, the receipt
+	// is returned as soon as the transaction is included in a block.
+	Confirmations uint64
+
+	// Interval is the interval at which the chain head is polled. The
+	// default is one second.
+	Interval time.Duration
+
+	// OnReorg is an optional callback called when the block in which the
+	// transaction was included is removed from the canonical chain.
+	// Included is the receipt from the new canonical block, or nil if the
+	// transaction is not included in any block at the moment.
+	OnReorg func(removed, included *types.TransactionReceipt)
+}
+
+// WaitForReceipt waits until the transaction is included in a block and
+// the required number of confirmations is reached, then returns the receipt
+// from the canonical chain.
+//
+// At every new head, the receipt is fetched again and the hash of the
+// inclusion block is compared with the hash of the canonical block at the
+// same height. If the block was reorged out, the confirmations are counted
+// again from the block in which the transaction is re-included, if any.
+func (c *Client) WaitForReceipt(ctx context.Context, hash types.Hash, opts WaitOptions) (*types.TransactionReceipt, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultWaitInterval
+	}
+	if opts.Confirmations == 0 {
+		opts.Confirmations = 1
+	}
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+	var (
+		current *types.TransactionReceipt // Receipt from the last check.
+		head    *big.Int                  // Head from the last check.
+	)
+	for {
+		newHead, err := c.BlockNumber(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if head == nil || newHead.Cmp(head) != 0 {
+			head = newHead
+			receipt, err := c.canonicalReceipt(ctx, hash)
+			if err != nil {
+				return nil, err
+			}
+			if current != nil && (receipt == nil || receipt.BlockHash != current.BlockHash) && opts.OnReorg != nil {
+				opts.OnReorg(current, receipt)
+			}
+			current = receipt
+			if current != nil {
+				confirmations := new(big.Int).Sub(head, current.BlockNumber)
+				confirmations.Add(confirmations, big.NewInt(1))
+				if confirmations.Cmp(new(big.Int).SetUint64(opts.Confirmations)) >= 0 {
+					return current, nil
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// canonicalReceipt returns the receipt of the transaction if it is included
+// in a block that belongs to the canonical chain, otherwise it returns nil.
+func (c *Client) canonicalReceipt(ctx context.Context, hash types.Hash) (*types.TransactionReceipt, error) {
+	var receipt *types.TransactionReceipt
+	if err := c.transport.Call(ctx, &receipt, "eth_getTransactionReceipt", hash); err != nil {
+		return nil, err
+	}
+	if receipt == nil || receipt.BlockNumber == nil {
+		return nil, nil
+	}
+	// Nodes may return receipts from blocks that are no longer canonical
+	// until their indexes are updated.
+	var block *struct {
+		Hash types.Hash `json:"hash"`
+	}
+	if err := c.transport.Call(ctx, &block, "eth_getBlockByNumber", types.BlockNumberFromBigInt(receipt.BlockNumber), false); err != nil {
+		return nil, err
+	}
+	if block == nil || block.Hash != receipt.BlockHash {
+		return nil, nil
+	}
+	return receipt, nil
+}
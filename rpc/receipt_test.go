@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+func TestClient_WaitForReceipt(t *testing.T) {
+	var (
+		txHash = types.MustHashFromHex("0x01", types.PadLeft)
+		hashA  = types.MustHashFromHex("0x0a", types.PadLeft)
+		hashB  = types.MustHashFromHex("0x0b", types.PadLeft)
+		hashC  = types.MustHashFromHex("0x0c", types.PadLeft)
+	)
+	receipt := func(number int64, blockHash types.Hash) *types.TransactionReceipt {
+		return &types.TransactionReceipt{
+			TransactionHash:   txHash,
+			BlockHash:         blockHash,
+			BlockNumber:       big.NewInt(number),
+			EffectiveGasPrice: big.NewInt(1),
+		}
+	}
+
+	// Chain state at every head.
+	type state struct {
+		receipt   *types.TransactionReceipt
+		canonical map[int64]types.Hash
+	}
+	states := map[int64]state{
+		10: {},
+		11: {receipt: receipt(11, hashA), canonical: map[int64]types.Hash{11: hashA}},
+		// Block 11 is reorged out, but the node still returns the old receipt.
+		12: {receipt: receipt(11, hashA), canonical: map[int64]types.Hash{11: hashB}},
+		// Transaction is re-included in block 12.
+		13: {receipt: receipt(12, hashC), canonical: map[int64]types.Hash{11: hashB, 12: hashC}},
+		14: {receipt: receipt(12, hashC), canonical: map[int64]types.Hash{11: hashB, 12: hashC}},
+	}
+	head := int64(9)
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		switch method {
+		case "eth_blockNumber":
+			head++
+			return types.NumberFromUint64(uint64(head)), nil
+		case "eth_getTransactionReceipt":
+			if r := states[head].receipt; r != nil {
+				return r, nil
+			}
+			return nil, nil
+		case "eth_getBlockByNumber":
+			number := args[0].(types.BlockNumber)
+			if h, ok := states[head].canonical[number.Big().Int64()]; ok {
+				return map[string]any{"hash": h}, nil
+			}
+			return nil, nil
+		}
+		t.Fatalf("unexpected call %s", method)
+		return nil, nil
+	}}
+	client, err := NewClient(WithTransport(mock))
+	require.NoError(t, err)
+
+	var reorgs [][2]*types.TransactionReceipt
+	res, err := client.WaitForReceipt(context.Background(), txHash, WaitOptions{
+		Confirmations: 3,
+		Interval:      time.Millisecond,
+		OnReorg: func(removed, included *types.TransactionReceipt) {
+			reorgs = append(reorgs, [2]*types.TransactionReceipt{removed, included})
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(14), head)
+	assert.Equal(t, hashC, res.BlockHash)
+	assert.Equal(t, big.NewInt(12), res.BlockNumber)
+	require.Len(t, reorgs, 1)
+	assert.Equal(t, hashA, reorgs[0][0].BlockHash)
+	assert.Nil(t, reorgs[0][1])
+}
+
+func TestClient_WaitForReceipt_Canceled(t *testing.T) {
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		if method == "eth_blockNumber" {
+			return types.NumberFromUint64(1), nil
+		}
+		return nil, nil
+	}}
+	client, err := NewClient(WithTransport(mock))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = client.WaitForReceipt(ctx, types.Hash{}, WaitOptions{Interval: time.Millisecond})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
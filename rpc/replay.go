@@ -0,0 +1,102 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/rpc/transport"
+	"github.com/defiweb/go-eth/types"
+)
+
+// ReplayResult is the result of Client.ReplayTransaction.
+type ReplayResult struct {
+	Transaction *types.OnChainTransaction // Transaction is the replayed transaction.
+	Receipt     *types.TransactionReceipt // Receipt is the receipt of the original execution.
+	Block       uint64                    // Block is the number of the block at which the transaction was replayed.
+
+	// Output is the data returned by the replayed call. If the call
+	// reverted, it is the revert data, if the node returned it.
+	Output []byte
+
+	// Err is the execution error of the replayed call, or nil if the call
+	// succeeded. Revert reasons and panics are returned as abi.RevertError
+	// and abi.PanicError.
+	Err error
+}
+
+// Success returns true if the replayed call succeeded.
+func (r *ReplayResult) Success() bool {
+	return r.Err == nil
+}
+
+// Matches returns true if the outcome of the replayed call, success or
+// failure, matches the status of the receipt. Receipts of pre-Byzantium
+// transactions have no status, in which case it returns false.
+func (r *ReplayResult) Matches() bool {
+	if r.Receipt == nil || r.Receipt.Status == nil {
+		return false
+	}
+	return (*r.Receipt.Status == 1) == r.Success()
+}
+
+// ReplayTransaction executes the mined transaction again using eth_call, at
+// the state of the parent block of the block in which it was included, and
+// returns the outcome together with the original receipt. It is useful for
+// finding the revert reason of a failed transaction, which is not stored
+// in the receipt.
+//
+// The call is made with the same sender, recipient, value, input, gas limit
+// and access list. Fees are omitted, because the base fee of the parent
+// block may be higher than the fee cap of the transaction. Transactions
+// that precede the replayed one in the same block are not applied, so the
+// outcome may differ if the transaction depends on them. The node must be
+// able to serve the state of the parent block, which usually requires an
+// archive node for older blocks.
+func (c *Client) ReplayTransaction(ctx context.Context, hash types.Hash) (*ReplayResult, error) {
+	tx, err := c.GetTransactionByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	receipt, err := c.GetTransactionReceipt(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.BlockNumber == nil || receipt.BlockNumber.Sign() == 0 {
+		return nil, fmt.Errorf("rpc client: transaction %s is not included in a block", hash)
+	}
+	res := &ReplayResult{
+		Transaction: tx,
+		Receipt:     receipt,
+		Block:       new(big.Int).Sub(receipt.BlockNumber, big.NewInt(1)).Uint64(),
+	}
+	call := tx.Call.Copy()
+	if call.From == nil {
+		call.From = &receipt.From
+	}
+	call.GasPrice = nil
+	call.MaxFeePerGas = nil
+	call.MaxPriorityFeePerGas = nil
+	res.Output, _, err = c.baseClient.Call(ctx, call, types.BlockNumberFromUint64(res.Block))
+	if err != nil {
+		var rpcErr transport.RPCErrorCode
+		if !errors.As(err, &rpcErr) {
+			return nil, err
+		}
+		res.Err = err
+		var dataErr transport.RPCErrorData
+		if errors.As(err, &dataErr) {
+			if data, ok := dataErr.RPCErrorData().([]byte); ok {
+				res.Output = data
+				if revertErr := abi.ToRevertError(data); revertErr != nil {
+					res.Err = revertErr
+				} else if panicErr := abi.ToPanicError(data); panicErr != nil {
+					res.Err = panicErr
+				}
+			}
+		}
+	}
+	return res, nil
+}
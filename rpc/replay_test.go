@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/hexutil"
+	"github.com/defiweb/go-eth/rpc/transport"
+	"github.com/defiweb/go-eth/types"
+)
+
+func TestClient_ReplayTransaction(t *testing.T) {
+	var (
+		hash   = types.MustHashFromHex("0x01", types.PadLeft)
+		from   = types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+		to     = types.MustAddressFromHex("0x2222222222222222222222222222222222222222")
+		status = uint64(0)
+	)
+	revertData := append([]byte{0x08, 0xc3, 0x79, 0xa0}, abi.MustEncodeValues(abi.MustParseType("(string)"), "insufficient balance")...)
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		switch method {
+		case "eth_getTransactionByHash":
+			return &types.OnChainTransaction{
+				Transaction: *types.NewTransaction().
+					SetType(types.DynamicFeeTxType).
+					SetFrom(from).
+					SetTo(to).
+					SetGasLimit(100000).
+					SetMaxFeePerGas(big.NewInt(10)).
+					SetMaxPriorityFeePerGas(big.NewInt(1)).
+					SetInput([]byte{1, 2, 3, 4}),
+				Hash: &hash,
+			}, nil
+		case "eth_getTransactionReceipt":
+			return &types.TransactionReceipt{
+				TransactionHash:   hash,
+				BlockNumber:       big.NewInt(11),
+				From:              from,
+				EffectiveGasPrice: big.NewInt(5),
+				Status:            &status,
+			}, nil
+		case "eth_call":
+			call := args[0].(*types.Call)
+			assert.Equal(t, uint64(10), blockArg(args[1]))
+			assert.Equal(t, from, *call.From)
+			assert.Equal(t, []byte{1, 2, 3, 4}, call.Input)
+			assert.Equal(t, uint64(100000), *call.GasLimit)
+			assert.Nil(t, call.MaxFeePerGas)
+			return nil, transport.NewRPCError(3, "execution reverted", hexutil.BytesToHex(revertData))
+		}
+		t.Fatalf("unexpected call %s", method)
+		return nil, nil
+	}}
+	client, err := NewClient(WithTransport(mock))
+	require.NoError(t, err)
+
+	res, err := client.ReplayTransaction(context.Background(), hash)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), res.Block)
+	assert.False(t, res.Success())
+	assert.True(t, res.Matches())
+	assert.Equal(t, revertData, res.Output)
+	assert.Equal(t, abi.RevertError{Reason: "insufficient balance"}, res.Err)
+}
+
+func TestClient_ReplayTransaction_TransportError(t *testing.T) {
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		switch method {
+		case "eth_getTransactionByHash":
+			return &types.OnChainTransaction{Transaction: *types.NewTransaction()}, nil
+		case "eth_getTransactionReceipt":
+			return &types.TransactionReceipt{BlockNumber: big.NewInt(11), EffectiveGasPrice: big.NewInt(1)}, nil
+		case "eth_call":
+			return nil, errors.New("connection refused")
+		}
+		t.Fatalf("unexpected call %s", method)
+		return nil, nil
+	}}
+	client, err := NewClient(WithTransport(mock))
+	require.NoError(t, err)
+
+	_, err = client.ReplayTransaction(context.Background(), types.Hash{})
+	assert.EqualError(t, err, "connection refused")
+}
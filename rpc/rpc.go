@@ -31,7 +31,8 @@ type RPC interface {
 
 	// Syncing performs eth_syncing RPC call.
 	//
-	// It returns an object with data about the sync status or false.
+	// It returns an object with data about the sync status or nil if the
+	// node is not syncing.
 	Syncing(ctx context.Context) (*types.SyncStatus, error)
 
 	// NetworkID performs net_version RPC call.
@@ -44,6 +45,14 @@ type RPC interface {
 	// It returns the current chain ID.
 	ChainID(ctx context.Context) (uint64, error)
 
+	// Config performs eth_config RPC call.
+	//
+	// It returns the fork configuration of the chain, including the blob
+	// schedule, precompiles and system contracts of the current and the
+	// next fork. The method is defined in EIP-7910 and is not supported by
+	// older nodes.
+	Config(ctx context.Context) (*types.ChainConfig, error)
+
 	// GasPrice performs eth_gasPrice RPC call.
 	//
 	// It returns the current price per gas in wei.
@@ -70,6 +79,12 @@ type RPC interface {
 	// address.
 	GetStorageAt(ctx context.Context, account types.Address, key types.Hash, block types.BlockNumber) (*types.Hash, error)
 
+	// GetProof performs eth_getProof RPC call.
+	//
+	// It returns the Merkle proof of the account and of the given storage
+	// keys of the account.
+	GetProof(ctx context.Context, account types.Address, keys []types.Hash, block types.BlockNumber) (*types.AccountProof, error)
+
 	// GetTransactionCount performs eth_getTransactionCount RPC call.
 	//
 	// It returns the number of transactions sent from the given address.
@@ -142,46 +157,64 @@ type RPC interface {
 	// BlockByHash performs eth_getBlockByHash RPC call.
 	//
 	// It returns information about a block by hash.
+	//
+	// If the block is not found, ErrNotFound is returned.
 	BlockByHash(ctx context.Context, hash types.Hash, full bool) (*types.Block, error)
 
 	// BlockByNumber performs eth_getBlockByNumber RPC call.
 	//
 	// It returns the block with the given number.
+	//
+	// If the block is not found, ErrNotFound is returned.
 	BlockByNumber(ctx context.Context, number types.BlockNumber, full bool) (*types.Block, error)
 
 	// GetTransactionByHash performs eth_getTransactionByHash RPC call.
 	//
 	// It returns the information about a transaction requested by transaction.
+	//
+	// If the transaction is not found, ErrNotFound is returned.
 	GetTransactionByHash(ctx context.Context, hash types.Hash) (*types.OnChainTransaction, error)
 
 	// GetTransactionByBlockHashAndIndex performs eth_getTransactionByBlockHashAndIndex RPC call.
 	//
 	// It returns the information about a transaction requested by transaction.
+	//
+	// If the transaction is not found, ErrNotFound is returned.
 	GetTransactionByBlockHashAndIndex(ctx context.Context, hash types.Hash, index uint64) (*types.OnChainTransaction, error)
 
 	// GetTransactionByBlockNumberAndIndex performs eth_getTransactionByBlockNumberAndIndex RPC call.
 	//
 	// It returns the information about a transaction requested by transaction.
+	//
+	// If the transaction is not found, ErrNotFound is returned.
 	GetTransactionByBlockNumberAndIndex(ctx context.Context, number types.BlockNumber, index uint64) (*types.OnChainTransaction, error)
 
 	// GetTransactionReceipt performs eth_getTransactionReceipt RPC call.
 	//
 	// It returns the receipt of a transaction by transaction hash.
+	//
+	// If the transaction is unknown or still pending, ErrNotFound is returned.
 	GetTransactionReceipt(ctx context.Context, hash types.Hash) (*types.TransactionReceipt, error)
 
 	// GetBlockReceipts performs eth_getBlockReceipts RPC call.
 	//
 	// It returns all transaction receipts for a given block hash or number.
+	//
+	// If the block is not found, ErrNotFound is returned.
 	GetBlockReceipts(ctx context.Context, block types.BlockNumber) ([]*types.TransactionReceipt, error)
 
 	// GetUncleByBlockHashAndIndex performs eth_getUncleByBlockNumberAndIndex RPC call.
 	//
 	// It returns information about an uncle of a block by number and uncle index position.
+	//
+	// If the uncle is not found, ErrNotFound is returned.
 	GetUncleByBlockHashAndIndex(ctx context.Context, hash types.Hash, index uint64) (*types.Block, error)
 
 	// GetUncleByBlockNumberAndIndex performs eth_getUncleByBlockNumberAndIndex RPC call.
 	//
 	// It returns information about an uncle of a block by hash and uncle index position.
+	//
+	// If the uncle is not found, ErrNotFound is returned.
 	GetUncleByBlockNumberAndIndex(ctx context.Context, number types.BlockNumber, index uint64) (*types.Block, error)
 
 	// NewFilter performs eth_newFilter RPC call.
@@ -228,11 +261,31 @@ type RPC interface {
 	// It returns logs that match the given query.
 	GetLogs(ctx context.Context, query *types.FilterLogsQuery) ([]types.Log, error)
 
+	// StreamLogs performs eth_getLogs RPC call.
+	//
+	// Unlike GetLogs, it passes logs to the callback as they are decoded,
+	// without materializing the whole array, which bounds memory usage for
+	// large responses. If the callback returns an error, decoding stops and
+	// the error is returned.
+	//
+	// With the HTTP transport, the response is decoded while it is read.
+	// Other transports read the whole response first. If the transport
+	// retries the call, the callback may receive the same logs again.
+	StreamLogs(ctx context.Context, query *types.FilterLogsQuery, fn func(types.Log) error) error
+
 	// MaxPriorityFeePerGas performs eth_maxPriorityFeePerGas RPC call.
 	//
 	// It returns the estimated maximum priority fee per gas.
 	MaxPriorityFeePerGas(ctx context.Context) (*big.Int, error)
 
+	// FeeHistory performs eth_feeHistory RPC call.
+	//
+	// It returns the base fee per gas, gas used ratio and the effective
+	// priority fees at the given reward percentiles for the blockCount blocks
+	// up to and including newestBlock. Nodes may return fewer blocks than
+	// requested.
+	FeeHistory(ctx context.Context, blockCount uint64, newestBlock types.BlockNumber, rewardPercentiles []float64) (*types.FeeHistory, error)
+
 	// SubscribeLogs performs eth_subscribe RPC call with "logs" subscription
 	// type.
 	//
@@ -256,4 +309,19 @@ type RPC interface {
 	//
 	// Subscription channel will be closed when the context is canceled.
 	SubscribeNewPendingTransactions(ctx context.Context) (<-chan types.Hash, error)
+
+	// LogsSubscription works like SubscribeLogs, but returns a Subscription
+	// handle that can be used to cancel the subscription and to check why
+	// it ended.
+	LogsSubscription(ctx context.Context, query *types.FilterLogsQuery) (*Subscription[types.Log], error)
+
+	// NewHeadsSubscription works like SubscribeNewHeads, but returns
+	// a Subscription handle that can be used to cancel the subscription and
+	// to check why it ended.
+	NewHeadsSubscription(ctx context.Context) (*Subscription[types.Block], error)
+
+	// NewPendingTransactionsSubscription works like
+	// SubscribeNewPendingTransactions, but returns a Subscription handle that
+	// can be used to cancel the subscription and to check why it ended.
+	NewPendingTransactionsSubscription(ctx context.Context) (*Subscription[types.Hash], error)
 }
@@ -0,0 +1,226 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/types"
+)
+
+// defaultSearchChunkSize is the default number of blocks per eth_getLogs
+// request made by Client.SearchEvents.
+const defaultSearchChunkSize = 2000
+
+// SearchOptions is the options for Client.SearchEvents.
+type SearchOptions struct {
+	// Addresses limits the search to logs emitted by the given contracts.
+	// If empty, logs of all contracts are returned.
+	Addresses []types.Address
+
+	// Topics are optional filters for indexed arguments, starting with the
+	// first indexed argument. The first topic of the event is added
+	// automatically.
+	Topics [][]types.Hash
+
+	// FromBlock and ToBlock are the block range to search, inclusive. Tags
+	// are resolved to block numbers before the search. The default range
+	// is from the genesis block to the latest block.
+	FromBlock *types.BlockNumber
+	ToBlock   *types.BlockNumber
+
+	// Since and Until limit the search to blocks with timestamps in the
+	// given range, inclusive. They are resolved to block numbers using
+	// Client.BlockNumberAt and narrow down FromBlock and ToBlock.
+	Since time.Time
+	Until time.Time
+
+	// ChunkSize is the maximum number of blocks per eth_getLogs request.
+	// The default is 2000 blocks.
+	ChunkSize uint64
+}
+
+// SearchResult is a log found by Client.SearchEvents.
+type SearchResult struct {
+	Log types.Log // Log is the raw log.
+
+	// Values are the decoded event arguments. Unnamed arguments are named
+	// "topicN" if indexed, where N is the topic index, and "dataN"
+	// otherwise, where N is the index of the non-indexed argument.
+	Values map[string]any
+
+	// Err is the decoding error. If set, Values is nil.
+	Err error
+}
+
+// SearchEvents searches logs of the event with the given signature, e.g.
+// "Transfer(address,address,uint256)" or
+// "event Transfer(address indexed from, address indexed to, uint256 value)".
+//
+// The search range is split into chunks of SearchOptions.ChunkSize blocks,
+// which are queried sequentially.
+//
+// If the signature does not specify indexed arguments, they are inferred
+// from the number of topics of every log, assuming that the leading
+// arguments are indexed, as is the case for most standard events. This
+// allows searching for events that share the signature but differ in
+// indexed arguments, such as ERC-20 and ERC-721 Transfer events.
+func (c *Client) SearchEvents(ctx context.Context, signature string, opts SearchOptions) ([]SearchResult, error) {
+	event, err := abi.ParseEvent(signature)
+	if err != nil {
+		return nil, fmt.Errorf("rpc client: invalid event signature: %w", err)
+	}
+	if opts.ChunkSize == 0 {
+		opts.ChunkSize = defaultSearchChunkSize
+	}
+	from, to, err := c.searchRange(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	topics := append([][]types.Hash{{event.Topic0()}}, opts.Topics...)
+	var res []SearchResult
+	for lo := from; lo <= to; lo += opts.ChunkSize {
+		hi := lo + opts.ChunkSize - 1
+		if hi > to || hi < lo {
+			hi = to
+		}
+		logs, err := c.GetLogs(ctx, types.NewFilterLogsQuery().
+			SetAddresses(opts.Addresses...).
+			SetFromBlock(types.BlockNumberFromUint64Ptr(lo)).
+			SetToBlock(types.BlockNumberFromUint64Ptr(hi)).
+			SetTopics(topics...))
+		if err != nil {
+			return nil, fmt.Errorf("rpc client: failed to fetch logs in blocks %d-%d: %w", lo, hi, err)
+		}
+		for _, l := range logs {
+			res = append(res, decodeSearchResult(event, l))
+		}
+		if hi == to {
+			break
+		}
+	}
+	return res, nil
+}
+
+// BlockNumberAt returns the number of the last block with a timestamp
+// not after the given time, that is, the block that was the chain head at
+// that time. It returns an error if t is before the genesis block.
+//
+// The block is found using a binary search over block timestamps.
+func (c *Client) BlockNumberAt(ctx context.Context, t time.Time) (uint64, error) {
+	latest, err := c.BlockNumber(ctx)
+	if err != nil {
+		return 0, err
+	}
+	n, err := c.firstBlockAfter(ctx, latest.Uint64(), t)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("rpc client: no blocks before %s", t)
+	}
+	return n - 1, nil
+}
+
+// searchRange resolves the block range of the search.
+func (c *Client) searchRange(ctx context.Context, opts SearchOptions) (from, to uint64, err error) {
+	latest, err := c.BlockNumber(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	to = latest.Uint64()
+	if opts.FromBlock != nil {
+		if from, err = c.resolveBlockNumber(ctx, *opts.FromBlock); err != nil {
+			return 0, 0, err
+		}
+	}
+	if opts.ToBlock != nil {
+		if to, err = c.resolveBlockNumber(ctx, *opts.ToBlock); err != nil {
+			return 0, 0, err
+		}
+	}
+	if !opts.Since.IsZero() {
+		// First block at or after Since.
+		n, err := c.firstBlockAfter(ctx, latest.Uint64(), opts.Since.Add(-time.Nanosecond))
+		if err != nil {
+			return 0, 0, err
+		}
+		if n > from {
+			from = n
+		}
+	}
+	if !opts.Until.IsZero() {
+		// Last block at or before Until.
+		n, err := c.firstBlockAfter(ctx, latest.Uint64(), opts.Until)
+		if err != nil {
+			return 0, 0, err
+		}
+		if n == 0 {
+			return 0, 0, fmt.Errorf("rpc client: no blocks before %s", opts.Until)
+		}
+		if n-1 < to {
+			to = n - 1
+		}
+	}
+	if from > to {
+		return 0, 0, fmt.Errorf("rpc client: empty search range %d-%d", from, to)
+	}
+	return from, to, nil
+}
+
+// resolveBlockNumber returns the number of the given block, resolving tags
+// such as "latest" or "finalized".
+func (c *Client) resolveBlockNumber(ctx context.Context, number types.BlockNumber) (uint64, error) {
+	if !number.IsTag() {
+		return number.Big().Uint64(), nil
+	}
+	block, err := c.BlockByNumber(ctx, number, false)
+	if err != nil {
+		return 0, err
+	}
+	return block.Number.Uint64(), nil
+}
+
+// firstBlockAfter returns the number of the first block with a timestamp
+// after t, or latest+1 if there is no such block.
+func (c *Client) firstBlockAfter(ctx context.Context, latest uint64, t time.Time) (uint64, error) {
+	lo, hi := uint64(0), latest+1
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		block, err := c.BlockByNumber(ctx, types.BlockNumberFromUint64(mid), false)
+		if err != nil {
+			return 0, err
+		}
+		if block.Timestamp.After(t) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo, nil
+}
+
+// decodeSearchResult decodes the log, inferring indexed arguments from the
+// number of topics if necessary.
+func decodeSearchResult(event *abi.Event, l types.Log) SearchResult {
+	res := SearchResult{Log: l}
+	if event.Inputs().IndexedSize() == 0 && len(l.Topics) > 1 {
+		elems := event.Inputs().Elements()
+		if len(l.Topics)-1 > len(elems) {
+			res.Err = fmt.Errorf("rpc client: log has more topics than %s has arguments", event.Name())
+			return res
+		}
+		for i := 0; i < len(l.Topics)-1; i++ {
+			elems[i].Indexed = true
+		}
+		event = abi.NewEvent(event.Name(), abi.NewEventTupleType(elems...), false)
+	}
+	values := make(map[string]any)
+	if err := event.DecodeValue(l.Topics, l.Data, &values); err != nil {
+		res.Err = err
+		return res
+	}
+	res.Values = values
+	return res
+}
@@ -0,0 +1,136 @@
+package rpc
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/types"
+)
+
+func TestClient_SearchEvents(t *testing.T) {
+	var (
+		transfer = abi.MustParseEvent("Transfer(address indexed, address indexed, uint256)")
+		alice    = types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+		bob      = types.MustAddressFromHex("0x2222222222222222222222222222222222222222")
+		token    = types.MustAddressFromHex("0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+		nft      = types.MustAddressFromHex("0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb")
+	)
+	topic := func(a types.Address) types.Hash {
+		return types.MustHashFromBytes(a.Bytes(), types.PadLeft)
+	}
+	logs := map[uint64]types.Log{
+		// ERC-20 transfer, the value is not indexed.
+		20: {
+			Address:     token,
+			Topics:      []types.Hash{transfer.Topic0(), topic(alice), topic(bob)},
+			Data:        abi.MustEncodeValue(abi.MustParseType("uint256"), big.NewInt(100)),
+			BlockNumber: big.NewInt(20),
+		},
+		// ERC-721 transfer, the token ID is indexed.
+		45: {
+			Address:     nft,
+			Topics:      []types.Hash{transfer.Topic0(), topic(bob), topic(alice), types.MustHashFromHex("0x07", types.PadLeft)},
+			BlockNumber: big.NewInt(45),
+		},
+		// Outside the time range.
+		80: {
+			Address:     token,
+			Topics:      []types.Hash{transfer.Topic0(), topic(alice), topic(bob)},
+			Data:        abi.MustEncodeValue(abi.MustParseType("uint256"), big.NewInt(1)),
+			BlockNumber: big.NewInt(80),
+		},
+	}
+	var ranges [][2]uint64
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		switch method {
+		case "eth_blockNumber":
+			return types.NumberFromUint64(100), nil
+		case "eth_getBlockByNumber":
+			number := blockArg(args[0])
+			return map[string]any{
+				"number":    types.NumberFromUint64(number),
+				"timestamp": types.NumberFromUint64(1000 + number*12),
+			}, nil
+		case "eth_getLogs":
+			query := args[0].(*types.FilterLogsQuery)
+			from, to := query.FromBlock.Big().Uint64(), query.ToBlock.Big().Uint64()
+			ranges = append(ranges, [2]uint64{from, to})
+			assert.Equal(t, transfer.Topic0(), query.Topics[0][0])
+			var res []types.Log
+			for n := from; n <= to; n++ {
+				if l, ok := logs[n]; ok {
+					res = append(res, l)
+				}
+			}
+			return res, nil
+		}
+		t.Fatalf("unexpected call %s", method)
+		return nil, nil
+	}}
+	client, err := NewClient(WithTransport(mock))
+	require.NoError(t, err)
+
+	res, err := client.SearchEvents(context.Background(), "Transfer(address,address,uint256)", SearchOptions{
+		Since:     time.Unix(1000+10*12, 0),
+		Until:     time.Unix(1000+50*12+5, 0),
+		ChunkSize: 20,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][2]uint64{{10, 29}, {30, 49}, {50, 50}}, ranges)
+	require.Len(t, res, 2)
+
+	require.NoError(t, res[0].Err)
+	assert.Equal(t, alice, res[0].Values["topic1"])
+	assert.Equal(t, bob, res[0].Values["topic2"])
+	assert.Equal(t, big.NewInt(100), res[0].Values["data0"])
+
+	require.NoError(t, res[1].Err)
+	assert.Equal(t, nft, res[1].Log.Address)
+	assert.Equal(t, big.NewInt(7), res[1].Values["topic3"])
+}
+
+func TestClient_BlockNumberAt(t *testing.T) {
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		switch method {
+		case "eth_blockNumber":
+			return types.NumberFromUint64(100), nil
+		case "eth_getBlockByNumber":
+			number := blockArg(args[0])
+			return map[string]any{
+				"number":    types.NumberFromUint64(number),
+				"timestamp": types.NumberFromUint64(1000 + number*12),
+			}, nil
+		}
+		t.Fatalf("unexpected call %s", method)
+		return nil, nil
+	}}
+	client, err := NewClient(WithTransport(mock))
+	require.NoError(t, err)
+
+	tests := []struct {
+		ts      int64
+		want    uint64
+		wantErr bool
+	}{
+		{ts: 1000, want: 0},
+		{ts: 1011, want: 0},
+		{ts: 1012, want: 1},
+		{ts: 1000 + 100*12 + 100, want: 100},
+		{ts: 999, wantErr: true},
+	}
+	for _, tt := range tests {
+		n, err := client.BlockNumberAt(context.Background(), time.Unix(tt.ts, 0))
+		if tt.wantErr {
+			assert.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, n)
+	}
+}
@@ -0,0 +1,242 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/store"
+	"github.com/defiweb/go-eth/types"
+)
+
+// defaultSenderCacheSize is the default number of senders cached by the
+// sender recovery.
+const defaultSenderCacheSize = 16384
+
+// SenderRecoveryOptions is the options for WithSenderRecovery.
+type SenderRecoveryOptions struct {
+	// Workers is the number of goroutines used to recover the senders of a
+	// single block. If zero, runtime.GOMAXPROCS(0) is used.
+	Workers int
+
+	// CacheSize is the maximum number of senders cached by transaction hash.
+	// Blocks that are fetched repeatedly, for example because of reorgs or
+	// overlapping ranges, do not need to be recovered again. If zero, 16384
+	// senders are cached. If negative, the cache is disabled.
+	CacheSize int
+
+	// OnlyMissing limits the recovery to transactions for which the node
+	// did not return the sender. By default, the sender returned by the
+	// node is replaced by the recovered one.
+	OnlyMissing bool
+
+	// Store, if set, persists recovered senders keyed by transaction hash,
+	// so that they survive restarts and can be shared between processes.
+	// It is used for senders that are not in the in-memory cache. Use
+	// store.NewPrefixed to share the storage with other components.
+	Store store.KV
+}
+
+// WithSenderRecovery makes BlockByNumber and BlockByHash recover the
+// sender of every transaction of full blocks from its signature and set the
+// From field.
+//
+// Unsigned transactions and transactions of types that the library cannot
+// decode, such as chain-specific system transactions, are left as returned
+// by the node.
+func WithSenderRecovery(opts SenderRecoveryOptions) ClientOptions {
+	return func(c *Client) error {
+		c.senders = newSenderRecovery(opts)
+		return nil
+	}
+}
+
+// BlockByHash implements the RPC interface.
+func (c *Client) BlockByHash(ctx context.Context, hash types.Hash, full bool) (*types.Block, error) {
+	block, err := c.baseClient.BlockByHash(ctx, hash, full)
+	if err != nil || !full || c.senders == nil {
+		return block, err
+	}
+	if err := c.senders.recoverBlock(ctx, block); err != nil {
+		return nil, fmt.Errorf("rpc client: %w", err)
+	}
+	return block, nil
+}
+
+// BlockByNumber implements the RPC interface.
+func (c *Client) BlockByNumber(ctx context.Context, number types.BlockNumber, full bool) (*types.Block, error) {
+	block, err := c.baseClient.BlockByNumber(ctx, number, full)
+	if err != nil || !full || c.senders == nil {
+		return block, err
+	}
+	if err := c.senders.recoverBlock(ctx, block); err != nil {
+		return nil, fmt.Errorf("rpc client: %w", err)
+	}
+	return block, nil
+}
+
+// senderRecovery recovers senders of block transactions.
+type senderRecovery struct {
+	opts  SenderRecoveryOptions
+	cache *senderCache
+}
+
+func newSenderRecovery(opts SenderRecoveryOptions) *senderRecovery {
+	s := &senderRecovery{opts: opts}
+	switch {
+	case opts.CacheSize == 0:
+		s.cache = newSenderCache(defaultSenderCacheSize)
+	case opts.CacheSize > 0:
+		s.cache = newSenderCache(opts.CacheSize)
+	}
+	return s
+}
+
+// recoverBlock sets the From field of the transactions of the block.
+func (s *senderRecovery) recoverBlock(ctx context.Context, block *types.Block) error {
+	var pending []int
+	for i := range block.Transactions {
+		tx := &block.Transactions[i]
+		if !recoverable(tx) || (s.opts.OnlyMissing && tx.From != nil) {
+			continue
+		}
+		if s.cache != nil && tx.Hash != nil {
+			if from, ok := s.cache.get(*tx.Hash); ok {
+				tx.From = &from
+				continue
+			}
+		}
+		pending = append(pending, i)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+	workers := s.opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(pending) {
+		workers = len(pending)
+	}
+	var (
+		next = int64(-1) // Index of the last claimed item.
+		errs = make([]error, len(pending))
+		wg   sync.WaitGroup
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				n := int(atomic.AddInt64(&next, 1))
+				if n >= len(pending) {
+					return
+				}
+				tx := &block.Transactions[pending[n]]
+				from, err := s.recoverTx(ctx, tx)
+				if err != nil {
+					errs[n] = fmt.Errorf("failed to recover sender of transaction %d: %w", pending[n], err)
+					continue
+				}
+				tx.From = from
+				if s.cache != nil && tx.Hash != nil {
+					s.cache.add(*tx.Hash, *from)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recoverTx returns the sender of the transaction from the store, or
+// recovers it from the signature and adds it to the store.
+func (s *senderRecovery) recoverTx(ctx context.Context, tx *types.OnChainTransaction) (*types.Address, error) {
+	if s.opts.Store == nil || tx.Hash == nil {
+		return crypto.ECRecoverer.RecoverTransaction(&tx.Transaction)
+	}
+	b, err := s.opts.Store.Get(ctx, tx.Hash.Bytes())
+	switch {
+	case err == nil:
+		from, err := types.AddressFromBytes(b)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stored sender: %w", err)
+		}
+		return &from, nil
+	case !errors.Is(err, store.ErrNotFound):
+		return nil, fmt.Errorf("failed to load sender: %w", err)
+	}
+	from, err := crypto.ECRecoverer.RecoverTransaction(&tx.Transaction)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.opts.Store.Put(ctx, tx.Hash.Bytes(), from.Bytes()); err != nil {
+		return nil, fmt.Errorf("failed to store sender: %w", err)
+	}
+	return from, nil
+}
+
+// recoverable returns true if the sender of the transaction can be
+// recovered from its signature.
+func recoverable(tx *types.OnChainTransaction) bool {
+	if tx.Signature == nil || tx.Signature.R == nil || tx.Signature.S == nil {
+		return false
+	}
+	if tx.Signature.R.Sign() == 0 && tx.Signature.S.Sign() == 0 {
+		return false
+	}
+	switch tx.Type {
+	case types.LegacyTxType, types.AccessListTxType, types.DynamicFeeTxType, types.SetCodeTxType:
+		return true
+	}
+	_, ok := types.LookupTransactionType(tx.Type)
+	return ok
+}
+
+// senderCache is a fixed-size cache of transaction senders. Senders never
+// change, so the oldest entries are evicted first.
+type senderCache struct {
+	mu    sync.Mutex
+	items map[types.Hash]types.Address
+	order []types.Hash
+	next  int
+}
+
+func newSenderCache(size int) *senderCache {
+	return &senderCache{
+		items: make(map[types.Hash]types.Address, size),
+		order: make([]types.Hash, 0, size),
+	}
+}
+
+func (c *senderCache) get(hash types.Hash) (types.Address, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	addr, ok := c.items[hash]
+	return addr, ok
+}
+
+func (c *senderCache) add(hash types.Hash, addr types.Address) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[hash]; ok {
+		return
+	}
+	if len(c.order) < cap(c.order) {
+		c.order = append(c.order, hash)
+	} else {
+		delete(c.items, c.order[c.next])
+		c.order[c.next] = hash
+		c.next = (c.next + 1) % len(c.order)
+	}
+	c.items[hash] = addr
+}
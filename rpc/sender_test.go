@@ -0,0 +1,143 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/store"
+	"github.com/defiweb/go-eth/types"
+)
+
+func senderBlockFixture(t *testing.T, n int) (types.Block, types.Address) {
+	key, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	signer := crypto.ECSigner(key.ToECDSA())
+	sender := crypto.ECPublicKeyToAddress(key.ToECDSA().Public().(*ecdsa.PublicKey))
+	block := types.Block{Number: big.NewInt(1)}
+	for i := 0; i < n; i++ {
+		tx := types.NewTransaction().
+			SetType(types.DynamicFeeTxType).
+			SetChainID(1).
+			SetNonce(uint64(i)).
+			SetTo(types.MustAddressFromHex("0x2222222222222222222222222222222222222222")).
+			SetGasLimit(21000).
+			SetMaxFeePerGas(big.NewInt(2e9)).
+			SetMaxPriorityFeePerGas(big.NewInt(1e9))
+		require.NoError(t, signer.SignTransaction(tx))
+		raw, err := tx.Raw()
+		require.NoError(t, err)
+		hash := crypto.Keccak256(raw)
+		block.Transactions = append(block.Transactions, types.OnChainTransaction{Transaction: *tx, Hash: &hash})
+	}
+	// Unsigned system transaction, which must be skipped.
+	block.Transactions = append(block.Transactions, types.OnChainTransaction{
+		Transaction: *types.NewTransaction().SetNonce(0),
+		Hash:        &types.Hash{1},
+	})
+	return block, sender
+}
+
+func TestClient_BlockByNumber_SenderRecovery(t *testing.T) {
+	block, sender := senderBlockFixture(t, 5)
+	wrong := types.MustAddressFromHex("0x3333333333333333333333333333333333333333")
+	block.Transactions[0].From = &wrong
+	calls := 0
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		calls++
+		return block, nil
+	}}
+	client, err := NewClient(WithTransport(mock), WithSenderRecovery(SenderRecoveryOptions{Workers: 2}))
+	require.NoError(t, err)
+
+	got, err := client.BlockByNumber(context.Background(), types.LatestBlockNumber, true)
+	require.NoError(t, err)
+	require.Len(t, got.Transactions, 6)
+	for _, tx := range got.Transactions[:5] {
+		require.NotNil(t, tx.From)
+		assert.Equal(t, sender, *tx.From)
+	}
+	assert.Nil(t, got.Transactions[5].From)
+	assert.Equal(t, 5, len(client.senders.cache.items))
+
+	// Senders are served from the cache on subsequent calls.
+	got, err = client.BlockByHash(context.Background(), types.Hash{}, true)
+	require.NoError(t, err)
+	assert.Equal(t, sender, *got.Transactions[4].From)
+	assert.Equal(t, 2, calls)
+}
+
+func TestClient_BlockByNumber_SenderRecoveryOnlyMissing(t *testing.T) {
+	block, sender := senderBlockFixture(t, 2)
+	wrong := types.MustAddressFromHex("0x3333333333333333333333333333333333333333")
+	block.Transactions[0].From = &wrong
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		return block, nil
+	}}
+	client, err := NewClient(WithTransport(mock), WithSenderRecovery(SenderRecoveryOptions{OnlyMissing: true, CacheSize: -1}))
+	require.NoError(t, err)
+
+	got, err := client.BlockByNumber(context.Background(), types.LatestBlockNumber, true)
+	require.NoError(t, err)
+	assert.Equal(t, wrong, *got.Transactions[0].From)
+	assert.Equal(t, sender, *got.Transactions[1].From)
+	assert.Nil(t, client.senders.cache)
+}
+
+func TestClient_BlockByNumber_SenderRecoveryInvalidSignature(t *testing.T) {
+	block, _ := senderBlockFixture(t, 1)
+	block.Transactions[0].Signature.R = big.NewInt(1)
+	block.Transactions[0].Signature.S = new(big.Int).Lsh(big.NewInt(1), 256)
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		return block, nil
+	}}
+	client, err := NewClient(WithTransport(mock), WithSenderRecovery(SenderRecoveryOptions{}))
+	require.NoError(t, err)
+
+	_, err = client.BlockByNumber(context.Background(), types.LatestBlockNumber, true)
+	assert.ErrorContains(t, err, "failed to recover sender of transaction 0")
+}
+
+func TestClient_BlockByNumber_SenderRecoveryStore(t *testing.T) {
+	block, sender := senderBlockFixture(t, 3)
+	mock := &callMock{Handler: func(method string, args ...any) (any, error) {
+		return block, nil
+	}}
+	kv := store.NewMemory()
+	client, err := NewClient(WithTransport(mock), WithSenderRecovery(SenderRecoveryOptions{CacheSize: -1, Store: kv}))
+	require.NoError(t, err)
+
+	_, err = client.BlockByNumber(context.Background(), types.LatestBlockNumber, true)
+	require.NoError(t, err)
+	stored, err := kv.Get(context.Background(), block.Transactions[0].Hash.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, sender.Bytes(), stored)
+
+	// Stored senders are not recovered again, even if the signature is
+	// invalid.
+	block.Transactions[0].Signature.S = new(big.Int).Lsh(big.NewInt(1), 256)
+	got, err := client.BlockByNumber(context.Background(), types.LatestBlockNumber, true)
+	require.NoError(t, err)
+	assert.Equal(t, sender, *got.Transactions[0].From)
+}
+
+func TestSenderCache(t *testing.T) {
+	c := newSenderCache(2)
+	c.add(types.Hash{1}, types.Address{1})
+	c.add(types.Hash{2}, types.Address{2})
+	c.add(types.Hash{3}, types.Address{3})
+	_, ok := c.get(types.Hash{1})
+	assert.False(t, ok)
+	addr, ok := c.get(types.Hash{3})
+	assert.True(t, ok)
+	assert.Equal(t, types.Address{3}, addr)
+	c.add(types.Hash{4}, types.Address{4})
+	_, ok = c.get(types.Hash{2})
+	assert.False(t, ok)
+}
@@ -0,0 +1,202 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/defiweb/go-eth/jsoncodec"
+	"github.com/defiweb/go-eth/rpc/transport"
+)
+
+// unsubscribeTimeout is the timeout for the eth_unsubscribe call made when
+// a subscription ends.
+const unsubscribeTimeout = 10 * time.Second
+
+var (
+	// ErrUnsubscribed is returned by Subscription.Err after the subscription
+	// was canceled using Subscription.Unsubscribe.
+	ErrUnsubscribed = errors.New("rpc client: unsubscribed")
+
+	// ErrSubscriptionClosed is returned by Subscription.Err when the
+	// subscription was closed by the transport, e.g. because the connection
+	// was closed.
+	ErrSubscriptionClosed = errors.New("rpc client: subscription closed by transport")
+)
+
+// Subscription is a handle to an active subscription.
+//
+// Messages are delivered to the channel returned by Chan. The channel is
+// closed when the subscription ends, either because Unsubscribe was called,
+// the context passed to the Subscribe method was canceled, or the transport
+// closed the subscription. Err returns the reason.
+type Subscription[T any] struct {
+	ch     chan T
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mu    sync.Mutex
+	err   error
+	stats SubscriptionStats
+}
+
+// SubscriptionStats contains delivery statistics of a subscription. They
+// help to find out why a subscription stopped delivering messages: if
+// LastReceived is old, the node stopped sending them, and if Pending is
+// not zero, the consumer does not read from the channel.
+type SubscriptionStats struct {
+	Received      uint64    // Received is the number of messages received from the transport.
+	Delivered     uint64    // Delivered is the number of messages delivered to the channel.
+	Malformed     uint64    // Malformed is the number of messages that could not be decoded and were skipped.
+	Pending       uint64    // Pending is the number of received messages waiting for the consumer.
+	LastReceived  time.Time // LastReceived is the time the last message was received, zero if none.
+	LastDelivered time.Time // LastDelivered is the time the last message was delivered, zero if none.
+}
+
+// Chan returns the channel that receives the subscription messages.
+func (s *Subscription[T]) Chan() <-chan T {
+	return s.ch
+}
+
+// Unsubscribe cancels the subscription and waits until the channel is
+// closed. It is safe to call Unsubscribe multiple times.
+func (s *Subscription[T]) Unsubscribe() {
+	s.setErr(ErrUnsubscribed)
+	s.cancel()
+	<-s.done
+}
+
+// Done returns a channel that is closed when the subscription ends, after
+// the channel returned by Chan is closed.
+func (s *Subscription[T]) Done() <-chan struct{} {
+	return s.done
+}
+
+// Stats returns the delivery statistics of the subscription.
+func (s *Subscription[T]) Stats() SubscriptionStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.stats
+	stats.Pending = stats.Received - stats.Delivered - stats.Malformed
+	return stats
+}
+
+// Err returns the reason the subscription ended. It returns nil while the
+// subscription is active.
+func (s *Subscription[T]) Err() error {
+	select {
+	case <-s.done:
+	default:
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// setErr sets the error if it has not been set yet.
+func (s *Subscription[T]) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// record updates the statistics.
+func (s *Subscription[T]) record(fn func(*SubscriptionStats)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&s.stats)
+}
+
+// subscribe creates a subscription to the given method. The messages are
+// unmarshalled to the T type. The subscription is unsubscribed and the
+// channel closed when the context is canceled.
+func subscribe[T any](ctx context.Context, t transport.Transport, method string, params ...any) (*Subscription[T], error) {
+	st, ok := t.(transport.SubscriptionTransport)
+	if !ok {
+		return nil, errors.New("transport does not support subscriptions")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	rawCh, subID, err := st.Subscribe(ctx, method, params...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	sub := &Subscription[T]{
+		ch:     make(chan T),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go subscriptionRoutine(ctx, st, subID, rawCh, sub)
+	return sub, nil
+}
+
+func subscriptionRoutine[T any](ctx context.Context, t transport.SubscriptionTransport, subID string, rawCh chan json.RawMessage, sub *Subscription[T]) {
+	defer close(sub.done)
+	defer close(sub.ch)
+	defer sub.cancel()
+	for {
+		select {
+		case <-ctx.Done():
+			sub.setErr(ctx.Err())
+			unsubscribe(t, subID, rawCh)
+			return
+		case raw, ok := <-rawCh:
+			if !ok {
+				sub.setErr(ErrSubscriptionClosed)
+				return
+			}
+			sub.record(func(s *SubscriptionStats) {
+				s.Received++
+				s.LastReceived = time.Now()
+			})
+			var msg T
+			if err := jsoncodec.Unmarshal(raw, &msg); err != nil {
+				sub.record(func(s *SubscriptionStats) { s.Malformed++ })
+				continue
+			}
+			select {
+			case sub.ch <- msg:
+				sub.record(func(s *SubscriptionStats) {
+					s.Delivered++
+					s.LastDelivered = time.Now()
+				})
+			case <-ctx.Done():
+				sub.setErr(ctx.Err())
+				unsubscribe(t, subID, rawCh)
+				return
+			}
+		}
+	}
+}
+
+// unsubscribe cancels the subscription on the transport. The subscription
+// context is already canceled at this point, so a new context is used.
+//
+// Messages received in the meantime are discarded, so that the transport
+// is not blocked on delivering them.
+//
+//nolint:errcheck
+func unsubscribe(t transport.SubscriptionTransport, subID string, rawCh chan json.RawMessage) {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case _, ok := <-rawCh:
+				if !ok {
+					return
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), unsubscribeTimeout)
+	defer cancel()
+	t.Unsubscribe(ctx, subID)
+}
@@ -0,0 +1,100 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+func newHeadsSubscription(t *testing.T, ctx context.Context) (*Subscription[types.Block], *streamMock, chan json.RawMessage) {
+	streamMock := newStreamMock(t)
+	client := &baseClient{transport: streamMock}
+	rawCh := make(chan json.RawMessage)
+	streamMock.SubscribeMocks = append(streamMock.SubscribeMocks, subscribeMock{
+		ArgMethod: "newHeads",
+		RetCh:     rawCh,
+		RetID:     "1",
+	})
+	streamMock.UnsubscribeMocks = append(streamMock.UnsubscribeMocks, unsubscribeMock{
+		ArgID: "1",
+	})
+	sub, err := client.NewHeadsSubscription(ctx)
+	require.NoError(t, err)
+	return sub, streamMock, rawCh
+}
+
+func TestSubscription_Unsubscribe(t *testing.T) {
+	sub, streamMock, rawCh := newHeadsSubscription(t, context.Background())
+	assert.NoError(t, sub.Err())
+
+	rawCh <- json.RawMessage(mockSubscribeNewHeadsResponse)
+	block := <-sub.Chan()
+	assert.Equal(t, "17", block.Number.String())
+
+	// The consumer stops reading while the transport is still delivering
+	// a message. Unsubscribe must not block.
+	go func() { rawCh <- json.RawMessage(mockSubscribeNewHeadsResponse) }()
+	time.Sleep(10 * time.Millisecond)
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+
+	assert.ErrorIs(t, sub.Err(), ErrUnsubscribed)
+	assert.Zero(t, streamMock.pendingUnsubscribes())
+	_, ok := <-sub.Chan()
+	assert.False(t, ok)
+}
+
+func TestSubscription_ContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, streamMock, _ := newHeadsSubscription(t, ctx)
+
+	cancel()
+	_, ok := <-sub.Chan()
+	assert.False(t, ok)
+	assert.ErrorIs(t, sub.Err(), context.Canceled)
+	assert.Zero(t, streamMock.pendingUnsubscribes())
+}
+
+func TestSubscription_ClosedByTransport(t *testing.T) {
+	sub, _, rawCh := newHeadsSubscription(t, context.Background())
+
+	close(rawCh)
+	_, ok := <-sub.Chan()
+	assert.False(t, ok)
+	assert.ErrorIs(t, sub.Err(), ErrSubscriptionClosed)
+}
+
+func TestSubscription_Stats(t *testing.T) {
+	sub, _, rawCh := newHeadsSubscription(t, context.Background())
+	assert.Equal(t, SubscriptionStats{}, sub.Stats())
+
+	rawCh <- json.RawMessage(mockSubscribeNewHeadsResponse)
+	<-sub.Chan()
+	rawCh <- json.RawMessage(`"malformed"`)
+	// The consumer does not read the last message.
+	rawCh <- json.RawMessage(mockSubscribeNewHeadsResponse)
+	require.Eventually(t, func() bool { return sub.Stats().Received == 3 }, time.Second, time.Millisecond)
+
+	stats := sub.Stats()
+	assert.Equal(t, uint64(3), stats.Received)
+	assert.Equal(t, uint64(1), stats.Delivered)
+	assert.Equal(t, uint64(1), stats.Malformed)
+	assert.Equal(t, uint64(1), stats.Pending)
+	assert.False(t, stats.LastReceived.IsZero())
+	assert.False(t, stats.LastDelivered.After(stats.LastReceived))
+
+	select {
+	case <-sub.Done():
+		t.Fatal("subscription ended")
+	default:
+	}
+	sub.Unsubscribe()
+	<-sub.Done()
+	assert.ErrorIs(t, sub.Err(), ErrUnsubscribed)
+}
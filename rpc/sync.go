@@ -0,0 +1,34 @@
+package rpc
+
+import (
+	"context"
+)
+
+// defaultSyncLag is the default number of blocks the node may lag behind
+// the highest known block to be considered synced.
+const defaultSyncLag = 2
+
+// IsSynced returns true if the node is not syncing or if it lags behind the
+// highest known block by no more than the tolerance set by
+// WithSyncTolerance.
+//
+// Nodes often report that they are syncing when they fall a block or two
+// behind the network, for example after a short network interruption. The
+// tolerance prevents treating such nodes as unusable.
+//
+// A node that reports that it is syncing, but does not know the highest
+// block yet, which happens early in the sync and at startup of some
+// clients, is not considered synced.
+func (c *Client) IsSynced(ctx context.Context) (bool, error) {
+	status, err := c.Syncing(ctx)
+	if err != nil {
+		return false, err
+	}
+	if status == nil {
+		return true, nil
+	}
+	if status.HighestBlock.Big().Sign() == 0 {
+		return false, nil
+	}
+	return status.Lag() <= c.syncLag, nil
+}
@@ -0,0 +1,41 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_IsSynced(t *testing.T) {
+	tests := []struct {
+		name      string
+		result    string
+		tolerance *uint64
+		want      bool
+	}{
+		{name: "not syncing", result: `false`, want: true},
+		{name: "small lag", result: `{"startingBlock": "0x0", "currentBlock": "0x10", "highestBlock": "0x12"}`, want: true},
+		{name: "large lag", result: `{"startingBlock": "0x0", "currentBlock": "0x10", "highestBlock": "0x13"}`, want: false},
+		{name: "unknown highest block", result: `{"startingBlock": "0x0", "currentBlock": "0x10", "highestBlock": "0x0"}`, want: false},
+		{name: "zero tolerance", result: `{"startingBlock": "0x0", "currentBlock": "0x10", "highestBlock": "0x11"}`, tolerance: new(uint64), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := []ClientOptions{WithTransport(&callMock{Handler: func(method string, args ...any) (any, error) {
+				require.Equal(t, "eth_syncing", method)
+				return json.RawMessage(tt.result), nil
+			}})}
+			if tt.tolerance != nil {
+				opts = append(opts, WithSyncTolerance(*tt.tolerance))
+			}
+			client, err := NewClient(opts...)
+			require.NoError(t, err)
+			synced, err := client.IsSynced(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, synced)
+		})
+	}
+}
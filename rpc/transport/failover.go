@@ -0,0 +1,267 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/defiweb/go-eth/jsoncodec"
+)
+
+// Failover is a wrapper around multiple transports that switches to the next
+// transport when a request fails.
+//
+// Transports are tried in order, starting from the last transport that
+// returned a response. Once a transport fails, it is not used again until all
+// subsequent transports have failed as well.
+//
+// If HedgeDelay is set, read requests that are not answered within the
+// delay are sent to the next transport as well, and the first successful
+// response is used. It reduces the tail latency at the cost of additional
+// requests.
+type Failover struct {
+	opts FailoverOptions
+
+	mu      sync.Mutex
+	current int
+	subs    map[string]failoverSub
+}
+
+// failoverSub is a subscription created by one of the transports.
+type failoverSub struct {
+	transport SubscriptionTransport
+	id        string // id is the subscription ID returned by the transport.
+}
+
+// FailoverOptions contains options for the Failover transport.
+type FailoverOptions struct {
+	// Transports is the list of transports in the order of preference.
+	Transports []Transport
+
+	// FailoverFunc is a function that returns true if the request should be
+	// repeated using the next transport. If nil, RetryOnAnyError is used.
+	FailoverFunc func(error) bool
+
+	// Timeout is the maximum duration of a call, including all failover
+	// and hedged requests. If zero, only the context deadline applies.
+	Timeout time.Duration
+
+	// HedgeDelay is the delay after which a request that has not been
+	// answered yet is sent to the next transport as well. At most one
+	// hedged request is sent per call. If zero, requests are not hedged.
+	HedgeDelay time.Duration
+
+	// HedgeFunc is a function that returns true if requests to the given
+	// method can be hedged. Only methods that do not modify the state of
+	// the node should be hedged. If nil, IsReadMethod is used.
+	HedgeFunc func(method string) bool
+}
+
+// readMethods are methods, other than the eth_get* methods, that only read
+// data and can be sent to multiple nodes.
+var readMethods = map[string]bool{
+	"eth_blockNumber":          true,
+	"eth_call":                 true,
+	"eth_chainId":              true,
+	"eth_createAccessList":     true,
+	"eth_estimateGas":          true,
+	"eth_feeHistory":           true,
+	"eth_gasPrice":             true,
+	"eth_maxPriorityFeePerGas": true,
+	"eth_blobBaseFee":          true,
+	"eth_syncing":              true,
+	"net_version":              true,
+	"web3_clientVersion":       true,
+}
+
+// nodeLocalMethods are eth_get* methods that depend on the state of a
+// single node, such as installed filters.
+var nodeLocalMethods = map[string]bool{
+	"eth_getFilterChanges": true,
+	"eth_getFilterLogs":    true,
+	"eth_getWork":          true,
+}
+
+// IsReadMethod returns true if the method only reads chain data, so that the
+// same request can be sent to multiple nodes.
+func IsReadMethod(method string) bool {
+	if strings.HasPrefix(method, "eth_get") {
+		return !nodeLocalMethods[method]
+	}
+	return readMethods[method]
+}
+
+// NewFailover creates a new Failover instance.
+func NewFailover(opts FailoverOptions) (*Failover, error) {
+	if len(opts.Transports) == 0 {
+		return nil, errors.New("at least one transport is required")
+	}
+	for _, t := range opts.Transports {
+		if t == nil {
+			return nil, errors.New("transport cannot be nil")
+		}
+	}
+	if opts.FailoverFunc == nil {
+		opts.FailoverFunc = RetryOnAnyError
+	}
+	if opts.HedgeFunc == nil {
+		opts.HedgeFunc = IsReadMethod
+	}
+	return &Failover{opts: opts, subs: make(map[string]failoverSub)}, nil
+}
+
+// Call implements the Transport interface.
+func (f *Failover) Call(ctx context.Context, result any, method string, args ...any) (err error) {
+	if f.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.opts.Timeout)
+		defer cancel()
+	}
+	if f.opts.HedgeDelay > 0 && len(f.opts.Transports) > 1 && f.opts.HedgeFunc(method) {
+		return f.hedgedCall(ctx, result, method, args...)
+	}
+	start := f.start()
+	for i := range f.opts.Transports {
+		idx := (start + i) % len(f.opts.Transports)
+		err = f.opts.Transports[idx].Call(ctx, result, method, args...)
+		if !f.opts.FailoverFunc(err) {
+			f.setCurrent(idx)
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// hedgedCall sends the request to the current transport and, if it is not
+// answered within the hedge delay, to the next transport as well. Failed
+// requests are repeated using the next transports, as in Call. Requests
+// that are still pending when a response is received are canceled.
+func (f *Failover) hedgedCall(ctx context.Context, result any, method string, args ...any) (err error) {
+	type response struct {
+		idx    int
+		hedged bool
+		raw    json.RawMessage
+		err    error
+	}
+	var (
+		n        = len(f.opts.Transports)
+		start    = f.start()
+		ch       = make(chan response, n)
+		launched = 0
+		pending  = 0
+		timer    = time.NewTimer(f.opts.HedgeDelay)
+	)
+	callCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer timer.Stop()
+	launch := func(hedged bool) {
+		idx := (start + launched) % n
+		launched++
+		pending++
+		go func() {
+			var raw json.RawMessage
+			err := f.opts.Transports[idx].Call(callCtx, &raw, method, args...)
+			ch <- response{idx: idx, hedged: hedged, raw: raw, err: err}
+		}()
+	}
+	launch(false)
+	for pending > 0 {
+		select {
+		case <-timer.C:
+			if launched < n {
+				launch(true)
+			}
+		case r := <-ch:
+			pending--
+			if !f.opts.FailoverFunc(r.err) {
+				// A hedged response does not change the preferred
+				// transport, because the previous one may still work.
+				if !r.hedged {
+					f.setCurrent(r.idx)
+				}
+				if r.err != nil || result == nil {
+					return r.err
+				}
+				if err := jsoncodec.Unmarshal(r.raw, result); err != nil {
+					return fmt.Errorf("failed to unmarshal RPC result: %w", err)
+				}
+				return nil
+			}
+			err = r.err
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if launched < n {
+				launch(false)
+			}
+		}
+	}
+	return err
+}
+
+// Subscribe implements the SubscriptionTransport interface.
+//
+// Transports that do not implement the SubscriptionTransport interface are
+// skipped. An existing subscription is not moved to another transport if
+// the transport it was created with fails.
+//
+// Subscription IDs are unique only within a single node, so the returned
+// ID is prefixed with the index of the transport. It must be passed to
+// Failover.Unsubscribe, not to the underlying transport.
+func (f *Failover) Subscribe(ctx context.Context, method string, args ...any) (ch chan json.RawMessage, id string, err error) {
+	err = ErrNotSubscriptionTransport
+	start := f.start()
+	for i := range f.opts.Transports {
+		idx := (start + i) % len(f.opts.Transports)
+		s, ok := f.opts.Transports[idx].(SubscriptionTransport)
+		if !ok {
+			continue
+		}
+		ch, id, err = s.Subscribe(ctx, method, args...)
+		if !f.opts.FailoverFunc(err) {
+			if err != nil {
+				return nil, "", err
+			}
+			localID := fmt.Sprintf("%d:%s", idx, id)
+			f.mu.Lock()
+			f.subs[localID] = failoverSub{transport: s, id: id}
+			f.mu.Unlock()
+			return ch, localID, nil
+		}
+		if ctx.Err() != nil {
+			return nil, "", ctx.Err()
+		}
+	}
+	return nil, "", err
+}
+
+// Unsubscribe implements the SubscriptionTransport interface.
+func (f *Failover) Unsubscribe(ctx context.Context, id string) error {
+	f.mu.Lock()
+	sub, ok := f.subs[id]
+	delete(f.subs, id)
+	f.mu.Unlock()
+	if !ok {
+		return errors.New("unknown subscription")
+	}
+	return sub.transport.Unsubscribe(ctx, sub.id)
+}
+
+func (f *Failover) start() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.current
+}
+
+func (f *Failover) setCurrent(idx int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.current = idx
+}
@@ -0,0 +1,202 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failoverTransport struct {
+	err   error
+	calls int
+}
+
+func (f *failoverTransport) Call(ctx context.Context, result any, method string, args ...any) error {
+	f.calls++
+	return f.err
+}
+
+func TestFailover(t *testing.T) {
+	t1 := &failoverTransport{err: errors.New("connection refused")}
+	t2 := &failoverTransport{}
+	t3 := &failoverTransport{}
+	f, err := NewFailover(FailoverOptions{Transports: []Transport{t1, t2, t3}})
+	require.NoError(t, err)
+
+	// The first transport fails, the second one is used.
+	require.NoError(t, f.Call(context.Background(), nil, "eth_blockNumber"))
+	assert.Equal(t, 1, t1.calls)
+	assert.Equal(t, 1, t2.calls)
+	assert.Equal(t, 0, t3.calls)
+
+	// The second transport is used until it fails.
+	require.NoError(t, f.Call(context.Background(), nil, "eth_blockNumber"))
+	assert.Equal(t, 1, t1.calls)
+	assert.Equal(t, 2, t2.calls)
+
+	// Errors that should not be retried are returned immediately.
+	t2.err = NewRPCError(ErrCodeInvalidParams, "invalid params", nil)
+	require.Error(t, f.Call(context.Background(), nil, "eth_blockNumber"))
+	assert.Equal(t, 3, t2.calls)
+	assert.Equal(t, 0, t3.calls)
+
+	// The second transport fails, the third one is used.
+	t2.err = errors.New("connection refused")
+	require.NoError(t, f.Call(context.Background(), nil, "eth_blockNumber"))
+	assert.Equal(t, 4, t2.calls)
+	assert.Equal(t, 1, t3.calls)
+
+	// All transports fail.
+	t3.err = errors.New("connection refused")
+	require.Error(t, f.Call(context.Background(), nil, "eth_blockNumber"))
+	assert.Equal(t, 2, t1.calls)
+	assert.Equal(t, 5, t2.calls)
+	assert.Equal(t, 2, t3.calls)
+
+	// Subscriptions are not supported by any transport.
+	_, _, err = f.Subscribe(context.Background(), "eth_subscribe")
+	require.ErrorIs(t, err, ErrNotSubscriptionTransport)
+}
+
+// delayedTransport is a transport that returns a fixed result after a delay.
+type failoverSubTransport struct {
+	failoverTransport
+	unsubscribed []string
+}
+
+func (f *failoverSubTransport) Subscribe(ctx context.Context, method string, args ...any) (chan json.RawMessage, string, error) {
+	if f.err != nil {
+		return nil, "", f.err
+	}
+	return make(chan json.RawMessage), "0x1", nil
+}
+
+func (f *failoverSubTransport) Unsubscribe(ctx context.Context, id string) error {
+	f.unsubscribed = append(f.unsubscribed, id)
+	return nil
+}
+
+func TestFailover_Subscribe(t *testing.T) {
+	t1 := &failoverSubTransport{}
+	t2 := &failoverSubTransport{}
+	f, err := NewFailover(FailoverOptions{Transports: []Transport{t1, t2}})
+	require.NoError(t, err)
+
+	_, id1, err := f.Subscribe(context.Background(), "eth_subscribe")
+	require.NoError(t, err)
+
+	// The second node returns the same subscription ID.
+	t1.err = errors.New("connection refused")
+	_, id2, err := f.Subscribe(context.Background(), "eth_subscribe")
+	require.NoError(t, err)
+	assert.NotEqual(t, id1, id2)
+
+	// Each subscription is removed from the node it was created with.
+	require.NoError(t, f.Unsubscribe(context.Background(), id2))
+	assert.Empty(t, t1.unsubscribed)
+	assert.Equal(t, []string{"0x1"}, t2.unsubscribed)
+	require.NoError(t, f.Unsubscribe(context.Background(), id1))
+	assert.Equal(t, []string{"0x1"}, t1.unsubscribed)
+	require.Error(t, f.Unsubscribe(context.Background(), id1))
+}
+
+type delayedTransport struct {
+	delay  time.Duration
+	result string
+	calls  int64
+}
+
+func (d *delayedTransport) Call(ctx context.Context, result any, method string, args ...any) error {
+	atomic.AddInt64(&d.calls, 1)
+	select {
+	case <-time.After(d.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return json.Unmarshal([]byte(d.result), result)
+}
+
+func TestFailover_Hedge(t *testing.T) {
+	slow := &delayedTransport{delay: time.Second, result: `"0x1"`}
+	fast := &delayedTransport{result: `"0x2"`}
+	f, err := NewFailover(FailoverOptions{
+		Transports: []Transport{slow, fast},
+		HedgeDelay: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	// The slow transport does not answer within the delay, the request is
+	// sent to the fast one as well.
+	var res string
+	started := time.Now()
+	require.NoError(t, f.Call(context.Background(), &res, "eth_blockNumber"))
+	assert.Equal(t, "0x2", res)
+	assert.Less(t, time.Since(started), 500*time.Millisecond)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&slow.calls))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&fast.calls))
+
+	// The hedged response does not change the preferred transport.
+	slow.delay = 0
+	require.NoError(t, f.Call(context.Background(), &res, "eth_blockNumber"))
+	assert.Equal(t, "0x1", res)
+	assert.Equal(t, int64(2), atomic.LoadInt64(&slow.calls))
+	assert.Equal(t, int64(1), atomic.LoadInt64(&fast.calls))
+
+	// Requests that modify the state are not hedged.
+	slow.delay = 50 * time.Millisecond
+	require.NoError(t, f.Call(context.Background(), &res, "eth_sendRawTransaction"))
+	assert.Equal(t, "0x1", res)
+	assert.Equal(t, int64(1), atomic.LoadInt64(&fast.calls))
+}
+
+func TestFailover_HedgeFailover(t *testing.T) {
+	t1 := &failoverTransport{err: errors.New("connection refused")}
+	t2 := &delayedTransport{result: `"0x2"`}
+	f, err := NewFailover(FailoverOptions{
+		Transports: []Transport{t1, t2},
+		HedgeDelay: time.Second,
+	})
+	require.NoError(t, err)
+
+	// Failed requests are repeated without waiting for the hedge delay.
+	var res string
+	started := time.Now()
+	require.NoError(t, f.Call(context.Background(), &res, "eth_getBalance"))
+	assert.Equal(t, "0x2", res)
+	assert.Less(t, time.Since(started), 500*time.Millisecond)
+
+	// Errors that should not be retried are returned.
+	t3 := &failoverTransport{err: NewRPCError(ErrCodeExecutionError, "execution reverted", nil)}
+	f, err = NewFailover(FailoverOptions{Transports: []Transport{t3, t2}, HedgeDelay: time.Second})
+	require.NoError(t, err)
+	assert.ErrorContains(t, f.Call(context.Background(), &res, "eth_call"), "execution reverted")
+}
+
+func TestFailover_Timeout(t *testing.T) {
+	slow := &delayedTransport{delay: time.Second, result: `"0x1"`}
+	for _, hedge := range []time.Duration{0, 5 * time.Millisecond} {
+		f, err := NewFailover(FailoverOptions{
+			Transports: []Transport{slow, slow},
+			Timeout:    20 * time.Millisecond,
+			HedgeDelay: hedge,
+		})
+		require.NoError(t, err)
+		var res string
+		assert.ErrorIs(t, f.Call(context.Background(), &res, "eth_blockNumber"), context.DeadlineExceeded)
+	}
+}
+
+func TestIsReadMethod(t *testing.T) {
+	assert.True(t, IsReadMethod("eth_getBlockByNumber"))
+	assert.True(t, IsReadMethod("eth_call"))
+	assert.True(t, IsReadMethod("eth_chainId"))
+	assert.False(t, IsReadMethod("eth_getFilterChanges"))
+	assert.False(t, IsReadMethod("eth_sendRawTransaction"))
+	assert.False(t, IsReadMethod("eth_newFilter"))
+}
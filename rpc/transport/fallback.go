@@ -0,0 +1,171 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/defiweb/go-eth/jsoncodec"
+	"github.com/defiweb/go-eth/types"
+)
+
+// FallbackFunc is an alternative implementation of an RPC method used when
+// the method is not supported by the node. The transport can be used to
+// call other methods. The returned value is marshaled to JSON and
+// unmarshaled into the call result.
+type FallbackFunc func(ctx context.Context, t Transport, args ...any) (any, error)
+
+var methodNotSupportedRegexp = regexp.MustCompile(`(?i)(method .*not (found|supported|available|exist)|unsupported method)`)
+
+// IsMethodNotSupported returns true if the error indicates that the RPC
+// method is not supported by the node.
+//
+// Nodes and providers report unsupported methods differently, so in
+// addition to the error codes, the error message is checked as well.
+func IsMethodNotSupported(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch errorCode(err) {
+	case ErrCodeMethodNotFound, NethermindErrCodeMethodNotSupported:
+		return true
+	case ErrCodeExecutionError:
+		return false
+	}
+	var rpcErr *RPCError
+	if errors.As(err, &rpcErr) {
+		return methodNotSupportedRegexp.MatchString(rpcErr.Message)
+	}
+	return false
+}
+
+// Fallback is a wrapper around another transport that uses alternative
+// implementations of RPC methods that are not supported by the node.
+//
+// The first time a method with a registered fallback fails with an error
+// recognized by IsMethodNotSupported, the fallback is used instead, and it
+// is used directly for all subsequent calls to that method.
+type Fallback struct {
+	opts FallbackOptions
+
+	mu          sync.RWMutex
+	unsupported map[string]bool
+}
+
+// FallbackOptions contains options for the Fallback transport.
+type FallbackOptions struct {
+	// Transport is the underlying transport to use.
+	Transport Transport
+
+	// Fallbacks maps RPC method names to their fallback implementations.
+	Fallbacks map[string]FallbackFunc
+}
+
+// NewFallback creates a new Fallback instance.
+func NewFallback(opts FallbackOptions) (*Fallback, error) {
+	if opts.Transport == nil {
+		return nil, errors.New("transport cannot be nil")
+	}
+	return &Fallback{opts: opts, unsupported: make(map[string]bool)}, nil
+}
+
+// Call implements the Transport interface.
+func (f *Fallback) Call(ctx context.Context, result any, method string, args ...any) error {
+	fallback, ok := f.opts.Fallbacks[method]
+	if !ok {
+		return f.opts.Transport.Call(ctx, result, method, args...)
+	}
+	f.mu.RLock()
+	unsupported := f.unsupported[method]
+	f.mu.RUnlock()
+	if !unsupported {
+		err := f.opts.Transport.Call(ctx, result, method, args...)
+		if !IsMethodNotSupported(err) {
+			return err
+		}
+		f.mu.Lock()
+		f.unsupported[method] = true
+		f.mu.Unlock()
+	}
+	res, err := fallback(ctx, f.opts.Transport, args...)
+	if err != nil {
+		return fmt.Errorf("fallback for %s failed: %w", method, err)
+	}
+	if result == nil {
+		return nil
+	}
+	raw, err := jsoncodec.Marshal(res)
+	if err != nil {
+		return fmt.Errorf("failed to marshal fallback result: %w", err)
+	}
+	if err := jsoncodec.Unmarshal(raw, result); err != nil {
+		return fmt.Errorf("failed to unmarshal fallback result: %w", err)
+	}
+	return nil
+}
+
+// Subscribe implements the SubscriptionTransport interface.
+func (f *Fallback) Subscribe(ctx context.Context, method string, args ...any) (chan json.RawMessage, string, error) {
+	if s, ok := f.opts.Transport.(SubscriptionTransport); ok {
+		return s.Subscribe(ctx, method, args...)
+	}
+	return nil, "", ErrNotSubscriptionTransport
+}
+
+// Unsubscribe implements the SubscriptionTransport interface.
+func (f *Fallback) Unsubscribe(ctx context.Context, id string) error {
+	if s, ok := f.opts.Transport.(SubscriptionTransport); ok {
+		return s.Unsubscribe(ctx, id)
+	}
+	return ErrNotSubscriptionTransport
+}
+
+// FixedValueFallback returns a FallbackFunc that always returns the given
+// value. For example, it may be used to return a fixed priority fee for
+// eth_maxPriorityFeePerGas.
+func FixedValueFallback(value any) FallbackFunc {
+	return func(context.Context, Transport, ...any) (any, error) {
+		return value, nil
+	}
+}
+
+// FeeHistoryPriorityFeeFallback returns a FallbackFunc for the
+// eth_maxPriorityFeePerGas method that uses the median of the given reward
+// percentile over the last blocks, as reported by eth_feeHistory.
+func FeeHistoryPriorityFeeFallback(blocks uint64, percentile float64) FallbackFunc {
+	return func(ctx context.Context, t Transport, _ ...any) (any, error) {
+		var res types.FeeHistory
+		if err := t.Call(ctx, &res, "eth_feeHistory", types.NumberFromUint64(blocks), types.LatestBlockNumber, []float64{percentile}); err != nil {
+			return nil, err
+		}
+		var rewards []*big.Int
+		for _, r := range res.Reward {
+			if len(r) > 0 && r[0] != nil {
+				rewards = append(rewards, r[0])
+			}
+		}
+		if len(rewards) == 0 {
+			return nil, errors.New("fee history does not contain rewards")
+		}
+		sort.Slice(rewards, func(i, j int) bool { return rewards[i].Cmp(rewards[j]) < 0 })
+		return types.NumberFromBigInt(rewards[len(rewards)/2]), nil
+	}
+}
+
+// GasPriceFallback returns a FallbackFunc that calls eth_gasPrice. It may be
+// used for eth_maxPriorityFeePerGas on chains without EIP-1559, where the
+// whole gas price is paid to the block producer.
+func GasPriceFallback() FallbackFunc {
+	return func(ctx context.Context, t Transport, _ ...any) (any, error) {
+		var res types.Number
+		if err := t.Call(ctx, &res, "eth_gasPrice"); err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+}
@@ -0,0 +1,80 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// fallbackTransport is a transport that does not support
+// eth_maxPriorityFeePerGas.
+type fallbackTransport struct {
+	calls map[string]int
+}
+
+func (f *fallbackTransport) Call(_ context.Context, result any, method string, _ ...any) error {
+	f.calls[method]++
+	switch method {
+	case "eth_maxPriorityFeePerGas":
+		return NewRPCError(ErrCodeGeneral, "the method eth_maxPriorityFeePerGas does not exist/is not available", nil)
+	case "eth_feeHistory":
+		return json.Unmarshal([]byte(`{"oldestBlock":"0x1","reward":[["0x1"],["0x5"],["0x3"]],"baseFeePerGas":["0x1","0x1","0x1","0x1"],"gasUsedRatio":[0.5,0.5,0.5]}`), result)
+	case "eth_blockNumber":
+		return json.Unmarshal([]byte(`"0x1"`), result)
+	}
+	return errors.New("unexpected method")
+}
+
+func TestFallback(t *testing.T) {
+	inner := &fallbackTransport{calls: map[string]int{}}
+	f, err := NewFallback(FallbackOptions{
+		Transport: inner,
+		Fallbacks: map[string]FallbackFunc{
+			"eth_maxPriorityFeePerGas": FeeHistoryPriorityFeeFallback(3, 50),
+			"eth_blockNumber":          FixedValueFallback("0x2"),
+		},
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		var fee types.Number
+		require.NoError(t, f.Call(context.Background(), &fee, "eth_maxPriorityFeePerGas"))
+		assert.Equal(t, uint64(3), fee.Big().Uint64())
+	}
+	// The unsupported method is called only once.
+	assert.Equal(t, 1, inner.calls["eth_maxPriorityFeePerGas"])
+	assert.Equal(t, 2, inner.calls["eth_feeHistory"])
+
+	// The fallback is not used if the method is supported.
+	var block types.Number
+	require.NoError(t, f.Call(context.Background(), &block, "eth_blockNumber"))
+	assert.Equal(t, uint64(1), block.Big().Uint64())
+
+	// Methods without fallbacks are passed through.
+	require.Error(t, f.Call(context.Background(), nil, "eth_chainId"))
+}
+
+func TestIsMethodNotSupported(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: nil, want: false},
+		{err: errors.New("method not found"), want: false},
+		{err: NewRPCError(ErrCodeMethodNotFound, "Method not found", nil), want: true},
+		{err: NewRPCError(NethermindErrCodeMethodNotSupported, "foo", nil), want: true},
+		{err: NewRPCError(ErrCodeGeneral, "the method eth_foo does not exist/is not available", nil), want: true},
+		{err: NewRPCError(ErrCodeGeneral, "Unsupported method: eth_foo", nil), want: true},
+		{err: NewRPCError(ErrCodeGeneral, "nonce too low", nil), want: false},
+		{err: NewRPCError(ErrCodeExecutionError, "execution reverted: method not found", nil), want: false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, IsMethodNotSupported(tt.err), "%v", tt.err)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"net/http"
 	"sync/atomic"
+
+	"github.com/defiweb/go-eth/jsoncodec"
 )
 
 // HTTP is a Transport implementation that uses the HTTP protocol.
@@ -47,7 +49,7 @@ func (h *HTTP) Call(ctx context.Context, result any, method string, args ...any)
 	if err != nil {
 		return fmt.Errorf("failed to create RPC request: %w", err)
 	}
-	httpBody, err := json.Marshal(rpcReq)
+	httpBody, err := jsoncodec.Marshal(rpcReq)
 	if err != nil {
 		return fmt.Errorf("failed to marshal RPC request: %w", err)
 	}
@@ -64,8 +66,11 @@ func (h *HTTP) Call(ctx context.Context, result any, method string, args ...any)
 		return fmt.Errorf("failed to send HTTP request: %w", err)
 	}
 	defer httpRes.Body.Close()
+	if sd, ok := result.(StreamDecoder); ok {
+		return decodeStream(httpRes, sd)
+	}
 	rpcRes := &rpcResponse{}
-	if err := json.NewDecoder(httpRes.Body).Decode(rpcRes); err != nil {
+	if err := jsoncodec.NewDecoder(httpRes.Body).Decode(rpcRes); err != nil {
 		// If the response is not a valid JSON-RPC response, return the HTTP
 		// status code as the error code.
 		return NewHTTPError(httpRes.StatusCode, nil)
@@ -80,8 +85,43 @@ func (h *HTTP) Call(ctx context.Context, result any, method string, args ...any)
 	if result == nil {
 		return nil
 	}
-	if err := json.Unmarshal(rpcRes.Result, result); err != nil {
+	if err := jsoncodec.Unmarshal(rpcRes.Result, result); err != nil {
 		return fmt.Errorf("failed to unmarshal RPC result: %w", err)
 	}
 	return nil
 }
+
+// decodeStream decodes the JSON-RPC response, passing the result value to
+// the StreamDecoder without buffering the whole response.
+func decodeStream(httpRes *http.Response, result StreamDecoder) error {
+	dec := json.NewDecoder(httpRes.Body)
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return NewHTTPError(httpRes.StatusCode, nil)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return NewHTTPError(httpRes.StatusCode, nil)
+		}
+		switch tok {
+		case "result":
+			if err := result.DecodeJSONStream(dec); err != nil {
+				return fmt.Errorf("failed to unmarshal RPC result: %w", err)
+			}
+		case "error":
+			rpcErr := &rpcError{}
+			if err := dec.Decode(rpcErr); err != nil {
+				return NewHTTPError(httpRes.StatusCode, nil)
+			}
+			if rpcErr.Code != 0 || rpcErr.Message != "" {
+				return NewRPCError(rpcErr.Code, rpcErr.Message, rpcErr.Data)
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return NewHTTPError(httpRes.StatusCode, nil)
+			}
+		}
+	}
+	return nil
+}
@@ -2,12 +2,13 @@ package transport
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net"
 	"time"
+
+	"github.com/defiweb/go-eth/jsoncodec"
 )
 
 // IPC is a Transport implementation that uses the IPC protocol.
@@ -59,7 +60,7 @@ func NewIPC(opts IPCOptions) (*IPC, error) {
 }
 
 func (i *IPC) readerRoutine() {
-	dec := json.NewDecoder(i.conn)
+	dec := jsoncodec.NewDecoder(i.conn)
 	for {
 		var res rpcResponse
 		if err := dec.Decode(&res); err != nil {
@@ -69,14 +70,21 @@ func (i *IPC) readerRoutine() {
 			if errors.Is(err, io.EOF) {
 				return
 			}
-			i.errCh <- err
+			// The decoder cannot recover from an error, so the connection
+			// is no longer usable.
+			i.reportErr(err)
+			return
+		}
+		select {
+		case i.readerCh <- res:
+		case <-i.ctx.Done():
+			return
 		}
-		i.readerCh <- res
 	}
 }
 
 func (i *IPC) writerRoutine() {
-	enc := json.NewEncoder(i.conn)
+	enc := jsoncodec.NewEncoder(i.conn)
 	for {
 		select {
 		case <-i.ctx.Done():
@@ -89,7 +97,7 @@ func (i *IPC) writerRoutine() {
 				if errors.Is(err, io.EOF) {
 					return
 				}
-				i.stream.errCh <- err
+				i.reportErr(err)
 			}
 		}
 	}
@@ -0,0 +1,350 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/defiweb/go-eth/hexutil"
+	"github.com/defiweb/go-eth/jsoncodec"
+	"github.com/defiweb/go-eth/types"
+)
+
+// QuirkFunc rewrites the raw JSON result of an RPC method, so that it can
+// be unmarshaled by the types package.
+type QuirkFunc func(method string, result json.RawMessage) (json.RawMessage, error)
+
+// Quirk is a fix for non-standard JSON returned by nodes of some chains or
+// clients, such as numbers without the 0x prefix, missing fields or
+// differently named fields.
+type Quirk struct {
+	// Name identifies the quirk in error messages.
+	Name string
+
+	// ChainIDs limits the quirk to the given chains. If empty, the quirk
+	// applies to all chains.
+	ChainIDs []uint64
+
+	// Clients limits the quirk to nodes whose client version, as returned
+	// by web3_clientVersion, starts with one of the given prefixes. The
+	// comparison is case-insensitive. If empty, the quirk applies to all
+	// clients.
+	Clients []string
+
+	// Methods limits the quirk to the given RPC methods. If empty, the
+	// quirk applies to all methods.
+	Methods []string
+
+	// Fix rewrites the result.
+	Fix QuirkFunc
+}
+
+// transactionMethods are RPC methods that return transactions.
+var transactionMethods = []string{
+	"eth_getBlockByHash",
+	"eth_getBlockByNumber",
+	"eth_getTransactionByHash",
+	"eth_getTransactionByBlockHashAndIndex",
+	"eth_getTransactionByBlockNumberAndIndex",
+}
+
+// DefaultQuirks is a list of quirks that are safe to use with any node.
+var DefaultQuirks = []Quirk{
+	{
+		// Some nodes return the transaction input as "data", which was the
+		// field name before it was standardized.
+		Name:    "transaction data field",
+		Methods: transactionMethods,
+		Fix:     RenameFields(map[string]string{"data": "input"}),
+	},
+}
+
+// Quirks is a wrapper around another transport that rewrites non-standard
+// JSON results before they are unmarshaled.
+//
+// Quirks are selected by the chain ID and the client version of the node.
+// If they are not provided in the options and at least one quirk depends
+// on them, they are detected using eth_chainId and web3_clientVersion
+// before the first call.
+//
+// Subscription messages are not rewritten.
+type Quirks struct {
+	opts QuirksOptions
+
+	mu       sync.Mutex
+	detected bool
+	active   []Quirk
+}
+
+// QuirksOptions contains options for the Quirks transport.
+type QuirksOptions struct {
+	// Transport is the underlying transport to use.
+	Transport Transport
+
+	// Quirks is the list of quirks to apply. Quirks are applied in order.
+	// DefaultQuirks may be used as a base.
+	Quirks []Quirk
+
+	// ChainID is the chain ID of the node. If zero, it is detected when
+	// needed.
+	ChainID uint64
+
+	// ClientVersion is the client version of the node. If empty, it is
+	// detected when needed.
+	ClientVersion string
+}
+
+// NewQuirks creates a new Quirks instance.
+func NewQuirks(opts QuirksOptions) (*Quirks, error) {
+	if opts.Transport == nil {
+		return nil, errors.New("transport cannot be nil")
+	}
+	for _, q := range opts.Quirks {
+		if q.Fix == nil {
+			return nil, fmt.Errorf("quirk %q has no fix function", q.Name)
+		}
+	}
+	return &Quirks{opts: opts}, nil
+}
+
+// Active returns the quirks that apply to the node. The chain ID and the
+// client version are detected if needed.
+func (q *Quirks) Active(ctx context.Context) ([]Quirk, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.detected {
+		return q.active, nil
+	}
+	var (
+		chainID   = q.opts.ChainID
+		client    = q.opts.ClientVersion
+		needChain bool
+		needName  bool
+	)
+	for _, quirk := range q.opts.Quirks {
+		needChain = needChain || len(quirk.ChainIDs) > 0
+		needName = needName || len(quirk.Clients) > 0
+	}
+	if needChain && chainID == 0 {
+		var res types.Number
+		if err := q.opts.Transport.Call(ctx, &res, "eth_chainId"); err != nil {
+			return nil, fmt.Errorf("failed to detect chain ID: %w", err)
+		}
+		chainID = res.Big().Uint64()
+	}
+	if needName && client == "" {
+		// Not all providers support web3_clientVersion, in that case
+		// quirks limited to specific clients are not used.
+		_ = q.opts.Transport.Call(ctx, &client, "web3_clientVersion")
+	}
+	for _, quirk := range q.opts.Quirks {
+		if matchChainID(quirk.ChainIDs, chainID) && matchClient(quirk.Clients, client) {
+			q.active = append(q.active, quirk)
+		}
+	}
+	q.detected = true
+	return q.active, nil
+}
+
+// Call implements the Transport interface.
+func (q *Quirks) Call(ctx context.Context, result any, method string, args ...any) error {
+	active, err := q.Active(ctx)
+	if err != nil {
+		return err
+	}
+	var fixes []Quirk
+	for _, quirk := range active {
+		if matchMethod(quirk.Methods, method) {
+			fixes = append(fixes, quirk)
+		}
+	}
+	if len(fixes) == 0 || result == nil {
+		return q.opts.Transport.Call(ctx, result, method, args...)
+	}
+	var raw json.RawMessage
+	if err := q.opts.Transport.Call(ctx, &raw, method, args...); err != nil {
+		return err
+	}
+	for _, quirk := range fixes {
+		if raw, err = quirk.Fix(method, raw); err != nil {
+			return fmt.Errorf("quirk %q failed: %w", quirk.Name, err)
+		}
+	}
+	if err := jsoncodec.Unmarshal(raw, result); err != nil {
+		return fmt.Errorf("failed to unmarshal RPC result: %w", err)
+	}
+	return nil
+}
+
+// Subscribe implements the SubscriptionTransport interface.
+func (q *Quirks) Subscribe(ctx context.Context, method string, args ...any) (chan json.RawMessage, string, error) {
+	if s, ok := q.opts.Transport.(SubscriptionTransport); ok {
+		return s.Subscribe(ctx, method, args...)
+	}
+	return nil, "", ErrNotSubscriptionTransport
+}
+
+// Unsubscribe implements the SubscriptionTransport interface.
+func (q *Quirks) Unsubscribe(ctx context.Context, id string) error {
+	if s, ok := q.opts.Transport.(SubscriptionTransport); ok {
+		return s.Unsubscribe(ctx, id)
+	}
+	return ErrNotSubscriptionTransport
+}
+
+// DecimalNumbers returns a QuirkFunc that converts decimal numbers in the
+// given fields to hex strings. Values may be JSON numbers or strings
+// without the 0x prefix. Fields are converted in objects at any depth.
+func DecimalNumbers(fields ...string) QuirkFunc {
+	names := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		names[f] = true
+	}
+	return func(_ string, result json.RawMessage) (json.RawMessage, error) {
+		return walkObjects(result, true, func(obj map[string]json.RawMessage) error {
+			for k, v := range obj {
+				if !names[k] {
+					continue
+				}
+				s := string(bytes.Trim(v, `"`))
+				if s == "null" || strings.HasPrefix(s, "0x") {
+					continue
+				}
+				n, ok := new(big.Int).SetString(s, 10)
+				if !ok {
+					return fmt.Errorf("invalid decimal number in field %s: %s", k, v)
+				}
+				obj[k] = json.RawMessage(`"` + hexutil.BigIntToHex(n) + `"`)
+			}
+			return nil
+		})
+	}
+}
+
+// RenameFields returns a QuirkFunc that renames fields using the given map
+// of old names to new names. A field is not renamed if the object already
+// contains the new name. Fields are renamed in objects at any depth.
+func RenameFields(renames map[string]string) QuirkFunc {
+	return func(_ string, result json.RawMessage) (json.RawMessage, error) {
+		return walkObjects(result, true, func(obj map[string]json.RawMessage) error {
+			for from, to := range renames {
+				v, ok := obj[from]
+				if !ok {
+					continue
+				}
+				if _, exists := obj[to]; exists {
+					continue
+				}
+				delete(obj, from)
+				obj[to] = v
+			}
+			return nil
+		})
+	}
+}
+
+// DefaultFields returns a QuirkFunc that adds the given fields if they are
+// missing. Fields are added to the result object, or to every object of
+// the result array, but not to nested objects.
+func DefaultFields(defaults map[string]json.RawMessage) QuirkFunc {
+	return func(_ string, result json.RawMessage) (json.RawMessage, error) {
+		return walkObjects(result, false, func(obj map[string]json.RawMessage) error {
+			for k, v := range defaults {
+				if _, ok := obj[k]; !ok {
+					obj[k] = v
+				}
+			}
+			return nil
+		})
+	}
+}
+
+// walkObjects calls fn for every object in the JSON value and returns the
+// modified value. If deep is false, only the value itself and the elements
+// of an array are visited.
+func walkObjects(data json.RawMessage, deep bool, fn func(map[string]json.RawMessage) error) (json.RawMessage, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return data, nil
+	}
+	switch trimmed[0] {
+	case '{':
+		var obj map[string]json.RawMessage
+		if err := jsoncodec.Unmarshal(trimmed, &obj); err != nil {
+			return nil, err
+		}
+		if deep {
+			for k, v := range obj {
+				fixed, err := walkObjects(v, deep, fn)
+				if err != nil {
+					return nil, err
+				}
+				obj[k] = fixed
+			}
+		}
+		if err := fn(obj); err != nil {
+			return nil, err
+		}
+		return jsoncodec.Marshal(obj)
+	case '[':
+		var arr []json.RawMessage
+		if err := jsoncodec.Unmarshal(trimmed, &arr); err != nil {
+			return nil, err
+		}
+		for i, v := range arr {
+			t := bytes.TrimSpace(v)
+			if !deep && (len(t) == 0 || t[0] != '{') {
+				continue
+			}
+			fixed, err := walkObjects(v, deep, fn)
+			if err != nil {
+				return nil, err
+			}
+			arr[i] = fixed
+		}
+		return jsoncodec.Marshal(arr)
+	}
+	return data, nil
+}
+
+func matchChainID(ids []uint64, id uint64) bool {
+	if len(ids) == 0 {
+		return true
+	}
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+func matchClient(prefixes []string, client string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	client = strings.ToLower(client)
+	for _, p := range prefixes {
+		if strings.HasPrefix(client, strings.ToLower(p)) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchMethod(methods []string, method string) bool {
+	if len(methods) == 0 {
+		return true
+	}
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// quirkyTransport is a transport that returns fixed raw results.
+type quirkyTransport struct {
+	results map[string]string
+	calls   map[string]int
+}
+
+func (q *quirkyTransport) Call(_ context.Context, result any, method string, _ ...any) error {
+	q.calls[method]++
+	res, ok := q.results[method]
+	if !ok {
+		return errors.New("unexpected method")
+	}
+	return json.Unmarshal([]byte(res), result)
+}
+
+func TestQuirks(t *testing.T) {
+	inner := &quirkyTransport{
+		results: map[string]string{
+			"eth_chainId":              `"0xa4ec"`,
+			"web3_clientVersion":       `"Celo/v1.8.0/linux-amd64/go1.19"`,
+			"eth_getTransactionByHash": `{"hash":"0x1111111111111111111111111111111111111111111111111111111111111111","nonce":12,"gas":"21000","data":"0x01"}`,
+			"eth_blockNumber":          `"100"`,
+		},
+		calls: map[string]int{},
+	}
+	q, err := NewQuirks(QuirksOptions{
+		Transport: inner,
+		Quirks: append([]Quirk{
+			{
+				Name:     "decimal numbers",
+				ChainIDs: []uint64{42220},
+				Methods:  []string{"eth_getTransactionByHash"},
+				Fix:      DecimalNumbers("nonce", "gas"),
+			},
+			{
+				Name:    "other client",
+				Clients: []string{"geth"},
+				Fix:     DecimalNumbers("hash"),
+			},
+			{
+				Name:    "missing fields",
+				Clients: []string{"celo"},
+				Methods: []string{"eth_getTransactionByHash"},
+				Fix:     DefaultFields(map[string]json.RawMessage{"value": json.RawMessage(`"0x0"`)}),
+			},
+		}, DefaultQuirks...),
+	})
+	require.NoError(t, err)
+
+	var tx types.OnChainTransaction
+	require.NoError(t, q.Call(context.Background(), &tx, "eth_getTransactionByHash"))
+	assert.Equal(t, uint64(12), *tx.Nonce)
+	assert.Equal(t, uint64(21000), *tx.GasLimit)
+	assert.Equal(t, []byte{1}, tx.Input)
+	assert.Equal(t, int64(0), tx.Value.Int64())
+
+	active, err := q.Active(context.Background())
+	require.NoError(t, err)
+	require.Len(t, active, 3)
+	assert.Equal(t, "decimal numbers", active[0].Name)
+	assert.Equal(t, "missing fields", active[1].Name)
+
+	// Methods without quirks are passed through.
+	var number types.Number
+	require.NoError(t, q.Call(context.Background(), &number, "eth_blockNumber"))
+	assert.Equal(t, uint64(0x100), number.Big().Uint64())
+
+	// The chain ID and the client version are detected only once.
+	assert.Equal(t, 1, inner.calls["eth_chainId"])
+	assert.Equal(t, 1, inner.calls["web3_clientVersion"])
+}
+
+func TestQuirks_NoDetection(t *testing.T) {
+	inner := &quirkyTransport{
+		results: map[string]string{"eth_blockNumber": `"100"`},
+		calls:   map[string]int{},
+	}
+	q, err := NewQuirks(QuirksOptions{
+		Transport: inner,
+		ChainID:   1,
+		Quirks:    []Quirk{{Name: "numbers", ChainIDs: []uint64{1}, Fix: DecimalNumbers()}},
+	})
+	require.NoError(t, err)
+	var number types.Number
+	require.NoError(t, q.Call(context.Background(), &number, "eth_blockNumber"))
+	assert.Equal(t, 0, inner.calls["eth_chainId"])
+	assert.Equal(t, 0, inner.calls["web3_clientVersion"])
+
+	_, err = NewQuirks(QuirksOptions{Transport: inner, Quirks: []Quirk{{Name: "invalid"}}})
+	assert.Error(t, err)
+}
+
+func TestQuirkFuncs(t *testing.T) {
+	tests := []struct {
+		name string
+		fix  QuirkFunc
+		in   string
+		out  string
+	}{
+		{
+			name: "decimal numbers",
+			fix:  DecimalNumbers("gas"),
+			in:   `{"gas":"21000","tx":{"gas":16},"list":[{"gas":"0x10"},{"gas":null}]}`,
+			out:  `{"gas":"0x5208","tx":{"gas":"0x10"},"list":[{"gas":"0x10"},{"gas":null}]}`,
+		},
+		{
+			name: "rename fields",
+			fix:  RenameFields(map[string]string{"data": "input"}),
+			in:   `[{"data":"0x01"},{"data":"0x02","input":"0x03"}]`,
+			out:  `[{"input":"0x01"},{"data":"0x02","input":"0x03"}]`,
+		},
+		{
+			name: "default fields",
+			fix:  DefaultFields(map[string]json.RawMessage{"value": json.RawMessage(`"0x0"`)}),
+			in:   `[{"tx":{}},{"value":"0x1"}]`,
+			out:  `[{"tx":{},"value":"0x0"},{"value":"0x1"}]`,
+		},
+		{
+			name: "null result",
+			fix:  RenameFields(map[string]string{"data": "input"}),
+			in:   `null`,
+			out:  `null`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := tt.fix("", json.RawMessage(tt.in))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.out, string(out))
+		})
+	}
+	_, err := DecimalNumbers("gas")("", json.RawMessage(`{"gas":"abc"}`))
+	assert.Error(t, err)
+}
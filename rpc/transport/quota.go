@@ -0,0 +1,225 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExceeded is returned by the Quota transport when the call would
+// exceed the budget of the current period.
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Quota is a wrapper around another transport that accounts the usage of a
+// provider quota, such as the compute units of Alchemy or the credits of
+// Infura, and refuses or delays calls that would exceed the budget.
+//
+// Every call is charged the weight of its method before it is sent, even if
+// it fails, because most providers charge for failed requests too.
+// Subscriptions are charged the weight of the "eth_subscribe" method once,
+// messages received on a subscription are not accounted.
+//
+// To switch to another provider when the quota is exhausted, wrap the
+// providers with Quota and combine them using Failover:
+//
+//	t, err := transport.NewFailover(transport.FailoverOptions{
+//		Transports: []transport.Transport{alchemy, infura},
+//	})
+type Quota struct {
+	opts QuotaOptions
+
+	mu      sync.Mutex
+	used    uint64
+	start   time.Time
+	methods map[string]uint64
+}
+
+// QuotaOptions contains options for the Quota transport.
+type QuotaOptions struct {
+	// Transport is the underlying transport to use.
+	Transport Transport
+
+	// Endpoint is the name of the endpoint, passed to OnCall. It is used to
+	// distinguish endpoints when the same callback is used for multiple
+	// Quota transports.
+	Endpoint string
+
+	// Weights is the weight of every method, e.g. the number of compute
+	// units charged by the provider.
+	Weights map[string]uint64
+
+	// DefaultWeight is the weight of methods not present in Weights.
+	DefaultWeight uint64
+
+	// Budget is the total weight of calls allowed in a period. If zero,
+	// the usage is accounted but not limited.
+	Budget uint64
+
+	// Period is the duration after which the usage is reset, e.g. one
+	// second for per-second throughput limits or 30 days for monthly
+	// plans. Periods start with the first call. If zero, the usage is never
+	// reset.
+	Period time.Duration
+
+	// Wait makes calls that would exceed the budget wait until the next
+	// period, instead of failing with ErrQuotaExceeded. It has no effect if
+	// Period is zero.
+	Wait bool
+
+	// OnCall is called synchronously after every call is charged. It may
+	// be used to export the usage to metrics or to an external accounting
+	// service.
+	OnCall func(QuotaCall)
+}
+
+// QuotaCall describes a call charged by the Quota transport.
+type QuotaCall struct {
+	Endpoint  string // Endpoint is the name of the endpoint.
+	Method    string // Method is the RPC method.
+	Weight    uint64 // Weight is the weight charged for the call.
+	Used      uint64 // Used is the total weight used in the current period.
+	Remaining uint64 // Remaining is the remaining budget, or zero if the budget is not limited.
+}
+
+// QuotaUsage is the usage of the quota in the current period.
+type QuotaUsage struct {
+	Used      uint64            // Used is the total weight used in the current period.
+	Budget    uint64            // Budget is the budget of a period, or zero if not limited.
+	Remaining uint64            // Remaining is the remaining budget, or zero if the budget is not limited.
+	ResetAt   time.Time         // ResetAt is the time at which the usage is reset, or zero if it is never reset.
+	Methods   map[string]uint64 // Methods is the total weight used per method in the current period.
+}
+
+// Fraction returns the used fraction of the budget, or zero if the budget
+// is not limited.
+func (u QuotaUsage) Fraction() float64 {
+	if u.Budget == 0 {
+		return 0
+	}
+	return float64(u.Used) / float64(u.Budget)
+}
+
+// NewQuota creates a new Quota instance.
+func NewQuota(opts QuotaOptions) (*Quota, error) {
+	if opts.Transport == nil {
+		return nil, errors.New("transport cannot be nil")
+	}
+	return &Quota{opts: opts, methods: make(map[string]uint64)}, nil
+}
+
+// Call implements the Transport interface.
+func (q *Quota) Call(ctx context.Context, result any, method string, args ...any) error {
+	if err := q.charge(ctx, method); err != nil {
+		return err
+	}
+	return q.opts.Transport.Call(ctx, result, method, args...)
+}
+
+// Subscribe implements the SubscriptionTransport interface.
+func (q *Quota) Subscribe(ctx context.Context, method string, args ...any) (chan json.RawMessage, string, error) {
+	st, ok := q.opts.Transport.(SubscriptionTransport)
+	if !ok {
+		return nil, "", ErrNotSubscriptionTransport
+	}
+	if err := q.charge(ctx, "eth_subscribe"); err != nil {
+		return nil, "", err
+	}
+	return st.Subscribe(ctx, method, args...)
+}
+
+// Unsubscribe implements the SubscriptionTransport interface.
+func (q *Quota) Unsubscribe(ctx context.Context, id string) error {
+	if st, ok := q.opts.Transport.(SubscriptionTransport); ok {
+		return st.Unsubscribe(ctx, id)
+	}
+	return ErrNotSubscriptionTransport
+}
+
+// Usage returns the usage of the quota in the current period.
+func (q *Quota) Usage() QuotaUsage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.reset(time.Now())
+	u := QuotaUsage{
+		Used:    q.used,
+		Budget:  q.opts.Budget,
+		Methods: make(map[string]uint64, len(q.methods)),
+	}
+	if q.opts.Budget > q.used {
+		u.Remaining = q.opts.Budget - q.used
+	}
+	if q.opts.Period > 0 && !q.start.IsZero() {
+		u.ResetAt = q.start.Add(q.opts.Period)
+	}
+	for m, w := range q.methods {
+		u.Methods[m] = w
+	}
+	return u
+}
+
+// Weight returns the weight of the given method.
+func (q *Quota) Weight(method string) uint64 {
+	if w, ok := q.opts.Weights[method]; ok {
+		return w
+	}
+	return q.opts.DefaultWeight
+}
+
+// charge charges the weight of the method, waiting for the next period if
+// the budget is exhausted and Wait is set.
+func (q *Quota) charge(ctx context.Context, method string) error {
+	weight := q.Weight(method)
+	for {
+		q.mu.Lock()
+		now := time.Now()
+		q.reset(now)
+		if q.start.IsZero() {
+			q.start = now
+		}
+		if q.opts.Budget == 0 || q.used+weight <= q.opts.Budget {
+			q.used += weight
+			q.methods[method] += weight
+			call := QuotaCall{
+				Endpoint: q.opts.Endpoint,
+				Method:   method,
+				Weight:   weight,
+				Used:     q.used,
+			}
+			if q.opts.Budget > 0 {
+				call.Remaining = q.opts.Budget - q.used
+			}
+			q.mu.Unlock()
+			if q.opts.OnCall != nil {
+				q.opts.OnCall(call)
+			}
+			return nil
+		}
+		if !q.opts.Wait || q.opts.Period == 0 || weight > q.opts.Budget {
+			q.mu.Unlock()
+			return ErrQuotaExceeded
+		}
+		wait := q.start.Add(q.opts.Period).Sub(now)
+		q.mu.Unlock()
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// reset starts a new period if the current one has ended.
+func (q *Quota) reset(now time.Time) {
+	if q.opts.Period == 0 || q.start.IsZero() {
+		return
+	}
+	if elapsed := now.Sub(q.start); elapsed >= q.opts.Period {
+		q.start = q.start.Add(elapsed / q.opts.Period * q.opts.Period)
+		q.used = 0
+		q.methods = make(map[string]uint64)
+	}
+}
@@ -0,0 +1,87 @@
+package transport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuota(t *testing.T) {
+	var calls []QuotaCall
+	inner := &statsTransport{}
+	q, err := NewQuota(QuotaOptions{
+		Transport:     inner,
+		Endpoint:      "alchemy",
+		Weights:       map[string]uint64{"eth_call": 26, "eth_chainId": 0},
+		DefaultWeight: 10,
+		Budget:        50,
+		OnCall:        func(c QuotaCall) { calls = append(calls, c) },
+	})
+	require.NoError(t, err)
+
+	var res string
+	require.NoError(t, q.Call(context.Background(), &res, "eth_call"))
+	require.NoError(t, q.Call(context.Background(), &res, "eth_blockNumber"))
+	require.NoError(t, q.Call(context.Background(), &res, "eth_chainId"))
+	assert.Equal(t, []QuotaCall{
+		{Endpoint: "alchemy", Method: "eth_call", Weight: 26, Used: 26, Remaining: 24},
+		{Endpoint: "alchemy", Method: "eth_blockNumber", Weight: 10, Used: 36, Remaining: 14},
+		{Endpoint: "alchemy", Method: "eth_chainId", Weight: 0, Used: 36, Remaining: 14},
+	}, calls)
+
+	// The call would exceed the budget.
+	assert.ErrorIs(t, q.Call(context.Background(), &res, "eth_call"), ErrQuotaExceeded)
+	require.NoError(t, q.Call(context.Background(), &res, "eth_blockNumber"))
+
+	u := q.Usage()
+	assert.Equal(t, uint64(46), u.Used)
+	assert.Equal(t, uint64(4), u.Remaining)
+	assert.Equal(t, 0.92, u.Fraction())
+	assert.True(t, u.ResetAt.IsZero())
+	assert.Equal(t, map[string]uint64{"eth_call": 26, "eth_blockNumber": 20, "eth_chainId": 0}, u.Methods)
+}
+
+func TestQuota_Period(t *testing.T) {
+	q, err := NewQuota(QuotaOptions{
+		Transport:     &statsTransport{},
+		DefaultWeight: 1,
+		Budget:        2,
+		Period:        50 * time.Millisecond,
+		Wait:          true,
+	})
+	require.NoError(t, err)
+
+	var res string
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, q.Call(context.Background(), &res, "eth_blockNumber"))
+	}
+	// The third call waits for the next period.
+	assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	assert.Equal(t, uint64(1), q.Usage().Used)
+
+	// The context is canceled before the next period.
+	require.NoError(t, q.Call(context.Background(), &res, "eth_blockNumber"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, q.Call(ctx, &res, "eth_blockNumber"), context.DeadlineExceeded)
+}
+
+func TestQuota_Failover(t *testing.T) {
+	first, err := NewQuota(QuotaOptions{Transport: &statsTransport{}, DefaultWeight: 1, Budget: 1})
+	require.NoError(t, err)
+	second, err := NewQuota(QuotaOptions{Transport: &statsTransport{}, DefaultWeight: 1})
+	require.NoError(t, err)
+	f, err := NewFailover(FailoverOptions{Transports: []Transport{first, second}})
+	require.NoError(t, err)
+
+	var res string
+	for i := 0; i < 3; i++ {
+		require.NoError(t, f.Call(context.Background(), &res, "eth_blockNumber"))
+	}
+	assert.Equal(t, uint64(1), first.Usage().Used)
+	assert.Equal(t, uint64(2), second.Usage().Used)
+}
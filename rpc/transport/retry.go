@@ -147,6 +147,9 @@ func (c *Retry) Call(ctx context.Context, result any, method string, args ...any
 		if c.opts.MaxRetries >= 0 && i >= c.opts.MaxRetries {
 			break
 		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
@@ -169,6 +172,9 @@ func (c *Retry) Subscribe(ctx context.Context, method string, args ...any) (ch c
 			if c.opts.MaxRetries >= 0 && i >= c.opts.MaxRetries {
 				break
 			}
+			if ctx.Err() != nil {
+				return nil, "", ctx.Err()
+			}
 			select {
 			case <-ctx.Done():
 				return nil, "", ctx.Err()
@@ -193,6 +199,9 @@ func (c *Retry) Unsubscribe(ctx context.Context, id string) (err error) {
 			if c.opts.MaxRetries >= 0 && i >= c.opts.MaxRetries {
 				break
 			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
@@ -3,6 +3,7 @@ package transport
 import (
 	"encoding/json"
 
+	"github.com/defiweb/go-eth/jsoncodec"
 	"github.com/defiweb/go-eth/types"
 )
 
@@ -27,6 +28,10 @@ type rpcResponse struct {
 	// Notification response:
 	Method string          `json:"method,omitempty"`
 	Params json.RawMessage `json:"params,omitempty"`
+
+	// err is set by stream transports when the request failed locally,
+	// before or instead of receiving a response from the server.
+	err error
 }
 
 // rpcSubscription is the JSON-RPC subscription object.
@@ -51,7 +56,7 @@ func newRPCRequest(id *uint64, method string, params []any) (rpcRequest, error)
 		Params:  json.RawMessage("[]"),
 	}
 	if len(params) > 0 {
-		params, err := json.Marshal(params)
+		params, err := jsoncodec.Marshal(params)
 		if err != nil {
 			return rpcRequest{}, err
 		}
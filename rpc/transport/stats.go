@@ -0,0 +1,244 @@
+package transport
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/defiweb/go-eth/jsoncodec"
+)
+
+// DefaultStatsSamples is the default number of latency samples kept per
+// method to calculate percentiles.
+const DefaultStatsSamples = 1024
+
+// Stats is a wrapper around another transport that collects per-method
+// statistics: call counts, error rates, latency percentiles and payload
+// sizes.
+//
+// Payload sizes are the sizes of the JSON encoded params and results. They
+// do not include the JSON-RPC envelope.
+type Stats struct {
+	opts StatsOptions
+
+	mu      sync.Mutex
+	methods map[string]*methodStats
+}
+
+// StatsOptions contains options for the Stats transport.
+type StatsOptions struct {
+	// Transport is the underlying transport to use.
+	Transport Transport
+
+	// Samples is the number of most recent latency samples kept per method
+	// to calculate percentiles. If zero, DefaultStatsSamples is used.
+	Samples int
+
+	// SlowThreshold is the duration after which a call is reported to
+	// OnSlowCall. If zero, slow calls are not reported.
+	SlowThreshold time.Duration
+
+	// OnSlowCall is called synchronously after a call that took longer than
+	// SlowThreshold.
+	OnSlowCall func(SlowCall)
+}
+
+// SlowCall describes a call that took longer than the slow threshold.
+type SlowCall struct {
+	Method   string        // Method is the RPC method.
+	Params   []any         // Params are the call params.
+	Duration time.Duration // Duration is the call duration.
+	Err      error         // Err is the error returned by the call, if any.
+}
+
+// MethodStats contains statistics for a single RPC method.
+type MethodStats struct {
+	Method        string        // Method is the RPC method.
+	Calls         uint64        // Calls is the number of calls.
+	Errors        uint64        // Errors is the number of failed calls.
+	P50           time.Duration // P50 is the median latency of the recent calls.
+	P95           time.Duration // P95 is the 95th percentile latency of the recent calls.
+	Max           time.Duration // Max is the maximum latency of all calls.
+	RequestBytes  uint64        // RequestBytes is the total size of the params.
+	ResponseBytes uint64        // ResponseBytes is the total size of the results.
+}
+
+// ErrorRate returns the fraction of failed calls.
+func (s MethodStats) ErrorRate() float64 {
+	if s.Calls == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Calls)
+}
+
+type methodStats struct {
+	calls         uint64
+	errors        uint64
+	max           time.Duration
+	requestBytes  uint64
+	responseBytes uint64
+	samples       []time.Duration
+	next          int
+}
+
+// NewStats creates a new Stats instance.
+func NewStats(opts StatsOptions) (*Stats, error) {
+	if opts.Transport == nil {
+		return nil, errors.New("transport cannot be nil")
+	}
+	if opts.Samples <= 0 {
+		opts.Samples = DefaultStatsSamples
+	}
+	return &Stats{opts: opts, methods: make(map[string]*methodStats)}, nil
+}
+
+// Call implements the Transport interface.
+func (s *Stats) Call(ctx context.Context, result any, method string, args ...any) error {
+	var (
+		raw     json.RawMessage
+		reqSize int
+	)
+	if args == nil {
+		args = []any{}
+	}
+	if params, err := jsoncodec.Marshal(args); err == nil {
+		reqSize = len(params)
+	}
+	start := time.Now()
+	err := s.opts.Transport.Call(ctx, &raw, method, args...)
+	duration := time.Since(start)
+	if err == nil && result != nil && len(raw) > 0 {
+		if uErr := jsoncodec.Unmarshal(raw, result); uErr != nil {
+			err = fmt.Errorf("failed to unmarshal RPC result: %w", uErr)
+		}
+	}
+	s.record(method, duration, reqSize, len(raw), err)
+	if s.opts.SlowThreshold > 0 && duration >= s.opts.SlowThreshold && s.opts.OnSlowCall != nil {
+		s.opts.OnSlowCall(SlowCall{Method: method, Params: args, Duration: duration, Err: err})
+	}
+	return err
+}
+
+// Subscribe implements the SubscriptionTransport interface.
+func (s *Stats) Subscribe(ctx context.Context, method string, args ...any) (chan json.RawMessage, string, error) {
+	if st, ok := s.opts.Transport.(SubscriptionTransport); ok {
+		return st.Subscribe(ctx, method, args...)
+	}
+	return nil, "", ErrNotSubscriptionTransport
+}
+
+// Unsubscribe implements the SubscriptionTransport interface.
+func (s *Stats) Unsubscribe(ctx context.Context, id string) error {
+	if st, ok := s.opts.Transport.(SubscriptionTransport); ok {
+		return st.Unsubscribe(ctx, id)
+	}
+	return ErrNotSubscriptionTransport
+}
+
+// Method returns the statistics for the given method.
+func (s *Stats) Method(method string) MethodStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.methods[method]
+	if !ok {
+		return MethodStats{Method: method}
+	}
+	return m.snapshot(method)
+}
+
+// Snapshot returns the statistics for all called methods, sorted by method
+// name.
+func (s *Stats) Snapshot() []MethodStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	res := make([]MethodStats, 0, len(s.methods))
+	for method, m := range s.methods {
+		res = append(res, m.snapshot(method))
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Method < res[j].Method })
+	return res
+}
+
+// Reset clears all collected statistics.
+func (s *Stats) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.methods = make(map[string]*methodStats)
+}
+
+// Dump writes the statistics for all called methods to w as a text table.
+// It may be used to print the statistics on shutdown.
+func (s *Stats) Dump(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tCALLS\tERRORS\tP50\tP95\tMAX\tREQ BYTES\tRES BYTES")
+	for _, m := range s.Snapshot() {
+		fmt.Fprintf(
+			tw, "%s\t%d\t%.2f%%\t%s\t%s\t%s\t%d\t%d\n",
+			m.Method, m.Calls, m.ErrorRate()*100, m.P50, m.P95, m.Max, m.RequestBytes, m.ResponseBytes,
+		)
+	}
+	return tw.Flush()
+}
+
+func (s *Stats) record(method string, duration time.Duration, reqSize, resSize int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m, ok := s.methods[method]
+	if !ok {
+		m = &methodStats{samples: make([]time.Duration, 0, s.opts.Samples)}
+		s.methods[method] = m
+	}
+	m.calls++
+	if err != nil {
+		m.errors++
+	}
+	if duration > m.max {
+		m.max = duration
+	}
+	m.requestBytes += uint64(reqSize)
+	m.responseBytes += uint64(resSize)
+	if len(m.samples) < cap(m.samples) {
+		m.samples = append(m.samples, duration)
+	} else {
+		m.samples[m.next] = duration
+		m.next = (m.next + 1) % len(m.samples)
+	}
+}
+
+func (m *methodStats) snapshot(method string) MethodStats {
+	sorted := append([]time.Duration(nil), m.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return MethodStats{
+		Method:        method,
+		Calls:         m.calls,
+		Errors:        m.errors,
+		P50:           percentile(sorted, 0.50),
+		P95:           percentile(sorted, 0.95),
+		Max:           m.max,
+		RequestBytes:  m.requestBytes,
+		ResponseBytes: m.responseBytes,
+	}
+}
+
+// percentile returns the p-th percentile of the sorted samples using the
+// nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
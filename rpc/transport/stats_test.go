@@ -0,0 +1,84 @@
+package transport
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type statsTransport struct {
+	delays []time.Duration
+	err    error
+}
+
+func (s *statsTransport) Call(_ context.Context, result any, _ string, _ ...any) error {
+	if len(s.delays) > 0 {
+		time.Sleep(s.delays[0])
+		s.delays = s.delays[1:]
+	}
+	if s.err != nil {
+		return s.err
+	}
+	return json.Unmarshal([]byte(`"0x1"`), result)
+}
+
+func TestStats(t *testing.T) {
+	var slow []SlowCall
+	inner := &statsTransport{delays: []time.Duration{0, 0, 50 * time.Millisecond}}
+	s, err := NewStats(StatsOptions{
+		Transport:     inner,
+		SlowThreshold: 40 * time.Millisecond,
+		OnSlowCall:    func(c SlowCall) { slow = append(slow, c) },
+	})
+	require.NoError(t, err)
+
+	var res string
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.Call(context.Background(), &res, "eth_blockNumber"))
+		assert.Equal(t, "0x1", res)
+	}
+	inner.err = errors.New("error")
+	require.Error(t, s.Call(context.Background(), &res, "eth_getBalance", "0x00", "latest"))
+
+	bn := s.Method("eth_blockNumber")
+	assert.Equal(t, uint64(3), bn.Calls)
+	assert.Equal(t, uint64(0), bn.Errors)
+	assert.Equal(t, uint64(3*len(`[]`)), bn.RequestBytes)
+	assert.Equal(t, uint64(3*len(`"0x1"`)), bn.ResponseBytes)
+	assert.Less(t, bn.P50, 40*time.Millisecond)
+	assert.GreaterOrEqual(t, bn.P95, 50*time.Millisecond)
+	assert.Equal(t, bn.P95, bn.Max)
+
+	gb := s.Method("eth_getBalance")
+	assert.Equal(t, uint64(1), gb.Calls)
+	assert.Equal(t, float64(1), gb.ErrorRate())
+	assert.Equal(t, uint64(len(`["0x00","latest"]`)), gb.RequestBytes)
+
+	require.Len(t, slow, 1)
+	assert.Equal(t, "eth_blockNumber", slow[0].Method)
+
+	snapshot := s.Snapshot()
+	require.Len(t, snapshot, 2)
+	assert.Equal(t, "eth_blockNumber", snapshot[0].Method)
+	assert.Equal(t, "eth_getBalance", snapshot[1].Method)
+
+	var buf bytes.Buffer
+	require.NoError(t, s.Dump(&buf))
+	assert.Contains(t, buf.String(), "eth_getBalance")
+
+	s.Reset()
+	assert.Empty(t, s.Snapshot())
+}
+
+func TestPercentile(t *testing.T) {
+	samples := []time.Duration{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	assert.Equal(t, time.Duration(5), percentile(samples, 0.50))
+	assert.Equal(t, time.Duration(10), percentile(samples, 0.95))
+	assert.Equal(t, time.Duration(0), percentile(nil, 0.50))
+}
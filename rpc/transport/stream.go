@@ -9,9 +9,14 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/defiweb/go-eth/jsoncodec"
 	"github.com/defiweb/go-eth/types"
 )
 
+// ErrClosed is returned when a call is made on a stream transport whose
+// connection has been closed.
+var ErrClosed = errors.New("transport is closed")
+
 // stream is a helper for handling JSON-RPC streams.
 type stream struct {
 	mu  sync.RWMutex
@@ -53,19 +58,35 @@ func (s *stream) Call(ctx context.Context, result any, method string, args ...an
 		return fmt.Errorf("failed to create RPC request: %w", err)
 	}
 
-	// Prepare the channel for the response.
-	ch := make(chan rpcResponse)
-	s.addCallCh(id, ch)
+	// Prepare the channel for the response. The channel is buffered, so the
+	// streamRoutine is never blocked by a call that is no longer waiting for
+	// the response.
+	ch := make(chan rpcResponse, 1)
+	if !s.addCallCh(id, ch) {
+		return ErrClosed
+	}
 	defer s.delCallCh(id)
 
 	// Send the request.
-	s.writerCh <- req
+	select {
+	case s.writerCh <- req:
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-s.ctx.Done():
+		return ErrClosed
+	}
 
 	// Wait for the response.
 	// The response is handled by the streamRoutine. It will send the response
 	// to the ch channel.
 	select {
-	case res := <-ch:
+	case res, ok := <-ch:
+		if !ok {
+			return ErrClosed
+		}
+		if res.err != nil {
+			return res.err
+		}
 		if res.Error != nil {
 			return NewRPCError(
 				res.Error.Code,
@@ -74,7 +95,7 @@ func (s *stream) Call(ctx context.Context, result any, method string, args ...an
 			)
 		}
 		if result != nil {
-			if err := json.Unmarshal(res.Result, result); err != nil {
+			if err := jsoncodec.Unmarshal(res.Result, result); err != nil {
 				return fmt.Errorf("failed to unmarshal RPC result: %w", err)
 			}
 		}
@@ -97,7 +118,9 @@ func (s *stream) Subscribe(ctx context.Context, method string, args ...any) (cha
 	}
 	id := rawID.String()
 	ch := make(chan json.RawMessage)
-	s.addSubCh(id, ch)
+	if !s.addSubCh(id, ch) {
+		return nil, "", ErrClosed
+	}
 	return ch, id, nil
 }
 
@@ -125,10 +148,8 @@ func (s *stream) streamRoutine() {
 		case res.ID == nil:
 			// If the ID is nil, it is a subscription notification.
 			sub := &rpcSubscription{}
-			if err := json.Unmarshal(res.Params, sub); err != nil {
-				if s.errCh != nil {
-					s.errCh <- fmt.Errorf("failed to unmarshal subscription: %w", err)
-				}
+			if err := jsoncodec.Unmarshal(res.Params, sub); err != nil {
+				s.reportErr(fmt.Errorf("failed to unmarshal subscription: %w", err))
 				continue
 			}
 			s.subChSend(sub.Subscription.String(), sub.Result)
@@ -157,21 +178,45 @@ func (s *stream) contextHandlerRoutine() {
 	}
 }
 
+// reportErr sends the error to the error channel, if provided. It does not
+// block after the stream is closed.
+func (s *stream) reportErr(err error) {
+	if s.errCh == nil {
+		return
+	}
+	select {
+	case s.errCh <- err:
+	case <-s.ctx.Done():
+	}
+}
+
 // addCallCh adds a channel to the calls map. Incoming response that match the
 // id will be sent to the given channel. Because message ids are unique, the
 // channel must be deleted after the response is received using delCallCh.
-func (s *stream) addCallCh(id uint64, ch chan rpcResponse) {
+//
+// It returns false if the stream is closed.
+func (s *stream) addCallCh(id uint64, ch chan rpcResponse) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.calls == nil {
+		return false
+	}
 	s.calls[id] = ch
+	return true
 }
 
 // addSubCh adds a channel to the subs map. Incoming subscription notifications
 // that match the id will be sent to the given channel.
-func (s *stream) addSubCh(id string, ch chan json.RawMessage) {
+//
+// It returns false if the stream is closed.
+func (s *stream) addSubCh(id string, ch chan json.RawMessage) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.subs == nil {
+		return false
+	}
 	s.subs[id] = ch
+	return true
 }
 
 // delCallCh deletes a channel from the calls map.
@@ -207,12 +252,24 @@ func (s *stream) callChSend(id uint64, res rpcResponse) {
 	}
 }
 
+// callChErr fails the call that matches the id with the given error.
+func (s *stream) callChErr(id uint64, err error) {
+	s.callChSend(id, rpcResponse{ID: &id, err: err})
+}
+
 // subChSend sends a subscription notification to the channel that matches the
 // id.
+//
+// The send blocks until the subscriber receives the notification, so the
+// subscriber must keep reading from the channel until it is closed by
+// Unsubscribe or by closing the stream.
 func (s *stream) subChSend(id string, res json.RawMessage) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	if ch := s.subs[id]; ch != nil {
-		ch <- res
+		select {
+		case ch <- res:
+		case <-s.ctx.Done():
+		}
 	}
 }
@@ -0,0 +1,29 @@
+package transport
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStream_CallCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Nothing reads requests from the stream, so the call blocks until the
+	// context is canceled.
+	s := (&stream{ctx: ctx, timeout: time.Minute}).initStream()
+	callCtx, callCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer callCancel()
+	assert.ErrorIs(t, s.Call(callCtx, nil, "eth_blockNumber"), context.DeadlineExceeded)
+
+	// After the stream is closed, calls fail immediately.
+	cancel()
+	assert.Eventually(t, func() bool {
+		return s.Call(context.Background(), nil, "eth_blockNumber") == ErrClosed
+	}, time.Second, 10*time.Millisecond)
+	_, _, err := s.Subscribe(context.Background(), "newHeads")
+	assert.ErrorIs(t, err, ErrClosed)
+}
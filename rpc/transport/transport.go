@@ -8,6 +8,9 @@ import (
 )
 
 // Transport handles the transport layer of the JSON-RPC protocol.
+//
+// Transports are used by clients concurrently, so implementations must be
+// safe for concurrent use by multiple goroutines.
 type Transport interface {
 	// Call performs a JSON-RPC call.
 	Call(ctx context.Context, result any, method string, args ...any) error
@@ -26,6 +29,17 @@ type SubscriptionTransport interface {
 	Unsubscribe(ctx context.Context, id string) error
 }
 
+// StreamDecoder may be implemented by call results that decode large
+// results incrementally instead of unmarshaling them at once.
+//
+// Transports that support streaming, such as HTTP, pass a decoder
+// positioned at the start of the result value to DecodeJSONStream. Other
+// transports unmarshal the result using the json.Unmarshaler interface, so
+// types that implement StreamDecoder should implement it as well.
+type StreamDecoder interface {
+	DecodeJSONStream(dec *json.Decoder) error
+}
+
 // New returns a new Transport instance based on the URL scheme.
 // Supported schemes are: http, https, ws, wss.
 // If scheme is empty, it will use IPC.
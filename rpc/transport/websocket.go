@@ -1,21 +1,79 @@
 package transport
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"nhooyr.io/websocket"
-	"nhooyr.io/websocket/wsjson"
+
+	"github.com/defiweb/go-eth/jsoncodec"
+)
+
+// DefaultWebsocketMaxMessageSize is the default maximum size of a single
+// websocket message.
+const DefaultWebsocketMaxMessageSize = 32 << 20 // 32 MiB
+
+var (
+	// ErrMessageTooLarge is returned when a websocket message exceeds the
+	// maximum message size. Errors of type *MessageTooLargeError match it
+	// with errors.Is.
+	ErrMessageTooLarge = errors.New("websocket message too large")
+
+	// ErrReadTimeout is reported when a websocket message is not received
+	// completely within the read timeout.
+	ErrReadTimeout = errors.New("websocket read timeout")
+
+	// ErrWriteTimeout is returned when a websocket message cannot be written
+	// within the write timeout.
+	ErrWriteTimeout = errors.New("websocket write timeout")
 )
 
+// MessageTooLargeError is returned when a websocket message exceeds the
+// maximum message size.
+//
+// Outgoing messages that are too large are not sent. Incoming messages that
+// are too large are read to the end and discarded, so the connection remains
+// usable. If the message was a response to a call, the call fails with this
+// error, provided that the request ID could be found at the beginning of the
+// message.
+type MessageTooLargeError struct {
+	Size     int64 // Size is the size of the message in bytes.
+	Limit    int64 // Limit is the maximum message size.
+	Outgoing bool  // Outgoing is true if the message was being sent.
+}
+
+// Error implements the error interface.
+func (e *MessageTooLargeError) Error() string {
+	dir := "incoming"
+	if e.Outgoing {
+		dir = "outgoing"
+	}
+	return fmt.Sprintf("%s: %s message of %d bytes exceeds limit of %d bytes", ErrMessageTooLarge, dir, e.Size, e.Limit)
+}
+
+// Is reports whether target is ErrMessageTooLarge.
+func (e *MessageTooLargeError) Is(target error) bool {
+	return target == ErrMessageTooLarge
+}
+
 // Websocket is a Transport implementation that uses the websocket
 // protocol.
 type Websocket struct {
 	*stream
 	conn *websocket.Conn
+	opts WebsocketOptions
+
+	// writeMu serializes message writes and the close handshake.
+	writeMu sync.Mutex
 }
 
 // WebsocketOptions contains options for the websocket transport.
@@ -37,6 +95,23 @@ type WebsocketOptions struct {
 	// Timeout is the timeout for the websocket requests. Default is 60s.
 	Timout time.Duration
 
+	// MaxMessageSize is the maximum size of a single message, in either
+	// direction. Default is DefaultWebsocketMaxMessageSize. Set to -1 to
+	// disable the limit.
+	MaxMessageSize int64
+
+	// ReadTimeout is the maximum time to read a single message once its
+	// first frame arrives. It does not limit how long the connection may
+	// stay idle. If the timeout is exceeded, the connection is closed,
+	// because the rest of the message cannot be skipped reliably. Zero
+	// means no timeout.
+	ReadTimeout time.Duration
+
+	// WriteTimeout is the maximum time to write a single message. If the
+	// timeout is exceeded, the connection is closed, because a partially
+	// written message cannot be recovered. Zero means no timeout.
+	WriteTimeout time.Duration
+
 	// ErrorCh is an optional channel used to report errors.
 	ErrorCh chan error
 }
@@ -52,6 +127,9 @@ func NewWebsocket(opts WebsocketOptions) (*Websocket, error) {
 	if opts.Timout == 0 {
 		opts.Timout = 60 * time.Second
 	}
+	if opts.MaxMessageSize == 0 {
+		opts.MaxMessageSize = DefaultWebsocketMaxMessageSize
+	}
 	conn, _, err := websocket.Dial(opts.Context, opts.URL, &websocket.DialOptions{ //nolint:bodyclose
 		HTTPClient: opts.HTTPClient,
 		HTTPHeader: opts.HTTPHeader,
@@ -59,6 +137,9 @@ func NewWebsocket(opts WebsocketOptions) (*Websocket, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial websocket: %w", err)
 	}
+	// The message size is limited by the readMessage method. The limit
+	// enforced by the websocket package closes the connection instead.
+	conn.SetReadLimit(-1)
 	i := &Websocket{
 		stream: &stream{
 			ctx:     opts.Context,
@@ -66,6 +147,7 @@ func NewWebsocket(opts WebsocketOptions) (*Websocket, error) {
 			timeout: opts.Timout,
 		},
 		conn: conn,
+		opts: opts,
 	}
 	i.onClose = i.close
 	i.stream.initStream()
@@ -75,23 +157,86 @@ func NewWebsocket(opts WebsocketOptions) (*Websocket, error) {
 }
 
 func (ws *Websocket) readerRoutine() {
-	// The background context is used here because closing context will
-	// cause the nhooyr.io/websocket package to close a connection with
-	// a close code of 1008 (policy violation) which is not what we want.
-	ctx := context.Background()
 	for {
-		res := rpcResponse{}
-		if err := wsjson.Read(ctx, ws.conn, &res); err != nil {
+		data, err := ws.readMessage()
+		if err != nil {
+			var tooLarge *MessageTooLargeError
+			if errors.As(err, &tooLarge) {
+				if id, ok := responseID(data); ok {
+					ws.callChErr(id, tooLarge)
+				}
+				ws.reportErr(err)
+				continue
+			}
 			if ws.ctx.Err() != nil || errors.As(err, &websocket.CloseError{}) {
 				return
 			}
-			if ws.errCh != nil {
-				ws.errCh <- fmt.Errorf("websocket reading error: %w", err)
+			ws.reportErr(fmt.Errorf("websocket reading error: %w", err))
+			if errors.Is(err, net.ErrClosed) || errors.Is(err, ErrReadTimeout) {
+				return
 			}
 			continue
 		}
-		ws.readerCh <- res
+		res := rpcResponse{}
+		if err := jsoncodec.Unmarshal(data, &res); err != nil {
+			// The whole message has been read, so the connection is still
+			// usable.
+			ws.reportErr(fmt.Errorf("websocket reading error: %w", err))
+			continue
+		}
+		select {
+		case ws.readerCh <- res:
+		case <-ws.ctx.Done():
+			return
+		}
+	}
+}
+
+// readMessage reads a single message from the connection.
+//
+// If the message exceeds the maximum message size, the rest of the message is
+// discarded and a *MessageTooLargeError is returned along with the beginning
+// of the message.
+func (ws *Websocket) readMessage() ([]byte, error) {
+	// The background context is used here because closing context will
+	// cause the nhooyr.io/websocket package to close a connection with
+	// a close code of 1008 (policy violation) which is not what we want.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, r, err := ws.conn.Reader(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var timedOut int32
+	if ws.opts.ReadTimeout > 0 {
+		t := time.AfterFunc(ws.opts.ReadTimeout, func() {
+			atomic.StoreInt32(&timedOut, 1)
+			cancel()
+		})
+		defer t.Stop()
+	}
+	if ws.opts.MaxMessageSize < 0 {
+		data, err := io.ReadAll(r)
+		if err != nil && atomic.LoadInt32(&timedOut) == 1 {
+			return nil, fmt.Errorf("%w: %v", ErrReadTimeout, err)
+		}
+		return data, err
+	}
+	data, err := io.ReadAll(io.LimitReader(r, ws.opts.MaxMessageSize+1))
+	if err == nil && int64(len(data)) > ws.opts.MaxMessageSize {
+		var n int64
+		n, err = io.Copy(io.Discard, r)
+		if err == nil {
+			return data[:ws.opts.MaxMessageSize], &MessageTooLargeError{
+				Size:  int64(len(data)) + n,
+				Limit: ws.opts.MaxMessageSize,
+			}
+		}
 	}
+	if err != nil && atomic.LoadInt32(&timedOut) == 1 {
+		return nil, fmt.Errorf("%w: %v", ErrReadTimeout, err)
+	}
+	return data, err
 }
 
 func (ws *Websocket) writerRoutine() {
@@ -100,19 +245,79 @@ func (ws *Websocket) writerRoutine() {
 		case <-ws.ctx.Done():
 			return
 		case req := <-ws.writerCh:
-			if err := wsjson.Write(ws.ctx, ws.conn, req); err != nil {
-				if ws.errCh != nil {
-					ws.errCh <- fmt.Errorf("websocket writing error: %w", err)
+			if err := ws.writeMessage(req); err != nil {
+				if req.ID != nil {
+					ws.callChErr(*req.ID, err)
 				}
+				ws.reportErr(fmt.Errorf("websocket writing error: %w", err))
 				continue
 			}
 		}
 	}
 }
 
+// writeMessage writes a single request to the connection.
+func (ws *Websocket) writeMessage(req rpcRequest) error {
+	data, err := jsoncodec.Marshal(req)
+	if err != nil {
+		return err
+	}
+	if ws.opts.MaxMessageSize >= 0 && int64(len(data)) > ws.opts.MaxMessageSize {
+		return &MessageTooLargeError{
+			Size:     int64(len(data)),
+			Limit:    ws.opts.MaxMessageSize,
+			Outgoing: true,
+		}
+	}
+	ctx := ws.ctx
+	if ws.opts.WriteTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, ws.opts.WriteTimeout)
+		defer cancel()
+	}
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	if err := ws.conn.Write(ctx, websocket.MessageText, data); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%w: %v", ErrWriteTimeout, err)
+		}
+		return err
+	}
+	return nil
+}
+
 func (ws *Websocket) close() {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
 	err := ws.conn.Close(websocket.StatusNormalClosure, "")
 	if err != nil && ws.errCh != nil {
 		ws.errCh <- fmt.Errorf("websocket closing error: %w", err)
 	}
 }
+
+// responseID returns the ID of a JSON-RPC response, if it can be found at the
+// beginning of a possibly truncated message.
+func responseID(data []byte) (uint64, bool) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return 0, false
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return 0, false
+		}
+		if key == "id" {
+			var id uint64
+			if err := dec.Decode(&id); err != nil {
+				return 0, false
+			}
+			return id, true
+		}
+		var skip json.RawMessage
+		if err := dec.Decode(&skip); err != nil {
+			return 0, false
+		}
+	}
+	return 0, false
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -212,3 +214,107 @@ func TestWebsocket(t *testing.T) {
 		})
 	}
 }
+
+func TestWebsocket_Limits(t *testing.T) {
+	// The server responds with a result of the size given in the first
+	// parameter. If the size is negative, the response is written in two
+	// frames with a delay between them. The first frame must exceed the
+	// write buffer of the websocket package to be flushed immediately.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.CloseNow()
+		ctx := context.Background()
+		for {
+			var req struct {
+				ID     uint64 `json:"id"`
+				Params []int  `json:"params"`
+			}
+			if err := wsjson.Read(ctx, conn, &req); err != nil {
+				return
+			}
+			size := req.Params[0]
+			if size < 0 {
+				w, err := conn.Writer(ctx, websocket.MessageText)
+				if err != nil {
+					return
+				}
+				_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":"0x%s`, req.ID, strings.Repeat("1", 8192))
+				time.Sleep(200 * time.Millisecond)
+				_, _ = w.Write([]byte(`"}`))
+				_ = w.Close()
+				continue
+			}
+			res := fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":"0x%s"}`, req.ID, strings.Repeat("1", size))
+			if err := conn.Write(ctx, websocket.MessageText, []byte(res)); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errCh := make(chan error, 10)
+	ws, err := NewWebsocket(WebsocketOptions{
+		Context:        ctx,
+		URL:            "ws" + strings.TrimPrefix(server.URL, "http"),
+		Timout:         time.Second,
+		MaxMessageSize: 1024,
+		ReadTimeout:    50 * time.Millisecond,
+		ErrorCh:        errCh,
+	})
+	require.NoError(t, err)
+
+	// Incoming message within the limit.
+	var res types.Number
+	require.NoError(t, ws.Call(ctx, &res, "test", 1))
+	assert.Equal(t, uint64(1), res.Big().Uint64())
+
+	// Incoming message exceeding the limit fails the call.
+	var tooLarge *MessageTooLargeError
+	err = ws.Call(ctx, &res, "test", 2048)
+	require.ErrorIs(t, err, ErrMessageTooLarge)
+	require.ErrorAs(t, err, &tooLarge)
+	assert.False(t, tooLarge.Outgoing)
+	assert.Equal(t, int64(1024), tooLarge.Limit)
+	assert.Equal(t, int64(2048+38), tooLarge.Size)
+	assert.ErrorIs(t, <-errCh, ErrMessageTooLarge)
+
+	// The connection is still usable.
+	require.NoError(t, ws.Call(ctx, &res, "test", 1))
+
+	// Outgoing message exceeding the limit is not sent.
+	err = ws.Call(ctx, nil, "test", strings.Repeat("1", 2048))
+	require.ErrorAs(t, err, &tooLarge)
+	assert.True(t, tooLarge.Outgoing)
+	assert.ErrorIs(t, <-errCh, ErrMessageTooLarge)
+	require.NoError(t, ws.Call(ctx, &res, "test", 1))
+
+	// Message not read within the read timeout.
+	require.Error(t, ws.Call(ctx, &res, "test", -1))
+	assert.ErrorIs(t, <-errCh, ErrReadTimeout)
+}
+
+func TestResponseID(t *testing.T) {
+	tests := []struct {
+		data string
+		id   uint64
+		ok   bool
+	}{
+		{data: `{"jsonrpc":"2.0","id":7,"result":"0x1`, id: 7, ok: true},
+		{data: `{"id":3,"result":`, id: 3, ok: true},
+		{data: `{"jsonrpc":"2.0","result":"0x1111`, ok: false},
+		{data: `{"jsonrpc":"2.0","method":"eth_subscription","params":{`, ok: false},
+		{data: `[`, ok: false},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			id, ok := responseID([]byte(tt.data))
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.id, id)
+		})
+	}
+}
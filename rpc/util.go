@@ -1,8 +1,11 @@
 package rpc
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 
+	"github.com/defiweb/go-eth/jsoncodec"
 	"github.com/defiweb/go-eth/types"
 )
 
@@ -16,17 +19,51 @@ type signTransactionResult struct {
 
 func (s *signTransactionResult) UnmarshalJSON(input []byte) error {
 	if len(input) >= 2 && input[0] == '"' && input[len(input)-1] == '"' {
-		return json.Unmarshal(input, &s.Raw)
+		return jsoncodec.Unmarshal(input, &s.Raw)
 	}
 	type alias struct {
 		Raw types.Bytes        `json:"raw"`
 		Tx  *types.Transaction `json:"tx"`
 	}
 	var dec alias
-	if err := json.Unmarshal(input, &dec); err != nil {
+	if err := jsoncodec.Unmarshal(input, &dec); err != nil {
 		return err
 	}
 	s.Tx = dec.Tx
 	s.Raw = dec.Raw
 	return nil
 }
+
+// logStream decodes an array of logs, passing each log to the callback as
+// soon as it is decoded, instead of materializing the whole array.
+type logStream struct {
+	fn func(types.Log) error
+}
+
+func (s *logStream) UnmarshalJSON(input []byte) error {
+	return s.DecodeJSONStream(json.NewDecoder(bytes.NewReader(input)))
+}
+
+func (s *logStream) DecodeJSONStream(dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if tok != json.Delim('[') {
+		return errors.New("expected an array of logs")
+	}
+	for dec.More() {
+		var log types.Log
+		if err := dec.Decode(&log); err != nil {
+			return err
+		}
+		if err := s.fn(log); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token()
+	return err
+}
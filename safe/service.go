@@ -0,0 +1,266 @@
+package safe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/rpc/transport"
+	"github.com/defiweb/go-eth/types"
+)
+
+// ServiceClient is a client for the Safe Transaction Service API, which is
+// used by the Safe apps to share pending transactions and signatures between
+// owners.
+type ServiceClient struct {
+	opts ServiceClientOptions
+}
+
+// ServiceClientOptions is the options for NewServiceClient.
+type ServiceClientOptions struct {
+	// URL is the base URL of the transaction service for a given chain,
+	// e.g. https://safe-transaction-mainnet.safe.global.
+	URL string
+
+	// HTTPClient is the HTTP client to use. If nil, http.DefaultClient is
+	// used.
+	HTTPClient *http.Client
+
+	// HTTPHeader specifies the HTTP headers to send with each request, e.g.
+	// an API key.
+	HTTPHeader http.Header
+}
+
+// SafeInfo is the Safe state reported by the transaction service.
+type SafeInfo struct {
+	Address   types.Address   `json:"address"`
+	Nonce     uint64          `json:"nonce"`
+	Threshold uint64          `json:"threshold"`
+	Owners    []types.Address `json:"owners"`
+}
+
+// Confirmation is an owner signature stored by the transaction service.
+type Confirmation struct {
+	Owner     types.Address `json:"owner"`
+	Signature types.Bytes   `json:"signature"`
+}
+
+// ServiceTx is a multisig transaction stored by the transaction service.
+type ServiceTx struct {
+	Tx
+	Safe                  types.Address
+	SafeTxHash            types.Hash
+	ConfirmationsRequired uint64
+	Confirmations         []Confirmation
+	IsExecuted            bool
+	TransactionHash       *types.Hash
+}
+
+// NewServiceClient returns a new ServiceClient.
+func NewServiceClient(opts ServiceClientOptions) (*ServiceClient, error) {
+	if opts.URL == "" {
+		return nil, errors.New("safe service: URL cannot be empty")
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	opts.URL = strings.TrimSuffix(opts.URL, "/")
+	return &ServiceClient{opts: opts}, nil
+}
+
+// Safe returns the Safe state.
+func (c *ServiceClient) Safe(ctx context.Context, safe types.Address) (*SafeInfo, error) {
+	var res SafeInfo
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/safes/%s/", safe.Checksum(crypto.Keccak256)), nil, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ProposeTransaction proposes a new transaction together with the signature
+// of the sender, who must be an owner or a delegate of the Safe.
+func (c *ServiceClient) ProposeTransaction(ctx context.Context, safe types.Address, tx *Tx, safeTxHash types.Hash, sender types.Address, signature []byte) error {
+	req := jsonProposeTx{
+		jsonTx:                  newJSONTx(tx),
+		ContractTransactionHash: safeTxHash,
+		Sender:                  sender,
+		Signature:               signature,
+	}
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/safes/%s/multisig-transactions/", safe.Checksum(crypto.Keccak256)), req, nil)
+}
+
+// ConfirmTransaction adds an owner signature to a proposed transaction.
+func (c *ServiceClient) ConfirmTransaction(ctx context.Context, safeTxHash types.Hash, signature []byte) error {
+	req := struct {
+		Signature types.Bytes `json:"signature"`
+	}{Signature: signature}
+	return c.do(ctx, http.MethodPost, fmt.Sprintf("/api/v1/multisig-transactions/%s/confirmations/", safeTxHash.String()), req, nil)
+}
+
+// Transaction returns a proposed transaction with its confirmations.
+func (c *ServiceClient) Transaction(ctx context.Context, safeTxHash types.Hash) (*ServiceTx, error) {
+	var res jsonServiceTx
+	if err := c.do(ctx, http.MethodGet, fmt.Sprintf("/api/v1/multisig-transactions/%s/", safeTxHash.String()), nil, &res); err != nil {
+		return nil, err
+	}
+	tx, err := res.tx()
+	if err != nil {
+		return nil, err
+	}
+	return &ServiceTx{
+		Tx:                    *tx,
+		Safe:                  res.Safe,
+		SafeTxHash:            res.SafeTxHash,
+		ConfirmationsRequired: res.ConfirmationsRequired,
+		Confirmations:         res.Confirmations,
+		IsExecuted:            res.IsExecuted,
+		TransactionHash:       res.TransactionHash,
+	}, nil
+}
+
+// Signatures returns the confirmations of the transaction as a verified
+// signature collection. Confirmations with invalid signatures are rejected.
+func (t *ServiceTx) Signatures() (*Signatures, error) {
+	sigs := NewSignatures(t.SafeTxHash)
+	for _, c := range t.Confirmations {
+		owner, err := sigs.Add(c.Signature)
+		if err != nil {
+			return nil, err
+		}
+		if owner != c.Owner {
+			return nil, fmt.Errorf("safe: confirmation signed by %s instead of %s", owner, c.Owner)
+		}
+	}
+	return sigs, nil
+}
+
+func (c *ServiceClient) do(ctx context.Context, method, path string, body, result any) error {
+	var reqBody io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("safe service: failed to marshal request: %w", err)
+		}
+		reqBody = bytes.NewReader(b)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.opts.URL+path, reqBody)
+	if err != nil {
+		return fmt.Errorf("safe service: failed to create HTTP request: %w", err)
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range c.opts.HTTPHeader {
+		httpReq.Header[k] = v
+	}
+	httpRes, err := c.opts.HTTPClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("safe service: failed to send HTTP request: %w", err)
+	}
+	defer httpRes.Body.Close()
+	resBody, err := io.ReadAll(httpRes.Body)
+	if err != nil {
+		return fmt.Errorf("safe service: failed to read response: %w", err)
+	}
+	if httpRes.StatusCode < 200 || httpRes.StatusCode >= 300 {
+		return fmt.Errorf("safe service: %w", transport.NewHTTPError(httpRes.StatusCode, errors.New(strings.TrimSpace(string(resBody)))))
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(resBody, result); err != nil {
+		return fmt.Errorf("safe service: failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// jsonTx is the JSON representation of Tx used by the transaction service.
+// Numeric values are encoded as decimal strings.
+type jsonTx struct {
+	To             types.Address `json:"to"`
+	Value          string        `json:"value"`
+	Data           *types.Bytes  `json:"data"`
+	Operation      Operation     `json:"operation"`
+	SafeTxGas      string        `json:"safeTxGas"`
+	BaseGas        string        `json:"baseGas"`
+	GasPrice       string        `json:"gasPrice"`
+	GasToken       types.Address `json:"gasToken"`
+	RefundReceiver types.Address `json:"refundReceiver"`
+	Nonce          string        `json:"nonce"`
+}
+
+type jsonProposeTx struct {
+	jsonTx
+	ContractTransactionHash types.Hash    `json:"contractTransactionHash"`
+	Sender                  types.Address `json:"sender"`
+	Signature               types.Bytes   `json:"signature"`
+}
+
+type jsonServiceTx struct {
+	jsonTx
+	Safe                  types.Address  `json:"safe"`
+	SafeTxHash            types.Hash     `json:"safeTxHash"`
+	ConfirmationsRequired uint64         `json:"confirmationsRequired"`
+	Confirmations         []Confirmation `json:"confirmations"`
+	IsExecuted            bool           `json:"isExecuted"`
+	TransactionHash       *types.Hash    `json:"transactionHash"`
+}
+
+func newJSONTx(tx *Tx) jsonTx {
+	j := jsonTx{
+		To:             tx.To,
+		Value:          bigIntOrZero(tx.Value).String(),
+		Operation:      tx.Operation,
+		SafeTxGas:      bigIntOrZero(tx.SafeTxGas).String(),
+		BaseGas:        bigIntOrZero(tx.BaseGas).String(),
+		GasPrice:       bigIntOrZero(tx.GasPrice).String(),
+		GasToken:       tx.GasToken,
+		RefundReceiver: tx.RefundReceiver,
+		Nonce:          bigIntOrZero(tx.Nonce).String(),
+	}
+	if len(tx.Data) > 0 {
+		data := types.Bytes(tx.Data)
+		j.Data = &data
+	}
+	return j
+}
+
+func (j *jsonTx) tx() (*Tx, error) {
+	tx := &Tx{
+		To:             j.To,
+		Operation:      j.Operation,
+		GasToken:       j.GasToken,
+		RefundReceiver: j.RefundReceiver,
+	}
+	if j.Data != nil {
+		tx.Data = *j.Data
+	}
+	for _, f := range []struct {
+		dst **big.Int
+		src string
+	}{
+		{&tx.Value, j.Value},
+		{&tx.SafeTxGas, j.SafeTxGas},
+		{&tx.BaseGas, j.BaseGas},
+		{&tx.GasPrice, j.GasPrice},
+		{&tx.Nonce, j.Nonce},
+	} {
+		if f.src == "" {
+			*f.dst = new(big.Int)
+			continue
+		}
+		v, ok := new(big.Int).SetString(f.src, 10)
+		if !ok {
+			return nil, fmt.Errorf("safe service: invalid number: %q", f.src)
+		}
+		*f.dst = v
+	}
+	return tx, nil
+}
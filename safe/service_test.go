@@ -0,0 +1,88 @@
+package safe
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+	"github.com/defiweb/go-eth/wallet"
+)
+
+func TestServiceClient(t *testing.T) {
+	safe := types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+	key := wallet.NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	tx := &Tx{
+		To:    types.MustAddressFromHex("0x2222222222222222222222222222222222222222"),
+		Value: big.NewInt(1000),
+		Nonce: big.NewInt(3),
+	}
+	hash, err := tx.Hash(safe, 1)
+	require.NoError(t, err)
+	sig, err := Sign(context.Background(), key, hash)
+	require.NoError(t, err)
+
+	var proposed map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/safes/"+safe.String()+"/multisig-transactions/":
+			body, _ := io.ReadAll(r.Body)
+			require.NoError(t, json.Unmarshal(body, &proposed))
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet && r.URL.Path == "/api/v1/multisig-transactions/"+hash.String()+"/":
+			res, _ := json.Marshal(map[string]any{
+				"safe":                  safe,
+				"to":                    tx.To,
+				"value":                 "1000",
+				"data":                  nil,
+				"operation":             0,
+				"safeTxGas":             "0",
+				"baseGas":               "0",
+				"gasPrice":              "0",
+				"gasToken":              types.ZeroAddress,
+				"refundReceiver":        types.ZeroAddress,
+				"nonce":                 "3",
+				"safeTxHash":            hash,
+				"confirmationsRequired": 2,
+				"confirmations": []map[string]any{
+					{"owner": key.Address(), "signature": types.Bytes(sig)},
+				},
+			})
+			_, _ = w.Write(res)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := NewServiceClient(ServiceClientOptions{URL: srv.URL + "/"})
+	require.NoError(t, err)
+
+	require.NoError(t, client.ProposeTransaction(context.Background(), safe, tx, hash, key.Address(), sig))
+	assert.Equal(t, "1000", proposed["value"])
+	assert.Equal(t, "3", proposed["nonce"])
+	assert.Equal(t, hash.String(), proposed["contractTransactionHash"])
+
+	stx, err := client.Transaction(context.Background(), hash)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), stx.ConfirmationsRequired)
+	assert.Equal(t, tx.Value, stx.Value)
+	stxHash, err := stx.Tx.Hash(safe, 1)
+	require.NoError(t, err)
+	assert.Equal(t, hash, stxHash)
+
+	sigs, err := stx.Signatures()
+	require.NoError(t, err)
+	assert.Equal(t, []types.Address{key.Address()}, sigs.Owners())
+
+	err = client.ConfirmTransaction(context.Background(), types.Hash{}, sig)
+	assert.Error(t, err)
+}
@@ -0,0 +1,167 @@
+package safe
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/types"
+	"github.com/defiweb/go-eth/wallet"
+)
+
+// SignatureLength is the length of a single owner signature.
+const SignatureLength = 65
+
+// Sign signs the Safe transaction hash with the given key.
+//
+// If the key can sign raw hashes, a regular ECDSA signature (v = 27 or 28) is
+// returned. Otherwise, the hash is signed using eth_sign semantics and the v
+// value is increased by 4, as required by the Safe contract.
+func Sign(ctx context.Context, key wallet.Key, hash types.Hash) ([]byte, error) {
+	if k, ok := key.(wallet.KeyWithHashSigner); ok {
+		sig, err := k.SignHash(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		return encodeSignature(sig, 27), nil
+	}
+	sig, err := key.SignMessage(ctx, hash.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return encodeSignature(sig, 31), nil
+}
+
+// RecoverOwner returns the owner that produced the signature for the given
+// Safe transaction hash.
+//
+// ECDSA and eth_sign signatures are verified. For approved hash signatures
+// (v = 1) the owner is taken from the signature, and the approval must be
+// checked on-chain. Contract signatures (v = 0) are not supported, because
+// they can only be verified by calling the owner contract.
+func RecoverOwner(hash types.Hash, sig []byte) (types.Address, error) {
+	if len(sig) != SignatureLength {
+		return types.Address{}, fmt.Errorf("safe: invalid signature length: %d", len(sig))
+	}
+	v := sig[64]
+	switch {
+	case v == 0:
+		return types.Address{}, errors.New("safe: contract signatures are not supported")
+	case v == 1:
+		return types.MustAddressFromBytes(sig[32-types.AddressLength : 32]), nil
+	case v == 27 || v == 28:
+		addr, err := crypto.ECRecoverer.RecoverHash(hash, signatureFromBytes(sig, v-27))
+		if err != nil {
+			return types.Address{}, fmt.Errorf("safe: %w", err)
+		}
+		return *addr, nil
+	case v == 31 || v == 32:
+		addr, err := crypto.ECRecoverer.RecoverMessage(hash.Bytes(), signatureFromBytes(sig, v-4))
+		if err != nil {
+			return types.Address{}, fmt.Errorf("safe: %w", err)
+		}
+		return *addr, nil
+	default:
+		return types.Address{}, fmt.Errorf("safe: invalid signature v value: %d", v)
+	}
+}
+
+// Signatures collects owner signatures for a Safe transaction.
+type Signatures struct {
+	hash types.Hash
+	sigs map[types.Address][]byte
+}
+
+// NewSignatures returns a new signature collection for the given Safe
+// transaction hash.
+func NewSignatures(hash types.Hash) *Signatures {
+	return &Signatures{hash: hash, sigs: make(map[types.Address][]byte)}
+}
+
+// Hash returns the Safe transaction hash.
+func (s *Signatures) Hash() types.Hash {
+	return s.hash
+}
+
+// Add verifies the signature and adds it to the collection. It returns the
+// recovered owner. A signature from the same owner replaces the previous one.
+//
+// The function does not check whether the signer is an owner of the Safe.
+func (s *Signatures) Add(sig []byte) (types.Address, error) {
+	owner, err := RecoverOwner(s.hash, sig)
+	if err != nil {
+		return types.Address{}, err
+	}
+	s.sigs[owner] = append([]byte(nil), sig...)
+	return owner, nil
+}
+
+// AddApprovedHash adds an approved hash signature for the given owner. The
+// owner must approve the hash on-chain using Safe.approveHash, or be the
+// sender of the execTransaction call.
+func (s *Signatures) AddApprovedHash(owner types.Address) {
+	sig := make([]byte, SignatureLength)
+	copy(sig[32-types.AddressLength:32], owner[:])
+	sig[64] = 1
+	s.sigs[owner] = sig
+}
+
+// Len returns the number of collected signatures.
+func (s *Signatures) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.sigs)
+}
+
+// Owners returns the owners that signed the transaction, in ascending order.
+func (s *Signatures) Owners() []types.Address {
+	if s == nil {
+		return nil
+	}
+	owners := make([]types.Address, 0, len(s.sigs))
+	for owner := range s.sigs {
+		owners = append(owners, owner)
+	}
+	sort.Slice(owners, func(i, j int) bool {
+		return bytes.Compare(owners[i][:], owners[j][:]) < 0
+	})
+	return owners
+}
+
+// Bytes returns the signatures encoded as expected by Safe.execTransaction,
+// that is, concatenated and sorted by owner address in ascending order.
+func (s *Signatures) Bytes() []byte {
+	owners := s.Owners()
+	b := make([]byte, 0, len(owners)*SignatureLength)
+	for _, owner := range owners {
+		b = append(b, s.sigs[owner]...)
+	}
+	return b
+}
+
+// encodeSignature encodes the signature as r || s || v, where v is the
+// recovery id increased by the given offset.
+func encodeSignature(sig *types.Signature, offset uint64) []byte {
+	v := sig.V.Uint64()
+	if v >= 27 {
+		v -= 27
+	}
+	b := make([]byte, SignatureLength)
+	sig.R.FillBytes(b[:32])
+	sig.S.FillBytes(b[32:64])
+	b[64] = byte(v + offset)
+	return b
+}
+
+func signatureFromBytes(sig []byte, v byte) types.Signature {
+	return types.SignatureFromVRS(
+		big.NewInt(int64(v)),
+		new(big.Int).SetBytes(sig[:32]),
+		new(big.Int).SetBytes(sig[32:64]),
+	)
+}
@@ -0,0 +1,80 @@
+package safe
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/types"
+	"github.com/defiweb/go-eth/wallet"
+)
+
+// messageOnlyKey hides the SignHash method of the underlying key.
+type messageOnlyKey struct {
+	wallet.Key
+}
+
+func TestSign(t *testing.T) {
+	key := wallet.NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	hash := crypto.Keccak256([]byte("safe tx"))
+
+	t.Run("hash signer", func(t *testing.T) {
+		sig, err := Sign(context.Background(), key, hash)
+		require.NoError(t, err)
+		require.Len(t, sig, SignatureLength)
+		assert.Contains(t, []byte{27, 28}, sig[64])
+
+		owner, err := RecoverOwner(hash, sig)
+		require.NoError(t, err)
+		assert.Equal(t, key.Address(), owner)
+	})
+	t.Run("eth_sign", func(t *testing.T) {
+		sig, err := Sign(context.Background(), messageOnlyKey{key}, hash)
+		require.NoError(t, err)
+		require.Len(t, sig, SignatureLength)
+		assert.Contains(t, []byte{31, 32}, sig[64])
+
+		owner, err := RecoverOwner(hash, sig)
+		require.NoError(t, err)
+		assert.Equal(t, key.Address(), owner)
+	})
+}
+
+func TestSignatures(t *testing.T) {
+	hash := crypto.Keccak256([]byte("safe tx"))
+	key1 := wallet.NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	key2 := wallet.NewKeyFromBytes(bytes.Repeat([]byte{0x02}, 32))
+	approver := types.MustAddressFromHex("0x0000000000000000000000000000000000000001")
+
+	sigs := NewSignatures(hash)
+	for _, key := range []wallet.Key{key1, key2} {
+		sig, err := Sign(context.Background(), key, hash)
+		require.NoError(t, err)
+		owner, err := sigs.Add(sig)
+		require.NoError(t, err)
+		assert.Equal(t, key.Address(), owner)
+	}
+	sigs.AddApprovedHash(approver)
+
+	owners := sigs.Owners()
+	require.Len(t, owners, 3)
+	assert.Equal(t, approver, owners[0])
+	for i := 1; i < len(owners); i++ {
+		assert.True(t, bytes.Compare(owners[i-1][:], owners[i][:]) < 0)
+	}
+
+	b := sigs.Bytes()
+	require.Len(t, b, 3*SignatureLength)
+	for i, owner := range owners {
+		got, err := RecoverOwner(hash, b[i*SignatureLength:(i+1)*SignatureLength])
+		require.NoError(t, err)
+		assert.Equal(t, owner, got)
+	}
+
+	_, err := sigs.Add(make([]byte, SignatureLength))
+	assert.Error(t, err)
+}
@@ -0,0 +1,111 @@
+// Package safe provides helpers for Safe (formerly Gnosis Safe) multisig
+// wallets: computing SafeTx hashes, collecting and verifying owner
+// signatures, encoding execTransaction calls and interacting with the Safe
+// Transaction Service.
+//
+// The package supports Safe contracts in version 1.3.0 and later.
+package safe
+
+import (
+	"math/big"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/types"
+)
+
+// Operation is the type of call executed by the Safe.
+type Operation uint8
+
+const (
+	CallOperation         Operation = iota // CallOperation is a regular call.
+	DelegateCallOperation                  // DelegateCallOperation is a delegate call.
+)
+
+// Tx is a Safe multisig transaction.
+type Tx struct {
+	To             types.Address // To is the destination address.
+	Value          *big.Int      // Value is the ether value sent by the Safe.
+	Data           []byte        // Data is the call data.
+	Operation      Operation     // Operation is the call type.
+	SafeTxGas      *big.Int      // SafeTxGas is the gas used for the Safe transaction.
+	BaseGas        *big.Int      // BaseGas is the gas costs independent of the transaction execution, used for refunds.
+	GasPrice       *big.Int      // GasPrice is the gas price used for the refund calculation.
+	GasToken       types.Address // GasToken is the token used for the refund, the zero address means ether.
+	RefundReceiver types.Address // RefundReceiver is the refund receiver, the zero address means tx.origin.
+	Nonce          *big.Int      // Nonce is the Safe nonce.
+}
+
+var (
+	// keccak256("EIP712Domain(uint256 chainId,address verifyingContract)")
+	domainSeparatorTypeHash = crypto.Keccak256([]byte("EIP712Domain(uint256 chainId,address verifyingContract)"))
+
+	// keccak256("SafeTx(address to,uint256 value,bytes data,uint8 operation,uint256 safeTxGas,uint256 baseGas,uint256 gasPrice,address gasToken,address refundReceiver,uint256 nonce)")
+	safeTxTypeHash = crypto.Keccak256([]byte("SafeTx(address to,uint256 value,bytes data,uint8 operation,uint256 safeTxGas,uint256 baseGas,uint256 gasPrice,address gasToken,address refundReceiver,uint256 nonce)"))
+
+	domainSeparatorType = abi.MustParseType("(bytes32,uint256,address)")
+	safeTxType          = abi.MustParseType("(bytes32,address,uint256,bytes32,uint8,uint256,uint256,uint256,address,address,uint256)")
+
+	execTransactionMethod = abi.MustParseMethod("execTransaction(address to, uint256 value, bytes data, uint8 operation, uint256 safeTxGas, uint256 baseGas, uint256 gasPrice, address gasToken, address refundReceiver, bytes signatures) returns (bool success)")
+)
+
+// DomainSeparator returns the EIP-712 domain separator of the Safe.
+func DomainSeparator(safe types.Address, chainID uint64) (types.Hash, error) {
+	enc, err := abi.EncodeValues(domainSeparatorType, domainSeparatorTypeHash, new(big.Int).SetUint64(chainID), safe)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return crypto.Keccak256(enc), nil
+}
+
+// Hash returns the EIP-712 hash of the transaction, as computed by the
+// Safe.getTransactionHash method. This is the hash signed by the owners.
+func (t *Tx) Hash(safe types.Address, chainID uint64) (types.Hash, error) {
+	domain, err := DomainSeparator(safe, chainID)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	enc, err := abi.EncodeValues(
+		safeTxType,
+		safeTxTypeHash,
+		t.To,
+		bigIntOrZero(t.Value),
+		crypto.Keccak256(t.Data),
+		uint8(t.Operation),
+		bigIntOrZero(t.SafeTxGas),
+		bigIntOrZero(t.BaseGas),
+		bigIntOrZero(t.GasPrice),
+		t.GasToken,
+		t.RefundReceiver,
+		bigIntOrZero(t.Nonce),
+	)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	structHash := crypto.Keccak256(enc)
+	return crypto.Keccak256([]byte{0x19, 0x01}, domain.Bytes(), structHash.Bytes()), nil
+}
+
+// ExecTransactionCalldata returns the call data of the Safe.execTransaction
+// call that executes the transaction with the given signatures.
+func (t *Tx) ExecTransactionCalldata(sigs *Signatures) ([]byte, error) {
+	return execTransactionMethod.EncodeArgs(
+		t.To,
+		bigIntOrZero(t.Value),
+		t.Data,
+		uint8(t.Operation),
+		bigIntOrZero(t.SafeTxGas),
+		bigIntOrZero(t.BaseGas),
+		bigIntOrZero(t.GasPrice),
+		t.GasToken,
+		t.RefundReceiver,
+		sigs.Bytes(),
+	)
+}
+
+func bigIntOrZero(x *big.Int) *big.Int {
+	if x == nil {
+		return new(big.Int)
+	}
+	return x
+}
@@ -0,0 +1,72 @@
+package safe
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+func TestTypeHashes(t *testing.T) {
+	// Values of DOMAIN_SEPARATOR_TYPEHASH and SAFE_TX_TYPEHASH constants
+	// from the Safe contract.
+	assert.Equal(t, "0x47e79534a245952e8b16893a336b85a3d9ea9fa8c573f3d803afb92a79469218", domainSeparatorTypeHash.String())
+	assert.Equal(t, "0xbb8310d486368db6bd6f849402fdd73ad53d316b5a4b2644ad6efe0f941286d8", safeTxTypeHash.String())
+}
+
+func TestTx_Hash(t *testing.T) {
+	safe := types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+	tx := &Tx{
+		To:    types.MustAddressFromHex("0x2222222222222222222222222222222222222222"),
+		Value: big.NewInt(1),
+		Nonce: big.NewInt(0),
+	}
+	h1, err := tx.Hash(safe, 1)
+	require.NoError(t, err)
+	h2, err := tx.Hash(safe, 5)
+	require.NoError(t, err)
+	assert.NotEqual(t, h1, h2)
+
+	tx.Nonce = big.NewInt(1)
+	h3, err := tx.Hash(safe, 1)
+	require.NoError(t, err)
+	assert.NotEqual(t, h1, h3)
+}
+
+func TestTx_ExecTransactionCalldata(t *testing.T) {
+	owner := types.MustAddressFromHex("0x3333333333333333333333333333333333333333")
+	tx := &Tx{
+		To:        types.MustAddressFromHex("0x2222222222222222222222222222222222222222"),
+		Value:     big.NewInt(1),
+		Data:      []byte{1, 2, 3},
+		Operation: DelegateCallOperation,
+	}
+	sigs := NewSignatures(types.Hash{})
+	sigs.AddApprovedHash(owner)
+
+	data, err := tx.ExecTransactionCalldata(sigs)
+	require.NoError(t, err)
+	assert.Equal(t, execTransactionMethod.FourBytes().Bytes(), data[:4])
+
+	var (
+		to             types.Address
+		value          *big.Int
+		callData       []byte
+		operation      uint8
+		safeTxGas      *big.Int
+		baseGas        *big.Int
+		gasPrice       *big.Int
+		gasToken       types.Address
+		refundReceiver types.Address
+		signatures     []byte
+	)
+	require.NoError(t, execTransactionMethod.DecodeArgs(data, &to, &value, &callData, &operation, &safeTxGas, &baseGas, &gasPrice, &gasToken, &refundReceiver, &signatures))
+	assert.Equal(t, tx.To, to)
+	assert.Equal(t, tx.Value, value)
+	assert.Equal(t, tx.Data, callData)
+	assert.Equal(t, uint8(1), operation)
+	assert.Equal(t, sigs.Bytes(), signatures)
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+)
+
+// Memory is a KV that keeps values in memory. It does not survive restarts
+// and is mostly useful for tests and short-lived processes.
+type Memory struct {
+	mu    sync.RWMutex
+	items map[string][]byte
+}
+
+// NewMemory returns a new Memory.
+func NewMemory() *Memory {
+	return &Memory{items: make(map[string][]byte)}
+}
+
+// Get implements the KV interface.
+func (m *Memory) Get(_ context.Context, key []byte) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.items[string(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return clone(v), nil
+}
+
+// Put implements the KV interface.
+func (m *Memory) Put(_ context.Context, key, value []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.items[string(key)] = clone(value)
+	return nil
+}
+
+// Delete implements the KV interface.
+func (m *Memory) Delete(_ context.Context, key []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.items, string(key))
+	return nil
+}
+
+// Iterate implements the KV interface.
+func (m *Memory) Iterate(ctx context.Context, prefix []byte, fn func(key, value []byte) error) error {
+	type item struct{ key, value []byte }
+	m.mu.RLock()
+	var items []item
+	for k, v := range m.items {
+		if bytes.HasPrefix([]byte(k), prefix) {
+			items = append(items, item{key: []byte(k), value: clone(v)})
+		}
+	}
+	m.mu.RUnlock()
+	sort.Slice(items, func(i, j int) bool {
+		return bytes.Compare(items[i].key, items[j].key) < 0
+	})
+	for _, i := range items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := fn(i.key, i.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
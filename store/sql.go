@@ -0,0 +1,184 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// Dialect is the SQL dialect of a database. It determines the style of
+// query parameters and the syntax used to insert or update a value.
+type Dialect uint8
+
+const (
+	SQLiteDialect     Dialect = iota // SQLiteDialect is the dialect of SQLite 3.24 or later.
+	MySQLDialect                     // MySQLDialect is the dialect of MySQL and MariaDB.
+	PostgreSQLDialect                // PostgreSQLDialect is the dialect of PostgreSQL 9.5 or later.
+)
+
+// String implements the fmt.Stringer interface.
+func (d Dialect) String() string {
+	switch d {
+	case SQLiteDialect:
+		return "sqlite"
+	case MySQLDialect:
+		return "mysql"
+	case PostgreSQLDialect:
+		return "postgresql"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(d))
+	}
+}
+
+// tableNameRegexp matches table names that are safe to use in queries.
+var tableNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQL is a KV that stores values in a table of an SQL database.
+//
+// The table must have two binary columns, k and v, with k being the
+// primary key. Keys must be compared byte by byte, which is the default
+// for binary columns. The table is not created automatically, e.g.:
+//
+//	CREATE TABLE kv (k BLOB PRIMARY KEY, v BLOB NOT NULL)               -- SQLite
+//	CREATE TABLE kv (k VARBINARY(255) PRIMARY KEY, v LONGBLOB NOT NULL) -- MySQL
+//	CREATE TABLE kv (k BYTEA PRIMARY KEY, v BYTEA NOT NULL)             -- PostgreSQL
+//
+// The package does not import any database driver, the database must be
+// opened by the caller.
+type SQL struct {
+	opts SQLOptions
+
+	get, del, put                string
+	iterAll, iterFrom, iterRange string
+}
+
+// SQLOptions is the options for NewSQL.
+type SQLOptions struct {
+	// DB is the database to use.
+	DB *sql.DB
+
+	// Table is the name of the table. If empty, "kv" is used.
+	Table string
+
+	// Dialect is the SQL dialect of the database.
+	Dialect Dialect
+}
+
+// NewSQL returns a new SQL.
+func NewSQL(opts SQLOptions) (*SQL, error) {
+	if opts.DB == nil {
+		return nil, errors.New("store: database cannot be nil")
+	}
+	if opts.Table == "" {
+		opts.Table = "kv"
+	}
+	if !tableNameRegexp.MatchString(opts.Table) {
+		return nil, fmt.Errorf("store: invalid table name %q", opts.Table)
+	}
+	t := opts.Table
+	p1, p2 := "?", "?"
+	var put string
+	switch opts.Dialect {
+	case SQLiteDialect:
+		put = fmt.Sprintf("INSERT INTO %s (k, v) VALUES (?, ?) ON CONFLICT (k) DO UPDATE SET v = excluded.v", t)
+	case MySQLDialect:
+		put = fmt.Sprintf("INSERT INTO %s (k, v) VALUES (?, ?) ON DUPLICATE KEY UPDATE v = VALUES(v)", t)
+	case PostgreSQLDialect:
+		p1, p2 = "$1", "$2"
+		put = fmt.Sprintf("INSERT INTO %s (k, v) VALUES ($1, $2) ON CONFLICT (k) DO UPDATE SET v = excluded.v", t)
+	default:
+		return nil, fmt.Errorf("store: invalid dialect %s", opts.Dialect)
+	}
+	return &SQL{
+		opts:      opts,
+		get:       fmt.Sprintf("SELECT v FROM %s WHERE k = %s", t, p1),
+		del:       fmt.Sprintf("DELETE FROM %s WHERE k = %s", t, p1),
+		put:       put,
+		iterAll:   fmt.Sprintf("SELECT k, v FROM %s ORDER BY k", t),
+		iterFrom:  fmt.Sprintf("SELECT k, v FROM %s WHERE k >= %s ORDER BY k", t, p1),
+		iterRange: fmt.Sprintf("SELECT k, v FROM %s WHERE k >= %s AND k < %s ORDER BY k", t, p1, p2),
+	}, nil
+}
+
+// Get implements the KV interface.
+func (s *SQL) Get(ctx context.Context, key []byte) ([]byte, error) {
+	var v []byte
+	err := s.opts.DB.QueryRowContext(ctx, s.get, key).Scan(&v)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("store: failed to get key: %w", err)
+	}
+	return v, nil
+}
+
+// Put implements the KV interface. The value is inserted or updated with
+// a single upsert statement, so concurrent puts of the same key do not
+// conflict with each other.
+func (s *SQL) Put(ctx context.Context, key, value []byte) error {
+	if value == nil {
+		value = []byte{}
+	}
+	if _, err := s.opts.DB.ExecContext(ctx, s.put, key, value); err != nil {
+		return fmt.Errorf("store: failed to put key: %w", err)
+	}
+	return nil
+}
+
+// Delete implements the KV interface.
+func (s *SQL) Delete(ctx context.Context, key []byte) error {
+	if _, err := s.opts.DB.ExecContext(ctx, s.del, key); err != nil {
+		return fmt.Errorf("store: failed to delete key: %w", err)
+	}
+	return nil
+}
+
+// Iterate implements the KV interface. Rows are read while fn is called,
+// so fn must not use the store if the database has a single connection.
+func (s *SQL) Iterate(ctx context.Context, prefix []byte, fn func(key, value []byte) error) error {
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	switch end := prefixEnd(prefix); {
+	case len(prefix) == 0:
+		rows, err = s.opts.DB.QueryContext(ctx, s.iterAll)
+	case end == nil:
+		rows, err = s.opts.DB.QueryContext(ctx, s.iterFrom, prefix)
+	default:
+		rows, err = s.opts.DB.QueryContext(ctx, s.iterRange, prefix, end)
+	}
+	if err != nil {
+		return fmt.Errorf("store: failed to iterate keys: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var k, v []byte
+		if err := rows.Scan(&k, &v); err != nil {
+			return fmt.Errorf("store: failed to iterate keys: %w", err)
+		}
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("store: failed to iterate keys: %w", err)
+	}
+	return nil
+}
+
+// prefixEnd returns the smallest key that is greater than all keys with
+// the given prefix, or nil if there is no such key.
+func prefixEnd(prefix []byte) []byte {
+	end := clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
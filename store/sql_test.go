@@ -0,0 +1,88 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openSQLite opens an SQLite database in a temporary directory and creates
+// the kv table. The test is skipped if the driver is not available, e.g.
+// because cgo is disabled.
+func openSQLite(t *testing.T) *sql.DB {
+	dsn := filepath.Join(t.TempDir(), "kv.db") + "?_journal_mode=WAL&_busy_timeout=10000"
+	db, err := sql.Open("sqlite3", dsn)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	if err := db.Ping(); err != nil {
+		t.Skipf("sqlite is not available: %v", err)
+	}
+	_, err = db.Exec("CREATE TABLE kv (k BLOB PRIMARY KEY, v BLOB NOT NULL)")
+	require.NoError(t, err)
+	return db
+}
+
+func TestSQL(t *testing.T) {
+	kv, err := NewSQL(SQLOptions{DB: openSQLite(t)})
+	require.NoError(t, err)
+	testKV(t, kv)
+}
+
+func TestSQL_ConcurrentPut(t *testing.T) {
+	const (
+		writers = 8
+		puts    = 50
+	)
+	ctx := context.Background()
+	kv, err := NewSQL(SQLOptions{DB: openSQLite(t)})
+	require.NoError(t, err)
+
+	// All writers put the same keys, so every put after the first one
+	// replaces an existing row.
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < puts; i++ {
+				key := []byte(fmt.Sprintf("key/%d", i%5))
+				assert.NoError(t, kv.Put(ctx, key, []byte(fmt.Sprintf("%d/%d", w, i))))
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	var keys int
+	require.NoError(t, kv.Iterate(ctx, []byte("key/"), func(key, value []byte) error {
+		keys++
+		return nil
+	}))
+	assert.Equal(t, 5, keys)
+}
+
+func TestNewSQL(t *testing.T) {
+	db := openSQLite(t)
+
+	kv, err := NewSQL(SQLOptions{DB: db, Table: "state", Dialect: PostgreSQLDialect})
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT v FROM state WHERE k = $1", kv.get)
+	assert.Equal(t, "INSERT INTO state (k, v) VALUES ($1, $2) ON CONFLICT (k) DO UPDATE SET v = excluded.v", kv.put)
+
+	kv, err = NewSQL(SQLOptions{DB: db, Dialect: MySQLDialect})
+	require.NoError(t, err)
+	assert.Equal(t, "INSERT INTO kv (k, v) VALUES (?, ?) ON DUPLICATE KEY UPDATE v = VALUES(v)", kv.put)
+
+	_, err = NewSQL(SQLOptions{})
+	assert.Error(t, err)
+	_, err = NewSQL(SQLOptions{DB: db, Table: "kv; DROP TABLE kv"})
+	assert.Error(t, err)
+	_, err = NewSQL(SQLOptions{DB: db, Dialect: 3})
+	assert.Error(t, err)
+}
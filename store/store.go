@@ -0,0 +1,85 @@
+// Package store provides a key-value storage interface used by components
+// that persist state, such as backfill checkpoints, nonces of the
+// transaction queue and the sender recovery cache of the RPC client, so
+// that a single storage can be shared by all of them.
+//
+// The package provides an in-memory implementation and an implementation
+// for SQL databases. Other databases can be used by implementing the KV
+// interface.
+package store
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned by KV.Get if the key does not exist.
+var ErrNotFound = errors.New("store: key not found")
+
+// KV is a key-value storage. Keys and values are arbitrary byte slices.
+//
+// Implementations must be safe for concurrent use.
+type KV interface {
+	// Get returns the value of the key. If the key does not exist,
+	// ErrNotFound is returned.
+	Get(ctx context.Context, key []byte) ([]byte, error)
+
+	// Put sets the value of the key.
+	Put(ctx context.Context, key, value []byte) error
+
+	// Delete removes the key. Deleting a key that does not exist is not
+	// an error.
+	Delete(ctx context.Context, key []byte) error
+
+	// Iterate calls fn for every key with the given prefix in ascending
+	// key order. If fn returns an error, the iteration stops and the error
+	// is returned. The function must not modify the store.
+	Iterate(ctx context.Context, prefix []byte, fn func(key, value []byte) error) error
+}
+
+// Prefixed is a KV that adds a prefix to all keys of another KV. It is
+// used to share a single storage between multiple components.
+type Prefixed struct {
+	kv     KV
+	prefix []byte
+}
+
+// NewPrefixed returns a new Prefixed that uses the given prefix.
+func NewPrefixed(kv KV, prefix []byte) *Prefixed {
+	return &Prefixed{kv: kv, prefix: clone(prefix)}
+}
+
+// Get implements the KV interface.
+func (p *Prefixed) Get(ctx context.Context, key []byte) ([]byte, error) {
+	return p.kv.Get(ctx, p.key(key))
+}
+
+// Put implements the KV interface.
+func (p *Prefixed) Put(ctx context.Context, key, value []byte) error {
+	return p.kv.Put(ctx, p.key(key), value)
+}
+
+// Delete implements the KV interface.
+func (p *Prefixed) Delete(ctx context.Context, key []byte) error {
+	return p.kv.Delete(ctx, p.key(key))
+}
+
+// Iterate implements the KV interface. The prefix is removed from the keys
+// passed to fn.
+func (p *Prefixed) Iterate(ctx context.Context, prefix []byte, fn func(key, value []byte) error) error {
+	return p.kv.Iterate(ctx, p.key(prefix), func(key, value []byte) error {
+		return fn(key[len(p.prefix):], value)
+	})
+}
+
+func (p *Prefixed) key(key []byte) []byte {
+	k := make([]byte, 0, len(p.prefix)+len(key))
+	k = append(k, p.prefix...)
+	return append(k, key...)
+}
+
+func clone(b []byte) []byte {
+	c := make([]byte, len(b))
+	copy(c, b)
+	return c
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testKV runs tests common to all KV implementations.
+func testKV(t *testing.T, kv KV) {
+	ctx := context.Background()
+
+	_, err := kv.Get(ctx, []byte("a"))
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, kv.Put(ctx, []byte("a"), []byte("1")))
+	require.NoError(t, kv.Put(ctx, []byte("a/2"), []byte("2")))
+	require.NoError(t, kv.Put(ctx, []byte("a/1"), []byte("3")))
+	require.NoError(t, kv.Put(ctx, []byte("b"), []byte("4")))
+	require.NoError(t, kv.Put(ctx, []byte{'a', '/', 0xff}, []byte("5")))
+	require.NoError(t, kv.Put(ctx, []byte("a"), []byte("6")))
+
+	v, err := kv.Get(ctx, []byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("6"), v)
+
+	var keys []string
+	collect := func(key, value []byte) error {
+		keys = append(keys, string(key)+"="+string(value))
+		return nil
+	}
+	require.NoError(t, kv.Iterate(ctx, []byte("a/"), collect))
+	assert.Equal(t, []string{"a/1=3", "a/2=2", "a/\xff=5"}, keys)
+
+	keys = nil
+	require.NoError(t, kv.Iterate(ctx, nil, collect))
+	assert.Equal(t, []string{"a=6", "a/1=3", "a/2=2", "a/\xff=5", "b=4"}, keys)
+
+	// Iteration stops at the first error.
+	stop := errors.New("stop")
+	calls := 0
+	err = kv.Iterate(ctx, nil, func(key, value []byte) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+
+	require.NoError(t, kv.Delete(ctx, []byte("a")))
+	require.NoError(t, kv.Delete(ctx, []byte("missing")))
+	_, err = kv.Get(ctx, []byte("a"))
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestMemory(t *testing.T) {
+	testKV(t, NewMemory())
+}
+
+func TestPrefixed(t *testing.T) {
+	mem := NewMemory()
+	testKV(t, NewPrefixed(mem, []byte("p/")))
+
+	// Keys are stored with the prefix.
+	v, err := mem.Get(context.Background(), []byte("p/b"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("4"), v)
+}
+
+func TestPrefixEnd(t *testing.T) {
+	assert.Equal(t, []byte("b"), prefixEnd([]byte("a")))
+	assert.Equal(t, []byte{0x01}, prefixEnd([]byte{0x00, 0xff}))
+	assert.Nil(t, prefixEnd([]byte{0xff, 0xff}))
+	assert.Nil(t, prefixEnd(nil))
+}
@@ -0,0 +1,224 @@
+// Package sweeper consolidates funds from deposit addresses, such as
+// addresses derived from an HD wallet for every customer of an exchange,
+// into a single hot wallet.
+package sweeper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/txmodifier"
+	"github.com/defiweb/go-eth/types"
+	"github.com/defiweb/go-eth/wallet"
+)
+
+// defaultGasLimit is the gas limit of a plain ether transfer.
+const defaultGasLimit = 21000
+
+var (
+	// ErrFeeBudgetExceeded is set in Sweep.Err when the fee of a sweep
+	// transaction exceeds SweeperOptions.MaxFee.
+	ErrFeeBudgetExceeded = errors.New("sweeper: fee exceeds the budget")
+
+	// ErrBalanceTooLow is set in Sweep.Err when the balance does not cover
+	// the fee of the sweep transaction.
+	ErrBalanceTooLow = errors.New("sweeper: balance does not cover the fee")
+)
+
+// Sweeper transfers balances of deposit addresses to a hot wallet.
+type Sweeper struct {
+	opts SweeperOptions
+}
+
+// SweeperOptions is the options for NewSweeper.
+type SweeperOptions struct {
+	// Client is the RPC client used to fetch balances and send transactions.
+	// Transactions are signed with the deposit keys, so the client does not
+	// need to have access to them.
+	Client rpc.RPC
+
+	// Keys are the keys of the deposit addresses.
+	Keys []wallet.Key
+
+	// HotWallet is the address to which funds are swept.
+	HotWallet types.Address
+
+	// Threshold is the minimum balance, in wei, for an address to be swept.
+	// If nil, every address with a balance that covers the fee is swept.
+	Threshold *big.Int
+
+	// MaxFee is the maximum fee, in wei, of a single sweep transaction.
+	// Addresses for which the fee would exceed the budget are skipped until
+	// fees drop. If nil, there is no limit.
+	MaxFee *big.Int
+
+	// GasLimit is the gas limit of sweep transactions. The default is 21000,
+	// which is enough unless the hot wallet is a contract.
+	GasLimit uint64
+
+	// TXModifiers set the chain ID, nonce and fees of sweep transactions.
+	// The default is a txmodifier.ChainIDProvider, a txmodifier.NonceProvider
+	// using the pending block and a txmodifier.LegacyGasFeeEstimator.
+	//
+	// With EIP-1559 fees, the unused part of the fee cap stays on the
+	// deposit address, hence the legacy gas price is used by default to
+	// sweep the whole balance.
+	TXModifiers []rpc.TXModifier
+
+	// DryRun prepares and signs sweep transactions without sending them.
+	DryRun bool
+}
+
+// Sweep is the result of sweeping a single deposit address.
+type Sweep struct {
+	Address     types.Address      // Address is the deposit address.
+	Balance     *big.Int           // Balance is the balance of the address before the sweep.
+	Fee         *big.Int           // Fee is the maximum fee of the sweep transaction.
+	Amount      *big.Int           // Amount is the amount transferred to the hot wallet.
+	Transaction *types.Transaction // Transaction is the signed sweep transaction.
+	Hash        *types.Hash        // Hash is the hash of the sent transaction, nil in dry-run mode.
+	Err         error              // Err is the reason why the address was not swept.
+}
+
+// NewSweeper returns a new Sweeper.
+func NewSweeper(opts SweeperOptions) (*Sweeper, error) {
+	if opts.Client == nil {
+		return nil, errors.New("sweeper: client is required")
+	}
+	if len(opts.Keys) == 0 {
+		return nil, errors.New("sweeper: at least one key is required")
+	}
+	if opts.HotWallet == types.ZeroAddress {
+		return nil, errors.New("sweeper: hot wallet address is required")
+	}
+	if opts.GasLimit == 0 {
+		opts.GasLimit = defaultGasLimit
+	}
+	if opts.TXModifiers == nil {
+		opts.TXModifiers = []rpc.TXModifier{
+			txmodifier.NewChainIDProvider(txmodifier.ChainIDProviderOptions{Cache: true}),
+			txmodifier.NewNonceProvider(txmodifier.NonceProviderOptions{UsePendingBlock: true}),
+			txmodifier.NewLegacyGasFeeEstimator(txmodifier.LegacyGasFeeEstimatorOptions{Multiplier: 1}),
+		}
+	}
+	return &Sweeper{opts: opts}, nil
+}
+
+// DeriveKeys derives count keys from the mnemonic, starting at the given
+// derivation path and increasing the address index for every key.
+func DeriveKeys(mnemonic wallet.Mnemonic, path wallet.DerivationPath, count int) ([]wallet.Key, error) {
+	dp := make(wallet.DerivationPath, len(path))
+	copy(dp, path)
+	keys := make([]wallet.Key, 0, count)
+	for i := 0; i < count; i++ {
+		key, err := mnemonic.Derive(dp)
+		if err != nil {
+			return nil, fmt.Errorf("sweeper: failed to derive key: %w", err)
+		}
+		keys = append(keys, key)
+		if err := dp.IncreaseAddressIndex(); err != nil {
+			return nil, fmt.Errorf("sweeper: %w", err)
+		}
+	}
+	return keys, nil
+}
+
+// Sweep checks balances of all deposit addresses and transfers balances
+// above the threshold to the hot wallet.
+//
+// It returns a Sweep for every address whose balance is above the
+// threshold. Addresses that could not be swept have the Err field set. An
+// error is returned only if the context is canceled.
+func (s *Sweeper) Sweep(ctx context.Context) ([]Sweep, error) {
+	var res []Sweep
+	for _, key := range s.opts.Keys {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		addr := key.Address()
+		balance, err := s.opts.Client.GetBalance(ctx, addr, types.LatestBlockNumber)
+		if err != nil {
+			res = append(res, Sweep{Address: addr, Err: fmt.Errorf("sweeper: failed to get balance: %w", err)})
+			continue
+		}
+		if balance.Sign() == 0 || (s.opts.Threshold != nil && balance.Cmp(s.opts.Threshold) < 0) {
+			continue
+		}
+		res = append(res, s.sweep(ctx, key, balance))
+	}
+	return res, nil
+}
+
+// Run calls Sweep at the given interval until the context is canceled.
+// Results of every round are passed to the callback.
+func (s *Sweeper) Run(ctx context.Context, interval time.Duration, fn func([]Sweep)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		res, err := s.Sweep(ctx)
+		if err != nil {
+			return err
+		}
+		if fn != nil {
+			fn(res)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Sweeper) sweep(ctx context.Context, key wallet.Key, balance *big.Int) Sweep {
+	addr := key.Address()
+	sw := Sweep{Address: addr, Balance: balance}
+	tx := types.NewTransaction().
+		SetFrom(addr).
+		SetTo(s.opts.HotWallet).
+		SetGasLimit(s.opts.GasLimit).
+		SetValue(big.NewInt(0))
+	for _, m := range s.opts.TXModifiers {
+		if err := m.Modify(ctx, s.opts.Client, tx); err != nil {
+			sw.Err = fmt.Errorf("sweeper: %w", err)
+			return sw
+		}
+	}
+	tx.Value = nil
+	sw.Fee = tx.MaxCost()
+	switch {
+	case sw.Fee == nil:
+		sw.Err = errors.New("sweeper: transaction modifiers did not set the fee")
+		return sw
+	case s.opts.MaxFee != nil && sw.Fee.Cmp(s.opts.MaxFee) > 0:
+		sw.Err = ErrFeeBudgetExceeded
+		return sw
+	case sw.Fee.Cmp(balance) >= 0:
+		sw.Err = ErrBalanceTooLow
+		return sw
+	}
+	sw.Amount = new(big.Int).Sub(balance, sw.Fee)
+	tx.Value = sw.Amount
+	if err := key.SignTransaction(ctx, tx); err != nil {
+		sw.Err = fmt.Errorf("sweeper: failed to sign transaction: %w", err)
+		return sw
+	}
+	sw.Transaction = tx
+	if s.opts.DryRun {
+		return sw
+	}
+	raw, err := tx.Raw()
+	if err != nil {
+		sw.Err = fmt.Errorf("sweeper: %w", err)
+		return sw
+	}
+	sw.Hash, err = s.opts.Client.SendRawTransaction(ctx, raw)
+	if err != nil {
+		sw.Err = fmt.Errorf("sweeper: failed to send transaction: %w", err)
+	}
+	return sw
+}
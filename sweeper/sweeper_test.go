@@ -0,0 +1,148 @@
+package sweeper
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/types"
+	"github.com/defiweb/go-eth/wallet"
+)
+
+type fakeRPC struct {
+	rpc.Client
+
+	balances map[types.Address]*big.Int
+	gasPrice *big.Int
+	sentRaw  [][]byte
+}
+
+func (f *fakeRPC) ChainID(context.Context) (uint64, error) {
+	return 1, nil
+}
+
+func (f *fakeRPC) GetBalance(_ context.Context, addr types.Address, _ types.BlockNumber) (*big.Int, error) {
+	if b, ok := f.balances[addr]; ok {
+		return b, nil
+	}
+	return big.NewInt(0), nil
+}
+
+func (f *fakeRPC) GetTransactionCount(context.Context, types.Address, types.BlockNumber) (uint64, error) {
+	return 3, nil
+}
+
+func (f *fakeRPC) GasPrice(context.Context) (*big.Int, error) {
+	return f.gasPrice, nil
+}
+
+func (f *fakeRPC) SendRawTransaction(_ context.Context, data []byte) (*types.Hash, error) {
+	f.sentRaw = append(f.sentRaw, data)
+	return &types.Hash{1}, nil
+}
+
+func testKeys(t *testing.T) []wallet.Key {
+	m, err := wallet.NewMnemonic(
+		"gravity trophy shrimp suspect sheriff avocado label trust dove tragic pitch title network myself spell task protect smooth sword diary brain blossom under bulb",
+		"fJF*(SDF*(*@J!)(SU*(D*F&^&TYSDFHL#@HO*&O",
+	)
+	require.NoError(t, err)
+	keys, err := DeriveKeys(m, wallet.DefaultDerivationPath, 3)
+	require.NoError(t, err)
+	return keys
+}
+
+func TestDeriveKeys(t *testing.T) {
+	keys := testKeys(t)
+	require.Len(t, keys, 3)
+	assert.Equal(t, "0x02941ca660485ba7dc196b510d9a6192c2648709", keys[0].Address().String())
+	assert.Equal(t, "0xd050d1f66eb5ed560079754f3c1623b369a1a5ee", keys[1].Address().String())
+}
+
+func TestSweeper_Sweep(t *testing.T) {
+	keys := testKeys(t)
+	hot := types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+	client := &fakeRPC{
+		balances: map[types.Address]*big.Int{
+			keys[0].Address(): big.NewInt(1_000_000),
+			keys[1].Address(): big.NewInt(100), // Below the threshold.
+			keys[2].Address(): big.NewInt(400_000),
+		},
+		gasPrice: big.NewInt(10),
+	}
+	s, err := NewSweeper(SweeperOptions{
+		Client:    client,
+		Keys:      keys,
+		HotWallet: hot,
+		Threshold: big.NewInt(1000),
+	})
+	require.NoError(t, err)
+
+	res, err := s.Sweep(context.Background())
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+
+	// Fee of 210000 wei is deducted from the balance.
+	assert.Equal(t, keys[0].Address(), res[0].Address)
+	require.NoError(t, res[0].Err)
+	assert.Equal(t, big.NewInt(210_000), res[0].Fee)
+	assert.Equal(t, big.NewInt(790_000), res[0].Amount)
+	assert.Equal(t, &types.Hash{1}, res[0].Hash)
+	assert.Equal(t, hot, *res[0].Transaction.To)
+	assert.Equal(t, uint64(3), *res[0].Transaction.Nonce)
+
+	assert.Equal(t, keys[2].Address(), res[1].Address)
+	require.NoError(t, res[1].Err)
+	assert.Equal(t, big.NewInt(190_000), res[1].Amount)
+
+	// Sent transactions must be signed by deposit keys.
+	require.Len(t, client.sentRaw, 2)
+	tx := &types.Transaction{}
+	_, err = tx.DecodeRLP(client.sentRaw[0])
+	require.NoError(t, err)
+	from, err := crypto.ECRecoverer.RecoverTransaction(tx)
+	require.NoError(t, err)
+	assert.Equal(t, keys[0].Address(), *from)
+	assert.Equal(t, big.NewInt(790_000), tx.Value)
+}
+
+func TestSweeper_Sweep_Limits(t *testing.T) {
+	keys := testKeys(t)
+	client := &fakeRPC{
+		balances: map[types.Address]*big.Int{
+			keys[0].Address(): big.NewInt(1_000_000),
+			keys[1].Address(): big.NewInt(100_000), // Does not cover the fee.
+		},
+		gasPrice: big.NewInt(10),
+	}
+	s, err := NewSweeper(SweeperOptions{
+		Client:    client,
+		Keys:      keys,
+		HotWallet: types.MustAddressFromHex("0x1111111111111111111111111111111111111111"),
+		MaxFee:    big.NewInt(300_000),
+		DryRun:    true,
+	})
+	require.NoError(t, err)
+
+	res, err := s.Sweep(context.Background())
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.NoError(t, res[0].Err)
+	assert.NotNil(t, res[0].Transaction.Signature)
+	assert.Nil(t, res[0].Hash)
+	assert.ErrorIs(t, res[1].Err, ErrBalanceTooLow)
+	assert.Empty(t, client.sentRaw)
+
+	// Fee spike above the budget.
+	client.gasPrice = big.NewInt(100)
+	res, err = s.Sweep(context.Background())
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.ErrorIs(t, res[0].Err, ErrFeeBudgetExceeded)
+	assert.ErrorIs(t, res[1].Err, ErrFeeBudgetExceeded)
+}
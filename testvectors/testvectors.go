@@ -0,0 +1,265 @@
+// Package testvectors provides deterministic test vectors that can be used
+// by downstream projects to verify their integration against the go-eth
+// implementation.
+//
+// The vectors cover transactions of all supported types, EIP-191 messages,
+// EIP-712 typed data (in the form of Safe transactions) and V3 keystores.
+// All signatures are deterministic (RFC 6979), so the same inputs always
+// produce the same outputs.
+//
+// The package tests check that the vectors match the current
+// implementation, so any change to the encoding or signing code that would
+// break compatibility is detected.
+//
+// The private keys used in this package are publicly known and must never
+// be used to hold any funds.
+package testvectors
+
+import (
+	"embed"
+	"math/big"
+
+	"github.com/defiweb/go-eth/hexutil"
+	"github.com/defiweb/go-eth/safe"
+	"github.com/defiweb/go-eth/types"
+)
+
+//go:embed testdata/*.json
+var testdata embed.FS
+
+// PrivateKey is the private key used to sign all transaction, message and
+// typed data vectors.
+var PrivateKey = hexutil.MustHexToBytes("0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+
+// Address is the address of PrivateKey.
+var Address = types.MustAddressFromHex("0x2c7536e3605d9c16a7a3d7b1898e529396a65c23")
+
+// Transaction is a transaction test vector.
+type Transaction struct {
+	Name        string             // Name is the name of the vector.
+	Tx          *types.Transaction // Tx is the unsigned transaction.
+	SigningHash types.Hash         // SigningHash is the hash signed by the sender.
+	Signature   types.Signature    // Signature is the transaction signature made with PrivateKey.
+	Raw         []byte             // Raw is the signed transaction encoded as for eth_sendRawTransaction.
+	Hash        types.Hash         // Hash is the transaction hash.
+}
+
+// Message is an EIP-191 message test vector.
+type Message struct {
+	Name      string          // Name is the name of the vector.
+	Data      []byte          // Data is the message.
+	Hash      types.Hash      // Hash is the hash of the message with the EIP-191 prefix.
+	Signature types.Signature // Signature is the message signature made with PrivateKey.
+}
+
+// TypedData is an EIP-712 typed data test vector. Typed data is represented
+// by a Safe transaction.
+type TypedData struct {
+	Name            string          // Name is the name of the vector.
+	Safe            types.Address   // Safe is the verifying contract.
+	ChainID         uint64          // ChainID is the chain ID of the domain.
+	Tx              *safe.Tx        // Tx is the Safe transaction.
+	DomainSeparator types.Hash      // DomainSeparator is the EIP-712 domain separator.
+	Hash            types.Hash      // Hash is the EIP-712 hash.
+	Signature       types.Signature // Signature is the signature of Hash made with PrivateKey, V is 27 or 28.
+}
+
+// Keystore is a V3 keystore test vector.
+type Keystore struct {
+	Name       string        // Name is the name of the vector.
+	JSON       []byte        // JSON is the keystore file content.
+	Passphrase string        // Passphrase is the keystore passphrase.
+	Address    types.Address // Address is the address of the key.
+	PrivateKey []byte        // PrivateKey is the decrypted private key.
+}
+
+// Transactions returns the transaction vectors. A new copy is returned on
+// every call, so the vectors can be safely modified.
+func Transactions() []Transaction {
+	var (
+		to       = types.MustAddressFromHex("0x3535353535353535353535353535353535353535")
+		gasPrice = big.NewInt(20_000_000_000)
+		tip      = big.NewInt(2_000_000_000)
+		feeCap   = big.NewInt(100_000_000_000)
+		ether    = big.NewInt(1_000_000_000_000_000_000)
+	)
+	return []Transaction{
+		{
+			Name: "legacy-eip155",
+			Tx: (&types.Transaction{}).
+				SetType(types.LegacyTxType).
+				SetChainID(1).
+				SetNonce(9).
+				SetGasPrice(gasPrice).
+				SetGasLimit(21000).
+				SetTo(to).
+				SetValue(ether),
+			SigningHash: types.MustHashFromHex("0xdaf5a779ae972f972197303d7b574746c7ef83eadac0f2791ad23db92e4c8e53", types.PadNone),
+			Signature:   types.MustSignatureFromHex("0x499aa1110848b179aa0f228e20faa3ba68b350e1feeab49638c6b8ce40ea56ae053ec9b43dcea26f8d10b43a44bdfafae5b1b26462367921079005d4d274e06d25"),
+			Raw:         hexutil.MustHexToBytes("0xf86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a0499aa1110848b179aa0f228e20faa3ba68b350e1feeab49638c6b8ce40ea56aea0053ec9b43dcea26f8d10b43a44bdfafae5b1b26462367921079005d4d274e06d"),
+			Hash:        types.MustHashFromHex("0xe4e0d6b0c5b43efcf6651888cf149d88384f0762ff684a58a06f8997b6e1f979", types.PadNone),
+		},
+		{
+			Name: "legacy-pre-eip155",
+			Tx: (&types.Transaction{}).
+				SetType(types.LegacyTxType).
+				SetNonce(0).
+				SetGasPrice(gasPrice).
+				SetGasLimit(21000).
+				SetTo(to).
+				SetValue(ether),
+			SigningHash: types.MustHashFromHex("0x597779acf7a80f7bd5089cbfe09ee7bb0749dc593e38b85d17c5f4ab81c34600", types.PadNone),
+			Signature:   types.MustSignatureFromHex("0xe8d157cf02a8edbe95aadd7c7076241c8b0fe57295f21b02c891f3ee6529013c62eca7325d8f0a8e9e3b424fe18dae91aa28eb05f8bad40547439767d18b4a321c"),
+			Raw:         hexutil.MustHexToBytes("0xf86c808504a817c800825208943535353535353535353535353535353535353535880de0b6b3a7640000801ca0e8d157cf02a8edbe95aadd7c7076241c8b0fe57295f21b02c891f3ee6529013ca062eca7325d8f0a8e9e3b424fe18dae91aa28eb05f8bad40547439767d18b4a32"),
+			Hash:        types.MustHashFromHex("0x22b6ec6572021a1d419163be583da7c3f2c8d5b60f8d7a8f396864fe1e6f2b23", types.PadNone),
+		},
+		{
+			Name: "access-list",
+			Tx: (&types.Transaction{}).
+				SetType(types.AccessListTxType).
+				SetChainID(1).
+				SetNonce(1).
+				SetGasPrice(gasPrice).
+				SetGasLimit(30000).
+				SetTo(to).
+				SetValue(ether).
+				SetInput(hexutil.MustHexToBytes("0xdeadbeef")).
+				SetAccessList(types.AccessList{{
+					Address:     to,
+					StorageKeys: []types.Hash{types.MustHashFromHex("0x01", types.PadLeft)},
+				}}),
+			SigningHash: types.MustHashFromHex("0x0ea55f5f2e4a37728370d92b8f18beaa0d4994a61f15f522d3eb2f4e46c5034a", types.PadNone),
+			Signature:   types.MustSignatureFromHex("0xf4fc864f9017c0f052545ab45632321ef3a6b784beaf81b5384d905876aa31f44e0da4d57ec13398600fca9598fc42f26915064fe566bdf87e75e7d5465cb2dc00"),
+			Raw:         hexutil.MustHexToBytes("0x01f8ab01018504a817c800827530943535353535353535353535353535353535353535880de0b6b3a764000084deadbeeff838f7943535353535353535353535353535353535353535e1a0000000000000000000000000000000000000000000000000000000000000000180a0f4fc864f9017c0f052545ab45632321ef3a6b784beaf81b5384d905876aa31f4a04e0da4d57ec13398600fca9598fc42f26915064fe566bdf87e75e7d5465cb2dc"),
+			Hash:        types.MustHashFromHex("0x2e55a9b2e332bcc2dcc7d95b72bfb5e1de82a2b51de5ac7b25b30ad9bc286515", types.PadNone),
+		},
+		{
+			Name: "dynamic-fee",
+			Tx: (&types.Transaction{}).
+				SetType(types.DynamicFeeTxType).
+				SetChainID(1).
+				SetNonce(2).
+				SetMaxPriorityFeePerGas(tip).
+				SetMaxFeePerGas(feeCap).
+				SetGasLimit(21000).
+				SetTo(to).
+				SetValue(ether),
+			SigningHash: types.MustHashFromHex("0xccf17a8f3c636d38da6f1eb927b4793f703f2f42331a1669c9c2ea54fcecb5da", types.PadNone),
+			Signature:   types.MustSignatureFromHex("0x5b3680c4576294934c8ca8dd8204c7b85df340503c8d7039c49a0ce0a4b0d0341f7fd45b4805ed7ff44ee3ef8acecb5df8ff05fa754503ff05de62ce55c3ca1601"),
+			Raw:         hexutil.MustHexToBytes("0x02f8730102847735940085174876e800825208943535353535353535353535353535353535353535880de0b6b3a764000080c001a05b3680c4576294934c8ca8dd8204c7b85df340503c8d7039c49a0ce0a4b0d034a01f7fd45b4805ed7ff44ee3ef8acecb5df8ff05fa754503ff05de62ce55c3ca16"),
+			Hash:        types.MustHashFromHex("0x92fa33596a14428595bb7831c9f4f9921d12ef50f0a1a685a501dbd950e2e6c2", types.PadNone),
+		},
+		{
+			Name: "dynamic-fee-contract-creation",
+			Tx: (&types.Transaction{}).
+				SetType(types.DynamicFeeTxType).
+				SetChainID(1).
+				SetNonce(3).
+				SetMaxPriorityFeePerGas(tip).
+				SetMaxFeePerGas(feeCap).
+				SetGasLimit(100000).
+				SetInput(hexutil.MustHexToBytes("0x60006000f3")),
+			SigningHash: types.MustHashFromHex("0x92b11b3e32fbcec998b67e516addb5b88b6c8e612e5aee5e13335049e32d4a62", types.PadNone),
+			Signature:   types.MustSignatureFromHex("0x8a4daa14a5aaae4db28dc7c11a4dedc03efd94646ba344e4fc37fe3e76e8f5546722bdf40e00b4634beca6807c2eee40cda928c8087a64a7f811190a6740029e01"),
+			Raw:         hexutil.MustHexToBytes("0x02f85d0103847735940085174876e800830186a080808560006000f3c001a08a4daa14a5aaae4db28dc7c11a4dedc03efd94646ba344e4fc37fe3e76e8f554a06722bdf40e00b4634beca6807c2eee40cda928c8087a64a7f811190a6740029e"),
+			Hash:        types.MustHashFromHex("0xce8cf2354c88274e6e0dd6ffcc41050e809013e2043d17e9c82b7fbf9ca8b6b2", types.PadNone),
+		},
+		{
+			Name: "set-code",
+			Tx: (&types.Transaction{}).
+				SetType(types.SetCodeTxType).
+				SetChainID(1).
+				SetNonce(0).
+				SetMaxPriorityFeePerGas(tip).
+				SetMaxFeePerGas(feeCap).
+				SetGasLimit(100000).
+				SetTo(Address).
+				SetAuthorizationList(types.AuthorizationList{{
+					ChainID: 1,
+					Address: types.MustAddressFromHex("0x4242424242424242424242424242424242424242"),
+					Nonce:   1,
+					Signature: types.SignatureFromVRSPtr(
+						big.NewInt(0),
+						hexutil.MustHexToBigInt("0x219cdd2830d6d724c32991de9781b48d820e4162c2d36d9c21151c2865aa4559"),
+						hexutil.MustHexToBigInt("0x041e3bcc36a21fa004645635ed066136413a4b3922a6b71e4dd99300f1373251"),
+					),
+				}}),
+			SigningHash: types.MustHashFromHex("0x614a741eb700513b8a1e9826772e2ae47c2ca757743c970d829c5c11b24bae96", types.PadNone),
+			Signature:   types.MustSignatureFromHex("0x0851f1dabafe76ea6011fbecbc0cb4376f8b434c4cf117b371271b9b0493111d55b437bac22f1809208d02cf8bf7cef10040eb5bca2a53cb8704e21a517be07001"),
+			Raw:         hexutil.MustHexToBytes("0x04f8ca0180847735940085174876e800830186a0942c7536e3605d9c16a7a3d7b1898e529396a65c238080c0f85cf85a019442424242424242424242424242424242424242420180a0219cdd2830d6d724c32991de9781b48d820e4162c2d36d9c21151c2865aa4559a0041e3bcc36a21fa004645635ed066136413a4b3922a6b71e4dd99300f137325101a00851f1dabafe76ea6011fbecbc0cb4376f8b434c4cf117b371271b9b0493111da055b437bac22f1809208d02cf8bf7cef10040eb5bca2a53cb8704e21a517be070"),
+			Hash:        types.MustHashFromHex("0x26a04092733a44576498d08caf008a01cf2027142116990281541c37826bf645", types.PadNone),
+		},
+	}
+}
+
+// Messages returns the EIP-191 message vectors.
+func Messages() []Message {
+	return []Message{
+		{
+			Name:      "text",
+			Data:      []byte("hello world"),
+			Hash:      types.MustHashFromHex("0xd9eba16ed0ecae432b71fe008c98cc872bb4cc214d3220a36f365326cf807d68", types.PadNone),
+			Signature: types.MustSignatureFromHex("0x0d5df3f9681b000a5b3a1d4252803318136714deba10c578f9b33718ad9c816e5520a6f19168136aa11e38d691d28a422bce4be3bf290fdb8efe45cdaefb20251c"),
+		},
+		{
+			Name:      "empty",
+			Data:      []byte{},
+			Hash:      types.MustHashFromHex("0x5f35dce98ba4fba25530a026ed80b2cecdaa31091ba4958b99b52ea1d068adad", types.PadNone),
+			Signature: types.MustSignatureFromHex("0x8a68b4e66cd2b575338e16069d7b65f6f67c7ceae8945dccf8cb7bdb06278d933dd9c888f3444ca4698464079a067ad3cfffe96d493b8ecf56885169d0fdfe7d1b"),
+		},
+		{
+			Name:      "binary",
+			Data:      []byte{0x00, 0x01, 0x02, 0xff},
+			Hash:      types.MustHashFromHex("0x0be7deb6e7a189a6b66096dd0abc8ec63a7c7f59b2b0eebe395d60778a337908", types.PadNone),
+			Signature: types.MustSignatureFromHex("0xea8d142cdaff8641c9f5da263b506aed67555e1dc15aface62f9fbc6e1c317756f6f3a8e7f4b0a7a3663dbe4fb8e658cb2aa8cc9dda4232a6562f5b207bfc3f81c"),
+		},
+	}
+}
+
+// TypedDataPayloads returns the EIP-712 typed data vectors.
+func TypedDataPayloads() []TypedData {
+	return []TypedData{
+		{
+			Name:    "safe-tx",
+			Safe:    types.MustAddressFromHex("0x1111111111111111111111111111111111111111"),
+			ChainID: 1,
+			Tx: &safe.Tx{
+				To:    types.MustAddressFromHex("0x3535353535353535353535353535353535353535"),
+				Value: big.NewInt(1_000_000_000_000_000_000),
+				Data:  hexutil.MustHexToBytes("0xdeadbeef"),
+				Nonce: big.NewInt(7),
+			},
+			DomainSeparator: types.MustHashFromHex("0xf0dcfe86ad4a409690a57dbaae9b1e14c5ea1750a48271a0a3a6037a8100624d", types.PadNone),
+			Hash:            types.MustHashFromHex("0xb3d45dd3d487f544dbfb04c89e1ce1730881ec7a390df10c8988d4130476a0e5", types.PadNone),
+			Signature:       types.MustSignatureFromHex("0x3a115fb70a08fc16375e36a56a2d9363b23984e08cfd6dee6e249f5b53e187960c75e4b8dcc8fb791546b457904882826671001f7a0ae8fe518e38547be9e2941c"),
+		},
+	}
+}
+
+// Keystores returns the V3 keystore vectors.
+func Keystores() []Keystore {
+	return []Keystore{
+		{
+			Name:       "scrypt",
+			JSON:       mustReadFile("testdata/scrypt.json"),
+			Passphrase: "test123",
+			Address:    types.MustAddressFromHex("0x2d800d93b065ce011af83f316cef9f0d005b0aa4"),
+			PrivateKey: hexutil.MustHexToBytes("0xf1553dbc0cf973ac0565ea6e391a76b21dd2d186a9d14ebdd9c8533a1658d6ea"),
+		},
+		{
+			Name:       "pbkdf2",
+			JSON:       mustReadFile("testdata/pbkdf2.json"),
+			Passphrase: "testpassword",
+			Address:    types.MustAddressFromHex("0x008aeeda4d805471df9b2a5b0f38a0c3bcba786b"),
+			PrivateKey: hexutil.MustHexToBytes("0x7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"),
+		},
+	}
+}
+
+func mustReadFile(name string) []byte {
+	b, err := testdata.ReadFile(name)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,90 @@
+package testvectors
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/safe"
+	"github.com/defiweb/go-eth/types"
+	"github.com/defiweb/go-eth/wallet"
+)
+
+func TestTransactions(t *testing.T) {
+	key := wallet.NewKeyFromBytes(PrivateKey)
+	require.Equal(t, Address, key.Address())
+	for _, v := range Transactions() {
+		t.Run(v.Name, func(t *testing.T) {
+			hash, err := crypto.TransactionSigningHash(v.Tx)
+			require.NoError(t, err)
+			assert.Equal(t, v.SigningHash, hash)
+
+			require.NoError(t, key.SignTransaction(context.Background(), v.Tx))
+			assert.Equal(t, v.Signature, *v.Tx.Signature)
+
+			raw, err := v.Tx.Raw()
+			require.NoError(t, err)
+			assert.Equal(t, v.Raw, raw)
+
+			txHash, err := v.Tx.Hash(crypto.Keccak256)
+			require.NoError(t, err)
+			assert.Equal(t, v.Hash, txHash)
+
+			// Decoding the raw transaction must recover the sender.
+			tx := new(types.Transaction)
+			_, err = tx.DecodeRLP(v.Raw)
+			require.NoError(t, err)
+			addr, err := crypto.ECRecoverer.RecoverTransaction(tx)
+			require.NoError(t, err)
+			assert.Equal(t, Address, *addr)
+		})
+	}
+}
+
+func TestMessages(t *testing.T) {
+	key := wallet.NewKeyFromBytes(PrivateKey)
+	for _, v := range Messages() {
+		t.Run(v.Name, func(t *testing.T) {
+			assert.Equal(t, v.Hash, crypto.Keccak256(crypto.AddMessagePrefix(v.Data)))
+
+			sig, err := key.SignMessage(context.Background(), v.Data)
+			require.NoError(t, err)
+			assert.Equal(t, v.Signature, *sig)
+		})
+	}
+}
+
+func TestTypedDataPayloads(t *testing.T) {
+	key := wallet.NewKeyFromBytes(PrivateKey)
+	for _, v := range TypedDataPayloads() {
+		t.Run(v.Name, func(t *testing.T) {
+			ds, err := safe.DomainSeparator(v.Safe, v.ChainID)
+			require.NoError(t, err)
+			assert.Equal(t, v.DomainSeparator, ds)
+
+			hash, err := v.Tx.Hash(v.Safe, v.ChainID)
+			require.NoError(t, err)
+			assert.Equal(t, v.Hash, hash)
+
+			sig, err := key.SignHash(context.Background(), hash)
+			require.NoError(t, err)
+			sig.V = new(big.Int).Add(sig.V, big.NewInt(27))
+			assert.Equal(t, v.Signature, *sig)
+		})
+	}
+}
+
+func TestKeystores(t *testing.T) {
+	for _, v := range Keystores() {
+		t.Run(v.Name, func(t *testing.T) {
+			key, err := wallet.NewKeyFromJSONContent(v.JSON, v.Passphrase)
+			require.NoError(t, err)
+			assert.Equal(t, v.Address, key.Address())
+			assert.Equal(t, v.PrivateKey, key.PrivateKey().D.FillBytes(make([]byte, 32)))
+		})
+	}
+}
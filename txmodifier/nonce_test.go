@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/defiweb/go-eth/types"
 )
@@ -42,6 +43,38 @@ func TestNonceProvider_Modify(t *testing.T) {
 		assert.Equal(t, uint64(11), *tx.Nonce)
 	})
 
+	t.Run("reserve nonces", func(t *testing.T) {
+		otherAddress := types.MustAddressFromHex("0xabcdef1234567890abcdef1234567890abcdef12")
+		rpcMock := new(mockRPC)
+		rpcMock.On("GetTransactionCount", ctx, fromAddress, types.LatestBlockNumber).Return(uint64(10), nil).Times(3)
+		rpcMock.On("GetTransactionCount", ctx, otherAddress, types.LatestBlockNumber).Return(uint64(5), nil).Once()
+		rpcMock.On("GetTransactionCount", ctx, fromAddress, types.LatestBlockNumber).Return(uint64(20), nil).Once()
+		rpcMock.On("GetTransactionCount", ctx, fromAddress, types.LatestBlockNumber).Return(uint64(10), nil).Once()
+
+		provider := NewNonceProvider(NonceProviderOptions{
+			Reserve: true,
+		})
+		modify := func(from types.Address) uint64 {
+			tx := &types.Transaction{Call: types.Call{From: &from}}
+			require.NoError(t, provider.Modify(ctx, rpcMock, tx))
+			return *tx.Nonce
+		}
+
+		// Nonces are reserved per address.
+		assert.Equal(t, uint64(10), modify(fromAddress))
+		assert.Equal(t, uint64(11), modify(fromAddress))
+		assert.Equal(t, uint64(5), modify(otherAddress))
+		assert.Equal(t, uint64(12), modify(fromAddress))
+
+		// The node's transaction count is used if it is higher.
+		assert.Equal(t, uint64(20), modify(fromAddress))
+
+		// After a reset, the node's transaction count is used again.
+		provider.Reset(fromAddress)
+		assert.Equal(t, uint64(10), modify(fromAddress))
+		rpcMock.AssertExpectations(t)
+	})
+
 	t.Run("missing from address", func(t *testing.T) {
 		txWithoutFrom := &types.Transaction{}
 		provider := NewNonceProvider(NonceProviderOptions{
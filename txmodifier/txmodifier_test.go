@@ -35,6 +35,11 @@ func (m *mockRPC) MaxPriorityFeePerGas(ctx context.Context) (*big.Int, error) {
 	return args.Get(0).(*big.Int), args.Error(1)
 }
 
+func (m *mockRPC) FeeHistory(ctx context.Context, blockCount uint64, newestBlock types.BlockNumber, rewardPercentiles []float64) (*types.FeeHistory, error) {
+	args := m.Called(ctx, blockCount, newestBlock, rewardPercentiles)
+	return args.Get(0).(*types.FeeHistory), args.Error(1)
+}
+
 func (m *mockRPC) GetTransactionCount(ctx context.Context, address types.Address, block types.BlockNumber) (uint64, error) {
 	args := m.Called(ctx, address, block)
 	return args.Get(0).(uint64), args.Error(1)
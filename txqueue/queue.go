@@ -0,0 +1,334 @@
+// Package txqueue provides a transaction queue that assigns nonces and
+// submits transactions of every sender in nonce order.
+package txqueue
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/store"
+	"github.com/defiweb/go-eth/types"
+)
+
+// nonceConflictRegexp matches errors returned by nodes when the nonce of a
+// transaction is already used, either by a mined transaction or by a pending
+// transaction sent outside the queue, e.g.:
+//
+//	nonce too low: next nonce 5, tx nonce 4
+//	replacement transaction underpriced
+var nonceConflictRegexp = regexp.MustCompile(`(?i)nonce too low|replacement transaction underpriced`)
+
+// Queue serializes transactions of every sender, so that concurrent
+// callers never create nonce gaps or reuse nonces.
+//
+// A transaction is submitted only after the previous transaction of the
+// same sender has been accepted by the node. If a submission fails, its
+// nonce is not consumed and is assigned to the next transaction. Senders do
+// not block each other.
+//
+// Queue is safe for concurrent use.
+type Queue struct {
+	opts QueueOptions
+
+	mu      sync.Mutex
+	senders map[types.Address]*sender
+}
+
+// QueueOptions is the options for NewQueue.
+type QueueOptions struct {
+	// Client is the RPC client used to send transactions. It must be able
+	// to sign transactions, e.g. using the rpc.WithKeys option, and should
+	// use transaction modifiers to set the remaining fields, such as fees
+	// and the gas limit. Nonces set by transaction modifiers are ignored
+	// unless they are configured to replace existing nonces.
+	Client rpc.RPC
+
+	// UseLatestBlock fetches nonces from the latest block instead of the
+	// pending block. It should be used only with nodes that do not report
+	// pending nonces correctly.
+	UseLatestBlock bool
+
+	// Store, if set, persists the next nonce of every sender and the
+	// hashes of sent transactions, so that nonces of transactions that
+	// were accepted but are not yet reported by the node, e.g. after a
+	// restart or by a lagging node behind a load balancer, are not reused.
+	// Use store.NewPrefixed to share the storage with other components.
+	//
+	// With a store, the nonce is reconciled with the pending nonce of the
+	// node before every transaction. If the next nonce is ahead of the
+	// node, the transaction with the first missing nonce is looked up. If
+	// the node does not know it, e.g. because it was dropped from the
+	// transaction pool, the nonce is rewound to the nonce of the node, so
+	// that later transactions do not wait behind a nonce gap.
+	//
+	// Hashes of transactions with nonces below the confirmed nonce of the
+	// node, fetched from the latest block, are deleted from the store after
+	// every transaction, because they are no longer needed.
+	//
+	// If the nonce cannot be stored after a transaction was sent, the hash
+	// and the transaction are returned along with the error.
+	Store store.KV
+}
+
+// sender is the state of a single sender.
+type sender struct {
+	mu     sync.Mutex
+	next   *uint64 // Next nonce to assign, nil if unknown.
+	pruned uint64  // Nonce below which stored transactions were deleted.
+}
+
+// NewQueue returns a new Queue.
+func NewQueue(opts QueueOptions) (*Queue, error) {
+	if opts.Client == nil {
+		return nil, errors.New("txqueue: client is required")
+	}
+	return &Queue{opts: opts, senders: make(map[types.Address]*sender)}, nil
+}
+
+// Send assigns the next nonce of the sender to the transaction and submits
+// it. The nonce of the given transaction is ignored.
+//
+// If the node reports that the nonce is already used, e.g. because a
+// transaction was sent outside the queue, the nonce is fetched again from
+// the node and the transaction is submitted once more.
+func (q *Queue) Send(ctx context.Context, tx *types.Transaction) (*types.Hash, *types.Transaction, error) {
+	if tx == nil || tx.From == nil {
+		return nil, nil, errors.New("txqueue: missing from address")
+	}
+	s := q.sender(*tx.From)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for attempt := 0; ; attempt++ {
+		nonce, err := q.nextNonce(ctx, *tx.From, s)
+		if err != nil {
+			return nil, nil, err
+		}
+		cpy := tx.Copy()
+		cpy.Nonce = &nonce
+		hash, sent, err := q.opts.Client.SendTransaction(ctx, cpy)
+		if err != nil {
+			if attempt == 0 && nonceConflictRegexp.MatchString(err.Error()) {
+				s.next = nil
+				continue
+			}
+			return nil, nil, err
+		}
+		next := nonce + 1
+		s.next = &next
+		if err := q.storeNonce(ctx, *tx.From, s, nonce, *hash, true); err != nil {
+			return hash, sent, err
+		}
+		return hash, sent, nil
+	}
+}
+
+// Replace submits a transaction that replaces a pending transaction of the
+// sender, e.g. to bump its fees or to cancel it. The transaction must have
+// the nonce of the replaced transaction set.
+//
+// Replacements are serialized with other transactions of the sender. If the
+// nonce is higher than any nonce assigned so far, the following nonces are
+// re-sequenced to continue after it.
+func (q *Queue) Replace(ctx context.Context, tx *types.Transaction) (*types.Hash, *types.Transaction, error) {
+	if tx == nil || tx.From == nil {
+		return nil, nil, errors.New("txqueue: missing from address")
+	}
+	if tx.Nonce == nil {
+		return nil, nil, errors.New("txqueue: missing nonce of the replaced transaction")
+	}
+	s := q.sender(*tx.From)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hash, sent, err := q.opts.Client.SendTransaction(ctx, tx)
+	if err != nil {
+		return nil, nil, err
+	}
+	advance := s.next == nil || *tx.Nonce >= *s.next
+	if advance {
+		next := *tx.Nonce + 1
+		s.next = &next
+	}
+	if err := q.storeNonce(ctx, *tx.From, s, *tx.Nonce, *hash, advance); err != nil {
+		return hash, sent, err
+	}
+	return hash, sent, nil
+}
+
+// Reset forgets the next nonce of the sender, so that it is fetched again
+// from the node before the next transaction is sent. It should be called
+// when transactions sent through the queue are dropped from the
+// transaction pool.
+func (q *Queue) Reset(addr types.Address) {
+	s := q.sender(addr)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.next = nil
+}
+
+// sender returns the state of the sender, creating it if necessary.
+func (q *Queue) sender(addr types.Address) *sender {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	s, ok := q.senders[addr]
+	if !ok {
+		s = &sender{}
+		q.senders[addr] = s
+	}
+	return s
+}
+
+// nextNonce returns the next nonce of the sender. The caller must hold the
+// sender lock.
+func (q *Queue) nextNonce(ctx context.Context, addr types.Address, s *sender) (uint64, error) {
+	if s.next != nil && q.opts.Store == nil {
+		return *s.next, nil
+	}
+	block := types.PendingBlockNumber
+	if q.opts.UseLatestBlock {
+		block = types.LatestBlockNumber
+	}
+	nonce, err := q.opts.Client.GetTransactionCount(ctx, addr, block)
+	if err != nil {
+		return 0, fmt.Errorf("txqueue: failed to fetch nonce of %s: %w", addr, err)
+	}
+	if q.opts.Store == nil {
+		return nonce, nil
+	}
+	next := s.next
+	if next == nil {
+		b, err := q.opts.Store.Get(ctx, nonceKey(addr))
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			return nonce, nil
+		case err != nil:
+			return 0, fmt.Errorf("txqueue: failed to load nonce of %s: %w", addr, err)
+		case len(b) != 8:
+			return 0, fmt.Errorf("txqueue: invalid stored nonce of %s", addr)
+		}
+		n := binary.BigEndian.Uint64(b)
+		next = &n
+	}
+	if *next <= nonce {
+		return nonce, nil
+	}
+	// The next nonce is ahead of the node. Keep it only if the node knows
+	// the transaction with the first missing nonce.
+	known, err := q.knownTx(ctx, addr, nonce)
+	if err != nil {
+		return 0, err
+	}
+	if !known {
+		return nonce, nil
+	}
+	return *next, nil
+}
+
+// knownTx returns true if the node knows the transaction sent by the queue
+// with the given nonce.
+func (q *Queue) knownTx(ctx context.Context, addr types.Address, nonce uint64) (bool, error) {
+	b, err := q.opts.Store.Get(ctx, txKey(addr, nonce))
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("txqueue: failed to load transaction of %s with nonce %d: %w", addr, nonce, err)
+	}
+	hash, err := types.HashFromBytes(b, types.PadNone)
+	if err != nil {
+		return false, fmt.Errorf("txqueue: invalid stored transaction of %s with nonce %d: %w", addr, nonce, err)
+	}
+	if _, err := q.opts.Client.GetTransactionByHash(ctx, hash); err != nil {
+		if errors.Is(err, rpc.ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("txqueue: failed to fetch transaction %s: %w", hash, err)
+	}
+	return true, nil
+}
+
+// storeNonce persists the hash of the transaction sent with the given
+// nonce and, if advance is true, the next nonce of the sender. Then it
+// prunes the stored transactions of the sender. It does nothing if the
+// store is not set. The caller must hold the sender lock.
+func (q *Queue) storeNonce(ctx context.Context, addr types.Address, s *sender, nonce uint64, hash types.Hash, advance bool) error {
+	if q.opts.Store == nil {
+		return nil
+	}
+	if err := q.opts.Store.Put(ctx, txKey(addr, nonce), hash.Bytes()); err != nil {
+		return fmt.Errorf("txqueue: failed to store transaction of %s: %w", addr, err)
+	}
+	if advance {
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], nonce+1)
+		if err := q.opts.Store.Put(ctx, nonceKey(addr), b[:]); err != nil {
+			return fmt.Errorf("txqueue: failed to store nonce of %s: %w", addr, err)
+		}
+	}
+	return q.prune(ctx, addr, s)
+}
+
+// errStopIteration stops the iteration over the store.
+var errStopIteration = errors.New("stop iteration")
+
+// prune deletes the stored hashes of transactions of the sender with nonces
+// below the confirmed nonce of the node. Such transactions are mined, so
+// they are never looked up by knownTx. The caller must hold the sender lock.
+func (q *Queue) prune(ctx context.Context, addr types.Address, s *sender) error {
+	confirmed, err := q.opts.Client.GetTransactionCount(ctx, addr, types.LatestBlockNumber)
+	if err != nil {
+		return fmt.Errorf("txqueue: failed to fetch confirmed nonce of %s: %w", addr, err)
+	}
+	if confirmed <= s.pruned {
+		return nil
+	}
+	var nonces []uint64
+	err = q.opts.Store.Iterate(ctx, txPrefix(addr), func(key, _ []byte) error {
+		if len(key) != txKeyLength {
+			return nil
+		}
+		nonce := binary.BigEndian.Uint64(key[txKeyLength-8:])
+		if nonce >= confirmed {
+			return errStopIteration
+		}
+		nonces = append(nonces, nonce)
+		return nil
+	})
+	if err != nil && !errors.Is(err, errStopIteration) {
+		return fmt.Errorf("txqueue: failed to prune transactions of %s: %w", addr, err)
+	}
+	for _, nonce := range nonces {
+		if err := q.opts.Store.Delete(ctx, txKey(addr, nonce)); err != nil {
+			return fmt.Errorf("txqueue: failed to prune transactions of %s: %w", addr, err)
+		}
+	}
+	s.pruned = confirmed
+	return nil
+}
+
+// nonceKey returns the store key of the next nonce of the sender.
+func nonceKey(addr types.Address) []byte {
+	return append([]byte("n/"), addr.Bytes()...)
+}
+
+// txKeyLength is the length of the keys returned by txKey.
+const txKeyLength = 2 + types.AddressLength + 8
+
+// txPrefix returns the prefix of the store keys of the transactions of the
+// sender.
+func txPrefix(addr types.Address) []byte {
+	return append([]byte("t/"), addr.Bytes()...)
+}
+
+// txKey returns the store key of the hash of the transaction sent with the
+// given nonce.
+func txKey(addr types.Address, nonce uint64) []byte {
+	k := make([]byte, txKeyLength)
+	copy(k, txPrefix(addr))
+	binary.BigEndian.PutUint64(k[2+types.AddressLength:], nonce)
+	return k
+}
@@ -0,0 +1,240 @@
+package txqueue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/store"
+	"github.com/defiweb/go-eth/types"
+)
+
+var alice = types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+
+// fakeRPC simulates the transaction pool of a single sender.
+type fakeRPC struct {
+	rpc.Client
+
+	mu      sync.Mutex
+	pending uint64   // Next nonce expected by the node.
+	sent    []uint64 // Nonces of accepted transactions.
+	fail    error    // Error returned by the next SendTransaction call.
+	lag     uint64   // Number of accepted nonces not reported by GetTransactionCount.
+	mined   uint64   // Next nonce in the latest block.
+	fetches int
+	known   map[types.Hash]bool // Transactions known to the node.
+}
+
+func fakeTxHash(nonce uint64) types.Hash {
+	return types.Hash{0: 0xaa, 31: byte(nonce)}
+}
+
+func (f *fakeRPC) GetTransactionByHash(_ context.Context, hash types.Hash) (*types.OnChainTransaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.known[hash] {
+		return nil, rpc.ErrNotFound
+	}
+	return &types.OnChainTransaction{Hash: &hash}, nil
+}
+
+// drop removes the transaction with the given nonce from the transaction
+// pool, later transactions wait behind the nonce gap.
+func (f *fakeRPC) drop(nonce uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.known, fakeTxHash(nonce))
+	f.pending = nonce
+}
+
+func (f *fakeRPC) GetTransactionCount(_ context.Context, _ types.Address, block types.BlockNumber) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if block.IsLatest() {
+		return f.mined, nil
+	}
+	f.fetches++
+	return f.pending - f.lag, nil
+}
+
+func (f *fakeRPC) SendTransaction(_ context.Context, tx *types.Transaction) (*types.Hash, *types.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.fail; err != nil {
+		f.fail = nil
+		return nil, nil, err
+	}
+	switch {
+	case *tx.Nonce < f.pending:
+		if len(f.sent) > 0 && f.sent[len(f.sent)-1] == *tx.Nonce {
+			// Replacement of the last pending transaction.
+			hash := fakeTxHash(*tx.Nonce)
+			return &hash, tx, nil
+		}
+		return nil, nil, fmt.Errorf("nonce too low: next nonce %d, tx nonce %d", f.pending, *tx.Nonce)
+	case *tx.Nonce > f.pending:
+		return nil, nil, fmt.Errorf("nonce gap: next nonce %d, tx nonce %d", f.pending, *tx.Nonce)
+	}
+	f.pending++
+	f.sent = append(f.sent, *tx.Nonce)
+	hash := fakeTxHash(*tx.Nonce)
+	if f.known == nil {
+		f.known = map[types.Hash]bool{}
+	}
+	f.known[hash] = true
+	return &hash, tx, nil
+}
+
+func TestQueue_Send_Concurrent(t *testing.T) {
+	client := &fakeRPC{pending: 5}
+	q, err := NewQueue(QueueOptions{Client: client})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := q.Send(context.Background(), types.NewTransaction().SetFrom(alice))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	require.Len(t, client.sent, 50)
+	for i, n := range client.sent {
+		assert.Equal(t, uint64(5+i), n)
+	}
+	assert.Equal(t, 1, client.fetches)
+}
+
+func TestQueue_Send_Failures(t *testing.T) {
+	client := &fakeRPC{}
+	q, err := NewQueue(QueueOptions{Client: client})
+	require.NoError(t, err)
+	send := func() (*types.Transaction, error) {
+		_, tx, err := q.Send(context.Background(), types.NewTransaction().SetFrom(alice))
+		return tx, err
+	}
+
+	tx, err := send()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), *tx.Nonce)
+
+	// A failed submission does not consume the nonce.
+	client.fail = errors.New("insufficient funds")
+	_, err = send()
+	require.Error(t, err)
+	tx, err = send()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), *tx.Nonce)
+
+	// A transaction sent outside the queue is detected and the nonces are
+	// re-sequenced.
+	client.pending = 5
+	tx, err = send()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), *tx.Nonce)
+
+	// A replacement with a higher nonce moves the sequence forward.
+	replacement := types.NewTransaction().SetFrom(alice).SetNonce(5)
+	_, _, err = q.Replace(context.Background(), replacement)
+	require.NoError(t, err)
+	tx, err = send()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), *tx.Nonce)
+
+	// After a reset, the nonce is fetched again.
+	fetches := client.fetches
+	q.Reset(alice)
+	_, err = send()
+	require.NoError(t, err)
+	assert.Equal(t, fetches+1, client.fetches)
+}
+
+func TestQueue_Store(t *testing.T) {
+	client := &fakeRPC{pending: 3}
+	kv := store.NewMemory()
+	q, err := NewQueue(QueueOptions{Client: client, Store: kv})
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, _, err := q.Send(context.Background(), types.NewTransaction().SetFrom(alice))
+		require.NoError(t, err)
+	}
+
+	// After a restart, the node does not report the accepted transactions
+	// yet, so the stored nonce is used.
+	client.lag = 2
+	q, err = NewQueue(QueueOptions{Client: client, Store: kv})
+	require.NoError(t, err)
+	_, tx, err := q.Send(context.Background(), types.NewTransaction().SetFrom(alice))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), *tx.Nonce)
+	assert.Equal(t, []uint64{3, 4, 5}, client.sent)
+
+	// A stored nonce ahead of the node is not used if the transaction with
+	// the first missing nonce was not sent by the queue.
+	client.lag = 0
+	q.Reset(alice)
+	require.NoError(t, kv.Put(context.Background(), nonceKey(alice), []byte{0, 0, 0, 0, 0, 0, 0, 9}))
+	_, tx, err = q.Send(context.Background(), types.NewTransaction().SetFrom(alice))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), *tx.Nonce)
+}
+
+func TestQueue_Store_DroppedTransaction(t *testing.T) {
+	client := &fakeRPC{}
+	q, err := NewQueue(QueueOptions{Client: client, Store: store.NewMemory()})
+	require.NoError(t, err)
+	send := func() uint64 {
+		_, tx, err := q.Send(context.Background(), types.NewTransaction().SetFrom(alice))
+		require.NoError(t, err)
+		return *tx.Nonce
+	}
+	for i := 0; i < 3; i++ {
+		send()
+	}
+
+	// The transaction with nonce 1 is dropped, so the nonce is rewound
+	// without calling Reset.
+	client.drop(1)
+	assert.Equal(t, uint64(1), send())
+	assert.Equal(t, uint64(2), send())
+	assert.Equal(t, []uint64{0, 1, 2, 1, 2}, client.sent)
+}
+
+func TestQueue_Store_Prune(t *testing.T) {
+	client := &fakeRPC{}
+	kv := store.NewMemory()
+	q, err := NewQueue(QueueOptions{Client: client, Store: kv})
+	require.NoError(t, err)
+	send := func() {
+		_, _, err := q.Send(context.Background(), types.NewTransaction().SetFrom(alice))
+		require.NoError(t, err)
+	}
+	storedNonces := func() []uint64 {
+		var nonces []uint64
+		require.NoError(t, kv.Iterate(context.Background(), txPrefix(alice), func(key, _ []byte) error {
+			nonces = append(nonces, uint64(key[len(key)-1]))
+			return nil
+		}))
+		return nonces
+	}
+	for i := 0; i < 4; i++ {
+		send()
+	}
+	assert.Equal(t, []uint64{0, 1, 2, 3}, storedNonces())
+
+	// Transactions below the confirmed nonce are deleted.
+	client.mined = 3
+	send()
+	assert.Equal(t, []uint64{3, 4}, storedNonces())
+	client.mined = 5
+	send()
+	assert.Equal(t, []uint64{5}, storedNonces())
+}
@@ -0,0 +1,292 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/defiweb/go-rlp"
+
+	"github.com/defiweb/go-eth/jsoncodec"
+)
+
+// TransactionExtension holds chain-specific transaction fields, such as the
+// fee currency used by Celo.
+//
+// In JSON, the extension fields are encoded as additional fields of the
+// transaction object, hence the extension must marshal to a JSON object.
+// When a transaction is decoded, the whole transaction object is unmarshaled
+// into the extension, so unrelated fields must be ignored.
+type TransactionExtension interface {
+	// EncodeRLPFields returns the RLP items of the chain-specific fields.
+	// The items are placed after the fields of the base transaction type,
+	// before the signature.
+	EncodeRLPFields() ([]rlp.Item, error)
+
+	// DecodeRLPFields decodes the chain-specific fields from the RLP items
+	// placed after the fields of the base transaction type.
+	DecodeRLPFields(fields []*rlp.RLP) error
+
+	// CopyExtension returns a deep copy of the extension.
+	CopyExtension() TransactionExtension
+}
+
+// CustomTransactionType describes a chain-specific transaction type that
+// extends one of the standard typed transactions with additional fields.
+type CustomTransactionType struct {
+	// Name is an optional name of the type, returned by
+	// TransactionType.String and recognized by ParseTransactionType, e.g.
+	// "celo-dynamic-fee". It must not collide with standard type names.
+	Name string
+
+	// Base is the standard transaction type whose fields are extended.
+	// It must be AccessListTxType, DynamicFeeTxType or SetCodeTxType.
+	Base TransactionType
+
+	// NewExtension returns a new, empty extension used to decode the
+	// chain-specific fields. It must return a pointer.
+	NewExtension func() TransactionExtension
+}
+
+var (
+	customTxTypesMu sync.RWMutex
+	customTxTypes   = map[TransactionType]CustomTransactionType{}
+)
+
+// RegisterTransactionType registers a chain-specific transaction type.
+//
+// Transactions of a registered type store the chain-specific fields in
+// Transaction.Extension. The fields are preserved when transactions are
+// encoded to and decoded from JSON and RLP, and are included in the signing
+// hash.
+//
+// Standard transaction types cannot be overridden. Registering the same type
+// again replaces the previous registration.
+func RegisterTransactionType(typ TransactionType, def CustomTransactionType) error {
+	switch typ {
+	case LegacyTxType, AccessListTxType, DynamicFeeTxType, SetCodeTxType:
+		return fmt.Errorf("cannot register standard transaction type: %d", typ)
+	}
+	if typ >= 0x80 {
+		return fmt.Errorf("invalid transaction type: %d", typ)
+	}
+	if baseTxFields(def.Base) == 0 {
+		return fmt.Errorf("unsupported base transaction type: %d", def.Base)
+	}
+	if def.NewExtension == nil {
+		return fmt.Errorf("transaction type %d: NewExtension is required", typ)
+	}
+	if def.Name != "" {
+		if _, err := ParseTransactionType(def.Name); err == nil {
+			if prev, ok := LookupTransactionType(typ); !ok || prev.Name != def.Name {
+				return fmt.Errorf("transaction type %d: name %q is already used", typ, def.Name)
+			}
+		}
+	}
+	customTxTypesMu.Lock()
+	customTxTypes[typ] = def
+	customTxTypesMu.Unlock()
+	return nil
+}
+
+// UnregisterTransactionType removes a transaction type registered with
+// RegisterTransactionType. It does nothing if the type is not registered.
+func UnregisterTransactionType(typ TransactionType) {
+	customTxTypesMu.Lock()
+	delete(customTxTypes, typ)
+	customTxTypesMu.Unlock()
+}
+
+// LookupTransactionType returns the definition of a transaction type
+// registered with RegisterTransactionType.
+func LookupTransactionType(typ TransactionType) (CustomTransactionType, bool) {
+	customTxTypesMu.RLock()
+	defer customTxTypesMu.RUnlock()
+	def, ok := customTxTypes[typ]
+	return def, ok
+}
+
+// lookupTransactionTypeName returns the registered transaction type with
+// the given name.
+func lookupTransactionTypeName(name string) (TransactionType, bool) {
+	customTxTypesMu.RLock()
+	defer customTxTypesMu.RUnlock()
+	for typ, def := range customTxTypes {
+		if def.Name != "" && strings.EqualFold(def.Name, name) {
+			return typ, true
+		}
+	}
+	return 0, false
+}
+
+// EncodeUnsignedRLP returns the RLP encoding of a typed transaction without
+// the signature, prefixed with the transaction type. The hash of the result
+// is the transaction signing hash.
+//
+// Legacy transactions are not supported, because their signing payload
+// depends on the chain ID as defined in EIP-155.
+func (t Transaction) EncodeUnsignedRLP() ([]byte, error) {
+	if t.Type == LegacyTxType {
+		return nil, fmt.Errorf("unsigned encoding of legacy transactions is not supported")
+	}
+	t.Signature = nil
+	bin, err := t.EncodeRLP()
+	if err != nil {
+		return nil, err
+	}
+	fields, err := decodeTxFields(bin[1:])
+	if err != nil {
+		return nil, err
+	}
+	return encodeTxFields(t.Type, fields[:len(fields)-3])
+}
+
+// encodeCustomRLP encodes a transaction of a registered custom type.
+func (t Transaction) encodeCustomRLP(def CustomTransactionType) ([]byte, error) {
+	if t.Extension == nil {
+		return nil, fmt.Errorf("transaction type %d requires an extension", t.Type)
+	}
+	ext, err := t.Extension.EncodeRLPFields()
+	if err != nil {
+		return nil, err
+	}
+	base := t
+	base.Type = def.Base
+	base.Extension = nil
+	bin, err := base.EncodeRLP()
+	if err != nil {
+		return nil, err
+	}
+	fields, err := decodeTxFields(bin[1:])
+	if err != nil {
+		return nil, err
+	}
+	n := len(fields) - 3 // Extension fields are placed before the signature.
+	items := make([]rlp.Item, 0, len(fields)+len(ext))
+	for _, f := range fields[:n] {
+		items = append(items, f)
+	}
+	items = append(items, ext...)
+	for _, f := range fields[n:] {
+		items = append(items, f)
+	}
+	return encodeTxFields(t.Type, items)
+}
+
+// decodeCustomRLP decodes a transaction of a registered custom type.
+func (t *Transaction) decodeCustomRLP(def CustomTransactionType, data []byte) (int, error) {
+	typ := TransactionType(data[0])
+	list, n, err := rlp.Decode(data[1:])
+	if err != nil {
+		return 0, err
+	}
+	fields, err := list.GetList()
+	if err != nil {
+		return 0, err
+	}
+	count := baseTxFields(def.Base)
+	if len(fields) < count {
+		return 0, fmt.Errorf("transaction type %d: expected at least %d fields, got %d", typ, count, len(fields))
+	}
+	sig := len(fields) - 3
+	items := make([]rlp.Item, 0, count)
+	for _, f := range fields[:count-3] {
+		items = append(items, f)
+	}
+	for _, f := range fields[sig:] {
+		items = append(items, f)
+	}
+	bin, err := encodeTxFields(def.Base, items)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := t.DecodeRLP(bin); err != nil {
+		return 0, err
+	}
+	ext := def.NewExtension()
+	if err := ext.DecodeRLPFields(fields[count-3 : sig]); err != nil {
+		return 0, err
+	}
+	t.Type = typ
+	t.Extension = ext
+	return n + 1, nil
+}
+
+// mergeExtensionJSON adds the extension fields to the JSON object of
+// a transaction. If withType is true, the transaction type is added as well.
+func (t Transaction) mergeExtensionJSON(data []byte, withType bool) ([]byte, error) {
+	if t.Extension == nil {
+		return data, nil
+	}
+	ext, err := jsoncodec.Marshal(t.Extension)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]json.RawMessage{}
+	if err := jsoncodec.Unmarshal(ext, &fields); err != nil {
+		return nil, fmt.Errorf("transaction extension must be a JSON object: %w", err)
+	}
+	if err := jsoncodec.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if withType {
+		typ, err := jsoncodec.Marshal(NumberFromUint64(uint64(t.Type)))
+		if err != nil {
+			return nil, err
+		}
+		fields["type"] = typ
+	}
+	return jsoncodec.Marshal(fields)
+}
+
+// unmarshalExtensionJSON decodes the extension fields if the transaction type
+// is a registered custom type.
+func (t *Transaction) unmarshalExtensionJSON(data []byte) error {
+	def, ok := LookupTransactionType(t.Type)
+	if !ok {
+		return nil
+	}
+	ext := def.NewExtension()
+	if err := jsoncodec.Unmarshal(data, ext); err != nil {
+		return err
+	}
+	t.Extension = ext
+	return nil
+}
+
+// baseTxFields returns the number of fields, including the signature, of
+// the standard transaction types that can be extended. It returns 0 for
+// other types.
+func baseTxFields(typ TransactionType) int {
+	switch typ {
+	case AccessListTxType:
+		return 11
+	case DynamicFeeTxType:
+		return 12
+	case SetCodeTxType:
+		return 13
+	default:
+		return 0
+	}
+}
+
+func decodeTxFields(data []byte) ([]*rlp.RLP, error) {
+	list, _, err := rlp.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	return list.GetList()
+}
+
+func encodeTxFields[T rlp.Item](typ TransactionType, fields []T) ([]byte, error) {
+	list := rlp.NewList()
+	for _, f := range fields {
+		list.Append(f)
+	}
+	bin, err := list.EncodeRLP()
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{byte(typ)}, bin...), nil
+}
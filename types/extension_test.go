@@ -0,0 +1,186 @@
+package types
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/defiweb/go-rlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/hexutil"
+	"github.com/defiweb/go-eth/jsoncodec"
+)
+
+const feeCurrencyTxType TransactionType = 0x7b
+
+// feeCurrencyExtension implements the fee currency field of CIP-64
+// transactions used by Celo.
+type feeCurrencyExtension struct {
+	FeeCurrency *Address `json:"feeCurrency,omitempty"`
+}
+
+func (e *feeCurrencyExtension) EncodeRLPFields() ([]rlp.Item, error) {
+	var feeCurrency []byte
+	if e.FeeCurrency != nil {
+		feeCurrency = e.FeeCurrency.Bytes()
+	}
+	return []rlp.Item{rlp.NewBytes(feeCurrency)}, nil
+}
+
+func (e *feeCurrencyExtension) DecodeRLPFields(fields []*rlp.RLP) error {
+	if len(fields) != 1 {
+		return errors.New("expected one field")
+	}
+	feeCurrency, err := fields[0].GetBytes()
+	if err != nil {
+		return err
+	}
+	e.FeeCurrency = AddressFromBytesPtr(feeCurrency)
+	return nil
+}
+
+func (e *feeCurrencyExtension) CopyExtension() TransactionExtension {
+	c := &feeCurrencyExtension{}
+	if e.FeeCurrency != nil {
+		feeCurrency := *e.FeeCurrency
+		c.FeeCurrency = &feeCurrency
+	}
+	return c
+}
+
+func registerFeeCurrencyTxType(t *testing.T) {
+	require.NoError(t, RegisterTransactionType(feeCurrencyTxType, CustomTransactionType{
+		Name:         "cip64",
+		Base:         DynamicFeeTxType,
+		NewExtension: func() TransactionExtension { return &feeCurrencyExtension{} },
+	}))
+	t.Cleanup(func() { UnregisterTransactionType(feeCurrencyTxType) })
+}
+
+func feeCurrencyTx() *Transaction {
+	feeCurrency := MustAddressFromHex("0x3333333333333333333333333333333333333333")
+	tx := (&Transaction{}).
+		SetType(feeCurrencyTxType).
+		SetTo(MustAddressFromHex("0x2222222222222222222222222222222222222222")).
+		SetGasLimit(100000).
+		SetMaxPriorityFeePerGas(big.NewInt(1000000000)).
+		SetMaxFeePerGas(big.NewInt(2000000000)).
+		SetInput([]byte{1, 2, 3, 4}).
+		SetNonce(1).
+		SetChainID(42220).
+		SetValue(big.NewInt(1000000000000000000)).
+		SetSignature(MustSignatureFromHex("0xa3a7b12762dbc5df6cfbedbecdf8a821929c6112d2634abbb0d99dc63ad914908051b2c8c7d159db49ad19bd01026156eedab2f3d8c1dfdd07d21c07a4bbdd8401"))
+	tx.Extension = &feeCurrencyExtension{FeeCurrency: &feeCurrency}
+	return tx
+}
+
+func TestRegisterTransactionType(t *testing.T) {
+	newExt := func() TransactionExtension { return &feeCurrencyExtension{} }
+	assert.Error(t, RegisterTransactionType(DynamicFeeTxType, CustomTransactionType{Base: DynamicFeeTxType, NewExtension: newExt}))
+	assert.Error(t, RegisterTransactionType(0x80, CustomTransactionType{Base: DynamicFeeTxType, NewExtension: newExt}))
+	assert.Error(t, RegisterTransactionType(0x7c, CustomTransactionType{Base: LegacyTxType, NewExtension: newExt}))
+	assert.Error(t, RegisterTransactionType(0x7c, CustomTransactionType{Base: DynamicFeeTxType}))
+	_, ok := LookupTransactionType(0x7c)
+	assert.False(t, ok)
+
+	registerFeeCurrencyTxType(t)
+	def, ok := LookupTransactionType(feeCurrencyTxType)
+	require.True(t, ok)
+	assert.Equal(t, DynamicFeeTxType, def.Base)
+
+	// Registered names are used by String and ParseTransactionType.
+	assert.Equal(t, "cip64", feeCurrencyTxType.String())
+	typ, err := ParseTransactionType("CIP64")
+	require.NoError(t, err)
+	assert.Equal(t, feeCurrencyTxType, typ)
+	assert.Error(t, RegisterTransactionType(0x7c, CustomTransactionType{Name: "dynamic-fee", Base: DynamicFeeTxType, NewExtension: newExt}))
+	assert.Error(t, RegisterTransactionType(0x7c, CustomTransactionType{Name: "cip64", Base: DynamicFeeTxType, NewExtension: newExt}))
+
+	UnregisterTransactionType(feeCurrencyTxType)
+	_, ok = LookupTransactionType(feeCurrencyTxType)
+	assert.False(t, ok)
+}
+
+func TestTransactionExtension_RLP(t *testing.T) {
+	registerFeeCurrencyTxType(t)
+	tx := feeCurrencyTx()
+
+	bin, err := tx.EncodeRLP()
+	require.NoError(t, err)
+	require.Equal(t, byte(feeCurrencyTxType), bin[0])
+
+	// The fee currency must be placed after the access list, before
+	// the signature.
+	fields, err := decodeTxFields(bin[1:])
+	require.NoError(t, err)
+	require.Len(t, fields, 13)
+	feeCurrency, err := fields[9].GetBytes()
+	require.NoError(t, err)
+	assert.Equal(t, hexutil.MustHexToBytes("0x3333333333333333333333333333333333333333"), feeCurrency)
+
+	decoded := &Transaction{}
+	n, err := decoded.DecodeRLP(bin)
+	require.NoError(t, err)
+	assert.Equal(t, len(bin), n)
+	assert.Equal(t, tx, decoded)
+
+	// The unsigned encoding must not contain the signature.
+	unsigned, err := tx.EncodeUnsignedRLP()
+	require.NoError(t, err)
+	fields, err = decodeTxFields(unsigned[1:])
+	require.NoError(t, err)
+	assert.Len(t, fields, 10)
+}
+
+func TestTransactionExtension_MissingExtension(t *testing.T) {
+	registerFeeCurrencyTxType(t)
+	tx := feeCurrencyTx()
+	tx.Extension = nil
+	_, err := tx.EncodeRLP()
+	assert.Error(t, err)
+}
+
+func TestTransactionExtension_JSON(t *testing.T) {
+	registerFeeCurrencyTxType(t)
+	tx := feeCurrencyTx()
+
+	bin, err := jsoncodec.Marshal(tx)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"to": "0x2222222222222222222222222222222222222222",
+		"gas": "0x186a0",
+		"maxPriorityFeePerGas": "0x3b9aca00",
+		"maxFeePerGas": "0x77359400",
+		"input": "0x01020304",
+		"nonce": "0x1",
+		"value": "0xde0b6b3a7640000",
+		"v": "0x1",
+		"r": "0xa3a7b12762dbc5df6cfbedbecdf8a821929c6112d2634abbb0d99dc63ad91490",
+		"s": "0x8051b2c8c7d159db49ad19bd01026156eedab2f3d8c1dfdd07d21c07a4bbdd84",
+		"type": "0x7b",
+		"feeCurrency": "0x3333333333333333333333333333333333333333"
+	}`, string(bin))
+
+	decoded := &Transaction{}
+	require.NoError(t, jsoncodec.Unmarshal(bin, decoded))
+	assert.Equal(t, feeCurrencyTxType, decoded.Type)
+	assert.Equal(t, tx.Extension, decoded.Extension)
+
+	onChain := &OnChainTransaction{Transaction: *tx}
+	bin, err = jsoncodec.Marshal(onChain)
+	require.NoError(t, err)
+	decodedOnChain := &OnChainTransaction{}
+	require.NoError(t, jsoncodec.Unmarshal(bin, decodedOnChain))
+	assert.Equal(t, feeCurrencyTxType, decodedOnChain.Type)
+	assert.Equal(t, tx.Extension, decodedOnChain.Extension)
+}
+
+func TestTransactionExtension_Copy(t *testing.T) {
+	tx := feeCurrencyTx()
+	cpy := tx.Copy()
+	assert.Equal(t, tx, cpy)
+	cpy.Extension.(*feeCurrencyExtension).FeeCurrency[0] = 0
+	assert.NotEqual(t, tx.Extension, cpy.Extension)
+}
@@ -0,0 +1,253 @@
+package types
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/defiweb/go-rlp"
+)
+
+const (
+	// CliqueVanityLength is the length of the vanity prefix of the extra
+	// data of clique blocks.
+	CliqueVanityLength = 32
+
+	// CliqueSealLength is the length of the signature appended to the extra
+	// data of clique blocks.
+	CliqueSealLength = 65
+)
+
+// ExtraDataKind is the detected encoding of the extraData field of a block.
+type ExtraDataKind uint8
+
+const (
+	// UnknownExtraData is extra data in an unrecognized encoding.
+	UnknownExtraData ExtraDataKind = iota
+
+	// EmptyExtraData is empty extra data.
+	EmptyExtraData
+
+	// TextExtraData is printable text, usually the name of the block
+	// builder or of the mining pool, e.g. "beaverbuild.org".
+	TextExtraData
+
+	// ClientVersionExtraData is an RLP encoded client version, which is the
+	// default extra data of geth and clients derived from it.
+	ClientVersionExtraData
+
+	// CliqueExtraData is the extra data of a clique proof-of-authority
+	// block: a vanity prefix, the list of signers on checkpoint blocks and
+	// the seal of the block signer.
+	CliqueExtraData
+)
+
+// String implements the fmt.Stringer interface.
+func (k ExtraDataKind) String() string {
+	switch k {
+	case UnknownExtraData:
+		return "unknown"
+	case EmptyExtraData:
+		return "empty"
+	case TextExtraData:
+		return "text"
+	case ClientVersionExtraData:
+		return "client-version"
+	case CliqueExtraData:
+		return "clique"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(k))
+	}
+}
+
+// ExtraData is the decoded extraData field of a block.
+type ExtraData struct {
+	Kind   ExtraDataKind  // Kind is the detected encoding.
+	Raw    []byte         // Raw is the undecoded extra data.
+	Text   string         // Text is the text, for TextExtraData, or the printable part of the clique vanity.
+	Client *ClientVersion // Client is the client version, for ClientVersionExtraData.
+	Clique *CliqueExtra   // Clique is the clique extra data, for CliqueExtraData.
+}
+
+// ClientVersion is the client version encoded in the extra data by geth
+// and clients derived from it.
+type ClientVersion struct {
+	Name    string // Name is the client name, e.g. "geth".
+	Major   uint64 // Major is the major version.
+	Minor   uint64 // Minor is the minor version.
+	Patch   uint64 // Patch is the patch version.
+	Runtime string // Runtime is the runtime version, e.g. "go1.21.4".
+	OS      string // OS is the operating system, e.g. "linux".
+}
+
+// String returns the version in the format "name/vX.Y.Z/os/runtime".
+func (c ClientVersion) String() string {
+	return fmt.Sprintf("%s/v%d.%d.%d/%s/%s", c.Name, c.Major, c.Minor, c.Patch, c.OS, c.Runtime)
+}
+
+// CliqueExtra is the extra data of a clique proof-of-authority block.
+//
+// The signer of a block is recovered from the seal, which signs the hash
+// of the header with the seal removed from the extra data. The Miner field
+// of clique blocks is not the signer.
+type CliqueExtra struct {
+	Vanity  [CliqueVanityLength]byte // Vanity is the vanity prefix, freely set by the signer.
+	Signers []Address                // Signers is the list of authorized signers, only present on checkpoint blocks.
+	Seal    Signature                // Seal is the signature of the block signer.
+}
+
+// DecodeCliqueExtraData decodes the extra data of a clique block.
+func DecodeCliqueExtraData(data []byte) (*CliqueExtra, error) {
+	if len(data) < CliqueVanityLength+CliqueSealLength {
+		return nil, errors.New("clique extra data too short")
+	}
+	signers := data[CliqueVanityLength : len(data)-CliqueSealLength]
+	if len(signers)%AddressLength != 0 {
+		return nil, errors.New("invalid clique signer list length")
+	}
+	c := &CliqueExtra{}
+	copy(c.Vanity[:], data[:CliqueVanityLength])
+	for i := 0; i < len(signers); i += AddressLength {
+		c.Signers = append(c.Signers, MustAddressFromBytes(signers[i:i+AddressLength]))
+	}
+	seal, err := SignatureFromBytes(data[len(data)-CliqueSealLength:])
+	if err != nil {
+		return nil, err
+	}
+	c.Seal = seal
+	return c, nil
+}
+
+// DecodeClientVersionExtraData decodes the RLP encoded client version
+// used as the default extra data by geth, which is a list of the version
+// packed as major<<16 | minor<<8 | patch, the client name, the runtime
+// version and the operating system.
+func DecodeClientVersionExtraData(data []byte) (*ClientVersion, error) {
+	d, n, err := rlp.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if n != len(data) {
+		return nil, errors.New("unexpected data after client version")
+	}
+	l, err := d.GetList()
+	if err != nil {
+		return nil, err
+	}
+	if len(l) != 4 {
+		return nil, errors.New("invalid client version list length")
+	}
+	version, err := l[0].GetUint()
+	if err != nil {
+		return nil, err
+	}
+	c := &ClientVersion{
+		Major: version >> 16,
+		Minor: (version >> 8) & 0xff,
+		Patch: version & 0xff,
+	}
+	for i, dst := range []*string{&c.Name, &c.Runtime, &c.OS} {
+		s, err := l[i+1].GetString()
+		if err != nil {
+			return nil, err
+		}
+		if !isPrintable(s) {
+			return nil, errors.New("client version contains non-printable characters")
+		}
+		*dst = s
+	}
+	return c, nil
+}
+
+// DecodeExtraData detects the encoding of the extra data of a block and
+// decodes it. Extra data that does not match any known encoding is
+// returned with the UnknownExtraData kind.
+//
+// Extra data longer than 32 bytes is only allowed on clique chains, so it
+// is decoded as clique extra data if its length matches.
+func DecodeExtraData(data []byte) ExtraData {
+	e := ExtraData{Kind: UnknownExtraData, Raw: data}
+	if len(data) == 0 {
+		e.Kind = EmptyExtraData
+		return e
+	}
+	if len(data) >= CliqueVanityLength+CliqueSealLength {
+		if c, err := DecodeCliqueExtraData(data); err == nil {
+			e.Kind = CliqueExtraData
+			e.Clique = c
+			e.Text = printablePrefix(c.Vanity[:])
+			return e
+		}
+	}
+	if c, err := DecodeClientVersionExtraData(data); err == nil {
+		e.Kind = ClientVersionExtraData
+		e.Client = c
+		return e
+	}
+	if text := string(bytes.TrimRight(data, "\x00")); text != "" && isPrintable(text) {
+		e.Kind = TextExtraData
+		e.Text = text
+		return e
+	}
+	return e
+}
+
+// ProposerInfo is the information about the proposer of a block available
+// in the block header.
+type ProposerInfo struct {
+	// FeeRecipient is the address that received the priority fees, which is
+	// the Miner field of the block. After the merge, it is usually the
+	// address of the block builder.
+	FeeRecipient Address
+
+	// Name is the name of the builder, mining pool or client, taken from the
+	// extra data. It is empty if the extra data contains no name.
+	Name string
+
+	// ExtraData is the decoded extra data.
+	ExtraData ExtraData
+}
+
+// ProposerInfo returns the information about the proposer of the block.
+func (b *Block) ProposerInfo() ProposerInfo {
+	p := ProposerInfo{
+		FeeRecipient: b.Miner,
+		ExtraData:    DecodeExtraData(b.ExtraData),
+	}
+	switch p.ExtraData.Kind {
+	case TextExtraData, CliqueExtraData:
+		p.Name = strings.TrimSpace(p.ExtraData.Text)
+	case ClientVersionExtraData:
+		p.Name = p.ExtraData.Client.Name
+	}
+	return p
+}
+
+// printablePrefix returns the longest prefix of b that is printable text,
+// with trailing spaces and zero bytes removed.
+func printablePrefix(b []byte) string {
+	end := 0
+	for end < len(b) {
+		r, size := utf8.DecodeRune(b[end:])
+		if r == utf8.RuneError || !unicode.IsPrint(r) {
+			break
+		}
+		end += size
+	}
+	return strings.TrimRight(string(b[:end]), " ")
+}
+
+func isPrintable(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
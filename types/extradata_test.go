@@ -0,0 +1,85 @@
+package types
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/hexutil"
+)
+
+func TestDecodeExtraData(t *testing.T) {
+	tests := []struct {
+		data   string
+		kind   ExtraDataKind
+		text   string
+		client *ClientVersion
+	}{
+		{data: "0x", kind: EmptyExtraData},
+		{data: "0x6265617665726275696c642e6f7267", kind: TextExtraData, text: "beaverbuild.org"},
+		{data: "0x546974616e2028746974616e6275696c6465722e78797a2900", kind: TextExtraData, text: "Titan (titanbuilder.xyz)"},
+		{
+			data:   "0xd883010d05846765746888676f312e32312e34856c696e7578",
+			kind:   ClientVersionExtraData,
+			client: &ClientVersion{Name: "geth", Major: 1, Minor: 13, Patch: 5, Runtime: "go1.21.4", OS: "linux"},
+		},
+		{data: "0x01ff02fe", kind: UnknownExtraData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			e := DecodeExtraData(hexutil.MustHexToBytes(tt.data))
+			assert.Equal(t, tt.kind, e.Kind)
+			assert.Equal(t, tt.text, e.Text)
+			assert.Equal(t, tt.client, e.Client)
+		})
+	}
+}
+
+func TestDecodeCliqueExtraData(t *testing.T) {
+	signer1 := MustAddressFromHex("0x1111111111111111111111111111111111111111")
+	signer2 := MustAddressFromHex("0x2222222222222222222222222222222222222222")
+	vanity := make([]byte, CliqueVanityLength)
+	copy(vanity, "my node")
+	seal := bytes.Repeat([]byte{0xab}, CliqueSealLength)
+	seal[64] = 1
+
+	t.Run("checkpoint", func(t *testing.T) {
+		data := append(append(append([]byte{}, vanity...), append(signer1.Bytes(), signer2.Bytes()...)...), seal...)
+		e := DecodeExtraData(data)
+		require.Equal(t, CliqueExtraData, e.Kind)
+		assert.Equal(t, "my node", e.Text)
+		assert.Equal(t, []Address{signer1, signer2}, e.Clique.Signers)
+		assert.Equal(t, seal, e.Clique.Seal.Bytes())
+	})
+	t.Run("regular", func(t *testing.T) {
+		c, err := DecodeCliqueExtraData(append(append([]byte{}, vanity...), seal...))
+		require.NoError(t, err)
+		assert.Empty(t, c.Signers)
+		assert.Equal(t, uint64(1), c.Seal.V.Uint64())
+	})
+	t.Run("invalid signers", func(t *testing.T) {
+		_, err := DecodeCliqueExtraData(append(append(append([]byte{}, vanity...), 1, 2, 3), seal...))
+		assert.Error(t, err)
+	})
+	t.Run("too short", func(t *testing.T) {
+		_, err := DecodeCliqueExtraData(vanity)
+		assert.Error(t, err)
+	})
+}
+
+func TestBlock_ProposerInfo(t *testing.T) {
+	miner := MustAddressFromHex("0x95222290dd7278aa3ddd389cc1e1d165cc4bafe5")
+	b := &Block{Miner: miner, ExtraData: []byte("beaverbuild.org")}
+	p := b.ProposerInfo()
+	assert.Equal(t, miner, p.FeeRecipient)
+	assert.Equal(t, "beaverbuild.org", p.Name)
+
+	b.ExtraData = hexutil.MustHexToBytes("0xd883010d05846765746888676f312e32312e34856c696e7578")
+	assert.Equal(t, "geth", b.ProposerInfo().Name)
+	assert.Equal(t, "geth/v1.13.5/linux/go1.21.4", b.ProposerInfo().ExtraData.Client.String())
+
+	b.ExtraData = []byte{0x01, 0xff}
+	assert.Empty(t, b.ProposerInfo().Name)
+}
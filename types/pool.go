@@ -0,0 +1,213 @@
+package types
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync"
+
+	"github.com/defiweb/go-eth/jsoncodec"
+)
+
+// The pools below allow reusing OnChainTransaction, TransactionReceipt and
+// Log objects to reduce GC pressure when decoding large numbers of
+// historical objects, e.g. in indexers. Using them is optional.
+//
+// The Acquire functions take an object from the pool and decode the given
+// JSON into it. Slices, big.Ints and pointers of previously released
+// objects are reused instead of being allocated again. Once the object is
+// no longer used, it should be returned to the pool with the matching
+// Release function. After an object is released, neither the object nor
+// the slices and pointers obtained from it may be used, because they may
+// be overwritten by a subsequent Acquire call. Only objects obtained from
+// the Acquire functions may be released.
+
+var (
+	onChainTransactionPool = sync.Pool{New: func() any { return new(OnChainTransaction) }}
+	transactionReceiptPool = sync.Pool{New: func() any { return new(TransactionReceipt) }}
+	logPool                = sync.Pool{New: func() any { return new(Log) }}
+	rawMessagesPool        = sync.Pool{New: func() any { return new([]json.RawMessage) }}
+)
+
+// AcquireOnChainTransaction takes an OnChainTransaction from the pool and
+// decodes the JSON-encoded transaction into it.
+func AcquireOnChainTransaction(input []byte) (*OnChainTransaction, error) {
+	tx := onChainTransactionPool.Get().(*OnChainTransaction)
+	if err := tx.unmarshalJSON(input, true); err != nil {
+		onChainTransactionPool.Put(tx)
+		return nil, err
+	}
+	return tx, nil
+}
+
+// ReleaseOnChainTransaction returns the transaction to the pool. It is
+// a no-op if tx is nil.
+func ReleaseOnChainTransaction(tx *OnChainTransaction) {
+	if tx == nil {
+		return
+	}
+	onChainTransactionPool.Put(tx)
+}
+
+// AcquireTransactionReceipt takes a TransactionReceipt from the pool and
+// decodes the JSON-encoded receipt into it. The logs of the receipt are
+// decoded into reused logs as well.
+func AcquireTransactionReceipt(input []byte) (*TransactionReceipt, error) {
+	r := transactionReceiptPool.Get().(*TransactionReceipt)
+	if err := r.unmarshalJSON(input, true); err != nil {
+		transactionReceiptPool.Put(r)
+		return nil, err
+	}
+	return r, nil
+}
+
+// ReleaseTransactionReceipt returns the receipt, including its logs, to the
+// pool. It is a no-op if r is nil.
+func ReleaseTransactionReceipt(r *TransactionReceipt) {
+	if r == nil {
+		return
+	}
+	transactionReceiptPool.Put(r)
+}
+
+// AcquireLog takes a Log from the pool and decodes the JSON-encoded log
+// into it.
+func AcquireLog(input []byte) (*Log, error) {
+	l := logPool.Get().(*Log)
+	if err := l.unmarshalJSON(input, true); err != nil {
+		logPool.Put(l)
+		return nil, err
+	}
+	return l, nil
+}
+
+// ReleaseLog returns the log to the pool. It is a no-op if l is nil.
+func ReleaseLog(l *Log) {
+	if l == nil {
+		return
+	}
+	logPool.Put(l)
+}
+
+// setBig sets *x to the value of n. If reuse is true, an existing big.Int
+// is updated in place and *x is set to nil if n is nil. Otherwise, a new
+// big.Int is allocated and *x is left unchanged if n is nil.
+func setBig(x **big.Int, n *Number, reuse bool) {
+	switch {
+	case n == nil:
+		if reuse {
+			*x = nil
+		}
+	case reuse && *x != nil:
+		(*x).Set(&n.x)
+	default:
+		*x = n.Big()
+	}
+}
+
+// setUint64 works like setBig, but for uint64 values.
+func setUint64(x **uint64, n *Number, reuse bool) {
+	switch {
+	case n == nil:
+		if reuse {
+			*x = nil
+		}
+	case reuse && *x != nil:
+		**x = n.x.Uint64()
+	default:
+		v := n.x.Uint64()
+		*x = &v
+	}
+}
+
+// setSignature works like setBig, but for signatures. The signature is set
+// only if all V, R and S values are present.
+func setSignature(s **Signature, v, r, sv *Number, reuse bool) {
+	switch {
+	case v == nil || r == nil || sv == nil:
+		if reuse {
+			*s = nil
+		}
+	case reuse && *s != nil:
+		setBig(&(*s).V, v, true)
+		setBig(&(*s).R, r, true)
+		setBig(&(*s).S, sv, true)
+	default:
+		*s = SignatureFromVRSPtr(v.Big(), r.Big(), sv.Big())
+	}
+}
+
+// reuseBytes works like Bytes, but it decodes into the existing slice if
+// its capacity is large enough. It is used in the JSON representations of
+// types that can be decoded into pooled objects.
+type reuseBytes []byte
+
+func (b reuseBytes) MarshalJSON() ([]byte, error) {
+	return bytesMarshalJSON(b), nil
+}
+
+func (b *reuseBytes) UnmarshalJSON(input []byte) error {
+	if string(input) == "null" {
+		return nil
+	}
+	h := naiveUnquote(input)
+	if len(h) >= 2 && h[0] == '0' && (h[1] == 'x' || h[1] == 'X') {
+		h = h[2:]
+	}
+	if len(h) == 1 && h[0] == '0' {
+		*b = append((*b)[:0], 0)
+		return nil
+	}
+	if len(h)%2 != 0 {
+		return errors.New("invalid hex string, length must be even")
+	}
+	buf := (*b)[:0]
+	if n := len(h) / 2; buf == nil || cap(buf) < n {
+		buf = make([]byte, n)
+	} else {
+		buf = buf[:n]
+	}
+	if _, err := hex.Decode(buf, h); err != nil {
+		return err
+	}
+	*b = buf
+	return nil
+}
+
+// logList is a list of logs in the JSON representation of a receipt. If
+// reuse is true, the logs are decoded into the existing elements of the
+// list.
+type logList struct {
+	logs  []Log
+	reuse bool
+}
+
+func (l logList) MarshalJSON() ([]byte, error) {
+	return jsoncodec.Marshal(l.logs)
+}
+
+func (l *logList) UnmarshalJSON(input []byte) error {
+	if !l.reuse {
+		return jsoncodec.Unmarshal(input, &l.logs)
+	}
+	raw := rawMessagesPool.Get().(*[]json.RawMessage)
+	defer rawMessagesPool.Put(raw)
+	*raw = (*raw)[:0]
+	if err := jsoncodec.Unmarshal(input, raw); err != nil {
+		return err
+	}
+	logs := l.logs[:0]
+	for _, r := range *raw {
+		if len(logs) < cap(logs) {
+			logs = logs[:len(logs)+1]
+		} else {
+			logs = append(logs, Log{})
+		}
+		if err := logs[len(logs)-1].unmarshalJSON(r, true); err != nil {
+			return err
+		}
+	}
+	l.logs = logs
+	return nil
+}
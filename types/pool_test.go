@@ -0,0 +1,211 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	poolTestLog = `{
+		"address": "0x1111111111111111111111111111111111111111",
+		"topics": ["0x2222222222222222222222222222222222222222222222222222222222222222", "0x3333333333333333333333333333333333333333333333333333333333333333"],
+		"data": "0x44444444444444444444444444444444444444444444444444444444444444445555555555555555555555555555555555555555555555555555555555555555",
+		"blockHash": "0x6666666666666666666666666666666666666666666666666666666666666666",
+		"blockNumber": "0x7",
+		"transactionHash": "0x8888888888888888888888888888888888888888888888888888888888888888",
+		"transactionIndex": "0x9",
+		"logIndex": "0xa",
+		"removed": false
+	}`
+	poolTestPendingLog = `{
+		"address": "0x1111111111111111111111111111111111111111",
+		"topics": ["0x2222222222222222222222222222222222222222222222222222222222222222"],
+		"data": "0x4444",
+		"blockHash": null,
+		"transactionHash": null,
+		"removed": false
+	}`
+	poolTestDynamicFeeTx = `{
+		"type": "0x2",
+		"chainId": "0x1",
+		"nonce": "0x3",
+		"from": "0x2222222222222222222222222222222222222222",
+		"to": "0x3333333333333333333333333333333333333333",
+		"gas": "0x5208",
+		"value": "0x10",
+		"input": "0xa9059cbb",
+		"maxPriorityFeePerGas": "0x1",
+		"maxFeePerGas": "0x2",
+		"accessList": [],
+		"v": "0x1",
+		"r": "0x3",
+		"s": "0x4",
+		"hash": "0x1111111111111111111111111111111111111111111111111111111111111111",
+		"blockHash": "0x5555555555555555555555555555555555555555555555555555555555555555",
+		"blockNumber": "0x6",
+		"transactionIndex": "0x0"
+	}`
+	poolTestLegacyTx = `{
+		"type": "0x0",
+		"nonce": "0x1",
+		"from": "0x2222222222222222222222222222222222222222",
+		"gas": "0x5208",
+		"gasPrice": "0x1",
+		"value": "0x0",
+		"input": "0x",
+		"v": "0x25",
+		"r": "0x1",
+		"s": "0x1"
+	}`
+)
+
+func poolTestReceipt(logs int, status string) string {
+	l := make([]string, logs)
+	for i := range l {
+		l[i] = poolTestLog
+	}
+	return fmt.Sprintf(`{
+		"transactionHash": "0x1111111111111111111111111111111111111111111111111111111111111111",
+		"transactionIndex": "0x1",
+		"blockHash": "0x2222222222222222222222222222222222222222222222222222222222222222",
+		"blockNumber": "0x3",
+		"from": "0x4444444444444444444444444444444444444444",
+		"to": "0x5555555555555555555555555555555555555555",
+		"cumulativeGasUsed": "0x6",
+		"effectiveGasPrice": "0x7",
+		"gasUsed": "0x8",
+		"contractAddress": null,
+		"logs": [%s],
+		"logsBloom": "0x%s"%s
+	}`, strings.Join(l, ","), strings.Repeat("00", 256), status)
+}
+
+func TestPool_Log(t *testing.T) {
+	var want Log
+	require.NoError(t, want.UnmarshalJSON([]byte(poolTestPendingLog)))
+
+	// Decoding into a used log must give the same result as decoding into
+	// a new one, while reusing its buffers.
+	var l Log
+	require.NoError(t, l.unmarshalJSON([]byte(poolTestLog), true))
+	data := &l.Data[0]
+	require.NoError(t, l.unmarshalJSON([]byte(poolTestPendingLog), true))
+	assert.Equal(t, want, l)
+	assert.Nil(t, l.BlockNumber)
+	assert.Nil(t, l.LogIndex)
+	assert.Same(t, data, &l.Data[0])
+
+	got, err := AcquireLog([]byte(poolTestLog))
+	require.NoError(t, err)
+	want = Log{}
+	require.NoError(t, want.UnmarshalJSON([]byte(poolTestLog)))
+	assert.Equal(t, want, *got)
+	ReleaseLog(got)
+
+	_, err = AcquireLog([]byte(`{"data": "0x1"}`))
+	assert.Error(t, err)
+	ReleaseLog(nil)
+}
+
+func TestPool_TransactionReceipt(t *testing.T) {
+	var want TransactionReceipt
+	require.NoError(t, want.UnmarshalJSON([]byte(poolTestReceipt(1, ""))))
+
+	var r TransactionReceipt
+	require.NoError(t, r.unmarshalJSON([]byte(poolTestReceipt(2, `, "status": "0x1"`)), true))
+	assert.Len(t, r.Logs, 2)
+	log := &r.Logs[0]
+	require.NoError(t, r.unmarshalJSON([]byte(poolTestReceipt(1, "")), true))
+	assert.Equal(t, want, r)
+	assert.Nil(t, r.Status)
+	assert.Same(t, log, &r.Logs[0])
+
+	got, err := AcquireTransactionReceipt([]byte(poolTestReceipt(3, `, "status": "0x1"`)))
+	require.NoError(t, err)
+	want = TransactionReceipt{}
+	require.NoError(t, want.UnmarshalJSON([]byte(poolTestReceipt(3, `, "status": "0x1"`))))
+	assert.Equal(t, want, *got)
+	ReleaseTransactionReceipt(got)
+	ReleaseTransactionReceipt(nil)
+}
+
+func TestPool_OnChainTransaction(t *testing.T) {
+	// Reused big.Ints may differ from new ones in their internal
+	// representation, so the JSON encodings are compared.
+	assertTxEqual := func(t *testing.T, input string, tx *OnChainTransaction) {
+		var want OnChainTransaction
+		require.NoError(t, want.UnmarshalJSON([]byte(input)))
+		wantJSON, err := want.MarshalJSON()
+		require.NoError(t, err)
+		gotJSON, err := tx.MarshalJSON()
+		require.NoError(t, err)
+		assert.JSONEq(t, string(wantJSON), string(gotJSON))
+		assert.Equal(t, want.LegacySigningMode, tx.LegacySigningMode)
+	}
+
+	var tx OnChainTransaction
+	require.NoError(t, tx.unmarshalJSON([]byte(poolTestDynamicFeeTx), true))
+	sig := tx.Signature
+	require.NoError(t, tx.unmarshalJSON([]byte(poolTestLegacyTx), true))
+	assertTxEqual(t, poolTestLegacyTx, &tx)
+	assert.Nil(t, tx.MaxFeePerGas)
+	assert.Nil(t, tx.BlockNumber)
+	assert.Equal(t, EIP155LegacySigning, tx.LegacySigningMode)
+	assert.Same(t, sig, tx.Signature)
+
+	got, err := AcquireOnChainTransaction([]byte(poolTestDynamicFeeTx))
+	require.NoError(t, err)
+	assertTxEqual(t, poolTestDynamicFeeTx, got)
+	ReleaseOnChainTransaction(got)
+	ReleaseOnChainTransaction(nil)
+}
+
+func BenchmarkPool_TransactionReceipt(b *testing.B) {
+	data := []byte(poolTestReceipt(4, `, "status": "0x1"`))
+	b.Run("unmarshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var r TransactionReceipt
+			if err := r.UnmarshalJSON(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("pool", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			r, err := AcquireTransactionReceipt(data)
+			if err != nil {
+				b.Fatal(err)
+			}
+			ReleaseTransactionReceipt(r)
+		}
+	})
+}
+
+func BenchmarkPool_OnChainTransaction(b *testing.B) {
+	data := []byte(poolTestDynamicFeeTx)
+	b.Run("unmarshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var tx OnChainTransaction
+			if err := tx.UnmarshalJSON(data); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("pool", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tx, err := AcquireOnChainTransaction(data)
+			if err != nil {
+				b.Fatal(err)
+			}
+			ReleaseOnChainTransaction(tx)
+		}
+	})
+}
@@ -3,11 +3,17 @@ package types
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/defiweb/go-rlp"
+
+	"github.com/defiweb/go-eth/hexutil"
+	"github.com/defiweb/go-eth/jsoncodec"
 )
 
 // Call represents a call to a contract or a contract creation if To is nil.
@@ -155,12 +161,12 @@ func (c Call) MarshalJSON() ([]byte, error) {
 		value := NumberFromBigInt(c.Value)
 		call.Value = &value
 	}
-	return json.Marshal(call)
+	return jsoncodec.Marshal(call)
 }
 
 func (c *Call) UnmarshalJSON(data []byte) error {
 	call := &jsonCall{}
-	if err := json.Unmarshal(data, call); err != nil {
+	if err := jsoncodec.Unmarshal(data, call); err != nil {
 		return err
 	}
 	c.From = call.From
@@ -203,11 +209,116 @@ type TransactionType uint64
 
 // Transaction types.
 const (
-	LegacyTxType TransactionType = iota
-	AccessListTxType
-	DynamicFeeTxType
+	LegacyTxType     TransactionType = 0 // LegacyTxType is a legacy transaction, named "legacy".
+	AccessListTxType TransactionType = 1 // AccessListTxType is an EIP-2930 transaction, named "access-list".
+	DynamicFeeTxType TransactionType = 2 // DynamicFeeTxType is an EIP-1559 transaction, named "dynamic-fee".
+	SetCodeTxType    TransactionType = 4 // SetCodeTxType is an EIP-7702 transaction, named "set-code".
+)
+
+// txTypeNames maps standard transaction types to their names.
+var txTypeNames = map[TransactionType]string{
+	LegacyTxType:     "legacy",
+	AccessListTxType: "access-list",
+	DynamicFeeTxType: "dynamic-fee",
+	SetCodeTxType:    "set-code",
+}
+
+// ParseTransactionType parses a transaction type from its name, as
+// returned by TransactionType.String, or from a decimal or 0x-prefixed hex
+// number. Names of chain-specific types registered with a name using
+// RegisterTransactionType are also recognized. Names are case-insensitive
+// and underscores may be used instead of dashes.
+func ParseTransactionType(s string) (TransactionType, error) {
+	name := strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "_", "-")
+	for typ, n := range txTypeNames {
+		if n == name {
+			return typ, nil
+		}
+	}
+	if typ, ok := lookupTransactionTypeName(name); ok {
+		return typ, nil
+	}
+	var (
+		x   uint64
+		err error
+	)
+	if hexutil.Has0xPrefix(name) {
+		x, err = hexutil.HexToUint64(name)
+	} else {
+		x, err = strconv.ParseUint(name, 10, 64)
+	}
+	if err != nil || x >= 0x80 {
+		return 0, fmt.Errorf("invalid transaction type: %q", s)
+	}
+	return TransactionType(x), nil
+}
+
+// String returns the name of the transaction type, e.g. "dynamic-fee".
+// Types without a name are returned as "unknown(N)".
+func (t TransactionType) String() string {
+	if name, ok := txTypeNames[t]; ok {
+		return name
+	}
+	if def, ok := LookupTransactionType(t); ok && def.Name != "" {
+		return def.Name
+	}
+	return fmt.Sprintf("unknown(%d)", uint64(t))
+}
+
+// MarshalText implements the encoding.TextMarshaler interface. Types
+// without a name are encoded as 0x-prefixed hex numbers.
+func (t TransactionType) MarshalText() ([]byte, error) {
+	s := t.String()
+	if strings.HasPrefix(s, "unknown(") {
+		s = hexutil.Uint64ToHex(uint64(t))
+	}
+	return []byte(s), nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface, see
+// ParseTransactionType.
+func (t *TransactionType) UnmarshalText(input []byte) error {
+	typ, err := ParseTransactionType(string(input))
+	if err != nil {
+		return err
+	}
+	*t = typ
+	return nil
+}
+
+// LegacySigningMode selects the signing hash of a legacy transaction.
+type LegacySigningMode uint8
+
+const (
+	// AutoLegacySigning uses the EIP-155 signing hash if the ChainID field is
+	// set to a non-zero value and the pre-EIP-155 signing hash otherwise.
+	AutoLegacySigning LegacySigningMode = iota
+
+	// HomesteadLegacySigning uses the pre-EIP-155 signing hash, which does
+	// not include the chain ID, even if the ChainID field is set. The V value
+	// of the signature is 27 or 28.
+	HomesteadLegacySigning
+
+	// EIP155LegacySigning uses the EIP-155 signing hash, which includes the
+	// chain ID. The ChainID field must be set. The V value of the signature
+	// is chainID*2+35 or chainID*2+36.
+	EIP155LegacySigning
 )
 
+// String implements the fmt.Stringer interface.
+func (m LegacySigningMode) String() string {
+	switch m {
+	case AutoLegacySigning:
+		return "auto"
+	case HomesteadLegacySigning:
+		return "homestead"
+	case EIP155LegacySigning:
+		return "eip155"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(m))
+	}
+}
+
 // Transaction represents a transaction.
 type Transaction struct {
 	Call
@@ -219,6 +330,15 @@ type Transaction struct {
 
 	// EIP-2930 fields:
 	ChainID *uint64 // ChainID is the chain ID of the transaction.
+
+	// EIP-7702 fields:
+	AuthorizationList AuthorizationList // AuthorizationList is the list of code delegations signed by their authorities.
+
+	// Legacy transaction options:
+	LegacySigningMode LegacySigningMode // LegacySigningMode selects the signing hash of legacy transactions, it is not encoded.
+
+	// Chain-specific fields:
+	Extension TransactionExtension // Extension holds fields of a transaction type registered with RegisterTransactionType.
 }
 
 func NewTransaction() *Transaction {
@@ -290,16 +410,112 @@ func (t *Transaction) SetChainID(chainID uint64) *Transaction {
 	return t
 }
 
+func (t *Transaction) SetAuthorizationList(authorizationList AuthorizationList) *Transaction {
+	t.AuthorizationList = authorizationList
+	return t
+}
+
+func (t *Transaction) SetLegacySigningMode(mode LegacySigningMode) *Transaction {
+	t.LegacySigningMode = mode
+	return t
+}
+
+// UsesEIP155Signing returns true if the signing hash of the transaction
+// includes the chain ID. For legacy transactions, it depends on the
+// LegacySigningMode, typed transactions always include the chain ID.
+//
+// It returns an error if EIP155LegacySigning is selected but the chain ID
+// is not set.
+func (t *Transaction) UsesEIP155Signing() (bool, error) {
+	if t.Type != LegacyTxType {
+		return true, nil
+	}
+	switch t.LegacySigningMode {
+	case AutoLegacySigning:
+		return t.ChainID != nil && *t.ChainID != 0, nil
+	case HomesteadLegacySigning:
+		return false, nil
+	case EIP155LegacySigning:
+		if t.ChainID == nil {
+			return false, fmt.Errorf("chain ID is required for EIP-155 signing")
+		}
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid legacy signing mode: %s", t.LegacySigningMode)
+	}
+}
+
 // Raw returns the raw transaction data that could be sent to the network.
 func (t Transaction) Raw() ([]byte, error) {
 	return t.EncodeRLP()
 }
 
+// FeeCap returns the maximum fee per gas the sender may pay: the max fee
+// per gas, or the gas price for legacy and access list transactions and
+// for transactions without the max fee.
+//
+// It returns nil if the fee is not set.
+func (t *Transaction) FeeCap() *big.Int {
+	if t.Type == LegacyTxType || t.Type == AccessListTxType || t.MaxFeePerGas == nil {
+		return t.GasPrice
+	}
+	return t.MaxFeePerGas
+}
+
+// MaxCost returns the maximum amount of wei the sender must hold to pay for
+// the transaction, calculated as gasLimit * FeeCap() + value. Blob
+// transactions are not supported, so the blob fee is not included.
+//
+// It returns nil if the gas limit or the fee is not set.
+func (t *Transaction) MaxCost() *big.Int {
+	fee := t.FeeCap()
+	if t.GasLimit == nil || fee == nil {
+		return nil
+	}
+	cost := new(big.Int).Mul(fee, new(big.Int).SetUint64(*t.GasLimit))
+	if t.Value != nil {
+		cost.Add(cost, t.Value)
+	}
+	return cost
+}
+
+// IsReplayProtected returns true if the transaction signature is bound to
+// a chain ID, so that the transaction cannot be replayed on other chains.
+//
+// Typed transactions always include the chain ID. Legacy transactions are
+// replay protected only if signed as defined in EIP-155. For signed legacy
+// transactions, the V value of the signature is checked, otherwise the
+// LegacySigningMode and ChainID fields.
+func (t *Transaction) IsReplayProtected() bool {
+	if t.Type != LegacyTxType {
+		return true
+	}
+	if t.Signature != nil && t.Signature.V != nil {
+		return t.Signature.V.Cmp(big.NewInt(35)) >= 0
+	}
+	eip155, err := t.UsesEIP155Signing()
+	return err == nil && eip155
+}
+
+// FindUnprotectedTransactions returns transactions that were signed without
+// replay protection, as reported by IsReplayProtected. It can be used to
+// audit the transaction history of an account.
+func FindUnprotectedTransactions(txs []OnChainTransaction) []OnChainTransaction {
+	var res []OnChainTransaction
+	for _, tx := range txs {
+		if !tx.IsReplayProtected() {
+			res = append(res, tx)
+		}
+	}
+	return res
+}
+
 func (t *Transaction) Copy() *Transaction {
 	var (
 		nonce     *uint64
 		signature *Signature
 		chainID   *uint64
+		extension TransactionExtension
 	)
 	if t.Nonce != nil {
 		nonce = new(uint64)
@@ -312,12 +528,18 @@ func (t *Transaction) Copy() *Transaction {
 		chainID = new(uint64)
 		*chainID = *t.ChainID
 	}
+	if t.Extension != nil {
+		extension = t.Extension.CopyExtension()
+	}
 	return &Transaction{
-		Call:      *t.Call.Copy(),
-		Type:      t.Type,
-		Nonce:     nonce,
-		Signature: signature,
-		ChainID:   chainID,
+		Call:              *t.Call.Copy(),
+		Type:              t.Type,
+		Nonce:             nonce,
+		Signature:         signature,
+		ChainID:           chainID,
+		AuthorizationList: t.AuthorizationList.Copy(),
+		LegacySigningMode: t.LegacySigningMode,
+		Extension:         extension,
 	}
 }
 
@@ -345,19 +567,38 @@ func (t Transaction) MarshalJSON() ([]byte, error) {
 		transaction.Value = NumberFromBigIntPtr(t.Value)
 	}
 	transaction.AccessList = t.AccessList
+	transaction.AuthorizationList = t.AuthorizationList
 	if t.Signature != nil {
 		transaction.V = NumberFromBigIntPtr(t.Signature.V)
 		transaction.R = NumberFromBigIntPtr(t.Signature.R)
 		transaction.S = NumberFromBigIntPtr(t.Signature.S)
 	}
-	return json.Marshal(transaction)
+	bin, err := jsoncodec.Marshal(transaction)
+	if err != nil {
+		return nil, err
+	}
+	return t.mergeExtensionJSON(bin, true)
 }
 
 func (t *Transaction) UnmarshalJSON(data []byte) error {
 	transaction := &jsonTransaction{}
-	if err := json.Unmarshal(data, transaction); err != nil {
+	if err := jsoncodec.Unmarshal(data, transaction); err != nil {
+		return err
+	}
+	// The transaction type is only used to decode chain-specific fields,
+	// standard transaction types are not included in the JSON encoding.
+	txType := &struct {
+		Type *Number `json:"type"`
+	}{}
+	if err := jsoncodec.Unmarshal(data, txType); err != nil {
 		return err
 	}
+	if txType.Type != nil {
+		typ := TransactionType(txType.Type.Big().Uint64())
+		if _, ok := LookupTransactionType(typ); ok {
+			t.Type = typ
+		}
+	}
 	t.To = transaction.To
 	t.From = transaction.From
 	if transaction.GasLimit != nil {
@@ -382,10 +623,11 @@ func (t *Transaction) UnmarshalJSON(data []byte) error {
 		t.Value = transaction.Value.Big()
 	}
 	t.AccessList = transaction.AccessList
+	t.AuthorizationList = transaction.AuthorizationList
 	if transaction.V != nil && transaction.R != nil && transaction.S != nil {
 		t.Signature = SignatureFromVRSPtr(transaction.V.Big(), transaction.R.Big(), transaction.S.Big())
 	}
-	return nil
+	return t.unmarshalExtensionJSON(data)
 }
 
 //nolint:funlen
@@ -400,6 +642,7 @@ func (t Transaction) EncodeRLP() ([]byte, error) {
 		to                   = ([]byte)(nil)
 		value                = big.NewInt(0)
 		accessList           = (AccessList)(nil)
+		authorizationList    = (AuthorizationList)(nil)
 		v                    = big.NewInt(0)
 		r                    = big.NewInt(0)
 		s                    = big.NewInt(0)
@@ -431,6 +674,9 @@ func (t Transaction) EncodeRLP() ([]byte, error) {
 	if t.AccessList != nil {
 		accessList = t.AccessList
 	}
+	if t.AuthorizationList != nil {
+		authorizationList = t.AuthorizationList
+	}
 	if t.Signature != nil {
 		v = t.Signature.V
 		r = t.Signature.R
@@ -486,7 +732,33 @@ func (t Transaction) EncodeRLP() ([]byte, error) {
 			return nil, err
 		}
 		return append([]byte{byte(t.Type)}, bin...), nil
+	case SetCodeTxType:
+		if t.To == nil {
+			return nil, fmt.Errorf("set code transaction cannot be a contract creation")
+		}
+		bin, err := rlp.NewList(
+			rlp.NewUint(chainID),
+			rlp.NewUint(nonce),
+			rlp.NewBigInt(maxPriorityFeePerGas),
+			rlp.NewBigInt(maxFeePerGas),
+			rlp.NewUint(gasLimit),
+			rlp.NewBytes(to),
+			rlp.NewBigInt(value),
+			rlp.NewBytes(t.Input),
+			&accessList,
+			&authorizationList,
+			rlp.NewBigInt(v),
+			rlp.NewBigInt(r),
+			rlp.NewBigInt(s),
+		).EncodeRLP()
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte{byte(t.Type)}, bin...), nil
 	default:
+		if def, ok := LookupTransactionType(t.Type); ok {
+			return t.encodeCustomRLP(def)
+		}
 		return nil, fmt.Errorf("unknown transaction type: %d", t.Type)
 	}
 }
@@ -508,6 +780,7 @@ func (t *Transaction) DecodeRLP(data []byte) (int, error) {
 		value                = &rlp.BigIntItem{}
 		input                = &rlp.StringItem{}
 		accessList           = &AccessList{}
+		authorizationList    = &AuthorizationList{}
 		v                    = &rlp.BigIntItem{}
 		r                    = &rlp.BigIntItem{}
 		s                    = &rlp.BigIntItem{}
@@ -559,13 +832,44 @@ func (t *Transaction) DecodeRLP(data []byte) (int, error) {
 			r,
 			s,
 		)
+	case data[0] == byte(SetCodeTxType):
+		t.Type = SetCodeTxType
+		data = data[1:]
+		list = rlp.NewList(
+			chainID,
+			nonce,
+			maxPriorityFeePerGas,
+			maxFeePerGas,
+			gasLimit,
+			to,
+			value,
+			input,
+			accessList,
+			authorizationList,
+			v,
+			r,
+			s,
+		)
 	default:
+		if def, ok := LookupTransactionType(TransactionType(data[0])); ok {
+			return t.decodeCustomRLP(def, data)
+		}
 		return 0, fmt.Errorf("invalid transaction type: %d", data[0])
 	}
 	if _, err := rlp.DecodeTo(data, list); err != nil {
 		return 0, err
 	}
 	t.ChainID = &chainID.X
+	if t.Type == LegacyTxType {
+		// Legacy transactions do not contain the chain ID, it is derived
+		// from the V value as defined in EIP-155.
+		mode, id, err := legacySigningModeFromV(v.X, r.X, s.X)
+		if err != nil {
+			return 0, err
+		}
+		t.ChainID = id
+		t.LegacySigningMode = mode
+	}
 	t.Nonce = &nonce.X
 	t.GasPrice = gasPrice.X
 	t.GasLimit = &gasLimit.X
@@ -579,6 +883,9 @@ func (t *Transaction) DecodeRLP(data []byte) (int, error) {
 	if len(*accessList) > 0 {
 		t.AccessList = *accessList
 	}
+	if len(*authorizationList) > 0 {
+		t.AuthorizationList = *authorizationList
+	}
 	if v.X.Sign() != 0 || r.X.Sign() != 0 || s.X.Sign() != 0 {
 		t.Signature = &Signature{
 			V: v.X,
@@ -589,6 +896,35 @@ func (t *Transaction) DecodeRLP(data []byte) (int, error) {
 	return len(data), nil
 }
 
+// legacySigningModeFromV returns the signing mode and the chain ID of a
+// legacy transaction implied by the V value of its signature. Unsigned
+// transactions, with V, R and S set to zero, use AutoLegacySigning.
+//
+// Some chains, e.g. L2s for system transactions and chains that predate
+// EIP-155, use V values other than 27, 28 or 35 and above. Such values do
+// not imply a signing mode, so AutoLegacySigning is used as well, rather
+// than failing to decode the whole block.
+func legacySigningModeFromV(v, r, s *big.Int) (LegacySigningMode, *uint64, error) {
+	if v == nil || v.Sign() == 0 {
+		if (r == nil || r.Sign() == 0) && (s == nil || s.Sign() == 0) {
+			return AutoLegacySigning, nil, nil
+		}
+		return 0, nil, fmt.Errorf("invalid legacy transaction signature: V is zero")
+	}
+	if !v.IsUint64() {
+		return 0, nil, fmt.Errorf("invalid legacy transaction signature: V is too large")
+	}
+	switch x := v.Uint64(); {
+	case x == 27 || x == 28:
+		return HomesteadLegacySigning, nil, nil
+	case x >= 35:
+		id := (x - 35) / 2
+		return EIP155LegacySigning, &id, nil
+	default:
+		return AutoLegacySigning, nil, nil
+	}
+}
+
 // Hash returns the hash of the transaction (transaction ID).
 func (t Transaction) Hash(h HashFunc) (Hash, error) {
 	raw, err := t.Raw()
@@ -599,19 +935,20 @@ func (t Transaction) Hash(h HashFunc) (Hash, error) {
 }
 
 type jsonTransaction struct {
-	From                 *Address   `json:"from,omitempty"`
-	To                   *Address   `json:"to,omitempty"`
-	GasLimit             *Number    `json:"gas,omitempty"`
-	GasPrice             *Number    `json:"gasPrice,omitempty"`
-	MaxFeePerGas         *Number    `json:"maxFeePerGas,omitempty"`
-	MaxPriorityFeePerGas *Number    `json:"maxPriorityFeePerGas,omitempty"`
-	Input                Bytes      `json:"input,omitempty"`
-	Nonce                *Number    `json:"nonce,omitempty"`
-	Value                *Number    `json:"value,omitempty"`
-	AccessList           AccessList `json:"accessList,omitempty"`
-	V                    *Number    `json:"v,omitempty"`
-	R                    *Number    `json:"r,omitempty"`
-	S                    *Number    `json:"s,omitempty"`
+	From                 *Address          `json:"from,omitempty"`
+	To                   *Address          `json:"to,omitempty"`
+	GasLimit             *Number           `json:"gas,omitempty"`
+	GasPrice             *Number           `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *Number           `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *Number           `json:"maxPriorityFeePerGas,omitempty"`
+	Input                Bytes             `json:"input,omitempty"`
+	Nonce                *Number           `json:"nonce,omitempty"`
+	Value                *Number           `json:"value,omitempty"`
+	AccessList           AccessList        `json:"accessList,omitempty"`
+	AuthorizationList    AuthorizationList `json:"authorizationList,omitempty"`
+	V                    *Number           `json:"v,omitempty"`
+	R                    *Number           `json:"r,omitempty"`
+	S                    *Number           `json:"s,omitempty"`
 }
 
 // OnChainTransaction represents a transaction that is included in a block.
@@ -627,10 +964,13 @@ type OnChainTransaction struct {
 
 type jsonOnChainTransaction struct {
 	jsonTransaction
-	Hash             *Hash   `json:"hash,omitempty"`
-	BlockHash        *Hash   `json:"blockHash,omitempty"`
-	BlockNumber      *Number `json:"blockNumber,omitempty"`
-	TransactionIndex *Number `json:"transactionIndex,omitempty"`
+	Type             *Number    `json:"type,omitempty"`
+	ChainID          *Number    `json:"chainId,omitempty"`
+	Input            reuseBytes `json:"input"`
+	Hash             *Hash      `json:"hash,omitempty"`
+	BlockHash        *Hash      `json:"blockHash,omitempty"`
+	BlockNumber      *Number    `json:"blockNumber,omitempty"`
+	TransactionIndex *Number    `json:"transactionIndex,omitempty"`
 }
 
 func (t OnChainTransaction) MarshalJSON() ([]byte, error) {
@@ -657,11 +997,16 @@ func (t OnChainTransaction) MarshalJSON() ([]byte, error) {
 		transaction.Value = NumberFromBigIntPtr(t.Value)
 	}
 	transaction.AccessList = t.AccessList
+	transaction.AuthorizationList = t.AuthorizationList
 	if t.Signature != nil {
 		transaction.V = NumberFromBigIntPtr(t.Signature.V)
 		transaction.R = NumberFromBigIntPtr(t.Signature.R)
 		transaction.S = NumberFromBigIntPtr(t.Signature.S)
 	}
+	transaction.Type = NumberFromUint64Ptr(uint64(t.Type))
+	if t.ChainID != nil {
+		transaction.ChainID = NumberFromUint64Ptr(*t.ChainID)
+	}
 	transaction.Hash = t.Hash
 	transaction.BlockHash = t.BlockHash
 	if t.BlockNumber != nil {
@@ -670,51 +1015,67 @@ func (t OnChainTransaction) MarshalJSON() ([]byte, error) {
 	if t.TransactionIndex != nil {
 		transaction.TransactionIndex = NumberFromUint64Ptr(*t.TransactionIndex)
 	}
-	return json.Marshal(transaction)
+	bin, err := jsoncodec.Marshal(transaction)
+	if err != nil {
+		return nil, err
+	}
+	return t.mergeExtensionJSON(bin, false)
 }
 
 func (t *OnChainTransaction) UnmarshalJSON(data []byte) error {
+	return t.unmarshalJSON(data, false)
+}
+
+// unmarshalJSON decodes the transaction. If reuse is true, all fields are
+// overwritten and the input, big.Ints and pointers of t are reused.
+func (t *OnChainTransaction) unmarshalJSON(data []byte, reuse bool) error {
 	transaction := &jsonOnChainTransaction{}
-	if err := json.Unmarshal(data, transaction); err != nil {
+	if reuse {
+		transaction.Input = t.Input[:0]
+		t.Type = LegacyTxType
+		t.LegacySigningMode = 0
+		t.Extension = nil
+	}
+	if err := jsoncodec.Unmarshal(data, transaction); err != nil {
 		return err
 	}
 	t.To = transaction.To
 	t.From = transaction.From
-	if transaction.GasLimit != nil {
-		gas := transaction.GasLimit.Big().Uint64()
-		t.GasLimit = &gas
-	}
-	if transaction.GasPrice != nil {
-		t.GasPrice = transaction.GasPrice.Big()
-	}
-	if transaction.MaxFeePerGas != nil {
-		t.MaxFeePerGas = transaction.MaxFeePerGas.Big()
-	}
-	if transaction.MaxPriorityFeePerGas != nil {
-		t.MaxPriorityFeePerGas = transaction.MaxPriorityFeePerGas.Big()
-	}
+	setUint64(&t.GasLimit, transaction.GasLimit, reuse)
+	setBig(&t.GasPrice, transaction.GasPrice, reuse)
+	setBig(&t.MaxFeePerGas, transaction.MaxFeePerGas, reuse)
+	setBig(&t.MaxPriorityFeePerGas, transaction.MaxPriorityFeePerGas, reuse)
 	t.Input = transaction.Input
-	if transaction.Nonce != nil {
-		nonce := transaction.Nonce.Big().Uint64()
-		t.Nonce = &nonce
-	}
-	if transaction.Value != nil {
-		t.Value = transaction.Value.Big()
-	}
+	setUint64(&t.Nonce, transaction.Nonce, reuse)
+	setBig(&t.Value, transaction.Value, reuse)
 	t.AccessList = transaction.AccessList
-	if transaction.V != nil && transaction.R != nil && transaction.S != nil {
-		t.Signature = SignatureFromVRSPtr(transaction.V.Big(), transaction.R.Big(), transaction.S.Big())
+	t.AuthorizationList = transaction.AuthorizationList
+	setSignature(&t.Signature, transaction.V, transaction.R, transaction.S, reuse)
+	if transaction.Type != nil {
+		t.Type = TransactionType(transaction.Type.Big().Uint64())
+	}
+	setUint64(&t.ChainID, transaction.ChainID, reuse)
+	if t.Type == LegacyTxType && t.Signature != nil {
+		// Some nodes return the chain ID also for transactions signed
+		// without replay protection, so the signing mode is taken from the
+		// V value.
+		mode, id, err := legacySigningModeFromV(t.Signature.V, t.Signature.R, t.Signature.S)
+		if err != nil {
+			return err
+		}
+		if id != nil {
+			if t.ChainID != nil && *t.ChainID != *id {
+				return fmt.Errorf("chain ID %d does not match the signature V value", *t.ChainID)
+			}
+			t.ChainID = id
+		}
+		t.LegacySigningMode = mode
 	}
 	t.Hash = transaction.Hash
 	t.BlockHash = transaction.BlockHash
-	if transaction.BlockNumber != nil {
-		t.BlockNumber = transaction.BlockNumber.Big()
-	}
-	if transaction.TransactionIndex != nil {
-		index := transaction.TransactionIndex.Big().Uint64()
-		t.TransactionIndex = &index
-	}
-	return nil
+	setBig(&t.BlockNumber, transaction.BlockNumber, reuse)
+	setUint64(&t.TransactionIndex, transaction.TransactionIndex, reuse)
+	return t.unmarshalExtensionJSON(data)
 }
 
 // AccessList is an EIP-2930 access list.
@@ -812,6 +1173,177 @@ func (a *AccessTuple) DecodeRLP(data []byte) (int, error) {
 	return n, nil
 }
 
+// AuthorizationList is an EIP-7702 authorization list.
+type AuthorizationList []SetCodeAuthorization
+
+// SetCodeAuthorization is an EIP-7702 authorization that allows the
+// authority (the signer) to delegate its code to the given address.
+type SetCodeAuthorization struct {
+	ChainID   uint64     // ChainID is the chain ID for which the authorization is valid, 0 means any chain.
+	Address   Address    // Address is the delegation target, the zero address revokes the delegation.
+	Nonce     uint64     // Nonce is the nonce of the authority at the time the authorization is applied.
+	Signature *Signature // Signature is the authority signature, V is the y-parity (0 or 1).
+}
+
+func (a *AuthorizationList) Copy() AuthorizationList {
+	if a == nil || *a == nil {
+		return nil
+	}
+	c := make(AuthorizationList, len(*a))
+	for i, auth := range *a {
+		c[i] = auth.Copy()
+	}
+	return c
+}
+
+func (a AuthorizationList) EncodeRLP() ([]byte, error) {
+	l := rlp.NewList()
+	for _, auth := range a {
+		auth := auth // Copy value because of loop variable reuse.
+		l.Append(&auth)
+	}
+	return rlp.Encode(l)
+}
+
+func (a *AuthorizationList) DecodeRLP(data []byte) (int, error) {
+	d, n, err := rlp.Decode(data)
+	if err != nil {
+		return 0, err
+	}
+	l, err := d.GetList()
+	if err != nil {
+		return 0, err
+	}
+	for _, item := range l {
+		var auth SetCodeAuthorization
+		if err := item.DecodeTo(&auth); err != nil {
+			return 0, err
+		}
+		*a = append(*a, auth)
+	}
+	return n, nil
+}
+
+func (a *SetCodeAuthorization) Copy() SetCodeAuthorization {
+	var signature *Signature
+	if a.Signature != nil {
+		signature = a.Signature.Copy()
+	}
+	return SetCodeAuthorization{
+		ChainID:   a.ChainID,
+		Address:   a.Address,
+		Nonce:     a.Nonce,
+		Signature: signature,
+	}
+}
+
+func (a SetCodeAuthorization) MarshalJSON() ([]byte, error) {
+	auth := &jsonSetCodeAuthorization{
+		ChainID: NumberFromUint64(a.ChainID),
+		Address: a.Address,
+		Nonce:   NumberFromUint64(a.Nonce),
+	}
+	if a.Signature != nil {
+		auth.YParity = NumberFromBigIntPtr(a.Signature.V)
+		auth.R = NumberFromBigIntPtr(a.Signature.R)
+		auth.S = NumberFromBigIntPtr(a.Signature.S)
+	}
+	return jsoncodec.Marshal(auth)
+}
+
+func (a *SetCodeAuthorization) UnmarshalJSON(data []byte) error {
+	auth := &jsonSetCodeAuthorization{}
+	if err := jsoncodec.Unmarshal(data, auth); err != nil {
+		return err
+	}
+	a.ChainID = auth.ChainID.Big().Uint64()
+	a.Address = auth.Address
+	a.Nonce = auth.Nonce.Big().Uint64()
+	if auth.YParity != nil && auth.R != nil && auth.S != nil {
+		a.Signature = SignatureFromVRSPtr(auth.YParity.Big(), auth.R.Big(), auth.S.Big())
+	}
+	return nil
+}
+
+func (a SetCodeAuthorization) EncodeRLP() ([]byte, error) {
+	var (
+		v = big.NewInt(0)
+		r = big.NewInt(0)
+		s = big.NewInt(0)
+	)
+	if a.Signature != nil {
+		v = a.Signature.V
+		r = a.Signature.R
+		s = a.Signature.S
+	}
+	return rlp.NewList(
+		rlp.NewUint(a.ChainID),
+		&a.Address,
+		rlp.NewUint(a.Nonce),
+		rlp.NewBigInt(v),
+		rlp.NewBigInt(r),
+		rlp.NewBigInt(s),
+	).EncodeRLP()
+}
+
+func (a *SetCodeAuthorization) DecodeRLP(data []byte) (int, error) {
+	var (
+		chainID = &rlp.UintItem{}
+		address = &Address{}
+		nonce   = &rlp.UintItem{}
+		v       = &rlp.BigIntItem{}
+		r       = &rlp.BigIntItem{}
+		s       = &rlp.BigIntItem{}
+	)
+	n, err := rlp.DecodeTo(data, rlp.NewList(chainID, address, nonce, v, r, s))
+	if err != nil {
+		return n, err
+	}
+	a.ChainID = chainID.X
+	a.Address = *address
+	a.Nonce = nonce.X
+	if v.X.Sign() != 0 || r.X.Sign() != 0 || s.X.Sign() != 0 {
+		a.Signature = &Signature{
+			V: v.X,
+			R: r.X,
+			S: s.X,
+		}
+	}
+	return n, nil
+}
+
+type jsonSetCodeAuthorization struct {
+	ChainID Number  `json:"chainId"`
+	Address Address `json:"address"`
+	Nonce   Number  `json:"nonce"`
+	YParity *Number `json:"yParity,omitempty"`
+	R       *Number `json:"r,omitempty"`
+	S       *Number `json:"s,omitempty"`
+}
+
+// DelegationPrefix is the EIP-7702 delegation designation prefix. The code
+// of an EOA that delegated its code to a contract is DelegationPrefix
+// followed by the 20-byte address of the contract.
+var DelegationPrefix = []byte{0xef, 0x01, 0x00}
+
+// DelegationCode returns the EIP-7702 delegation designation code that is set
+// on the authority account after the delegation to the given address.
+func DelegationCode(addr Address) []byte {
+	code := make([]byte, len(DelegationPrefix)+AddressLength)
+	copy(code, DelegationPrefix)
+	copy(code[len(DelegationPrefix):], addr[:])
+	return code
+}
+
+// DelegationFromCode returns the delegation target if the code is an EIP-7702
+// delegation designation.
+func DelegationFromCode(code []byte) (Address, bool) {
+	if len(code) != len(DelegationPrefix)+AddressLength || !bytes.HasPrefix(code, DelegationPrefix) {
+		return Address{}, false
+	}
+	return MustAddressFromBytes(code[len(DelegationPrefix):]), true
+}
+
 // TransactionReceipt represents transaction receipt.
 type TransactionReceipt struct {
 	TransactionHash   Hash     // TransactionHash is the hash of the transaction.
@@ -842,7 +1374,7 @@ func (t TransactionReceipt) MarshalJSON() ([]byte, error) {
 		EffectiveGasPrice: NumberFromBigInt(t.EffectiveGasPrice),
 		GasUsed:           NumberFromUint64(t.GasUsed),
 		ContractAddress:   t.ContractAddress,
-		Logs:              t.Logs,
+		Logs:              logList{logs: t.Logs},
 		LogsBloom:         t.LogsBloom,
 		Root:              t.Root,
 	}
@@ -850,49 +1382,57 @@ func (t TransactionReceipt) MarshalJSON() ([]byte, error) {
 		status := NumberFromUint64(*t.Status)
 		receipt.Status = &status
 	}
-	return json.Marshal(receipt)
+	return jsoncodec.Marshal(receipt)
 }
 
 func (t *TransactionReceipt) UnmarshalJSON(data []byte) error {
+	return t.unmarshalJSON(data, false)
+}
+
+// unmarshalJSON decodes the receipt. If reuse is true, all fields are
+// overwritten and the slices, big.Ints and pointers of t, including its
+// logs, are reused.
+func (t *TransactionReceipt) unmarshalJSON(data []byte, reuse bool) error {
 	receipt := &jsonTransactionReceipt{}
-	if err := json.Unmarshal(data, receipt); err != nil {
+	if reuse {
+		receipt.Logs = logList{logs: t.Logs, reuse: true}
+		receipt.LogsBloom = t.LogsBloom[:0]
+	}
+	if err := jsoncodec.Unmarshal(data, receipt); err != nil {
 		return err
 	}
 	t.TransactionHash = receipt.TransactionHash
-	t.TransactionIndex = receipt.TransactionIndex.Big().Uint64()
+	t.TransactionIndex = receipt.TransactionIndex.x.Uint64()
 	t.BlockHash = receipt.BlockHash
-	t.BlockNumber = receipt.BlockNumber.Big()
+	setBig(&t.BlockNumber, &receipt.BlockNumber, reuse)
 	t.From = receipt.From
 	t.To = receipt.To
-	t.CumulativeGasUsed = receipt.CumulativeGasUsed.Big().Uint64()
-	t.EffectiveGasPrice = receipt.EffectiveGasPrice.Big()
-	t.GasUsed = receipt.GasUsed.Big().Uint64()
+	t.CumulativeGasUsed = receipt.CumulativeGasUsed.x.Uint64()
+	setBig(&t.EffectiveGasPrice, &receipt.EffectiveGasPrice, reuse)
+	t.GasUsed = receipt.GasUsed.x.Uint64()
 	t.ContractAddress = receipt.ContractAddress
-	t.Logs = receipt.Logs
+	t.Logs = receipt.Logs.logs
 	t.LogsBloom = receipt.LogsBloom
 	t.Root = receipt.Root
-	if receipt.Status != nil {
-		status := receipt.Status.Big().Uint64()
-		t.Status = &status
-	}
+	setUint64(&t.Status, receipt.Status, reuse)
 	return nil
 }
 
 type jsonTransactionReceipt struct {
-	TransactionHash   Hash     `json:"transactionHash"`
-	TransactionIndex  Number   `json:"transactionIndex"`
-	BlockHash         Hash     `json:"blockHash"`
-	BlockNumber       Number   `json:"blockNumber"`
-	From              Address  `json:"from"`
-	To                Address  `json:"to"`
-	CumulativeGasUsed Number   `json:"cumulativeGasUsed"`
-	EffectiveGasPrice Number   `json:"effectiveGasPrice"`
-	GasUsed           Number   `json:"gasUsed"`
-	ContractAddress   *Address `json:"contractAddress"`
-	Logs              []Log    `json:"logs"`
-	LogsBloom         Bytes    `json:"logsBloom"`
-	Root              *Hash    `json:"root"`
-	Status            *Number  `json:"status"`
+	TransactionHash   Hash       `json:"transactionHash"`
+	TransactionIndex  Number     `json:"transactionIndex"`
+	BlockHash         Hash       `json:"blockHash"`
+	BlockNumber       Number     `json:"blockNumber"`
+	From              Address    `json:"from"`
+	To                Address    `json:"to"`
+	CumulativeGasUsed Number     `json:"cumulativeGasUsed"`
+	EffectiveGasPrice Number     `json:"effectiveGasPrice"`
+	GasUsed           Number     `json:"gasUsed"`
+	ContractAddress   *Address   `json:"contractAddress"`
+	Logs              logList    `json:"logs"`
+	LogsBloom         reuseBytes `json:"logsBloom"`
+	Root              *Hash      `json:"root,omitempty"`
+	Status            *Number    `json:"status,omitempty"`
 }
 
 type Block struct {
@@ -917,6 +1457,90 @@ type Block struct {
 	Transactions      []OnChainTransaction // Transactions is the list of transactions in the block.
 	TransactionHashes []Hash               // TransactionHashes is the list of transaction hashes in the block.
 	ExtraData         []byte               // ExtraData is the "extra data" field of this block.
+
+	// EIP-1559 fields:
+	BaseFeePerGas *big.Int // BaseFeePerGas is the base fee per gas of the block.
+
+	// EIP-4895 fields:
+	WithdrawalsRoot *Hash        // WithdrawalsRoot is the root hash of the withdrawals trie.
+	Withdrawals     []Withdrawal // Withdrawals is the list of validator withdrawals processed in the block.
+
+	// EIP-4844 and EIP-4788 fields:
+	BlobGasUsed           *uint64 // BlobGasUsed is the total blob gas used by transactions in the block.
+	ExcessBlobGas         *uint64 // ExcessBlobGas is the running total of blob gas consumed in excess of the target.
+	ParentBeaconBlockRoot *Hash   // ParentBeaconBlockRoot is the root of the parent beacon block.
+
+	// EIP-7685 fields:
+	RequestsHash *Hash // RequestsHash is the commitment to the execution layer requests of the block.
+
+	// UncleHeaders is the list of full uncle headers. It is not returned by
+	// the RPC API, but it is required to encode blocks with uncles to RLP,
+	// and it is set when a block is decoded from RLP.
+	UncleHeaders []Block
+
+	// Features is the set of optional fields that were present when the
+	// block was decoded. It allows to distinguish fields that are missing
+	// from fields that are set to zero values, e.g. after the removal of
+	// ommers. It is zero for blocks that were not decoded.
+	Features BlockFeature
+
+	// Extra contains JSON fields not known to this package, in raw JSON
+	// form, e.g. fields added by future forks or chain-specific fields.
+	Extra map[string]json.RawMessage
+
+	// ExtraHeaderFields contains the RLP encoded header fields that follow
+	// the fields known to this package. They are preserved, so that the
+	// header hash of blocks from future forks can still be calculated.
+	ExtraHeaderFields [][]byte
+}
+
+// BlockFeature is a set of optional block fields. Features can be combined
+// using the bitwise OR operator.
+type BlockFeature uint16
+
+const (
+	OmmersFeature           BlockFeature = 1 << iota // OmmersFeature indicates the sha3Uncles and uncles fields.
+	BaseFeeFeature                                   // BaseFeeFeature indicates the EIP-1559 base fee.
+	WithdrawalsFeature                               // WithdrawalsFeature indicates the EIP-4895 withdrawals.
+	BlobGasFeature                                   // BlobGasFeature indicates the EIP-4844 blob gas fields.
+	BeaconRootFeature                                // BeaconRootFeature indicates the EIP-4788 parent beacon block root.
+	RequestsFeature                                  // RequestsFeature indicates the EIP-7685 requests hash.
+	ExecutionWitnessFeature                          // ExecutionWitnessFeature indicates the Verkle execution witness, which is kept in Block.Extra.
+)
+
+// blockFeatureFields maps JSON fields to the features they indicate.
+var blockFeatureFields = map[string]BlockFeature{
+	"sha3Uncles":            OmmersFeature,
+	"uncles":                OmmersFeature,
+	"baseFeePerGas":         BaseFeeFeature,
+	"withdrawalsRoot":       WithdrawalsFeature,
+	"withdrawals":           WithdrawalsFeature,
+	"blobGasUsed":           BlobGasFeature,
+	"excessBlobGas":         BlobGasFeature,
+	"parentBeaconBlockRoot": BeaconRootFeature,
+	"requestsHash":          RequestsFeature,
+	"executionWitness":      ExecutionWitnessFeature,
+}
+
+// Has returns true if all the given features are in the set.
+func (f BlockFeature) Has(features BlockFeature) bool {
+	return f&features == features
+}
+
+// String implements the fmt.Stringer interface.
+func (f BlockFeature) String() string {
+	names := []string{"ommers", "base-fee", "withdrawals", "blob-gas", "beacon-root", "requests", "execution-witness"}
+	var parts []string
+	for i, name := range names {
+		if f&(1<<i) != 0 {
+			parts = append(parts, name)
+			f &^= 1 << i
+		}
+	}
+	if f != 0 {
+		parts = append(parts, fmt.Sprintf("unknown(%d)", uint16(f)))
+	}
+	return strings.Join(parts, "|")
 }
 
 func (b Block) MarshalJSON() ([]byte, error) {
@@ -940,6 +1564,20 @@ func (b Block) MarshalJSON() ([]byte, error) {
 		Timestamp:        NumberFromUint64(uint64(b.Timestamp.Unix())),
 		Uncles:           b.Uncles,
 		ExtraData:        b.ExtraData,
+
+		WithdrawalsRoot:       b.WithdrawalsRoot,
+		Withdrawals:           b.Withdrawals,
+		ParentBeaconBlockRoot: b.ParentBeaconBlockRoot,
+		RequestsHash:          b.RequestsHash,
+	}
+	if b.BaseFeePerGas != nil {
+		block.BaseFeePerGas = NumberFromBigIntPtr(b.BaseFeePerGas)
+	}
+	if b.BlobGasUsed != nil {
+		block.BlobGasUsed = NumberFromUint64Ptr(*b.BlobGasUsed)
+	}
+	if b.ExcessBlobGas != nil {
+		block.ExcessBlobGas = NumberFromUint64Ptr(*b.ExcessBlobGas)
 	}
 	if len(b.Transactions) > 0 {
 		block.Transactions.Objects = b.Transactions
@@ -947,12 +1585,32 @@ func (b Block) MarshalJSON() ([]byte, error) {
 	if len(b.TransactionHashes) > 0 {
 		block.Transactions.Hashes = b.TransactionHashes
 	}
-	return json.Marshal(block)
+	data, err := jsoncodec.Marshal(block)
+	if err != nil {
+		return nil, err
+	}
+	noOmmers := b.Features != 0 && !b.Features.Has(OmmersFeature)
+	if len(b.Extra) == 0 && !noOmmers {
+		return data, nil
+	}
+	fields := make(map[string]json.RawMessage, len(b.Extra))
+	for k, v := range b.Extra {
+		fields[k] = v
+	}
+	if err := jsoncodec.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if noOmmers {
+		// Do not add ommers to blocks that were decoded without them.
+		delete(fields, "sha3Uncles")
+		delete(fields, "uncles")
+	}
+	return jsoncodec.Marshal(fields)
 }
 
 func (b *Block) UnmarshalJSON(data []byte) error {
 	block := &jsonBlock{}
-	if err := json.Unmarshal(data, block); err != nil {
+	if err := jsoncodec.Unmarshal(data, block); err != nil {
 		return err
 	}
 	b.Number = block.Number.Big()
@@ -976,9 +1634,75 @@ func (b *Block) UnmarshalJSON(data []byte) error {
 	b.ExtraData = block.ExtraData
 	b.Transactions = block.Transactions.Objects
 	b.TransactionHashes = block.Transactions.Hashes
+	b.BaseFeePerGas = nil
+	if block.BaseFeePerGas != nil {
+		b.BaseFeePerGas = block.BaseFeePerGas.Big()
+	}
+	b.WithdrawalsRoot = block.WithdrawalsRoot
+	b.Withdrawals = block.Withdrawals
+	b.BlobGasUsed = nil
+	if block.BlobGasUsed != nil {
+		blobGasUsed := block.BlobGasUsed.Big().Uint64()
+		b.BlobGasUsed = &blobGasUsed
+	}
+	b.ExcessBlobGas = nil
+	if block.ExcessBlobGas != nil {
+		excessBlobGas := block.ExcessBlobGas.Big().Uint64()
+		b.ExcessBlobGas = &excessBlobGas
+	}
+	b.ParentBeaconBlockRoot = block.ParentBeaconBlockRoot
+	b.RequestsHash = block.RequestsHash
+	var fields map[string]json.RawMessage
+	if err := jsoncodec.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	b.Features = 0
+	b.Extra = nil
+	for k, v := range fields {
+		if f, ok := blockFeatureFields[k]; ok && !bytes.Equal(v, []byte("null")) {
+			b.Features |= f
+		}
+		if _, ok := knownBlockFields[k]; ok {
+			continue
+		}
+		if b.Extra == nil {
+			b.Extra = make(map[string]json.RawMessage)
+		}
+		b.Extra[k] = v
+	}
 	return nil
 }
 
+var knownBlockFields = map[string]struct{}{
+	"number":                {},
+	"hash":                  {},
+	"parentHash":            {},
+	"stateRoot":             {},
+	"receiptsRoot":          {},
+	"transactionsRoot":      {},
+	"mixHash":               {},
+	"sha3Uncles":            {},
+	"nonce":                 {},
+	"miner":                 {},
+	"logsBloom":             {},
+	"difficulty":            {},
+	"totalDifficulty":       {},
+	"size":                  {},
+	"gasLimit":              {},
+	"gasUsed":               {},
+	"timestamp":             {},
+	"uncles":                {},
+	"extraData":             {},
+	"transactions":          {},
+	"baseFeePerGas":         {},
+	"withdrawalsRoot":       {},
+	"withdrawals":           {},
+	"blobGasUsed":           {},
+	"excessBlobGas":         {},
+	"parentBeaconBlockRoot": {},
+	"requestsHash":          {},
+}
+
 type jsonBlock struct {
 	Number           Number                `json:"number"`
 	Hash             Hash                  `json:"hash"`
@@ -1000,6 +1724,14 @@ type jsonBlock struct {
 	Uncles           []Hash                `json:"uncles"`
 	ExtraData        Bytes                 `json:"extraData"`
 	Transactions     jsonBlockTransactions `json:"transactions"`
+
+	BaseFeePerGas         *Number      `json:"baseFeePerGas,omitempty"`
+	WithdrawalsRoot       *Hash        `json:"withdrawalsRoot,omitempty"`
+	Withdrawals           []Withdrawal `json:"withdrawals,omitempty"`
+	BlobGasUsed           *Number      `json:"blobGasUsed,omitempty"`
+	ExcessBlobGas         *Number      `json:"excessBlobGas,omitempty"`
+	ParentBeaconBlockRoot *Hash        `json:"parentBeaconBlockRoot,omitempty"`
+	RequestsHash          *Hash        `json:"requestsHash,omitempty"`
 }
 
 type jsonBlockTransactions struct {
@@ -1009,9 +1741,9 @@ type jsonBlockTransactions struct {
 
 func (b *jsonBlockTransactions) MarshalJSON() ([]byte, error) {
 	if len(b.Objects) > 0 {
-		return json.Marshal(b.Objects)
+		return jsoncodec.Marshal(b.Objects)
 	}
-	return json.Marshal(b.Hashes)
+	return jsoncodec.Marshal(b.Hashes)
 }
 
 func (b *jsonBlockTransactions) UnmarshalJSON(data []byte) error {
@@ -1019,9 +1751,407 @@ func (b *jsonBlockTransactions) UnmarshalJSON(data []byte) error {
 		return nil
 	}
 	if bytes.IndexByte(data[1:], '{') >= 0 {
-		return json.Unmarshal(data, &b.Objects)
+		return jsoncodec.Unmarshal(data, &b.Objects)
+	}
+	return jsoncodec.Unmarshal(data, &b.Hashes)
+}
+
+// EncodeRLP returns the RLP encoding of the block as used by the devp2p
+// protocol and era files: a list of the header, the transactions, the
+// uncle headers and, after the Shanghai fork, the withdrawals.
+//
+// Transactions must be included in the block. If the block has uncles,
+// their headers must be provided in UncleHeaders.
+func (b Block) EncodeRLP() ([]byte, error) {
+	if len(b.Transactions) == 0 && len(b.TransactionHashes) > 0 {
+		return nil, fmt.Errorf("block transactions are required to encode the block")
+	}
+	if len(b.UncleHeaders) != len(b.Uncles) && len(b.Uncles) > 0 {
+		return nil, fmt.Errorf("uncle headers are required to encode the block")
+	}
+	header, err := b.headerRLP()
+	if err != nil {
+		return nil, err
+	}
+	txs := rlp.NewList()
+	for _, tx := range b.Transactions {
+		raw, err := tx.Transaction.EncodeRLP()
+		if err != nil {
+			return nil, err
+		}
+		if tx.Type == LegacyTxType {
+			// Legacy transactions are embedded as lists, typed
+			// transactions as strings containing the envelope.
+			item := rlp.RLP(raw)
+			txs.Append(&item)
+			continue
+		}
+		txs.Append(rlp.NewBytes(raw))
+	}
+	uncles := rlp.NewList()
+	for _, uncle := range b.UncleHeaders {
+		u, err := uncle.headerRLP()
+		if err != nil {
+			return nil, err
+		}
+		uncles.Append(u)
+	}
+	block := rlp.NewList(header, txs, uncles)
+	if b.WithdrawalsRoot != nil {
+		withdrawals := rlp.NewList()
+		for _, w := range b.Withdrawals {
+			w := w
+			withdrawals.Append(&w)
+		}
+		block.Append(withdrawals)
+	}
+	return block.EncodeRLP()
+}
+
+// DecodeRLP decodes a block encoded with EncodeRLP.
+//
+// Fields that are not part of the encoding, such as Hash, TotalDifficulty
+// and hashes of transactions and uncles, are not set. The block hash can be
+// calculated using HeaderHash.
+func (b *Block) DecodeRLP(data []byte) (int, error) {
+	d, n, err := rlp.Decode(data)
+	if err != nil {
+		return 0, err
+	}
+	l, err := d.GetList()
+	if err != nil {
+		return 0, err
+	}
+	if len(l) != 3 && len(l) != 4 {
+		return 0, fmt.Errorf("invalid block list length %d", len(l))
+	}
+	var block Block
+	if err := block.decodeHeaderRLP(l[0]); err != nil {
+		return 0, err
+	}
+	txs, err := l[1].GetList()
+	if err != nil {
+		return 0, err
+	}
+	for i, item := range txs {
+		raw := item.Bytes()
+		if item.IsString() {
+			if raw, err = item.GetBytes(); err != nil {
+				return 0, err
+			}
+		}
+		var tx OnChainTransaction
+		if _, err := tx.Transaction.DecodeRLP(raw); err != nil {
+			return 0, fmt.Errorf("invalid transaction %d: %w", i, err)
+		}
+		index := uint64(i)
+		tx.TransactionIndex = &index
+		if block.Number != nil {
+			tx.BlockNumber = new(big.Int).Set(block.Number)
+		}
+		block.Transactions = append(block.Transactions, tx)
+	}
+	uncles, err := l[2].GetList()
+	if err != nil {
+		return 0, err
+	}
+	for _, item := range uncles {
+		var uncle Block
+		if err := uncle.decodeHeaderRLP(item); err != nil {
+			return 0, fmt.Errorf("invalid uncle header: %w", err)
+		}
+		block.UncleHeaders = append(block.UncleHeaders, uncle)
+	}
+	if len(l) == 4 {
+		withdrawals, err := l[3].GetList()
+		if err != nil {
+			return 0, err
+		}
+		block.Withdrawals = make([]Withdrawal, len(withdrawals))
+		for i, item := range withdrawals {
+			if err := item.DecodeTo(&block.Withdrawals[i]); err != nil {
+				return 0, fmt.Errorf("invalid withdrawal %d: %w", i, err)
+			}
+		}
+	}
+	block.Size = uint64(n)
+	*b = block
+	return n, nil
+}
+
+// EncodeHeaderRLP returns the RLP encoding of the block header.
+func (b Block) EncodeHeaderRLP() ([]byte, error) {
+	header, err := b.headerRLP()
+	if err != nil {
+		return nil, err
+	}
+	return header.EncodeRLP()
+}
+
+// HeaderHash calculates the hash of the block header, which is the block
+// hash. It can be compared with the Hash field to verify the block.
+func (b Block) HeaderHash(h HashFunc) (Hash, error) {
+	raw, err := b.EncodeHeaderRLP()
+	if err != nil {
+		return Hash{}, err
+	}
+	return h(raw), nil
+}
+
+func (b *Block) headerRLP() (*rlp.ListItem, error) {
+	var (
+		number     = big.NewInt(0)
+		difficulty = big.NewInt(0)
+		bloom      = bloomFromBytes(b.LogsBloom)
+		nonce      = nonceFromBigInt(b.Nonce)
+	)
+	if b.Number != nil {
+		number = b.Number
+	}
+	if b.Difficulty != nil {
+		difficulty = b.Difficulty
+	}
+	header := rlp.NewList(
+		&b.ParentHash,
+		&b.Sha3Uncles,
+		&b.Miner,
+		&b.StateRoot,
+		&b.TransactionsRoot,
+		&b.ReceiptsRoot,
+		rlp.NewBytes(bloom[:]),
+		rlp.NewBigInt(difficulty),
+		rlp.NewBigInt(number),
+		rlp.NewUint(b.GasLimit),
+		rlp.NewUint(b.GasUsed),
+		rlp.NewUint(uint64(b.Timestamp.Unix())),
+		rlp.NewBytes(b.ExtraData),
+		&b.MixHash,
+		rlp.NewBytes(nonce[:]),
+	)
+	// Fields added by forks are appended in order. A field can only be
+	// present if all fields added by earlier forks are present.
+	optional := []struct {
+		name string
+		item rlp.Item
+	}{
+		{"baseFeePerGas", nil},
+		{"withdrawalsRoot", nil},
+		{"blobGasUsed", nil},
+		{"excessBlobGas", nil},
+		{"parentBeaconBlockRoot", nil},
+		{"requestsHash", nil},
+	}
+	if b.BaseFeePerGas != nil {
+		optional[0].item = rlp.NewBigInt(b.BaseFeePerGas)
+	}
+	if b.WithdrawalsRoot != nil {
+		optional[1].item = b.WithdrawalsRoot
+	}
+	if b.BlobGasUsed != nil {
+		optional[2].item = rlp.NewUint(*b.BlobGasUsed)
+	}
+	if b.ExcessBlobGas != nil {
+		optional[3].item = rlp.NewUint(*b.ExcessBlobGas)
+	}
+	if b.ParentBeaconBlockRoot != nil {
+		optional[4].item = b.ParentBeaconBlockRoot
+	}
+	if b.RequestsHash != nil {
+		optional[5].item = b.RequestsHash
+	}
+	missing := ""
+	for _, f := range optional {
+		if f.item == nil {
+			if missing == "" {
+				missing = f.name
+			}
+			continue
+		}
+		if missing != "" {
+			return nil, fmt.Errorf("block header field %s is set but %s is missing", f.name, missing)
+		}
+		header.Append(f.item)
+	}
+	if len(b.ExtraHeaderFields) > 0 && missing != "" {
+		return nil, fmt.Errorf("block header has extra fields but %s is missing", missing)
+	}
+	for _, raw := range b.ExtraHeaderFields {
+		item := rlp.RLP(raw)
+		header.Append(&item)
+	}
+	return header, nil
+}
+
+//nolint:funlen
+func (b *Block) decodeHeaderRLP(r *rlp.RLP) error {
+	l, err := r.GetList()
+	if err != nil {
+		return err
+	}
+	if len(l) < 15 {
+		return fmt.Errorf("invalid block header list length %d", len(l))
+	}
+	b.Features = OmmersFeature
+	for i, dst := range []rlp.Item{
+		0:  &b.ParentHash,
+		1:  &b.Sha3Uncles,
+		2:  &b.Miner,
+		3:  &b.StateRoot,
+		4:  &b.TransactionsRoot,
+		5:  &b.ReceiptsRoot,
+		13: &b.MixHash,
+	} {
+		if dst == nil {
+			continue
+		}
+		if err := l[i].DecodeTo(dst); err != nil {
+			return err
+		}
+	}
+	if b.LogsBloom, err = l[6].GetBytes(); err != nil {
+		return err
+	}
+	if len(b.LogsBloom) != bloomLength {
+		return fmt.Errorf("invalid logs bloom length %d", len(b.LogsBloom))
+	}
+	if b.Difficulty, err = l[7].GetBigInt(); err != nil {
+		return err
+	}
+	if b.Number, err = l[8].GetBigInt(); err != nil {
+		return err
+	}
+	if b.GasLimit, err = l[9].GetUint(); err != nil {
+		return err
+	}
+	if b.GasUsed, err = l[10].GetUint(); err != nil {
+		return err
+	}
+	timestamp, err := l[11].GetUint()
+	if err != nil {
+		return err
+	}
+	b.Timestamp = time.Unix(int64(timestamp), 0)
+	if b.ExtraData, err = l[12].GetBytes(); err != nil {
+		return err
+	}
+	nonce, err := l[14].GetBytes()
+	if err != nil {
+		return err
+	}
+	if len(nonce) != nonceLength {
+		return fmt.Errorf("invalid nonce length %d", len(nonce))
+	}
+	b.Nonce = new(big.Int).SetBytes(nonce)
+	if len(l) > 15 {
+		if b.BaseFeePerGas, err = l[15].GetBigInt(); err != nil {
+			return err
+		}
+		b.Features |= BaseFeeFeature
+	}
+	if len(l) > 16 {
+		b.Features |= WithdrawalsFeature
+		b.WithdrawalsRoot = &Hash{}
+		if err := l[16].DecodeTo(b.WithdrawalsRoot); err != nil {
+			return err
+		}
+	}
+	if len(l) > 17 {
+		blobGasUsed, err := l[17].GetUint()
+		if err != nil {
+			return err
+		}
+		b.BlobGasUsed = &blobGasUsed
+		b.Features |= BlobGasFeature
+	}
+	if len(l) > 18 {
+		excessBlobGas, err := l[18].GetUint()
+		if err != nil {
+			return err
+		}
+		b.ExcessBlobGas = &excessBlobGas
+	}
+	if len(l) > 19 {
+		b.Features |= BeaconRootFeature
+		b.ParentBeaconBlockRoot = &Hash{}
+		if err := l[19].DecodeTo(b.ParentBeaconBlockRoot); err != nil {
+			return err
+		}
+	}
+	if len(l) > 20 {
+		b.Features |= RequestsFeature
+		b.RequestsHash = &Hash{}
+		if err := l[20].DecodeTo(b.RequestsHash); err != nil {
+			return err
+		}
+	}
+	if len(l) > 21 {
+		for _, item := range l[21:] {
+			b.ExtraHeaderFields = append(b.ExtraHeaderFields, item.Bytes())
+		}
+	}
+	return nil
+}
+
+// Withdrawal is a validator withdrawal processed by the execution layer,
+// as defined in EIP-4895.
+type Withdrawal struct {
+	Index          uint64  // Index is the index of the withdrawal, incremented for every withdrawal.
+	ValidatorIndex uint64  // ValidatorIndex is the index of the withdrawing validator.
+	Address        Address // Address is the recipient of the withdrawn ether.
+	Amount         uint64  // Amount is the withdrawn amount in gwei.
+}
+
+func (w Withdrawal) MarshalJSON() ([]byte, error) {
+	return jsoncodec.Marshal(&jsonWithdrawal{
+		Index:          NumberFromUint64(w.Index),
+		ValidatorIndex: NumberFromUint64(w.ValidatorIndex),
+		Address:        w.Address,
+		Amount:         NumberFromUint64(w.Amount),
+	})
+}
+
+func (w *Withdrawal) UnmarshalJSON(data []byte) error {
+	withdrawal := &jsonWithdrawal{}
+	if err := jsoncodec.Unmarshal(data, withdrawal); err != nil {
+		return err
+	}
+	w.Index = withdrawal.Index.Big().Uint64()
+	w.ValidatorIndex = withdrawal.ValidatorIndex.Big().Uint64()
+	w.Address = withdrawal.Address
+	w.Amount = withdrawal.Amount.Big().Uint64()
+	return nil
+}
+
+func (w Withdrawal) EncodeRLP() ([]byte, error) {
+	return rlp.NewList(
+		rlp.NewUint(w.Index),
+		rlp.NewUint(w.ValidatorIndex),
+		&w.Address,
+		rlp.NewUint(w.Amount),
+	).EncodeRLP()
+}
+
+func (w *Withdrawal) DecodeRLP(data []byte) (int, error) {
+	var (
+		index          = &rlp.UintItem{}
+		validatorIndex = &rlp.UintItem{}
+		amount         = &rlp.UintItem{}
+	)
+	n, err := rlp.DecodeTo(data, rlp.NewList(index, validatorIndex, &w.Address, amount))
+	if err != nil {
+		return 0, err
 	}
-	return json.Unmarshal(data, &b.Hashes)
+	w.Index = index.X
+	w.ValidatorIndex = validatorIndex.X
+	w.Amount = amount.X
+	return n, nil
+}
+
+type jsonWithdrawal struct {
+	Index          Number  `json:"index"`
+	ValidatorIndex Number  `json:"validatorIndex"`
+	Address        Address `json:"address"`
+	Amount         Number  `json:"amount"`
 }
 
 // FeeHistory represents the result of the feeHistory Client call.
@@ -1052,12 +2182,12 @@ func (f FeeHistory) MarshalJSON() ([]byte, error) {
 			feeHistory.BaseFeePerGas[i] = NumberFromBigInt(b)
 		}
 	}
-	return json.Marshal(feeHistory)
+	return jsoncodec.Marshal(feeHistory)
 }
 
 func (f *FeeHistory) UnmarshalJSON(input []byte) error {
 	feeHistory := &jsonFeeHistory{}
-	if err := json.Unmarshal(input, feeHistory); err != nil {
+	if err := jsoncodec.Unmarshal(input, feeHistory); err != nil {
 		return err
 	}
 	f.OldestBlock = feeHistory.OldestBlock.Big().Uint64()
@@ -1084,6 +2214,126 @@ type jsonFeeHistory struct {
 	GasUsedRatio  []float64  `json:"gasUsedRatio"`
 }
 
+// ChainConfig represents the result of the eth_config call, as defined in
+// EIP-7910. It describes the fork configuration of the chain as used by the
+// node.
+type ChainConfig struct {
+	Current *ForkConfig `json:"current"` // Current is the configuration of the current fork.
+	Next    *ForkConfig `json:"next"`    // Next is the configuration of the next scheduled fork, or nil if none is scheduled.
+	Last    *ForkConfig `json:"last"`    // Last is the configuration of the last scheduled fork, or nil if none is scheduled.
+}
+
+// At returns the fork configuration active at the given block timestamp.
+// Only the current and the next fork are known, so for timestamps before the
+// activation of the current fork the current fork is returned.
+func (c *ChainConfig) At(timestamp uint64) *ForkConfig {
+	if c.Next != nil && timestamp >= c.Next.ActivationTime {
+		return c.Next
+	}
+	return c.Current
+}
+
+// ForkConfig is the configuration of a single fork.
+type ForkConfig struct {
+	ActivationTime  uint64             // ActivationTime is the timestamp at which the fork is activated.
+	BlobSchedule    *BlobSchedule      // BlobSchedule is the blob configuration, nil before Cancun.
+	ChainID         uint64             // ChainID is the chain ID.
+	ForkID          [4]byte            // ForkID is the EIP-2124 fork hash.
+	Precompiles     map[string]Address // Precompiles maps names of the active precompiles to their addresses.
+	SystemContracts map[string]Address // SystemContracts maps names of the system contracts to their addresses.
+}
+
+// IsPrecompile returns true if the address is an active precompile.
+func (f *ForkConfig) IsPrecompile(addr Address) bool {
+	for _, p := range f.Precompiles {
+		if p == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func (f ForkConfig) MarshalJSON() ([]byte, error) {
+	return jsoncodec.Marshal(&jsonForkConfig{
+		ActivationTime:  f.ActivationTime,
+		BlobSchedule:    f.BlobSchedule,
+		ChainID:         NumberFromUint64(f.ChainID),
+		ForkID:          f.ForkID[:],
+		Precompiles:     f.Precompiles,
+		SystemContracts: f.SystemContracts,
+	})
+}
+
+func (f *ForkConfig) UnmarshalJSON(input []byte) error {
+	forkConfig := &jsonForkConfig{}
+	if err := jsoncodec.Unmarshal(input, forkConfig); err != nil {
+		return err
+	}
+	if len(forkConfig.ForkID) != len(f.ForkID) {
+		return fmt.Errorf("invalid fork ID length: %d", len(forkConfig.ForkID))
+	}
+	chainID := forkConfig.ChainID.Big()
+	if !chainID.IsUint64() {
+		return fmt.Errorf("chain ID is too big")
+	}
+	f.ActivationTime = forkConfig.ActivationTime
+	f.BlobSchedule = forkConfig.BlobSchedule
+	f.ChainID = chainID.Uint64()
+	copy(f.ForkID[:], forkConfig.ForkID)
+	f.Precompiles = forkConfig.Precompiles
+	f.SystemContracts = forkConfig.SystemContracts
+	return nil
+}
+
+// jsonForkConfig is the JSON representation of a fork configuration.
+type jsonForkConfig struct {
+	ActivationTime  uint64             `json:"activationTime"`
+	BlobSchedule    *BlobSchedule      `json:"blobSchedule"`
+	ChainID         Number             `json:"chainId"`
+	ForkID          Bytes              `json:"forkId"`
+	Precompiles     map[string]Address `json:"precompiles"`
+	SystemContracts map[string]Address `json:"systemContracts"`
+}
+
+// BlobSchedule is the blob configuration of a fork, as defined in EIP-7840.
+type BlobSchedule struct {
+	Target                uint64 `json:"target"`                // Target is the target number of blobs per block.
+	Max                   uint64 `json:"max"`                   // Max is the maximum number of blobs per block.
+	BaseFeeUpdateFraction uint64 `json:"baseFeeUpdateFraction"` // BaseFeeUpdateFraction is the blob base fee update fraction.
+}
+
+// MaxBlobsPerTransaction is the maximum number of blobs in a single
+// transaction, as defined in EIP-7594. It applies since the Osaka fork,
+// before that only the limit per block applies.
+const MaxBlobsPerTransaction = 6
+
+// Blob schedules of the Ethereum mainnet forks that changed the blob
+// limits. The Osaka fork uses the Prague schedule.
+var (
+	CancunBlobSchedule = BlobSchedule{Target: 3, Max: 6, BaseFeeUpdateFraction: 3338477}    // CancunBlobSchedule is defined in EIP-4844.
+	PragueBlobSchedule = BlobSchedule{Target: 6, Max: 9, BaseFeeUpdateFraction: 5007716}    // PragueBlobSchedule is defined in EIP-7691.
+	BPO1BlobSchedule   = BlobSchedule{Target: 10, Max: 15, BaseFeeUpdateFraction: 8346193}  // BPO1BlobSchedule is the first blob parameter only fork, see EIP-7892.
+	BPO2BlobSchedule   = BlobSchedule{Target: 14, Max: 21, BaseFeeUpdateFraction: 11684671} // BPO2BlobSchedule is the second blob parameter only fork, see EIP-7892.
+)
+
+// ErrTooManyBlobs is returned by ValidateBlobCount when the number of
+// blobs exceeds the limit.
+var ErrTooManyBlobs = errors.New("too many blobs")
+
+// ValidateBlobCount checks that a transaction with the given number of
+// blobs can be included in a block using the given schedule. If maxPerTx
+// is not zero, the number of blobs is also checked against it. Since the
+// Osaka fork, MaxBlobsPerTransaction should be used.
+func ValidateBlobCount(count uint64, schedule BlobSchedule, maxPerTx uint64) error {
+	if maxPerTx > 0 && count > maxPerTx {
+		return fmt.Errorf("%w: %d blobs exceed the limit of %d blobs per transaction", ErrTooManyBlobs, count, maxPerTx)
+	}
+	if count > schedule.Max {
+		return fmt.Errorf("%w: %d blobs exceed the limit of %d blobs per block", ErrTooManyBlobs, count, schedule.Max)
+	}
+	return nil
+}
+
 // Log represents a contract log event.
 type Log struct {
 	Address          Address  // Address of the contract that generated the event
@@ -1114,44 +2364,46 @@ func (l Log) MarshalJSON() ([]byte, error) {
 		j.LogIndex = NumberFromUint64Ptr(*l.LogIndex)
 	}
 	j.Removed = l.Removed
-	return json.Marshal(j)
+	return jsoncodec.Marshal(j)
 }
 
 func (l *Log) UnmarshalJSON(input []byte) error {
+	return l.unmarshalJSON(input, false)
+}
+
+// unmarshalJSON decodes the log. If reuse is true, all fields are
+// overwritten and the slices, big.Ints and pointers of l are reused.
+func (l *Log) unmarshalJSON(input []byte, reuse bool) error {
 	log := &jsonLog{}
-	if err := json.Unmarshal(input, log); err != nil {
+	if reuse {
+		log.Topics = l.Topics[:0]
+		log.Data = l.Data[:0]
+	}
+	if err := jsoncodec.Unmarshal(input, log); err != nil {
 		return err
 	}
 	l.Address = log.Address
 	l.Topics = log.Topics
 	l.Data = log.Data
 	l.BlockHash = log.BlockHash
-	if log.BlockNumber != nil {
-		l.BlockNumber = log.BlockNumber.Big()
-	}
+	setBig(&l.BlockNumber, log.BlockNumber, reuse)
 	l.TransactionHash = log.TransactionHash
-	if log.TransactionIndex != nil {
-		l.TransactionIndex = new(uint64)
-		*l.TransactionIndex = log.TransactionIndex.Big().Uint64()
-	}
-	if log.LogIndex != nil {
-		l.LogIndex = new(uint64)
-		*l.LogIndex = log.LogIndex.Big().Uint64()
-	}
+	setUint64(&l.TransactionIndex, log.TransactionIndex, reuse)
+	setUint64(&l.LogIndex, log.LogIndex, reuse)
 	l.Removed = log.Removed
 	return nil
 }
 
 type jsonLog struct {
-	Address          Address `json:"address"`
-	Topics           []Hash  `json:"topics"`
-	Data             Bytes   `json:"data"`
-	BlockHash        *Hash   `json:"blockHash"`
-	BlockNumber      *Number `json:"blockNumber"`
-	TransactionHash  *Hash   `json:"transactionHash"`
-	TransactionIndex *Number `json:"transactionIndex"`
-	LogIndex         *Number `json:"logIndex"`
-	Removed          bool    `json:"removed"`
+	Address          Address    `json:"address"`
+	Topics           []Hash     `json:"topics"`
+	Data             reuseBytes `json:"data"`
+	BlockHash        *Hash      `json:"blockHash"`
+	BlockNumber      *Number    `json:"blockNumber"`
+	TransactionHash  *Hash      `json:"transactionHash"`
+	TransactionIndex *Number    `json:"transactionIndex"`
+	LogIndex         *Number    `json:"logIndex"`
+	Removed          bool       `json:"removed"`
 }
 
 // FilterLogsQuery represents a query to filter logs.
@@ -1202,6 +2454,25 @@ func (q *FilterLogsQuery) SetBlockHash(blockHash *Hash) *FilterLogsQuery {
 	return q
 }
 
+// maxTopics is the maximum number of topic positions in a log filter.
+const maxTopics = 4
+
+// Validate checks if the query is valid. The BlockHash field is mutually
+// exclusive with the FromBlock and ToBlock fields, and logs have at most
+// four topics.
+func (q *FilterLogsQuery) Validate() error {
+	if q == nil {
+		return nil
+	}
+	if q.BlockHash != nil && (q.FromBlock != nil || q.ToBlock != nil) {
+		return fmt.Errorf("block hash cannot be used together with from and to blocks")
+	}
+	if len(q.Topics) > maxTopics {
+		return fmt.Errorf("too many topics: %d, maximum is %d", len(q.Topics), maxTopics)
+	}
+	return nil
+}
+
 func (q FilterLogsQuery) MarshalJSON() ([]byte, error) {
 	logsQuery := &jsonFilterLogsQuery{
 		FromBlock: q.FromBlock,
@@ -1219,12 +2490,12 @@ func (q FilterLogsQuery) MarshalJSON() ([]byte, error) {
 			copy(logsQuery.Topics[i], t)
 		}
 	}
-	return json.Marshal(logsQuery)
+	return jsoncodec.Marshal(logsQuery)
 }
 
 func (q *FilterLogsQuery) UnmarshalJSON(input []byte) error {
 	logsQuery := &jsonFilterLogsQuery{}
-	if err := json.Unmarshal(input, logsQuery); err != nil {
+	if err := jsoncodec.Unmarshal(input, logsQuery); err != nil {
 		return err
 	}
 	q.FromBlock = logsQuery.FromBlock
@@ -1249,5 +2520,169 @@ type jsonFilterLogsQuery struct {
 	FromBlock *BlockNumber `json:"fromBlock,omitempty"`
 	ToBlock   *BlockNumber `json:"toBlock,omitempty"`
 	Topics    []hashList   `json:"topics"`
-	BlockHash *Hash        `json:"blockhash,omitempty"`
+	BlockHash *Hash        `json:"blockHash,omitempty"`
+}
+
+// AccountProof is the Merkle proof of an account and its storage slots, as
+// returned by the eth_getProof call.
+type AccountProof struct {
+	Address      Address        // Address is the address of the account.
+	AccountProof [][]byte       // AccountProof is the list of RLP-encoded nodes from the state root to the account.
+	Balance      *big.Int       // Balance is the balance of the account.
+	CodeHash     Hash           // CodeHash is the hash of the account code.
+	Nonce        uint64         // Nonce is the nonce of the account.
+	StorageHash  Hash           // StorageHash is the root of the account storage trie.
+	StorageProof []StorageProof // StorageProof is the list of proofs of the requested storage slots.
+}
+
+// StorageProof is the Merkle proof of a single storage slot.
+type StorageProof struct {
+	Key   Hash     // Key is the storage slot.
+	Value *big.Int // Value is the value stored in the slot.
+	Proof [][]byte // Proof is the list of RLP-encoded nodes from the storage root to the slot.
+}
+
+func (p AccountProof) MarshalJSON() ([]byte, error) {
+	proof := &jsonAccountProof{
+		Address:      p.Address,
+		AccountProof: bytesFromBytesSlice(p.AccountProof),
+		Balance:      NumberFromBigInt(p.Balance),
+		CodeHash:     p.CodeHash,
+		Nonce:        NumberFromUint64(p.Nonce),
+		StorageHash:  p.StorageHash,
+		StorageProof: make([]jsonStorageProof, len(p.StorageProof)),
+	}
+	for i, sp := range p.StorageProof {
+		proof.StorageProof[i] = jsonStorageProof{
+			Key:   NumberFromBigInt(new(big.Int).SetBytes(sp.Key.Bytes())),
+			Value: NumberFromBigInt(sp.Value),
+			Proof: bytesFromBytesSlice(sp.Proof),
+		}
+	}
+	return jsoncodec.Marshal(proof)
+}
+
+func (p *AccountProof) UnmarshalJSON(input []byte) error {
+	proof := &jsonAccountProof{}
+	if err := jsoncodec.Unmarshal(input, proof); err != nil {
+		return err
+	}
+	p.Address = proof.Address
+	p.AccountProof = bytesSliceFromBytes(proof.AccountProof)
+	p.Balance = proof.Balance.Big()
+	p.CodeHash = proof.CodeHash
+	p.Nonce = proof.Nonce.Big().Uint64()
+	p.StorageHash = proof.StorageHash
+	p.StorageProof = make([]StorageProof, len(proof.StorageProof))
+	for i, sp := range proof.StorageProof {
+		// Nodes return the key in the same form as it was requested, which
+		// may be shorter than 32 bytes, so it is decoded as a number.
+		key, err := HashFromBigInt(sp.Key.Big())
+		if err != nil {
+			return err
+		}
+		p.StorageProof[i] = StorageProof{
+			Key:   key,
+			Value: sp.Value.Big(),
+			Proof: bytesSliceFromBytes(sp.Proof),
+		}
+	}
+	return nil
+}
+
+type jsonAccountProof struct {
+	Address      Address            `json:"address"`
+	AccountProof []Bytes            `json:"accountProof"`
+	Balance      Number             `json:"balance"`
+	CodeHash     Hash               `json:"codeHash"`
+	Nonce        Number             `json:"nonce"`
+	StorageHash  Hash               `json:"storageHash"`
+	StorageProof []jsonStorageProof `json:"storageProof"`
+}
+
+type jsonStorageProof struct {
+	Key   Number  `json:"key"`
+	Value Number  `json:"value"`
+	Proof []Bytes `json:"proof"`
+}
+
+// StateOverride is a set of account overrides applied to the state before
+// a call is executed, as accepted by eth_call and eth_estimateGas.
+type StateOverride map[Address]AccountOverride
+
+// AccountOverride overrides the fields of a single account. Nil fields are
+// not overridden. State replaces the whole storage of the account, while
+// StateDiff overrides only the given slots, so they cannot be used
+// together.
+type AccountOverride struct {
+	Nonce     *uint64       // Nonce is the nonce of the account.
+	Code      []byte        // Code is the code of the account.
+	Balance   *big.Int      // Balance is the balance of the account.
+	State     map[Hash]Hash // State replaces the storage of the account.
+	StateDiff map[Hash]Hash // StateDiff overrides the given storage slots.
+}
+
+func (o AccountOverride) MarshalJSON() ([]byte, error) {
+	override := &jsonAccountOverride{
+		State:     o.State,
+		StateDiff: o.StateDiff,
+	}
+	if o.Nonce != nil {
+		override.Nonce = NumberFromUint64Ptr(*o.Nonce)
+	}
+	if o.Code != nil {
+		code := Bytes(o.Code)
+		override.Code = &code
+	}
+	if o.Balance != nil {
+		override.Balance = NumberFromBigIntPtr(o.Balance)
+	}
+	return jsoncodec.Marshal(override)
+}
+
+func (o *AccountOverride) UnmarshalJSON(input []byte) error {
+	override := &jsonAccountOverride{}
+	if err := jsoncodec.Unmarshal(input, override); err != nil {
+		return err
+	}
+	o.Nonce = nil
+	if override.Nonce != nil {
+		nonce := override.Nonce.Big().Uint64()
+		o.Nonce = &nonce
+	}
+	o.Code = nil
+	if override.Code != nil {
+		o.Code = *override.Code
+	}
+	o.Balance = nil
+	if override.Balance != nil {
+		o.Balance = override.Balance.Big()
+	}
+	o.State = override.State
+	o.StateDiff = override.StateDiff
+	return nil
+}
+
+type jsonAccountOverride struct {
+	Nonce     *Number       `json:"nonce,omitempty"`
+	Code      *Bytes        `json:"code,omitempty"`
+	Balance   *Number       `json:"balance,omitempty"`
+	State     map[Hash]Hash `json:"state,omitempty"`
+	StateDiff map[Hash]Hash `json:"stateDiff,omitempty"`
+}
+
+func bytesSliceFromBytes(b []Bytes) [][]byte {
+	r := make([][]byte, len(b))
+	for i, v := range b {
+		r[i] = v
+	}
+	return r
+}
+
+func bytesFromBytesSlice(b [][]byte) []Bytes {
+	r := make([]Bytes, len(b))
+	for i, v := range b {
+		r[i] = v
+	}
+	return r
 }
@@ -1,9 +1,11 @@
 package types
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -104,6 +106,30 @@ func TestTransaction_RLP(t1 *testing.T) {
 				SetMaxFeePerGas(big.NewInt(2000000000)),
 			want: hexutil.MustHexToBytes("02f8770101843b9aca008477359400830186a0942222222222222222222222222222222222222222880de0b6b3a76400008401020304c06fa0a3a7b12762dbc5df6cfbedbecdf8a821929c6112d2634abbb0d99dc63ad91490a08051b2c8c7d159db49ad19bd01026156eedab2f3d8c1dfdd07d21c07a4bbdd84"),
 		},
+		// Set code transaction:
+		{
+			tx: (&Transaction{}).
+				SetType(SetCodeTxType).
+				SetFrom(MustAddressFromHex("0x1111111111111111111111111111111111111111")).
+				SetTo(MustAddressFromHex("0x2222222222222222222222222222222222222222")).
+				SetGasLimit(100000).
+				SetInput([]byte{1, 2, 3, 4}).
+				SetNonce(1).
+				SetValue(big.NewInt(1000000000000000000)).
+				SetSignature(MustSignatureFromHex("0xa3a7b12762dbc5df6cfbedbecdf8a821929c6112d2634abbb0d99dc63ad914908051b2c8c7d159db49ad19bd01026156eedab2f3d8c1dfdd07d21c07a4bbdd8401")).
+				SetChainID(1).
+				SetMaxPriorityFeePerGas(big.NewInt(1000000000)).
+				SetMaxFeePerGas(big.NewInt(2000000000)).
+				SetAuthorizationList(AuthorizationList{
+					{
+						ChainID:   1,
+						Address:   MustAddressFromHex("0x3333333333333333333333333333333333333333"),
+						Nonce:     2,
+						Signature: MustSignatureFromHexPtr("0xa3a7b12762dbc5df6cfbedbecdf8a821929c6112d2634abbb0d99dc63ad914908051b2c8c7d159db49ad19bd01026156eedab2f3d8c1dfdd07d21c07a4bbdd8401"),
+					},
+				}),
+			want: hexutil.MustHexToBytes("04f8d50101843b9aca008477359400830186a0942222222222222222222222222222222222222222880de0b6b3a76400008401020304c0f85cf85a019433333333333333333333333333333333333333330201a0a3a7b12762dbc5df6cfbedbecdf8a821929c6112d2634abbb0d99dc63ad91490a08051b2c8c7d159db49ad19bd01026156eedab2f3d8c1dfdd07d21c07a4bbdd8401a0a3a7b12762dbc5df6cfbedbecdf8a821929c6112d2634abbb0d99dc63ad91490a08051b2c8c7d159db49ad19bd01026156eedab2f3d8c1dfdd07d21c07a4bbdd84"),
+		},
 		// Example from EIP-155:
 		{
 			tx: (&Transaction{}).
@@ -165,4 +191,534 @@ func equalTx(t *testing.T, expected, got *Transaction) {
 		assert.Equal(t, accessTuple.Address, got.AccessList[i].Address)
 		assert.Equal(t, accessTuple.StorageKeys, got.AccessList[i].StorageKeys)
 	}
+	assert.Equal(t, expected.AuthorizationList, got.AuthorizationList)
+}
+
+func TestSetCodeAuthorization_JSON(t *testing.T) {
+	auth := SetCodeAuthorization{
+		ChainID:   1,
+		Address:   MustAddressFromHex("0x3333333333333333333333333333333333333333"),
+		Nonce:     2,
+		Signature: SignatureFromVRSPtr(big.NewInt(1), big.NewInt(3), big.NewInt(4)),
+	}
+	j, err := auth.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"chainId":"0x1","address":"0x3333333333333333333333333333333333333333","nonce":"0x2","yParity":"0x1","r":"0x3","s":"0x4"}`, string(j))
+
+	var got SetCodeAuthorization
+	require.NoError(t, got.UnmarshalJSON(j))
+	assert.Equal(t, auth, got)
+}
+
+func TestOnChainTransaction_JSON(t *testing.T) {
+	j := `{
+		"type": "0x2",
+		"chainId": "0x1",
+		"nonce": "0x3",
+		"to": "0x3333333333333333333333333333333333333333",
+		"gas": "0x5208",
+		"value": "0x0",
+		"input": "0x",
+		"maxPriorityFeePerGas": "0x1",
+		"maxFeePerGas": "0x2",
+		"accessList": [],
+		"v": "0x1",
+		"r": "0x3",
+		"s": "0x4",
+		"hash": "0x1111111111111111111111111111111111111111111111111111111111111111",
+		"transactionIndex": "0x0"
+	}`
+	var tx OnChainTransaction
+	require.NoError(t, tx.UnmarshalJSON([]byte(j)))
+	assert.Equal(t, DynamicFeeTxType, tx.Type)
+	require.NotNil(t, tx.ChainID)
+	assert.Equal(t, uint64(1), *tx.ChainID)
+
+	got, err := tx.MarshalJSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(got), `"type":"0x2"`)
+	assert.Contains(t, string(got), `"chainId":"0x1"`)
+	assert.Contains(t, string(got), `"input":"0x"`)
+}
+
+func TestOnChainTransaction_JSON_LegacySigningMode(t *testing.T) {
+	legacy := func(chainID, v string) string {
+		return `{"type":"0x0",` + chainID + `"nonce":"0x1","gas":"0x5208","gasPrice":"0x1","value":"0x0","input":"0x","v":"` + v + `","r":"0x1","s":"0x1"}`
+	}
+	t.Run("homestead", func(t *testing.T) {
+		// The chain ID returned by the node must not be used in the signing hash.
+		var tx OnChainTransaction
+		require.NoError(t, tx.UnmarshalJSON([]byte(legacy(`"chainId":"0x1",`, "0x1b"))))
+		assert.Equal(t, HomesteadLegacySigning, tx.LegacySigningMode)
+		eip155, err := tx.UsesEIP155Signing()
+		require.NoError(t, err)
+		assert.False(t, eip155)
+	})
+	t.Run("eip155", func(t *testing.T) {
+		var tx OnChainTransaction
+		require.NoError(t, tx.UnmarshalJSON([]byte(legacy("", "0x25"))))
+		assert.Equal(t, EIP155LegacySigning, tx.LegacySigningMode)
+		require.NotNil(t, tx.ChainID)
+		assert.Equal(t, uint64(1), *tx.ChainID)
+	})
+	t.Run("chain ID mismatch", func(t *testing.T) {
+		var tx OnChainTransaction
+		assert.Error(t, tx.UnmarshalJSON([]byte(legacy(`"chainId":"0x2",`, "0x25"))))
+	})
+	t.Run("non-standard V", func(t *testing.T) {
+		var tx OnChainTransaction
+		require.NoError(t, tx.UnmarshalJSON([]byte(legacy(`"chainId":"0x2",`, "0x1"))))
+		assert.Equal(t, AutoLegacySigning, tx.LegacySigningMode)
+		require.NotNil(t, tx.ChainID)
+		assert.Equal(t, uint64(2), *tx.ChainID)
+	})
+}
+
+func TestTransaction_DecodeRLP_LegacyV(t *testing.T) {
+	encode := func(v int64) []byte {
+		raw, err := NewTransaction().
+			SetGasLimit(21000).
+			SetGasPrice(big.NewInt(1)).
+			SetNonce(1).
+			SetSignature(SignatureFromVRS(big.NewInt(v), big.NewInt(1), big.NewInt(1))).
+			Raw()
+		require.NoError(t, err)
+		return raw
+	}
+	var tx Transaction
+	_, err := tx.DecodeRLP(encode(28))
+	require.NoError(t, err)
+	assert.Equal(t, HomesteadLegacySigning, tx.LegacySigningMode)
+	assert.Nil(t, tx.ChainID)
+
+	tx = Transaction{}
+	_, err = tx.DecodeRLP(encode(38))
+	require.NoError(t, err)
+	assert.Equal(t, EIP155LegacySigning, tx.LegacySigningMode)
+	assert.Equal(t, uint64(1), *tx.ChainID)
+
+	for _, v := range []int64{1, 29, 34} {
+		tx = Transaction{}
+		_, err = tx.DecodeRLP(encode(v))
+		require.NoError(t, err, "V=%d", v)
+		assert.Equal(t, AutoLegacySigning, tx.LegacySigningMode, "V=%d", v)
+		assert.Nil(t, tx.ChainID, "V=%d", v)
+	}
+}
+
+func TestTransaction_UsesEIP155Signing(t *testing.T) {
+	eip155, err := NewTransaction().SetChainID(1).UsesEIP155Signing()
+	require.NoError(t, err)
+	assert.True(t, eip155)
+
+	eip155, err = NewTransaction().SetChainID(1).SetLegacySigningMode(HomesteadLegacySigning).UsesEIP155Signing()
+	require.NoError(t, err)
+	assert.False(t, eip155)
+
+	_, err = NewTransaction().SetLegacySigningMode(EIP155LegacySigning).UsesEIP155Signing()
+	assert.Error(t, err)
+
+	assert.False(t, NewTransaction().SetChainID(1).SetLegacySigningMode(HomesteadLegacySigning).IsReplayProtected())
+	assert.Equal(t, HomesteadLegacySigning, NewTransaction().SetLegacySigningMode(HomesteadLegacySigning).Copy().LegacySigningMode)
+}
+
+func TestDelegationCode(t *testing.T) {
+	addr := MustAddressFromHex("0x3333333333333333333333333333333333333333")
+	code := DelegationCode(addr)
+	assert.Equal(t, hexutil.MustHexToBytes("0xef01003333333333333333333333333333333333333333"), code)
+
+	got, ok := DelegationFromCode(code)
+	assert.True(t, ok)
+	assert.Equal(t, addr, got)
+
+	_, ok = DelegationFromCode(code[:len(code)-1])
+	assert.False(t, ok)
+	_, ok = DelegationFromCode(append([]byte{0x60}, code[1:]...))
+	assert.False(t, ok)
+}
+
+func TestTransaction_MaxCost(t *testing.T) {
+	legacy := NewTransaction().
+		SetGasLimit(21000).
+		SetGasPrice(big.NewInt(10)).
+		SetMaxFeePerGas(big.NewInt(20)).
+		SetValue(big.NewInt(5))
+	assert.Equal(t, big.NewInt(210005), legacy.MaxCost())
+
+	dynamic := legacy.Copy().SetType(DynamicFeeTxType)
+	assert.Equal(t, big.NewInt(420005), dynamic.MaxCost())
+
+	assert.Nil(t, NewTransaction().SetGasLimit(21000).MaxCost())
+	assert.Nil(t, NewTransaction().SetGasPrice(big.NewInt(1)).MaxCost())
+}
+
+func TestTransaction_IsReplayProtected(t *testing.T) {
+	assert.False(t, NewTransaction().IsReplayProtected())
+	assert.False(t, NewTransaction().SetChainID(0).IsReplayProtected())
+	assert.True(t, NewTransaction().SetChainID(1).IsReplayProtected())
+	assert.True(t, NewTransaction().SetType(DynamicFeeTxType).IsReplayProtected())
+
+	// For signed transactions, the chain ID is derived from the signature.
+	unprotected := NewTransaction().SetChainID(1).SetSignature(SignatureFromVRS(big.NewInt(27), big.NewInt(1), big.NewInt(1)))
+	protected := NewTransaction().SetSignature(SignatureFromVRS(big.NewInt(37), big.NewInt(1), big.NewInt(1)))
+	assert.False(t, unprotected.IsReplayProtected())
+	assert.True(t, protected.IsReplayProtected())
+
+	txs := []OnChainTransaction{
+		{Transaction: *protected},
+		{Transaction: *unprotected},
+		{Transaction: *NewTransaction().SetType(AccessListTxType)},
+	}
+	res := FindUnprotectedTransactions(txs)
+	require.Len(t, res, 1)
+	assert.Equal(t, txs[1], res[0])
+}
+
+func TestFilterLogsQuery_Validate(t *testing.T) {
+	hash := MustHashFromHex("0x1111111111111111111111111111111111111111111111111111111111111111", PadNone)
+	assert.NoError(t, NewFilterLogsQuery().SetBlockHash(&hash).Validate())
+	assert.NoError(t, NewFilterLogsQuery().SetFromBlock(BlockNumberFromUint64Ptr(1)).Validate())
+	assert.Error(t, NewFilterLogsQuery().SetBlockHash(&hash).SetToBlock(BlockNumberFromUint64Ptr(1)).Validate())
+	assert.Error(t, NewFilterLogsQuery().SetTopics(nil, nil, nil, nil, nil).Validate())
+
+	j, err := NewFilterLogsQuery().SetBlockHash(&hash).MarshalJSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(j), `"blockHash":"0x1111111111111111111111111111111111111111111111111111111111111111"`)
+}
+
+func TestTransactionType_String(t *testing.T) {
+	tests := []struct {
+		typ  TransactionType
+		want string
+	}{
+		{typ: LegacyTxType, want: "legacy"},
+		{typ: AccessListTxType, want: "access-list"},
+		{typ: DynamicFeeTxType, want: "dynamic-fee"},
+		{typ: SetCodeTxType, want: "set-code"},
+		{typ: 3, want: "unknown(3)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.typ.String())
+			if tt.typ == 3 {
+				return
+			}
+			typ, err := ParseTransactionType(tt.want)
+			require.NoError(t, err)
+			assert.Equal(t, tt.typ, typ)
+		})
+	}
+}
+
+func TestParseTransactionType(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    TransactionType
+		wantErr bool
+	}{
+		{arg: "Dynamic_Fee", want: DynamicFeeTxType},
+		{arg: "2", want: DynamicFeeTxType},
+		{arg: "0x4", want: SetCodeTxType},
+		{arg: "0x03", want: 3},
+		{arg: "0x80", wantErr: true},
+		{arg: "eip1559", wantErr: true},
+		{arg: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			typ, err := ParseTransactionType(tt.arg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, typ)
+		})
+	}
+}
+
+func TestTransactionType_JSON(t *testing.T) {
+	var cfg struct {
+		Types []TransactionType `json:"types"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"types":["legacy","dynamic-fee","0x3"]}`), &cfg))
+	assert.Equal(t, []TransactionType{LegacyTxType, DynamicFeeTxType, 3}, cfg.Types)
+	b, err := json.Marshal(cfg)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"types":["legacy","dynamic-fee","0x3"]}`, string(b))
+}
+
+func TestForkConfig_JSON(t *testing.T) {
+	cfg := ForkConfig{
+		ActivationTime:  1746612311,
+		BlobSchedule:    &BlobSchedule{Target: 6, Max: 9, BaseFeeUpdateFraction: 5007716},
+		ChainID:         1,
+		ForkID:          [4]byte{0xc3, 0x76, 0xcf, 0x8b},
+		Precompiles:     map[string]Address{"ECREC": MustAddressFromHex("0x0000000000000000000000000000000000000001")},
+		SystemContracts: map[string]Address{},
+	}
+	j, err := cfg.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"activationTime": 1746612311,
+		"blobSchedule": {"target": 6, "max": 9, "baseFeeUpdateFraction": 5007716},
+		"chainId": "0x1",
+		"forkId": "0xc376cf8b",
+		"precompiles": {"ECREC": "0x0000000000000000000000000000000000000001"},
+		"systemContracts": {}
+	}`, string(j))
+
+	var got ForkConfig
+	require.NoError(t, got.UnmarshalJSON(j))
+	assert.Equal(t, cfg, got)
+
+	// Fork ID must be 4 bytes long.
+	assert.Error(t, got.UnmarshalJSON([]byte(`{"chainId":"0x1","forkId":"0xc376cf"}`)))
+}
+
+func TestBlock_HeaderHash(t *testing.T) {
+	// Mainnet genesis block.
+	genesis := Block{
+		Number:           big.NewInt(0),
+		StateRoot:        MustHashFromHex("0xd7f8974fb5ac78d9ac099b9ad5018bedc2ce0a72dad1827a1709da30580f0544", PadNone),
+		TransactionsRoot: MustHashFromHex("0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421", PadNone),
+		ReceiptsRoot:     MustHashFromHex("0x56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421", PadNone),
+		Sha3Uncles:       MustHashFromHex("0x1dcc4de8dec75d7aab85b567b6ccd41ad312451b948a7413f0a142fd40d49347", PadNone),
+		Nonce:            big.NewInt(0x42),
+		Difficulty:       big.NewInt(0x400000000),
+		GasLimit:         5000,
+		Timestamp:        time.Unix(0, 0),
+		ExtraData:        hexutil.MustHexToBytes("0x11bbe8db4e347b4e8c937c1c8370e4b5ed33adb3db69cbdb7a38e1e50b1b82fa"),
+	}
+	hash, err := genesis.HeaderHash(keccak256)
+	require.NoError(t, err)
+	assert.Equal(t, "0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3", hash.String())
+}
+
+func TestBlock_RLP(t *testing.T) {
+	var (
+		chainID     = uint64(1)
+		nonce       = uint64(7)
+		gasLimit    = uint64(21000)
+		blobGasUsed = uint64(131072)
+		excess      = uint64(0)
+		to          = MustAddressFromHex("0x2222222222222222222222222222222222222222")
+	)
+	legacy := OnChainTransaction{Transaction: Transaction{
+		Call:      Call{To: &to, GasLimit: &gasLimit, GasPrice: big.NewInt(1e9), Value: big.NewInt(1)},
+		Type:      LegacyTxType,
+		Nonce:     &nonce,
+		ChainID:   &chainID,
+		Signature: SignatureFromVRSPtr(big.NewInt(37), big.NewInt(1), big.NewInt(2)),
+	}}
+	dynamic := OnChainTransaction{Transaction: Transaction{
+		Call:      Call{To: &to, GasLimit: &gasLimit, MaxFeePerGas: big.NewInt(2e9), MaxPriorityFeePerGas: big.NewInt(1e9), Input: []byte{1, 2}},
+		Type:      DynamicFeeTxType,
+		Nonce:     &nonce,
+		ChainID:   &chainID,
+		Signature: SignatureFromVRSPtr(big.NewInt(1), big.NewInt(3), big.NewInt(4)),
+	}}
+	block := Block{
+		Number:                big.NewInt(20000000),
+		ParentHash:            Hash{1},
+		Miner:                 to,
+		Difficulty:            big.NewInt(0),
+		Nonce:                 big.NewInt(0),
+		LogsBloom:             make([]byte, 256),
+		GasLimit:              30000000,
+		GasUsed:               42000,
+		Timestamp:             time.Unix(1717000000, 0),
+		ExtraData:             []byte("beaverbuild.org"),
+		BaseFeePerGas:         big.NewInt(5e9),
+		WithdrawalsRoot:       &Hash{2},
+		BlobGasUsed:           &blobGasUsed,
+		ExcessBlobGas:         &excess,
+		ParentBeaconBlockRoot: &Hash{3},
+		Transactions:          []OnChainTransaction{legacy, dynamic},
+		Withdrawals:           []Withdrawal{{Index: 1, ValidatorIndex: 2, Address: to, Amount: 3}},
+	}
+
+	raw, err := block.EncodeRLP()
+	require.NoError(t, err)
+
+	var decoded Block
+	n, err := decoded.DecodeRLP(raw)
+	require.NoError(t, err)
+	assert.Equal(t, len(raw), n)
+	assert.Equal(t, uint64(len(raw)), decoded.Size)
+	assert.Equal(t, block.Number, decoded.Number)
+	assert.Equal(t, block.Timestamp, decoded.Timestamp)
+	assert.Equal(t, block.BaseFeePerGas, decoded.BaseFeePerGas)
+	assert.Equal(t, block.ParentBeaconBlockRoot, decoded.ParentBeaconBlockRoot)
+	assert.Nil(t, decoded.RequestsHash)
+	assert.Equal(t, block.Withdrawals, decoded.Withdrawals)
+	require.Len(t, decoded.Transactions, 2)
+	assert.Equal(t, LegacyTxType, decoded.Transactions[0].Type)
+	assert.Equal(t, DynamicFeeTxType, decoded.Transactions[1].Type)
+	assert.Equal(t, uint64(1), *decoded.Transactions[1].TransactionIndex)
+	assert.Equal(t, []byte{1, 2}, decoded.Transactions[1].Input)
+
+	reencoded, err := decoded.EncodeRLP()
+	require.NoError(t, err)
+	assert.Equal(t, raw, reencoded)
+
+	hash, err := block.HeaderHash(keccak256)
+	require.NoError(t, err)
+	decodedHash, err := decoded.HeaderHash(keccak256)
+	require.NoError(t, err)
+	assert.Equal(t, hash, decodedHash)
+}
+
+func TestBlock_RLP_Errors(t *testing.T) {
+	t.Run("missing fork field", func(t *testing.T) {
+		_, err := Block{WithdrawalsRoot: &Hash{}}.EncodeRLP()
+		assert.ErrorContains(t, err, "baseFeePerGas is missing")
+	})
+	t.Run("transaction hashes only", func(t *testing.T) {
+		_, err := Block{TransactionHashes: []Hash{{1}}}.EncodeRLP()
+		assert.Error(t, err)
+	})
+	t.Run("missing uncle headers", func(t *testing.T) {
+		_, err := Block{Uncles: []Hash{{1}}}.EncodeRLP()
+		assert.Error(t, err)
+	})
+	t.Run("invalid data", func(t *testing.T) {
+		var b Block
+		_, err := b.DecodeRLP([]byte{0xc1, 0x80})
+		assert.Error(t, err)
+	})
+}
+
+func TestBlock_JSON_Features(t *testing.T) {
+	// Block without ommers, with a Verkle execution witness and an unknown
+	// field.
+	data := `{
+		"number": "0x1",
+		"hash": "0x1111111111111111111111111111111111111111111111111111111111111111",
+		"baseFeePerGas": "0x7",
+		"withdrawalsRoot": null,
+		"executionWitness": {"stateDiff": []},
+		"someFutureField": "0x1234",
+		"transactions": []
+	}`
+	var b Block
+	require.NoError(t, b.UnmarshalJSON([]byte(data)))
+	assert.True(t, b.Features.Has(BaseFeeFeature|ExecutionWitnessFeature))
+	assert.False(t, b.Features.Has(OmmersFeature))
+	assert.False(t, b.Features.Has(WithdrawalsFeature))
+	assert.Equal(t, "base-fee|execution-witness", b.Features.String())
+	assert.JSONEq(t, `{"stateDiff": []}`, string(b.Extra["executionWitness"]))
+	assert.JSONEq(t, `"0x1234"`, string(b.Extra["someFutureField"]))
+	assert.Len(t, b.Extra, 2)
+
+	out, err := b.MarshalJSON()
+	require.NoError(t, err)
+	var fields map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(out, &fields))
+	assert.NotContains(t, fields, "uncles")
+	assert.NotContains(t, fields, "sha3Uncles")
+	assert.JSONEq(t, `"0x1234"`, string(fields["someFutureField"]))
+
+	// Blocks that were not decoded are encoded with all fields.
+	out, err = Block{Number: big.NewInt(1)}.MarshalJSON()
+	require.NoError(t, err)
+	assert.Contains(t, string(out), `"sha3Uncles"`)
+	assert.Equal(t, "", BlockFeature(0).String())
+	assert.Equal(t, "ommers|unknown(256)", (OmmersFeature | 1<<8).String())
+}
+
+func TestBlock_RLP_ExtraHeaderFields(t *testing.T) {
+	var (
+		blobGasUsed = uint64(0)
+		excess      = uint64(0)
+	)
+	block := Block{
+		Number:                big.NewInt(1),
+		Difficulty:            big.NewInt(0),
+		Nonce:                 big.NewInt(0),
+		BaseFeePerGas:         big.NewInt(7),
+		WithdrawalsRoot:       &Hash{1},
+		BlobGasUsed:           &blobGasUsed,
+		ExcessBlobGas:         &excess,
+		ParentBeaconBlockRoot: &Hash{2},
+		RequestsHash:          &Hash{3},
+		ExtraHeaderFields:     [][]byte{{0x82, 0x01, 0x02}},
+	}
+	raw, err := block.EncodeRLP()
+	require.NoError(t, err)
+
+	var decoded Block
+	_, err = decoded.DecodeRLP(raw)
+	require.NoError(t, err)
+	assert.Equal(t, block.ExtraHeaderFields, decoded.ExtraHeaderFields)
+	assert.True(t, decoded.Features.Has(OmmersFeature|BaseFeeFeature|WithdrawalsFeature|BlobGasFeature|BeaconRootFeature|RequestsFeature))
+
+	hash, err := block.HeaderHash(keccak256)
+	require.NoError(t, err)
+	decodedHash, err := decoded.HeaderHash(keccak256)
+	require.NoError(t, err)
+	assert.Equal(t, hash, decodedHash)
+
+	// Extra fields cannot follow missing fork fields.
+	block.RequestsHash = nil
+	_, err = block.EncodeRLP()
+	assert.ErrorContains(t, err, "requestsHash is missing")
+}
+
+func TestStateOverride_JSON(t *testing.T) {
+	nonce := uint64(5)
+	override := StateOverride{
+		MustAddressFromHex("0x1111111111111111111111111111111111111111"): {
+			Nonce:     &nonce,
+			Code:      []byte{0x60, 0x00},
+			Balance:   big.NewInt(1000),
+			StateDiff: map[Hash]Hash{{1}: {2}},
+		},
+		MustAddressFromHex("0x2222222222222222222222222222222222222222"): {
+			Balance: big.NewInt(1),
+		},
+	}
+	data, err := json.Marshal(override)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"0x1111111111111111111111111111111111111111": {
+			"nonce": "0x5",
+			"code": "0x6000",
+			"balance": "0x3e8",
+			"stateDiff": {
+				"0x0100000000000000000000000000000000000000000000000000000000000000": "0x0200000000000000000000000000000000000000000000000000000000000000"
+			}
+		},
+		"0x2222222222222222222222222222222222222222": {"balance": "0x1"}
+	}`, string(data))
+
+	var decoded StateOverride
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, override, decoded)
+}
+
+func TestValidateBlobCount(t *testing.T) {
+	tests := []struct {
+		count    uint64
+		schedule BlobSchedule
+		maxPerTx uint64
+		wantErr  bool
+	}{
+		{count: 6, schedule: CancunBlobSchedule},
+		{count: 7, schedule: CancunBlobSchedule, wantErr: true},
+		{count: 9, schedule: PragueBlobSchedule},
+		{count: 9, schedule: PragueBlobSchedule, maxPerTx: MaxBlobsPerTransaction, wantErr: true},
+		{count: 6, schedule: BPO2BlobSchedule, maxPerTx: MaxBlobsPerTransaction},
+		{count: 22, schedule: BPO2BlobSchedule, wantErr: true},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			err := ValidateBlobCount(tt.count, tt.schedule, tt.maxPerTx)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrTooManyBlobs)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
 }
@@ -5,11 +5,13 @@ import (
 	"fmt"
 	"math"
 	"math/big"
+	"strconv"
 	"strings"
 
 	"github.com/defiweb/go-rlp"
 
 	"github.com/defiweb/go-eth/hexutil"
+	"github.com/defiweb/go-eth/jsoncodec"
 )
 
 // HashFunc returns the hash for the given input.
@@ -181,6 +183,82 @@ func (t *Address) DecodeRLP(data []byte) (int, error) {
 	return n, nil
 }
 
+//
+// ChainAddress type:
+//
+
+// ChainAddress is an address on a specific EVM chain.
+//
+// It is encoded as a CAIP-10 account identifier in the "eip155" namespace,
+// e.g. "eip155:1:0xab5801a7d398351b8be11c439e05c5b3259aec9b".
+type ChainAddress struct {
+	ChainID uint64  // ChainID is the EIP-155 chain ID.
+	Address Address // Address is the address on the chain.
+}
+
+// ParseChainAddress parses a CAIP-10 account identifier in the "eip155"
+// namespace.
+func ParseChainAddress(s string) (ChainAddress, error) {
+	var a ChainAddress
+	err := a.UnmarshalText([]byte(s))
+	return a, err
+}
+
+// MustParseChainAddress parses a CAIP-10 account identifier in the
+// "eip155" namespace. It panics if the identifier is invalid.
+func MustParseChainAddress(s string) ChainAddress {
+	a, err := ParseChainAddress(s)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+// String returns the CAIP-10 representation of the address.
+func (t ChainAddress) String() string {
+	return "eip155:" + strconv.FormatUint(t.ChainID, 10) + ":" + t.Address.String()
+}
+
+func (t ChainAddress) MarshalJSON() ([]byte, error) {
+	return jsoncodec.Marshal(t.String())
+}
+
+func (t *ChainAddress) UnmarshalJSON(input []byte) error {
+	var s string
+	if err := jsoncodec.Unmarshal(input, &s); err != nil {
+		return err
+	}
+	return t.UnmarshalText([]byte(s))
+}
+
+func (t ChainAddress) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *ChainAddress) UnmarshalText(input []byte) error {
+	parts := strings.Split(string(input), ":")
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid CAIP-10 account identifier %q", input)
+	}
+	if parts[0] != "eip155" {
+		return fmt.Errorf("unsupported CAIP-2 namespace %q", parts[0])
+	}
+	if len(parts[1]) == 0 || (len(parts[1]) > 1 && parts[1][0] == '0') {
+		return fmt.Errorf("invalid chain ID %q", parts[1])
+	}
+	chainID, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid chain ID %q", parts[1])
+	}
+	addr, err := AddressFromHex(parts[2])
+	if err != nil {
+		return err
+	}
+	t.ChainID = chainID
+	t.Address = addr
+	return nil
+}
+
 //
 // Hash type:
 //
@@ -400,14 +478,54 @@ const (
 	finalizedBlockNumber = -5
 )
 
+// Block tags.
 var (
-	EarliestBlockNumber  = BlockNumber{x: *new(big.Int).SetInt64(earliestBlockNumber)}
-	LatestBlockNumber    = BlockNumber{x: *new(big.Int).SetInt64(latestBlockNumber)}
-	PendingBlockNumber   = BlockNumber{x: *new(big.Int).SetInt64(pendingBlockNumber)}
-	SafeBlockNumber      = BlockNumber{x: *new(big.Int).SetInt64(safeBlockNumber)}
+	// EarliestBlockNumber is the "earliest" tag, the lowest block available
+	// on the node, usually the genesis block.
+	EarliestBlockNumber = BlockNumber{x: *new(big.Int).SetInt64(earliestBlockNumber)}
+
+	// LatestBlockNumber is the "latest" tag, the most recent block of the
+	// canonical chain seen by the node.
+	LatestBlockNumber = BlockNumber{x: *new(big.Int).SetInt64(latestBlockNumber)}
+
+	// PendingBlockNumber is the "pending" tag, the block being built on top
+	// of the latest block, including pending transactions.
+	PendingBlockNumber = BlockNumber{x: *new(big.Int).SetInt64(pendingBlockNumber)}
+
+	// SafeBlockNumber is the "safe" tag, the most recent block that is
+	// unlikely to be reorged under honest majority assumptions.
+	SafeBlockNumber = BlockNumber{x: *new(big.Int).SetInt64(safeBlockNumber)}
+
+	// FinalizedBlockNumber is the "finalized" tag, the most recent block
+	// finalized by the consensus layer, which cannot be reorged.
 	FinalizedBlockNumber = BlockNumber{x: *new(big.Int).SetInt64(finalizedBlockNumber)}
 )
 
+// ParseBlockNumber parses a block number from a tag, such as "latest" or
+// "finalized", a 0x-prefixed hex number or a decimal number. It is meant for
+// user input, such as configuration files or command line flags; JSON-RPC
+// values are always hex encoded and should be parsed with BlockNumberFromHex.
+func ParseBlockNumber(s string) (BlockNumber, error) {
+	s = strings.TrimSpace(s)
+	if hexutil.Has0xPrefix(s) {
+		return BlockNumberFromHex(s)
+	}
+	if len(s) > 0 && s[0] >= '0' && s[0] <= '9' {
+		x, err := strconv.ParseUint(s, 10, 63)
+		if err != nil {
+			return BlockNumber{}, fmt.Errorf("invalid block number: %q", s)
+		}
+		return BlockNumberFromUint64(x), nil
+	}
+	var b BlockNumber
+	switch strings.ToLower(s) {
+	case "earliest", "latest", "pending", "safe", "finalized":
+		err := b.UnmarshalText([]byte(s))
+		return b, err
+	}
+	return BlockNumber{}, fmt.Errorf("invalid block number: %q", s)
+}
+
 // BlockNumberFromHex converts a string to a BlockNumber type.
 // The string can be a hex number or one of the following strings:
 // "earliest", "latest", "safe", "finalized", "pending".
@@ -1043,10 +1161,125 @@ func (b *Bytes) UnmarshalText(input []byte) error {
 //
 
 // SyncStatus represents the sync status of a node.
+//
+// Besides the standard fields, clients report additional progress
+// information. Known fields are decoded into the corresponding struct
+// fields, which are nil if not reported. Unknown fields are preserved in
+// Extra.
 type SyncStatus struct {
 	StartingBlock BlockNumber `json:"startingBlock"`
 	CurrentBlock  BlockNumber `json:"currentBlock"`
 	HighestBlock  BlockNumber `json:"highestBlock"`
+
+	// State sync progress reported by older Geth versions:
+	PulledStates *Number `json:"pulledStates,omitempty"`
+	KnownStates  *Number `json:"knownStates,omitempty"`
+
+	// Snap sync progress reported by Geth:
+	SyncedAccounts      *Number `json:"syncedAccounts,omitempty"`
+	SyncedAccountBytes  *Number `json:"syncedAccountBytes,omitempty"`
+	SyncedBytecodes     *Number `json:"syncedBytecodes,omitempty"`
+	SyncedBytecodeBytes *Number `json:"syncedBytecodeBytes,omitempty"`
+	SyncedStorage       *Number `json:"syncedStorage,omitempty"`
+	SyncedStorageBytes  *Number `json:"syncedStorageBytes,omitempty"`
+	HealedTrienodes     *Number `json:"healedTrienodes,omitempty"`
+	HealedTrienodeBytes *Number `json:"healedTrienodeBytes,omitempty"`
+	HealedBytecodes     *Number `json:"healedBytecodes,omitempty"`
+	HealedBytecodeBytes *Number `json:"healedBytecodeBytes,omitempty"`
+	HealingTrienodes    *Number `json:"healingTrienodes,omitempty"`
+	HealingBytecode     *Number `json:"healingBytecode,omitempty"`
+
+	// Transaction indexing progress reported by Geth:
+	TxIndexFinishedBlocks  *Number `json:"txIndexFinishedBlocks,omitempty"`
+	TxIndexRemainingBlocks *Number `json:"txIndexRemainingBlocks,omitempty"`
+
+	// Stages is the progress of the staged sync reported by Erigon.
+	Stages []SyncStage `json:"stages,omitempty"`
+
+	// Extra contains fields not known to this package, in raw JSON form.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// SyncStage is the progress of a single stage of the staged sync.
+type SyncStage struct {
+	Name        string      `json:"stage_name"`
+	BlockNumber BlockNumber `json:"block_number"`
+}
+
+// Lag returns the number of blocks between the current block and the
+// highest known block. It returns 0 if the highest block is not ahead of
+// the current block, including when the node does not know the highest
+// block yet and reports it as 0.
+func (s *SyncStatus) Lag() uint64 {
+	current, highest := s.CurrentBlock.Big(), s.HighestBlock.Big()
+	if highest.Cmp(current) <= 0 {
+		return 0
+	}
+	return new(big.Int).Sub(highest, current).Uint64()
+}
+
+func (s SyncStatus) MarshalJSON() ([]byte, error) {
+	type alias SyncStatus
+	data, err := jsoncodec.Marshal(alias(s))
+	if err != nil {
+		return nil, err
+	}
+	if len(s.Extra) == 0 {
+		return data, nil
+	}
+	fields := make(map[string]json.RawMessage, len(s.Extra))
+	for k, v := range s.Extra {
+		fields[k] = v
+	}
+	if err := jsoncodec.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return jsoncodec.Marshal(fields)
+}
+
+func (s *SyncStatus) UnmarshalJSON(input []byte) error {
+	type alias SyncStatus
+	if err := jsoncodec.Unmarshal(input, (*alias)(s)); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := jsoncodec.Unmarshal(input, &fields); err != nil {
+		return err
+	}
+	s.Extra = nil
+	for k, v := range fields {
+		if _, ok := knownSyncStatusFields[k]; ok {
+			continue
+		}
+		if s.Extra == nil {
+			s.Extra = make(map[string]json.RawMessage)
+		}
+		s.Extra[k] = v
+	}
+	return nil
+}
+
+var knownSyncStatusFields = map[string]struct{}{
+	"startingBlock":          {},
+	"currentBlock":           {},
+	"highestBlock":           {},
+	"pulledStates":           {},
+	"knownStates":            {},
+	"syncedAccounts":         {},
+	"syncedAccountBytes":     {},
+	"syncedBytecodes":        {},
+	"syncedBytecodeBytes":    {},
+	"syncedStorage":          {},
+	"syncedStorageBytes":     {},
+	"healedTrienodes":        {},
+	"healedTrienodeBytes":    {},
+	"healedBytecodes":        {},
+	"healedBytecodeBytes":    {},
+	"healingTrienodes":       {},
+	"healingBytecode":        {},
+	"txIndexFinishedBlocks":  {},
+	"txIndexRemainingBlocks": {},
+	"stages":                 {},
 }
 
 //
@@ -1144,32 +1377,32 @@ type hashList []Hash
 
 func (b hashList) MarshalJSON() ([]byte, error) {
 	if len(b) == 1 {
-		return json.Marshal(b[0])
+		return jsoncodec.Marshal(b[0])
 	}
-	return json.Marshal([]Hash(b))
+	return jsoncodec.Marshal([]Hash(b))
 }
 
 func (b *hashList) UnmarshalJSON(input []byte) error {
 	if len(input) >= 2 && input[0] == '"' && input[len(input)-1] == '"' {
 		*b = hashList{{}}
-		return json.Unmarshal(input, &((*b)[0]))
+		return jsoncodec.Unmarshal(input, &((*b)[0]))
 	}
-	return json.Unmarshal(input, (*[]Hash)(b))
+	return jsoncodec.Unmarshal(input, (*[]Hash)(b))
 }
 
 type addressList []Address
 
 func (t addressList) MarshalJSON() ([]byte, error) {
 	if len(t) == 1 {
-		return json.Marshal(t[0])
+		return jsoncodec.Marshal(t[0])
 	}
-	return json.Marshal([]Address(t))
+	return jsoncodec.Marshal([]Address(t))
 }
 
 func (t *addressList) UnmarshalJSON(input []byte) error {
 	if len(input) >= 2 && input[0] == '"' && input[len(input)-1] == '"' {
 		*t = addressList{{}}
-		return json.Unmarshal(input, &((*t)[0]))
+		return jsoncodec.Unmarshal(input, &((*t)[0]))
 	}
-	return json.Unmarshal(input, (*[]Address)(t))
+	return jsoncodec.Unmarshal(input, (*[]Address)(t))
 }
@@ -1,6 +1,7 @@
 package types
 
 import (
+	"encoding/json"
 	"fmt"
 	"math/big"
 	"testing"
@@ -91,6 +92,55 @@ func Test_AddressType_Checksum(t *testing.T) {
 	}
 }
 
+func Test_ChainAddressType_Parse(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    ChainAddress
+		wantErr bool
+	}{
+		{
+			arg:  "eip155:1:0xab5801a7d398351b8be11c439e05c5b3259aec9b",
+			want: ChainAddress{ChainID: 1, Address: MustAddressFromHex("0xab5801a7d398351b8be11c439e05c5b3259aec9b")},
+		},
+		{
+			arg:  "eip155:42161:0xAb5801a7D398351b8bE11C439e05C5B3259aeC9B",
+			want: ChainAddress{ChainID: 42161, Address: MustAddressFromHex("0xab5801a7d398351b8be11c439e05c5b3259aec9b")},
+		},
+		{arg: "cosmos:cosmoshub-3:cosmos1t2uflqwqe0fsj0shcfkrvpukewcw40yjj6hdc0", wantErr: true},
+		{arg: "eip155:01:0xab5801a7d398351b8be11c439e05c5b3259aec9b", wantErr: true},
+		{arg: "eip155::0xab5801a7d398351b8be11c439e05c5b3259aec9b", wantErr: true},
+		{arg: "eip155:1:0xab58", wantErr: true},
+		{arg: "0xab5801a7d398351b8be11c439e05c5b3259aec9b", wantErr: true},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			a, err := ParseChainAddress(tt.arg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, a)
+		})
+	}
+}
+
+func Test_ChainAddressType_JSON(t *testing.T) {
+	a := MustParseChainAddress("eip155:10:0xab5801a7d398351b8be11c439e05c5b3259aec9b")
+	j, err := json.Marshal(a)
+	require.NoError(t, err)
+	assert.Equal(t, `"eip155:10:0xab5801a7d398351b8be11c439e05c5b3259aec9b"`, string(j))
+
+	// Text encoding allows the type to be used as a map key.
+	m := map[ChainAddress]int{a: 1}
+	j, err = json.Marshal(m)
+	require.NoError(t, err)
+	assert.Equal(t, `{"eip155:10:0xab5801a7d398351b8be11c439e05c5b3259aec9b":1}`, string(j))
+	var decoded map[ChainAddress]int
+	require.NoError(t, json.Unmarshal(j, &decoded))
+	assert.Equal(t, m, decoded)
+}
+
 func Test_hashType_Unmarshal(t *testing.T) {
 	tests := []struct {
 		arg     string
@@ -381,6 +431,35 @@ func Test_BlockNumberType_Marshal(t *testing.T) {
 	}
 }
 
+func Test_ParseBlockNumber(t *testing.T) {
+	tests := []struct {
+		arg     string
+		want    BlockNumber
+		wantErr bool
+	}{
+		{arg: "latest", want: LatestBlockNumber},
+		{arg: " Finalized ", want: FinalizedBlockNumber},
+		{arg: "safe", want: SafeBlockNumber},
+		{arg: "0x10", want: BlockNumberFromUint64(16)},
+		{arg: "16", want: BlockNumberFromUint64(16)},
+		{arg: "", wantErr: true},
+		{arg: "-1", wantErr: true},
+		{arg: "newest", wantErr: true},
+		{arg: "99999999999999999999", wantErr: true},
+	}
+	for n, tt := range tests {
+		t.Run(fmt.Sprintf("case-%d", n+1), func(t *testing.T) {
+			got, err := ParseBlockNumber(tt.arg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.String(), got.String())
+		})
+	}
+}
+
 func Test_SignatureType_Unmarshal(t *testing.T) {
 	tests := []struct {
 		arg     string
@@ -631,3 +710,31 @@ func keccak256(data ...[]byte) Hash {
 	}
 	return MustHashFromBytes(h.Sum(nil), PadNone)
 }
+
+func Test_SyncStatusType_JSON(t *testing.T) {
+	input := `{
+		"startingBlock": "0x384",
+		"currentBlock": "0x386",
+		"highestBlock": "0x454",
+		"healedBytecodes": "0x10",
+		"stages": [{"stage_name": "Headers", "block_number": "0x454"}],
+		"syncMode": "snap"
+	}`
+	var s SyncStatus
+	require.NoError(t, s.UnmarshalJSON([]byte(input)))
+	assert.Equal(t, MustBlockNumberFromHex("0x386"), s.CurrentBlock)
+	assert.Equal(t, big.NewInt(16), s.HealedBytecodes.Big())
+	assert.Nil(t, s.SyncedAccounts)
+	assert.Equal(t, []SyncStage{{Name: "Headers", BlockNumber: MustBlockNumberFromHex("0x454")}}, s.Stages)
+	assert.Equal(t, `"snap"`, string(s.Extra["syncMode"]))
+	assert.Equal(t, uint64(0xce), s.Lag())
+
+	output, err := s.MarshalJSON()
+	require.NoError(t, err)
+	assert.JSONEq(t, input, string(output))
+
+	// Without extra fields.
+	require.NoError(t, s.UnmarshalJSON([]byte(`{"startingBlock": "0x1", "currentBlock": "0x2", "highestBlock": "0x1"}`)))
+	assert.Nil(t, s.Extra)
+	assert.Equal(t, uint64(0), s.Lag())
+}
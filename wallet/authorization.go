@@ -0,0 +1,62 @@
+package wallet
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/types"
+)
+
+// SignAuthorization signs the EIP-7702 authorization with the given key and
+// sets its signature.
+func SignAuthorization(ctx context.Context, key KeyWithHashSigner, auth *types.SetCodeAuthorization) error {
+	hash, err := crypto.AuthorizationHash(auth)
+	if err != nil {
+		return err
+	}
+	sig, err := key.SignHash(ctx, hash)
+	if err != nil {
+		return err
+	}
+	if sig.V.BitLen() > 1 {
+		return fmt.Errorf("invalid authorization signature: y-parity must be 0 or 1")
+	}
+	auth.Signature = sig
+	return nil
+}
+
+// NewDelegationTransaction returns a set code transaction, sent by the key
+// address to itself, that delegates the key's code to the given address.
+//
+// The nonce is the current nonce of the key address. Because the sender
+// nonce is incremented before the authorization list is processed, the
+// authorization is signed with nonce+1.
+//
+// Only the authorization is signed; the gas fields are left empty so that
+// they can be filled by transaction modifiers before the transaction is
+// signed and sent.
+func NewDelegationTransaction(ctx context.Context, key KeyWithHashSigner, chainID, nonce uint64, delegate types.Address) (*types.Transaction, error) {
+	auth := types.SetCodeAuthorization{
+		ChainID: chainID,
+		Address: delegate,
+		Nonce:   nonce + 1,
+	}
+	if err := SignAuthorization(ctx, key, &auth); err != nil {
+		return nil, err
+	}
+	return (&types.Transaction{}).
+		SetType(types.SetCodeTxType).
+		SetFrom(key.Address()).
+		SetTo(key.Address()).
+		SetChainID(chainID).
+		SetNonce(nonce).
+		SetAuthorizationList(types.AuthorizationList{auth}), nil
+}
+
+// NewRevokeDelegationTransaction returns a set code transaction that clears
+// the EIP-7702 delegation of the key address by delegating to the zero
+// address. See NewDelegationTransaction for details.
+func NewRevokeDelegationTransaction(ctx context.Context, key KeyWithHashSigner, chainID, nonce uint64) (*types.Transaction, error) {
+	return NewDelegationTransaction(ctx, key, chainID, nonce, types.ZeroAddress)
+}
@@ -0,0 +1,34 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/types"
+)
+
+func TestNewRevokeDelegationTransaction(t *testing.T) {
+	key := NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	tx, err := NewRevokeDelegationTransaction(context.Background(), key, 1, 5)
+	require.NoError(t, err)
+
+	assert.Equal(t, types.SetCodeTxType, tx.Type)
+	assert.Equal(t, key.Address(), *tx.From)
+	assert.Equal(t, key.Address(), *tx.To)
+	assert.Equal(t, uint64(5), *tx.Nonce)
+	require.Len(t, tx.AuthorizationList, 1)
+
+	auth := tx.AuthorizationList[0]
+	assert.Equal(t, uint64(1), auth.ChainID)
+	assert.Equal(t, types.ZeroAddress, auth.Address)
+	assert.Equal(t, uint64(6), auth.Nonce)
+
+	authority, err := crypto.RecoverAuthority(&auth)
+	require.NoError(t, err)
+	assert.Equal(t, key.Address(), *authority)
+}
@@ -19,6 +19,23 @@ type Key interface {
 
 	// VerifyMessage verifies whether the given data is signed by the key.
 	VerifyMessage(ctx context.Context, data []byte, sig types.Signature) bool
+
+	// Destroy zeroes the private key material held by the key. The key
+	// cannot be used for signing afterwards, but it still can be used to
+	// verify signatures. Keys that do not hold any key material in memory
+	// implement it as a no-op.
+	Destroy()
+}
+
+// KeyWithAddresses is the interface for a key whose address may change over
+// time, such as RotatingKey. Clients that index keys by address use it to
+// find the key of an address that is no longer the current one.
+type KeyWithAddresses interface {
+	Key
+
+	// Addresses returns the current address of the key followed by its
+	// previous addresses.
+	Addresses() []types.Address
 }
 
 // KeyWithHashSigner is the interface for an Ethereum key that can sign data using
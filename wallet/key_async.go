@@ -0,0 +1,149 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/types"
+)
+
+// ErrSigningRejected may be returned by PendingSignature.Wait if the signing
+// request was rejected, for example by an approver.
+var ErrSigningRejected = errors.New("signing request rejected")
+
+// AsyncKey is the interface for keys where signing may take a long time and
+// require multiple round-trips, such as threshold (MPC) signers or signers
+// that require a manual approval.
+//
+// Instead of returning a signature, the signing methods start a signing
+// request and return a handle that can be used to wait for the result.
+//
+// An AsyncKey can be used where a Key is expected by wrapping it with
+// NewAwaitingKey.
+type AsyncKey interface {
+	// Address returns the address of the key.
+	Address() types.Address
+
+	// RequestMessageSignature starts a request to sign the given message.
+	// The resulting signature must be the same as returned by
+	// Key.SignMessage.
+	RequestMessageSignature(ctx context.Context, data []byte) (PendingSignature, error)
+
+	// RequestTransactionSignature starts a request to sign the given
+	// transaction. The resulting signature must be ready to be used as
+	// the transaction signature, including the V value adjusted for the
+	// transaction type. The transaction must not be modified.
+	RequestTransactionSignature(ctx context.Context, tx *types.Transaction) (PendingSignature, error)
+
+	// VerifyMessage verifies whether the given data is signed by the key.
+	VerifyMessage(ctx context.Context, data []byte, sig types.Signature) bool
+
+	// Destroy zeroes the private key material held by the key, if any.
+	Destroy()
+}
+
+// PendingSignature is a handle to a signing request started by an AsyncKey.
+type PendingSignature interface {
+	// ID returns the request identifier, for example the ID of the approval
+	// request in the external system.
+	ID() string
+
+	// Wait blocks until the signature is available, the request fails or
+	// the context is canceled.
+	Wait(ctx context.Context) (*types.Signature, error)
+
+	// Cancel cancels the request. Canceling a completed request is a no-op.
+	Cancel(ctx context.Context) error
+}
+
+// AwaitingKey is a Key that uses an AsyncKey to sign data and blocks until
+// the signing request is completed.
+//
+// If the context is canceled while waiting, the pending request is canceled.
+type AwaitingKey struct {
+	key     AsyncKey
+	recover crypto.Recoverer
+}
+
+// NewAwaitingKey returns a new AwaitingKey that wraps the given AsyncKey.
+func NewAwaitingKey(key AsyncKey) *AwaitingKey {
+	return &AwaitingKey{key: key, recover: crypto.ECRecoverer}
+}
+
+// AsyncKey returns the wrapped AsyncKey.
+func (k *AwaitingKey) AsyncKey() AsyncKey {
+	return k.key
+}
+
+// Address implements the Key interface.
+func (k *AwaitingKey) Address() types.Address {
+	return k.key.Address()
+}
+
+// SignMessage implements the Key interface.
+func (k *AwaitingKey) SignMessage(ctx context.Context, data []byte) (*types.Signature, error) {
+	pending, err := k.key.RequestMessageSignature(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := k.wait(ctx, pending)
+	if err != nil {
+		return nil, err
+	}
+	if !k.key.VerifyMessage(ctx, data, *sig) {
+		return nil, fmt.Errorf("awaiting key: invalid signature for request %s", pending.ID())
+	}
+	return sig, nil
+}
+
+// SignTransaction implements the Key interface.
+func (k *AwaitingKey) SignTransaction(ctx context.Context, tx *types.Transaction) error {
+	from := k.key.Address()
+	if tx.From != nil && *tx.From != from {
+		return fmt.Errorf("awaiting key: invalid signer address: %s", tx.From)
+	}
+	txCpy := tx.Copy()
+	txCpy.From = &from
+	pending, err := k.key.RequestTransactionSignature(ctx, txCpy)
+	if err != nil {
+		return err
+	}
+	sig, err := k.wait(ctx, pending)
+	if err != nil {
+		return err
+	}
+	txCpy.Signature = sig
+	addr, err := k.recover.RecoverTransaction(txCpy)
+	if err != nil || *addr != from {
+		return fmt.Errorf("awaiting key: invalid signature for request %s", pending.ID())
+	}
+	tx.From = &from
+	tx.Signature = sig
+	return nil
+}
+
+// VerifyMessage implements the Key interface.
+func (k *AwaitingKey) VerifyMessage(ctx context.Context, data []byte, sig types.Signature) bool {
+	return k.key.VerifyMessage(ctx, data, sig)
+}
+
+// Destroy implements the Key interface.
+func (k *AwaitingKey) Destroy() {
+	k.key.Destroy()
+}
+
+func (k *AwaitingKey) wait(ctx context.Context, pending PendingSignature) (*types.Signature, error) {
+	sig, err := pending.Wait(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			_ = pending.Cancel(context.Background())
+		}
+		return nil, fmt.Errorf("awaiting key: signing request %s failed: %w", pending.ID(), err)
+	}
+	if sig == nil {
+		return nil, fmt.Errorf("awaiting key: signing request %s returned no signature", pending.ID())
+	}
+	return sig, nil
+}
@@ -0,0 +1,113 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// approvalKey is an AsyncKey that signs requests using a private key after
+// they are approved or rejected by sending a value to the approve channel.
+type approvalKey struct {
+	*PrivateKey
+
+	approve chan bool
+	n       int
+}
+
+type approvalRequest struct {
+	id       string
+	sign     func() (*types.Signature, error)
+	approve  chan bool
+	canceled bool
+}
+
+func (k *approvalKey) RequestMessageSignature(ctx context.Context, data []byte) (PendingSignature, error) {
+	return k.request(func() (*types.Signature, error) {
+		return k.SignMessage(ctx, data)
+	}), nil
+}
+
+func (k *approvalKey) RequestTransactionSignature(ctx context.Context, tx *types.Transaction) (PendingSignature, error) {
+	return k.request(func() (*types.Signature, error) {
+		txCpy := tx.Copy()
+		if err := k.SignTransaction(ctx, txCpy); err != nil {
+			return nil, err
+		}
+		return txCpy.Signature, nil
+	}), nil
+}
+
+func (k *approvalKey) request(sign func() (*types.Signature, error)) *approvalRequest {
+	k.n++
+	return &approvalRequest{id: strconv.Itoa(k.n), sign: sign, approve: k.approve}
+}
+
+func (r *approvalRequest) ID() string {
+	return r.id
+}
+
+func (r *approvalRequest) Wait(ctx context.Context) (*types.Signature, error) {
+	select {
+	case ok := <-r.approve:
+		if !ok {
+			return nil, ErrSigningRejected
+		}
+		return r.sign()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (r *approvalRequest) Cancel(context.Context) error {
+	r.canceled = true
+	return nil
+}
+
+func TestAwaitingKey(t *testing.T) {
+	async := &approvalKey{
+		PrivateKey: NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32)),
+		approve:    make(chan bool, 1),
+	}
+	key := NewAwaitingKey(async)
+
+	// Approved message.
+	async.approve <- true
+	sig, err := key.SignMessage(context.Background(), []byte("foo"))
+	require.NoError(t, err)
+	assert.True(t, async.VerifyMessage(context.Background(), []byte("foo"), *sig))
+
+	// Approved transaction.
+	async.approve <- true
+	tx := (&types.Transaction{}).
+		SetTo(types.MustAddressFromHex("0x2222222222222222222222222222222222222222")).
+		SetChainID(1).
+		SetNonce(0).
+		SetGasLimit(21000).
+		SetGasPrice(big.NewInt(1))
+	require.NoError(t, key.SignTransaction(context.Background(), tx))
+	assert.Equal(t, async.Address(), *tx.From)
+	assert.NotNil(t, tx.Signature)
+
+	// Rejected request.
+	async.approve <- false
+	_, err = key.SignMessage(context.Background(), []byte("foo"))
+	assert.ErrorIs(t, err, ErrSigningRejected)
+
+	// Canceled context.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	pending, err := async.RequestMessageSignature(ctx, []byte("foo"))
+	require.NoError(t, err)
+	_, err = key.wait(ctx, pending)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.True(t, pending.(*approvalRequest).canceled)
+}
@@ -0,0 +1,214 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/defiweb/go-eth/crypto"
+)
+
+// The functions below store mnemonics and private keys encrypted with
+// a passphrase. Unlike the V3 keystore format, which can only hold a single
+// private key, the format can also hold a mnemonic, so that any number of
+// keys can be derived from a single file.
+//
+// The passphrase is stretched using scrypt and the secret is encrypted using
+// ChaCha20-Poly1305, the same primitives as used by the age file encryption
+// tool.
+//
+// All intermediate buffers holding the decrypted secret, the seed or the
+// encryption key are zeroed as soon as they are no longer needed. Note that
+// Go strings are immutable and cannot be zeroed, so the mnemonic passed to
+// EncryptMnemonic should be discarded by the caller as soon as possible.
+
+const (
+	encryptedVersion        = 1
+	encryptedKindMnemonic   = "mnemonic"
+	encryptedKindPrivateKey = "private-key"
+)
+
+type jsonEncrypted struct {
+	Version    int64                  `json:"version"`
+	Kind       string                 `json:"kind"`
+	KDF        string                 `json:"kdf"`
+	KDFParams  jsonEncryptedKDFParams `json:"kdfparams"`
+	Cipher     string                 `json:"cipher"`
+	Nonce      jsonHex                `json:"nonce"`
+	CipherText jsonHex                `json:"ciphertext"`
+}
+
+type jsonEncryptedKDFParams struct {
+	Salt jsonHex `json:"salt"`
+	N    int     `json:"n"`
+	P    int     `json:"p"`
+	R    int     `json:"r"`
+}
+
+// EncryptMnemonic encrypts the mnemonic and the optional BIP-39 password
+// using the given passphrase. The scryptN and scryptP parameters control the
+// cost of the key derivation, the StandardScryptN and StandardScryptP
+// constants are recommended.
+//
+// The encrypted mnemonic can be opened using NewMnemonicFromEncrypted or
+// DeriveFromEncryptedMnemonic.
+func EncryptMnemonic(mnemonic, password, passphrase string, scryptN, scryptP int) ([]byte, error) {
+	if !bip39.IsMnemonicValid(mnemonic) {
+		return nil, errors.New("invalid mnemonic")
+	}
+	plainText := make([]byte, 0, len(mnemonic)+len(password)+1)
+	plainText = append(plainText, mnemonic...)
+	plainText = append(plainText, 0)
+	plainText = append(plainText, password...)
+	defer crypto.ZeroBytes(plainText)
+	return encryptSecret(encryptedKindMnemonic, plainText, []byte(passphrase), scryptN, scryptP)
+}
+
+// EncryptPrivateKey encrypts the private key using the given passphrase.
+// The scryptN and scryptP parameters control the cost of the key derivation,
+// the StandardScryptN and StandardScryptP constants are recommended.
+//
+// The encrypted key can be opened using NewKeyFromEncrypted.
+func EncryptPrivateKey(key *PrivateKey, passphrase string, scryptN, scryptP int) ([]byte, error) {
+	if key.IsDestroyed() {
+		return nil, errors.New("private key is destroyed")
+	}
+	plainText := make([]byte, 32)
+	defer crypto.ZeroBytes(plainText)
+	key.private.D.FillBytes(plainText)
+	return encryptSecret(encryptedKindPrivateKey, plainText, []byte(passphrase), scryptN, scryptP)
+}
+
+// NewMnemonicFromEncrypted decrypts the mnemonic encrypted by
+// EncryptMnemonic. Only the master key is kept in memory, the mnemonic phrase
+// and the seed are zeroed before the function returns.
+func NewMnemonicFromEncrypted(content []byte, passphrase string) (Mnemonic, error) {
+	plainText, err := decryptSecret(encryptedKindMnemonic, content, []byte(passphrase))
+	if err != nil {
+		return Mnemonic{}, err
+	}
+	defer crypto.ZeroBytes(plainText)
+	sep := bytes.IndexByte(plainText, 0)
+	if sep < 0 {
+		return Mnemonic{}, errors.New("invalid encrypted mnemonic")
+	}
+	// The seed is derived directly from the byte slices, as described in
+	// BIP-39, to avoid creating copies of the mnemonic as strings.
+	salt := make([]byte, 0, len("mnemonic")+len(plainText)-sep-1)
+	salt = append(salt, "mnemonic"...)
+	salt = append(salt, plainText[sep+1:]...)
+	defer crypto.ZeroBytes(salt)
+	seed := pbkdf2.Key(plainText[:sep], salt, 2048, 64, sha512.New)
+	defer crypto.ZeroBytes(seed)
+	masterKey, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		return Mnemonic{}, err
+	}
+	return Mnemonic{masterKey: masterKey}, nil
+}
+
+// DeriveFromEncryptedMnemonic decrypts the mnemonic encrypted by
+// EncryptMnemonic and derives a single private key using the given
+// derivation path. The decrypted mnemonic is not kept in memory.
+func DeriveFromEncryptedMnemonic(content []byte, passphrase string, path DerivationPath) (*PrivateKey, error) {
+	m, err := NewMnemonicFromEncrypted(content, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer m.Destroy()
+	return m.Derive(path)
+}
+
+// NewKeyFromEncrypted decrypts the private key encrypted by
+// EncryptPrivateKey.
+func NewKeyFromEncrypted(content []byte, passphrase string) (*PrivateKey, error) {
+	plainText, err := decryptSecret(encryptedKindPrivateKey, content, []byte(passphrase))
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.ZeroBytes(plainText)
+	if len(plainText) != 32 || new(big.Int).SetBytes(plainText).Sign() == 0 {
+		return nil, errors.New("invalid encrypted private key")
+	}
+	return NewKeyFromBytes(plainText), nil
+}
+
+func encryptSecret(kind string, plainText, passphrase []byte, scryptN, scryptP int) ([]byte, error) {
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	params := jsonEncryptedKDFParams{Salt: salt, N: scryptN, P: scryptP, R: scryptR}
+	aead, err := newSecretAEAD(params, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(jsonEncrypted{
+		Version:    encryptedVersion,
+		Kind:       kind,
+		KDF:        "scrypt",
+		KDFParams:  params,
+		Cipher:     "xchacha20-poly1305",
+		Nonce:      nonce,
+		CipherText: aead.Seal(nil, nonce, plainText, []byte(kind)),
+	})
+}
+
+func decryptSecret(kind string, content, passphrase []byte) ([]byte, error) {
+	var enc jsonEncrypted
+	if err := json.Unmarshal(content, &enc); err != nil {
+		return nil, err
+	}
+	if enc.Version != encryptedVersion {
+		return nil, fmt.Errorf("unsupported version: %d", enc.Version)
+	}
+	if enc.Kind != kind {
+		return nil, fmt.Errorf("expected %s, got %s", kind, enc.Kind)
+	}
+	if enc.KDF != "scrypt" {
+		return nil, fmt.Errorf("unsupported KDF: %s", enc.KDF)
+	}
+	if enc.Cipher != "xchacha20-poly1305" {
+		return nil, fmt.Errorf("cipher not supported: %s", enc.Cipher)
+	}
+	if len(enc.Nonce) != chacha20poly1305.NonceSizeX {
+		return nil, errors.New("invalid nonce")
+	}
+	aead, err := newSecretAEAD(enc.KDFParams, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	plainText, err := aead.Open(nil, enc.Nonce, enc.CipherText, []byte(kind))
+	if err != nil {
+		return nil, errors.New("invalid passphrase or encrypted data")
+	}
+	return plainText, nil
+}
+
+// newSecretAEAD derives the encryption key from the passphrase and returns
+// the cipher. The derived key is zeroed before returning, the cipher keeps
+// its own copy.
+func newSecretAEAD(params jsonEncryptedKDFParams, passphrase []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, params.Salt, params.N, params.R, params.P, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	defer crypto.ZeroBytes(key)
+	return chacha20poly1305.NewX(key)
+}
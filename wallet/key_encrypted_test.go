@@ -0,0 +1,50 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptMnemonic(t *testing.T) {
+	const mnemonic = "gravity trophy shrimp suspect sheriff avocado label trust dove tragic pitch title network myself spell task protect smooth sword diary brain blossom under bulb"
+
+	content, err := EncryptMnemonic(mnemonic, "password", "passphrase", LightScryptN, LightScryptP)
+	require.NoError(t, err)
+
+	m, err := NewMnemonic(mnemonic, "password")
+	require.NoError(t, err)
+	expected, err := m.Derive(DefaultDerivationPath)
+	require.NoError(t, err)
+
+	key, err := DeriveFromEncryptedMnemonic(content, "passphrase", DefaultDerivationPath)
+	require.NoError(t, err)
+	assert.Equal(t, expected.Address(), key.Address())
+
+	_, err = DeriveFromEncryptedMnemonic(content, "wrong", DefaultDerivationPath)
+	assert.Error(t, err)
+
+	_, err = NewKeyFromEncrypted(content, "passphrase")
+	assert.Error(t, err)
+
+	_, err = EncryptMnemonic("foo bar", "", "passphrase", LightScryptN, LightScryptP)
+	assert.Error(t, err)
+}
+
+func TestEncryptPrivateKey(t *testing.T) {
+	key := NewRandomKey()
+
+	content, err := EncryptPrivateKey(key, "passphrase", LightScryptN, LightScryptP)
+	require.NoError(t, err)
+
+	decrypted, err := NewKeyFromEncrypted(content, "passphrase")
+	require.NoError(t, err)
+	assert.Equal(t, key.Address(), decrypted.Address())
+
+	_, err = NewKeyFromEncrypted(content, "wrong")
+	assert.Error(t, err)
+
+	_, err = NewMnemonicFromEncrypted(content, "passphrase")
+	assert.Error(t, err)
+}
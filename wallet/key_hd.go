@@ -120,6 +120,14 @@ func (m Mnemonic) Derive(path DerivationPath) (*PrivateKey, error) {
 	return NewKeyFromECDSA(privKeyECDSA), nil
 }
 
+// Destroy zeroes the master key. The mnemonic cannot be used to derive keys
+// afterwards.
+func (m Mnemonic) Destroy() {
+	if m.masterKey != nil {
+		m.masterKey.Zero()
+	}
+}
+
 // ParseDerivationPath converts a BIP-33 derivation path string into the
 // internal binary format.
 //
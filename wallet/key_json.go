@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/defiweb/go-eth/crypto"
 	"github.com/defiweb/go-eth/types"
 )
 
@@ -34,6 +35,7 @@ func NewKeyFromJSONContent(content []byte, passphrase string) (*PrivateKey, erro
 	if err != nil {
 		return nil, err
 	}
+	defer crypto.ZeroBytes(prv)
 	key := NewKeyFromBytes(prv)
 	if !jKey.Address.IsZero() && jKey.Address != key.Address() {
 		return nil, errors.New("decrypted key address does not match address in file")
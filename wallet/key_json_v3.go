@@ -41,6 +41,7 @@ func encryptV3Key(key *ecdsa.PrivateKey, passphrase string, scryptN, scryptP int
 	if err != nil {
 		return nil, err
 	}
+	defer crypto.ZeroBytes(derivedKey)
 
 	// Generate a random IV.
 	iv := make([]byte, aes.BlockSize)
@@ -49,9 +50,9 @@ func encryptV3Key(key *ecdsa.PrivateKey, passphrase string, scryptN, scryptP int
 	}
 
 	// Encrypt the key with AES-128-CTR.
-	d := key.D.Bytes()
 	data := make([]byte, 32)
-	copy(data[32-len(d):], d)
+	defer crypto.ZeroBytes(data)
+	key.D.FillBytes(data)
 	cipherText, err := aesCTRXOR(derivedKey[:16], data, iv)
 	if err != nil {
 		return nil, err
@@ -5,6 +5,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/rand"
 	"encoding/json"
+	"errors"
 
 	"github.com/btcsuite/btcd/btcec/v2"
 
@@ -14,6 +15,10 @@ import (
 
 var s256 = btcec.S256()
 
+// PrivateKey is a Key that holds the private key in memory.
+//
+// The private key is never printed by the fmt package, and it can be zeroed
+// using the Destroy method once it is no longer needed.
 type PrivateKey struct {
 	private *ecdsa.PrivateKey
 	public  *ecdsa.PublicKey
@@ -36,6 +41,7 @@ func NewKeyFromECDSA(prv *ecdsa.PrivateKey) *PrivateKey {
 // NewKeyFromBytes creates a new private key from private key bytes.
 func NewKeyFromBytes(prv []byte) *PrivateKey {
 	key, _ := btcec.PrivKeyFromBytes(prv)
+	defer key.Zero()
 	return NewKeyFromECDSA(key.ToECDSA())
 }
 
@@ -54,12 +60,18 @@ func (k *PrivateKey) PublicKey() *ecdsa.PublicKey {
 }
 
 // PrivateKey returns the ECDSA private key.
+//
+// The returned key is not a copy, it is zeroed when the Destroy method is
+// called.
 func (k *PrivateKey) PrivateKey() *ecdsa.PrivateKey {
 	return k.private
 }
 
 // JSON returns the JSON representation of the private key.
 func (k *PrivateKey) JSON(passphrase string, scryptN, scryptP int) ([]byte, error) {
+	if k.IsDestroyed() {
+		return nil, errors.New("private key is destroyed")
+	}
 	key, err := encryptV3Key(k.private, passphrase, scryptN, scryptP)
 	if err != nil {
 		return nil, err
@@ -104,3 +116,25 @@ func (k *PrivateKey) VerifyMessage(_ context.Context, data []byte, sig types.Sig
 	}
 	return *addr == k.address
 }
+
+// Destroy implements the Key interface.
+func (k *PrivateKey) Destroy() {
+	crypto.ZeroECPrivateKey(k.private)
+}
+
+// IsDestroyed returns true if the private key has been destroyed.
+func (k *PrivateKey) IsDestroyed() bool {
+	return k.private.D == nil || k.private.D.Sign() == 0
+}
+
+// String implements the fmt.Stringer interface. It returns the key address,
+// so the private key is never printed by accident.
+func (k *PrivateKey) String() string {
+	return "PrivateKey(" + k.address.String() + ")"
+}
+
+// GoString implements the fmt.GoStringer interface. It returns the key
+// address, so the private key is never printed by accident.
+func (k *PrivateKey) GoString() string {
+	return k.String()
+}
@@ -0,0 +1,37 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrivateKey_Destroy(t *testing.T) {
+	key := NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	sig, err := key.SignMessage(context.Background(), []byte("foo"))
+	require.NoError(t, err)
+
+	key.Destroy()
+	assert.True(t, key.IsDestroyed())
+	assert.Zero(t, key.PrivateKey().D.Sign())
+
+	_, err = key.SignMessage(context.Background(), []byte("foo"))
+	assert.Error(t, err)
+	_, err = key.JSON("passphrase", LightScryptN, LightScryptP)
+	assert.Error(t, err)
+
+	// Verification does not require the private key.
+	assert.True(t, key.VerifyMessage(context.Background(), []byte("foo"), *sig))
+}
+
+func TestPrivateKey_Format(t *testing.T) {
+	key := NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	expected := "PrivateKey(" + key.Address().String() + ")"
+	for _, f := range []string{"%v", "%+v", "%#v", "%s"} {
+		assert.Equal(t, expected, fmt.Sprintf(f, key), f)
+	}
+}
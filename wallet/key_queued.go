@@ -0,0 +1,299 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/types"
+)
+
+// ErrQueueClosed is returned by QueuedKey after it is closed.
+var ErrQueueClosed = errors.New("queued key: queue is closed")
+
+// BatchSigner is implemented by remote signers, such as key management
+// services, that can sign multiple hashes in a single request.
+type BatchSigner interface {
+	// SignHashes signs the given hashes without the EIP-191 message prefix.
+	// The signatures must be returned in the same order as the hashes, and
+	// their V values must be the recovery IDs (0 or 1), the same as returned
+	// by KeyWithHashSigner.SignHash.
+	SignHashes(ctx context.Context, hashes []types.Hash) ([]*types.Signature, error)
+}
+
+// QueuedKey is a Key wrapper for remote signers. Signing requests are placed
+// in a queue and sent to the underlying key by background workers, which
+// coalesce concurrent requests into batches if the key implements the
+// BatchSigner interface, limit the number of concurrent requests and retry
+// failed requests.
+//
+// All signing methods are reduced to signing a hash, so the underlying key
+// must implement the KeyWithHashSigner interface.
+type QueuedKey struct {
+	opts QueuedKeyOptions
+
+	batch   BatchSigner
+	queueCh chan *signRequest
+	sem     chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// QueuedKeyOptions contains options for NewQueuedKey.
+type QueuedKeyOptions struct {
+	// Key is the underlying key. If it implements the BatchSigner interface,
+	// requests are signed in batches.
+	Key KeyWithHashSigner
+
+	// MaxBatchSize is the maximum number of hashes signed in a single batch
+	// request. Default is 32.
+	MaxBatchSize int
+
+	// BatchDelay is the time to wait for more requests after the first
+	// request of a batch arrives. Default is 5ms.
+	BatchDelay time.Duration
+
+	// Concurrency is the maximum number of concurrent requests to the
+	// underlying key. Default is 4.
+	Concurrency int
+
+	// MaxRetries is the maximum number of retries of a failed request.
+	// Zero means no retries.
+	MaxRetries int
+
+	// RetryDelay is the delay before the first retry. It is doubled after
+	// each retry. Default is 100ms.
+	RetryDelay time.Duration
+}
+
+type signRequest struct {
+	ctx   context.Context
+	hash  types.Hash
+	resCh chan signResult
+}
+
+type signResult struct {
+	sig *types.Signature
+	err error
+}
+
+// NewQueuedKey returns a new QueuedKey. The Close method must be called to
+// stop the background workers.
+func NewQueuedKey(opts QueuedKeyOptions) (*QueuedKey, error) {
+	if opts.Key == nil {
+		return nil, errors.New("queued key: key is required")
+	}
+	if opts.MaxBatchSize <= 0 {
+		opts.MaxBatchSize = 32
+	}
+	if opts.BatchDelay <= 0 {
+		opts.BatchDelay = 5 * time.Millisecond
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = 100 * time.Millisecond
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	k := &QueuedKey{
+		opts:    opts,
+		queueCh: make(chan *signRequest),
+		sem:     make(chan struct{}, opts.Concurrency),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	k.batch, _ = opts.Key.(BatchSigner)
+	k.wg.Add(1)
+	go k.dispatchRoutine()
+	return k, nil
+}
+
+// Close stops the background workers. Pending requests fail with
+// ErrQueueClosed.
+func (k *QueuedKey) Close() {
+	k.cancel()
+	k.wg.Wait()
+}
+
+// Address implements the Key interface.
+func (k *QueuedKey) Address() types.Address {
+	return k.opts.Key.Address()
+}
+
+// SignHash implements the KeyWithHashSigner interface.
+func (k *QueuedKey) SignHash(ctx context.Context, hash types.Hash) (*types.Signature, error) {
+	req := &signRequest{ctx: ctx, hash: hash, resCh: make(chan signResult, 1)}
+	select {
+	case k.queueCh <- req:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-k.ctx.Done():
+		return nil, ErrQueueClosed
+	}
+	select {
+	case res := <-req.resCh:
+		return res.sig, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// SignMessage implements the Key interface.
+func (k *QueuedKey) SignMessage(ctx context.Context, data []byte) (*types.Signature, error) {
+	sig, err := k.SignHash(ctx, crypto.Keccak256(crypto.AddMessagePrefix(data)))
+	if err != nil {
+		return nil, err
+	}
+	return types.SignatureFromVRSPtr(new(big.Int).Add(sig.V, big.NewInt(27)), sig.R, sig.S), nil
+}
+
+// SignTransaction implements the Key interface.
+func (k *QueuedKey) SignTransaction(ctx context.Context, tx *types.Transaction) error {
+	from := k.Address()
+	if tx.From != nil && *tx.From != from {
+		return fmt.Errorf("queued key: invalid signer address: %s", tx.From)
+	}
+	hash, err := crypto.TransactionSigningHash(tx)
+	if err != nil {
+		return err
+	}
+	sig, err := k.SignHash(ctx, hash)
+	if err != nil {
+		return err
+	}
+	txSig, err := crypto.TransactionSignature(tx, *sig)
+	if err != nil {
+		return err
+	}
+	tx.From = &from
+	tx.Signature = txSig
+	return nil
+}
+
+// VerifyHash implements the KeyWithHashSigner interface.
+func (k *QueuedKey) VerifyHash(ctx context.Context, hash types.Hash, sig types.Signature) bool {
+	return k.opts.Key.VerifyHash(ctx, hash, sig)
+}
+
+// VerifyMessage implements the Key interface.
+func (k *QueuedKey) VerifyMessage(ctx context.Context, data []byte, sig types.Signature) bool {
+	return k.opts.Key.VerifyMessage(ctx, data, sig)
+}
+
+// Destroy implements the Key interface. It stops the background workers and
+// destroys the underlying key.
+func (k *QueuedKey) Destroy() {
+	k.Close()
+	k.opts.Key.Destroy()
+}
+
+// dispatchRoutine collects requests into batches and dispatches them to
+// workers, respecting the concurrency limit.
+func (k *QueuedKey) dispatchRoutine() {
+	defer k.wg.Done()
+	var workers sync.WaitGroup
+	defer workers.Wait()
+	for {
+		var batch []*signRequest
+		select {
+		case <-k.ctx.Done():
+			return
+		case req := <-k.queueCh:
+			batch = append(batch, req)
+		}
+		if k.batch != nil {
+			timer := time.NewTimer(k.opts.BatchDelay)
+		collect:
+			for len(batch) < k.opts.MaxBatchSize {
+				select {
+				case req := <-k.queueCh:
+					batch = append(batch, req)
+				case <-timer.C:
+					break collect
+				case <-k.ctx.Done():
+					break collect
+				}
+			}
+			timer.Stop()
+		}
+		select {
+		case k.sem <- struct{}{}:
+		case <-k.ctx.Done():
+			for _, req := range batch {
+				req.resCh <- signResult{err: ErrQueueClosed}
+			}
+			return
+		}
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			defer func() { <-k.sem }()
+			k.sign(batch)
+		}()
+	}
+}
+
+// sign signs the batch of requests, retrying on failure.
+func (k *QueuedKey) sign(batch []*signRequest) {
+	// Requests whose callers are no longer waiting are skipped.
+	pending := batch[:0]
+	for _, req := range batch {
+		if err := req.ctx.Err(); err != nil {
+			req.resCh <- signResult{err: err}
+			continue
+		}
+		pending = append(pending, req)
+	}
+	if len(pending) == 0 {
+		return
+	}
+	var (
+		sigs  []*types.Signature
+		err   error
+		delay = k.opts.RetryDelay
+	)
+	for i := 0; ; i++ {
+		sigs, err = k.signHashes(pending)
+		if err == nil || i >= k.opts.MaxRetries || k.ctx.Err() != nil {
+			break
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-k.ctx.Done():
+		case <-t.C:
+		}
+		t.Stop()
+		delay *= 2
+	}
+	if err == nil && len(sigs) != len(pending) {
+		err = fmt.Errorf("queued key: expected %d signatures, got %d", len(pending), len(sigs))
+	}
+	for i, req := range pending {
+		if err != nil {
+			req.resCh <- signResult{err: err}
+			continue
+		}
+		req.resCh <- signResult{sig: sigs[i]}
+	}
+}
+
+func (k *QueuedKey) signHashes(batch []*signRequest) ([]*types.Signature, error) {
+	if k.batch != nil {
+		hashes := make([]types.Hash, len(batch))
+		for i, req := range batch {
+			hashes[i] = req.hash
+		}
+		return k.batch.SignHashes(k.ctx, hashes)
+	}
+	sig, err := k.opts.Key.SignHash(batch[0].ctx, batch[0].hash)
+	if err != nil {
+		return nil, err
+	}
+	return []*types.Signature{sig}, nil
+}
@@ -0,0 +1,129 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/crypto"
+	"github.com/defiweb/go-eth/types"
+)
+
+// batchKey is a PrivateKey that implements the BatchSigner interface and
+// records the size of every batch.
+type batchKey struct {
+	*PrivateKey
+
+	mu      sync.Mutex
+	batches []int
+	fails   int
+}
+
+func (k *batchKey) SignHashes(ctx context.Context, hashes []types.Hash) ([]*types.Signature, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.fails > 0 {
+		k.fails--
+		return nil, errors.New("temporary error")
+	}
+	k.batches = append(k.batches, len(hashes))
+	sigs := make([]*types.Signature, len(hashes))
+	for i, h := range hashes {
+		sig, err := k.SignHash(ctx, h)
+		if err != nil {
+			return nil, err
+		}
+		sigs[i] = sig
+	}
+	return sigs, nil
+}
+
+func TestQueuedKey_Batching(t *testing.T) {
+	key := &batchKey{PrivateKey: NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))}
+	qk, err := NewQueuedKey(QueuedKeyOptions{
+		Key:          key,
+		MaxBatchSize: 8,
+		BatchDelay:   50 * time.Millisecond,
+		Concurrency:  1,
+	})
+	require.NoError(t, err)
+	defer qk.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			data := []byte{byte(i)}
+			sig, err := qk.SignMessage(context.Background(), data)
+			require.NoError(t, err)
+			addr, err := crypto.ECRecoverer.RecoverMessage(data, *sig)
+			require.NoError(t, err)
+			assert.Equal(t, key.Address(), *addr)
+		}(i)
+	}
+	wg.Wait()
+
+	total := 0
+	for _, n := range key.batches {
+		assert.LessOrEqual(t, n, 8)
+		total += n
+	}
+	assert.Equal(t, 16, total)
+	assert.Less(t, len(key.batches), 16)
+}
+
+func TestQueuedKey_SignTransaction(t *testing.T) {
+	key := NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	qk, err := NewQueuedKey(QueuedKeyOptions{Key: key})
+	require.NoError(t, err)
+	defer qk.Close()
+
+	tx := (&types.Transaction{}).
+		SetTo(types.MustAddressFromHex("0x2222222222222222222222222222222222222222")).
+		SetChainID(1).
+		SetNonce(0).
+		SetGasLimit(21000).
+		SetGasPrice(big.NewInt(1))
+	expected := tx.Copy()
+	require.NoError(t, key.SignTransaction(context.Background(), expected))
+	require.NoError(t, qk.SignTransaction(context.Background(), tx))
+	assert.Equal(t, expected.Signature, tx.Signature)
+	assert.Equal(t, key.Address(), *tx.From)
+}
+
+func TestQueuedKey_Retry(t *testing.T) {
+	key := &batchKey{PrivateKey: NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32)), fails: 2}
+	qk, err := NewQueuedKey(QueuedKeyOptions{
+		Key:        key,
+		MaxRetries: 2,
+		RetryDelay: time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer qk.Close()
+
+	hash := types.MustHashFromBytes(bytes.Repeat([]byte{0x02}, 32), types.PadNone)
+	sig, err := qk.SignHash(context.Background(), hash)
+	require.NoError(t, err)
+	assert.True(t, key.VerifyHash(context.Background(), hash, *sig))
+
+	key.fails = 3
+	_, err = qk.SignHash(context.Background(), hash)
+	require.Error(t, err)
+}
+
+func TestQueuedKey_Close(t *testing.T) {
+	qk, err := NewQueuedKey(QueuedKeyOptions{Key: NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))})
+	require.NoError(t, err)
+	qk.Close()
+
+	_, err = qk.SignMessage(context.Background(), []byte("foo"))
+	assert.ErrorIs(t, err, ErrQueueClosed)
+}
@@ -0,0 +1,262 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+// RotatingKey is a Key wrapper that signs with the current key, but still
+// recognizes signatures made by keys that have been rotated out. It can be
+// used by services that periodically rotate their hot keys.
+//
+// Rotations may be performed immediately using the Rotate method, or
+// scheduled for a specific time or block height using the ScheduleRotation
+// method. Scheduled rotations are applied lazily, before the next signing
+// request.
+//
+// The key can be passed to rpc.WithKeys. The client looks the key up by its
+// current address, and by the previous ones, because RotatingKey implements
+// the KeyWithAddresses interface.
+type RotatingKey struct {
+	mu       sync.Mutex
+	opts     RotatingKeyOptions
+	current  Key
+	previous []Key
+	pending  []Rotation
+	now      func() time.Time
+}
+
+// RotatingKeyOptions contains options for NewRotatingKey.
+type RotatingKeyOptions struct {
+	// Key is the initial key.
+	Key Key
+
+	// BlockNumber returns the current block number. It is required for
+	// rotations scheduled at a block height.
+	BlockNumber func(ctx context.Context) (uint64, error)
+
+	// OnRotate is an optional callback called after the key is rotated.
+	OnRotate func(from, to types.Address)
+}
+
+// Rotation describes a scheduled key rotation. The rotation is applied when
+// both the time and the block conditions are met. At least one of them must
+// be set.
+type Rotation struct {
+	// Key is the new key.
+	Key Key
+
+	// Time is the time after which the key is rotated. If zero, the time is
+	// not checked.
+	Time time.Time
+
+	// Block is the block height at which the key is rotated. If zero, the
+	// block height is not checked.
+	Block uint64
+}
+
+// NewRotatingKey returns a new RotatingKey.
+func NewRotatingKey(opts RotatingKeyOptions) (*RotatingKey, error) {
+	if opts.Key == nil {
+		return nil, errors.New("rotating key: key is required")
+	}
+	return &RotatingKey{opts: opts, current: opts.Key, now: time.Now}, nil
+}
+
+// Address implements the Key interface. It returns the address of the
+// current key. Scheduled rotations that are already due are not taken into
+// account until the next signing request.
+func (k *RotatingKey) Address() types.Address {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.current.Address()
+}
+
+// Addresses implements the KeyWithAddresses interface. It returns the
+// address of the current key followed by the addresses of previous keys,
+// from the most recent one.
+func (k *RotatingKey) Addresses() []types.Address {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	addrs := make([]types.Address, 0, len(k.previous)+1)
+	addrs = append(addrs, k.current.Address())
+	for i := len(k.previous) - 1; i >= 0; i-- {
+		addrs = append(addrs, k.previous[i].Address())
+	}
+	return addrs
+}
+
+// HasAddress returns true if the address belongs to the current or one of
+// the previous keys.
+func (k *RotatingKey) HasAddress(addr types.Address) bool {
+	for _, a := range k.Addresses() {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// Rotate immediately replaces the current key with the given one.
+func (k *RotatingKey) Rotate(key Key) error {
+	if key == nil {
+		return errors.New("rotating key: key is required")
+	}
+	k.mu.Lock()
+	from := k.rotate(key)
+	k.mu.Unlock()
+	if k.opts.OnRotate != nil {
+		k.opts.OnRotate(from, key.Address())
+	}
+	return nil
+}
+
+// ScheduleRotation schedules a key rotation. Multiple rotations may be
+// scheduled, they are applied in the order in which they were scheduled, so
+// a rotation is never applied before the preceding ones.
+func (k *RotatingKey) ScheduleRotation(r Rotation) error {
+	if r.Key == nil {
+		return errors.New("rotating key: key is required")
+	}
+	if r.Time.IsZero() && r.Block == 0 {
+		return errors.New("rotating key: rotation time or block is required")
+	}
+	if r.Block > 0 && k.opts.BlockNumber == nil {
+		return errors.New("rotating key: block number function is required to schedule rotation at block")
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.pending = append(k.pending, r)
+	return nil
+}
+
+// PendingRotations returns the scheduled rotations that have not been
+// applied yet.
+func (k *RotatingKey) PendingRotations() []Rotation {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return append([]Rotation(nil), k.pending...)
+}
+
+// SignMessage implements the Key interface.
+func (k *RotatingKey) SignMessage(ctx context.Context, data []byte) (*types.Signature, error) {
+	key, err := k.currentKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return key.SignMessage(ctx, data)
+}
+
+// SignTransaction implements the Key interface.
+//
+// If the transaction has the From field set to the address of a previous
+// key, an error is returned.
+func (k *RotatingKey) SignTransaction(ctx context.Context, tx *types.Transaction) error {
+	key, err := k.currentKey(ctx)
+	if err != nil {
+		return err
+	}
+	if tx.From != nil && *tx.From != key.Address() && k.HasAddress(*tx.From) {
+		return fmt.Errorf("rotating key: key %s has been rotated out", tx.From)
+	}
+	return key.SignTransaction(ctx, tx)
+}
+
+// VerifyMessage implements the Key interface. It returns true if the data is
+// signed by the current or one of the previous keys.
+func (k *RotatingKey) VerifyMessage(ctx context.Context, data []byte, sig types.Signature) bool {
+	k.mu.Lock()
+	keys := append([]Key{k.current}, k.previous...)
+	k.mu.Unlock()
+	for _, key := range keys {
+		if key.VerifyMessage(ctx, data, sig) {
+			return true
+		}
+	}
+	return false
+}
+
+// Destroy implements the Key interface. It destroys the current key, all
+// previous keys and the keys of pending rotations.
+func (k *RotatingKey) Destroy() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.current.Destroy()
+	for _, key := range k.previous {
+		key.Destroy()
+	}
+	for _, r := range k.pending {
+		r.Key.Destroy()
+	}
+}
+
+// currentKey applies due rotations and returns the current key.
+//
+// The block number is fetched without holding the lock, so that a slow
+// node does not block other methods of the key.
+func (k *RotatingKey) currentKey(ctx context.Context) (Key, error) {
+	var (
+		block    uint64
+		hasBlock bool
+	)
+	if k.needsBlock() {
+		var err error
+		if block, err = k.opts.BlockNumber(ctx); err != nil {
+			return nil, fmt.Errorf("rotating key: failed to get block number: %w", err)
+		}
+		hasBlock = true
+	}
+	k.mu.Lock()
+	var rotated [][2]types.Address
+	for len(k.pending) > 0 {
+		r := k.pending[0]
+		if !r.Time.IsZero() && k.now().Before(r.Time) {
+			break
+		}
+		if r.Block > 0 && (!hasBlock || block < r.Block) {
+			// If the rotation was scheduled after the block number was
+			// checked, it is applied on the next call.
+			break
+		}
+		k.pending = k.pending[1:]
+		rotated = append(rotated, [2]types.Address{k.rotate(r.Key), r.Key.Address()})
+	}
+	key := k.current
+	k.mu.Unlock()
+	if k.opts.OnRotate != nil {
+		for _, r := range rotated {
+			k.opts.OnRotate(r[0], r[1])
+		}
+	}
+	return key, nil
+}
+
+// needsBlock returns true if the block number is needed to decide whether
+// the next due rotation is applied.
+func (k *RotatingKey) needsBlock() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	for _, r := range k.pending {
+		if !r.Time.IsZero() && k.now().Before(r.Time) {
+			return false
+		}
+		if r.Block > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// rotate replaces the current key and returns the address of the previous
+// one. The caller must hold the lock.
+func (k *RotatingKey) rotate(key Key) types.Address {
+	from := k.current.Address()
+	k.previous = append(k.previous, k.current)
+	k.current = key
+	return from
+}
@@ -0,0 +1,110 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/types"
+)
+
+func TestRotatingKey(t *testing.T) {
+	key1 := NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	key2 := NewKeyFromBytes(bytes.Repeat([]byte{0x02}, 32))
+	key3 := NewKeyFromBytes(bytes.Repeat([]byte{0x03}, 32))
+
+	now := time.Unix(1000, 0)
+	block := uint64(10)
+	var rotations [][2]types.Address
+	key, err := NewRotatingKey(RotatingKeyOptions{
+		Key: key1,
+		BlockNumber: func(context.Context) (uint64, error) {
+			return block, nil
+		},
+		OnRotate: func(from, to types.Address) {
+			rotations = append(rotations, [2]types.Address{from, to})
+		},
+	})
+	require.NoError(t, err)
+	key.now = func() time.Time { return now }
+
+	require.NoError(t, key.ScheduleRotation(Rotation{Key: key2, Time: now.Add(time.Hour)}))
+	require.NoError(t, key.ScheduleRotation(Rotation{Key: key3, Block: 20}))
+	require.Error(t, key.ScheduleRotation(Rotation{Key: key2}))
+
+	// No rotation is due yet.
+	sig1, err := key.SignMessage(context.Background(), []byte("foo"))
+	require.NoError(t, err)
+	assert.True(t, key1.VerifyMessage(context.Background(), []byte("foo"), *sig1))
+
+	// Time-based rotation is due, the block-based one is not.
+	now = now.Add(2 * time.Hour)
+	sig2, err := key.SignMessage(context.Background(), []byte("foo"))
+	require.NoError(t, err)
+	assert.True(t, key2.VerifyMessage(context.Background(), []byte("foo"), *sig2))
+	assert.Len(t, key.PendingRotations(), 1)
+
+	// Block-based rotation is due.
+	block = 20
+	tx := (&types.Transaction{}).
+		SetTo(types.MustAddressFromHex("0x2222222222222222222222222222222222222222")).
+		SetChainID(1).
+		SetNonce(0).
+		SetGasLimit(21000).
+		SetGasPrice(big.NewInt(1))
+	require.NoError(t, key.SignTransaction(context.Background(), tx))
+	assert.Equal(t, key3.Address(), *tx.From)
+	assert.Empty(t, key.PendingRotations())
+
+	// Signing transactions from previous keys is not allowed.
+	tx = tx.Copy().SetFrom(key1.Address())
+	require.Error(t, key.SignTransaction(context.Background(), tx))
+
+	// Signatures of previous keys are still valid.
+	assert.True(t, key.VerifyMessage(context.Background(), []byte("foo"), *sig1))
+	assert.True(t, key.VerifyMessage(context.Background(), []byte("foo"), *sig2))
+
+	assert.Equal(t, key3.Address(), key.Address())
+	assert.Equal(t, []types.Address{key3.Address(), key2.Address(), key1.Address()}, key.Addresses())
+	assert.Equal(t, [][2]types.Address{
+		{key1.Address(), key2.Address()},
+		{key2.Address(), key3.Address()},
+	}, rotations)
+}
+
+func TestRotatingKey_SlowBlockNumber(t *testing.T) {
+	key1 := NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+	key2 := NewKeyFromBytes(bytes.Repeat([]byte{0x02}, 32))
+	called := make(chan struct{})
+	release := make(chan struct{})
+	key, err := NewRotatingKey(RotatingKeyOptions{
+		Key: key1,
+		BlockNumber: func(context.Context) (uint64, error) {
+			close(called)
+			<-release
+			return 20, nil
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, key.ScheduleRotation(Rotation{Key: key2, Block: 20}))
+
+	done := make(chan error)
+	go func() {
+		_, err := key.SignMessage(context.Background(), []byte("foo"))
+		done <- err
+	}()
+	<-called
+
+	// Other methods are not blocked while the block number is fetched.
+	assert.Equal(t, key1.Address(), key.Address())
+	assert.Equal(t, []types.Address{key1.Address()}, key.Addresses())
+
+	close(release)
+	require.NoError(t, <-done)
+	assert.Equal(t, key2.Address(), key.Address())
+}
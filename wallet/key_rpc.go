@@ -50,6 +50,10 @@ func (k *KeyRPC) SignTransaction(ctx context.Context, tx *types.Transaction) err
 	return err
 }
 
+// Destroy implements the Key interface. KeyRPC does not hold any key
+// material, so it does nothing.
+func (k *KeyRPC) Destroy() {}
+
 // VerifyMessage implements the Key interface.
 func (k *KeyRPC) VerifyMessage(_ context.Context, data []byte, sig types.Signature) bool {
 	addr, err := k.recover.RecoverMessage(data, sig)
@@ -0,0 +1,163 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/types"
+)
+
+// ErrScopeViolation is returned by ScopedKey when a payload is not allowed
+// by the key policy.
+var ErrScopeViolation = errors.New("scoped key: payload not allowed by policy")
+
+// ScopedKey is a Key wrapper that signs only transactions matching a policy.
+// It can be used to hand a limited signing capability to a subsystem
+// without exposing the full power of the underlying key.
+//
+// ScopedKey intentionally does not implement KeyWithHashSigner, because a
+// raw hash cannot be checked against the policy.
+type ScopedKey struct {
+	key    Key
+	policy ScopedKeyPolicy
+	now    func() time.Time
+}
+
+// ScopedKeyPolicy defines what a ScopedKey is allowed to sign.
+type ScopedKeyPolicy struct {
+	// Targets is the list of contracts the transactions can be sent to.
+	// If empty, transactions to any address are allowed. Contract creation
+	// transactions are never allowed.
+	Targets []ScopedKeyTarget
+
+	// Expiry is the time after which the key refuses to sign anything. If
+	// zero, the key does not expire.
+	Expiry time.Time
+
+	// MaxValue is the maximum value of a single transaction. If nil,
+	// transactions cannot transfer any value.
+	MaxValue *big.Int
+
+	// MaxFee is the maximum fee of a single transaction, calculated as the
+	// gas limit multiplied by the maximum fee per gas, or by the gas price.
+	// If nil, fees are not limited. If set, transactions without the gas
+	// limit or the fee fields are not allowed, because their fee cannot be
+	// checked.
+	MaxFee *big.Int
+
+	// AllowMessages allows signing EIP-191 messages. Messages are not
+	// checked against the targets.
+	AllowMessages bool
+}
+
+// ScopedKeyTarget is a contract that a ScopedKey can send transactions to.
+type ScopedKeyTarget struct {
+	// Address is the contract address.
+	Address types.Address
+
+	// Selectors is the list of allowed method selectors. If empty, any call
+	// data is allowed, including plain value transfers.
+	Selectors []abi.FourBytes
+}
+
+// NewScopedKey returns a new ScopedKey that wraps the given key.
+func NewScopedKey(key Key, policy ScopedKeyPolicy) *ScopedKey {
+	return &ScopedKey{key: key, policy: policy, now: time.Now}
+}
+
+// Policy returns the key policy.
+func (k *ScopedKey) Policy() ScopedKeyPolicy {
+	return k.policy
+}
+
+// Address implements the Key interface.
+func (k *ScopedKey) Address() types.Address {
+	return k.key.Address()
+}
+
+// SignMessage implements the Key interface.
+func (k *ScopedKey) SignMessage(ctx context.Context, data []byte) (*types.Signature, error) {
+	if err := k.checkExpiry(); err != nil {
+		return nil, err
+	}
+	if !k.policy.AllowMessages {
+		return nil, fmt.Errorf("%w: message signing is disabled", ErrScopeViolation)
+	}
+	return k.key.SignMessage(ctx, data)
+}
+
+// SignTransaction implements the Key interface.
+func (k *ScopedKey) SignTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := k.CheckTransaction(tx); err != nil {
+		return err
+	}
+	return k.key.SignTransaction(ctx, tx)
+}
+
+// VerifyMessage implements the Key interface.
+func (k *ScopedKey) VerifyMessage(ctx context.Context, data []byte, sig types.Signature) bool {
+	return k.key.VerifyMessage(ctx, data, sig)
+}
+
+// Destroy implements the Key interface. It destroys the underlying key.
+func (k *ScopedKey) Destroy() {
+	k.key.Destroy()
+}
+
+// CheckTransaction returns an error wrapping ErrScopeViolation if the
+// transaction is not allowed by the policy.
+func (k *ScopedKey) CheckTransaction(tx *types.Transaction) error {
+	if err := k.checkExpiry(); err != nil {
+		return err
+	}
+	if tx.To == nil {
+		return fmt.Errorf("%w: contract creation is not allowed", ErrScopeViolation)
+	}
+	if tx.Value != nil && tx.Value.Sign() > 0 {
+		if k.policy.MaxValue == nil || tx.Value.Cmp(k.policy.MaxValue) > 0 {
+			return fmt.Errorf("%w: value %s exceeds the limit", ErrScopeViolation, tx.Value)
+		}
+	}
+	if k.policy.MaxFee != nil {
+		feeCap := tx.FeeCap()
+		if tx.GasLimit == nil || feeCap == nil {
+			return fmt.Errorf("%w: gas limit and fee must be set", ErrScopeViolation)
+		}
+		fee := new(big.Int).Mul(feeCap, new(big.Int).SetUint64(*tx.GasLimit))
+		if fee.Cmp(k.policy.MaxFee) > 0 {
+			return fmt.Errorf("%w: fee %s exceeds the limit", ErrScopeViolation, fee)
+		}
+	}
+	if len(tx.AuthorizationList) > 0 {
+		return fmt.Errorf("%w: set code transactions are not allowed", ErrScopeViolation)
+	}
+	if len(k.policy.Targets) == 0 {
+		return nil
+	}
+	for _, t := range k.policy.Targets {
+		if t.Address != *tx.To {
+			continue
+		}
+		if len(t.Selectors) == 0 {
+			return nil
+		}
+		for _, s := range t.Selectors {
+			if s.Match(tx.Input) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%w: method not allowed for %s", ErrScopeViolation, tx.To)
+	}
+	return fmt.Errorf("%w: target %s not allowed", ErrScopeViolation, tx.To)
+}
+
+func (k *ScopedKey) checkExpiry() error {
+	if !k.policy.Expiry.IsZero() && !k.now().Before(k.policy.Expiry) {
+		return fmt.Errorf("%w: key expired at %s", ErrScopeViolation, k.policy.Expiry)
+	}
+	return nil
+}
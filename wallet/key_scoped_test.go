@@ -0,0 +1,148 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/abi"
+	"github.com/defiweb/go-eth/types"
+)
+
+func TestScopedKey_SignTransaction(t *testing.T) {
+	token := types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+	other := types.MustAddressFromHex("0x2222222222222222222222222222222222222222")
+	transfer := abi.MustParseMethod("transfer(address,uint256)")
+	approve := abi.MustParseMethod("approve(address,uint256)")
+
+	now := time.Unix(1000, 0)
+	key := NewScopedKey(NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32)), ScopedKeyPolicy{
+		Targets:  []ScopedKeyTarget{{Address: token, Selectors: []abi.FourBytes{transfer.FourBytes()}}},
+		Expiry:   now.Add(time.Hour),
+		MaxValue: big.NewInt(100),
+	})
+	key.now = func() time.Time { return now }
+
+	tests := []struct {
+		name    string
+		tx      *types.Transaction
+		wantErr bool
+	}{
+		{
+			name: "allowed",
+			tx:   (&types.Transaction{}).SetTo(token).SetInput(transfer.MustEncodeArgs(other, big.NewInt(1))),
+		},
+		{
+			name: "value within cap",
+			tx:   (&types.Transaction{}).SetTo(token).SetValue(big.NewInt(100)).SetInput(transfer.MustEncodeArgs(other, big.NewInt(1))),
+		},
+		{
+			name:    "value above cap",
+			tx:      (&types.Transaction{}).SetTo(token).SetValue(big.NewInt(101)).SetInput(transfer.MustEncodeArgs(other, big.NewInt(1))),
+			wantErr: true,
+		},
+		{
+			name:    "selector not allowed",
+			tx:      (&types.Transaction{}).SetTo(token).SetInput(approve.MustEncodeArgs(other, big.NewInt(1))),
+			wantErr: true,
+		},
+		{
+			name:    "target not allowed",
+			tx:      (&types.Transaction{}).SetTo(other).SetInput(transfer.MustEncodeArgs(other, big.NewInt(1))),
+			wantErr: true,
+		},
+		{
+			name:    "contract creation",
+			tx:      (&types.Transaction{}).SetInput([]byte{0x60}),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := tt.tx.SetChainID(1).SetNonce(0).SetGasLimit(21000).SetGasPrice(big.NewInt(1))
+			err := key.SignTransaction(context.Background(), tx)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrScopeViolation)
+				assert.Nil(t, tx.Signature)
+			} else {
+				require.NoError(t, err)
+				assert.NotNil(t, tx.Signature)
+			}
+		})
+	}
+
+	// Fee limit.
+	feeKey := NewScopedKey(NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32)), ScopedKeyPolicy{
+		MaxFee: big.NewInt(21000 * 10),
+	})
+	feeTests := []struct {
+		name    string
+		tx      *types.Transaction
+		wantErr bool
+	}{
+		{
+			name: "gas price within limit",
+			tx:   (&types.Transaction{}).SetGasLimit(21000).SetGasPrice(big.NewInt(10)),
+		},
+		{
+			name:    "gas price above limit",
+			tx:      (&types.Transaction{}).SetGasLimit(21000).SetGasPrice(big.NewInt(11)),
+			wantErr: true,
+		},
+		{
+			name: "max fee per gas within limit",
+			tx:   (&types.Transaction{}).SetType(types.DynamicFeeTxType).SetGasLimit(21000).SetMaxFeePerGas(big.NewInt(10)).SetMaxPriorityFeePerGas(big.NewInt(1)),
+		},
+		{
+			name:    "max fee per gas above limit",
+			tx:      (&types.Transaction{}).SetType(types.DynamicFeeTxType).SetGasLimit(21000).SetMaxFeePerGas(big.NewInt(11)).SetMaxPriorityFeePerGas(big.NewInt(1)),
+			wantErr: true,
+		},
+		{
+			name:    "gas limit above limit",
+			tx:      (&types.Transaction{}).SetGasLimit(21001).SetGasPrice(big.NewInt(10)),
+			wantErr: true,
+		},
+		{
+			name:    "missing gas limit",
+			tx:      (&types.Transaction{}).SetGasPrice(big.NewInt(10)),
+			wantErr: true,
+		},
+		{
+			name:    "missing fee",
+			tx:      (&types.Transaction{}).SetGasLimit(21000),
+			wantErr: true,
+		},
+	}
+	for _, tt := range feeTests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := feeKey.CheckTransaction(tt.tx.SetTo(token))
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrScopeViolation)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// Expired key.
+	key.now = func() time.Time { return now.Add(2 * time.Hour) }
+	tx := (&types.Transaction{}).SetTo(token).SetInput(transfer.MustEncodeArgs(other, big.NewInt(1))).SetChainID(1)
+	assert.ErrorIs(t, key.SignTransaction(context.Background(), tx), ErrScopeViolation)
+}
+
+func TestScopedKey_SignMessage(t *testing.T) {
+	priv := NewKeyFromBytes(bytes.Repeat([]byte{0x01}, 32))
+
+	_, err := NewScopedKey(priv, ScopedKeyPolicy{}).SignMessage(context.Background(), []byte("foo"))
+	assert.ErrorIs(t, err, ErrScopeViolation)
+
+	sig, err := NewScopedKey(priv, ScopedKeyPolicy{AllowMessages: true}).SignMessage(context.Background(), []byte("foo"))
+	require.NoError(t, err)
+	assert.True(t, priv.VerifyMessage(context.Background(), []byte("foo"), *sig))
+}
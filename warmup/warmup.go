@@ -0,0 +1,230 @@
+// Package warmup provides an in-memory cache of rarely changing chain state,
+// such as the chain ID, contract code and configuration storage slots, that
+// can be pre-fetched at startup.
+//
+// Latency-sensitive services often read the same state on every request.
+// Warming the cache before serving requests avoids a burst of RPC calls on
+// the first requests and keeps the latency of the first requests in line
+// with the following ones.
+package warmup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/types"
+)
+
+// DefaultConcurrency is the default number of concurrent requests made by
+// Cache.Warmup.
+const DefaultConcurrency = 8
+
+// Contract is a contract whose state is cached.
+type Contract struct {
+	Address types.Address // Address is the address of the contract.
+	Slots   []types.Hash  // Slots is the list of storage slots to cache.
+}
+
+// CacheOptions is the options for NewCache.
+type CacheOptions struct {
+	// Client is the RPC client used to fetch the state.
+	Client rpc.RPC
+
+	// Contracts is the list of contracts whose code and storage slots are
+	// cached.
+	Contracts []Contract
+
+	// StorageTTL is the time after which cached storage slots are fetched
+	// again. If zero, storage slots are cached until Warmup is called again.
+	StorageTTL time.Duration
+
+	// Concurrency is the number of concurrent requests made by Warmup. If
+	// zero, DefaultConcurrency is used.
+	Concurrency int
+}
+
+// Cache is an rpc.RPC that serves the chain ID, and the code and selected
+// storage slots of the configured contracts from memory. Other calls are
+// passed to the underlying client.
+//
+// Only requests for the latest block are served from the cache. Values
+// missing from the cache are fetched on first use.
+type Cache struct {
+	rpc.RPC
+
+	opts      CacheOptions
+	contracts map[types.Address]map[types.Hash]struct{}
+
+	mu      sync.RWMutex
+	chainID *uint64
+	code    map[types.Address][]byte
+	storage map[slotKey]storageEntry
+}
+
+type slotKey struct {
+	address types.Address
+	slot    types.Hash
+}
+
+type storageEntry struct {
+	value     types.Hash
+	fetchedAt time.Time
+}
+
+// NewCache returns a new Cache. The cache is empty until Warmup is called
+// or the values are requested.
+func NewCache(opts CacheOptions) (*Cache, error) {
+	if opts.Client == nil {
+		return nil, errors.New("warmup: client is required")
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+	c := &Cache{
+		RPC:       opts.Client,
+		opts:      opts,
+		contracts: make(map[types.Address]map[types.Hash]struct{}, len(opts.Contracts)),
+		code:      make(map[types.Address][]byte),
+		storage:   make(map[slotKey]storageEntry),
+	}
+	for _, contract := range opts.Contracts {
+		slots := c.contracts[contract.Address]
+		if slots == nil {
+			slots = make(map[types.Hash]struct{}, len(contract.Slots))
+			c.contracts[contract.Address] = slots
+		}
+		for _, slot := range contract.Slots {
+			slots[slot] = struct{}{}
+		}
+	}
+	return c, nil
+}
+
+// Warmup fetches the chain ID, and the code and storage slots of all
+// configured contracts. Previously cached values are replaced.
+//
+// All values are fetched even if some requests fail. The first error is
+// returned.
+func (c *Cache) Warmup(ctx context.Context) error {
+	var (
+		wg       sync.WaitGroup
+		sem      = make(chan struct{}, c.opts.Concurrency)
+		errMu    sync.Mutex
+		firstErr error
+	)
+	run := func(fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			if err := fn(); err != nil {
+				errMu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				errMu.Unlock()
+			}
+		}()
+	}
+	run(func() error {
+		_, err := c.fetchChainID(ctx)
+		return err
+	})
+	for addr, slots := range c.contracts {
+		addr := addr
+		run(func() error {
+			_, err := c.fetchCode(ctx, addr)
+			return err
+		})
+		for slot := range slots {
+			slot := slot
+			run(func() error {
+				_, err := c.fetchStorage(ctx, addr, slot)
+				return err
+			})
+		}
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// ChainID implements the rpc.RPC interface.
+func (c *Cache) ChainID(ctx context.Context) (uint64, error) {
+	c.mu.RLock()
+	chainID := c.chainID
+	c.mu.RUnlock()
+	if chainID != nil {
+		return *chainID, nil
+	}
+	return c.fetchChainID(ctx)
+}
+
+// GetCode implements the rpc.RPC interface.
+func (c *Cache) GetCode(ctx context.Context, account types.Address, block types.BlockNumber) ([]byte, error) {
+	if _, ok := c.contracts[account]; !ok || !block.IsLatest() {
+		return c.RPC.GetCode(ctx, account, block)
+	}
+	c.mu.RLock()
+	code, ok := c.code[account]
+	c.mu.RUnlock()
+	if ok {
+		return append([]byte(nil), code...), nil
+	}
+	return c.fetchCode(ctx, account)
+}
+
+// GetStorageAt implements the rpc.RPC interface.
+func (c *Cache) GetStorageAt(ctx context.Context, account types.Address, key types.Hash, block types.BlockNumber) (*types.Hash, error) {
+	if _, ok := c.contracts[account][key]; !ok || !block.IsLatest() {
+		return c.RPC.GetStorageAt(ctx, account, key, block)
+	}
+	c.mu.RLock()
+	entry, ok := c.storage[slotKey{address: account, slot: key}]
+	c.mu.RUnlock()
+	if ok && (c.opts.StorageTTL == 0 || time.Since(entry.fetchedAt) < c.opts.StorageTTL) {
+		value := entry.value
+		return &value, nil
+	}
+	return c.fetchStorage(ctx, account, key)
+}
+
+func (c *Cache) fetchChainID(ctx context.Context) (uint64, error) {
+	chainID, err := c.RPC.ChainID(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("warmup: failed to fetch chain ID: %w", err)
+	}
+	c.mu.Lock()
+	c.chainID = &chainID
+	c.mu.Unlock()
+	return chainID, nil
+}
+
+func (c *Cache) fetchCode(ctx context.Context, addr types.Address) ([]byte, error) {
+	code, err := c.RPC.GetCode(ctx, addr, types.LatestBlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("warmup: failed to fetch code of %s: %w", addr, err)
+	}
+	// Do not cache missing code, the contract may be deployed later.
+	if len(code) > 0 {
+		c.mu.Lock()
+		c.code[addr] = append([]byte(nil), code...)
+		c.mu.Unlock()
+	}
+	return code, nil
+}
+
+func (c *Cache) fetchStorage(ctx context.Context, addr types.Address, slot types.Hash) (*types.Hash, error) {
+	value, err := c.RPC.GetStorageAt(ctx, addr, slot, types.LatestBlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("warmup: failed to fetch storage slot %s of %s: %w", slot, addr, err)
+	}
+	c.mu.Lock()
+	c.storage[slotKey{address: addr, slot: slot}] = storageEntry{value: *value, fetchedAt: time.Now()}
+	c.mu.Unlock()
+	return value, nil
+}
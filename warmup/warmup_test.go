@@ -0,0 +1,136 @@
+package warmup
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/types"
+)
+
+type fakeRPC struct {
+	rpc.Client
+
+	mu      sync.Mutex
+	calls   map[string]int
+	storage types.Hash
+	err     error
+}
+
+func (f *fakeRPC) count(method string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.calls == nil {
+		f.calls = make(map[string]int)
+	}
+	f.calls[method]++
+}
+
+func (f *fakeRPC) ChainID(context.Context) (uint64, error) {
+	f.count("chainID")
+	return 1, nil
+}
+
+func (f *fakeRPC) GetCode(_ context.Context, addr types.Address, _ types.BlockNumber) ([]byte, error) {
+	f.count("code")
+	if addr == testEOA {
+		return nil, nil
+	}
+	return []byte{0x60, 0x00}, nil
+}
+
+func (f *fakeRPC) GetStorageAt(context.Context, types.Address, types.Hash, types.BlockNumber) (*types.Hash, error) {
+	f.count("storage")
+	if f.err != nil {
+		return nil, f.err
+	}
+	value := f.storage
+	return &value, nil
+}
+
+var (
+	testContract = types.MustAddressFromHex("0x1111111111111111111111111111111111111111")
+	testEOA      = types.MustAddressFromHex("0x2222222222222222222222222222222222222222")
+	testSlot     = types.MustHashFromHex("0x01", types.PadLeft)
+)
+
+func TestCache_Warmup(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeRPC{storage: types.Hash{1}}
+	c, err := NewCache(CacheOptions{
+		Client: client,
+		Contracts: []Contract{
+			{Address: testContract, Slots: []types.Hash{testSlot}},
+			{Address: testEOA},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.Warmup(ctx))
+	assert.Equal(t, map[string]int{"chainID": 1, "code": 2, "storage": 1}, client.calls)
+
+	// Cached values are served without calling the node.
+	chainID, err := c.ChainID(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), chainID)
+	code, err := c.GetCode(ctx, testContract, types.LatestBlockNumber)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x60, 0x00}, code)
+	value, err := c.GetStorageAt(ctx, testContract, testSlot, types.LatestBlockNumber)
+	require.NoError(t, err)
+	assert.Equal(t, types.Hash{1}, *value)
+	assert.Equal(t, map[string]int{"chainID": 1, "code": 2, "storage": 1}, client.calls)
+
+	// Missing code, other slots and other blocks are not served from the cache.
+	_, err = c.GetCode(ctx, testEOA, types.LatestBlockNumber)
+	require.NoError(t, err)
+	_, err = c.GetStorageAt(ctx, testContract, types.Hash{}, types.LatestBlockNumber)
+	require.NoError(t, err)
+	_, err = c.GetStorageAt(ctx, testContract, testSlot, types.BlockNumberFromUint64(1))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"chainID": 1, "code": 3, "storage": 3}, client.calls)
+
+	// Warmup replaces cached values.
+	client.storage = types.Hash{2}
+	require.NoError(t, c.Warmup(ctx))
+	value, err = c.GetStorageAt(ctx, testContract, testSlot, types.LatestBlockNumber)
+	require.NoError(t, err)
+	assert.Equal(t, types.Hash{2}, *value)
+}
+
+func TestCache_StorageTTL(t *testing.T) {
+	ctx := context.Background()
+	client := &fakeRPC{}
+	c, err := NewCache(CacheOptions{
+		Client:     client,
+		Contracts:  []Contract{{Address: testContract, Slots: []types.Hash{testSlot}}},
+		StorageTTL: time.Millisecond,
+	})
+	require.NoError(t, err)
+	_, err = c.GetStorageAt(ctx, testContract, testSlot, types.LatestBlockNumber)
+	require.NoError(t, err)
+	time.Sleep(2 * time.Millisecond)
+	_, err = c.GetStorageAt(ctx, testContract, testSlot, types.LatestBlockNumber)
+	require.NoError(t, err)
+	assert.Equal(t, 2, client.calls["storage"])
+}
+
+func TestCache_Warmup_Error(t *testing.T) {
+	client := &fakeRPC{err: errors.New("boom")}
+	c, err := NewCache(CacheOptions{
+		Client:    client,
+		Contracts: []Contract{{Address: testContract, Slots: []types.Hash{testSlot}}},
+	})
+	require.NoError(t, err)
+	assert.ErrorContains(t, c.Warmup(context.Background()), "boom")
+
+	// Values fetched successfully are cached.
+	_, err = c.ChainID(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, client.calls["chainID"])
+}
@@ -0,0 +1,342 @@
+// Package webhook delivers chain events, such as new heads and logs, to
+// HTTP endpoints. It lets systems that cannot hold a persistent connection
+// to a node receive events as signed HTTP requests.
+//
+// Every request is a POST with a JSON encoded Event in the body and the
+// following headers:
+//
+//	X-Webhook-ID         unique ID of the event, the same for every attempt
+//	X-Webhook-Timestamp  Unix time at which the request was signed
+//	X-Webhook-Signature  "sha256=" followed by the hex encoded HMAC-SHA256
+//	                     of the timestamp, a dot and the body
+//
+// Receivers should verify requests using Verify.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/types"
+)
+
+// HTTP headers set on every webhook request.
+const (
+	IDHeader        = "X-Webhook-ID"
+	TimestampHeader = "X-Webhook-Timestamp"
+	SignatureHeader = "X-Webhook-Signature"
+)
+
+const (
+	defaultMaxAttempts = 5
+	defaultRetryDelay  = time.Second
+	signaturePrefix    = "sha256="
+)
+
+var (
+	// ErrInvalidSignature is returned by Verify if the signature is missing
+	// or does not match the request.
+	ErrInvalidSignature = errors.New("webhook: invalid signature")
+
+	// ErrExpired is returned by Verify if the request timestamp is outside
+	// the allowed tolerance.
+	ErrExpired = errors.New("webhook: request expired")
+)
+
+// EventType is the type of the delivered event.
+type EventType string
+
+const (
+	HeadEventType EventType = "head" // HeadEventType is a new chain head.
+	LogEventType  EventType = "log"  // LogEventType is a log matching the endpoint query.
+)
+
+// Event is the body of a webhook request.
+type Event struct {
+	ID   string       `json:"id"`
+	Type EventType    `json:"type"`
+	Head *types.Block `json:"head,omitempty"`
+	Log  *types.Log   `json:"log,omitempty"`
+}
+
+// Endpoint is a URL to which events are delivered.
+type Endpoint struct {
+	// URL is the URL to which events are posted.
+	URL string
+
+	// Secret is the key used to sign requests.
+	Secret []byte
+
+	// Heads enables delivery of new chain heads.
+	Heads bool
+
+	// Logs enables delivery of logs matching the query.
+	Logs *types.FilterLogsQuery
+}
+
+// DeliveryError is reported to DispatcherOptions.OnError when an event
+// could not be delivered after all attempts.
+type DeliveryError struct {
+	Endpoint string // Endpoint is the URL of the endpoint.
+	Event    Event  // Event is the event that was not delivered.
+	Attempts int    // Attempts is the number of delivery attempts.
+	Err      error  // Err is the error of the last attempt.
+}
+
+// Error implements the error interface.
+func (e *DeliveryError) Error() string {
+	return fmt.Sprintf("webhook: failed to deliver event %s to %s after %d attempts: %v", e.Event.ID, e.Endpoint, e.Attempts, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// Dispatcher subscribes to chain events and delivers them to endpoints.
+type Dispatcher struct {
+	opts DispatcherOptions
+}
+
+// DispatcherOptions is the options for NewDispatcher.
+type DispatcherOptions struct {
+	// Client is the RPC client used to subscribe to events. The client
+	// transport must support subscriptions.
+	Client rpc.RPC
+
+	// Endpoints are the endpoints to which events are delivered.
+	Endpoints []Endpoint
+
+	// HTTPClient is the HTTP client to use. If nil, http.DefaultClient is
+	// used.
+	HTTPClient *http.Client
+
+	// MaxAttempts is the maximum number of delivery attempts of a single
+	// event. The default is 5.
+	MaxAttempts int
+
+	// RetryDelay is the delay before the first retry, it is doubled after
+	// every failed attempt. The default is one second.
+	RetryDelay time.Duration
+
+	// OnError is called for every event that could not be delivered. If
+	// nil, such events are dropped silently.
+	OnError func(err *DeliveryError)
+}
+
+// NewDispatcher returns a new Dispatcher.
+func NewDispatcher(opts DispatcherOptions) (*Dispatcher, error) {
+	if opts.Client == nil {
+		return nil, errors.New("webhook: client is required")
+	}
+	for _, ep := range opts.Endpoints {
+		if ep.URL == "" {
+			return nil, errors.New("webhook: endpoint URL cannot be empty")
+		}
+		if !ep.Heads && ep.Logs == nil {
+			return nil, fmt.Errorf("webhook: endpoint %s has no events enabled", ep.URL)
+		}
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = defaultMaxAttempts
+	}
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = defaultRetryDelay
+	}
+	return &Dispatcher{opts: opts}, nil
+}
+
+// Run subscribes to events of all endpoints and delivers them until the
+// context is canceled or a subscription is closed.
+//
+// Events are delivered to every endpoint sequentially, in the order in
+// which they were received, so a slow endpoint delays only its own events.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg   sync.WaitGroup
+		once sync.Once
+		err  error
+	)
+	fail := func(e error) {
+		once.Do(func() { err = e })
+		cancel()
+	}
+	for _, ep := range d.opts.Endpoints {
+		ep := ep
+		if ep.Heads {
+			ch, err := d.opts.Client.SubscribeNewHeads(ctx)
+			if err != nil {
+				return fmt.Errorf("webhook: failed to subscribe to new heads: %w", err)
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := forward(ctx, d, ep, ch, headEvent); err != nil {
+					fail(err)
+				}
+			}()
+		}
+		if ep.Logs != nil {
+			ch, err := d.opts.Client.SubscribeLogs(ctx, ep.Logs)
+			if err != nil {
+				return fmt.Errorf("webhook: failed to subscribe to logs: %w", err)
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := forward(ctx, d, ep, ch, logEvent); err != nil {
+					fail(err)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	if err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// Deliver posts the event to the endpoint, retrying failed attempts.
+//
+// Network errors, 429 and 5xx responses are retried, other responses with
+// a non-2xx status are treated as permanent failures.
+func (d *Dispatcher) Deliver(ctx context.Context, ep Endpoint, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("webhook: failed to encode event: %w", err)
+	}
+	delay := d.opts.RetryDelay
+	for attempt := 1; ; attempt++ {
+		retry, err := d.post(ctx, ep, ev.ID, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= d.opts.MaxAttempts {
+			return &DeliveryError{Endpoint: ep.URL, Event: ev, Attempts: attempt, Err: err}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// post sends a single request. It returns true if the request may be
+// retried.
+func (d *Dispatcher) post(ctx context.Context, ep Endpoint, id string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(IDHeader, id)
+	req.Header.Set(TimestampHeader, strconv.FormatInt(ts, 10))
+	req.Header.Set(SignatureHeader, Sign(ep.Secret, ts, body))
+	res, err := d.opts.HTTPClient.Do(req)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return false, nil
+	}
+	retry := res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+	return retry, fmt.Errorf("unexpected status %d", res.StatusCode)
+}
+
+// Sign returns the value of the signature header for the given timestamp
+// and body.
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify verifies the signature of a webhook request. Requests with a
+// timestamp that differs from the current time by more than the tolerance
+// are rejected to prevent replay attacks. If tolerance is 0, the timestamp
+// is not checked.
+func Verify(secret []byte, header http.Header, body []byte, tolerance time.Duration) error {
+	ts, err := strconv.ParseInt(header.Get(TimestampHeader), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	sig := header.Get(SignatureHeader)
+	if !strings.HasPrefix(sig, signaturePrefix) || !hmac.Equal([]byte(sig), []byte(Sign(secret, ts, body))) {
+		return ErrInvalidSignature
+	}
+	if tolerance > 0 {
+		age := time.Since(time.Unix(ts, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrExpired
+		}
+	}
+	return nil
+}
+
+// forward delivers messages from the channel to the endpoint. It returns
+// an error if the channel is closed before the context is canceled.
+func forward[T any](ctx context.Context, d *Dispatcher, ep Endpoint, ch <-chan T, event func(T) Event) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case msg, ok := <-ch:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return errors.New("webhook: subscription closed")
+			}
+			ev := event(msg)
+			if err := d.Deliver(ctx, ep, ev); err != nil {
+				var delErr *DeliveryError
+				if errors.As(err, &delErr) && d.opts.OnError != nil {
+					d.opts.OnError(delErr)
+				}
+			}
+		}
+	}
+}
+
+func headEvent(b types.Block) Event {
+	return Event{ID: b.Hash.String(), Type: HeadEventType, Head: &b}
+}
+
+func logEvent(l types.Log) Event {
+	id := ""
+	if l.BlockHash != nil && l.LogIndex != nil {
+		id = fmt.Sprintf("%s-%d", l.BlockHash, *l.LogIndex)
+	} else if l.TransactionHash != nil {
+		id = l.TransactionHash.String()
+	}
+	if l.Removed {
+		id += "-removed"
+	}
+	return Event{ID: id, Type: LogEventType, Log: &l}
+}
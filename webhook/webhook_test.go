@@ -0,0 +1,131 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/defiweb/go-eth/rpc"
+	"github.com/defiweb/go-eth/types"
+)
+
+var secret = []byte("secret")
+
+type fakeRPC struct {
+	rpc.Client
+
+	heads chan types.Block
+}
+
+func (f *fakeRPC) SubscribeNewHeads(context.Context) (<-chan types.Block, error) {
+	return f.heads, nil
+}
+
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int // Statuses returned for consecutive requests, then 200.
+	events   []Event
+	attempts int
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts++
+	body, _ := io.ReadAll(req.Body)
+	if err := Verify(secret, req.Header, body, time.Minute); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if len(r.statuses) > 0 {
+		status := r.statuses[0]
+		r.statuses = r.statuses[1:]
+		w.WriteHeader(status)
+		return
+	}
+	var ev Event
+	if err := json.Unmarshal(body, &ev); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	r.events = append(r.events, ev)
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	recv := &receiver{statuses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests}}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+
+	d, err := NewDispatcher(DispatcherOptions{Client: &fakeRPC{}, RetryDelay: time.Millisecond})
+	require.NoError(t, err)
+
+	ep := Endpoint{URL: srv.URL, Secret: secret, Heads: true}
+	require.NoError(t, d.Deliver(context.Background(), ep, Event{ID: "1", Type: HeadEventType}))
+	assert.Equal(t, 3, recv.attempts)
+	require.Len(t, recv.events, 1)
+	assert.Equal(t, "1", recv.events[0].ID)
+
+	// Permanent failure is not retried.
+	recv.attempts = 0
+	recv.statuses = []int{http.StatusBadRequest}
+	err = d.Deliver(context.Background(), ep, Event{ID: "2", Type: HeadEventType})
+	var delErr *DeliveryError
+	require.ErrorAs(t, err, &delErr)
+	assert.Equal(t, 1, delErr.Attempts)
+
+	// Invalid secret is rejected by the receiver.
+	recv.attempts = 0
+	err = d.Deliver(context.Background(), Endpoint{URL: srv.URL, Secret: []byte("wrong")}, Event{ID: "3"})
+	require.ErrorAs(t, err, &delErr)
+	assert.Equal(t, 1, recv.attempts)
+}
+
+func TestDispatcher_Run(t *testing.T) {
+	recv := &receiver{}
+	srv := httptest.NewServer(recv)
+	defer srv.Close()
+
+	client := &fakeRPC{heads: make(chan types.Block, 2)}
+	client.heads <- types.Block{Number: big.NewInt(1), Hash: types.MustHashFromHex("0x01", types.PadLeft)}
+	client.heads <- types.Block{Number: big.NewInt(2), Hash: types.MustHashFromHex("0x02", types.PadLeft)}
+	close(client.heads)
+
+	d, err := NewDispatcher(DispatcherOptions{
+		Client:    client,
+		Endpoints: []Endpoint{{URL: srv.URL, Secret: secret, Heads: true}},
+	})
+	require.NoError(t, err)
+
+	// The subscription is closed after two heads.
+	err = d.Run(context.Background())
+	require.Error(t, err)
+	require.Len(t, recv.events, 2)
+	assert.Equal(t, HeadEventType, recv.events[0].Type)
+	assert.Equal(t, types.MustHashFromHex("0x02", types.PadLeft).String(), recv.events[1].ID)
+	assert.Equal(t, uint64(2), recv.events[1].Head.Number.Uint64())
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	header := http.Header{}
+	header.Set(TimestampHeader, "1")
+	header.Set(SignatureHeader, Sign(secret, 1, body))
+	assert.NoError(t, Verify(secret, header, body, 0))
+	assert.ErrorIs(t, Verify(secret, header, body, time.Minute), ErrExpired)
+	assert.ErrorIs(t, Verify(secret, header, []byte(`{"id":"2"}`), 0), ErrInvalidSignature)
+
+	header.Set(TimestampHeader, "2")
+	assert.ErrorIs(t, Verify(secret, header, body, 0), ErrInvalidSignature)
+
+	header.Set(TimestampHeader, "invalid")
+	assert.ErrorIs(t, Verify(secret, header, body, 0), ErrInvalidSignature)
+}